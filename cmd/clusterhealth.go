@@ -0,0 +1,183 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/bootstrap"
+	"github.com/gardener/etcd-wrapper/internal/clusterhealth"
+	"github.com/gardener/etcd-wrapper/internal/secret"
+	"github.com/gardener/etcd-wrapper/internal/types"
+	"github.com/gardener/etcd-wrapper/internal/util"
+
+	"go.uber.org/zap"
+)
+
+// defaultClusterHealthTimeout is the default time after which a request to a single member fails.
+const defaultClusterHealthTimeout = 5 * time.Second
+
+var (
+	// ClusterHealthCmd prints the aggregated health of all members of the etcd cluster.
+	ClusterHealthCmd = Command{
+		Name:      "cluster-health",
+		UsageLine: "etcd-wrapper cluster-health --endpoints=<url>[,<url>...] [--output=table|json]",
+		ShortDesc: "Prints the health of all members of the etcd cluster and fails if quorum is at risk",
+		LongDesc: `Reads the membership of the etcd cluster from the given endpoints and checks every member through its own client
+URLs: its status (leader, raft term and index, DB size, version), whether it serves linearizable reads, and the alarms
+raised in the cluster. It prints a table with one row per member followed by the quorum and fault tolerance of the cluster.
+Learners are shown but do not count towards quorum.
+
+The command exits with code 17 if quorum is lost, if the cluster cannot tolerate the failure of another voting member,
+if no member or different members are seen as leader, or if an alarm is raised, so that it can be used in runbooks and
+scripts. It exits with code 1 if the membership cannot be read at all.
+
+Flags:
+	--endpoints
+		Comma-separated list of client URLs of etcd from which the membership is read, e.g. https://etcd-main-client:2379. Can be repeated. Required.
+	--etcd-ca-cert-path
+		File path of the CA certificate bundle to verify the certificates of the members. Enables TLS if set.
+	--etcd-server-name
+		Name expected in the TLS certificates of etcd. Defaults to the host of the endpoint.
+	--etcd-client-cert-path
+		File path of the client certificate used to authenticate against etcd.
+	--etcd-client-key-path
+		File path of the key of the client certificate.
+	--etcd-client-key-passphrase-from
+		Reference to the passphrase of an encrypted client key, one of: file:<path> (regular file or named pipe), env:<variable>, stdin:<name>.
+	--etcd-client-username
+		Name of the etcd user to authenticate with when auth is enabled in etcd.
+	--etcd-client-password-from
+		Reference to the password of the etcd user, one of: file:<path> (regular file or named pipe), env:<variable>, stdin:<name>.
+	--disable-proxy-env
+		Ignores the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables. By default proxies configured via these variables are used.
+	--timeout
+		Time after which a request to a single member fails. Default: 5s
+	--output
+		Output format, one of table or json. Default: table`,
+		AddFlags: AddClusterHealthFlags,
+		Run:      PrintClusterHealth,
+	}
+	clusterHealthEndpoints      []string
+	clusterHealthCACertPath     string
+	clusterHealthTimeout        time.Duration
+	clusterHealthOutput         string
+	clusterHealthWriter         io.Writer = os.Stdout
+	errClusterHealthNoEndpoints           = errors.New("--endpoints must be specified")
+)
+
+// AddClusterHealthFlags adds flags of the cluster-health command to the passed FlagSet.
+func AddClusterHealthFlags(fs *flag.FlagSet) {
+	clusterHealthEndpoints = nil
+	fs.Var((*stringSliceValue)(&clusterHealthEndpoints), "endpoints", "Comma-separated list of client URLs of etcd from which the membership is read")
+	fs.StringVar(&clusterHealthCACertPath, "etcd-ca-cert-path", "", "File path of the CA certificate bundle to verify the certificates of the members. Enables TLS if set")
+	addEtcdClientFlags(fs)
+	fs.DurationVar(&clusterHealthTimeout, "timeout", defaultClusterHealthTimeout, "Time after which a request to a single member fails")
+	fs.StringVar(&clusterHealthOutput, "output", snapshotStatusOutputTable, "Output format, one of table or json")
+}
+
+// PrintClusterHealth checks the health of all members of the etcd cluster, prints it in the requested output format and
+// returns a clusterhealth.QuorumAtRiskError if quorum is at risk.
+func PrintClusterHealth(ctx context.Context, _ context.CancelFunc, _ *zap.Logger) error {
+	if clusterHealthOutput != snapshotStatusOutputTable && clusterHealthOutput != snapshotStatusOutputJSON {
+		return fmt.Errorf("unsupported output format %q, must be one of %s or %s", clusterHealthOutput, snapshotStatusOutputTable, snapshotStatusOutputJSON)
+	}
+	if len(clusterHealthEndpoints) == 0 {
+		return errClusterHealthNoEndpoints
+	}
+	if err := resolveSecrets(secret.NewResolver(os.Stdin)); err != nil {
+		return err
+	}
+	tlsConfig, err := util.CreateTLSConfig(func() bool { return clusterHealthCACertPath != "" }, config.EtcdClientTLS.ServerName, clusterHealthCACertPath, &util.KeyPair{
+		CertPath:      config.EtcdClientTLS.CertPath,
+		KeyPath:       config.EtcdClientTLS.KeyPath,
+		KeyPassphrase: config.EtcdClientTLS.KeyPassphrase,
+	})
+	if err != nil {
+		return err
+	}
+	if clusterHealthCACertPath == "" {
+		tlsConfig = nil
+	}
+	summary, err := clusterhealth.Check(ctx, clusterhealth.Config{
+		Endpoints:   clusterHealthEndpoints,
+		TLS:         tlsConfig,
+		Username:    config.EtcdClientAuth.Username,
+		Password:    config.EtcdClientAuth.Password,
+		Timeout:     clusterHealthTimeout,
+		DialOptions: util.GRPCProxyDialOptions(config.DisableProxyEnv),
+		LogConfig:   bootstrap.SetupLoggerConfig(types.DefaultLogLevel),
+	})
+	if err != nil {
+		return err
+	}
+	if clusterHealthOutput == snapshotStatusOutputJSON {
+		encoder := json.NewEncoder(clusterHealthWriter)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(summary)
+	} else {
+		err = printClusterHealthTable(summary)
+	}
+	if err != nil {
+		return err
+	}
+	if summary.QuorumAtRisk() {
+		return &clusterhealth.QuorumAtRiskError{Risks: summary.Risks}
+	}
+	return nil
+}
+
+func printClusterHealthTable(summary *clusterhealth.Summary) error {
+	w := tabwriter.NewWriter(clusterHealthWriter, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "ID\tNAME\tENDPOINT\tROLE\tHEALTHY\tLEADER\tVERSION\tDB SIZE\tRAFT TERM\tRAFT INDEX\tERROR")
+	for _, member := range summary.Members {
+		role := "voter"
+		if member.Learner {
+			role = "learner"
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%t\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			member.ID, orDash(member.Name), orDash(member.Endpoint), role, member.Healthy, orDash(member.Leader), orDash(member.Version),
+			formatNonZero(member.DBSize), formatNonZero(member.RaftTerm), formatNonZero(member.RaftIndex), orDash(member.Error))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(clusterHealthWriter, "\nhealthy voting members: %d/%d, quorum: %d, fault tolerance: %d\n",
+		summary.HealthyVoters, summary.Voters, summary.Quorum, max(summary.FaultTolerance, 0))
+	for _, alarm := range summary.Alarms {
+		_, _ = fmt.Fprintf(clusterHealthWriter, "alarm: %s on member %s\n", alarm.Type, alarm.MemberID)
+	}
+	if summary.AlarmsError != "" {
+		_, _ = fmt.Fprintf(clusterHealthWriter, "alarms could not be listed: %s\n", summary.AlarmsError)
+	}
+	for _, risk := range summary.Risks {
+		_, _ = fmt.Fprintf(clusterHealthWriter, "QUORUM AT RISK: %s\n", risk)
+	}
+	return nil
+}
+
+// formatNonZero formats the value, or a dash if it is zero because the member did not report it.
+func formatNonZero[T int64 | uint64](value T) string {
+	if value == 0 {
+		return "-"
+	}
+	return fmt.Sprint(value)
+}
+
+// orDash returns the value, or a dash if it is empty.
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
@@ -0,0 +1,100 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/clusterhealth"
+
+	. "github.com/onsi/gomega"
+	"go.etcd.io/etcd/embed"
+	"go.etcd.io/etcd/etcdserver/etcdserverpb"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestPrintClusterHealth(t *testing.T) {
+	g := NewWithT(t)
+	cfg := embed.NewConfig()
+	cfg.Dir = t.TempDir()
+	cfg.Logger = "zap"
+	cfg.LogOutputs = []string{"/dev/null"}
+	clientURL := url.URL{Scheme: "http", Host: localAddress(g)}
+	peerURL := url.URL{Scheme: "http", Host: localAddress(g)}
+	cfg.ListenClientUrls, cfg.AdvertiseClientUrls = []url.URL{clientURL}, []url.URL{clientURL}
+	cfg.ListenPeerUrls, cfg.AdvertisePeerUrls = []url.URL{peerURL}, []url.URL{peerURL}
+	cfg.InitialCluster = cfg.InitialClusterFromName(cfg.Name)
+	etcd, err := embed.StartEtcd(cfg)
+	g.Expect(err).ToNot(HaveOccurred())
+	defer etcd.Close()
+	select {
+	case <-etcd.Server.ReadyNotify():
+	case <-time.After(time.Minute):
+		t.Fatal("timed out waiting for etcd to be ready")
+	}
+
+	table := []struct {
+		description    string
+		args           []string
+		expectError    bool
+		expectedOutput []string
+	}{
+		{"should print the health of the members as table", []string{"-endpoints", clientURL.String()}, false, []string{"RAFT INDEX", clientURL.String(), "voter", "true", "healthy voting members: 1/1, quorum: 1, fault tolerance: 0"}},
+		{"should print the health of the members as JSON", []string{"-endpoints", clientURL.String(), "-output", "json"}, false, []string{`"healthy": true`, `"quorum": 1`}},
+		{"should return error if no endpoints are given", nil, true, nil},
+		{"should return error for unsupported output format", []string{"-endpoints", clientURL.String(), "-output", "yaml"}, true, nil},
+		{"should return error if etcd is not reachable", []string{"-endpoints", "http://" + localAddress(g), "-timeout", "500ms"}, true, nil},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		fs := flag.NewFlagSet("testutil", flag.ContinueOnError)
+		AddClusterHealthFlags(fs)
+		g.Expect(fs.Parse(entry.args)).To(Succeed())
+		output := &bytes.Buffer{}
+		clusterHealthWriter = output
+
+		err := PrintClusterHealth(context.Background(), nil, zaptest.NewLogger(t))
+		g.Expect(err != nil).To(Equal(entry.expectError))
+		for _, expected := range entry.expectedOutput {
+			g.Expect(output.String()).To(ContainSubstring(expected))
+		}
+	}
+
+	t.Log("should return an error with the quorum at risk exit code if an alarm is raised")
+	_, err = etcd.Server.Alarm(context.Background(), &etcdserverpb.AlarmRequest{
+		Action:   etcdserverpb.AlarmRequest_ACTIVATE,
+		MemberID: uint64(etcd.Server.ID()),
+		Alarm:    etcdserverpb.AlarmType_NOSPACE,
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+	fs := flag.NewFlagSet("testutil", flag.ContinueOnError)
+	AddClusterHealthFlags(fs)
+	g.Expect(fs.Parse([]string{"-endpoints", clientURL.String()})).To(Succeed())
+	output := &bytes.Buffer{}
+	clusterHealthWriter = output
+	err = PrintClusterHealth(context.Background(), nil, zaptest.NewLogger(t))
+	var quorumAtRiskErr *clusterhealth.QuorumAtRiskError
+	g.Expect(errors.As(err, &quorumAtRiskErr)).To(BeTrue())
+	g.Expect(quorumAtRiskErr.ExitCode()).To(Equal(17))
+	g.Expect(output.String()).To(ContainSubstring("QUORUM AT RISK: alarm NOSPACE"))
+}
+
+func localAddress(g *WithT) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		g.Expect(listener.Close()).To(Succeed())
+	}()
+	return listener.Addr().String()
+}
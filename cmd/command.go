@@ -15,6 +15,8 @@ import (
 type Command struct {
 	// Name is the name of the command.
 	Name string
+	// UsageLine is the one-line usage message of the command.
+	UsageLine string
 	// ShortDesc is the short description of the command.
 	ShortDesc string
 	// LongDesc is the text containing the details of the command.
@@ -29,15 +31,30 @@ var (
 	// Commands is a list of possible commands that could be run
 	Commands = []*Command{
 		&EtcdCmd,
+		&PrepareCmd,
+		&RecoverSingleMemberCmd,
+		&MaintenanceHistoryCmd,
+		&SnapshotStatusCmd,
+		&SnapshotSaveCmd,
+		&ClusterHealthCmd,
+		&WaitUntilReadyCmd,
+		&TransferLeadershipCmd,
+		&DiffConfigCmd,
+		&FakeSidecarCmd,
 	}
 )
 
 // IsCommandSupported checks if the command with the passed in commandName is a supported command.
 func IsCommandSupported(commandName string) bool {
+	return GetCommand(commandName) != nil
+}
+
+// GetCommand returns the command with the passed in commandName, or nil if there is no such command.
+func GetCommand(commandName string) *Command {
 	for _, cmd := range Commands {
 		if cmd.Name == commandName {
-			return true
+			return cmd
 		}
 	}
-	return false
+	return nil
 }
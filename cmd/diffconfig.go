@@ -0,0 +1,131 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/gardener/etcd-wrapper/internal/brclient"
+	"github.com/gardener/etcd-wrapper/internal/types"
+
+	"go.uber.org/zap"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	// DiffConfigCmd compares the current etcd configuration with the last known good etcd configuration.
+	DiffConfigCmd = Command{
+		Name:      "diff-config",
+		UsageLine: "etcd-wrapper diff-config [--etcd-config-path=<path>] [--last-known-good-config-path=<path>]",
+		ShortDesc: "Compares the current etcd configuration with the last known good etcd configuration",
+		LongDesc: `Compares the etcd configuration last fetched from backup-restore with the last known good etcd configuration, with
+which etcd last became ready, and prints the fields which differ. Fields of the last known good configuration are prefixed
+with -, fields of the current configuration with +. Nested fields are printed with their full path, e.g.
+client-transport-security.cert-file. The configurations are read from their files, so they can be compared while
+etcd-wrapper is not running, e.g. from an ephemeral container.
+
+Flags:
+	--etcd-config-path
+		File path of the current etcd configuration. Default: etcd.conf.yaml in the home directory of the user
+	--last-known-good-config-path
+		File path of the last known good etcd configuration. Default: /var/etcd/data/last_known_good_etcd_config.yaml`,
+		AddFlags: AddDiffConfigFlags,
+		Run:      DiffConfig,
+	}
+	diffConfigEtcdConfigPath    string
+	diffConfigLastKnownGoodPath string
+	diffConfigWriter            io.Writer = os.Stdout
+)
+
+// AddDiffConfigFlags adds flags of the diff-config command to the passed FlagSet.
+func AddDiffConfigFlags(fs *flag.FlagSet) {
+	etcdConfigPath, _ := brclient.DefaultEtcdConfigFilePath()
+	fs.StringVar(&diffConfigEtcdConfigPath, "etcd-config-path", etcdConfigPath, "File path of the current etcd configuration")
+	fs.StringVar(&diffConfigLastKnownGoodPath, "last-known-good-config-path", types.DefaultLastKnownGoodConfigFilePath, "File path of the last known good etcd configuration")
+}
+
+// DiffConfig prints the fields in which the current and the last known good etcd configuration differ.
+func DiffConfig(_ context.Context, _ context.CancelFunc, _ *zap.Logger) error {
+	current, err := readConfigFields(diffConfigEtcdConfigPath)
+	if err != nil {
+		return fmt.Errorf("failed to read the current etcd configuration: %w", err)
+	}
+	lastKnownGood, err := readConfigFields(diffConfigLastKnownGoodPath)
+	if err != nil {
+		return fmt.Errorf("failed to read the last known good etcd configuration: %w", err)
+	}
+	keys := make([]string, 0, len(current)+len(lastKnownGood))
+	for key := range current {
+		keys = append(keys, key)
+	}
+	for key := range lastKnownGood {
+		if _, ok := current[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+
+	_, _ = fmt.Fprintf(diffConfigWriter, "--- %s\n+++ %s\n", diffConfigLastKnownGoodPath, diffConfigEtcdConfigPath)
+	differences := 0
+	for _, key := range keys {
+		before, inLastKnownGood := lastKnownGood[key]
+		after, inCurrent := current[key]
+		if inLastKnownGood && inCurrent && before == after {
+			continue
+		}
+		differences++
+		if inLastKnownGood {
+			_, _ = fmt.Fprintf(diffConfigWriter, "-%s: %s\n", key, before)
+		}
+		if inCurrent {
+			_, _ = fmt.Fprintf(diffConfigWriter, "+%s: %s\n", key, after)
+		}
+	}
+	if differences == 0 {
+		_, _ = fmt.Fprintln(diffConfigWriter, "no differences")
+	}
+	return nil
+}
+
+// readConfigFields reads the etcd configuration file at path and returns its fields by their dotted path, with their
+// values encoded as JSON.
+func readConfigFields(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path) // #nosec G304 -- path is an etcd configuration passed by the user of the command.
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]any
+	if err = yaml.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	flattened := make(map[string]string)
+	if err = flattenConfigFields("", fields, flattened); err != nil {
+		return nil, err
+	}
+	return flattened, nil
+}
+
+func flattenConfigFields(prefix string, fields map[string]any, flattened map[string]string) error {
+	for key, value := range fields {
+		if nested, ok := value.(map[string]any); ok {
+			if err := flattenConfigFields(prefix+key+".", nested, flattened); err != nil {
+				return err
+			}
+			continue
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		flattened[prefix+key] = string(encoded)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestDiffConfig(t *testing.T) {
+	g := NewWithT(t)
+	testDir := t.TempDir()
+	lastKnownGoodPath := filepath.Join(testDir, "last_known_good_etcd_config.yaml")
+	g.Expect(os.WriteFile(lastKnownGoodPath, []byte("name: etcd-main-0\nquota-backend-bytes: 8589934592\nsnapshot-count: 75000\nclient-transport-security:\n  cert-file: /var/etcd/ssl/old/tls.crt\n"), 0600)).To(Succeed())
+	changedPath := filepath.Join(testDir, "changed.yaml")
+	g.Expect(os.WriteFile(changedPath, []byte("name: etcd-main-0\nquota-backend-bytes: 17179869184\nclient-transport-security:\n  cert-file: /var/etcd/ssl/new/tls.crt\nauto-compaction-mode: periodic\n"), 0600)).To(Succeed())
+
+	table := []struct {
+		description      string
+		etcdConfigPath   string
+		expectError      bool
+		expectedOutput   []string
+		unexpectedOutput []string
+	}{
+		{"should print no differences for identical configurations", lastKnownGoodPath, false, []string{"no differences"}, []string{"-name", "+name"}},
+		{"should print changed, removed and added fields", changedPath, false,
+			[]string{"-quota-backend-bytes: 8589934592\n+quota-backend-bytes: 17179869184", "-snapshot-count: 75000", "+auto-compaction-mode: \"periodic\"", "-client-transport-security.cert-file: \"/var/etcd/ssl/old/tls.crt\"", "+client-transport-security.cert-file: \"/var/etcd/ssl/new/tls.crt\""},
+			[]string{"name: \"etcd-main-0\"", "no differences"}},
+		{"should return error if the current configuration does not exist", filepath.Join(testDir, "missing.yaml"), true, nil, nil},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		fs := flag.NewFlagSet("testutil", flag.ContinueOnError)
+		AddDiffConfigFlags(fs)
+		g.Expect(fs.Parse([]string{"-etcd-config-path", entry.etcdConfigPath, "-last-known-good-config-path", lastKnownGoodPath})).To(Succeed())
+		output := &bytes.Buffer{}
+		diffConfigWriter = output
+
+		err := DiffConfig(context.Background(), nil, zaptest.NewLogger(t))
+		g.Expect(err != nil).To(Equal(entry.expectError))
+		for _, expected := range entry.expectedOutput {
+			g.Expect(output.String()).To(ContainSubstring(expected))
+		}
+		for _, unexpected := range entry.unexpectedOutput {
+			g.Expect(output.String()).ToNot(ContainSubstring(unexpected))
+		}
+	}
+}
@@ -6,12 +6,18 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"flag"
+	"fmt"
+	"os"
+	"strings"
 	"time"
 
+	"github.com/gardener/etcd-wrapper/internal/app"
+	"github.com/gardener/etcd-wrapper/internal/devmode"
+	"github.com/gardener/etcd-wrapper/internal/secret"
 	"github.com/gardener/etcd-wrapper/internal/types"
 
-	"github.com/gardener/etcd-wrapper/internal/app"
 	"go.uber.org/zap"
 )
 
@@ -19,6 +25,7 @@ var (
 	// EtcdCmd initializes and starts an embedded etcd.
 	EtcdCmd = Command{
 		Name:      "start-etcd",
+		UsageLine: "etcd-wrapper start-etcd [flags]",
 		ShortDesc: "Starts the etcd-wrapper application by initializing and starting an embedded etcd",
 		LongDesc: `Initializes the etcd data directory by coordinating with a backup-sidecar container
 and starts an embedded etcd which is by default exposed on port 2379 for client traffic.
@@ -26,45 +33,622 @@ and starts an embedded etcd which is by default exposed on port 2379 for client
 Flags:
 	--etcd-wrapper-port
 		Port used by etcd-wrapper to expose the server. Default: 9095
+	--http-read-timeout
+		Time allowed to read a whole request to the etcd-wrapper server. Default: 10s
+	--http-write-timeout
+		Time allowed to write a response of the etcd-wrapper server, except for streamed events. Default: 30s
+	--http-idle-timeout
+		Time after which idle keep-alive connections to the etcd-wrapper server are closed. Default: 2m
+	--http-shutdown-timeout
+		Time to wait for in-flight requests to the etcd-wrapper server on shutdown before connections are closed. Default: 10s
 	--backup-restore-tls-enabled
 		Enables TLS for communicating with backup-restore if its value is true. It is disabled by default.
 	--backup-restore-host-port
 		Host address and port of the backup restore with which this container will interact during initialization. Should be of the format <host>:<port> and must not include the protocol.
+	--backup-restore-host-port-env
+		Name of an environment variable from which the host and port of backup-restore are taken at start instead of backup-restore-host-port.
+	--backup-restore-host-port-file
+		File path, e.g. projected by the downward API, from which the host and port of backup-restore are taken instead of backup-restore-host-port. Mutually exclusive with backup-restore-host-port-env. The file is read again every backup-restore-host-port-refresh-interval, so that backup-restore can move to another host and port without a restart.
+	--backup-restore-host-port-refresh-interval
+		Interval in which backup-restore-host-port-file is read again. Set to 0 to disable. Default: 30s
 	--backup-restore-ca-cert-bundle-path
 		Path of CA cert bundle (This will be used when TLS is enabled via backup-restore-tls-enabled flag.
+	--backup-restore-ca-reload-interval
+		Interval in which the CA bundle of backup-restore is checked for changes, which are used for new connections to backup-restore without a restart. Set to 0 to disable. Default: 1m0s
+	--backup-restore-server-name
+		Name expected in the TLS certificate of backup-restore, which allows connecting through an address (e.g. a service VIP) that is not among the SANs of the certificate. Defaults to the host of backup-restore-host-port.
+	--disable-proxy-env
+		Ignores the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables for all outbound connections of etcd-wrapper (backup-restore, peers and the embedded etcd). By default proxies configured via these variables are used.
+	--dns-servers
+		Comma-separated list of DNS servers, in the form <host>:<port>, which are queried in turn to resolve the host names of peers and of backup-restore (http protocol) instead of the DNS servers of /etc/resolv.conf, e.g. to bypass a node-local DNS cache. Can be repeated.
+	--dns-lookup-timeout
+		Time after which resolving the host name of a peer or of backup-restore fails, so that a stalling cluster DNS does not stall the bootstrap for the timeouts of the resolver. By default the timeout of the resolver is kept.
+	--dial-timeout
+		Time after which establishing a connection to a peer or to backup-restore fails. By default the timeout of the operating system is kept.
+	--sidecar-protocol
+		Protocol used to communicate with backup-restore, one of: http, grpc. Default: http
     --etcd-client-port
 		Client port when talking to etcd. Default: 2379
     --etcd-client-cert-path
 		Path of TLS certificate of the etcd client (This will be used if client-transport-security is set in the etcd configuration).
 	--etcd-client-key-path
 		Path of TLS key of the etcd client (This will be used if client-transport-security is set in the etcd configuration).
+	--etcd-client-key-passphrase-from
+		Reference to the passphrase of the encrypted TLS key of the etcd client, one of: file:<path> (regular file or named pipe), env:<variable>, stdin:<name>. Secrets read from stdin are given as one <name>=<value> per line.
+	--etcd-client-username
+		Name of the etcd user with which etcd-wrapper authenticates against etcd when auth is enabled.
+	--etcd-client-password-from
+		Reference to the password of the etcd user, one of: file:<path> (regular file or named pipe), env:<variable>, stdin:<name>.
 	--etcd-server-name
 		Name of the server (host) which will be used to configure TLS config to connect to the etcd server process.
 	--etcd-ready-timeout
-		time duration the application will wait for etcd to get ready, by default it waits forever.`,
+		time duration the application will wait for etcd to get ready, by default it waits forever. Exits with code 13 on expiry.
+	--dev
+		Runs a single-member etcd with TLS for client and peer communication using throwaway self-signed certificates, which are generated on every start, and bootstraps it from a built-in fake backup-restore. Flags of backup-restore and of the etcd client TLS are ignored. For development only. It is disabled by default.
+	--dev-dir
+		Directory holding the certificates, etcd configuration and data directory of dev mode. Defaults to a new temporary directory.
+	--ephemeral
+		Runs a single-member etcd without TLS from a new data directory in /dev/shm, which is backed by memory, and bootstraps it from a built-in fake backup-restore. The directory is removed when etcd-wrapper exits. Flags of backup-restore and of the etcd client TLS are ignored. Cannot be combined with --dev. For CI and testing only. It is disabled by default.
+	--fault-injection
+		Enables injecting artificial faults, i.e. latency of backup-restore, dropped peer connections and delayed readiness, via the /debug/faults endpoint, to test orchestration layers against them. For development only. It is disabled by default.
+	--sidecar-probe-timeout
+		time duration the application will wait for backup-restore to respond with an initialization status, by default it waits forever. Exits with code 10 on expiry.
+	--validation-timeout
+		time duration the application will wait for backup-restore to start the triggered data directory validation, by default it waits forever. Exits with code 11 on expiry.
+	--restoration-wait-timeout
+		time duration the application will wait for an initialization in progress (including restoration) to complete, by default it waits forever. Exits with code 12 on expiry.
+	--state-file-path
+		Path of a file, e.g. on a volume shared with other containers of the pod, into which the state of etcd-wrapper (bootstrap phase) and the readiness of etcd are written on every change. The file uses the format of the downward API annotations file. Disabled if not set.
+	--audit-log-path
+		Path of the file into which cluster-mutating operations performed by etcd-wrapper are recorded. Audit logging is disabled if not set.
+	--audit-log-max-size-bytes
+		Size in bytes after which the audit log file is rotated. Default: 10485760
+	--audit-log-max-backups
+		Maximum number of rotated audit log files to retain. Default: 3
+	--experimental-initial-corrupt-check
+		Enables the initial corruption check of etcd which verifies the data of a member against its peers before serving client requests. Default: true
+	--experimental-corrupt-check-time
+		Interval of the periodic corruption check of etcd across members, used only if the etcd configuration does not set one. Default: 1h0m0s
+	--proposal-backpressure-pending-threshold
+		Number of pending raft proposals from which on backpressure is observed. Default: 100
+	--proposal-backpressure-sustained-duration
+		Duration for which raft proposal backpressure must be observed before it is reported. Default: 30s
+	--proposal-backpressure-fail-readiness
+		Fails the readiness probe while sustained raft proposal backpressure is reported. It is disabled by default.
+	--apply-lag-threshold
+		Number of raft entries committed but not yet applied by the local member from which on an apply lag is observed. Set to 0 to disable the detection. Default: 1000
+	--apply-lag-sustained-duration
+		Duration for which the apply lag must be observed before a warning with diagnostics is logged and the metric etcd_wrapper_raft_apply_lag_sustained is set. Default: 30s
+	--apply-lag-fail-readiness
+		Fails the readiness probe while a sustained apply lag is reported. It is disabled by default.
+	--peer-tls-server-name
+		Name expected in the TLS certificates of peers, used by etcd for peer communication and by etcd-wrapper for probing peers. Required if peer URLs (e.g. service VIPs) are not among the SANs of the peer certificates.
+	--external-client-listen-url
+		https URL of an additional gRPC client listener of etcd served by etcd-wrapper with its own TLS settings, e.g. for operator access via the service network while the client URLs of etcd serve in-cluster traffic. Disabled if not set.
+	--external-client-cert-path
+		File path of the server certificate of the external client listener. Required if the external client listener is enabled.
+	--external-client-key-path
+		File path of the key of the server certificate of the external client listener. Required if the external client listener is enabled.
+	--external-client-trusted-ca-path
+		File path of the CA bundle against which client certificates on the external client listener are verified. If set, clients must present a certificate signed by one of the CAs. Client certificates are not required if not set.
+	--client-unix-socket-path
+		Absolute path of a unix socket on which the embedded etcd additionally serves clients without TLS, e.g. sidecars in the same pod sharing a volume. Access is gated by the file mode of the socket. Disabled if not set.
+	--client-unix-socket-mode
+		Octal file mode of the client unix socket. Default: 0660
+	--restart-budget-max-restarts
+		Maximum number of restarts of the embedded etcd within the restart budget window (token bucket). Once exhausted, etcd-wrapper exits with code 14 so that the back-off of the kubelet takes over. Set to 0 to allow unlimited restarts. Default: 5
+	--restart-budget-window
+		Window within which the number of restarts of the embedded etcd is limited. Default: 10m0s
+	--cert-rotation-check-interval
+		Interval in which the peer CA bundle of the etcd configuration is checked for changes. Once it has been rotated, etcd is restarted while holding a cluster-wide lock in etcd, so that members restart one at a time and quorum is retained. Set to 0 to disable. Default: 0
+	--cert-rotation-lock-key
+		Key prefix of the lock in etcd which serializes the restarts of members after a rotation of the peer CA. Default: /_wrapper/cert-rotation-lock
+	--cert-rotation-lock-ttl
+		TTL of the lease to which the restart lock is bound, after which it is released if its holder has died. Must exceed the time needed to restart a member. Default: 5m0s
+	--maintenance-history-path
+		File path of the persisted history of the compactions and defragmentations performed by etcd-wrapper, which is served at /maintenance/history and printed by the maintenance-history command. The history is not persisted if empty. Default: /var/etcd/data/maintenance_history.json
+	--maintenance-history-size
+		Number of most recent compactions and defragmentations retained in the maintenance history. Default: 100
+	--defragmentation-schedule
+		Cron expression (UTC) at which a defragmentation round starts, in which the members of the cluster defragment their etcd backend one at a time, coordinated via a lock in etcd. Followers defragment first, the leader last after transferring its leadership. Confined to the maintenance window if configured. If empty, etcd-wrapper does not defragment.
+	--defragmentation-key-prefix
+		Key prefix in etcd under which the defragmentation lock and the defragmentations of the members are recorded. Default: /_wrapper/defragmentation
+	--defragmentation-lock-ttl
+		TTL of the lease to which the defragmentation lock is bound, after which it is released if its holder has died. Must exceed the time needed to defragment a member. Default: 5m0s
+	--defragmentation-timeout
+		Time for which the leader waits for the followers to defragment in a round before it defragments regardless, e.g. if a follower is down. Default: 1h0m0s
+	--maintenance-leader-lease-name
+		Name of the coordination.k8s.io/v1 Lease via which the etcd-wrappers of the cluster elect a maintenance leader, which orchestrates cluster-wide maintenance, e.g. the defragmentation rounds, on behalf of all members. Only effective when running in a Kubernetes pod whose service account may get, create and update the Lease. If empty, no maintenance leader is elected.
+	--maintenance-leader-lease-namespace
+		Namespace of the maintenance leader Lease. If empty, the namespace of the pod is used.
+	--maintenance-leader-identity
+		Identity with which etcd-wrapper competes for the maintenance leader Lease. If empty, the hostname is used.
+	--maintenance-leader-lease-duration
+		Time after its last renewal after which the maintenance leader Lease is taken over by another etcd-wrapper. Default: 15s
+	--maintenance-leader-renew-interval
+		Interval in which the maintenance leader renews the Lease. Must be less than the lease duration. Default: 5s
+	--maintenance-window-schedule
+		Cron expression (UTC) at which the maintenance window opens. Disruptive operations initiated by etcd-wrapper, e.g. on-demand validations of the data directory, requested outside of the window are queued till it opens. If empty, disruptive operations are never queued.
+	--maintenance-window-duration
+		Duration for which the maintenance window stays open. Default: 1h0m0s
+	--clock-skew-threshold
+		Clock skew to other members above which a warning is logged. Set to 0 to disable clock skew detection. Default: 1s
+	--peer-connectivity-check-interval
+		Interval in which the reachability of the peer URLs of all other members via TCP and TLS is checked and exported as metrics. The check can always be run on demand via /debug/peer-connectivity. Set to 0 to disable the periodic check. Default: 0s
+	--etcd-max-concurrent-streams
+		Maximum number of concurrent gRPC streams per client connection of the embedded etcd, overriding the etcd configuration. Default: 0 (use the etcd configuration)
+	--etcd-max-request-bytes
+		Maximum size of a client request to the embedded etcd, from which the maximum size of a gRPC message received by etcd is derived. Overrides the etcd configuration. Must be at least 1572864 (1.5MiB), the size of objects kube-apiserver stores. Default: 0 (use the etcd configuration)
+	--etcd-max-txn-ops
+		Maximum number of operations in a transaction of the embedded etcd, overriding the etcd configuration. Default: 0 (use the etcd configuration)
+	--request-size-warning-ratio
+		Fraction of the maximum request size and of the maximum number of operations in a transaction of etcd beyond which a warning is logged for an observed write request, e.g. 0.8. Write requests are observed by watching the whole keyspace. Set to 0 to disable. Default: 0
+	--etcd-grpc-keepalive-min-time
+		Minimum interval in which clients may send keepalive pings to the embedded etcd, overriding the etcd configuration. Default: 0 (use the etcd configuration)
+	--etcd-grpc-keepalive-interval
+		Interval in which the embedded etcd pings idle client connections, overriding the etcd configuration. Default: 0 (use the etcd configuration)
+	--etcd-grpc-keepalive-timeout
+		Time the embedded etcd waits for the response to a keepalive ping before closing the connection, overriding the etcd configuration. Default: 0 (use the etcd configuration)
+	--memory-limit-ratio
+		Fraction of the container memory limit (cgroup v1 or v2) which is set as Go memory limit. The in-memory raft log of etcd is also sized to fit into the container memory limit. Set to 0 to disable. Default: 0.9
+	--go-memory-limit
+		Go memory limit in bytes, overriding the limit derived from the container memory limit and the GOMEMLIMIT environment variable.
+	--etcd-snapshot-count
+		Number of committed raft entries after which etcd takes a snapshot and truncates its in-memory raft log, overriding the count derived from the container memory limit and the etcd configuration.
+	--cpu-aware-tuning
+		Derives settings from the container CPU limit, read from cgroup v2 (cpu.max) or cgroup v1 (cpu.cfs_quota_us): GOMAXPROCS is set to the limit rounded up, and below 2 CPUs the snapshot count and the backend batch limit and interval of etcd are scaled down proportionally. Default: true
+	--go-max-procs
+		Maximum number of CPUs executing Go code simultaneously, overriding the value derived from the container CPU limit and the GOMAXPROCS environment variable.
+	--etcd-backend-batch-limit
+		Maximum number of operations etcd batches into one backend transaction, overriding the limit derived from the container CPU limit and the etcd configuration.
+	--etcd-backend-batch-interval
+		Maximum time after which etcd commits a backend transaction, overriding the interval derived from the container CPU limit and the etcd configuration.
+	--compaction-revision-threshold
+		Number of revisions since the last compaction above which etcd-wrapper compacts the etcd history, independent of the auto-compaction of etcd. Set to 0 to disable this trigger. Default: 0
+	--compaction-db-size-growth-percent
+		Growth in percent of the logically used DB size since the last compaction above which etcd-wrapper compacts the etcd history. Set to 0 to disable this trigger. Default: 0
+	--compaction-retained-revisions
+		Number of most recent revisions retained when etcd-wrapper compacts the etcd history. Default: 1000
+	--compaction-check-interval
+		Interval in which etcd-wrapper checks whether the etcd history needs to be compacted. Default: 1m0s
+	--db-size-trend-horizon
+		Projected time until the DB size of etcd reaches its backend quota, at the growth rate over the trend window, below which a warning is logged and the metric etcd_wrapper_db_quota_exhaustion_predicted is set. Set to 0 to disable the tracking. Default: 72h0m0s
+	--db-size-trend-window
+		Sliding time window over which the growth rate of the DB size is computed. A prediction is made once half of the window has been sampled. Default: 6h0m0s
+	--db-size-trend-sample-interval
+		Interval in which the DB size is sampled. Default: 1m0s
+	--quota-risk-threshold
+		Ratio of the DB size of etcd to its backend quota at or above which /quotaz reports writes at risk of being rejected. Default: 0.8
+	--quota-advisory-report-interval
+		Interval in which the quota advisory served by /quotaz is reported to backup-restore. Set to 0 to disable the reports. Default: 0s
+	--health-score-interval
+		Interval in which etcd is probed to compute the health score of the member, which is exposed via /status and the metric etcd_wrapper_health_score. Set to 0 to disable. Default: 10s
+	--health-score-smoothing-factor
+		Weight of the latest probe in the exponentially smoothed health score, between 0 and 1. Higher values make the health score react faster. Default: 0.2
+	--health-score-latency-threshold
+		Probe latency up to which the latency is considered healthy. Default: 100ms
+	--volume-size-record-path
+		Path of the file into which the size of the data volume is recorded at every start, so that a resize of the data volume since the last start is detected and logged. Disabled if set to an empty value. Default: /var/etcd/data/volume_size.json
+	--quota-backend-volume-percentage
+		Percentage of the size of the data volume which is set as backend quota of etcd, overriding quota-backend-bytes of the etcd configuration, so that the backend quota follows resizes of the data volume. By default the backend quota of the etcd configuration is kept.
+	--volume-resize-check-interval
+		Interval in which the size of the data volume is checked while etcd is running. A backend quota derived from the size of the data volume takes effect at the next restart of etcd. Disabled by default.
+	--restart-on-volume-resize
+		Restarts etcd to apply the backend quota derived from a data volume resized while etcd is running. The restart is deferred to the maintenance window and coordinated with the other members like a restart after a rotation of the peer CA. It is disabled by default.
+	--prefix-usage-prefixes
+		Comma-separated list of key prefixes, e.g. of tenants, for which the number of keys is periodically sampled and exported as metric etcd_wrapper_prefix_keys. The flag can be repeated. Sampling is disabled if not set.
+	--prefix-usage-measure-size
+		Additionally measures the total size of the keys and values per prefix, exported as metric etcd_wrapper_prefix_size_bytes. Unlike counting, this reads all keys and values of the prefixes. It is disabled by default.
+	--prefix-usage-interval
+		Interval in which the usage of the key prefixes is sampled. Default: 5m0s
+	--warm-up-max-keys
+		Maximum number of keys read in a warm-up of etcd after it has started and before readiness is reported, which loads the backend pages holding them into the page cache. Set to 0 to disable the warm-up. Default: 0
+	--warm-up-prefixes
+		Comma-separated list of key prefixes which are read in the given order in a warm-up. The flag can be repeated. If not set, the whole keyspace is read.
+	--warm-up-timeout
+		Time after which a warm-up is stopped and readiness is reported regardless. Default: 30s
+	--auth-sync-spec-path
+		Path of a YAML file describing the desired etcd users, roles and permissions, with which etcd is reconciled on start and on change. Reconciliation is disabled if not set.
+	--auth-sync-interval
+		Interval in which the auth spec file is checked for changes. Default: 30s
+	--snapshot-on-shutdown
+		Kind of snapshot, one of: full, delta, which is requested from backup-restore before etcd is stopped on shutdown. No snapshot is requested if not set.
+	--snapshot-on-shutdown-timeout
+		time duration the application will wait for backup-restore to confirm the snapshot requested on shutdown. Default: 20s
+	--hibernation-intent-file
+		Path of a file whose existence on shutdown signals the intent to hibernate, in which case a final full snapshot is taken and the data directory is marked as safe to delete before etcd is stopped. Not checked if not set.
+	--hibernation-endpoint
+		Enables requesting a hibernation via the /hibernate endpoint of the HTTP server. The endpoint is not authenticated, hence any client which can reach the HTTP server can stop the member and mark its data directory as safe to delete. It is disabled by default.
+	--hibernation-timeout
+		time duration the application will wait for the final full snapshot to be taken and confirmed when hibernating. Default: 5m0s
+	--churn-report-interval
+		Interval in which the rate at which the etcd revision grows is reported to backup-restore, which can adapt the period of delta snapshots to bursty workloads. Reporting stops if backup-restore does not support churn reports. Disabled if set to 0. Default: 0s
+	--etcd-config-poll-interval
+		Interval in which backup-restore is polled for changes of the etcd configuration. The configuration is fetched conditionally and only transferred if it has changed, in which case it is stored to take effect on the next start of etcd-wrapper. Disabled if set to 0. Default: 0s
+	--crash-report-dir
+		Directory into which a crash bundle (goroutine dump, most recent log lines, configuration without secrets, status of etcd-wrapper and etcd) is written when etcd-wrapper panics, before it exits. The newest 5 bundles are retained. No crash bundles are written if not set.
+	--crash-report-log-lines
+		Number of most recent log lines included in a crash bundle. Default: 1000
+	--hot-standby
+		Runs the member as a permanent raft learner which is never promoted and serves serializable (possibly stale) reads only. The readiness policy defaults to learner-serving-stale. It is disabled by default.
+	--cluster-id-pin-path
+		Path of the file into which the cluster ID is pinned together with the peer URLs of the other members once etcd has become ready. Before etcd is started, the cluster ID reported by the peers is compared with the pinned one, and etcd-wrapper exits with exit code 16 if they differ for the same peers, which indicates a split brain or a restore from a backup of another cluster. Disabled if set to an empty value. Default: /var/etcd/data/cluster_id_pin.json
+	--initial-cluster-token
+		Token overriding the initial-cluster-token of the etcd configuration. etcd mixes the token into the IDs of the cluster and its members when a new cluster is bootstrapped, which keeps members of different etcd clusters sharing a network from joining each other. All members of a cluster must use the same token.
+	--initial-cluster-token-from
+		Reference to the initial cluster token, one of: file:<path>, env:<variable>, stdin:<name>. Mutually exclusive with --initial-cluster-token.
+	--initial-cluster-token-path
+		Path of the file into which a random token is persisted when a single-member cluster is bootstrapped for the first time while neither --initial-cluster-token nor the etcd configuration sets a token other than the etcd default. The persisted token is used on later bootstraps. Disabled if set to an empty value. Default: /var/etcd/data/initial_cluster_token
+	--member-identity-file-path
+		Path of the env file into which the IDs of the etcd cluster and member (ETCD_CLUSTER_ID, ETCD_MEMBER_ID, ETCD_MEMBER_NAME) are written every time etcd has become ready. Disabled if set to an empty value. Default: /var/etcd/data/member_identity.env
+	--heartbeat-file-path
+		Path of a file into which the current time, the state of etcd-wrapper and the readiness of etcd are written as JSON every heartbeat interval, e.g. for file-based liveness probes or node-level agents. Disabled if not set.
+	--heartbeat-interval
+		Interval in which the heartbeat file is rewritten. Default: 10s
+	--safety-max-backup-age
+		Age of the latest snapshot taken by backup-restore beyond which /safetyz reports the member as unsafe to disrupt, for use by automation honoring PodDisruptionBudgets. /safetyz is disabled if set to 0. Default: 0s
+	--safety-backup-poll-interval
+		Interval in which the latest snapshots are fetched from backup-restore for /safetyz. Default: 30s
+	--disk-latency-probe-interval
+		Interval in which a small write is synced to the data volume and, if --wal-dir is set, to the WAL volume, to expose the 99th percentile of the fsync latency per volume. Disabled if set to 0. Default: 0s
+	--disk-latency-window
+		Number of most recent disk latency probes from which the 99th percentile of the fsync latency is computed. Default: 100
+	--disk-latency-warning-threshold
+		99th percentile of the fsync latency beyond which a warning is logged. Set to 0 to disable the warning. Default: 10ms
+	--request-sampling-fraction
+		Fraction of client requests on the external client listener which is sampled into an in-memory buffer served at /debug/requests. Disabled if 0. Default: 0
+	--request-sampling-buffer-size
+		Number of most recent request samples which are retained. Default: 1000
+	--request-sampling-prefix-depth
+		Number of leading path segments of a key which are hashed into the key prefix of a sample. Default: 2
+	--client-traffic-sample-interval
+		Interval in which the RPC rate per client of the external client listener is computed and served at /debug/clients. Disabled if 0. Default: 0
+	--client-traffic-top-clients
+		Number of clients with the highest RPC rate which are served at /debug/clients. Default: 10
+	--readiness-policy
+		Defines what readiness of etcd means, one of: member-serving, member-has-leader, cluster-has-quorum, learner-serving-stale. Default: cluster-has-quorum, or learner-serving-stale with --hot-standby
+	--readiness-gate
+		Additional condition which must hold for etcd-wrapper to report readiness, in the form <kind>:<target>, one of: exec:<command> (passes if the command, which is run without a shell, exits with status 0), file:<path> (passes if the file exists), http:<url> (passes if a GET request to the URL returns a 2xx status code). Can be repeated.
+	--readiness-gate-interval
+		Interval in which the readiness gates are evaluated. Default: 10s
+	--readiness-gate-timeout
+		Time after which the evaluation of a single readiness gate fails. Default: 5s
+	--pre-start-hook
+		Command run every time before etcd is started, e.g. to fetch keys. The command is split at white space and run without a shell. etcd-wrapper exits with code 19 if it fails. Disabled if not set.
+	--post-ready-hook
+		Command run every time etcd has become ready, e.g. to notify other systems. The command is split at white space and run without a shell. Its failure is only logged. Disabled if not set.
+	--hook-timeout
+		Time after which a pre-start or post-ready hook is killed. Default: 1m0s
+	--etcd-mode
+		How etcd is run, one of: embedded (etcd is embedded into etcd-wrapper), process (the etcd binary at --etcd-binary-path is run as child process with the etcd configuration file). Default: embedded
+	--etcd-binary-path
+		Path of the etcd binary run in process mode. Default: /usr/local/bin/etcd
+	--etcd-stop-grace-period
+		Time the etcd process is given to exit after SIGTERM before it is killed in process mode. Default: 30s
+	--bootstrap-history-path
+		Path of the file into which the most recent start attempts (timestamp, phase reached, outcome) are recorded. The history is not persisted if set to an empty value. Default: /var/etcd/data/bootstrap_history.json
+	--crash-loop-threshold
+		Number of failed start attempts within the crash loop window from which on a crash loop is detected, which escalates to a full validation of the data directory. Set to 0 to disable crash loop detection. Default: 3
+	--crash-loop-window
+		Window within which failed start attempts are counted for crash loop detection. Default: 10m0s
+	--skip-restore-verification
+		Skips verifying the restored etcd DB against the latest snapshot reported by backup-restore before starting etcd.
+	--skip-empty-data-dir-recovery
+		Skips restoring a data directory which has been initialized but contains no WAL. By default its member directory is moved aside and backup-restore is asked to restore it, since etcd would bootstrap an empty cluster on it.
+	--wal-dir
+		Directory into which etcd writes its WAL, e.g. on a separate volume backed by faster storage. Overrides the wal-dir of the etcd configuration. A WAL found in the data directory, e.g. after a restoration, is moved into it before etcd is started.
+	--skip-preflight-checks
+		Skips checking the data and WAL volumes (existence, permissions, fsync latency) before etcd is started.
+	--preflight-fsync-probes
+		Number of writes synced to each volume to probe its fsync latency. Default: 5
+	--preflight-fsync-latency-threshold
+		Fsync latency of the data or WAL volume beyond which etcd is not started. The latency is only reported if set to 0. Default: 0s
+	--skip-tls-validation
+		Skips validating the certificates of the client and peer listeners and of the external client listener (expiry, key usages, SANs of the advertised URLs) before etcd is started.
+	--tls-min-validity
+		Time for which every certificate has to remain valid when etcd is started. Only expired certificates are rejected if set to 0. Default: 0s
+	--cert-expiry-check-interval
+		Interval in which the expiry of the certificates and CA bundles used by etcd and etcd-wrapper is exported as metrics, with escalating warnings logged 30 days, 7 days and 1 day before expiry. Set to 0 to disable. Default: 1h0m0s
+	--sidecar-optional
+		Starts etcd without backup-restore if backup-restore cannot be reached at all within the sidecar optional window and the data directory passes local verification. The etcd configuration last fetched from backup-restore is used. It is disabled by default.
+	--sidecar-optional-window
+		Window within which backup-restore must respond before etcd is started without it in sidecar optional mode. Default: 2m0s
+	--last-known-good-config-path
+		Path of the file into which the etcd configuration is written once etcd has become ready with it, and which can be compared with the current etcd configuration by the diff-config command. Disabled if set to an empty value. Default: /var/etcd/data/last_known_good_etcd_config.yaml
+	--use-last-known-good-config
+		Starts etcd with the last known good etcd configuration if the etcd configuration cannot be fetched from backup-restore. It is disabled by default.
+	--allow-etcd-downgrade
+		Allows starting etcd on a data directory last used by etcd of a newer minor version. Upgrades which skip a minor version are always refused with exit code 15.
+	--skip-client-url-self-test
+		Skips verifying that the advertised client URLs of etcd are reachable (dial, TLS handshake and status RPC) once etcd is ready. If the verification fails, etcd-wrapper does not report ready.
+	--restore-marker-enabled
+		Writes metadata about the restored snapshot into the restore marker key once etcd is ready after a restoration. It is disabled by default.
+	--restore-marker-key
+		Key into which metadata about the restored snapshot is written. Default: /_wrapper/restored-at
+	--revision-watermark-path
+		File path into which the highest etcd revision observed is persisted, against which the revision of a restored data directory is verified. Must be outside the data directory. Disabled if set to empty. Default: /var/etcd/data/revision_watermark.json
+	--revision-watermark-interval
+		Interval in which the revision watermark is updated while etcd is running. It is always updated before etcd is stopped on shutdown. Set to 0 to update it on shutdown only. Default: 1m0s
+	--revision-watermark-max-delta
+		Number of revisions by which a restored data directory may be older than the revision watermark before the restoration is reported as regressed. Default: 0
+	--post-restore-maintenance
+		Compacts the history and defragments the backend of etcd once it is ready after a restoration of the data directory. It is disabled by default.
+	--post-restore-defer-readiness
+		Withholds readiness until the compaction and defragmentation after a restoration have finished, so that clients do not hit a member which is about to undergo heavy maintenance. Requires --post-restore-maintenance.
+	--log-sampling-interval
+		Interval within which repetitions of a warning or error of etcd-wrapper with the same message are suppressed. The first entry is logged, and once the interval has passed the last repetition is logged with the number of suppressed repetitions. Set to 0 to disable. Default: 0s
+	--enrich-etcd-logs
+		Switches etcd to a zap logger writing JSON to its configured log outputs, which adds the fields member, clusterID and phase (the state of etcd-wrapper) to every log entry. It is disabled by default.
+	--wrapper-log-outputs
+		Comma-separated list of outputs of the logs of etcd-wrapper, each of which is stdout, stderr or the path of a file. Entries logged before the configuration has been validated are written to stderr. stderr is used if not set.
+	--wrapper-log-max-size-bytes
+		Size in bytes after which a log file of etcd-wrapper is rotated. Default: 104857600
+	--wrapper-log-max-backups
+		Maximum number of rotated log files of etcd-wrapper to retain. Default: 5
+	--etcd-log-outputs
+		Comma-separated list of outputs of the logs of etcd, each of which is stdout, stderr or the path of a file, replacing the log outputs of the etcd configuration. The log outputs of the etcd configuration are used if not set.
+	--etcd-log-max-size-bytes
+		Size in bytes after which a log file of etcd is rotated. Default: 104857600
+	--etcd-log-max-backups
+		Maximum number of rotated log files of etcd to retain. Default: 5`,
 		AddFlags: AddEtcdFlags,
 		Run:      InitAndStartEtcd,
 	}
-	config = types.Config{}
+	config = types.NewDefaultConfig()
 	// etcdReadyTimeout is the timeout for an embedded etcd server to be ready.
 	etcdReadyTimeout time.Duration
+	// etcdClientKeyPassphraseRef is the secret reference of the passphrase of the etcd client key.
+	etcdClientKeyPassphraseRef string
+	// etcdClientPasswordRef is the secret reference of the password of the etcd user.
+	etcdClientPasswordRef string
+	// initialClusterTokenRef is the reference of the initial cluster token.
+	initialClusterTokenRef string
+	// devMode runs a single-member etcd with throwaway self-signed certificates without backup-restore.
+	devMode bool
+	// devDir is the directory holding the certificates, configuration and data of dev mode.
+	devDir string
+	// ephemeralMode runs a single-member etcd without TLS from a data directory backed by memory without backup-restore.
+	ephemeralMode bool
+	// errDevAndEphemeralMode is returned if both dev and ephemeral mode are requested.
+	errDevAndEphemeralMode = errors.New("--dev and --ephemeral cannot be combined")
 )
 
+// devPeerPort is the port at which etcd listens for peers in dev mode.
+const devPeerPort = 2380
+
+// addEtcdClientFlags adds the flags configuring the client connection to etcd shared by the commands which talk to a
+// running etcd, i.e. cluster-health, snapshot-save and wait-until-ready, to the passed FlagSet.
+func addEtcdClientFlags(fs *flag.FlagSet) {
+	fs.StringVar(&config.EtcdClientTLS.ServerName, "etcd-server-name", "", "Name expected in the TLS certificates of etcd")
+	fs.StringVar(&config.EtcdClientTLS.CertPath, "etcd-client-cert-path", "", "File path of the client certificate used to authenticate against etcd")
+	fs.StringVar(&config.EtcdClientTLS.KeyPath, "etcd-client-key-path", "", "File path of the key of the client certificate")
+	fs.StringVar(&etcdClientKeyPassphraseRef, "etcd-client-key-passphrase-from", "", "Reference to the passphrase of the encrypted client key, one of: file:<path>, env:<variable>, stdin:<name>")
+	fs.StringVar(&config.EtcdClientAuth.Username, "etcd-client-username", "", "Name of the etcd user to authenticate with when auth is enabled")
+	fs.StringVar(&etcdClientPasswordRef, "etcd-client-password-from", "", "Reference to the password of the etcd user, one of: file:<path>, env:<variable>, stdin:<name>")
+	fs.BoolVar(&config.DisableProxyEnv, "disable-proxy-env", false, "Ignores the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables")
+}
+
 // AddEtcdFlags adds flags from the parsed FlagSet into application structs
 func AddEtcdFlags(fs *flag.FlagSet) {
-	fs.IntVar(&config.EtcdWrapperPort, "etcd-wrapper-port", 9095, "Port used by etcd-wrapper to expose the server. Default: 9095")
-	fs.BoolVar(&config.BackupRestore.TLSEnabled, "backup-restore-tls-enabled", types.DefaultBackupRestoreTLSEnabled, "Enables TLS for communicating with backup-restore container")
-	fs.StringVar(&config.BackupRestore.HostPort, "backup-restore-host-port", types.DefaultBackupRestoreHostPort, "Host and Port to be used to connect to the backup-restore container")
-	fs.StringVar(&config.BackupRestore.CaCertBundlePath, "backup-restore-ca-cert-bundle-path", "", "File path of CA cert bundle to help establish TLS communication with backup-restore container")
+	defaults := types.NewDefaultConfig()
+	addBootstrapFlags(fs)
+	fs.IntVar(&config.EtcdWrapperPort, "etcd-wrapper-port", defaults.EtcdWrapperPort, "Port used by etcd-wrapper to expose the server. Default: 9095")
+	fs.DurationVar(&config.HTTPServer.ReadTimeout, "http-read-timeout", defaults.HTTPServer.ReadTimeout, "Time allowed to read a whole request to the etcd-wrapper server")
+	fs.DurationVar(&config.HTTPServer.WriteTimeout, "http-write-timeout", defaults.HTTPServer.WriteTimeout, "Time allowed to write a response of the etcd-wrapper server, except for streamed events")
+	fs.DurationVar(&config.HTTPServer.IdleTimeout, "http-idle-timeout", defaults.HTTPServer.IdleTimeout, "Time after which idle keep-alive connections to the etcd-wrapper server are closed")
+	fs.DurationVar(&config.HTTPServer.ShutdownTimeout, "http-shutdown-timeout", defaults.HTTPServer.ShutdownTimeout, "Time to wait for in-flight requests to the etcd-wrapper server on shutdown before connections are closed")
 	fs.StringVar(&config.EtcdClientTLS.ServerName, "etcd-server-name", "", "Name of the server (host) which will be used to configure TLS config to connect to the etcd server process")
-	fs.IntVar(&config.EtcdClientPort, "etcd-client-port", 2379, "Client port when talking to etcd. Default: 2379")
+	fs.IntVar(&config.EtcdClientPort, "etcd-client-port", defaults.EtcdClientPort, "Client port when talking to etcd. Default: 2379")
 	fs.StringVar(&config.EtcdClientTLS.CertPath, "etcd-client-cert-path", "", "File path of ETCD client certificate to help establish TLS communication of the client to ETCD")
 	fs.StringVar(&config.EtcdClientTLS.KeyPath, "etcd-client-key-path", "", "File path of ETCD client key to help establish TLS communication of the client to ETCD")
+	fs.StringVar(&etcdClientKeyPassphraseRef, "etcd-client-key-passphrase-from", "", "Reference to the passphrase of the encrypted ETCD client key, one of: file:<path>, env:<variable>, stdin:<name>")
+	fs.StringVar(&config.EtcdClientAuth.Username, "etcd-client-username", "", "Name of the ETCD user to authenticate with when auth is enabled")
+	fs.StringVar(&etcdClientPasswordRef, "etcd-client-password-from", "", "Reference to the password of the ETCD user, one of: file:<path>, env:<variable>, stdin:<name>")
 	fs.DurationVar(&etcdReadyTimeout, "etcd-ready-timeout", 0, "Time duration to wait for etcd to be ready")
+	fs.BoolVar(&devMode, "dev", false, "Runs a single-member etcd with throwaway self-signed certificates for client and peer TLS without backup-restore. For development only")
+	fs.StringVar(&devDir, "dev-dir", "", "Directory holding the certificates, configuration and data of dev mode. Defaults to a new temporary directory")
+	fs.BoolVar(&config.FaultInjection, "fault-injection", false, "Enables injecting artificial faults, i.e. latency of backup-restore, dropped peer connections and delayed readiness, via the /debug/faults endpoint. For development only")
+	fs.BoolVar(&ephemeralMode, "ephemeral", false, "Runs a single-member etcd without TLS from a data directory in /dev/shm, which is removed on exit, without backup-restore. For CI and testing only")
+	fs.BoolVar(&config.CorruptCheck.InitialCheck, "experimental-initial-corrupt-check", defaults.CorruptCheck.InitialCheck, "Enables the initial corruption check of etcd which verifies the data of a member against its peers before serving client requests")
+	fs.DurationVar(&config.CorruptCheck.CheckTime, "experimental-corrupt-check-time", defaults.CorruptCheck.CheckTime, "Interval of the periodic corruption check of etcd across members")
+	fs.IntVar(&config.ProposalBackpressure.PendingThreshold, "proposal-backpressure-pending-threshold", defaults.ProposalBackpressure.PendingThreshold, "Number of pending raft proposals from which on backpressure is observed")
+	fs.DurationVar(&config.ProposalBackpressure.SustainedDuration, "proposal-backpressure-sustained-duration", defaults.ProposalBackpressure.SustainedDuration, "Duration for which raft proposal backpressure must be observed before it is reported")
+	fs.BoolVar(&config.ProposalBackpressure.FailReadiness, "proposal-backpressure-fail-readiness", false, "Fails the readiness probe while sustained raft proposal backpressure is reported")
+	fs.Uint64Var(&config.ApplyLag.Threshold, "apply-lag-threshold", defaults.ApplyLag.Threshold, "Number of committed but not yet applied raft entries from which on an apply lag is observed. Set to 0 to disable")
+	fs.DurationVar(&config.ApplyLag.SustainedDuration, "apply-lag-sustained-duration", defaults.ApplyLag.SustainedDuration, "Duration for which the apply lag must be observed before it is reported")
+	fs.BoolVar(&config.ApplyLag.FailReadiness, "apply-lag-fail-readiness", false, "Fails the readiness probe while a sustained apply lag is reported")
+	fs.StringVar(&config.ExternalClientListener.URL, "external-client-listen-url", "", "https URL of an additional gRPC client listener of etcd with its own TLS settings, e.g. for access via the service network. Disabled if empty")
+	fs.StringVar(&config.ExternalClientListener.CertPath, "external-client-cert-path", "", "File path of the server certificate of the external client listener")
+	fs.StringVar(&config.ExternalClientListener.KeyPath, "external-client-key-path", "", "File path of the key of the server certificate of the external client listener")
+	fs.StringVar(&config.ExternalClientListener.TrustedCAPath, "external-client-trusted-ca-path", "", "File path of the CA bundle against which client certificates on the external client listener are verified. Client certificates are not required if empty")
+	fs.StringVar(&config.ClientUnixSocket.Path, "client-unix-socket-path", "", "Absolute path of a unix socket on which the embedded etcd additionally serves clients without TLS. Disabled if empty")
+	fs.StringVar(&config.ClientUnixSocket.Mode, "client-unix-socket-mode", defaults.ClientUnixSocket.Mode, "Octal file mode of the client unix socket, which gates access to it")
+	fs.StringVar(&config.PeerTLSServerName, "peer-tls-server-name", "", "Name expected in the TLS certificates of peers, overriding the host of their peer URLs and the server name of the etcd configuration")
+	fs.IntVar(&config.RestartBudget.MaxRestarts, "restart-budget-max-restarts", defaults.RestartBudget.MaxRestarts, "Maximum number of restarts of the embedded etcd within the restart budget window, after which etcd-wrapper exits. Set to 0 to allow unlimited restarts")
+	fs.DurationVar(&config.RestartBudget.Window, "restart-budget-window", defaults.RestartBudget.Window, "Window within which the number of restarts of the embedded etcd is limited")
+	fs.DurationVar(&config.BackupRestore.HostPortRefreshInterval, "backup-restore-host-port-refresh-interval", defaults.BackupRestore.HostPortRefreshInterval, "Interval in which backup-restore-host-port-file is read again, so that backup-restore can move to another host and port without a restart. Set to 0 to disable")
+	fs.DurationVar(&config.BackupRestore.CAReloadInterval, "backup-restore-ca-reload-interval", defaults.BackupRestore.CAReloadInterval, "Interval in which the CA bundle of backup-restore is checked for changes, which are used for new connections to backup-restore without a restart. Set to 0 to disable")
+	fs.DurationVar(&config.CertRotation.CheckInterval, "cert-rotation-check-interval", 0, "Interval in which the peer CA bundle is checked for changes, which trigger a restart of etcd coordinated across the cluster. Set to 0 to disable")
+	fs.StringVar(&config.CertRotation.LockKey, "cert-rotation-lock-key", defaults.CertRotation.LockKey, "Key prefix of the lock in etcd which serializes the restarts of members after a rotation of the peer CA")
+	fs.DurationVar(&config.CertRotation.LockTTL, "cert-rotation-lock-ttl", defaults.CertRotation.LockTTL, "TTL of the lease to which the restart lock is bound. Must exceed the time needed to restart a member")
+	fs.StringVar(&config.MaintenanceHistory.Path, "maintenance-history-path", defaults.MaintenanceHistory.Path, "File path of the history of the compactions and defragmentations performed by etcd-wrapper. The history is not persisted if empty")
+	fs.IntVar(&config.MaintenanceHistory.Size, "maintenance-history-size", defaults.MaintenanceHistory.Size, "Number of most recent compactions and defragmentations retained in the maintenance history")
+	fs.StringVar(&config.Defragmentation.Schedule, "defragmentation-schedule", "", "Cron expression (UTC) at which the members of the cluster defragment their etcd backend one at a time, the leader last. If empty, etcd-wrapper does not defragment")
+	fs.StringVar(&config.Defragmentation.KeyPrefix, "defragmentation-key-prefix", defaults.Defragmentation.KeyPrefix, "Key prefix in etcd under which the defragmentation lock and the defragmentations of the members are recorded")
+	fs.DurationVar(&config.Defragmentation.LockTTL, "defragmentation-lock-ttl", defaults.Defragmentation.LockTTL, "TTL of the lease to which the defragmentation lock is bound. Must exceed the time needed to defragment a member")
+	fs.DurationVar(&config.Defragmentation.Timeout, "defragmentation-timeout", defaults.Defragmentation.Timeout, "Time for which the leader waits for the followers to defragment in a round before it defragments regardless")
+	fs.StringVar(&config.MaintenanceLeader.LeaseName, "maintenance-leader-lease-name", "", "Name of the Kubernetes Lease via which a maintenance leader orchestrating cluster-wide maintenance is elected. If empty, no maintenance leader is elected")
+	fs.StringVar(&config.MaintenanceLeader.LeaseNamespace, "maintenance-leader-lease-namespace", "", "Namespace of the maintenance leader Lease. If empty, the namespace of the pod is used")
+	fs.StringVar(&config.MaintenanceLeader.Identity, "maintenance-leader-identity", "", "Identity with which etcd-wrapper competes for the maintenance leader Lease. If empty, the hostname is used")
+	fs.DurationVar(&config.MaintenanceLeader.LeaseDuration, "maintenance-leader-lease-duration", defaults.MaintenanceLeader.LeaseDuration, "Time after its last renewal after which the maintenance leader Lease is taken over by another etcd-wrapper")
+	fs.DurationVar(&config.MaintenanceLeader.RenewInterval, "maintenance-leader-renew-interval", defaults.MaintenanceLeader.RenewInterval, "Interval in which the maintenance leader renews the Lease")
+	fs.StringVar(&config.MaintenanceWindow.Schedule, "maintenance-window-schedule", "", "Cron expression (UTC) at which the maintenance window opens. Disruptive operations requested outside of the window are queued till it opens. If empty, disruptive operations are never queued")
+	fs.DurationVar(&config.MaintenanceWindow.Duration, "maintenance-window-duration", defaults.MaintenanceWindow.Duration, "Duration for which the maintenance window stays open")
+	fs.DurationVar(&config.ClockSkewThreshold, "clock-skew-threshold", defaults.ClockSkewThreshold, "Clock skew to other members above which a warning is logged. Set to 0 to disable clock skew detection")
+	fs.DurationVar(&config.PeerConnectivityCheckInterval, "peer-connectivity-check-interval", 0, "Interval in which the reachability of the peer URLs of all other members via TCP and TLS is checked. Set to 0 to disable the periodic check")
+	fs.UintVar(&config.ServerTuning.MaxConcurrentStreams, "etcd-max-concurrent-streams", 0, "Maximum number of concurrent gRPC streams per client connection of the embedded etcd. Set to 0 to use the etcd configuration")
+	fs.UintVar(&config.ServerTuning.MaxRequestBytes, "etcd-max-request-bytes", 0, "Maximum size of a client request to the embedded etcd, from which the maximum gRPC receive message size is derived. Must be at least 1.5MiB as required by kube-apiserver. Set to 0 to use the etcd configuration")
+	fs.UintVar(&config.ServerTuning.MaxTxnOps, "etcd-max-txn-ops", 0, "Maximum number of operations in a transaction of the embedded etcd. Set to 0 to use the etcd configuration")
+	fs.Float64Var(&config.ServerTuning.RequestSizeWarningRatio, "request-size-warning-ratio", 0, "Fraction of the request limits of etcd beyond which a warning is logged for an observed write request. Set to 0 to disable")
+	fs.DurationVar(&config.ServerTuning.GRPCKeepAliveMinTime, "etcd-grpc-keepalive-min-time", 0, "Minimum interval in which clients may send keepalive pings to the embedded etcd. Set to 0 to use the etcd configuration")
+	fs.DurationVar(&config.ServerTuning.GRPCKeepAliveInterval, "etcd-grpc-keepalive-interval", 0, "Interval in which the embedded etcd pings idle client connections. Set to 0 to use the etcd configuration")
+	fs.DurationVar(&config.ServerTuning.GRPCKeepAliveTimeout, "etcd-grpc-keepalive-timeout", 0, "Time the embedded etcd waits for the response to a keepalive ping before closing the connection. Set to 0 to use the etcd configuration")
+	fs.Float64Var(&config.MemoryLimit.Ratio, "memory-limit-ratio", defaults.MemoryLimit.Ratio, "Fraction of the container memory limit which is set as Go memory limit, and into which the in-memory raft log of etcd is sized. Set to 0 to disable")
+	fs.Int64Var(&config.MemoryLimit.GoMemoryLimit, "go-memory-limit", 0, "Go memory limit in bytes, overriding the limit derived from the container memory limit and the GOMEMLIMIT environment variable")
+	fs.Uint64Var(&config.MemoryLimit.SnapshotCount, "etcd-snapshot-count", 0, "Number of committed raft entries after which etcd takes a snapshot, overriding the count derived from the container memory limit and the etcd configuration")
+	fs.BoolVar(&config.CPULimit.Enabled, "cpu-aware-tuning", defaults.CPULimit.Enabled, "Derives GOMAXPROCS, the snapshot count and the backend batching of etcd from the container CPU limit")
+	fs.IntVar(&config.CPULimit.GoMaxProcs, "go-max-procs", 0, "Maximum number of CPUs executing Go code simultaneously, overriding the value derived from the container CPU limit and the GOMAXPROCS environment variable")
+	fs.IntVar(&config.CPULimit.BackendBatchLimit, "etcd-backend-batch-limit", 0, "Maximum number of operations etcd batches into one backend transaction, overriding the limit derived from the container CPU limit and the etcd configuration")
+	fs.DurationVar(&config.CPULimit.BackendBatchInterval, "etcd-backend-batch-interval", 0, "Maximum time after which etcd commits a backend transaction, overriding the interval derived from the container CPU limit and the etcd configuration")
+	fs.Int64Var(&config.Compaction.RevisionThreshold, "compaction-revision-threshold", 0, "Number of revisions since the last compaction above which etcd-wrapper compacts the etcd history. Set to 0 to disable this trigger")
+	fs.IntVar(&config.Compaction.DBSizeGrowthPercent, "compaction-db-size-growth-percent", 0, "Growth in percent of the logically used DB size since the last compaction above which etcd-wrapper compacts the etcd history. Set to 0 to disable this trigger")
+	fs.Int64Var(&config.Compaction.RetainedRevisions, "compaction-retained-revisions", defaults.Compaction.RetainedRevisions, "Number of most recent revisions retained when etcd-wrapper compacts the etcd history")
+	fs.DurationVar(&config.Compaction.CheckInterval, "compaction-check-interval", defaults.Compaction.CheckInterval, "Interval in which etcd-wrapper checks whether the etcd history needs to be compacted")
+	fs.DurationVar(&config.DBSizeTrend.Horizon, "db-size-trend-horizon", defaults.DBSizeTrend.Horizon, "Projected time until the DB size reaches the backend quota below which a warning is raised. Set to 0 to disable")
+	fs.DurationVar(&config.DBSizeTrend.Window, "db-size-trend-window", defaults.DBSizeTrend.Window, "Sliding time window over which the growth rate of the DB size is computed")
+	fs.DurationVar(&config.DBSizeTrend.SampleInterval, "db-size-trend-sample-interval", defaults.DBSizeTrend.SampleInterval, "Interval in which the DB size is sampled")
+	fs.Float64Var(&config.QuotaAdvisory.RiskThreshold, "quota-risk-threshold", defaults.QuotaAdvisory.RiskThreshold, "Ratio of the DB size to the backend quota at or above which writes are reported at risk of being rejected")
+	fs.DurationVar(&config.QuotaAdvisory.ReportInterval, "quota-advisory-report-interval", 0, "Interval in which the quota advisory is reported to backup-restore. Set to 0 to disable")
+	fs.DurationVar(&config.HealthScore.Interval, "health-score-interval", defaults.HealthScore.Interval, "Interval in which etcd is probed to compute the health score of the member. Set to 0 to disable")
+	fs.Float64Var(&config.HealthScore.SmoothingFactor, "health-score-smoothing-factor", defaults.HealthScore.SmoothingFactor, "Weight of the latest probe in the exponentially smoothed health score, between 0 and 1")
+	fs.DurationVar(&config.HealthScore.LatencyThreshold, "health-score-latency-threshold", defaults.HealthScore.LatencyThreshold, "Probe latency up to which the latency is considered healthy")
+	fs.StringVar(&config.VolumeResize.SizeRecordPath, "volume-size-record-path", defaults.VolumeResize.SizeRecordPath, "File path into which the size of the data volume is recorded at every start to detect resizes. Disabled if empty")
+	fs.Float64Var(&config.VolumeResize.QuotaBackendPercentage, "quota-backend-volume-percentage", 0, "Percentage of the size of the data volume which is set as backend quota of etcd, overriding quota-backend-bytes of the etcd configuration. Set to 0 to keep the backend quota of the etcd configuration")
+	fs.DurationVar(&config.VolumeResize.CheckInterval, "volume-resize-check-interval", 0, "Interval in which the size of the data volume is checked while etcd is running. Set to 0 to disable")
+	fs.BoolVar(&config.VolumeResize.RestartOnResize, "restart-on-volume-resize", false, "Restarts etcd in the maintenance window, one member at a time, to apply the backend quota derived from a data volume resized while etcd is running")
+	fs.Var((*stringSliceValue)(&config.PrefixUsage.Prefixes), "prefix-usage-prefixes", "Comma-separated list of key prefixes for which the number of keys is periodically sampled. Sampling is disabled if empty")
+	fs.BoolVar(&config.PrefixUsage.MeasureSize, "prefix-usage-measure-size", false, "Additionally measures the total size of the keys and values per prefix, which requires reading all of them")
+	fs.DurationVar(&config.PrefixUsage.Interval, "prefix-usage-interval", defaults.PrefixUsage.Interval, "Interval in which the usage of the key prefixes is sampled")
+	fs.Int64Var(&config.WarmUp.MaxKeys, "warm-up-max-keys", 0, "Maximum number of keys read to warm up etcd before readiness is reported. Set to 0 to disable the warm-up")
+	fs.Var((*stringSliceValue)(&config.WarmUp.Prefixes), "warm-up-prefixes", "Comma-separated list of key prefixes which are read in the given order to warm up etcd. The whole keyspace is read if empty")
+	fs.DurationVar(&config.WarmUp.Timeout, "warm-up-timeout", defaults.WarmUp.Timeout, "Time after which the warm-up of etcd is stopped and readiness is reported regardless")
+	fs.StringVar(&config.AuthSync.SpecPath, "auth-sync-spec-path", "", "File path of a YAML file describing the desired etcd users, roles and permissions. Reconciliation is disabled if empty")
+	fs.DurationVar(&config.AuthSync.Interval, "auth-sync-interval", defaults.AuthSync.Interval, "Interval in which the auth spec file is checked for changes")
+	fs.StringVar(&config.SnapshotOnShutdown.Kind, "snapshot-on-shutdown", "", "Kind of snapshot, one of: full, delta, which is requested from backup-restore before etcd is stopped on shutdown. No snapshot is requested if empty")
+	fs.DurationVar(&config.SnapshotOnShutdown.Timeout, "snapshot-on-shutdown-timeout", defaults.SnapshotOnShutdown.Timeout, "Time duration to wait for backup-restore to confirm the snapshot requested on shutdown")
+	fs.StringVar(&config.Hibernation.IntentFilePath, "hibernation-intent-file", "", "Path of a file whose existence on shutdown signals the intent to hibernate. Not checked if empty")
+	fs.BoolVar(&config.Hibernation.EndpointEnabled, "hibernation-endpoint", false, "Enables requesting a hibernation via the /hibernate endpoint of the HTTP server, which is not authenticated")
+	fs.DurationVar(&config.Hibernation.Timeout, "hibernation-timeout", defaults.Hibernation.Timeout, "Time duration to wait for the final full snapshot to be taken and confirmed when hibernating")
+	fs.DurationVar(&config.ChurnReportInterval, "churn-report-interval", 0, "Interval in which the rate at which the etcd revision grows is reported to backup-restore to adapt the period of delta snapshots. Set to 0 to disable")
+	fs.DurationVar(&config.EtcdConfigPollInterval, "etcd-config-poll-interval", 0, "Interval in which backup-restore is polled for changes of the etcd configuration. Set to 0 to disable")
+	fs.StringVar(&config.CrashReport.Dir, "crash-report-dir", "", "Directory into which a crash bundle is written when etcd-wrapper panics. No crash bundles are written if empty")
+	fs.IntVar(&config.CrashReport.LogLines, "crash-report-log-lines", defaults.CrashReport.LogLines, "Number of most recent log lines included in a crash bundle")
+	fs.StringVar(&config.ClusterIDPinPath, "cluster-id-pin-path", defaults.ClusterIDPinPath, "File path into which the cluster ID is pinned once etcd is ready, to refuse starting if the peers report another cluster ID. Disabled if empty")
+	fs.StringVar(&config.InitialClusterToken, "initial-cluster-token", "", "Token overriding the initial-cluster-token of the etcd configuration, which keeps members of different etcd clusters sharing a network from joining each other on bootstrap")
+	fs.StringVar(&initialClusterTokenRef, "initial-cluster-token-from", "", "Reference to the initial cluster token, one of: file:<path>, env:<variable>, stdin:<name>. Mutually exclusive with initial-cluster-token")
+	fs.StringVar(&config.InitialClusterTokenPath, "initial-cluster-token-path", defaults.InitialClusterTokenPath, "File path into which a token generated on the first bootstrap of a single-member cluster without a configured token is persisted. Disabled if empty")
+	fs.StringVar(&config.MemberIdentityFilePath, "member-identity-file-path", defaults.MemberIdentityFilePath, "File path of the env file into which the IDs of the etcd cluster and member are written every time etcd has become ready. Disabled if empty")
+	fs.StringVar(&config.Heartbeat.Path, "heartbeat-file-path", "", "File path into which the current time, state and readiness are written every heartbeat interval for external liveness monitors. Disabled if empty")
+	fs.DurationVar(&config.Heartbeat.Interval, "heartbeat-interval", defaults.Heartbeat.Interval, "Interval in which the heartbeat file is rewritten")
+	fs.DurationVar(&config.BackupFreshness.MaxAge, "safety-max-backup-age", 0, "Age of the latest snapshot beyond which /safetyz reports the member as unsafe to disrupt. Disabled if 0")
+	fs.DurationVar(&config.BackupFreshness.PollInterval, "safety-backup-poll-interval", defaults.BackupFreshness.PollInterval, "Interval in which the latest snapshots are fetched from backup-restore for /safetyz")
+	fs.DurationVar(&config.DiskLatency.ProbeInterval, "disk-latency-probe-interval", 0, "Interval in which a small write is synced to the data and WAL volumes to probe their fsync latency. Set to 0 to disable")
+	fs.IntVar(&config.DiskLatency.Window, "disk-latency-window", defaults.DiskLatency.Window, "Number of most recent disk latency probes from which the 99th percentile of the fsync latency is computed")
+	fs.DurationVar(&config.DiskLatency.WarningThreshold, "disk-latency-warning-threshold", defaults.DiskLatency.WarningThreshold, "99th percentile of the fsync latency beyond which a warning is logged. Set to 0 to disable the warning")
+	fs.Float64Var(&config.RequestSampling.Fraction, "request-sampling-fraction", 0, "Fraction of client requests on the external client listener which is sampled for debugging. Disabled if 0")
+	fs.IntVar(&config.RequestSampling.BufferSize, "request-sampling-buffer-size", defaults.RequestSampling.BufferSize, "Number of most recent request samples which are retained")
+	fs.IntVar(&config.RequestSampling.PrefixDepth, "request-sampling-prefix-depth", defaults.RequestSampling.PrefixDepth, "Number of leading path segments of a key which are hashed into the key prefix of a sample")
+	fs.DurationVar(&config.ClientTraffic.SampleInterval, "client-traffic-sample-interval", 0, "Interval in which the RPC rate per client of the external client listener is computed. Disabled if 0")
+	fs.IntVar(&config.ClientTraffic.TopClients, "client-traffic-top-clients", defaults.ClientTraffic.TopClients, "Number of clients with the highest RPC rate which are reported")
+	fs.BoolVar(&config.HotStandby, "hot-standby", false, "Runs the member as a permanent raft learner which serves serializable reads only")
+	fs.StringVar(&config.ReadinessPolicy, "readiness-policy", "", "Defines what readiness of etcd means, one of: member-serving, member-has-leader, cluster-has-quorum, learner-serving-stale. Defaults to cluster-has-quorum, or learner-serving-stale in hot-standby mode")
+	fs.Var((*stringListValue)(&config.ReadinessGates.Gates), "readiness-gate", "Additional condition which must hold for etcd-wrapper to report readiness, in the form <kind>:<target> with kind one of: exec, file, http. Can be repeated")
+	fs.DurationVar(&config.ReadinessGates.Interval, "readiness-gate-interval", defaults.ReadinessGates.Interval, "Interval in which the readiness gates are evaluated")
+	fs.DurationVar(&config.ReadinessGates.Timeout, "readiness-gate-timeout", defaults.ReadinessGates.Timeout, "Time after which the evaluation of a single readiness gate fails")
+	fs.StringVar(&config.Hooks.PreStart, "pre-start-hook", "", "Command run every time before etcd is started. etcd is not started if it fails. Disabled if empty")
+	fs.StringVar(&config.Hooks.PostReady, "post-ready-hook", "", "Command run every time etcd has become ready. Its failure is only logged. Disabled if empty")
+	fs.DurationVar(&config.Hooks.Timeout, "hook-timeout", defaults.Hooks.Timeout, "Time after which a pre-start or post-ready hook is killed")
+	fs.StringVar(&config.EtcdProcess.Mode, "etcd-mode", defaults.EtcdProcess.Mode, "How etcd is run, one of: embedded, process. In process mode the etcd binary at etcd-binary-path is run as child process")
+	fs.StringVar(&config.EtcdProcess.BinaryPath, "etcd-binary-path", defaults.EtcdProcess.BinaryPath, "Path of the etcd binary run in process mode")
+	fs.DurationVar(&config.EtcdProcess.StopGracePeriod, "etcd-stop-grace-period", defaults.EtcdProcess.StopGracePeriod, "Time the etcd process is given to exit after SIGTERM before it is killed in process mode")
+	fs.BoolVar(&config.SkipClientURLSelfTest, "skip-client-url-self-test", false, "Skips verifying that the advertised client URLs of etcd are reachable once etcd is ready")
+	fs.BoolVar(&config.RestoreMarker.Enabled, "restore-marker-enabled", false, "Writes metadata about the restored snapshot into the restore marker key once etcd is ready after a restoration")
+	fs.StringVar(&config.RestoreMarker.Key, "restore-marker-key", defaults.RestoreMarker.Key, "Key into which metadata about the restored snapshot is written")
+	fs.StringVar(&config.RevisionWatermark.Path, "revision-watermark-path", defaults.RevisionWatermark.Path, "File path into which the highest etcd revision observed is persisted, against which the revision of a restored data directory is verified. Disabled if empty")
+	fs.DurationVar(&config.RevisionWatermark.Interval, "revision-watermark-interval", defaults.RevisionWatermark.Interval, "Interval in which the revision watermark is updated while etcd is running. Set to 0 to update it on shutdown only")
+	fs.Int64Var(&config.RevisionWatermark.MaxDelta, "revision-watermark-max-delta", 0, "Number of revisions by which a restored data directory may be older than the revision watermark before the restoration is reported as regressed")
+	fs.BoolVar(&config.PostRestoreMaintenance.Enabled, "post-restore-maintenance", false, "Compacts the history and defragments the backend of etcd once it is ready after a restoration")
+	fs.BoolVar(&config.PostRestoreMaintenance.DeferReadiness, "post-restore-defer-readiness", false, "Withholds readiness until the compaction and defragmentation after a restoration have finished")
+	fs.DurationVar(&config.LogSamplingInterval, "log-sampling-interval", 0, "Interval within which repetitions of a warning or error with the same message are suppressed and then summarized with their number. Set to 0 to disable")
+	fs.BoolVar(&config.EnrichEtcdLogs, "enrich-etcd-logs", false, "Adds the member name, cluster ID and state of etcd-wrapper to every log entry of etcd")
+	fs.Var((*stringSliceValue)(&config.LogSinks.Wrapper.Outputs), "wrapper-log-outputs", "Comma-separated list of outputs of the logs of etcd-wrapper, each of which is stdout, stderr or the path of a file. stderr is used if empty")
+	fs.Int64Var(&config.LogSinks.Wrapper.MaxSizeBytes, "wrapper-log-max-size-bytes", defaults.LogSinks.Wrapper.MaxSizeBytes, "Size in bytes after which a log file of etcd-wrapper is rotated")
+	fs.IntVar(&config.LogSinks.Wrapper.MaxBackups, "wrapper-log-max-backups", defaults.LogSinks.Wrapper.MaxBackups, "Maximum number of rotated log files of etcd-wrapper to retain")
+	fs.Var((*stringSliceValue)(&config.LogSinks.Etcd.Outputs), "etcd-log-outputs", "Comma-separated list of outputs of the logs of etcd, each of which is stdout, stderr or the path of a file. The log outputs of the etcd configuration are used if empty")
+	fs.Int64Var(&config.LogSinks.Etcd.MaxSizeBytes, "etcd-log-max-size-bytes", defaults.LogSinks.Etcd.MaxSizeBytes, "Size in bytes after which a log file of etcd is rotated")
+	fs.IntVar(&config.LogSinks.Etcd.MaxBackups, "etcd-log-max-backups", defaults.LogSinks.Etcd.MaxBackups, "Maximum number of rotated log files of etcd to retain")
+}
+
+// addBootstrapFlags adds the flags required to initialize the etcd data directory in coordination with backup-restore.
+func addBootstrapFlags(fs *flag.FlagSet) {
+	defaults := types.NewDefaultConfig()
+	addBackupRestoreClientFlags(fs)
+	fs.DurationVar(&config.PhaseTimeouts.SidecarProbe, "sidecar-probe-timeout", 0, "Time duration to wait for backup-restore to respond with an initialization status")
+	fs.DurationVar(&config.PhaseTimeouts.Validation, "validation-timeout", 0, "Time duration to wait for backup-restore to start the triggered data directory validation")
+	fs.DurationVar(&config.PhaseTimeouts.RestorationWait, "restoration-wait-timeout", 0, "Time duration to wait for an initialization in progress, including restoration, to complete")
+	fs.StringVar(&config.StateFilePath, "state-file-path", "", "File path into which the state of etcd-wrapper and the readiness of etcd are written on every change, in the format of the downward API annotations file. Disabled if empty")
+	fs.StringVar(&config.AuditLog.Path, "audit-log-path", "", "File path of the audit log recording cluster-mutating operations performed by etcd-wrapper. Audit logging is disabled if empty")
+	fs.Int64Var(&config.AuditLog.MaxSizeBytes, "audit-log-max-size-bytes", defaults.AuditLog.MaxSizeBytes, "Size in bytes after which the audit log file is rotated")
+	fs.IntVar(&config.AuditLog.MaxBackups, "audit-log-max-backups", defaults.AuditLog.MaxBackups, "Maximum number of rotated audit log files to retain")
+	fs.StringVar(&config.BootstrapHistory.Path, "bootstrap-history-path", defaults.BootstrapHistory.Path, "File path of the history of the most recent start attempts. The history is not persisted if empty")
+	fs.IntVar(&config.BootstrapHistory.CrashLoopThreshold, "crash-loop-threshold", defaults.BootstrapHistory.CrashLoopThreshold, "Number of failed start attempts within the crash loop window from which on full validation of the data directory is requested. Set to 0 to disable")
+	fs.DurationVar(&config.BootstrapHistory.CrashLoopWindow, "crash-loop-window", defaults.BootstrapHistory.CrashLoopWindow, "Window within which failed start attempts are counted for crash loop detection")
+	fs.BoolVar(&config.AllowEtcdDowngrade, "allow-etcd-downgrade", false, "Allows starting etcd on a data directory last used by etcd of a newer minor version")
+	fs.StringVar(&config.WALDir, "wal-dir", "", "Directory into which etcd writes its WAL, e.g. on a separate volume. Overrides the wal-dir of the etcd configuration if set")
+	fs.BoolVar(&config.Preflight.Disabled, "skip-preflight-checks", false, "Skips checking the data and WAL volumes before etcd is started")
+	fs.IntVar(&config.Preflight.FsyncProbes, "preflight-fsync-probes", defaults.Preflight.FsyncProbes, "Number of writes synced to each volume to probe its fsync latency")
+	fs.DurationVar(&config.Preflight.FsyncLatencyThreshold, "preflight-fsync-latency-threshold", 0, "Fsync latency of the data or WAL volume beyond which etcd is not started. Set to 0 to only report the latency")
+	fs.BoolVar(&config.TLSValidation.Disabled, "skip-tls-validation", false, "Skips validating the configured certificates before etcd is started")
+	fs.DurationVar(&config.TLSValidation.MinValidity, "tls-min-validity", 0, "Time for which every certificate has to remain valid when etcd is started. Set to 0 to only reject expired certificates")
+	fs.DurationVar(&config.CertExpiryCheckInterval, "cert-expiry-check-interval", defaults.CertExpiryCheckInterval, "Interval in which the expiry of the certificates used by etcd and etcd-wrapper is checked and exported as metrics. Set to 0 to disable")
+	fs.BoolVar(&config.SkipRestoreVerification, "skip-restore-verification", false, "Skips verifying the restored etcd DB against the latest snapshot reported by backup-restore before starting etcd")
+	fs.BoolVar(&config.SkipEmptyDataDirRecovery, "skip-empty-data-dir-recovery", false, "Skips restoring a data directory which has been initialized but contains no WAL, on which etcd would bootstrap an empty cluster")
+	fs.BoolVar(&config.SidecarOptional.Enabled, "sidecar-optional", false, "Starts etcd without backup-restore if it cannot be reached within the sidecar optional window and the data directory passes local verification")
+	fs.DurationVar(&config.SidecarOptional.Window, "sidecar-optional-window", defaults.SidecarOptional.Window, "Window within which backup-restore must respond before etcd is started without it in sidecar optional mode")
+	fs.StringVar(&config.LastKnownGoodConfig.Path, "last-known-good-config-path", defaults.LastKnownGoodConfig.Path, "File path into which the etcd configuration is written once etcd has become ready with it. Disabled if empty")
+	fs.BoolVar(&config.LastKnownGoodConfig.UseOnFetchFailure, "use-last-known-good-config", false, "Starts etcd with the last known good etcd configuration if the etcd configuration cannot be fetched from backup-restore")
+}
+
+// addBackupRestoreClientFlags adds the flags required to connect to backup-restore.
+func addBackupRestoreClientFlags(fs *flag.FlagSet) {
+	defaults := types.NewDefaultConfig()
+	fs.BoolVar(&config.BackupRestore.TLSEnabled, "backup-restore-tls-enabled", defaults.BackupRestore.TLSEnabled, "Enables TLS for communicating with backup-restore container")
+	fs.StringVar(&config.BackupRestore.HostPort, "backup-restore-host-port", defaults.BackupRestore.HostPort, "Host and Port to be used to connect to the backup-restore container")
+	fs.StringVar(&config.BackupRestore.HostPortEnv, "backup-restore-host-port-env", "", "Name of an environment variable from which the host and port of the backup-restore container are taken instead of backup-restore-host-port")
+	fs.StringVar(&config.BackupRestore.HostPortFile, "backup-restore-host-port-file", "", "File path, e.g. projected by the downward API, from which the host and port of the backup-restore container are taken instead of backup-restore-host-port. It is read again on changes")
+	fs.StringVar(&config.BackupRestore.CaCertBundlePath, "backup-restore-ca-cert-bundle-path", "", "File path of CA cert bundle to help establish TLS communication with backup-restore container")
+	fs.StringVar(&config.BackupRestore.ServerName, "backup-restore-server-name", "", "Name expected in the TLS certificate of the backup-restore container. Defaults to the host of backup-restore-host-port")
+	fs.BoolVar(&config.DisableProxyEnv, "disable-proxy-env", false, "Ignores the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables for all outbound connections of etcd-wrapper")
+	fs.Var((*stringSliceValue)(&config.DNS.Servers), "dns-servers", "Comma-separated list of DNS servers, in the form <host>:<port>, which are queried in turn to resolve peers and backup-restore instead of the DNS servers of the system")
+	fs.DurationVar(&config.DNS.LookupTimeout, "dns-lookup-timeout", 0, "Time after which resolving the host name of a peer or of backup-restore fails. Set to 0 to keep the timeout of the resolver")
+	fs.DurationVar(&config.DNS.DialTimeout, "dial-timeout", 0, "Time after which connecting to a peer or to backup-restore fails. Set to 0 to keep the timeout of the operating system")
+	fs.StringVar(&config.BackupRestore.Protocol, "sidecar-protocol", defaults.BackupRestore.Protocol, "Protocol used to communicate with backup-restore container, one of: http, grpc. grpc does not support etcd-config-poll-interval, quota-advisory-report-interval and refreshing backup-restore-host-port-file")
 }
 
 // InitAndStartEtcd sets up and starts an embedded etcd
 func InitAndStartEtcd(ctx context.Context, cancelFn context.CancelFunc, logger *zap.Logger) error {
+	if err := resolveSecrets(secret.NewResolver(os.Stdin)); err != nil {
+		return err
+	}
+	if devMode && ephemeralMode {
+		return errDevAndEphemeralMode
+	}
+	if devMode {
+		if err := setupDevMode(ctx, logger); err != nil {
+			return err
+		}
+	}
+	if ephemeralMode {
+		dir, err := setupEphemeralMode(ctx, logger)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := os.RemoveAll(dir); err != nil {
+				logger.Error("failed to remove directory of ephemeral mode", zap.String("dir", dir), zap.Error(err))
+			}
+		}()
+	}
 	etcdApp, err := app.NewApplication(ctx, cancelFn, config, etcdReadyTimeout, logger)
 	if err != nil {
 		return err
@@ -74,3 +658,102 @@ func InitAndStartEtcd(ctx context.Context, cancelFn context.CancelFunc, logger *
 	}
 	return etcdApp.Start()
 }
+
+// setupDevMode prepares the dev mode environment with throwaway certificates in devDir, or in a temporary directory if
+// it is not set, and configures etcd-wrapper to bootstrap etcd from a fake backup-restore serving its configuration.
+func setupDevMode(ctx context.Context, logger *zap.Logger) error {
+	dir := devDir
+	if dir == "" {
+		var err error
+		if dir, err = os.MkdirTemp("", "etcd-wrapper-dev-"); err != nil {
+			return err
+		}
+	}
+	env, err := devmode.Prepare(dir, config.EtcdClientPort, devPeerPort)
+	if err != nil {
+		return fmt.Errorf("failed to prepare dev mode: %w", err)
+	}
+	hostPort, err := env.ServeBackupRestore(ctx, logger)
+	if err != nil {
+		return fmt.Errorf("failed to serve fake backup-restore of dev mode: %w", err)
+	}
+	config.BackupRestore = types.BackupRestoreConfig{HostPort: hostPort, Protocol: types.BackupRestoreProtocolHTTP}
+	config.EtcdClientTLS = types.EtcdClientTLSConfig{ServerName: devmode.ServerName, CertPath: env.ClientCertPath, KeyPath: env.ClientKeyPath}
+	config.RedirectDefaultPaths(dir)
+	logger.Warn("Running in dev mode with throwaway self-signed certificates, which must only be used for development",
+		zap.String("dir", dir), zap.String("clientURL", env.ClientURL), zap.String("caCertPath", env.CACertPath),
+		zap.String("clientCertPath", env.ClientCertPath), zap.String("clientKeyPath", env.ClientKeyPath))
+	return nil
+}
+
+// setupEphemeralMode prepares the ephemeral mode environment without TLS in a new directory backed by memory and
+// configures etcd-wrapper to bootstrap etcd from a fake backup-restore serving its configuration. It returns the
+// directory, which must be removed once etcd-wrapper exits.
+func setupEphemeralMode(ctx context.Context, logger *zap.Logger) (string, error) {
+	dir, err := devmode.NewEphemeralDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to create directory of ephemeral mode: %w", err)
+	}
+	env, err := devmode.PrepareEphemeral(dir, config.EtcdClientPort, devPeerPort)
+	if err != nil {
+		return dir, fmt.Errorf("failed to prepare ephemeral mode: %w", err)
+	}
+	hostPort, err := env.ServeBackupRestore(ctx, logger)
+	if err != nil {
+		return dir, fmt.Errorf("failed to serve fake backup-restore of ephemeral mode: %w", err)
+	}
+	config.BackupRestore = types.BackupRestoreConfig{HostPort: hostPort, Protocol: types.BackupRestoreProtocolHTTP}
+	config.EtcdClientTLS = types.EtcdClientTLSConfig{ServerName: devmode.ServerName}
+	config.RedirectDefaultPaths(dir)
+	logger.Warn("Running in ephemeral mode, all data is lost when etcd-wrapper exits", zap.String("dir", dir), zap.String("clientURL", env.ClientURL))
+	return dir, nil
+}
+
+// resolveSecrets resolves the secret references passed as flags into the config, so that sensitive values never need
+// to be passed as flags themselves.
+func resolveSecrets(resolver *secret.Resolver) (err error) {
+	if config.EtcdClientTLS.KeyPassphrase, err = resolver.Resolve(etcdClientKeyPassphraseRef); err != nil {
+		return fmt.Errorf("failed to resolve passphrase of etcd client key: %w", err)
+	}
+	if config.EtcdClientAuth.Password, err = resolver.Resolve(etcdClientPasswordRef); err != nil {
+		return fmt.Errorf("failed to resolve password of etcd user: %w", err)
+	}
+	if initialClusterTokenRef != "" {
+		if config.InitialClusterToken != "" {
+			return fmt.Errorf("initial-cluster-token and initial-cluster-token-from are mutually exclusive")
+		}
+		if config.InitialClusterToken, err = resolver.Resolve(initialClusterTokenRef); err != nil {
+			return fmt.Errorf("failed to resolve initial cluster token: %w", err)
+		}
+	}
+	return nil
+}
+
+// stringSliceValue is a flag.Value holding a list of strings, which are passed comma-separated or by repeating the flag.
+type stringSliceValue []string
+
+func (v *stringSliceValue) String() string {
+	return strings.Join(*v, ",")
+}
+
+func (v *stringSliceValue) Set(value string) error {
+	for _, s := range strings.Split(value, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			*v = append(*v, s)
+		}
+	}
+	return nil
+}
+
+// stringListValue is a flag.Value holding a list of strings, which are passed by repeating the flag. Unlike
+// stringSliceValue, values are not split at commas.
+type stringListValue []string
+
+func (v *stringListValue) String() string {
+	return strings.Join(*v, " ")
+}
+
+func (v *stringListValue) Set(value string) error {
+	*v = append(*v, value)
+	return nil
+}
@@ -5,10 +5,18 @@
 package cmd
 
 import (
+	"context"
 	"flag"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/secret"
+	"github.com/gardener/etcd-wrapper/internal/types"
 
 	. "github.com/onsi/gomega"
+	"go.uber.org/zap/zaptest"
 )
 
 func TestAddEtcdFlags(t *testing.T) {
@@ -19,6 +27,7 @@ func TestAddEtcdFlags(t *testing.T) {
 	expectedETCDClientCertPath := "/var/etcd/ssl/client/tls.crt"
 	expectedETCDClientKeyPath := "/var/etcd/ssl/client/tls.key"
 	expectedETCDReadyTimeout := "2m0s"
+	expectedAuditLogPath := "/var/etcd/data/audit.log"
 	args := []string{
 		"-backup-restore-tls-enabled=true",
 		"-backup-restore-host-port", expectedBRHostPort,
@@ -27,6 +36,34 @@ func TestAddEtcdFlags(t *testing.T) {
 		"-etcd-client-cert-path", expectedETCDClientCertPath,
 		"-etcd-client-key-path", expectedETCDClientKeyPath,
 		"-etcd-ready-timeout", expectedETCDReadyTimeout,
+		"-audit-log-path", expectedAuditLogPath,
+		"-audit-log-max-backups", "5",
+		"-sidecar-probe-timeout", "30s",
+		"-restoration-wait-timeout", "1h0m0s",
+		"-experimental-corrupt-check-time", "15m",
+		"-restore-marker-enabled",
+		"-auth-sync-spec-path", "/var/etcd/auth/spec.yaml",
+		"-hot-standby",
+		"-snapshot-on-shutdown", "delta",
+		"-readiness-policy", "learner-serving-stale",
+		"-compaction-revision-threshold", "50000",
+		"-go-memory-limit", "268435456",
+		"-cpu-aware-tuning=false",
+		"-etcd-backend-batch-limit", "5000",
+		"-crash-loop-threshold", "5",
+		"-backup-restore-server-name", "etcd-backup-restore",
+		"-peer-tls-server-name", "etcd-main-peer",
+		"-disable-proxy-env",
+		"-cert-rotation-check-interval", "1m",
+		"-etcd-max-concurrent-streams", "2000",
+		"-etcd-grpc-keepalive-interval", "30s",
+		"-maintenance-window-schedule", "0 2 * * 1-5",
+		"-state-file-path", "/var/etcd/shared/etcd-wrapper-state",
+		"-db-size-trend-horizon", "48h",
+		"-prefix-usage-prefixes", "/registry/tenant-a/, /registry/tenant-b/",
+		"-prefix-usage-prefixes", "/registry/tenant-c/",
+		"-etcd-client-username", "etcd-wrapper",
+		"-etcd-client-password-from", "stdin:password",
 	}
 	fs := flag.NewFlagSet("testutil", flag.ContinueOnError)
 	AddEtcdFlags(fs)
@@ -38,5 +75,121 @@ func TestAddEtcdFlags(t *testing.T) {
 	g.Expect(config.EtcdClientTLS.ServerName).To(Equal(expectedETCDServerName))
 	g.Expect(config.EtcdClientTLS.CertPath).To(Equal(expectedETCDClientCertPath))
 	g.Expect(config.EtcdClientTLS.KeyPath).To(Equal(expectedETCDClientKeyPath))
+	g.Expect(config.EtcdClientAuth.Username).To(Equal("etcd-wrapper"))
+	g.Expect(etcdClientPasswordRef).To(Equal("stdin:password"))
 	g.Expect(etcdReadyTimeout.String()).To(Equal(expectedETCDReadyTimeout))
+	g.Expect(config.AuditLog.Path).To(Equal(expectedAuditLogPath))
+	g.Expect(config.DBSizeTrend.Horizon).To(Equal(48 * time.Hour))
+	g.Expect(config.DBSizeTrend.Window).To(Equal(types.DefaultDBSizeTrendWindow))
+	g.Expect(config.PrefixUsage.Prefixes).To(Equal([]string{"/registry/tenant-a/", "/registry/tenant-b/", "/registry/tenant-c/"}))
+	g.Expect(config.PrefixUsage.Interval).To(Equal(types.DefaultPrefixUsageInterval))
+	g.Expect(config.AuditLog.MaxSizeBytes).To(Equal(int64(types.DefaultAuditLogMaxSizeBytes)))
+	g.Expect(config.AuditLog.MaxBackups).To(Equal(5))
+	g.Expect(config.PhaseTimeouts.SidecarProbe).To(Equal(30 * time.Second))
+	g.Expect(config.PhaseTimeouts.Validation).To(BeZero())
+	g.Expect(config.PhaseTimeouts.RestorationWait).To(Equal(time.Hour))
+	g.Expect(config.CorruptCheck.InitialCheck).To(BeTrue())
+	g.Expect(config.CorruptCheck.CheckTime).To(Equal(15 * time.Minute))
+	g.Expect(config.RestoreMarker.Enabled).To(BeTrue())
+	g.Expect(config.AuthSync.SpecPath).To(Equal("/var/etcd/auth/spec.yaml"))
+	g.Expect(config.AuthSync.Interval).To(Equal(types.DefaultAuthSyncInterval))
+	g.Expect(config.HotStandby).To(BeTrue())
+	g.Expect(config.ReadinessPolicy).To(Equal(types.ReadinessPolicyLearnerServingStale))
+	g.Expect(config.Compaction.RevisionThreshold).To(Equal(int64(50000)))
+	g.Expect(config.Compaction.DBSizeGrowthPercent).To(BeZero())
+	g.Expect(config.BackupRestore.ServerName).To(Equal("etcd-backup-restore"))
+	g.Expect(config.PeerTLSServerName).To(Equal("etcd-main-peer"))
+	g.Expect(config.DisableProxyEnv).To(BeTrue())
+	g.Expect(config.RestartBudget.MaxRestarts).To(Equal(types.DefaultRestartBudgetMaxRestarts))
+	g.Expect(config.RestartBudget.Window).To(Equal(types.DefaultRestartBudgetWindow))
+	g.Expect(config.MaintenanceWindow.Schedule).To(Equal("0 2 * * 1-5"))
+	g.Expect(config.CertRotation.CheckInterval).To(Equal(time.Minute))
+	g.Expect(config.CertRotation.LockKey).To(Equal(types.DefaultCertRotationLockKey))
+	g.Expect(config.CertRotation.LockTTL).To(Equal(types.DefaultCertRotationLockTTL))
+	g.Expect(config.ServerTuning.MaxConcurrentStreams).To(Equal(uint(2000)))
+	g.Expect(config.ServerTuning.MaxRequestBytes).To(BeZero())
+	g.Expect(config.ServerTuning.GRPCKeepAliveInterval).To(Equal(30 * time.Second))
+	g.Expect(config.MaintenanceWindow.Duration).To(Equal(types.DefaultMaintenanceWindowDuration))
+	g.Expect(config.StateFilePath).To(Equal("/var/etcd/shared/etcd-wrapper-state"))
+	g.Expect(config.BootstrapHistory.Path).To(Equal(types.DefaultBootstrapHistoryFilePath))
+	g.Expect(config.BootstrapHistory.CrashLoopThreshold).To(Equal(5))
+	g.Expect(config.BootstrapHistory.CrashLoopWindow).To(Equal(types.DefaultCrashLoopWindow))
+	g.Expect(config.MemoryLimit.Ratio).To(Equal(types.DefaultMemoryLimitRatio))
+	g.Expect(config.MemoryLimit.GoMemoryLimit).To(Equal(int64(268435456)))
+	g.Expect(config.CPULimit.Enabled).To(BeFalse())
+	g.Expect(config.CPULimit.BackendBatchLimit).To(Equal(5000))
+	g.Expect(config.Compaction.RetainedRevisions).To(Equal(int64(types.DefaultCompactionRetainedRevisions)))
+	g.Expect(config.SnapshotOnShutdown.Kind).To(Equal(types.SnapshotKindDelta))
+	g.Expect(config.SnapshotOnShutdown.Timeout).To(Equal(types.DefaultSnapshotOnShutdownTimeout))
+	g.Expect(config.RestoreMarker.Key).To(Equal(types.DefaultRestoreMarkerKey))
+}
+
+func TestAddEtcdFlagsDefaults(t *testing.T) {
+	g := NewWithT(t)
+	config = types.Config{}
+	fs := flag.NewFlagSet("testutil", flag.ContinueOnError)
+	AddEtcdFlags(fs)
+	g.Expect(fs.Parse(nil)).To(Succeed())
+	g.Expect(config).To(Equal(types.NewDefaultConfig()))
+}
+
+func TestResolveSecrets(t *testing.T) {
+	g := NewWithT(t)
+	t.Setenv("ETCD_CLIENT_KEY_PASSPHRASE", "passphrase")
+	etcdClientKeyPassphraseRef, etcdClientPasswordRef = "env:ETCD_CLIENT_KEY_PASSPHRASE", "stdin:password"
+	defer func() {
+		etcdClientKeyPassphraseRef, etcdClientPasswordRef = "", ""
+	}()
+
+	g.Expect(resolveSecrets(secret.NewResolver(strings.NewReader("password=secret\n")))).To(Succeed())
+	g.Expect(config.EtcdClientTLS.KeyPassphrase).To(Equal("passphrase"))
+	g.Expect(config.EtcdClientAuth.Password).To(Equal("secret"))
+
+	t.Log("should return error when a secret cannot be resolved")
+	g.Expect(resolveSecrets(secret.NewResolver(strings.NewReader("")))).ToNot(Succeed())
+}
+
+func TestResolveInitialClusterToken(t *testing.T) {
+	g := NewWithT(t)
+	t.Setenv("ETCD_INITIAL_CLUSTER_TOKEN", "etcd-main-token")
+	initialClusterTokenRef = "env:ETCD_INITIAL_CLUSTER_TOKEN"
+	defer func() {
+		initialClusterTokenRef, config.InitialClusterToken = "", ""
+	}()
+
+	g.Expect(resolveSecrets(secret.NewResolver(strings.NewReader("")))).To(Succeed())
+	g.Expect(config.InitialClusterToken).To(Equal("etcd-main-token"))
+
+	t.Log("should reject a token passed both as value and as reference")
+	g.Expect(resolveSecrets(secret.NewResolver(strings.NewReader("")))).To(MatchError(ContainSubstring("mutually exclusive")))
+}
+
+func TestRedirectDefaultPaths(t *testing.T) {
+	g := NewWithT(t)
+	fs := flag.NewFlagSet("testutil", flag.ContinueOnError)
+	AddEtcdFlags(fs)
+	g.Expect(fs.Parse([]string{"-cluster-id-pin-path", "/var/etcd/shared/cluster_id_pin.json"})).To(Succeed())
+	dir := t.TempDir()
+
+	config.RedirectDefaultPaths(dir)
+	g.Expect(config.BootstrapHistory.Path).To(Equal(filepath.Join(dir, "bootstrap_history.json")))
+	g.Expect(config.MaintenanceHistory.Path).To(Equal(filepath.Join(dir, "maintenance_history.json")))
+	g.Expect(config.MemberIdentityFilePath).To(Equal(filepath.Join(dir, "member_identity.env")))
+	g.Expect(config.LastKnownGoodConfig.Path).To(Equal(filepath.Join(dir, "last_known_good_etcd_config.yaml")))
+	g.Expect(config.VolumeResize.SizeRecordPath).To(Equal(filepath.Join(dir, "volume_size.json")))
+	g.Expect(config.RevisionWatermark.Path).To(Equal(filepath.Join(dir, "revision_watermark.json")))
+	t.Log("should keep paths which are set explicitly")
+	g.Expect(config.ClusterIDPinPath).To(Equal("/var/etcd/shared/cluster_id_pin.json"))
+}
+
+func TestInitAndStartEtcdRejectsDevAndEphemeralMode(t *testing.T) {
+	g := NewWithT(t)
+	fs := flag.NewFlagSet("testutil", flag.ContinueOnError)
+	AddEtcdFlags(fs)
+	g.Expect(fs.Parse([]string{"-dev", "-ephemeral"})).To(Succeed())
+	defer func() {
+		devMode, ephemeralMode = false, false
+	}()
+
+	g.Expect(InitAndStartEtcd(context.Background(), nil, zaptest.NewLogger(t))).To(MatchError(errDevAndEphemeralMode))
 }
@@ -0,0 +1,71 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"flag"
+	"net"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/fakesidecar"
+	"github.com/gardener/etcd-wrapper/internal/httpserver"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultFakeSidecarListenAddress = ":8080"
+	fakeSidecarShutdownTimeout      = 5 * time.Second
+)
+
+var (
+	// FakeSidecarCmd serves the backup-restore API with scripted responses, which allows running etcd-wrapper locally
+	// without backup-restore.
+	FakeSidecarCmd = Command{
+		Name:      "fake-sidecar",
+		UsageLine: "etcd-wrapper fake-sidecar [flags]",
+		ShortDesc: "Serves the HTTP API of backup-restore with scripted responses for local development",
+		LongDesc: `Serves the HTTP API of backup-restore used by etcd-wrapper, which allows running start-etcd or prepare locally
+against it instead of a backup-restore sidecar. Without a script, it behaves like a backup-restore whose data directory
+is valid: initialization succeeds as soon as it is triggered and there are no snapshots. A script (YAML) can serve an etcd
+configuration file and script initialization statuses, the behaviour per validation mode, snapshots and delays and
+failures of individual endpoints. This command is meant for development only.
+
+Flags:
+	--listen-address
+		Address at which the fake backup-restore listens. Default: :8080
+	--script-path
+		Path of the YAML script describing the responses, see docs/development/local-setup.md. Without a script no etcd configuration is served.`,
+		AddFlags: AddFakeSidecarFlags,
+		Run:      RunFakeSidecar,
+	}
+	fakeSidecarListenAddress string
+	fakeSidecarScriptPath    string
+)
+
+// AddFakeSidecarFlags adds flags of the fake-sidecar command to the passed FlagSet.
+func AddFakeSidecarFlags(fs *flag.FlagSet) {
+	fs.StringVar(&fakeSidecarListenAddress, "listen-address", defaultFakeSidecarListenAddress, "Address at which the fake backup-restore listens")
+	fs.StringVar(&fakeSidecarScriptPath, "script-path", "", "Path of the YAML script describing the responses of the fake backup-restore")
+}
+
+// RunFakeSidecar serves the fake backup-restore until ctx is cancelled.
+func RunFakeSidecar(ctx context.Context, _ context.CancelFunc, logger *zap.Logger) error {
+	script := &fakesidecar.Script{}
+	if fakeSidecarScriptPath != "" {
+		var err error
+		if script, err = fakesidecar.LoadScript(fakeSidecarScriptPath); err != nil {
+			return err
+		}
+	}
+	listener, err := net.Listen("tcp", fakeSidecarListenAddress)
+	if err != nil {
+		return err
+	}
+	server := httpserver.New(httpserver.Config{ShutdownTimeout: fakeSidecarShutdownTimeout}, fakesidecar.NewServer(*script))
+	logger.Warn("Serving fake backup-restore, which must only be used for development", zap.String("address", listener.Addr().String()), zap.String("scriptPath", fakeSidecarScriptPath))
+	return server.Serve(ctx, listener)
+}
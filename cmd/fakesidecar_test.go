@@ -0,0 +1,56 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"flag"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestRunFakeSidecar(t *testing.T) {
+	g := NewWithT(t)
+	testDir := t.TempDir()
+	invalidScriptPath := filepath.Join(testDir, "invalid.yaml")
+	g.Expect(os.WriteFile(invalidScriptPath, []byte("unknown: true"), 0600)).To(Succeed())
+	scriptPath := filepath.Join(testDir, "script.yaml")
+	g.Expect(os.WriteFile(scriptPath, []byte("initStatuses: [Successful]"), 0600)).To(Succeed())
+
+	t.Log("should return error when script is invalid")
+	fs := flag.NewFlagSet("testutil", flag.ContinueOnError)
+	AddFakeSidecarFlags(fs)
+	g.Expect(fs.Parse([]string{"-script-path", invalidScriptPath})).To(Succeed())
+	g.Expect(RunFakeSidecar(context.Background(), nil, zaptest.NewLogger(t))).ToNot(Succeed())
+
+	t.Log("should serve the scripted responses till the context is cancelled")
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	g.Expect(err).ToNot(HaveOccurred())
+	address := listener.Addr().String()
+	g.Expect(listener.Close()).To(Succeed())
+	g.Expect(fs.Parse([]string{"-script-path", scriptPath, "-listen-address", address})).To(Succeed())
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- RunFakeSidecar(ctx, cancel, zaptest.NewLogger(t))
+	}()
+	g.Eventually(func() (int, error) {
+		response, err := http.Get("http://" + address + "/initialization/status")
+		if err != nil {
+			return 0, err
+		}
+		_ = response.Body.Close()
+		return response.StatusCode, nil
+	}).WithTimeout(5 * time.Second).Should(Equal(http.StatusOK))
+	cancel()
+	g.Eventually(errCh).WithTimeout(10 * time.Second).Should(Receive(BeNil()))
+}
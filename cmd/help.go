@@ -24,13 +24,18 @@ DESCRIPTION:
 `
 )
 
-// PrintHelp prints out help test for the start-etcd command
+// PrintHelp prints out help text for all commands
 func PrintHelp(w io.Writer) error {
 	bufW := bufio.NewWriter(w)
 	defer func() {
 		_ = bufW.Flush()
 	}()
-	return executeTemplate(w, cliHelpTemplate, EtcdCmd)
+	for _, cmd := range Commands {
+		if err := executeTemplate(bufW, cliHelpTemplate, cmd); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func executeTemplate(w io.Writer, tmplText string, tmplData interface{}) error {
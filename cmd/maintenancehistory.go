@@ -0,0 +1,94 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/maintenance"
+	"github.com/gardener/etcd-wrapper/internal/types"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// maintenanceHistoryOutputTable prints the maintenance history as table.
+	maintenanceHistoryOutputTable = "table"
+	// maintenanceHistoryOutputJSON prints the maintenance history as JSON.
+	maintenanceHistoryOutputJSON = "json"
+)
+
+var (
+	// MaintenanceHistoryCmd prints the persisted history of compactions and defragmentations.
+	MaintenanceHistoryCmd = Command{
+		Name:      "maintenance-history",
+		UsageLine: "etcd-wrapper maintenance-history [--maintenance-history-path=<path>] [--output=table|json]",
+		ShortDesc: "Prints the history of compactions and defragmentations performed by etcd-wrapper",
+		LongDesc: `Prints the persisted history of the compactions and defragmentations performed by etcd-wrapper, oldest first,
+with their trigger, duration and the size of the etcd DB before and after, to audit the effectiveness of maintenance.
+The history is read from its file, so it can be printed while etcd-wrapper is not running, e.g. from an ephemeral container.
+
+Flags:
+	--maintenance-history-path
+		File path of the maintenance history. Default: /var/etcd/data/maintenance_history.json
+	--output
+		Output format, one of table or json. Default: table`,
+		AddFlags: AddMaintenanceHistoryFlags,
+		Run:      PrintMaintenanceHistory,
+	}
+	maintenanceHistoryPath   string
+	maintenanceHistoryOutput string
+	maintenanceHistoryWriter io.Writer = os.Stdout
+)
+
+// AddMaintenanceHistoryFlags adds flags of the maintenance-history command to the passed FlagSet.
+func AddMaintenanceHistoryFlags(fs *flag.FlagSet) {
+	fs.StringVar(&maintenanceHistoryPath, "maintenance-history-path", types.DefaultMaintenanceHistoryFilePath, "File path of the maintenance history")
+	fs.StringVar(&maintenanceHistoryOutput, "output", maintenanceHistoryOutputTable, "Output format, one of table or json")
+}
+
+// PrintMaintenanceHistory prints the persisted maintenance history in the requested output format.
+func PrintMaintenanceHistory(_ context.Context, _ context.CancelFunc, _ *zap.Logger) error {
+	if maintenanceHistoryOutput != maintenanceHistoryOutputTable && maintenanceHistoryOutput != maintenanceHistoryOutputJSON {
+		return fmt.Errorf("unsupported output format %q, must be one of %s or %s", maintenanceHistoryOutput, maintenanceHistoryOutputTable, maintenanceHistoryOutputJSON)
+	}
+	if _, err := os.Stat(maintenanceHistoryPath); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("no maintenance history found at %s", maintenanceHistoryPath)
+		}
+		return err
+	}
+	// the size is not limited, the persisted history has already been trimmed by etcd-wrapper.
+	history, err := maintenance.LoadHistory(maintenanceHistoryPath, 0)
+	if err != nil {
+		return err
+	}
+	records := history.Records()
+	if maintenanceHistoryOutput == maintenanceHistoryOutputJSON {
+		if records == nil {
+			records = []maintenance.Record{}
+		}
+		encoder := json.NewEncoder(maintenanceHistoryWriter)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(records)
+	}
+	w := tabwriter.NewWriter(maintenanceHistoryWriter, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "STARTED\tOPERATION\tTRIGGER\tTARGET\tDURATION\tDB SIZE\tDB SIZE IN USE\tERROR")
+	for _, record := range records {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d -> %d\t%d -> %d\t%s\n",
+			record.StartedAt.Format(time.RFC3339), record.Operation, record.Trigger, record.Target,
+			time.Duration(record.DurationSeconds*float64(time.Second)).Round(time.Millisecond),
+			record.DBSizeBefore, record.DBSizeAfter, record.DBSizeInUseBefore, record.DBSizeInUseAfter, record.Error)
+	}
+	return w.Flush()
+}
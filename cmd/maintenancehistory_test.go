@@ -0,0 +1,64 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/maintenance"
+
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestPrintMaintenanceHistory(t *testing.T) {
+	g := NewWithT(t)
+	historyPath := filepath.Join(t.TempDir(), "maintenance_history.json")
+	history, err := maintenance.LoadHistory(historyPath, 10)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(history.Add(maintenance.Record{
+		Operation:         maintenance.OperationCompaction,
+		Trigger:           "revision",
+		Target:            "1000",
+		StartedAt:         time.Date(2024, time.March, 15, 10, 0, 0, 0, time.UTC),
+		DurationSeconds:   0.25,
+		DBSizeBefore:      4096,
+		DBSizeAfter:       4096,
+		DBSizeInUseBefore: 4096,
+		DBSizeInUseAfter:  2048,
+	})).To(Succeed())
+
+	table := []struct {
+		description    string
+		args           []string
+		expectError    bool
+		expectedOutput []string
+	}{
+		{"should print the history as table", []string{"-maintenance-history-path", historyPath}, false, []string{"OPERATION", "2024-03-15T10:00:00Z", "compaction", "revision", "1000", "250ms", "4096 -> 2048"}},
+		{"should print the history as JSON", []string{"-maintenance-history-path", historyPath, "-output", "json"}, false, []string{`"operation": "compaction"`, `"dbSizeInUseAfter": 2048`}},
+		{"should return error for unsupported output format", []string{"-maintenance-history-path", historyPath, "-output", "yaml"}, true, nil},
+		{"should return error if no history exists", []string{"-maintenance-history-path", filepath.Join(t.TempDir(), "missing.json")}, true, nil},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		fs := flag.NewFlagSet("testutil", flag.ContinueOnError)
+		AddMaintenanceHistoryFlags(fs)
+		g.Expect(fs.Parse(entry.args)).To(Succeed())
+		output := &bytes.Buffer{}
+		maintenanceHistoryWriter = output
+
+		err := PrintMaintenanceHistory(context.Background(), nil, zaptest.NewLogger(t))
+		g.Expect(err != nil).To(Equal(entry.expectError))
+		for _, expected := range entry.expectedOutput {
+			g.Expect(output.String()).To(ContainSubstring(expected))
+		}
+	}
+}
@@ -0,0 +1,109 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"flag"
+
+	"github.com/gardener/etcd-wrapper/internal/app"
+
+	"go.uber.org/zap"
+)
+
+var (
+	// PrepareCmd initializes the etcd data directory without starting etcd, which allows running it as an init container.
+	PrepareCmd = Command{
+		Name:      "prepare",
+		UsageLine: "etcd-wrapper prepare [flags]",
+		ShortDesc: "Initializes the etcd data directory by coordinating with backup-restore and exits without starting etcd",
+		LongDesc: `Runs only the bootstrapping steps of start-etcd: it coordinates with a backup-sidecar container to validate
+and, if required, restore the etcd data directory, fetches the etcd configuration and verifies the restored data directory.
+It exits once the data directory has been initialized, without starting etcd. This allows running etcd-wrapper as an init
+container, with a slimmer main container starting etcd.
+
+Flags:
+	--backup-restore-tls-enabled
+		Enables TLS for communicating with backup-restore if its value is true. It is disabled by default.
+	--backup-restore-host-port
+		Host address and port of the backup restore with which this container will interact during initialization. Should be of the format <host>:<port> and must not include the protocol.
+	--backup-restore-ca-cert-bundle-path
+		Path of CA cert bundle (This will be used when TLS is enabled via backup-restore-tls-enabled flag.
+	--backup-restore-server-name
+		Name expected in the TLS certificate of backup-restore, which allows connecting through an address (e.g. a service VIP) that is not among the SANs of the certificate. Defaults to the host of backup-restore-host-port.
+	--disable-proxy-env
+		Ignores the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables for all outbound connections of etcd-wrapper (backup-restore, peers and the embedded etcd). By default proxies configured via these variables are used.
+	--dns-servers
+		Comma-separated list of DNS servers, in the form <host>:<port>, which are queried in turn to resolve the host names of peers and of backup-restore (http protocol) instead of the DNS servers of /etc/resolv.conf, e.g. to bypass a node-local DNS cache. Can be repeated.
+	--dns-lookup-timeout
+		Time after which resolving the host name of a peer or of backup-restore fails, so that a stalling cluster DNS does not stall the bootstrap for the timeouts of the resolver. By default the timeout of the resolver is kept.
+	--dial-timeout
+		Time after which establishing a connection to a peer or to backup-restore fails. By default the timeout of the operating system is kept.
+	--sidecar-protocol
+		Protocol used to communicate with backup-restore, one of: http, grpc. Default: http
+	--sidecar-probe-timeout
+		time duration the application will wait for backup-restore to respond with an initialization status, by default it waits forever. Exits with code 10 on expiry.
+	--validation-timeout
+		time duration the application will wait for backup-restore to start the triggered data directory validation, by default it waits forever. Exits with code 11 on expiry.
+	--restoration-wait-timeout
+		time duration the application will wait for an initialization in progress (including restoration) to complete, by default it waits forever. Exits with code 12 on expiry.
+	--state-file-path
+		Path of a file, e.g. on a volume shared with other containers of the pod, into which the state of etcd-wrapper (bootstrap phase) and the readiness of etcd are written on every change. The file uses the format of the downward API annotations file. Disabled if not set.
+	--audit-log-path
+		Path of the file into which cluster-mutating operations performed by etcd-wrapper are recorded. Audit logging is disabled if not set.
+	--audit-log-max-size-bytes
+		Size in bytes after which the audit log file is rotated. Default: 10485760
+	--audit-log-max-backups
+		Maximum number of rotated audit log files to retain. Default: 3
+	--bootstrap-history-path
+		Path of the file into which the most recent start attempts (timestamp, phase reached, outcome) are recorded. The history is not persisted if set to an empty value. Default: /var/etcd/data/bootstrap_history.json
+	--crash-loop-threshold
+		Number of failed start attempts within the crash loop window from which on a crash loop is detected, which escalates to a full validation of the data directory. Set to 0 to disable crash loop detection. Default: 3
+	--crash-loop-window
+		Window within which failed start attempts are counted for crash loop detection. Default: 10m0s
+	--sidecar-optional
+		Starts etcd without backup-restore if backup-restore cannot be reached at all within the sidecar optional window and the data directory passes local verification. The etcd configuration last fetched from backup-restore is used. It is disabled by default.
+	--sidecar-optional-window
+		Window within which backup-restore must respond before etcd is started without it in sidecar optional mode. Default: 2m0s
+	--last-known-good-config-path
+		Path of the file into which the etcd configuration is written once etcd has become ready with it, and which can be compared with the current etcd configuration by the diff-config command. Disabled if set to an empty value. Default: /var/etcd/data/last_known_good_etcd_config.yaml
+	--use-last-known-good-config
+		Starts etcd with the last known good etcd configuration if the etcd configuration cannot be fetched from backup-restore. It is disabled by default.
+	--allow-etcd-downgrade
+		Allows starting etcd on a data directory last used by etcd of a newer minor version. Upgrades which skip a minor version are always refused with exit code 15.
+	--skip-restore-verification
+		Skips verifying the restored etcd DB against the latest snapshot reported by backup-restore.
+	--skip-empty-data-dir-recovery
+		Skips restoring a data directory which has been initialized but contains no WAL.
+	--wal-dir
+		Directory into which etcd writes its WAL, e.g. on a separate volume backed by faster storage. Overrides the wal-dir of the etcd configuration. A WAL found in the data directory, e.g. after a restoration, is moved into it before etcd is started.
+	--skip-preflight-checks
+		Skips checking the data and WAL volumes (existence, permissions, fsync latency) before etcd is started.
+	--preflight-fsync-probes
+		Number of writes synced to each volume to probe its fsync latency. Default: 5
+	--preflight-fsync-latency-threshold
+		Fsync latency of the data or WAL volume beyond which etcd is not started. The latency is only reported if set to 0. Default: 0s`,
+		AddFlags: AddPrepareFlags,
+		Run:      PrepareEtcd,
+	}
+)
+
+// AddPrepareFlags adds flags of the prepare command to the passed FlagSet.
+func AddPrepareFlags(fs *flag.FlagSet) {
+	addBootstrapFlags(fs)
+}
+
+// PrepareEtcd initializes the etcd data directory and returns without starting etcd.
+func PrepareEtcd(ctx context.Context, cancelFn context.CancelFunc, logger *zap.Logger) error {
+	etcdApp, err := app.NewApplication(ctx, cancelFn, config, 0, logger)
+	if err != nil {
+		return err
+	}
+	if err = etcdApp.Setup(); err != nil {
+		return err
+	}
+	logger.Info("etcd data directory has been prepared, exiting without starting etcd")
+	return nil
+}
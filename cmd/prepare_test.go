@@ -0,0 +1,40 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"flag"
+	"io"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestAddPrepareFlags(t *testing.T) {
+	table := []struct {
+		description string
+		args        []string
+		expectError bool
+	}{
+		{"should accept bootstrap flags", []string{"-backup-restore-host-port", "etcd-main-local:8080", "-restoration-wait-timeout", "1h", "-skip-restore-verification", "-allow-etcd-downgrade"}, false},
+		{"should reject flags which are only relevant for a running etcd", []string{"-etcd-wrapper-port", "9095"}, true},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		fs := flag.NewFlagSet("testutil", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		AddPrepareFlags(fs)
+		err := fs.Parse(entry.args)
+		g.Expect(err != nil).To(Equal(entry.expectError))
+		if !entry.expectError {
+			g.Expect(config.BackupRestore.HostPort).To(Equal("etcd-main-local:8080"))
+			g.Expect(config.PhaseTimeouts.RestorationWait).To(Equal(time.Hour))
+			g.Expect(config.SkipRestoreVerification).To(BeTrue())
+		}
+	}
+}
@@ -0,0 +1,83 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/recovery"
+
+	"go.uber.org/zap"
+)
+
+const forceNewClusterWarning = `
+################################################################################
+#                                  WARNING                                     #
+#                                                                              #
+#  recover-single-member starts etcd with --force-new-cluster. All other       #
+#  members are removed from the cluster membership and this member becomes     #
+#  the only member of a new single-member cluster, keeping its own data.       #
+#                                                                              #
+#  Writes that were not yet replicated to this member are LOST. Only use this  #
+#  as a last resort when quorum has been lost and cannot be restored.          #
+#  Make sure that no other member of the cluster is running.                   #
+################################################################################
+`
+
+var (
+	// RecoverSingleMemberCmd recovers a single member from an existing data directory after a loss of quorum.
+	RecoverSingleMemberCmd = Command{
+		Name:      "recover-single-member",
+		UsageLine: "etcd-wrapper recover-single-member --etcd-config-file-path=<path> --confirm-force-new-cluster",
+		ShortDesc: "Recovers a single etcd member with force-new-cluster semantics after a loss of quorum",
+		LongDesc: `Starts the embedded etcd from an existing data directory with force-new-cluster semantics, which turns the
+member into the only member of a new single-member cluster while keeping its data. Once etcd is ready it is
+stopped again, after which etcd-wrapper can be started normally using start-etcd and the cluster can be scaled out again.
+This is a last-resort procedure for quorum-loss recovery which can lose writes that have not been replicated to this member.
+
+Flags:
+	--etcd-config-file-path
+		Path of the etcd configuration file of the member to recover. Required.
+	--confirm-force-new-cluster
+		Confirms that the cluster membership is to be overwritten. Required.
+	--etcd-ready-timeout
+		time duration the command will wait for etcd to get ready, by default it waits forever.`,
+		AddFlags: AddRecoverSingleMemberFlags,
+		Run:      RecoverSingleMember,
+	}
+	recoverEtcdConfigFilePath  string
+	recoverConfirmed           bool
+	recoverEtcdReadyTimeout    time.Duration
+	recoverWarningWriter       io.Writer = os.Stderr
+	errRecoveryNotConfirmed              = errors.New("recover-single-member overwrites the cluster membership and must be confirmed via --confirm-force-new-cluster")
+	errRecoveryConfigFileEmpty           = errors.New("--etcd-config-file-path must be specified")
+)
+
+// AddRecoverSingleMemberFlags adds flags of the recover-single-member command to the passed FlagSet.
+func AddRecoverSingleMemberFlags(fs *flag.FlagSet) {
+	fs.StringVar(&recoverEtcdConfigFilePath, "etcd-config-file-path", "", "Path of the etcd configuration file of the member to recover")
+	fs.BoolVar(&recoverConfirmed, "confirm-force-new-cluster", false, "Confirms that the cluster membership is to be overwritten")
+	fs.DurationVar(&recoverEtcdReadyTimeout, "etcd-ready-timeout", 0, "Time duration to wait for etcd to be ready")
+}
+
+// RecoverSingleMember starts the embedded etcd with force-new-cluster semantics after the recovery has been confirmed.
+func RecoverSingleMember(ctx context.Context, _ context.CancelFunc, logger *zap.Logger) error {
+	if strings.TrimSpace(recoverEtcdConfigFilePath) == "" {
+		return errRecoveryConfigFileEmpty
+	}
+	_, _ = fmt.Fprint(recoverWarningWriter, forceNewClusterWarning)
+	if !recoverConfirmed {
+		return errRecoveryNotConfirmed
+	}
+	logger.Warn("Recovery with force-new-cluster has been confirmed", zap.String("etcdConfigFilePath", recoverEtcdConfigFilePath))
+	return recovery.RecoverSingleMember(ctx, recoverEtcdConfigFilePath, recoverEtcdReadyTimeout, logger)
+}
@@ -0,0 +1,57 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestRecoverSingleMemberGuards(t *testing.T) {
+	table := []struct {
+		description   string
+		args          []string
+		expectedErr   error
+		expectWarning bool
+	}{
+		{"should return error when etcd config file path is not specified", []string{"-confirm-force-new-cluster"}, errRecoveryConfigFileEmpty, false},
+		{"should print warning and return error when recovery is not confirmed", []string{"-etcd-config-file-path", "/var/etcd/config/etcd.conf.yaml"}, errRecoveryNotConfirmed, true},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		recoverEtcdConfigFilePath, recoverConfirmed = "", false
+		fs := flag.NewFlagSet("testutil", flag.ContinueOnError)
+		AddRecoverSingleMemberFlags(fs)
+		g.Expect(fs.Parse(entry.args)).To(Succeed())
+		warning := &bytes.Buffer{}
+		recoverWarningWriter = warning
+
+		err := RecoverSingleMember(context.Background(), nil, zaptest.NewLogger(t))
+		g.Expect(err).To(Equal(entry.expectedErr))
+		g.Expect(warning.String()).To(Equal(map[bool]string{true: forceNewClusterWarning, false: ""}[entry.expectWarning]))
+	}
+}
+
+func TestGetCommand(t *testing.T) {
+	g := NewWithT(t)
+	g.Expect(GetCommand("start-etcd")).To(BeIdenticalTo(&EtcdCmd))
+	g.Expect(GetCommand("prepare")).To(BeIdenticalTo(&PrepareCmd))
+	g.Expect(GetCommand("recover-single-member")).To(BeIdenticalTo(&RecoverSingleMemberCmd))
+	g.Expect(GetCommand("maintenance-history")).To(BeIdenticalTo(&MaintenanceHistoryCmd))
+	g.Expect(GetCommand("snapshot-status")).To(BeIdenticalTo(&SnapshotStatusCmd))
+	g.Expect(GetCommand("diff-config")).To(BeIdenticalTo(&DiffConfigCmd))
+	g.Expect(GetCommand("cluster-health")).To(BeIdenticalTo(&ClusterHealthCmd))
+	g.Expect(GetCommand("wait-until-ready")).To(BeIdenticalTo(&WaitUntilReadyCmd))
+	g.Expect(GetCommand("fake-sidecar")).To(BeIdenticalTo(&FakeSidecarCmd))
+	g.Expect(GetCommand("does-not-exist")).To(BeNil())
+	g.Expect(IsCommandSupported("recover-single-member")).To(BeTrue())
+}
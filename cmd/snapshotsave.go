@@ -0,0 +1,120 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/bootstrap"
+	"github.com/gardener/etcd-wrapper/internal/secret"
+	"github.com/gardener/etcd-wrapper/internal/snapshotsave"
+	"github.com/gardener/etcd-wrapper/internal/types"
+	"github.com/gardener/etcd-wrapper/internal/util"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultSnapshotSaveEndpoint is the default client URL of the local member.
+	defaultSnapshotSaveEndpoint = "localhost:2379"
+	// defaultSnapshotSaveDialTimeout is the default time after which connecting to the member fails.
+	defaultSnapshotSaveDialTimeout = 5 * time.Second
+)
+
+var (
+	// SnapshotSaveCmd saves a snapshot of the etcd DB of the local member into a file.
+	SnapshotSaveCmd = Command{
+		Name:      "snapshot-save",
+		UsageLine: "etcd-wrapper snapshot-save --path=<path> [--endpoint=<url>]",
+		ShortDesc: "Saves a snapshot of the etcd DB of the local member into a file",
+		LongDesc: `Streams a snapshot of the etcd DB from the maintenance API of a single member, by default the local one, into a file,
+independent of backup-restore, e.g. for an ad-hoc backup before a risky operation. The snapshot is verified against the
+SHA-256 hash which etcd appends to it and only then moved to the given path, so that the path never holds a partial or
+corrupted snapshot. The snapshot can be restored with etcdctl snapshot restore.
+
+Flags:
+	--path
+		File path into which the snapshot is saved. An existing file is replaced. Required.
+	--endpoint
+		Client URL of the member whose DB is saved. Default: localhost:2379
+	--etcd-ca-cert-path
+		File path of the CA certificate bundle to verify the certificate of the member. Enables TLS if set.
+	--etcd-server-name
+		Name expected in the TLS certificates of etcd. Defaults to the host of the endpoint.
+	--etcd-client-cert-path
+		File path of the client certificate used to authenticate against etcd.
+	--etcd-client-key-path
+		File path of the key of the client certificate.
+	--etcd-client-key-passphrase-from
+		Reference to the passphrase of an encrypted client key, one of: file:<path> (regular file or named pipe), env:<variable>, stdin:<name>.
+	--etcd-client-username
+		Name of the etcd user to authenticate with when auth is enabled in etcd.
+	--etcd-client-password-from
+		Reference to the password of the etcd user, one of: file:<path> (regular file or named pipe), env:<variable>, stdin:<name>.
+	--disable-proxy-env
+		Ignores the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables. By default proxies configured via these variables are used.
+	--dial-timeout
+		Time after which connecting to the member fails. Default: 5s`,
+		AddFlags: AddSnapshotSaveFlags,
+		Run:      SaveSnapshot,
+	}
+	snapshotSavePath        string
+	snapshotSaveEndpoint    string
+	snapshotSaveCACertPath  string
+	snapshotSaveDialTimeout time.Duration
+	snapshotSaveWriter      io.Writer = os.Stdout
+	errSnapshotSaveNoPath             = errors.New("--path must be specified")
+)
+
+// AddSnapshotSaveFlags adds flags of the snapshot-save command to the passed FlagSet.
+func AddSnapshotSaveFlags(fs *flag.FlagSet) {
+	fs.StringVar(&snapshotSavePath, "path", "", "File path into which the snapshot is saved")
+	fs.StringVar(&snapshotSaveEndpoint, "endpoint", defaultSnapshotSaveEndpoint, "Client URL of the member whose DB is saved")
+	fs.StringVar(&snapshotSaveCACertPath, "etcd-ca-cert-path", "", "File path of the CA certificate bundle to verify the certificate of the member. Enables TLS if set")
+	addEtcdClientFlags(fs)
+	fs.DurationVar(&snapshotSaveDialTimeout, "dial-timeout", defaultSnapshotSaveDialTimeout, "Time after which connecting to the member fails")
+}
+
+// SaveSnapshot saves a verified snapshot of the etcd DB of the member into the given path and prints its metadata.
+func SaveSnapshot(ctx context.Context, _ context.CancelFunc, _ *zap.Logger) error {
+	if snapshotSavePath == "" {
+		return errSnapshotSaveNoPath
+	}
+	if err := resolveSecrets(secret.NewResolver(os.Stdin)); err != nil {
+		return err
+	}
+	tlsConfig, err := util.CreateTLSConfig(func() bool { return snapshotSaveCACertPath != "" }, config.EtcdClientTLS.ServerName, snapshotSaveCACertPath, &util.KeyPair{
+		CertPath:      config.EtcdClientTLS.CertPath,
+		KeyPath:       config.EtcdClientTLS.KeyPath,
+		KeyPassphrase: config.EtcdClientTLS.KeyPassphrase,
+	})
+	if err != nil {
+		return err
+	}
+	if snapshotSaveCACertPath == "" {
+		tlsConfig = nil
+	}
+	result, err := snapshotsave.Save(ctx, snapshotsave.Config{
+		Endpoint:    snapshotSaveEndpoint,
+		TLS:         tlsConfig,
+		Username:    config.EtcdClientAuth.Username,
+		Password:    config.EtcdClientAuth.Password,
+		DialTimeout: snapshotSaveDialTimeout,
+		DialOptions: util.GRPCProxyDialOptions(config.DisableProxyEnv),
+		LogConfig:   bootstrap.SetupLoggerConfig(types.DefaultLogLevel),
+	}, snapshotSavePath)
+	if err != nil {
+		return fmt.Errorf("failed to save snapshot: %w", err)
+	}
+	_, err = fmt.Fprintf(snapshotSaveWriter, "saved snapshot at revision %d into %s (%d bytes, sha256 %s) in %s\n",
+		result.Revision, result.Path, result.SizeBytes, result.SHA256, result.Duration)
+	return err
+}
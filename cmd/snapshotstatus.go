@@ -0,0 +1,134 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/brclient"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// snapshotStatusOutputTable prints the snapshot status as table.
+	snapshotStatusOutputTable = "table"
+	// snapshotStatusOutputJSON prints the snapshot status as JSON.
+	snapshotStatusOutputJSON = "json"
+	// defaultSnapshotStatusTimeout is the default time to wait for backup-restore to return the latest snapshots.
+	defaultSnapshotStatusTimeout = 10 * time.Second
+)
+
+var (
+	// SnapshotStatusCmd prints the metadata of the latest snapshots taken by backup-restore.
+	SnapshotStatusCmd = Command{
+		Name:      "snapshot-status",
+		UsageLine: "etcd-wrapper snapshot-status [--backup-restore-host-port=<host>:<port>] [--output=table|json]",
+		ShortDesc: "Prints the metadata of the latest full and delta snapshots taken by backup-restore",
+		LongDesc: `Queries backup-restore for the latest full snapshot and the delta snapshots taken after it, and prints their kind,
+revisions, creation time, age and size, to check the freshness of the backups from within the etcd container.
+The size is only printed if backup-restore reports it.
+
+Flags:
+	--backup-restore-tls-enabled
+		Enables TLS for communicating with backup-restore if its value is true. It is disabled by default.
+	--backup-restore-host-port
+		Host address and port of backup-restore. Should be of the format <host>:<port> and must not include the protocol. Default: :8080
+	--backup-restore-host-port-env
+		Name of an environment variable from which the host and port of backup-restore are taken instead of backup-restore-host-port.
+	--backup-restore-host-port-file
+		File path from which the host and port of backup-restore are taken instead of backup-restore-host-port.
+	--backup-restore-ca-cert-bundle-path
+		Path of CA cert bundle (This will be used when TLS is enabled via backup-restore-tls-enabled flag.
+	--backup-restore-server-name
+		Name expected in the TLS certificate of backup-restore. Defaults to the host of backup-restore-host-port.
+	--disable-proxy-env
+		Ignores the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables. By default proxies configured via these variables are used.
+	--dns-servers
+		Comma-separated list of DNS servers, in the form <host>:<port>, which are queried in turn to resolve the host names of peers and of backup-restore (http protocol) instead of the DNS servers of /etc/resolv.conf, e.g. to bypass a node-local DNS cache. Can be repeated.
+	--dns-lookup-timeout
+		Time after which resolving the host name of a peer or of backup-restore fails, so that a stalling cluster DNS does not stall the bootstrap for the timeouts of the resolver. By default the timeout of the resolver is kept.
+	--dial-timeout
+		Time after which establishing a connection to a peer or to backup-restore fails. By default the timeout of the operating system is kept.
+	--sidecar-protocol
+		Protocol used to communicate with backup-restore, one of: http, grpc. Default: http
+	--timeout
+		Time to wait for backup-restore to return the latest snapshots. Default: 10s
+	--output
+		Output format, one of table or json. Default: table`,
+		AddFlags: AddSnapshotStatusFlags,
+		Run:      PrintSnapshotStatus,
+	}
+	snapshotStatusTimeout time.Duration
+	snapshotStatusOutput  string
+	snapshotStatusWriter  io.Writer = os.Stdout
+)
+
+// AddSnapshotStatusFlags adds flags of the snapshot-status command to the passed FlagSet.
+func AddSnapshotStatusFlags(fs *flag.FlagSet) {
+	addBackupRestoreClientFlags(fs)
+	fs.DurationVar(&snapshotStatusTimeout, "timeout", defaultSnapshotStatusTimeout, "Time to wait for backup-restore to return the latest snapshots")
+	fs.StringVar(&snapshotStatusOutput, "output", snapshotStatusOutputTable, "Output format, one of table or json")
+}
+
+// PrintSnapshotStatus queries backup-restore for the latest snapshots and prints them in the requested output format.
+func PrintSnapshotStatus(ctx context.Context, _ context.CancelFunc, _ *zap.Logger) error {
+	if snapshotStatusOutput != snapshotStatusOutputTable && snapshotStatusOutput != snapshotStatusOutputJSON {
+		return fmt.Errorf("unsupported output format %q, must be one of %s or %s", snapshotStatusOutput, snapshotStatusOutputTable, snapshotStatusOutputJSON)
+	}
+	if err := config.BackupRestore.ResolveHostPort(); err != nil {
+		return err
+	}
+	if err := config.BackupRestore.Validate(); err != nil {
+		return err
+	}
+	brClient, err := brclient.NewDefaultClient(config.BackupRestore, config.DisableProxyEnv, config.DNS.NewDialer())
+	if err != nil {
+		return err
+	}
+	ctx, cancelFunc := context.WithTimeout(ctx, snapshotStatusTimeout)
+	defer cancelFunc()
+	latestSnapshots, err := brClient.GetLatestSnapshots(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get latest snapshots from backup-restore: %w", err)
+	}
+	if latestSnapshots == nil {
+		latestSnapshots = &brclient.LatestSnapshots{}
+	}
+	if snapshotStatusOutput == snapshotStatusOutputJSON {
+		encoder := json.NewEncoder(snapshotStatusWriter)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(latestSnapshots)
+	}
+	snapshots := latestSnapshots.DeltaSnapshots
+	if latestSnapshots.FullSnapshot != nil {
+		snapshots = append([]*brclient.Snapshot{latestSnapshots.FullSnapshot}, snapshots...)
+	}
+	if len(snapshots) == 0 {
+		_, err = fmt.Fprintln(snapshotStatusWriter, "backup-restore has not taken any snapshots yet")
+		return err
+	}
+	now := time.Now()
+	w := tabwriter.NewWriter(snapshotStatusWriter, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "KIND\tSTART REVISION\tLAST REVISION\tCREATED\tAGE\tSIZE\tNAME")
+	for _, snapshot := range snapshots {
+		size := "-"
+		if snapshot.SizeBytes > 0 {
+			size = strconv.FormatInt(snapshot.SizeBytes, 10)
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\t%s\t%s\n",
+			snapshot.Kind, snapshot.StartRevision, snapshot.LastRevision, snapshot.CreatedOn.Format(time.RFC3339),
+			now.Sub(snapshot.CreatedOn).Round(time.Second), size, snapshot.SnapName)
+	}
+	return w.Flush()
+}
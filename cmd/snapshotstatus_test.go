@@ -0,0 +1,65 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/brclient"
+	"github.com/gardener/etcd-wrapper/internal/fakesidecar"
+
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestPrintSnapshotStatus(t *testing.T) {
+	sidecar := fakesidecar.NewServer(fakesidecar.Script{})
+	sidecar.SetLatestSnapshots(&brclient.LatestSnapshots{
+		FullSnapshot: &brclient.Snapshot{Kind: "Full", LastRevision: 1000, CreatedOn: time.Date(2024, time.March, 15, 10, 0, 0, 0, time.UTC), SnapName: "Full-00000000-00001000-1710496800", SizeBytes: 4096},
+		DeltaSnapshots: []*brclient.Snapshot{
+			{Kind: "Incr", StartRevision: 1001, LastRevision: 1200, CreatedOn: time.Date(2024, time.March, 15, 10, 5, 0, 0, time.UTC), SnapName: "Incr-00001001-00001200-1710497100"},
+		},
+	})
+	server := httptest.NewServer(sidecar)
+	defer server.Close()
+	hostPort := strings.TrimPrefix(server.URL, "http://")
+	emptyServer := httptest.NewServer(fakesidecar.NewServer(fakesidecar.Script{}))
+	defer emptyServer.Close()
+
+	table := []struct {
+		description    string
+		args           []string
+		expectError    bool
+		expectedOutput []string
+	}{
+		{"should print the latest snapshots as table", []string{"-backup-restore-host-port", hostPort}, false, []string{"LAST REVISION", "Full", "2024-03-15T10:00:00Z", "4096", "Incr", "1001", "1200", "Incr-00001001-00001200-1710497100"}},
+		{"should print the latest snapshots as JSON", []string{"-backup-restore-host-port", hostPort, "-output", "json"}, false, []string{`"lastRevision": 1000`, `"sizeBytes": 4096`, `"startRevision": 1001`}},
+		{"should report that no snapshots have been taken yet", []string{"-backup-restore-host-port", strings.TrimPrefix(emptyServer.URL, "http://")}, false, []string{"not taken any snapshots"}},
+		{"should return error for unsupported output format", []string{"-backup-restore-host-port", hostPort, "-output", "yaml"}, true, nil},
+		{"should return error if backup-restore is not reachable", []string{"-backup-restore-host-port", "127.0.0.1:1", "-timeout", "1s"}, true, nil},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		fs := flag.NewFlagSet("testutil", flag.ContinueOnError)
+		AddSnapshotStatusFlags(fs)
+		g.Expect(fs.Parse(entry.args)).To(Succeed())
+		output := &bytes.Buffer{}
+		snapshotStatusWriter = output
+
+		err := PrintSnapshotStatus(context.Background(), nil, zaptest.NewLogger(t))
+		g.Expect(err != nil).To(Equal(entry.expectError))
+		for _, expected := range entry.expectedOutput {
+			g.Expect(output.String()).To(ContainSubstring(expected))
+		}
+	}
+}
@@ -0,0 +1,113 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/util"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultTransferLeadershipWrapperURL is the default base URL of the etcd-wrapper requested to transfer the leadership.
+	defaultTransferLeadershipWrapperURL = "http://localhost:9095"
+	// defaultTransferLeadershipTimeout is the default time after which the leadership transfer request is given up.
+	defaultTransferLeadershipTimeout = 30 * time.Second
+)
+
+var (
+	// TransferLeadershipCmd requests etcd-wrapper to transfer the leadership of the etcd cluster.
+	TransferLeadershipCmd = Command{
+		Name:      "transfer-leadership",
+		UsageLine: "etcd-wrapper transfer-leadership [--wrapper-url=<url>] [--timeout=<duration>] [target-member]",
+		ShortDesc: "Transfers the leadership of the etcd cluster to the given member or the best candidate",
+		LongDesc: `Requests etcd-wrapper to transfer the leadership of the etcd cluster to the target member, given by its name or
+hexadecimal ID, to be used by update orchestrators before the pod of the leader is deleted. If no target member is given,
+the started voting follower which has applied the most raft entries is chosen. The target member must be given after
+the flags. Nothing is done if the target member is the leader already. The result is printed as JSON.
+
+Flags:
+	--wrapper-url
+		Base URL of any etcd-wrapper of the cluster whose /transfer-leadership endpoint is requested. Default: http://localhost:9095
+	--wrapper-ca-cert-path
+		File path of the CA certificate bundle to verify the certificate of etcd-wrapper if the wrapper URL uses https. By default the CA certificates of the system are used.
+	--disable-proxy-env
+		Ignores the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables. By default proxies configured via these variables are used.
+	--timeout
+		Time after which the request is given up. Default: 30s`,
+		AddFlags: AddTransferLeadershipFlags,
+		Run:      TransferLeadership,
+	}
+	transferLeadershipFlags         *flag.FlagSet
+	transferLeadershipWrapperURL    string
+	transferLeadershipWrapperCACert string
+	transferLeadershipTimeout       time.Duration
+	transferLeadershipWriter        io.Writer = os.Stdout
+)
+
+// AddTransferLeadershipFlags adds flags of the transfer-leadership command to the passed FlagSet, whose remaining
+// argument is the target member.
+func AddTransferLeadershipFlags(fs *flag.FlagSet) {
+	transferLeadershipFlags = fs
+	fs.StringVar(&transferLeadershipWrapperURL, "wrapper-url", defaultTransferLeadershipWrapperURL, "Base URL of etcd-wrapper whose /transfer-leadership endpoint is requested")
+	fs.StringVar(&transferLeadershipWrapperCACert, "wrapper-ca-cert-path", "", "File path of the CA certificate bundle to verify the certificate of etcd-wrapper")
+	fs.BoolVar(&config.DisableProxyEnv, "disable-proxy-env", false, "Ignores the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables")
+	fs.DurationVar(&transferLeadershipTimeout, "timeout", defaultTransferLeadershipTimeout, "Time after which the request is given up")
+}
+
+// TransferLeadership requests etcd-wrapper to transfer the leadership of the etcd cluster to the target member, or to
+// the best candidate if no target member is given, and prints the result.
+func TransferLeadership(ctx context.Context, _ context.CancelFunc, logger *zap.Logger) error {
+	args := transferLeadershipFlags.Args()
+	if len(args) > 1 {
+		return fmt.Errorf("at most one target member can be given, got %q", args)
+	}
+	wrapperURL, err := url.Parse(transferLeadershipWrapperURL)
+	if err != nil {
+		return fmt.Errorf("invalid --wrapper-url: %w", err)
+	}
+	transferURL := wrapperURL.JoinPath("transfer-leadership")
+	if len(args) == 1 {
+		transferURL.RawQuery = url.Values{"target": args}.Encode()
+	}
+	tlsConfig, err := util.CreateTLSConfig(func() bool { return wrapperURL.Scheme == "https" }, wrapperURL.Hostname(), transferLeadershipWrapperCACert, nil)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig, Proxy: util.ProxyFunc(config.DisableProxyEnv)}}
+	defer client.CloseIdleConnections()
+
+	requestCtx, cancelFunc := context.WithTimeout(ctx, transferLeadershipTimeout)
+	defer cancelFunc()
+	request, err := http.NewRequestWithContext(requestCtx, http.MethodPost, transferURL.String(), nil)
+	if err != nil {
+		return err
+	}
+	logger.Info("requesting leadership transfer", zap.String("url", transferURL.String()))
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer util.CloseResponseBody(response)
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("etcd-wrapper responded with status %s: %s", response.Status, strings.TrimSpace(string(body)))
+	}
+	_, err = transferLeadershipWriter.Write(body)
+	return err
+}
@@ -0,0 +1,65 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestTransferLeadership(t *testing.T) {
+	var requests []string
+	wrapper := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.RequestURI())
+		if r.URL.Query().Get("target") == "unknown" {
+			http.Error(w, "invalid target of leadership transfer", http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"previousLeader":"etcd-main-0","leader":"etcd-main-1","transferred":true}`))
+	}))
+	defer wrapper.Close()
+
+	table := []struct {
+		description     string
+		args            []string
+		expectedRequest string
+		expectError     bool
+	}{
+		{"should request the transfer to the best candidate without target", []string{"-wrapper-url", wrapper.URL}, "POST /transfer-leadership", false},
+		{"should request the transfer to the given target", []string{"-wrapper-url", wrapper.URL, "etcd-main-1"}, "POST /transfer-leadership?target=etcd-main-1", false},
+		{"should return an error if etcd-wrapper rejects the transfer", []string{"-wrapper-url", wrapper.URL, "unknown"}, "POST /transfer-leadership?target=unknown", true},
+		{"should reject more than one target", []string{"-wrapper-url", wrapper.URL, "etcd-main-1", "etcd-main-2"}, "", true},
+	}
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		requests = nil
+		output := &bytes.Buffer{}
+		transferLeadershipWriter = output
+		fs := flag.NewFlagSet("testutil", flag.ContinueOnError)
+		AddTransferLeadershipFlags(fs)
+		g.Expect(fs.Parse(entry.args)).To(Succeed())
+
+		err := TransferLeadership(context.Background(), nil, zaptest.NewLogger(t))
+		if entry.expectedRequest != "" {
+			g.Expect(requests).To(ConsistOf(entry.expectedRequest))
+		} else {
+			g.Expect(requests).To(BeEmpty())
+		}
+		if entry.expectError {
+			g.Expect(err).To(HaveOccurred())
+			continue
+		}
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(output.String()).To(ContainSubstring(`"leader":"etcd-main-1"`))
+	}
+}
@@ -0,0 +1,255 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/bootstrap"
+	"github.com/gardener/etcd-wrapper/internal/secret"
+	"github.com/gardener/etcd-wrapper/internal/types"
+	"github.com/gardener/etcd-wrapper/internal/util"
+
+	"go.etcd.io/etcd/clientv3"
+	"go.etcd.io/etcd/etcdserver/api/v3rpc/rpctypes"
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultWaitUntilReadyWrapperURL is the default base URL of the etcd-wrapper whose readiness endpoint is polled.
+	defaultWaitUntilReadyWrapperURL = "http://localhost:9095"
+	// defaultWaitUntilReadyTimeout is the default time after which waiting for etcd to become ready is given up.
+	defaultWaitUntilReadyTimeout = 5 * time.Minute
+	// defaultWaitUntilReadyInterval is the default interval in which readiness is checked.
+	defaultWaitUntilReadyInterval = 2 * time.Second
+	// waitUntilReadyRequestTimeout is the time after which a single readiness check fails.
+	waitUntilReadyRequestTimeout = 5 * time.Second
+	// waitUntilReadyHealthKey is the key read from etcd to check that it serves linearizable reads.
+	waitUntilReadyHealthKey = "health"
+)
+
+var (
+	// WaitUntilReadyCmd blocks until etcd-wrapper or etcd reports ready.
+	WaitUntilReadyCmd = Command{
+		Name:      "wait-until-ready",
+		UsageLine: "etcd-wrapper wait-until-ready [--wrapper-url=<url> | --endpoints=<url>[,<url>...]] [--timeout=<duration>]",
+		ShortDesc: "Blocks until etcd-wrapper or etcd reports ready or the timeout elapses",
+		LongDesc: `Polls the readiness endpoint of etcd-wrapper, or etcd directly if endpoints are given, until it reports ready, to be
+used as startup gate in other containers of the pod, e.g. as init container, or in jobs. etcd-wrapper is ready once its
+/readyz endpoint responds with status 200. etcd is ready once one of the endpoints serves a linearizable read, which
+requires a leader and quorum.
+
+The command exits with code 0 once ready and with code 20 if the timeout elapses before.
+
+Flags:
+	--wrapper-url
+		Base URL of etcd-wrapper whose /readyz endpoint is polled. Ignored if endpoints are given. Default: http://localhost:9095
+	--wrapper-ca-cert-path
+		File path of the CA certificate bundle to verify the certificate of etcd-wrapper if the wrapper URL uses https. By default the CA certificates of the system are used.
+	--endpoints
+		Comma-separated list of client URLs of etcd which are checked directly instead of etcd-wrapper, e.g. https://etcd-main-client:2379. Can be repeated.
+	--etcd-ca-cert-path
+		File path of the CA certificate bundle to verify the certificates of etcd. Enables TLS towards the endpoints if set.
+	--etcd-server-name
+		Name expected in the TLS certificates of etcd. Defaults to the host of the endpoint.
+	--etcd-client-cert-path
+		File path of the client certificate used to authenticate against etcd.
+	--etcd-client-key-path
+		File path of the key of the client certificate.
+	--etcd-client-key-passphrase-from
+		Reference to the passphrase of an encrypted client key, one of: file:<path> (regular file or named pipe), env:<variable>, stdin:<name>.
+	--etcd-client-username
+		Name of the etcd user to authenticate with when auth is enabled in etcd.
+	--etcd-client-password-from
+		Reference to the password of the etcd user, one of: file:<path> (regular file or named pipe), env:<variable>, stdin:<name>.
+	--disable-proxy-env
+		Ignores the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables. By default proxies configured via these variables are used.
+	--timeout
+		Time after which waiting is given up. Default: 5m
+	--interval
+		Interval in which readiness is checked. Default: 2s`,
+		AddFlags: AddWaitUntilReadyFlags,
+		Run:      WaitUntilReady,
+	}
+	waitUntilReadyWrapperURL        string
+	waitUntilReadyWrapperCACertPath string
+	waitUntilReadyEndpoints         []string
+	waitUntilReadyEtcdCACertPath    string
+	waitUntilReadyTimeout           time.Duration
+	waitUntilReadyInterval          time.Duration
+)
+
+// WaitUntilReadyTimeoutError is returned by the wait-until-ready command if etcd has not become ready within the timeout.
+type WaitUntilReadyTimeoutError struct {
+	// Target is the etcd-wrapper URL or the etcd endpoints which have been checked.
+	Target string
+	// Timeout is the time after which waiting has been given up.
+	Timeout time.Duration
+	// Cause is the reason why the last check did not report ready.
+	Cause error
+}
+
+func (e *WaitUntilReadyTimeoutError) Error() string {
+	return fmt.Sprintf("%s has not become ready within %s: %v", e.Target, e.Timeout, e.Cause)
+}
+
+func (e *WaitUntilReadyTimeoutError) Unwrap() error {
+	return e.Cause
+}
+
+// ExitCode returns the exit code with which the wait-until-ready command exits if etcd has not become ready in time.
+func (e *WaitUntilReadyTimeoutError) ExitCode() int {
+	return types.ExitCodeWaitUntilReadyTimeout
+}
+
+// AddWaitUntilReadyFlags adds flags of the wait-until-ready command to the passed FlagSet.
+func AddWaitUntilReadyFlags(fs *flag.FlagSet) {
+	waitUntilReadyEndpoints = nil
+	fs.StringVar(&waitUntilReadyWrapperURL, "wrapper-url", defaultWaitUntilReadyWrapperURL, "Base URL of etcd-wrapper whose /readyz endpoint is polled")
+	fs.StringVar(&waitUntilReadyWrapperCACertPath, "wrapper-ca-cert-path", "", "File path of the CA certificate bundle to verify the certificate of etcd-wrapper")
+	fs.Var((*stringSliceValue)(&waitUntilReadyEndpoints), "endpoints", "Comma-separated list of client URLs of etcd which are checked directly instead of etcd-wrapper")
+	fs.StringVar(&waitUntilReadyEtcdCACertPath, "etcd-ca-cert-path", "", "File path of the CA certificate bundle to verify the certificates of etcd. Enables TLS if set")
+	addEtcdClientFlags(fs)
+	fs.DurationVar(&waitUntilReadyTimeout, "timeout", defaultWaitUntilReadyTimeout, "Time after which waiting is given up")
+	fs.DurationVar(&waitUntilReadyInterval, "interval", defaultWaitUntilReadyInterval, "Interval in which readiness is checked")
+}
+
+// WaitUntilReady blocks until etcd-wrapper, or etcd if endpoints are given, reports ready. It returns a
+// WaitUntilReadyTimeoutError if the timeout elapses before.
+func WaitUntilReady(ctx context.Context, _ context.CancelFunc, logger *zap.Logger) error {
+	if waitUntilReadyTimeout <= 0 || waitUntilReadyInterval <= 0 {
+		return errors.New("--timeout and --interval must be positive")
+	}
+	target, check, closeFn, err := newReadinessCheck(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	waitCtx, cancelWait := context.WithTimeout(ctx, waitUntilReadyTimeout)
+	defer cancelWait()
+	ticker := time.NewTicker(waitUntilReadyInterval)
+	defer ticker.Stop()
+	var lastErr error
+	for {
+		checkCtx, cancel := context.WithTimeout(waitCtx, waitUntilReadyRequestTimeout)
+		err = check(checkCtx)
+		cancel()
+		if err == nil {
+			logger.Info("ready", zap.String("target", target))
+			return nil
+		}
+		// a check interrupted by the timeout is not more telling than the previous one
+		if lastErr != nil && waitCtx.Err() != nil {
+			err = lastErr
+		}
+		// only changes of the reason are logged so that the log is not flooded while waiting
+		if lastErr == nil || lastErr.Error() != err.Error() {
+			logger.Info("not ready yet", zap.String("target", target), zap.Error(err))
+		}
+		lastErr = err
+		select {
+		case <-waitCtx.Done():
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return &WaitUntilReadyTimeoutError{Target: target, Timeout: waitUntilReadyTimeout, Cause: lastErr}
+		case <-ticker.C:
+		}
+	}
+}
+
+// newReadinessCheck returns the target and a function checking its readiness once, along with a function releasing the
+// resources of the check.
+func newReadinessCheck(ctx context.Context) (string, func(context.Context) error, func(), error) {
+	if len(waitUntilReadyEndpoints) > 0 {
+		client, err := newWaitUntilReadyEtcdClient(ctx)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		closeFn := func() {
+			_ = client.Close()
+		}
+		return strings.Join(waitUntilReadyEndpoints, ","), func(ctx context.Context) error { return checkEtcdReady(ctx, client) }, closeFn, nil
+	}
+	wrapperURL, err := url.Parse(waitUntilReadyWrapperURL)
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("invalid --wrapper-url: %w", err)
+	}
+	readyzURL := wrapperURL.JoinPath("readyz").String()
+	tlsConfig, err := util.CreateTLSConfig(func() bool { return wrapperURL.Scheme == "https" }, wrapperURL.Hostname(), waitUntilReadyWrapperCACertPath, nil)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig, Proxy: util.ProxyFunc(config.DisableProxyEnv)}}
+	return readyzURL, func(ctx context.Context) error { return checkWrapperReady(ctx, client, readyzURL) }, client.CloseIdleConnections, nil
+}
+
+// checkWrapperReady returns an error unless the readiness endpoint of etcd-wrapper responds with status 200.
+func checkWrapperReady(ctx context.Context, client *http.Client, readyzURL string) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, readyzURL, nil)
+	if err != nil {
+		return err
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer util.CloseResponseBody(response)
+	if response.StatusCode != http.StatusOK {
+		reason, _ := io.ReadAll(io.LimitReader(response.Body, 1024))
+		return fmt.Errorf("etcd-wrapper responded with status %s: %s", response.Status, strings.TrimSpace(string(reason)))
+	}
+	return nil
+}
+
+// checkEtcdReady returns an error unless etcd serves a linearizable read, which requires a leader and quorum.
+func checkEtcdReady(ctx context.Context, client *clientv3.Client) error {
+	_, err := client.Get(clientv3.WithRequireLeader(ctx), waitUntilReadyHealthKey)
+	// a permission denied response proves that etcd serves requests.
+	if err != nil && !errors.Is(err, rpctypes.ErrPermissionDenied) {
+		return err
+	}
+	return nil
+}
+
+func newWaitUntilReadyEtcdClient(ctx context.Context) (*clientv3.Client, error) {
+	if err := resolveSecrets(secret.NewResolver(os.Stdin)); err != nil {
+		return nil, err
+	}
+	var tlsConfig *tls.Config
+	if waitUntilReadyEtcdCACertPath != "" {
+		var err error
+		tlsConfig, err = util.CreateTLSConfig(func() bool { return true }, config.EtcdClientTLS.ServerName, waitUntilReadyEtcdCACertPath, &util.KeyPair{
+			CertPath:      config.EtcdClientTLS.CertPath,
+			KeyPath:       config.EtcdClientTLS.KeyPath,
+			KeyPassphrase: config.EtcdClientTLS.KeyPassphrase,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	// the client does not block on dialing so that unreachable endpoints are retried by the checks
+	return clientv3.New(clientv3.Config{
+		Context:     ctx,
+		Endpoints:   waitUntilReadyEndpoints,
+		DialTimeout: waitUntilReadyRequestTimeout,
+		TLS:         tlsConfig,
+		Username:    config.EtcdClientAuth.Username,
+		Password:    config.EtcdClientAuth.Password,
+		DialOptions: util.GRPCProxyDialOptions(config.DisableProxyEnv),
+		LogConfig:   bootstrap.SetupLoggerConfig(types.DefaultLogLevel),
+	})
+}
@@ -0,0 +1,108 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"go.etcd.io/etcd/embed"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestWaitUntilReadyWrapper(t *testing.T) {
+	var checks atomic.Int32
+	wrapper := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/readyz" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if checks.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte("validation of data directory in progress"))
+		}
+	}))
+	defer wrapper.Close()
+	notReady := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer notReady.Close()
+
+	table := []struct {
+		description       string
+		args              []string
+		expectTimeout     bool
+		expectedMinChecks int32
+	}{
+		{"should wait until etcd-wrapper reports ready", []string{"-wrapper-url", wrapper.URL, "-interval", "10ms"}, false, 3},
+		{"should return a timeout error if etcd-wrapper does not report ready in time", []string{"-wrapper-url", notReady.URL, "-interval", "10ms", "-timeout", "100ms"}, true, 0},
+	}
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		fs := flag.NewFlagSet("testutil", flag.ContinueOnError)
+		AddWaitUntilReadyFlags(fs)
+		g.Expect(fs.Parse(entry.args)).To(Succeed())
+
+		err := WaitUntilReady(context.Background(), nil, zaptest.NewLogger(t))
+		if entry.expectTimeout {
+			var timeoutErr *WaitUntilReadyTimeoutError
+			g.Expect(errors.As(err, &timeoutErr)).To(BeTrue())
+			g.Expect(timeoutErr.ExitCode()).To(Equal(20))
+			g.Expect(err.Error()).To(ContainSubstring("503"))
+			continue
+		}
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(checks.Load()).To(BeNumerically(">=", entry.expectedMinChecks))
+	}
+}
+
+func TestWaitUntilReadyEtcd(t *testing.T) {
+	g := NewWithT(t)
+	cfg := embed.NewConfig()
+	cfg.Dir = t.TempDir()
+	cfg.Logger = "zap"
+	cfg.LogOutputs = []string{"/dev/null"}
+	clientURL := url.URL{Scheme: "http", Host: localAddress(g)}
+	peerURL := url.URL{Scheme: "http", Host: localAddress(g)}
+	cfg.ListenClientUrls, cfg.AdvertiseClientUrls = []url.URL{clientURL}, []url.URL{clientURL}
+	cfg.ListenPeerUrls, cfg.AdvertisePeerUrls = []url.URL{peerURL}, []url.URL{peerURL}
+	cfg.InitialCluster = cfg.InitialClusterFromName(cfg.Name)
+
+	t.Log("should wait until etcd is started and serves linearizable reads")
+	fs := flag.NewFlagSet("testutil", flag.ContinueOnError)
+	AddWaitUntilReadyFlags(fs)
+	g.Expect(fs.Parse([]string{"-endpoints", clientURL.String(), "-interval", "50ms", "-timeout", "1m"})).To(Succeed())
+	started := make(chan *embed.Etcd, 1)
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		etcd, err := embed.StartEtcd(cfg)
+		if err != nil {
+			t.Error(err)
+		}
+		started <- etcd
+	}()
+	err := WaitUntilReady(context.Background(), nil, zaptest.NewLogger(t))
+	etcd := <-started
+	g.Expect(etcd).ToNot(BeNil())
+	defer etcd.Close()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	t.Log("should return a timeout error if etcd is not reachable")
+	fs = flag.NewFlagSet("testutil", flag.ContinueOnError)
+	AddWaitUntilReadyFlags(fs)
+	g.Expect(fs.Parse([]string{"-endpoints", "http://" + localAddress(g), "-interval", "50ms", "-timeout", "300ms"})).To(Succeed())
+	err = WaitUntilReady(context.Background(), nil, zaptest.NewLogger(t))
+	var timeoutErr *WaitUntilReadyTimeoutError
+	g.Expect(errors.As(err, &timeoutErr)).To(BeTrue())
+}
@@ -7,11 +7,17 @@ TOOLS_BIN_DIR              := $(TOOLS_DIR)/bin
 GOLANGCI_LINT              := $(TOOLS_BIN_DIR)/golangci-lint
 GOSEC                      := $(TOOLS_BIN_DIR)/gosec
 GO_ADD_LICENSE             := $(TOOLS_BIN_DIR)/addlicense
+PROTOC                     := $(TOOLS_BIN_DIR)/protoc
+PROTOC_GEN_GO              := $(TOOLS_BIN_DIR)/protoc-gen-go
+PROTOC_GEN_GO_GRPC         := $(TOOLS_BIN_DIR)/protoc-gen-go-grpc
 
 # default tool versions
 GOLANGCI_LINT_VERSION ?= v1.64.8
 GOSEC_VERSION ?= v2.22.2
 GO_ADD_LICENSE_VERSION ?= latest
+PROTOC_VERSION ?= v29.3
+PROTOC_GEN_GO_VERSION ?= v1.36.10
+PROTOC_GEN_GO_GRPC_VERSION ?= v1.5.1
 
 export TOOLS_BIN_DIR := $(TOOLS_BIN_DIR)
 export PATH := $(abspath $(TOOLS_BIN_DIR)):$(PATH)
@@ -33,4 +39,13 @@ $(GOSEC): $(call tool_version_file,$(GOSEC),$(GOSEC_VERSION))
 	@GOSEC_VERSION=$(GOSEC_VERSION) $(TOOLS_DIR)/install-gosec.sh
 
 $(GO_ADD_LICENSE):
-	GOBIN=$(abspath $(TOOLS_BIN_DIR)) go install github.com/google/addlicense@$(GO_ADD_LICENSE_VERSION)
\ No newline at end of file
+	GOBIN=$(abspath $(TOOLS_BIN_DIR)) go install github.com/google/addlicense@$(GO_ADD_LICENSE_VERSION)
+
+$(PROTOC): $(call tool_version_file,$(PROTOC),$(PROTOC_VERSION))
+	@PROTOC_VERSION=$(PROTOC_VERSION) $(TOOLS_DIR)/install-protoc.sh
+
+$(PROTOC_GEN_GO): $(call tool_version_file,$(PROTOC_GEN_GO),$(PROTOC_GEN_GO_VERSION))
+	GOBIN=$(abspath $(TOOLS_BIN_DIR)) go install google.golang.org/protobuf/cmd/protoc-gen-go@$(PROTOC_GEN_GO_VERSION)
+
+$(PROTOC_GEN_GO_GRPC): $(call tool_version_file,$(PROTOC_GEN_GO_GRPC),$(PROTOC_GEN_GO_GRPC_VERSION))
+	GOBIN=$(abspath $(TOOLS_BIN_DIR)) go install google.golang.org/grpc/cmd/protoc-gen-go-grpc@$(PROTOC_GEN_GO_GRPC_VERSION)
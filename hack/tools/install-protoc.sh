@@ -0,0 +1,51 @@
+#!/usr/bin/env bash
+#
+# SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+#
+# SPDX-License-Identifier: Apache-2.0
+
+set -e
+
+echo "> Installing protoc"
+
+TOOLS_BIN_DIR=${TOOLS_BIN_DIR:-$(dirname $0)/bin}
+
+version=$PROTOC_VERSION
+case $(uname -s) in
+  Linux)
+    platform="linux"
+    ;;
+  Darwin)
+    platform="osx"
+    ;;
+  *)
+    echo "Unknown platform"
+    exit 1
+    ;;
+esac
+case $(uname -m) in
+  aarch64 | arm64)
+    arch="aarch_64"
+    ;;
+  x86_64)
+    arch="x86_64"
+    ;;
+  *)
+    echo "Unknown architecture"
+    exit 1
+    ;;
+esac
+
+file_name="protoc-${version#v}-${platform}-${arch}.zip"
+
+temp_dir="$(mktemp -d)"
+function cleanup {
+  rm -rf "${temp_dir}"
+}
+trap cleanup EXIT ERR INT TERM
+
+curl -L -o "${temp_dir}/${file_name}" "https://github.com/protocolbuffers/protobuf/releases/download/${version}/${file_name}"
+
+unzip -q -o "${temp_dir}/${file_name}" bin/protoc -d "${temp_dir}"
+mv "${temp_dir}/bin/protoc" $TOOLS_BIN_DIR
+chmod +x "${TOOLS_BIN_DIR}/protoc"
@@ -6,17 +6,34 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"net/http"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/gardener/etcd-wrapper/internal/types"
 
+	"github.com/gardener/etcd-wrapper/internal/audit"
 	"github.com/gardener/etcd-wrapper/internal/bootstrap"
+	"github.com/gardener/etcd-wrapper/internal/brclient"
+	"github.com/gardener/etcd-wrapper/internal/clienttraffic"
+	"github.com/gardener/etcd-wrapper/internal/crashreport"
+	"github.com/gardener/etcd-wrapper/internal/etcdprocess"
+	"github.com/gardener/etcd-wrapper/internal/faultinjection"
+	"github.com/gardener/etcd-wrapper/internal/httpserver"
+	"github.com/gardener/etcd-wrapper/internal/lifecycle"
+	"github.com/gardener/etcd-wrapper/internal/logsampling"
+	"github.com/gardener/etcd-wrapper/internal/logsink"
+	"github.com/gardener/etcd-wrapper/internal/maintenance"
+	"github.com/gardener/etcd-wrapper/internal/reqsample"
+	"github.com/gardener/etcd-wrapper/internal/state"
 	"go.etcd.io/etcd/clientv3"
 	"go.etcd.io/etcd/embed"
+	"go.etcd.io/etcd/version"
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
 )
 
 // Application is a top level struct which serves as an entry point for this application.
@@ -24,50 +41,261 @@ type Application struct {
 	ctx      context.Context
 	cancelFn context.CancelFunc
 	// Config is the application config
-	Config           types.Config
-	etcdInitializer  bootstrap.EtcdInitializer
-	cfg              *embed.Config
-	etcdClient       *clientv3.Client
-	etcd             *embed.Etcd
-	waitReadyTimeout time.Duration
-	logger           *zap.Logger
-	etcdReady        bool // should have only one actor that updates it, queryAndUpdateEtcdReadiness()
-	server           *http.Server
+	Config               types.Config
+	etcdInitializer      bootstrap.EtcdInitializer
+	brClient             brclient.BackupRestoreClient
+	cfg                  *embed.Config
+	etcdClient           *clientv3.Client
+	etcdMu               sync.RWMutex
+	etcd                 *embed.Etcd
+	etcdProcess          *etcdprocess.Process // guarded by etcdMu, nil unless etcd runs as process
+	restartCh            chan struct{}
+	restarts             atomic.Int32
+	restartBudget        *restartBudget
+	maintenance          *maintenance.Scheduler
+	maintenanceHistory   *maintenance.History
+	corruptionAlarm      atomic.Bool
+	proposalBackpressure atomic.Bool
+	applyLag             atomic.Bool
+	learner              atomic.Bool
+	validationMu         sync.Mutex
+	pendingValidation    brclient.ValidationType
+	lastValidation       *ValidationResult
+	clientURLsMu         sync.RWMutex
+	clientURLsErr        error
+	readinessGatesMu     sync.RWMutex
+	readinessGatesErr    error
+	waitReadyTimeout     time.Duration
+	logger               *zap.Logger
+	etcdReady            atomic.Bool // should have only one actor that updates it, queryAndUpdateEtcdReadiness()
+	server               *httpserver.Server
+	lifecycle            *lifecycle.Manager
+	monitors             sync.WaitGroup // goroutines started by startMonitors
+	auditLogger          audit.Logger
+	wrapperLogSink       *logsink.Sink // nil if etcd-wrapper logs to its default output
+	etcdLogSink          *logsink.Sink // nil if etcd logs to the log outputs of the etcd configuration
+	stateMachine         *state.Machine
+	crashReporter        *crashreport.Reporter
+	requestSampler       *reqsample.Sampler     // nil if request sampling is disabled
+	clientTraffic        *clienttraffic.Tracker // nil if client traffic tracking is disabled
+	heartbeatMu          sync.Mutex
+	externalClientServer *grpc.Server // guarded by etcdMu
+	startedAt            time.Time
+	exitReason           atomic.Value             // string, why the embedded etcd has stopped
+	faultInjector        *faultinjection.Injector // nil if fault injection is disabled
+
+	// warmedUpEtcd is the embedded etcd which has last been warmed up. It is only accessed by queryAndUpdateEtcdReadiness.
+	warmedUpEtcd *embed.Etcd
+	// maintenanceLeaderElection indicates whether a maintenance leader is elected, maintenanceLeader whether it is
+	// this etcd-wrapper.
+	maintenanceLeaderElection atomic.Bool
+	maintenanceLeader         atomic.Bool
+	// volumeSizeBytes is the size of the data volume last seen, pendingQuotaBackendBytes the backend quota derived from
+	// a resize of the data volume which is applied at the next restart of etcd.
+	volumeSizeBytes          atomic.Int64
+	pendingQuotaBackendBytes atomic.Int64
+	// quotaExhaustionPredicted indicates that the DB size is projected to reach the backend quota within the horizon.
+	quotaExhaustionPredicted atomic.Bool
+	// healthScoreMu guards healthScore, which is nil till etcd has been probed for the health score.
+	healthScoreMu sync.Mutex
+	healthScore   *HealthScore
+	// etcdConfigChanged indicates that the etcd configuration served by backup-restore has changed since etcd-wrapper
+	// has been started, so that etcd does not run with the desired configuration.
+	etcdConfigChanged atomic.Bool
+	// lastBackupMu guards lastBackup and lastBackupErr, the creation time of the latest snapshot and the error of its
+	// last fetch from backup-restore.
+	lastBackupMu  sync.RWMutex
+	lastBackup    time.Time
+	lastBackupErr error
+	// postRestoreMaintenancePending indicates that the compaction and defragmentation after a restoration have not
+	// finished yet.
+	postRestoreMaintenancePending atomic.Bool
+	// hibernationMu serializes hibernations, hibernated indicates that the member has hibernated and its data directory
+	// has been marked as safe to delete. hibernating fails the readiness while client traffic is stopped for a
+	// hibernation. The result of verifyHibernation is sent to hibernationErrCh once etcd has stopped.
+	hibernationMu    sync.Mutex
+	hibernated       atomic.Bool
+	hibernating      atomic.Bool
+	hibernationErrCh chan error
+	// leadershipTransferMu serializes leadership transfers requested via the leadership transfer endpoint.
+	leadershipTransferMu sync.Mutex
+	// etcdClusterID is the ID of the etcd cluster, stored as string once etcd has been ready, with which the log
+	// entries of etcd are enriched.
+	etcdClusterID atomic.Value
 }
 
 // NewApplication initializes and returns an application struct
 func NewApplication(ctx context.Context, cancelFn context.CancelFunc, config types.Config, waitReadyTimeout time.Duration, logger *zap.Logger) (*Application, error) {
+	if err := config.BackupRestore.ResolveHostPort(); err != nil {
+		return nil, err
+	}
 	logger.Info("Initializing application", zap.Any("config", config))
-	etcdInitializer, err := bootstrap.NewEtcdInitializer(&config.BackupRestore, logger)
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	wrapperLogSink, err := openLogSink(config.LogSinks.Wrapper)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open wrapper log outputs: %w", err)
+	}
+	if wrapperLogSink != nil {
+		logger = redirectLogger(logger, wrapperLogSink)
+	}
+	etcdLogSink, err := openLogSink(config.LogSinks.Etcd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open etcd log outputs: %w", err)
+	}
+	crashLogs := crashreport.NewLogBuffer(config.CrashReport.LogLines)
+	if config.CrashReport.Dir != "" {
+		logger = crashLogs.Tee(logger)
+	}
+	var logSampler *logsampling.Sampler
+	if config.LogSamplingInterval > 0 {
+		logSampler = logsampling.NewSampler(config.LogSamplingInterval)
+		logger = logSampler.Wrap(logger)
+	}
+	logProxyEnv(config.DisableProxyEnv, logger)
+	brDialer := config.DNS.NewDialer()
+	var faultInjector *faultinjection.Injector
+	if config.FaultInjection {
+		logger.Warn("fault injection is enabled, faults can be injected via /debug/faults. For development only")
+		faultInjector = faultinjection.NewInjector()
+		brDialer.SetConnWrapper(faultInjector.WrapSidecarConn)
+	}
+	brClient, err := brclient.NewDefaultClient(config.BackupRestore, config.DisableProxyEnv, brDialer)
 	if err != nil {
 		return nil, err
 	}
-	return &Application{
-		ctx:              ctx,
-		cancelFn:         cancelFn,
-		Config:           config,
-		etcdInitializer:  etcdInitializer,
-		waitReadyTimeout: waitReadyTimeout,
-		logger:           logger,
-	}, nil
+	auditLogger, err := audit.NewLogger(config.AuditLog.Path, config.AuditLog.MaxSizeBytes, config.AuditLog.MaxBackups)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create audit logger: %w", err)
+	}
+	var maintenanceWindow *maintenance.Window
+	if config.MaintenanceWindow.Schedule != "" {
+		if maintenanceWindow, err = maintenance.NewWindow(config.MaintenanceWindow.Schedule, config.MaintenanceWindow.Duration); err != nil {
+			return nil, err
+		}
+	}
+	maintenanceHistorySize := config.MaintenanceHistory.Size
+	if maintenanceHistorySize == 0 {
+		maintenanceHistorySize = types.DefaultMaintenanceHistorySize
+	}
+	maintenanceHistory, err := maintenance.LoadHistory(config.MaintenanceHistory.Path, maintenanceHistorySize)
+	if err != nil {
+		logger.Error("failed to load maintenance history, starting with an empty history", zap.Error(err))
+	}
+	stateMachine := state.NewMachine(logger)
+	a := &Application{
+		ctx:                ctx,
+		cancelFn:           cancelFn,
+		Config:             config,
+		etcdInitializer:    bootstrap.NewEtcdInitializerWithClient(brClient, &config, stateMachine, auditLogger, logger),
+		brClient:           brClient,
+		waitReadyTimeout:   waitReadyTimeout,
+		logger:             logger,
+		auditLogger:        auditLogger,
+		wrapperLogSink:     wrapperLogSink,
+		etcdLogSink:        etcdLogSink,
+		stateMachine:       stateMachine,
+		restartCh:          make(chan struct{}),
+		hibernationErrCh:   make(chan error, 1),
+		restartBudget:      newRestartBudget(config.RestartBudget.MaxRestarts, config.RestartBudget.Window, time.Now()),
+		maintenance:        maintenance.NewScheduler(maintenanceWindow, logger),
+		maintenanceHistory: maintenanceHistory,
+		startedAt:          time.Now(),
+		faultInjector:      faultInjector,
+		lifecycle:          lifecycle.NewManager(ctx, logger),
+	}
+	if len(config.ReadinessGates.Gates) > 0 {
+		a.readinessGatesErr = errReadinessGatesNotEvaluated
+	}
+	if config.RequestSampling.Fraction > 0 {
+		a.requestSampler = reqsample.NewSampler(config.RequestSampling.Fraction, config.RequestSampling.BufferSize, config.RequestSampling.PrefixDepth)
+	}
+	if config.ClientTraffic.SampleInterval > 0 {
+		a.clientTraffic = clienttraffic.NewTracker()
+	}
+	a.crashReporter = crashreport.NewReporter(config.CrashReport.Dir, crashLogs, config, a.crashStatus, logger)
+	if logSampler != nil {
+		a.crashReporter.Go("log-sampling", func() { logSampler.Run(ctx) })
+	}
+	// Reflect every state transition in the state file which is read by other containers of the pod
+	stateMachine.Subscribe(func(state.Transition) { a.writeStateFile() })
+	a.writeStateFile()
+	// Reflect state transitions in the heartbeat file without waiting for the next heartbeat
+	stateMachine.Subscribe(func(state.Transition) { a.writeHeartbeatFile() })
+	return a, nil
 }
 
 // Setup sets up etcd by triggering initialization of the etcd DB.
-func (a *Application) Setup() error {
+func (a *Application) Setup() (err error) {
+	defer func() {
+		if err != nil {
+			a.reportExit(err)
+		}
+	}()
+	// Prove liveness to external monitors for the whole lifetime of etcd-wrapper, including the bootstrap
+	a.crashReporter.Go("heartbeat", a.writeHeartbeats)
+
+	// Follow backup-restore once it has moved to another host and port
+	a.crashReporter.Go("backup-restore-host-port", a.watchBackupRestoreHostPort)
+
 	// Set up etcd
 	cfg, err := a.etcdInitializer.Run(a.ctx)
 	if err != nil {
+		a.transitionTo(state.Failed)
+		return err
+	}
+	if a.Config.EtcdProcess.IsProcessMode() {
+		a.logger.Warn("etcd runs as process, adjustments of the etcd configuration by etcd-wrapper are not applied",
+			zap.String("etcdBinaryPath", a.Config.EtcdProcess.BinaryPath), zap.String("etcdConfigPath", a.etcdInitializer.EtcdConfigFilePath()))
+	} else if err = a.verifyEtcdVersion(cfg.Dir); err != nil {
+		a.etcdInitializer.RecordOutcome(bootstrap.OutcomeFailed)
+		a.transitionTo(state.Failed)
+		return err
+	}
+	a.applyCorruptCheckConfig(cfg)
+	a.applyPeerTLSServerName(cfg)
+	a.applyMemoryLimits(cfg)
+	a.applyCPULimits(cfg)
+	a.applyServerTuning(cfg)
+	a.applyWALDir(cfg)
+	a.applyClientUnixSocket(cfg)
+	a.applyEtcdLogging(cfg)
+	if err = a.applyInitialClusterToken(cfg); err != nil {
+		a.etcdInitializer.RecordOutcome(bootstrap.OutcomeFailed)
+		a.transitionTo(state.Failed)
 		return err
 	}
 	a.cfg = cfg
+	a.clearHibernationMarker()
+	if err = a.validateTLSCertificates(); err != nil {
+		a.etcdInitializer.RecordOutcome(bootstrap.OutcomeFailed)
+		a.transitionTo(state.Failed)
+		return err
+	}
+	if err = a.prepareVolumes(); err != nil {
+		a.etcdInitializer.RecordOutcome(bootstrap.OutcomeFailed)
+		a.transitionTo(state.Failed)
+		return err
+	}
+	a.applyVolumeSize()
+	a.checkRevisionWatermark()
+	if err = a.verifyClusterID(); err != nil {
+		a.etcdInitializer.RecordOutcome(bootstrap.OutcomeFailed)
+		a.transitionTo(state.Failed)
+		return err
+	}
+
+	a.logEffectiveConfig()
 
 	syscall.Umask(0077)
 	return nil
 }
 
 // Start sets up readiness probe and starts an embedded etcd.
-func (a *Application) Start() error {
-	var err error
+func (a *Application) Start() (err error) {
+	defer a.crashReporter.Recover("start")
+	defer func() { a.reportExit(err) }()
 
 	// Change file permissions for files previously created without umask 0077
 	// TODO (shreyas-s-rao): remove this temporary code in etcd-wrapper v0.8.0
@@ -75,48 +303,195 @@ func (a *Application) Start() error {
 		return fmt.Errorf("failed to change file permissions: %w", err)
 	}
 
-	// Create etcd client for readiness probe
-	cli, err := a.createEtcdClient()
-	if err != nil {
+	// Start the components in order and stop them in reverse order once etcd has stopped for good
+	defer a.stopComponents()
+	if err = a.lifecycle.Start(a.components()...); err != nil {
 		return err
 	}
-	a.etcdClient = cli
-	defer a.Close()
 
+	a.startPostRestoreMaintenance()
+	for {
+		// Create embedded etcd and start.
+		a.transitionTo(state.StartingEtcd)
+		if err = a.startEtcd(); err != nil {
+			a.etcdInitializer.RecordOutcome(bootstrap.OutcomeFailed)
+			a.transitionTo(state.Failed)
+			return err
+		}
+		// Delete exit code file after etcd starts successfully
+		if err = bootstrap.CleanupExitCode(types.DefaultExitCodeFilePath); err != nil {
+			a.logger.Warn("failed to clean-up last captured exit code", zap.Error(err))
+		}
+		a.selfTestAdvertisedClientURLs()
+		if a.restarts.Load() == 0 {
+			a.writeRestoreMarker()
+			if a.postRestoreMaintenancePending.Load() {
+				a.crashReporter.Go("post-restore-maintenance", a.runPostRestoreMaintenance)
+			}
+		}
+		if !a.waitForEtcdStop() {
+			a.transitionTo(state.Stopping)
+			a.recordRevisionWatermarkOnShutdown()
+			a.snapshotOrHibernateOnShutdown()
+			return nil
+		}
+		if !a.restartBudget.take(time.Now()) {
+			a.logger.Error("not restarting embedded etcd, restart budget is exhausted")
+			a.transitionTo(state.Failed)
+			return a.restartBudget.exhaustedError()
+		}
+		a.logger.Info("restarting embedded etcd")
+		a.closeEtcd()
+		a.applyPendingQuotaBackend()
+		if err = a.runPendingValidation(); err != nil {
+			if a.ctx.Err() != nil {
+				a.transitionTo(state.Stopping)
+				return nil
+			}
+			a.transitionTo(state.Failed)
+			return err
+		}
+		a.restarts.Add(1)
+	}
+}
+
+// startMonitors starts the goroutines watching etcd, its volumes and backup-restore. They stop once the application
+// context is cancelled.
+func (a *Application) startMonitors() {
 	// Setup readiness probe
-	go a.queryAndUpdateEtcdReadiness()
+	a.goMonitor("readiness", a.queryAndUpdateEtcdReadiness)
 
-	// start HTTP server to serve endpoints
-	go a.startHTTPServer()
-	defer func() {
-		if err := a.stopHTTPServer(); err != nil {
-			a.logger.Error("unable to stop HTTP server: %v",
-				zap.Error(err),
-			)
-		}
-	}()
+	// Repeat a failed self-test of the advertised client URLs which keeps etcd-wrapper unready
+	a.goMonitor("client-urls", a.watchClientURLs)
 
-	// Create embedded etcd and start.
-	if err = a.startEtcd(); err != nil {
-		return err
-	}
-	// Delete exit code file after etcd starts successfully
-	if err = bootstrap.CleanupExitCode(types.DefaultExitCodeFilePath); err != nil {
-		a.logger.Warn("failed to clean-up last captured exit code", zap.Error(err))
-	}
+	// Alert on corruption alarms raised by the corruption checks of etcd
+	a.goMonitor("corruption-alarms", a.watchCorruptionAlarms)
+
+	// Surface overload of etcd via raft proposal backpressure
+	a.goMonitor("proposal-backpressure", a.watchProposalBackpressure)
+
+	// Catch stalls of the apply loop from the divergence of the committed and applied raft index
+	a.goMonitor("apply-lag", a.watchApplyLag)
+
+	// Warn about clock skew to other members which breaks lease semantics
+	a.goMonitor("clock-skew", a.watchClockSkew)
+
+	// Check the reachability of the peer URLs of all other members to catch network policies blocking peer traffic
+	a.goMonitor("peer-connectivity", a.watchPeerConnectivity)
+
+	// Ensure that a hot-standby member is never promoted to a voting member unnoticed
+	a.goMonitor("hot-standby", a.watchHotStandby)
+
+	// Probe the fsync latency of the data and WAL volumes, slow disks being the most common cause of leader elections
+	a.goMonitor("disk-latency", a.watchDiskLatency)
+
+	// Track the age of the latest snapshot which decides whether the member is safe to disrupt
+	a.goMonitor("backup-freshness", a.watchBackupFreshness)
+
+	// Evaluate the readiness gates which must pass in addition to the readiness of etcd
+	a.goMonitor("readiness-gates", a.watchReadinessGates)
+
+	// Warn about write requests approaching the request limits of etcd before clients fail
+	a.goMonitor("request-size", a.watchRequestSizes)
+
+	// Compact the etcd history once revisions or DB size have grown beyond the configured thresholds
+	a.goMonitor("compaction", a.watchCompaction)
+
+	// Detect resizes of the data volume from which the backend quota may be derived
+	a.goMonitor("volume-size", a.watchVolumeSize)
+
+	// Predict the exhaustion of the backend quota from the growth of the DB size
+	a.goMonitor("db-size-trend", a.watchDBSizeTrend)
+
+	// Report to backup-restore whether writes are at risk of being rejected due to the backend quota
+	a.goMonitor("quota-advisory", a.watchQuotaAdvisory)
+
+	// Persist the revision of etcd to verify the revision of a restored data directory against it
+	a.goMonitor("revision-watermark", a.watchRevisionWatermark)
+
+	// Smooth periodic probes of etcd into a single health score
+	a.goMonitor("health-score", a.watchHealthScore)
+
+	// Report the write churn to backup-restore to adapt the period of delta snapshots
+	a.goMonitor("churn", a.watchChurn)
+
+	// Poll backup-restore for changes of the etcd configuration
+	a.goMonitor("etcd-config-poll", a.watchEtcdConfig)
+
+	// Sample the number and size of keys per configured key prefix
+	a.goMonitor("prefix-usage", a.watchPrefixUsage)
+
+	// Compute the RPC rate per client to locate clients which put an unexpected load onto etcd
+	a.goMonitor("client-traffic", a.sampleClientTraffic)
+
+	// Reconcile etcd users and roles with the declarative auth spec
+	a.goMonitor("auth-sync", a.runAuthSync)
+
+	// Restart members one at a time once the peer CA bundle has been rotated
+	a.goMonitor("peer-ca-rotation", a.watchPeerCARotation)
+
+	// Reload the CA bundle of backup-restore once it has been rotated
+	a.goMonitor("backup-restore-ca-reload", a.watchBackupRestoreCABundle)
+
+	// Export the expiry of all certificates and warn in time before they expire
+	a.goMonitor("certificate-expiry", a.watchCertificateExpiry)
+
+	// Compete for the lease which elects the etcd-wrapper orchestrating cluster-wide maintenance
+	a.goMonitor("maintenance-leader", a.runMaintenanceLeaderElection)
+
+	// Defragment the etcd backend in turn with the other members, or orchestrated by the maintenance leader, at every
+	// scheduled defragmentation round
+	a.goMonitor("defragmentation", a.watchDefragmentation)
+
+	// Run disruptive operations requested outside of the maintenance window once it opens
+	a.goMonitor("maintenance", func() { a.maintenance.Run(a.ctx) })
+}
 
-	// block till application context is cancelled, or there is a notification on etcd.Server.StopNotify channel
-	// or there is an error notification on etcd.Err channel
+// waitForEtcdStop blocks till application context is cancelled, or there is a notification on etcd.Server.StopNotify channel
+// or there is an error notification on etcd.Err channel or a restart has been requested. It returns true only if a restart has been requested.
+func (a *Application) waitForEtcdStop() bool {
+	if process := a.getEtcdProcess(); process != nil {
+		return a.waitForEtcdProcessStop(process)
+	}
+	etcd := a.getEtcd()
 	select {
 	case <-a.ctx.Done():
 		a.logger.Error("application context has been cancelled", zap.Error(a.ctx.Err()))
-	case <-a.etcd.Server.StopNotify():
+	case <-etcd.Server.StopNotify():
 		a.logger.Error("etcd server has been aborted, received notification on StopNotify channel")
-	case err = <-a.etcd.Err():
+		a.setExitReason("etcd server has been aborted")
+	case err := <-etcd.Err():
 		a.logger.Error("error received on etcd Err channel", zap.Error(err))
+		a.setExitReason(fmt.Sprintf("etcd has failed: %v", err))
+	case <-a.restartCh:
+		return true
 	}
+	return false
+}
 
-	return nil
+// Stop stops the embedded etcd and cancels the application context which causes Start to return.
+func (a *Application) Stop() {
+	a.transitionTo(state.Stopping)
+	_ = audit.Record(a.auditLogger, audit.OperationStop, "", func() error {
+		a.cancelContext()
+		return nil
+	})
+}
+
+// Restart stops the running embedded etcd and starts it again using the same configuration. Start must have been
+// called before calling Restart.
+func (a *Application) Restart() error {
+	return audit.Record(a.auditLogger, audit.OperationRestart, "", func() error {
+		if !a.etcdRunning() {
+			return errors.New("etcd is not running")
+		}
+		select {
+		case a.restartCh <- struct{}{}:
+			return nil
+		case <-a.ctx.Done():
+			return a.ctx.Err()
+		}
+	})
 }
 
 // Close closes resources(e.g. etcd client) and cancels the context if not already done so.
@@ -124,8 +499,9 @@ func (a *Application) Close() {
 	if err := a.etcdClient.Close(); err != nil {
 		a.logger.Error("failed to close etcd client", zap.Error(err))
 	}
-	if a.etcd != nil {
-		a.etcd.Close()
+	a.closeEtcd()
+	if err := a.auditLogger.Close(); err != nil {
+		a.logger.Error("failed to close audit logger", zap.Error(err))
 	}
 	a.cancelContext()
 }
@@ -139,21 +515,78 @@ func (a *Application) cancelContext() {
 
 func (a *Application) startEtcd() error {
 	// TODO StartEtcd returns an Etcd object. In future we should use that to listen on leadership change notifications (when we move to a version of etcd which exposes the channel).
+	if err := a.runPreStartHook(); err != nil {
+		return err
+	}
+	if a.Config.EtcdProcess.IsProcessMode() {
+		return a.startEtcdProcess()
+	}
 	etcd, err := embed.StartEtcd(a.cfg)
 	if err != nil {
 		return err
 	}
+	if err = a.restrictClientUnixSocket(); err != nil {
+		etcd.Close()
+		return err
+	}
 
 	// wait till the etcd server notifies that it is ready, or if an abrupt stop has happened which is notified
-	// via etcd.Server.Notify or there is a timeout waiting for the etcd server to start.
+	// via etcd.Server.Notify or there is a timeout waiting for the etcd server to start. A zero timeout waits forever.
+	var readyTimeoutCh <-chan time.Time
+	if a.waitReadyTimeout > 0 {
+		readyTimeoutCh = time.After(a.waitReadyTimeout)
+	}
 	select {
 	case <-etcd.Server.ReadyNotify():
 		a.logger.Info("etcd server is now ready to serve client requests")
+		a.transitionTo(state.Ready)
+		a.etcdInitializer.RecordOutcome(bootstrap.OutcomeSucceeded)
+		a.recordEtcdVersion(version.Version)
+		a.etcdClusterID.Store(etcd.Server.Cluster().ID().String())
+		a.recordMemberIdentity(etcd)
+		a.pinClusterID(etcd)
+		a.crashReporter.Go("post-ready-hook", func() { a.runPostReadyHook(etcd) })
+		if err = a.startExternalClientListener(etcd); err != nil {
+			etcd.Close()
+			return err
+		}
+		a.applyPeerConnectionFault(etcd)
 	case <-etcd.Server.StopNotify():
 		a.logger.Error("etcd server has been aborted, received notification on StopNotify channel")
-	case <-time.After(a.waitReadyTimeout):
+	case <-readyTimeoutCh:
 		a.logger.Error("timeout waiting for ReadyNotify signal, aborting start of etcd")
+		etcd.Close()
+		return &bootstrap.PhaseTimeoutError{Phase: bootstrap.PhaseEtcdReady, Timeout: a.waitReadyTimeout}
 	}
+	a.etcdMu.Lock()
 	a.etcd = etcd
+	a.etcdMu.Unlock()
 	return nil
 }
+
+// transitionTo transitions the state machine to the given state, logging invalid transitions.
+func (a *Application) transitionTo(s state.State) {
+	if err := a.stateMachine.TransitionTo(s); err != nil {
+		a.logger.Error("failed to transition state", zap.Error(err))
+	}
+}
+
+func (a *Application) getEtcd() *embed.Etcd {
+	a.etcdMu.RLock()
+	defer a.etcdMu.RUnlock()
+	return a.etcd
+}
+
+func (a *Application) closeEtcd() {
+	a.etcdMu.Lock()
+	defer a.etcdMu.Unlock()
+	a.stopExternalClientListener()
+	if a.etcd != nil {
+		a.etcd.Close()
+		a.etcd = nil
+	}
+	if a.etcdProcess != nil {
+		a.etcdProcess.Stop(a.Config.EtcdProcess.StopGracePeriod)
+		a.etcdProcess = nil
+	}
+}
@@ -0,0 +1,99 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/metrics"
+
+	"go.uber.org/zap"
+)
+
+const applyLagCheckInterval = 5 * time.Second
+
+// applyLagDetector detects a sustained divergence between the raft committed index and the applied index of the local
+// member, which indicates that the apply loop of etcd is stalled or cannot keep up with the committed entries.
+type applyLagDetector struct {
+	threshold         uint64
+	sustainedDuration time.Duration
+	// since is the time since when the lag is at or above the threshold, zero if it is currently below.
+	since       time.Time
+	lastApplied uint64
+}
+
+// observe records the committed and applied index observed at now. It returns whether the lag has been at or above the
+// threshold for at least the sustained duration, the lag and the number of entries applied since the last observation.
+func (d *applyLagDetector) observe(now time.Time, committed, applied uint64) (bool, uint64, uint64) {
+	var lag, appliedSinceLast uint64
+	if committed > applied {
+		lag = committed - applied
+	}
+	if applied > d.lastApplied {
+		appliedSinceLast = applied - d.lastApplied
+	}
+	d.lastApplied = applied
+	if lag < d.threshold {
+		d.since = time.Time{}
+		return false, lag, appliedSinceLast
+	}
+	if d.since.IsZero() {
+		d.since = now
+	}
+	return now.Sub(d.since) >= d.sustainedDuration, lag, appliedSinceLast
+}
+
+// watchApplyLag periodically compares the raft committed index with the applied index of the embedded etcd to detect
+// stalls of the apply loop early. It stops when the application context is cancelled.
+func (a *Application) watchApplyLag() {
+	if a.Config.ApplyLag.Threshold == 0 {
+		return
+	}
+	detector := &applyLagDetector{
+		threshold:         a.Config.ApplyLag.Threshold,
+		sustainedDuration: a.Config.ApplyLag.SustainedDuration,
+	}
+	ticker := time.NewTicker(applyLagCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-ticker.C:
+			a.checkApplyLag(detector)
+		}
+	}
+}
+
+// checkApplyLag reads the committed and applied index of the embedded etcd and records whether the lag is sustained.
+func (a *Application) checkApplyLag(detector *applyLagDetector) {
+	etcd := a.getEtcd()
+	if etcd == nil {
+		return
+	}
+	committed, applied := etcd.Server.CommittedIndex(), etcd.Server.AppliedIndex()
+	active, lag, appliedSinceLast := detector.observe(time.Now(), committed, applied)
+	metrics.ApplyLag.Set(float64(lag))
+	if active {
+		a.logger.Warn("sustained divergence between committed and applied raft index detected, the apply loop of etcd is stalled or cannot keep up; "+
+			"etcd rejects proposals once the lag exceeds 5000 entries. Check the backend commit latency (etcd_disk_backend_commit_duration_seconds), "+
+			"slow applies (etcd_server_slow_apply_total) and expensive requests such as large ranges or transactions",
+			zap.Uint64("committedIndex", committed),
+			zap.Uint64("appliedIndex", applied),
+			zap.Uint64("lag", lag),
+			zap.Uint64("appliedSinceLastCheck", appliedSinceLast),
+			zap.Bool("stalled", appliedSinceLast == 0),
+			zap.Time("since", detector.since))
+	} else if a.applyLag.Load() {
+		a.logger.Info("divergence between committed and applied raft index has been resolved", zap.Uint64("lag", lag))
+	}
+	a.applyLag.Store(active)
+	value := 0.0
+	if active {
+		value = 1
+	}
+	metrics.ApplyLagSustained.Set(value)
+}
@@ -0,0 +1,64 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestApplyLagDetector(t *testing.T) {
+	start := time.Now()
+	type observation struct {
+		offset                   time.Duration
+		committed                uint64
+		applied                  uint64
+		expectActive             bool
+		expectedLag              uint64
+		expectedAppliedSinceLast uint64
+	}
+	table := []struct {
+		description  string
+		observations []observation
+	}{
+		{"should not report lag below threshold", []observation{
+			{0, 1000, 500, false, 500, 500},
+			{time.Minute, 2000, 1001, false, 999, 501},
+		}},
+		{"should report lag only once it is sustained above threshold", []observation{
+			{0, 2000, 1000, false, 1000, 1000},
+			{20 * time.Second, 3000, 1500, false, 1500, 500},
+			{30 * time.Second, 3500, 2000, true, 1500, 500},
+			{40 * time.Second, 3500, 3000, false, 500, 1000},
+		}},
+		{"should report a stalled apply loop", []observation{
+			{0, 2000, 1000, false, 1000, 1000},
+			{30 * time.Second, 2500, 1000, true, 1500, 0},
+		}},
+		{"should restart the sustained duration once the lag has dropped below threshold", []observation{
+			{0, 2000, 1000, false, 1000, 1000},
+			{20 * time.Second, 2000, 1500, false, 500, 500},
+			{30 * time.Second, 3000, 1500, false, 1500, 0},
+			{60 * time.Second, 3000, 1500, true, 1500, 0},
+		}},
+		{"should not report negative lag when applied index is ahead of the observed committed index", []observation{
+			{0, 1000, 1001, false, 0, 1001},
+		}},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		detector := &applyLagDetector{threshold: 1000, sustainedDuration: 30 * time.Second}
+		for _, o := range entry.observations {
+			active, lag, appliedSinceLast := detector.observe(start.Add(o.offset), o.committed, o.applied)
+			g.Expect(active).To(Equal(o.expectActive))
+			g.Expect(lag).To(Equal(o.expectedLag))
+			g.Expect(appliedSinceLast).To(Equal(o.expectedAppliedSinceLast))
+		}
+	}
+}
@@ -0,0 +1,21 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"github.com/gardener/etcd-wrapper/internal/authsync"
+
+	"go.uber.org/zap"
+)
+
+// runAuthSync reconciles the users, roles and permissions of etcd with the configured auth spec file on start and
+// whenever the file changes. It is a no-op if no auth spec file has been configured.
+func (a *Application) runAuthSync() {
+	if a.Config.AuthSync.SpecPath == "" {
+		return
+	}
+	a.logger.Info("Starting reconciliation of etcd auth state", zap.String("specPath", a.Config.AuthSync.SpecPath), zap.Duration("interval", a.Config.AuthSync.Interval))
+	authsync.NewSyncer(a.etcdClient.Auth, a.Config.AuthSync.SpecPath, a.auditLogger, a.logger).Run(a.ctx, a.Config.AuthSync.Interval)
+}
@@ -0,0 +1,124 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/metrics"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+const (
+	proposalBackpressureCheckInterval = 10 * time.Second
+	proposalsPendingMetricName        = "etcd_server_proposals_pending"
+	proposalsFailedMetricName         = "etcd_server_proposals_failed_total"
+)
+
+// backpressureDetector detects sustained raft proposal backpressure from the number of pending and failed proposals.
+type backpressureDetector struct {
+	pendingThreshold  float64
+	sustainedDuration time.Duration
+	// since is the time since when backpressure is observed, zero if it is currently not observed.
+	since      time.Time
+	lastFailed float64
+}
+
+// observe records the number of pending proposals and the total number of failed proposals observed at now.
+// Backpressure is observed if the pending proposals reach the threshold or if proposals have failed since the last
+// observation. It returns whether backpressure has been observed for at least the sustained duration, and the number
+// of proposals which have failed since the last observation.
+func (d *backpressureDetector) observe(now time.Time, pending, failedTotal float64) (bool, float64) {
+	failed := failedTotal - d.lastFailed
+	if failed < 0 {
+		// counters are reset when the embedded etcd is restarted.
+		failed = failedTotal
+	}
+	d.lastFailed = failedTotal
+	if pending < d.pendingThreshold && failed == 0 {
+		d.since = time.Time{}
+		return false, failed
+	}
+	if d.since.IsZero() {
+		d.since = now
+	}
+	return now.Sub(d.since) >= d.sustainedDuration, failed
+}
+
+// watchProposalBackpressure periodically checks the raft proposal metrics of the embedded etcd for sustained
+// backpressure. It stops when the application context is cancelled.
+func (a *Application) watchProposalBackpressure() {
+	detector := &backpressureDetector{
+		pendingThreshold:  float64(a.Config.ProposalBackpressure.PendingThreshold),
+		sustainedDuration: a.Config.ProposalBackpressure.SustainedDuration,
+	}
+	ticker := time.NewTicker(proposalBackpressureCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-ticker.C:
+			a.checkProposalBackpressure(detector, prometheus.DefaultGatherer)
+		}
+	}
+}
+
+// checkProposalBackpressure reads the raft proposal metrics from gatherer and records whether there is sustained backpressure.
+func (a *Application) checkProposalBackpressure(detector *backpressureDetector, gatherer prometheus.Gatherer) {
+	pending, failedTotal, err := readProposalMetrics(gatherer)
+	if err != nil {
+		a.logger.Error("failed to read raft proposal metrics", zap.Error(err))
+		return
+	}
+	active, failed := detector.observe(time.Now(), pending, failedTotal)
+	if active {
+		a.logger.Warn("sustained raft proposal backpressure detected, etcd is overloaded and clients may soon see errors; "+
+			"check the disk latency of the WAL and backend (etcd_disk_wal_fsync_duration_seconds, etcd_disk_backend_commit_duration_seconds), "+
+			"the network latency to peers and the request rate of clients",
+			zap.Float64("proposalsPending", pending),
+			zap.Float64("proposalsFailedSinceLastCheck", failed),
+			zap.Time("since", detector.since))
+	} else if a.proposalBackpressure.Load() {
+		a.logger.Info("raft proposal backpressure has been resolved")
+	}
+	a.proposalBackpressure.Store(active)
+	value := 0.0
+	if active {
+		value = 1
+	}
+	metrics.ProposalBackpressure.Set(value)
+}
+
+// readProposalMetrics reads the number of pending proposals and the total number of failed proposals from gatherer.
+func readProposalMetrics(gatherer prometheus.Gatherer) (float64, float64, error) {
+	metricFamilies, err := gatherer.Gather()
+	if err != nil {
+		return 0, 0, err
+	}
+	var (
+		pending, failed           float64
+		foundPending, foundFailed bool
+	)
+	for _, mf := range metricFamilies {
+		if len(mf.GetMetric()) == 0 {
+			continue
+		}
+		switch mf.GetName() {
+		case proposalsPendingMetricName:
+			pending, foundPending = mf.GetMetric()[0].GetGauge().GetValue(), true
+		case proposalsFailedMetricName:
+			failed, foundFailed = mf.GetMetric()[0].GetCounter().GetValue(), true
+		}
+	}
+	if !foundPending || !foundFailed {
+		return 0, 0, fmt.Errorf("metrics %s and %s are not registered", proposalsPendingMetricName, proposalsFailedMetricName)
+	}
+	return pending, failed, nil
+}
@@ -0,0 +1,78 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestBackpressureDetector(t *testing.T) {
+	start := time.Now()
+	type observation struct {
+		offset         time.Duration
+		pending        float64
+		failedTotal    float64
+		expectActive   bool
+		expectedFailed float64
+	}
+	table := []struct {
+		description  string
+		observations []observation
+	}{
+		{"should not report backpressure when pending proposals are below threshold", []observation{
+			{0, 10, 0, false, 0},
+			{time.Minute, 99, 0, false, 0},
+		}},
+		{"should report backpressure only once pending proposals are sustained above threshold", []observation{
+			{0, 100, 0, false, 0},
+			{20 * time.Second, 150, 0, false, 0},
+			{30 * time.Second, 150, 0, true, 0},
+			{40 * time.Second, 10, 0, false, 0},
+		}},
+		{"should report backpressure when proposals keep failing", []observation{
+			{0, 0, 5, false, 5},
+			{30 * time.Second, 0, 8, true, 3},
+			{40 * time.Second, 0, 8, false, 0},
+		}},
+		{"should handle counter resets on restarts of etcd", []observation{
+			{0, 0, 5, false, 5},
+			{10 * time.Second, 0, 2, false, 2},
+		}},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		detector := &backpressureDetector{pendingThreshold: 100, sustainedDuration: 30 * time.Second}
+		for _, o := range entry.observations {
+			active, failed := detector.observe(start.Add(o.offset), o.pending, o.failedTotal)
+			g.Expect(active).To(Equal(o.expectActive))
+			g.Expect(failed).To(Equal(o.expectedFailed))
+		}
+	}
+}
+
+func TestReadProposalMetrics(t *testing.T) {
+	g := NewWithT(t)
+	registry := prometheus.NewRegistry()
+
+	_, _, err := readProposalMetrics(registry)
+	g.Expect(err).To(HaveOccurred())
+
+	pending := prometheus.NewGauge(prometheus.GaugeOpts{Namespace: "etcd", Subsystem: "server", Name: "proposals_pending"})
+	failed := prometheus.NewCounter(prometheus.CounterOpts{Namespace: "etcd", Subsystem: "server", Name: "proposals_failed_total"})
+	registry.MustRegister(pending, failed)
+	pending.Set(42)
+	failed.Add(3)
+
+	pendingValue, failedValue, err := readProposalMetrics(registry)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(pendingValue).To(Equal(42.0))
+	g.Expect(failedValue).To(Equal(3.0))
+}
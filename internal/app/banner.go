@@ -0,0 +1,31 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"runtime"
+
+	"github.com/gardener/etcd-wrapper/internal/util"
+
+	"go.etcd.io/etcd/version"
+	"go.uber.org/zap"
+)
+
+// logEffectiveConfig logs the fully resolved configuration in a single entry once etcd is set up: the configuration of
+// etcd-wrapper from its flags, environment variables and files, and the etcd configuration served by backup-restore
+// with the overrides of etcd-wrapper applied. Secrets are redacted.
+func (a *Application) logEffectiveConfig() {
+	proxyEnv := util.ProxyEnv()
+	for name, value := range proxyEnv {
+		proxyEnv[name] = util.RedactURL(value)
+	}
+	a.logger.Info("Effective configuration",
+		zap.String("etcdVersion", version.Version),
+		zap.String("goVersion", runtime.Version()),
+		zap.Any("etcdWrapperConfig", util.Redact(a.Config)),
+		zap.Any("etcdConfig", util.Redact(a.cfg)),
+		zap.Any("proxyEnv", proxyEnv),
+	)
+}
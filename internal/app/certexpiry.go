@@ -0,0 +1,111 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/metrics"
+	"github.com/gardener/etcd-wrapper/internal/tlscheck"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// certExpiryWarnings are the times before the expiry of a certificate at which a warning is logged, in descending
+// order. The level of the warning escalates once a certificate expires within a day.
+var certExpiryWarnings = []struct {
+	before time.Duration
+	level  zapcore.Level
+}{
+	{30 * 24 * time.Hour, zapcore.WarnLevel},
+	{7 * 24 * time.Hour, zapcore.WarnLevel},
+	{24 * time.Hour, zapcore.ErrorLevel},
+	{0, zapcore.ErrorLevel},
+}
+
+// certificateFile is a certificate file used by etcd or etcd-wrapper.
+type certificateFile struct {
+	// name is the purpose of the certificate, which is used as label of its metric.
+	name string
+	path string
+}
+
+// watchCertificateExpiry periodically exports the days until the expiry of every certificate file used by etcd and
+// etcd-wrapper as metrics, and logs a warning every time a certificate has come closer to its expiry than the next of
+// the certExpiryWarnings. It stops when the application context is cancelled.
+func (a *Application) watchCertificateExpiry() {
+	if a.Config.CertExpiryCheckInterval <= 0 {
+		return
+	}
+	// warned holds per certificate path the number of certExpiryWarnings which have been logged.
+	warned := map[string]int{}
+	a.checkCertificateExpiry(warned, time.Now())
+	ticker := time.NewTicker(a.Config.CertExpiryCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-a.ctx.Done():
+			return
+		case now := <-ticker.C:
+			a.checkCertificateExpiry(warned, now)
+		}
+	}
+}
+
+// checkCertificateExpiry exports the days until expiry of every certificate file and logs the warnings due since the
+// last check. A renewed certificate starts over with no warning logged.
+func (a *Application) checkCertificateExpiry(warned map[string]int, now time.Time) {
+	for _, file := range a.certificateFiles() {
+		expiry, err := tlscheck.Expiry(file.path)
+		if err != nil {
+			a.logger.Warn("failed to determine expiry of certificate", zap.String("certificate", file.name), zap.String("path", file.path), zap.Error(err))
+			continue
+		}
+		remaining := expiry.Sub(now)
+		metrics.CertificateDaysUntilExpiry.WithLabelValues(file.name).Set(remaining.Hours() / 24)
+		due := 0
+		for due < len(certExpiryWarnings) && remaining <= certExpiryWarnings[due].before {
+			due++
+		}
+		if due < warned[file.path] {
+			a.logger.Info("certificate has been renewed", zap.String("certificate", file.name), zap.String("path", file.path), zap.Time("expiry", expiry))
+		}
+		if due > warned[file.path] {
+			message := "certificate expires soon, renew it"
+			if remaining <= 0 {
+				message = "certificate has expired, renew it"
+			}
+			a.logger.Log(certExpiryWarnings[due-1].level, message, zap.String("certificate", file.name), zap.String("path", file.path), zap.Time("expiry", expiry), zap.Duration("remaining", remaining.Round(time.Minute)))
+		}
+		warned[file.path] = due
+	}
+}
+
+// certificateFiles returns the certificate files and CA bundles configured for etcd and etcd-wrapper.
+func (a *Application) certificateFiles() []certificateFile {
+	candidates := []certificateFile{
+		{name: "client", path: a.cfg.ClientTLSInfo.CertFile},
+		{name: "client-ca", path: a.cfg.ClientTLSInfo.TrustedCAFile},
+		{name: "peer", path: a.cfg.PeerTLSInfo.CertFile},
+		{name: "peer-ca", path: a.cfg.PeerTLSInfo.TrustedCAFile},
+		{name: "etcd-client", path: a.Config.EtcdClientTLS.CertPath},
+	}
+	if a.Config.ExternalClientListener.URL != "" {
+		candidates = append(candidates,
+			certificateFile{name: "external-client-listener", path: a.Config.ExternalClientListener.CertPath},
+			certificateFile{name: "external-client-listener-ca", path: a.Config.ExternalClientListener.TrustedCAPath})
+	}
+	if a.Config.BackupRestore.TLSEnabled {
+		candidates = append(candidates, certificateFile{name: "backup-restore-ca", path: a.Config.BackupRestore.CaCertBundlePath})
+	}
+	var files []certificateFile
+	for _, candidate := range candidates {
+		if candidate.path != "" {
+			files = append(files, candidate)
+		}
+	}
+	return files
+}
@@ -0,0 +1,83 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/metrics"
+	"github.com/gardener/etcd-wrapper/internal/testutil"
+	"github.com/gardener/etcd-wrapper/internal/tlscheck"
+	"github.com/gardener/etcd-wrapper/internal/types"
+
+	. "github.com/onsi/gomega"
+	dto "github.com/prometheus/client_model/go"
+	"go.etcd.io/etcd/embed"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestCheckCertificateExpiry(t *testing.T) {
+	g := NewWithT(t)
+	dir := t.TempDir()
+	tlsResourceCreator, err := testutil.NewTLSResourceCreator()
+	g.Expect(err).ToNot(HaveOccurred())
+	ca, err := tlsResourceCreator.CreateCACertAndKey()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(ca.EncodeAndWrite(dir, "ca.crt", "ca.key")).To(Succeed())
+	caPath := filepath.Join(dir, "ca.crt")
+	expiry, err := tlscheck.Expiry(caPath)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	var logged []zapcore.Level
+	logger := zaptest.NewLogger(t, zaptest.WrapOptions(zap.Hooks(func(entry zapcore.Entry) error {
+		if entry.Level >= zapcore.WarnLevel {
+			logged = append(logged, entry.Level)
+		}
+		return nil
+	})))
+	cfg := embed.NewConfig()
+	cfg.PeerTLSInfo.TrustedCAFile = caPath
+	app := &Application{cfg: cfg, logger: logger, Config: types.Config{
+		BackupRestore: types.BackupRestoreConfig{CaCertBundlePath: filepath.Join(dir, "missing.crt")},
+	}}
+	warned := map[string]int{}
+
+	table := []struct {
+		description    string
+		now            time.Time
+		expectedDays   float64
+		expectedLogged []zapcore.Level
+	}{
+		{"should not warn about a certificate expiring in more than 30 days", expiry.Add(-40 * 24 * time.Hour), 40, nil},
+		{"should warn once the certificate expires within 30 days", expiry.Add(-20 * 24 * time.Hour), 20, []zapcore.Level{zapcore.WarnLevel}},
+		{"should not repeat a warning", expiry.Add(-19 * 24 * time.Hour), 19, nil},
+		{"should escalate to errors within the last day", expiry.Add(-12 * time.Hour), 0.5, []zapcore.Level{zapcore.ErrorLevel}},
+		{"should report an expired certificate", expiry.Add(12 * time.Hour), -0.5, []zapcore.Level{zapcore.ErrorLevel}},
+		{"should start over once the certificate has been renewed", expiry.Add(-40 * 24 * time.Hour), 40, nil},
+		{"should warn again after the renewal", expiry.Add(-6 * 24 * time.Hour), 6, []zapcore.Level{zapcore.WarnLevel}},
+	}
+	for _, entry := range table {
+		t.Log(entry.description)
+		logged = nil
+		app.checkCertificateExpiry(warned, entry.now)
+		g.Expect(logged).To(Equal(entry.expectedLogged))
+		g.Expect(certificateDaysUntilExpiry(g, "peer-ca")).To(BeNumerically("~", entry.expectedDays, 0.001))
+	}
+
+	t.Log("should only check certificates of the backup-restore CA if TLS is enabled")
+	g.Expect(app.certificateFiles()).To(HaveLen(1))
+	app.Config.BackupRestore.TLSEnabled = true
+	g.Expect(app.certificateFiles()).To(HaveLen(2))
+}
+
+func certificateDaysUntilExpiry(g *WithT, certificate string) float64 {
+	m := &dto.Metric{}
+	g.Expect(metrics.CertificateDaysUntilExpiry.WithLabelValues(certificate).Write(m)).To(Succeed())
+	return m.GetGauge().GetValue()
+}
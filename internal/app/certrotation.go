@@ -0,0 +1,135 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/state"
+
+	"go.etcd.io/etcd/clientv3/concurrency"
+	"go.uber.org/zap"
+)
+
+// rotationReadyPollInterval is the interval in which the readiness of etcd is polled after a coordinated restart.
+const rotationReadyPollInterval = time.Second
+
+// fileChangeDetector detects changes of the content of a file.
+type fileChangeDetector struct {
+	path   string
+	digest *[sha256.Size]byte
+}
+
+// changed returns true if the content of the file has changed since the last call. The first call records the
+// content and always returns false.
+func (d *fileChangeDetector) changed() (bool, error) {
+	content, err := os.ReadFile(d.path) // #nosec G304 -- path is taken from the etcd configuration.
+	if err != nil {
+		return false, err
+	}
+	digest := sha256.Sum256(content)
+	if d.digest == nil {
+		d.digest = &digest
+		return false, nil
+	}
+	if digest == *d.digest {
+		return false, nil
+	}
+	d.digest = &digest
+	return true, nil
+}
+
+// watchPeerCARotation periodically checks whether the peer CA bundle has been rotated. Since etcd only reads the peer
+// CA bundle at start, a rotation requires a restart, which is coordinated with the other members of the cluster.
+// It stops when the application context is cancelled.
+func (a *Application) watchPeerCARotation() {
+	if a.Config.CertRotation.CheckInterval <= 0 || a.cfg.PeerTLSInfo.TrustedCAFile == "" {
+		return
+	}
+	detector := &fileChangeDetector{path: a.cfg.PeerTLSInfo.TrustedCAFile}
+	if _, err := detector.changed(); err != nil {
+		a.logger.Error("failed to read peer CA bundle, rotations of the peer CA will not be detected", zap.Error(err))
+		return
+	}
+	ticker := time.NewTicker(a.Config.CertRotation.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-ticker.C:
+			changed, err := detector.changed()
+			if err != nil {
+				a.logger.Error("failed to read peer CA bundle", zap.Error(err))
+				continue
+			}
+			if !changed {
+				continue
+			}
+			a.logger.Info("peer CA bundle has been rotated, restarting etcd", zap.String("path", detector.path))
+			if err = a.coordinatedRestart(); err != nil {
+				a.logger.Error("failed to restart etcd after rotation of the peer CA bundle", zap.Error(err))
+			}
+		}
+	}
+}
+
+// coordinatedRestart restarts the embedded etcd while holding a cluster-wide lock in etcd, so that members of the
+// cluster restart one at a time and quorum is retained. The lock is only released once the restarted member is ready
+// again. The lock is bound to a lease, so that it is released if etcd-wrapper dies while holding it.
+func (a *Application) coordinatedRestart() error {
+	session, err := concurrency.NewSession(a.etcdClient, concurrency.WithTTL(int(a.Config.CertRotation.LockTTL.Seconds())), concurrency.WithContext(a.ctx))
+	if err != nil {
+		return fmt.Errorf("failed to create session for restart lock: %w", err)
+	}
+	defer func() {
+		if err := session.Close(); err != nil {
+			a.logger.Warn("failed to close session of restart lock", zap.Error(err))
+		}
+	}()
+	mutex := concurrency.NewMutex(session, a.Config.CertRotation.LockKey)
+	a.logger.Info("waiting for restart lock", zap.String("key", a.Config.CertRotation.LockKey))
+	if err = mutex.Lock(a.ctx); err != nil {
+		return fmt.Errorf("failed to acquire restart lock: %w", err)
+	}
+	defer func() {
+		ctx, cancelFunc := context.WithTimeout(context.Background(), etcdGetTimeout)
+		defer cancelFunc()
+		if err := mutex.Unlock(ctx); err != nil {
+			a.logger.Warn("failed to release restart lock, it is released once its lease expires", zap.Error(err))
+			return
+		}
+		a.logger.Info("released restart lock", zap.String("key", mutex.Key()))
+	}()
+	a.logger.Info("acquired restart lock", zap.String("key", mutex.Key()))
+
+	restartedAt := time.Now()
+	if err = a.Restart(); err != nil {
+		return err
+	}
+	return a.waitReadyAfter(restartedAt)
+}
+
+// waitReadyAfter blocks till the embedded etcd has been started after since and is ready according to the readiness
+// policy, or till the application context is cancelled.
+func (a *Application) waitReadyAfter(since time.Time) error {
+	ticker := time.NewTicker(rotationReadyPollInterval)
+	defer ticker.Stop()
+	for {
+		if currentState, stateSince := a.stateMachine.Current(); currentState == state.Ready && stateSince.After(since) && a.isEtcdReady() {
+			return nil
+		}
+		select {
+		case <-a.ctx.Done():
+			return a.ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,36 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestFileChangeDetector(t *testing.T) {
+	g := NewWithT(t)
+	path := filepath.Join(t.TempDir(), "ca.crt")
+	detector := &fileChangeDetector{path: path}
+
+	t.Log("should return error when file cannot be read")
+	_, err := detector.changed()
+	g.Expect(err).To(HaveOccurred())
+
+	t.Log("should not report a change on the first read")
+	g.Expect(os.WriteFile(path, []byte("old-ca"), 0600)).To(Succeed())
+	g.Expect(detector.changed()).To(BeFalse())
+
+	t.Log("should not report a change when the content is unchanged")
+	g.Expect(os.WriteFile(path, []byte("old-ca"), 0600)).To(Succeed())
+	g.Expect(detector.changed()).To(BeFalse())
+
+	t.Log("should report a change once when the content has changed")
+	g.Expect(os.WriteFile(path, []byte("old-ca\nnew-ca"), 0600)).To(Succeed())
+	g.Expect(detector.changed()).To(BeTrue())
+	g.Expect(detector.changed()).To(BeFalse())
+}
@@ -0,0 +1,95 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/brclient"
+	"github.com/gardener/etcd-wrapper/internal/metrics"
+
+	"go.uber.org/zap"
+)
+
+// churnReportTimeout is the time to wait for backup-restore to accept a churn report.
+const churnReportTimeout = 10 * time.Second
+
+// churnTracker derives the rate at which the etcd revision grows from successive observations of it.
+type churnTracker struct {
+	lastRevision int64
+	lastAt       time.Time
+}
+
+// observe records the revision observed at the given time and returns the rate at which the revision has grown since
+// the previous observation, in revisions per second, and the window of the rate. It returns false for the first
+// observation and whenever the revision has decreased, e.g. after the data directory has been restored.
+func (t *churnTracker) observe(at time.Time, revision int64) (float64, time.Duration, bool) {
+	lastRevision, lastAt := t.lastRevision, t.lastAt
+	t.lastRevision, t.lastAt = revision, at
+	window := at.Sub(lastAt)
+	if lastAt.IsZero() || revision < lastRevision || window <= 0 {
+		return 0, 0, false
+	}
+	return float64(revision-lastRevision) / window.Seconds(), window, true
+}
+
+// watchChurn periodically measures the rate at which the etcd revision grows and reports it to backup-restore, which
+// can adapt the period of delta snapshots to bursty workloads. Reporting stops if backup-restore does not support churn
+// reports or when the application context is cancelled.
+func (a *Application) watchChurn() {
+	if a.Config.ChurnReportInterval <= 0 {
+		return
+	}
+	tracker := &churnTracker{}
+	ticker := time.NewTicker(a.Config.ChurnReportInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-ticker.C:
+			if err := a.reportChurn(tracker); errors.Is(err, brclient.ErrNotSupported) {
+				a.logger.Info("backup-restore does not support churn reports, not reporting churn anymore")
+				return
+			}
+		}
+	}
+}
+
+// reportChurn observes the current etcd revision and reports the resulting churn to backup-restore. Only an error
+// returned by backup-restore is returned, all other errors are logged.
+func (a *Application) reportChurn(tracker *churnTracker) error {
+	if !a.etcdRunning() {
+		return nil
+	}
+	ctx, cancelFunc := context.WithTimeout(a.ctx, etcdGetTimeout)
+	defer cancelFunc()
+	status, err := a.etcdClient.Status(ctx, a.etcdClient.Endpoints()[0])
+	if err != nil {
+		a.logger.Error("failed to get etcd status for churn report", zap.Error(err))
+		return nil
+	}
+	now := time.Now()
+	rate, window, ok := tracker.observe(now, status.Header.Revision)
+	if !ok {
+		return nil
+	}
+	metrics.RevisionChurnRate.Set(rate)
+
+	reportCtx, cancelReport := context.WithTimeout(a.ctx, churnReportTimeout)
+	defer cancelReport()
+	period, err := a.brClient.ReportChurn(reportCtx, brclient.ChurnReport{Revision: status.Header.Revision, RevisionsPerSecond: rate, Window: window, ObservedAt: now})
+	if err != nil {
+		if !errors.Is(err, brclient.ErrNotSupported) {
+			a.logger.Error("failed to report churn to backup-restore", zap.Float64("revisionsPerSecond", rate), zap.Error(err))
+		}
+		return err
+	}
+	a.logger.Debug("Reported churn to backup-restore", zap.Float64("revisionsPerSecond", rate), zap.Duration("window", window), zap.Duration("deltaSnapshotPeriod", period))
+	return nil
+}
@@ -0,0 +1,48 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestChurnTrackerObserve(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	table := []struct {
+		description  string
+		revisions    []int64
+		expectRate   bool
+		expectedRate float64
+	}{
+		{"should not compute rate from the first observation", []int64{100}, false, 0},
+		{"should compute rate since the previous observation", []int64{100, 160}, true, 1},
+		{"should compute zero rate when revision does not change", []int64{100, 160, 160}, true, 0},
+		{"should only consider the previous observation", []int64{0, 6000, 6120}, true, 2},
+		{"should not compute rate when revision has decreased", []int64{100, 160, 10}, false, 0},
+		{"should compute rate again after revision has decreased", []int64{100, 160, 10, 70}, true, 1},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		tracker := &churnTracker{}
+		var (
+			rate   float64
+			window time.Duration
+			ok     bool
+		)
+		for i, revision := range entry.revisions {
+			rate, window, ok = tracker.observe(start.Add(time.Duration(i)*time.Minute), revision)
+		}
+		g.Expect(ok).To(Equal(entry.expectRate))
+		g.Expect(rate).To(BeNumerically("~", entry.expectedRate, 1e-9))
+		if ok {
+			g.Expect(window).To(Equal(time.Minute))
+		}
+	}
+}
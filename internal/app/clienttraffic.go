@@ -0,0 +1,60 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/clienttraffic"
+
+	"go.uber.org/zap"
+)
+
+// clientTrafficResponse is the response of the client traffic endpoint.
+type clientTrafficResponse struct {
+	// SampledAt is the time of the last sample, zero if no sample has been taken yet.
+	SampledAt time.Time `json:"sampledAt"`
+	// SampleInterval is the interval in which the RPC rate per client is computed.
+	SampleInterval string `json:"sampleInterval"`
+	// Clients are the clients with the highest RPC rate, ordered by descending RPC rate.
+	Clients []clienttraffic.Client `json:"clients"`
+}
+
+// sampleClientTraffic periodically computes the RPC rate per client of the external client listener. It stops when
+// the application context is cancelled.
+func (a *Application) sampleClientTraffic() {
+	if a.clientTraffic == nil {
+		return
+	}
+	ticker := time.NewTicker(a.Config.ClientTraffic.SampleInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-a.ctx.Done():
+			return
+		case now := <-ticker.C:
+			a.clientTraffic.Sample(now)
+		}
+	}
+}
+
+// clientTrafficHandler writes the clients with the highest RPC rate as JSON onto the http.ResponseWriter.
+func (a *Application) clientTrafficHandler(w http.ResponseWriter, _ *http.Request) {
+	if a.clientTraffic == nil {
+		http.Error(w, "client traffic tracking is disabled", http.StatusNotFound)
+		return
+	}
+	clients, sampledAt := a.clientTraffic.Top(a.Config.ClientTraffic.TopClients)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(clientTrafficResponse{
+		SampledAt:      sampledAt,
+		SampleInterval: a.Config.ClientTraffic.SampleInterval.String(),
+		Clients:        clients,
+	}); err != nil {
+		a.logger.Error("failed to write client traffic response", zap.Error(err))
+	}
+}
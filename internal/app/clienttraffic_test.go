@@ -0,0 +1,55 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/clienttraffic"
+	"github.com/gardener/etcd-wrapper/internal/types"
+
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap/zaptest"
+	"google.golang.org/grpc/stats"
+)
+
+func TestClientTrafficHandler(t *testing.T) {
+	g := NewWithT(t)
+	logger := zaptest.NewLogger(t)
+
+	t.Log("should respond with not found if client traffic tracking is disabled")
+	app := &Application{logger: logger}
+	response := httptest.NewRecorder()
+	app.clientTrafficHandler(response, httptest.NewRequest(http.MethodGet, "/debug/clients", nil))
+	g.Expect(response.Code).To(Equal(http.StatusNotFound))
+
+	t.Log("should respond with the top clients by RPC rate")
+	app.Config = types.Config{ClientTraffic: types.ClientTrafficConfig{SampleInterval: time.Minute, TopClients: 1}}
+	app.clientTraffic = clienttraffic.NewTracker()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	app.clientTraffic.Sample(start)
+	for host, rpcs := range map[string]int{"10.0.0.1": 60, "10.0.0.2": 120} {
+		ctx := app.clientTraffic.TagConn(context.Background(), &stats.ConnTagInfo{RemoteAddr: &net.TCPAddr{IP: net.ParseIP(host), Port: 4711}})
+		app.clientTraffic.HandleConn(ctx, &stats.ConnBegin{})
+		for range rpcs {
+			app.clientTraffic.HandleRPC(ctx, &stats.Begin{})
+		}
+	}
+	app.clientTraffic.Sample(start.Add(time.Minute))
+	response = httptest.NewRecorder()
+	app.clientTrafficHandler(response, httptest.NewRequest(http.MethodGet, "/debug/clients", nil))
+	g.Expect(response.Code).To(Equal(http.StatusOK))
+	var body clientTrafficResponse
+	g.Expect(json.Unmarshal(response.Body.Bytes(), &body)).To(Succeed())
+	g.Expect(body.SampledAt).To(BeTemporally("==", start.Add(time.Minute)))
+	g.Expect(body.SampleInterval).To(Equal("1m0s"))
+	g.Expect(body.Clients).To(Equal([]clienttraffic.Client{{Address: "10.0.0.2", OpenConnections: 1, TotalRPCs: 120, RPCRate: 2}}))
+}
@@ -0,0 +1,125 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/bootstrap"
+	"github.com/gardener/etcd-wrapper/internal/types"
+	"github.com/gardener/etcd-wrapper/internal/util"
+
+	"go.etcd.io/etcd/clientv3"
+	"go.uber.org/zap"
+)
+
+const (
+	clientURLSelfTestTimeout = 5 * time.Second
+	// clientURLSelfTestRetryInterval is the interval in which a failed self-test of the advertised client URLs is
+	// repeated, e.g. until the DNS records of the advertised client URLs have propagated.
+	clientURLSelfTestRetryInterval = 10 * time.Second
+)
+
+// selfTestAdvertisedClientURLs verifies that every advertised client URL of the embedded etcd is reachable from within
+// the pod by dialing it, performing the TLS handshake for https URLs and calling the Status RPC. The result is recorded
+// and reflected in the readiness of etcd-wrapper, which catches misconfigured advertised client URLs early.
+func (a *Application) selfTestAdvertisedClientURLs() {
+	if a.Config.SkipClientURLSelfTest {
+		return
+	}
+	var errs error
+	for _, u := range a.cfg.AdvertiseClientUrls {
+		if err := a.checkClientURL(u); err != nil {
+			errs = errors.Join(errs, fmt.Errorf("advertised client URL %s is not reachable: %w", u.String(), err))
+		}
+	}
+	previousErr := a.getClientURLsErr()
+	switch {
+	case errs == nil:
+		a.logger.Info("self-test of advertised client URLs succeeded", zap.Int("count", len(a.cfg.AdvertiseClientUrls)))
+	// only changes of the reason are logged so that the log is not flooded by the retries.
+	case previousErr == nil || previousErr.Error() != errs.Error():
+		a.logger.Error("self-test of advertised client URLs failed, etcd-wrapper will not report ready till it succeeds", zap.Error(errs),
+			zap.Duration("retryInterval", clientURLSelfTestRetryInterval))
+	}
+	a.setClientURLsErr(errs)
+}
+
+// watchClientURLs repeats a failed self-test of the advertised client URLs every clientURLSelfTestRetryInterval while
+// etcd is running, so that a transient failure, e.g. a DNS record which has not propagated yet, does not keep
+// etcd-wrapper unready until etcd is restarted. It stops when the application context is cancelled.
+func (a *Application) watchClientURLs() {
+	if a.Config.SkipClientURLSelfTest {
+		return
+	}
+	ticker := time.NewTicker(clientURLSelfTestRetryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-ticker.C:
+			if a.etcdRunning() && a.getClientURLsErr() != nil {
+				a.selfTestAdvertisedClientURLs()
+			}
+		}
+	}
+}
+
+// checkClientURL dials the given client URL and calls the Status RPC.
+func (a *Application) checkClientURL(u url.URL) error {
+	tlsEnabled := u.Scheme == "https"
+	tlsConfig, err := util.CreateTLSConfig(func() bool { return tlsEnabled }, u.Hostname(), a.cfg.ClientTLSInfo.TrustedCAFile, a.clientKeyPair())
+	if err != nil {
+		return err
+	}
+	ctx, cancelFunc := context.WithTimeout(a.ctx, clientURLSelfTestTimeout)
+	defer cancelFunc()
+	cli, err := clientv3.New(clientv3.Config{
+		Context:     ctx,
+		Endpoints:   []string{u.String()},
+		DialTimeout: clientURLSelfTestTimeout,
+		LogConfig:   bootstrap.SetupLoggerConfig(types.DefaultLogLevel),
+		TLS:         tlsConfig,
+		DialOptions: util.GRPCProxyDialOptions(a.Config.DisableProxyEnv),
+	})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = cli.Close()
+	}()
+	_, err = cli.Status(ctx, u.String())
+	return err
+}
+
+// clientKeyPair returns the key pair of the etcd client if one has been configured.
+func (a *Application) clientKeyPair() *util.KeyPair {
+	if a.Config.EtcdClientTLS.CertPath == "" || a.Config.EtcdClientTLS.KeyPath == "" {
+		return nil
+	}
+	return &util.KeyPair{
+		CertPath:      a.Config.EtcdClientTLS.CertPath,
+		KeyPath:       a.Config.EtcdClientTLS.KeyPath,
+		KeyPassphrase: a.Config.EtcdClientTLS.KeyPassphrase,
+	}
+}
+
+func (a *Application) setClientURLsErr(err error) {
+	a.clientURLsMu.Lock()
+	defer a.clientURLsMu.Unlock()
+	a.clientURLsErr = err
+}
+
+func (a *Application) getClientURLsErr() error {
+	a.clientURLsMu.RLock()
+	defer a.clientURLsMu.RUnlock()
+	return a.clientURLsErr
+}
@@ -0,0 +1,126 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/metrics"
+	"github.com/gardener/etcd-wrapper/internal/util"
+
+	"go.uber.org/zap"
+)
+
+const (
+	clockSkewCheckInterval = time.Minute
+	clockSkewProbeTimeout  = 5 * time.Second
+	// dateHeaderResolution is the resolution of the Date header of HTTP responses.
+	dateHeaderResolution = time.Second
+)
+
+// watchClockSkew periodically compares the local time with the time of all other members of the etcd cluster.
+// It stops when the application context is cancelled.
+func (a *Application) watchClockSkew() {
+	if a.Config.ClockSkewThreshold <= 0 {
+		return
+	}
+	ticker := time.NewTicker(clockSkewCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-ticker.C:
+			a.checkClockSkew()
+		}
+	}
+}
+
+// checkClockSkew measures the clock skew to every other member of the etcd cluster via the Date header of a response
+// from its peer URL, and warns if the skew exceeds the configured threshold since clock skew breaks lease semantics.
+func (a *Application) checkClockSkew() {
+	etcd := a.getEtcd()
+	if etcd == nil {
+		return
+	}
+	ctx, cancelFunc := context.WithTimeout(a.ctx, etcdGetTimeout)
+	defer cancelFunc()
+	response, err := a.etcdClient.MemberList(ctx)
+	if err != nil {
+		a.logger.Error("failed to list members for clock skew detection", zap.Error(err))
+		return
+	}
+	localID := uint64(etcd.Server.ID())
+	for _, member := range response.Members {
+		if member.ID == localID || len(member.PeerURLs) == 0 {
+			continue
+		}
+		skew, err := a.measureClockSkew(member.PeerURLs[0])
+		if err != nil {
+			a.logger.Error("failed to measure clock skew to member", zap.String("member", member.Name), zap.Error(err))
+			continue
+		}
+		metrics.PeerClockSkewSeconds.WithLabelValues(member.Name).Set(skew.Seconds())
+		if skew.Abs() > a.Config.ClockSkewThreshold {
+			a.logger.Warn("clock skew to member exceeds threshold, which breaks lease semantics; ensure that clocks of all members are synchronized via NTP",
+				zap.String("member", member.Name),
+				zap.Duration("skew", skew),
+				zap.Duration("threshold", a.Config.ClockSkewThreshold))
+		}
+	}
+}
+
+// measureClockSkew returns the difference between the clock of the member serving peerURL and the local clock.
+// The skew is positive if the clock of the member is ahead. Its accuracy is limited by the resolution of the
+// Date header and by half of the round-trip time.
+func (a *Application) measureClockSkew(peerURL string) (time.Duration, error) {
+	u, err := url.Parse(peerURL)
+	if err != nil {
+		return 0, err
+	}
+	client, err := a.createPeerHTTPClient(u)
+	if err != nil {
+		return 0, err
+	}
+	return measureClockSkew(a.ctx, client, u.JoinPath("version").String())
+}
+
+func (a *Application) createPeerHTTPClient(u *url.URL) (*http.Client, error) {
+	tlsConfig, err := a.peerTLSConfig(u)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{
+		Transport: &http.Transport{TLSClientConfig: tlsConfig, Proxy: util.ProxyFunc(a.Config.DisableProxyEnv), DialContext: a.Config.DNS.NewDialer().DialContext},
+		Timeout:   clockSkewProbeTimeout,
+	}, nil
+}
+
+func measureClockSkew(ctx context.Context, client *http.Client, endpoint string) (time.Duration, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return 0, err
+	}
+	sent := time.Now()
+	response, err := client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	received := time.Now()
+	_ = response.Body.Close()
+	remoteTime, err := http.ParseTime(response.Header.Get("Date"))
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse Date header of response from %s: %w", endpoint, err)
+	}
+	// the Date header is truncated to its resolution, the remote time is on average half of the resolution later.
+	remoteTime = remoteTime.Add(dateHeaderResolution / 2)
+	localTime := sent.Add(received.Sub(sent) / 2)
+	return remoteTime.Sub(localTime), nil
+}
@@ -0,0 +1,49 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestMeasureClockSkew(t *testing.T) {
+	table := []struct {
+		description string
+		remoteSkew  time.Duration
+		dateHeader  string
+		expectError bool
+	}{
+		{"should measure no significant skew when clocks are synchronized", 0, "", false},
+		{"should measure positive skew when remote clock is ahead", 10 * time.Second, "", false},
+		{"should measure negative skew when remote clock is behind", -10 * time.Second, "", false},
+		{"should return error when Date header is invalid", 0, "not-a-date", true},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			dateHeader := entry.dateHeader
+			if dateHeader == "" {
+				dateHeader = time.Now().Add(entry.remoteSkew).UTC().Format(http.TimeFormat)
+			}
+			w.Header().Set("Date", dateHeader)
+			w.WriteHeader(http.StatusOK)
+		}))
+
+		skew, err := measureClockSkew(context.Background(), server.Client(), server.URL+"/version")
+		server.Close()
+		g.Expect(err != nil).To(Equal(entry.expectError))
+		if !entry.expectError {
+			g.Expect(skew).To(BeNumerically("~", entry.remoteSkew, dateHeaderResolution))
+		}
+	}
+}
@@ -0,0 +1,194 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/types"
+	"github.com/gardener/etcd-wrapper/internal/util"
+
+	"go.etcd.io/etcd/embed"
+	"go.etcd.io/etcd/etcdserver"
+	etcdtypes "go.etcd.io/etcd/pkg/types"
+	"go.uber.org/zap"
+)
+
+// ClusterIDMismatchError is returned when the peers of the member report another cluster ID than the one pinned for
+// the same set of peers, which indicates a split brain or a data directory restored from a backup of another cluster.
+type ClusterIDMismatchError struct {
+	// Pinned is the cluster ID pinned on an earlier start of the member.
+	Pinned string
+	// Remote is the cluster ID reported by the peers.
+	Remote string
+	// PeerURLs are the peer URLs of the other members of the cluster.
+	PeerURLs []string
+}
+
+func (e *ClusterIDMismatchError) Error() string {
+	return fmt.Sprintf("refusing to start etcd: peers %s report cluster ID %s, but cluster ID %s has been pinned for them, which indicates a split brain or a restore from a backup of another cluster",
+		strings.Join(e.PeerURLs, ","), e.Remote, e.Pinned)
+}
+
+// ExitCode returns the exit code with which etcd-wrapper exits because of the cluster ID mismatch.
+func (e *ClusterIDMismatchError) ExitCode() int {
+	return types.ExitCodeClusterIDMismatch
+}
+
+// clusterIDPin is the cluster ID of the member, pinned together with the peer URLs of the other members once the member
+// has successfully joined the cluster.
+type clusterIDPin struct {
+	// ClusterID is the ID of the etcd cluster.
+	ClusterID string `json:"clusterID"`
+	// PeerURLs are the sorted peer URLs of the other members of the cluster at the time of pinning.
+	PeerURLs []string `json:"peerURLs"`
+	// PinnedAt is the time at which the cluster ID has been pinned.
+	PinnedAt time.Time `json:"pinnedAt"`
+}
+
+// verifyClusterID compares the cluster ID reported by the peers with the pinned cluster ID before etcd is started.
+// The verification is skipped if no cluster ID has been pinned yet, if the peer set has changed since, or if no peer
+// responds, e.g. because all members start at the same time.
+func (a *Application) verifyClusterID() error {
+	if a.Config.ClusterIDPinPath == "" {
+		return nil
+	}
+	pin, err := loadClusterIDPin(a.Config.ClusterIDPinPath)
+	if err != nil {
+		a.logger.Warn("failed to load pinned cluster ID, skipping cluster ID verification", zap.Error(err))
+		return nil
+	}
+	if pin == nil {
+		return nil
+	}
+	peerURLs, err := remotePeerURLs(a.cfg)
+	if err != nil {
+		return err
+	}
+	if len(peerURLs) == 0 {
+		return nil
+	}
+	if !slices.Equal(peerURLs, pin.PeerURLs) {
+		a.logger.Info("peer set has changed since the cluster ID has been pinned, skipping cluster ID verification", zap.Strings("pinnedPeerURLs", pin.PeerURLs), zap.Strings("peerURLs", peerURLs))
+		return nil
+	}
+	remoteClusterID, err := a.getRemoteClusterID(peerURLs)
+	if err != nil {
+		a.logger.Warn("no peer reported the cluster ID, skipping cluster ID verification", zap.Error(err))
+		return nil
+	}
+	if remoteClusterID != pin.ClusterID {
+		return &ClusterIDMismatchError{Pinned: pin.ClusterID, Remote: remoteClusterID, PeerURLs: peerURLs}
+	}
+	a.logger.Info("cluster ID reported by peers matches pinned cluster ID", zap.String("clusterID", pin.ClusterID))
+	return nil
+}
+
+// getRemoteClusterID returns the cluster ID reported by the first of the peers which responds.
+func (a *Application) getRemoteClusterID(peerURLs []string) (string, error) {
+	var errs error
+	for _, peerURL := range peerURLs {
+		u, err := url.Parse(peerURL)
+		if err != nil {
+			errs = errors.Join(errs, err)
+			continue
+		}
+		// the TLS server name may differ per peer, hence every peer is queried with its own transport.
+		client, err := a.createPeerHTTPClient(u)
+		if err != nil {
+			errs = errors.Join(errs, err)
+			continue
+		}
+		cluster, err := etcdserver.GetClusterFromRemotePeers(a.logger, []string{peerURL}, client.Transport, a.cfg.NextClusterVersionCompatible)
+		if err != nil {
+			errs = errors.Join(errs, fmt.Errorf("%s: %w", peerURL, err))
+			continue
+		}
+		return cluster.ID().String(), nil
+	}
+	return "", errs
+}
+
+// pinClusterID pins the cluster ID of the running etcd together with the current peer set. A differing pinned cluster
+// ID is replaced, since the member has then been started without verification and has joined the cluster with quorum.
+func (a *Application) pinClusterID(etcd *embed.Etcd) {
+	path := a.Config.ClusterIDPinPath
+	if path == "" {
+		return
+	}
+	clusterID := etcd.Server.Cluster().ID().String()
+	peerURLs, err := remotePeerURLs(a.cfg)
+	if err != nil {
+		a.logger.Error("failed to determine peer set for pinning the cluster ID", zap.Error(err))
+		return
+	}
+	pin, err := loadClusterIDPin(path)
+	if err == nil && pin != nil {
+		if pin.ClusterID == clusterID && slices.Equal(pin.PeerURLs, peerURLs) {
+			return
+		}
+		if pin.ClusterID != clusterID {
+			a.logger.Warn("cluster ID differs from the pinned cluster ID, pinning the new cluster ID", zap.String("pinned", pin.ClusterID), zap.String("clusterID", clusterID))
+		}
+	}
+	if err = writeClusterIDPin(path, clusterIDPin{ClusterID: clusterID, PeerURLs: peerURLs, PinnedAt: time.Now().UTC()}); err != nil {
+		a.logger.Error("failed to pin cluster ID", zap.String("path", path), zap.Error(err))
+		return
+	}
+	a.logger.Info("pinned cluster ID", zap.String("clusterID", clusterID), zap.Strings("peerURLs", peerURLs))
+}
+
+// remotePeerURLs returns the sorted peer URLs of the members of the initial cluster other than the member itself.
+func remotePeerURLs(cfg *embed.Config) ([]string, error) {
+	if cfg.InitialCluster == "" {
+		return nil, nil
+	}
+	urlsMap, err := etcdtypes.NewURLsMap(cfg.InitialCluster)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse initial cluster: %w", err)
+	}
+	var peerURLs []string
+	for name, urls := range urlsMap {
+		if name == cfg.Name {
+			continue
+		}
+		peerURLs = append(peerURLs, urls.StringSlice()...)
+	}
+	slices.Sort(peerURLs)
+	return peerURLs, nil
+}
+
+// loadClusterIDPin loads the cluster ID pinned at path. It returns nil if no cluster ID has been pinned.
+func loadClusterIDPin(path string) (*clusterIDPin, error) {
+	data, err := os.ReadFile(path) // #nosec G304 -- path is configured by the operator.
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	pin := &clusterIDPin{}
+	if err = json.Unmarshal(data, pin); err != nil {
+		return nil, fmt.Errorf("failed to parse pinned cluster ID: %w", err)
+	}
+	return pin, nil
+}
+
+// writeClusterIDPin atomically writes pin into the file at path by writing a temporary file which is then renamed.
+func writeClusterIDPin(path string, pin clusterIDPin) error {
+	data, err := json.Marshal(pin)
+	if err != nil {
+		return err
+	}
+	return util.WriteFileAtomic(path, data, 0600)
+}
@@ -0,0 +1,94 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/types"
+
+	. "github.com/onsi/gomega"
+	"go.etcd.io/etcd/embed"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestRemotePeerURLs(t *testing.T) {
+	g := NewWithT(t)
+	cfg := &embed.Config{Name: "etcd-1", InitialCluster: "etcd-2=https://etcd-2:2380,etcd-1=https://etcd-1:2380,etcd-0=https://etcd-0:2380,etcd-0=https://etcd-0.alt:2380"}
+	peerURLs, err := remotePeerURLs(cfg)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(peerURLs).To(Equal([]string{"https://etcd-0.alt:2380", "https://etcd-0:2380", "https://etcd-2:2380"}))
+
+	t.Log("should return no peers for a single member cluster")
+	peerURLs, err = remotePeerURLs(&embed.Config{Name: "etcd-0", InitialCluster: "etcd-0=https://etcd-0:2380"})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(peerURLs).To(BeEmpty())
+}
+
+func TestVerifyClusterID(t *testing.T) {
+	peer := startTestEtcd(t, NewWithT(t))
+	peerURL := peer.Config().AdvertisePeerUrls[0].String()
+	clusterID := peer.Server.Cluster().ID().String()
+	unreachableURL := "http://" + freeLocalAddress(NewWithT(t))
+
+	table := []struct {
+		description      string
+		peerURL          string
+		pin              *clusterIDPin
+		expectedMismatch bool
+	}{
+		{"should start if no cluster ID has been pinned", peerURL, nil, false},
+		{"should start if the peers report the pinned cluster ID", peerURL, &clusterIDPin{ClusterID: clusterID, PeerURLs: []string{peerURL}}, false},
+		{"should refuse to start if the peers report another cluster ID", peerURL, &clusterIDPin{ClusterID: "1234", PeerURLs: []string{peerURL}}, true},
+		{"should start if the peer set has changed since pinning", peerURL, &clusterIDPin{ClusterID: "1234", PeerURLs: []string{unreachableURL}}, false},
+		{"should start if no peer responds", unreachableURL, &clusterIDPin{ClusterID: "1234", PeerURLs: []string{unreachableURL}}, false},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		pinPath := filepath.Join(t.TempDir(), "cluster_id_pin.json")
+		if entry.pin != nil {
+			g.Expect(writeClusterIDPin(pinPath, *entry.pin)).To(Succeed())
+		}
+		app := &Application{
+			Config: types.Config{ClusterIDPinPath: pinPath},
+			cfg:    &embed.Config{Name: "self", InitialCluster: "self=http://127.0.0.1:1,peer=" + entry.peerURL},
+			logger: zaptest.NewLogger(t),
+		}
+		err := app.verifyClusterID()
+		var mismatchErr *ClusterIDMismatchError
+		g.Expect(errors.As(err, &mismatchErr)).To(Equal(entry.expectedMismatch))
+		if entry.expectedMismatch {
+			g.Expect(mismatchErr.Remote).To(Equal(clusterID))
+			g.Expect(mismatchErr.ExitCode()).To(Equal(types.ExitCodeClusterIDMismatch))
+		} else {
+			g.Expect(err).ToNot(HaveOccurred())
+		}
+	}
+}
+
+func TestPinClusterID(t *testing.T) {
+	g := NewWithT(t)
+	etcd := startTestEtcd(t, g)
+	cfg := etcd.Config()
+	pinPath := filepath.Join(t.TempDir(), "cluster_id_pin.json")
+	app := &Application{
+		Config: types.Config{ClusterIDPinPath: pinPath},
+		cfg:    &cfg,
+		logger: zaptest.NewLogger(t),
+	}
+	g.Expect(writeClusterIDPin(pinPath, clusterIDPin{ClusterID: "1234", PinnedAt: time.Now()})).To(Succeed())
+
+	t.Log("should replace a differing pinned cluster ID with the cluster ID of the running etcd")
+	app.pinClusterID(etcd)
+	pin, err := loadClusterIDPin(pinPath)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(pin.ClusterID).To(Equal(etcd.Server.Cluster().ID().String()))
+	g.Expect(pin.PeerURLs).To(BeEmpty())
+}
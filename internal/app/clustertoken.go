@@ -0,0 +1,123 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/gardener/etcd-wrapper/internal/bootstrap"
+	"github.com/gardener/etcd-wrapper/internal/util"
+
+	"go.etcd.io/etcd/embed"
+	etcdtypes "go.etcd.io/etcd/pkg/types"
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultInitialClusterToken is the initial cluster token used by etcd if none is configured.
+	defaultInitialClusterToken = "etcd-cluster"
+	// generatedClusterTokenBytes is the number of random bytes of a generated initial cluster token.
+	generatedClusterTokenBytes = 16
+)
+
+// applyInitialClusterToken sets the initial cluster token of the etcd configuration, which etcd mixes into the IDs of
+// the cluster and its members when a new cluster is bootstrapped. A token passed via etcd-wrapper flags takes
+// precedence. Otherwise, if the etcd configuration leaves the token at the etcd default, the token persisted on an
+// earlier bootstrap is used, or a token is generated and persisted on the first bootstrap of a single-member cluster.
+// Tokens are never generated for multi-member clusters since every member would generate a different one.
+func (a *Application) applyInitialClusterToken(cfg *embed.Config) error {
+	if a.Config.InitialClusterToken != "" {
+		if !isDefaultInitialClusterToken(cfg.InitialClusterToken) && cfg.InitialClusterToken != a.Config.InitialClusterToken {
+			a.logger.Info("overriding initial cluster token of the etcd configuration")
+		}
+		cfg.InitialClusterToken = a.Config.InitialClusterToken
+		return nil
+	}
+	if !isDefaultInitialClusterToken(cfg.InitialClusterToken) {
+		return nil
+	}
+	path := a.Config.InitialClusterTokenPath
+	if path != "" {
+		token, err := loadInitialClusterToken(path)
+		if err != nil {
+			return err
+		}
+		if token != "" {
+			cfg.InitialClusterToken = token
+			return nil
+		}
+	}
+	if cfg.ClusterState != embed.ClusterStateFlagNew || bootstrap.HasWAL(cfg) {
+		return nil
+	}
+	singleMember, err := isSingleMemberCluster(cfg)
+	if err != nil {
+		return err
+	}
+	if path == "" || !singleMember {
+		a.logger.Warn("bootstrapping a new cluster with the default initial cluster token, members of other etcd clusters sharing the network may join it; set --initial-cluster-token to the same token on all members",
+			zap.String("token", cfg.InitialClusterToken))
+		return nil
+	}
+	token, err := generateInitialClusterToken(cfg.Name)
+	if err != nil {
+		return err
+	}
+	if err = writeInitialClusterToken(path, token); err != nil {
+		return fmt.Errorf("failed to persist initial cluster token: %w", err)
+	}
+	a.logger.Info("generated initial cluster token for the bootstrap of a new cluster", zap.String("path", path))
+	cfg.InitialClusterToken = token
+	return nil
+}
+
+func isDefaultInitialClusterToken(token string) bool {
+	return token == "" || token == defaultInitialClusterToken
+}
+
+// isSingleMemberCluster returns whether the initial cluster of cfg consists of the member itself only.
+func isSingleMemberCluster(cfg *embed.Config) (bool, error) {
+	if cfg.InitialCluster == "" {
+		return true, nil
+	}
+	urlsMap, err := etcdtypes.NewURLsMap(cfg.InitialCluster)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse initial cluster: %w", err)
+	}
+	_, ok := urlsMap[cfg.Name]
+	return len(urlsMap) == 1 && ok, nil
+}
+
+func generateInitialClusterToken(name string) (string, error) {
+	random := make([]byte, generatedClusterTokenBytes)
+	if _, err := rand.Read(random); err != nil {
+		return "", fmt.Errorf("failed to generate initial cluster token: %w", err)
+	}
+	return name + "-" + hex.EncodeToString(random), nil
+}
+
+// loadInitialClusterToken returns the token persisted in the file at path, or an empty token if there is none.
+func loadInitialClusterToken(path string) (string, error) {
+	data, err := os.ReadFile(path) // #nosec G304 -- path is configured by the operator.
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read persisted initial cluster token: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// writeInitialClusterToken atomically writes token into the file at path by writing a temporary file which is then
+// renamed.
+func writeInitialClusterToken(path, token string) error {
+	return util.WriteFileAtomic(path, []byte(token+"\n"), 0600)
+}
@@ -0,0 +1,84 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gardener/etcd-wrapper/internal/types"
+
+	. "github.com/onsi/gomega"
+	"go.etcd.io/etcd/embed"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestApplyInitialClusterToken(t *testing.T) {
+	const (
+		singleMember = "etcd-main-0=https://etcd-main-0:2380"
+		multiMember  = "etcd-main-0=https://etcd-main-0:2380,etcd-main-1=https://etcd-main-1:2380"
+	)
+	table := []struct {
+		description      string
+		flagToken        string
+		configToken      string
+		clusterState     string
+		initialCluster   string
+		persistedToken   string
+		withWAL          bool
+		disabled         bool
+		expectedToken    string
+		expectGenerated  bool
+		expectPersisting bool
+	}{
+		{"should override the token of the etcd configuration by the flag", "flag-token", "config-token", embed.ClusterStateFlagNew, singleMember, "", false, false, "flag-token", false, false},
+		{"should keep a token set by the etcd configuration", "", "config-token", embed.ClusterStateFlagNew, singleMember, "persisted-token", false, false, "config-token", false, false},
+		{"should use the persisted token", "", defaultInitialClusterToken, embed.ClusterStateFlagNew, singleMember, "persisted-token", false, false, "persisted-token", false, false},
+		{"should generate and persist a token on the first bootstrap of a single-member cluster", "", defaultInitialClusterToken, embed.ClusterStateFlagNew, singleMember, "", false, false, "", true, true},
+		{"should not generate a token for a multi-member cluster", "", defaultInitialClusterToken, embed.ClusterStateFlagNew, multiMember, "", false, false, defaultInitialClusterToken, false, false},
+		{"should not generate a token when joining an existing cluster", "", defaultInitialClusterToken, embed.ClusterStateFlagExisting, singleMember, "", false, false, defaultInitialClusterToken, false, false},
+		{"should not generate a token if the member has been bootstrapped before", "", defaultInitialClusterToken, embed.ClusterStateFlagNew, singleMember, "", true, false, defaultInitialClusterToken, false, false},
+		{"should not generate a token if persisting is disabled", "", defaultInitialClusterToken, embed.ClusterStateFlagNew, singleMember, "", false, true, defaultInitialClusterToken, false, false},
+	}
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		dir := t.TempDir()
+		tokenPath := filepath.Join(dir, "initial_cluster_token")
+		if entry.persistedToken != "" {
+			g.Expect(os.WriteFile(tokenPath, []byte(entry.persistedToken+"\n"), 0600)).To(Succeed())
+		}
+		cfg := embed.NewConfig()
+		cfg.Name = "etcd-main-0"
+		cfg.Dir = filepath.Join(dir, "new.etcd")
+		cfg.InitialClusterToken = entry.configToken
+		cfg.ClusterState = entry.clusterState
+		cfg.InitialCluster = entry.initialCluster
+		if entry.withWAL {
+			g.Expect(os.MkdirAll(filepath.Join(cfg.Dir, "member", "wal"), 0700)).To(Succeed())
+			g.Expect(os.WriteFile(filepath.Join(cfg.Dir, "member", "wal", "0000000000000000-0000000000000000.wal"), nil, 0600)).To(Succeed())
+		}
+		app := &Application{logger: zaptest.NewLogger(t), Config: types.Config{InitialClusterToken: entry.flagToken, InitialClusterTokenPath: tokenPath}}
+		if entry.disabled {
+			app.Config.InitialClusterTokenPath = ""
+		}
+
+		g.Expect(app.applyInitialClusterToken(cfg)).To(Succeed())
+		if entry.expectGenerated {
+			g.Expect(cfg.InitialClusterToken).To(HavePrefix("etcd-main-0-"))
+			g.Expect(cfg.InitialClusterToken).To(HaveLen(len("etcd-main-0-") + 2*generatedClusterTokenBytes))
+		} else {
+			g.Expect(cfg.InitialClusterToken).To(Equal(entry.expectedToken))
+		}
+		persisted, err := loadInitialClusterToken(tokenPath)
+		g.Expect(err).ToNot(HaveOccurred())
+		if entry.expectPersisting {
+			g.Expect(persisted).To(Equal(cfg.InitialClusterToken))
+		} else {
+			g.Expect(persisted).To(Equal(entry.persistedToken))
+		}
+	}
+}
@@ -0,0 +1,140 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/audit"
+	"github.com/gardener/etcd-wrapper/internal/maintenance"
+	"github.com/gardener/etcd-wrapper/internal/metrics"
+
+	"go.etcd.io/etcd/etcdserver/api/v3rpc/rpctypes"
+	"go.uber.org/zap"
+)
+
+const (
+	// compactionTriggerRevision indicates that a compaction is triggered by the number of revisions since the last compaction.
+	compactionTriggerRevision = "revision"
+	// compactionTriggerDBSize indicates that a compaction is triggered by the growth of the DB size since the last compaction.
+	compactionTriggerDBSize = "db-size"
+)
+
+// compactionTrigger decides whether the etcd history should be compacted based on the growth of the revision and
+// of the logically used DB size since the last compaction.
+type compactionTrigger struct {
+	revisionThreshold   int64
+	dbSizeGrowthPercent int64
+	// baselineRevision and baselineDBSize are the revision and the logically used DB size at the last compaction, or
+	// at the first observation. baselineRevision is zero before the first observation.
+	baselineRevision int64
+	baselineDBSize   int64
+	// compactedRevision is the revision to which etcd-wrapper has compacted last.
+	compactedRevision int64
+}
+
+// observe records the current revision and logically used DB size. It returns which trigger requires a compaction,
+// or an empty string if no compaction is required.
+func (t *compactionTrigger) observe(revision, dbSizeInUse int64) string {
+	if t.baselineRevision == 0 || revision < t.baselineRevision {
+		// first observation, or the data directory has been restored from an older snapshot.
+		t.reset(revision, dbSizeInUse)
+		return ""
+	}
+	if dbSizeInUse < t.baselineDBSize {
+		// the space freed by the last compaction is only reported once the backend has been committed.
+		t.baselineDBSize = dbSizeInUse
+	}
+	if t.revisionThreshold > 0 && revision-t.baselineRevision > t.revisionThreshold {
+		return compactionTriggerRevision
+	}
+	if t.dbSizeGrowthPercent > 0 && t.baselineDBSize > 0 && (dbSizeInUse-t.baselineDBSize)*100 > t.baselineDBSize*t.dbSizeGrowthPercent {
+		return compactionTriggerDBSize
+	}
+	return ""
+}
+
+// reset sets the baseline to the given revision and logically used DB size.
+func (t *compactionTrigger) reset(revision, dbSizeInUse int64) {
+	t.baselineRevision = revision
+	t.baselineDBSize = dbSizeInUse
+}
+
+// watchCompaction periodically checks the growth of the revision and of the DB size and compacts the etcd history
+// once a configured threshold is exceeded, independent of the auto-compaction of etcd. It stops when the application
+// context is cancelled.
+func (a *Application) watchCompaction() {
+	if a.Config.Compaction.RevisionThreshold <= 0 && a.Config.Compaction.DBSizeGrowthPercent <= 0 {
+		return
+	}
+	trigger := &compactionTrigger{
+		revisionThreshold:   a.Config.Compaction.RevisionThreshold,
+		dbSizeGrowthPercent: int64(a.Config.Compaction.DBSizeGrowthPercent),
+	}
+	ticker := time.NewTicker(a.Config.Compaction.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-ticker.C:
+			a.checkCompaction(trigger)
+		}
+	}
+}
+
+// checkCompaction compacts the etcd history if required by trigger, retaining the configured number of revisions.
+// Since a compaction is replicated to all members, only the leader compacts.
+func (a *Application) checkCompaction(trigger *compactionTrigger) {
+	etcd := a.getEtcd()
+	if etcd == nil || etcd.Server.Leader() != etcd.Server.ID() {
+		return
+	}
+	ctx, cancelFunc := context.WithTimeout(a.ctx, etcdGetTimeout)
+	defer cancelFunc()
+	status, err := a.etcdClient.Status(ctx, a.etcdClient.Endpoints()[0])
+	if err != nil {
+		a.logger.Error("failed to get etcd status for proactive compaction", zap.Error(err))
+		return
+	}
+	revision := status.Header.Revision
+	reason := trigger.observe(revision, status.DbSizeInUse)
+	if reason == "" {
+		return
+	}
+	compactRevision := revision - a.Config.Compaction.RetainedRevisions
+	if compactRevision <= trigger.compactedRevision {
+		return
+	}
+	a.logger.Info("compacting etcd history", zap.String("trigger", reason), zap.Int64("revision", compactRevision), zap.Int64("dbSizeInUse", status.DbSizeInUse))
+	record := maintenance.Record{
+		Operation:         maintenance.OperationCompaction,
+		Trigger:           reason,
+		Target:            strconv.FormatInt(compactRevision, 10),
+		StartedAt:         time.Now(),
+		DBSizeBefore:      status.DbSize,
+		DBSizeInUseBefore: status.DbSizeInUse,
+	}
+	err = audit.Record(a.auditLogger, audit.OperationCompact, strconv.FormatInt(compactRevision, 10), func() error {
+		_, err := a.etcdClient.Compact(ctx, compactRevision)
+		return err
+	})
+	if err != nil && !errors.Is(err, rpctypes.ErrCompacted) {
+		a.logger.Error("failed to compact etcd history", zap.Int64("revision", compactRevision), zap.Error(err))
+		a.recordMaintenance(record, err)
+		return
+	}
+	if err == nil {
+		metrics.ProactiveCompactionsTotal.WithLabelValues(reason).Inc()
+		a.recordMaintenance(record, nil)
+	}
+	// a compaction to an already compacted revision means that etcd has compacted beyond it by itself, e.g. via its auto-compaction.
+	trigger.compactedRevision = compactRevision
+	trigger.reset(revision, status.DbSizeInUse)
+}
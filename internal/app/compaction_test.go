@@ -0,0 +1,40 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestCompactionTrigger(t *testing.T) {
+	type observation struct {
+		revision        int64
+		dbSizeInUse     int64
+		expectedTrigger string
+	}
+	table := []struct {
+		description         string
+		revisionThreshold   int64
+		dbSizeGrowthPercent int64
+		observations        []observation
+	}{
+		{"should not trigger on the first observation", 100, 10, []observation{{1000, 1000, ""}}},
+		{"should trigger once revisions exceed the threshold", 100, 0, []observation{{1000, 1000, ""}, {1100, 5000, ""}, {1101, 5000, compactionTriggerRevision}}},
+		{"should trigger once DB size growth exceeds the threshold", 0, 10, []observation{{1000, 1000, ""}, {5000, 1100, ""}, {5000, 1101, compactionTriggerDBSize}}},
+		{"should measure DB size growth from the lowest size since the last compaction", 0, 10, []observation{{1000, 1000, ""}, {1000, 500, ""}, {1000, 551, compactionTriggerDBSize}}},
+		{"should reset the baseline when the revision decreases", 100, 0, []observation{{1000, 1000, ""}, {500, 1000, ""}, {601, 1000, compactionTriggerRevision}}},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		trigger := &compactionTrigger{revisionThreshold: entry.revisionThreshold, dbSizeGrowthPercent: entry.dbSizeGrowthPercent}
+		for _, o := range entry.observations {
+			g.Expect(trigger.observe(o.revision, o.dbSizeInUse)).To(Equal(o.expectedTrigger))
+		}
+	}
+}
@@ -0,0 +1,79 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/metrics"
+
+	"go.etcd.io/etcd/embed"
+	pb "go.etcd.io/etcd/etcdserver/etcdserverpb"
+	"go.uber.org/zap"
+)
+
+const corruptionAlarmCheckInterval = 30 * time.Second
+
+// applyCorruptCheckConfig enables the corruption checks of etcd as configured via etcd-wrapper flags. Checks which are
+// configured in the etcd configuration fetched from backup-restore take precedence, i.e. they are never disabled and
+// their interval is never overridden.
+func (a *Application) applyCorruptCheckConfig(cfg *embed.Config) {
+	if a.Config.CorruptCheck.InitialCheck {
+		cfg.ExperimentalInitialCorruptCheck = true
+	}
+	if cfg.ExperimentalCorruptCheckTime == 0 && a.Config.CorruptCheck.CheckTime > 0 {
+		cfg.ExperimentalCorruptCheckTime = a.Config.CorruptCheck.CheckTime
+	}
+	a.logger.Info("Configured etcd corruption checks",
+		zap.Bool("initialCorruptCheck", cfg.ExperimentalInitialCorruptCheck),
+		zap.Duration("corruptCheckTime", cfg.ExperimentalCorruptCheckTime))
+}
+
+// watchCorruptionAlarms periodically checks if etcd has raised a corruption alarm. It stops when the application
+// context is cancelled.
+func (a *Application) watchCorruptionAlarms() {
+	ticker := time.NewTicker(corruptionAlarmCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-ticker.C:
+			a.checkCorruptionAlarm()
+		}
+	}
+}
+
+// checkCorruptionAlarm lists the active etcd alarms and records whether a corruption alarm is active. Every active
+// corruption alarm is logged as an error so that it can be alerted upon.
+func (a *Application) checkCorruptionAlarm() {
+	ctx, cancelFunc := context.WithTimeout(a.ctx, etcdGetTimeout)
+	defer cancelFunc()
+	response, err := a.etcdClient.AlarmList(ctx)
+	if err != nil {
+		a.logger.Error("failed to list etcd alarms", zap.Error(err))
+		return
+	}
+	active := false
+	for _, alarm := range response.Alarms {
+		if alarm.Alarm == pb.AlarmType_CORRUPT {
+			active = true
+			a.logger.Error("etcd has raised a corruption alarm, data of this member might be inconsistent with the rest of the cluster",
+				zap.String("memberID", fmt.Sprintf("%x", alarm.MemberID)))
+		}
+	}
+	if active && !a.corruptionAlarm.Load() {
+		metrics.CorruptionAlarmsTotal.Inc()
+	}
+	a.corruptionAlarm.Store(active)
+	value := 0.0
+	if active {
+		value = 1
+	}
+	metrics.CorruptionAlarmActive.Set(value)
+}
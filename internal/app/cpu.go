@@ -0,0 +1,134 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"math"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/cpulimit"
+	"github.com/gardener/etcd-wrapper/internal/memlimit"
+	"github.com/gardener/etcd-wrapper/internal/types"
+
+	"go.etcd.io/etcd/embed"
+	"go.etcd.io/etcd/etcdserver"
+	"go.uber.org/zap"
+)
+
+const (
+	// cpuConstrainedThreshold is the container CPU limit below which the snapshot count and the backend batching of
+	// etcd are scaled down proportionally.
+	cpuConstrainedThreshold = 2.0
+	// defaultBackendBatchLimit is the backend batch limit used by etcd if none is configured.
+	defaultBackendBatchLimit = 10000
+	// defaultBackendBatchInterval is the backend batch interval used by etcd if none is configured.
+	defaultBackendBatchInterval = 100 * time.Millisecond
+	// minBackendBatchLimit is the lowest backend batch limit derived from the container CPU limit.
+	minBackendBatchLimit = 1000
+	// minBackendBatchInterval is the lowest backend batch interval derived from the container CPU limit.
+	minBackendBatchInterval = 10 * time.Millisecond
+)
+
+// applyCPULimits sets GOMAXPROCS and scales down the snapshot count and the backend batching of etcd according to
+// the CPU limit of the container, so that snapshots and backend commits do not stall etcd for longer than the CFS
+// quota allows, which would otherwise cause leader elections on throttled members. Explicitly configured values take
+// precedence.
+func (a *Application) applyCPULimits(cfg *embed.Config) {
+	var containerLimit float64
+	if a.Config.CPULimit.Enabled {
+		limit, limited, err := cpulimit.ContainerCPULimit(memlimit.DefaultCgroupRoot)
+		if err != nil {
+			a.logger.Warn("failed to read CPU limit of container", zap.Error(err))
+		}
+		if limited {
+			containerLimit = limit
+		}
+	}
+	_, goMaxProcsEnvSet := os.LookupEnv("GOMAXPROCS")
+	if goMaxProcs := resolveGoMaxProcs(a.Config.CPULimit, containerLimit, goMaxProcsEnvSet); goMaxProcs > 0 {
+		runtime.GOMAXPROCS(goMaxProcs)
+	}
+	if a.Config.MemoryLimit.SnapshotCount == 0 {
+		cfg.SnapshotCount = scaleSnapshotCount(containerLimit, cfg.SnapshotCount)
+	}
+	cfg.BackendBatchLimit = resolveBackendBatchLimit(a.Config.CPULimit, containerLimit, cfg.BackendBatchLimit)
+	cfg.BackendBatchInterval = resolveBackendBatchInterval(a.Config.CPULimit, containerLimit, cfg.BackendBatchInterval)
+	a.logger.Info("Configured CPU limits",
+		zap.Float64("containerCPULimit", containerLimit),
+		zap.Int("goMaxProcs", runtime.GOMAXPROCS(0)),
+		zap.Uint64("snapshotCount", cfg.SnapshotCount),
+		zap.Int("backendBatchLimit", cfg.BackendBatchLimit),
+		zap.Duration("backendBatchInterval", cfg.BackendBatchInterval))
+}
+
+// resolveGoMaxProcs returns the GOMAXPROCS to set, or zero if it should be left unchanged. An explicitly configured
+// value takes precedence over the GOMAXPROCS environment variable, which takes precedence over the value derived from
+// the container CPU limit.
+func resolveGoMaxProcs(config types.CPULimitConfig, containerLimit float64, goMaxProcsEnvSet bool) int {
+	switch {
+	case config.GoMaxProcs > 0:
+		return config.GoMaxProcs
+	case goMaxProcsEnvSet || containerLimit <= 0:
+		return 0
+	default:
+		return max(1, int(math.Ceil(containerLimit)))
+	}
+}
+
+// cpuScale returns the factor by which CPU-bound settings of etcd are scaled for the container CPU limit, which is 1
+// if the container is not CPU constrained.
+func cpuScale(containerLimit float64) float64 {
+	if containerLimit <= 0 || containerLimit >= cpuConstrainedThreshold {
+		return 1
+	}
+	return containerLimit / cpuConstrainedThreshold
+}
+
+// scaleSnapshotCount lowers the snapshot count of etcd proportionally to the container CPU limit, which keeps the
+// in-memory raft log and hence the work of the garbage collector small, but never below the number of entries
+// retained for slow followers.
+func scaleSnapshotCount(containerLimit float64, snapshotCount uint64) uint64 {
+	scale := cpuScale(containerLimit)
+	if scale >= 1 {
+		return snapshotCount
+	}
+	return min(snapshotCount, max(uint64(float64(snapshotCount)*scale), etcdserver.DefaultSnapshotCatchUpEntries))
+}
+
+// resolveBackendBatchLimit returns the maximum number of operations etcd batches into one backend transaction. An
+// explicitly configured limit takes precedence. Otherwise the limit of etcd is scaled down proportionally to the
+// container CPU limit, which shortens the backend commits.
+func resolveBackendBatchLimit(config types.CPULimitConfig, containerLimit float64, etcdBatchLimit int) int {
+	if config.BackendBatchLimit > 0 {
+		return config.BackendBatchLimit
+	}
+	scale := cpuScale(containerLimit)
+	if scale >= 1 {
+		return etcdBatchLimit
+	}
+	if etcdBatchLimit <= 0 {
+		etcdBatchLimit = defaultBackendBatchLimit
+	}
+	return min(etcdBatchLimit, max(int(float64(etcdBatchLimit)*scale), minBackendBatchLimit))
+}
+
+// resolveBackendBatchInterval returns the maximum time after which etcd commits a backend transaction. An explicitly
+// configured interval takes precedence. Otherwise the interval of etcd is scaled down proportionally to the container
+// CPU limit, which shortens the backend commits.
+func resolveBackendBatchInterval(config types.CPULimitConfig, containerLimit float64, etcdBatchInterval time.Duration) time.Duration {
+	if config.BackendBatchInterval > 0 {
+		return config.BackendBatchInterval
+	}
+	scale := cpuScale(containerLimit)
+	if scale >= 1 {
+		return etcdBatchInterval
+	}
+	if etcdBatchInterval <= 0 {
+		etcdBatchInterval = defaultBackendBatchInterval
+	}
+	return min(etcdBatchInterval, max(time.Duration(float64(etcdBatchInterval)*scale), minBackendBatchInterval))
+}
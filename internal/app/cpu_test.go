@@ -0,0 +1,83 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/types"
+
+	. "github.com/onsi/gomega"
+	"go.etcd.io/etcd/etcdserver"
+)
+
+func TestResolveGoMaxProcs(t *testing.T) {
+	table := []struct {
+		description      string
+		config           types.CPULimitConfig
+		containerLimit   float64
+		goMaxProcsEnvSet bool
+		expectedProcs    int
+	}{
+		{"should round container CPU limit up", types.CPULimitConfig{Enabled: true}, 1.5, false, 2},
+		{"should use at least one CPU", types.CPULimitConfig{Enabled: true}, 0.25, false, 1},
+		{"should not set GOMAXPROCS when container has no CPU limit", types.CPULimitConfig{Enabled: true}, 0, false, 0},
+		{"should respect GOMAXPROCS environment variable", types.CPULimitConfig{Enabled: true}, 1.5, true, 0},
+		{"should prefer explicitly configured value", types.CPULimitConfig{Enabled: true, GoMaxProcs: 4}, 1.5, true, 4},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		g.Expect(resolveGoMaxProcs(entry.config, entry.containerLimit, entry.goMaxProcsEnvSet)).To(Equal(entry.expectedProcs))
+	}
+}
+
+func TestScaleSnapshotCount(t *testing.T) {
+	table := []struct {
+		description    string
+		containerLimit float64
+		snapshotCount  uint64
+		expectedCount  uint64
+	}{
+		{"should keep snapshot count when container has no CPU limit", 0, 100000, 100000},
+		{"should keep snapshot count when container is not CPU constrained", 2, 100000, 100000},
+		{"should scale snapshot count down for constrained containers", 0.5, 100000, 25000},
+		{"should not lower snapshot count below the catch-up entries", 0.1, 20000, etcdserver.DefaultSnapshotCatchUpEntries},
+		{"should not raise a snapshot count below the catch-up entries", 0.1, 1000, 1000},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		g.Expect(scaleSnapshotCount(entry.containerLimit, entry.snapshotCount)).To(Equal(entry.expectedCount))
+	}
+}
+
+func TestResolveBackendBatching(t *testing.T) {
+	table := []struct {
+		description       string
+		config            types.CPULimitConfig
+		containerLimit    float64
+		etcdBatchLimit    int
+		etcdBatchInterval time.Duration
+		expectedLimit     int
+		expectedInterval  time.Duration
+	}{
+		{"should keep etcd batching when container is not CPU constrained", types.CPULimitConfig{Enabled: true}, 4, 0, 0, 0, 0},
+		{"should scale etcd defaults down for constrained containers", types.CPULimitConfig{Enabled: true}, 1, 0, 0, 5000, 50 * time.Millisecond},
+		{"should scale configured etcd batching down for constrained containers", types.CPULimitConfig{Enabled: true}, 0.5, 20000, 200 * time.Millisecond, 5000, 50 * time.Millisecond},
+		{"should not scale below the minimum", types.CPULimitConfig{Enabled: true}, 0.1, 0, 0, minBackendBatchLimit, minBackendBatchInterval},
+		{"should prefer explicitly configured values", types.CPULimitConfig{Enabled: true, BackendBatchLimit: 500, BackendBatchInterval: time.Second}, 0.5, 0, 0, 500, time.Second},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		g.Expect(resolveBackendBatchLimit(entry.config, entry.containerLimit, entry.etcdBatchLimit)).To(Equal(entry.expectedLimit))
+		g.Expect(resolveBackendBatchInterval(entry.config, entry.containerLimit, entry.etcdBatchInterval)).To(Equal(entry.expectedInterval))
+	}
+}
@@ -0,0 +1,39 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+
+	"go.etcd.io/etcd/clientv3"
+)
+
+// crashStatus is the status of the Application and etcd written into crash bundles.
+type crashStatus struct {
+	// Wrapper is the status of the Application.
+	Wrapper Status `json:"wrapper"`
+	// Etcd is the status of the embedded etcd. It is nil if etcd is not running or its status cannot be determined.
+	Etcd *clientv3.StatusResponse `json:"etcd,omitempty"`
+	// EtcdError is the error encountered while determining the status of etcd.
+	EtcdError string `json:"etcdError,omitempty"`
+}
+
+// crashStatus returns the status written into crash bundles. Since the application context might already be cancelled,
+// the status of etcd is requested independently of it.
+func (a *Application) crashStatus() any {
+	status := crashStatus{Wrapper: a.Status()}
+	if !a.etcdRunning() || a.etcdClient == nil {
+		return status
+	}
+	ctx, cancelFunc := context.WithTimeout(context.Background(), etcdGetTimeout)
+	defer cancelFunc()
+	etcdStatus, err := a.etcdClient.Status(ctx, a.etcdClient.Endpoints()[0])
+	if err != nil {
+		status.EtcdError = err.Error()
+		return status
+	}
+	status.Etcd = etcdStatus
+	return status
+}
@@ -0,0 +1,145 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/metrics"
+
+	"go.etcd.io/etcd/etcdserver"
+	"go.uber.org/zap"
+)
+
+// dbSizeSample is the DB size of etcd observed at a point in time.
+type dbSizeSample struct {
+	at   time.Time
+	size int64
+}
+
+// dbSizeTrend tracks the DB size of etcd within a sliding time window and derives its growth rate.
+type dbSizeTrend struct {
+	window  time.Duration
+	samples []dbSizeSample
+}
+
+// observe records the DB size observed at the given time and drops all samples which have left the window.
+func (t *dbSizeTrend) observe(at time.Time, size int64) {
+	t.samples = append(t.samples, dbSizeSample{at: at, size: size})
+	cutoff := at.Add(-t.window)
+	i := 0
+	for i < len(t.samples) && t.samples[i].at.Before(cutoff) {
+		i++
+	}
+	t.samples = t.samples[i:]
+}
+
+// growthRate returns the growth rate of the DB size in bytes per second as the slope of the least-squares fit through
+// all samples. The rate is only returned once the samples span at least half of the window, to not extrapolate from
+// short-term fluctuations, e.g. right after etcd-wrapper has started.
+func (t *dbSizeTrend) growthRate() (float64, bool) {
+	if len(t.samples) < 2 || t.samples[len(t.samples)-1].at.Sub(t.samples[0].at) < t.window/2 {
+		return 0, false
+	}
+	origin := t.samples[0].at
+	var sumX, sumY, sumXY, sumXX float64
+	for _, sample := range t.samples {
+		x, y := sample.at.Sub(origin).Seconds(), float64(sample.size)
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+	n := float64(len(t.samples))
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return 0, false
+	}
+	return (n*sumXY - sumX*sumY) / denominator, true
+}
+
+// timeToQuotaExhaustion projects the time until the DB size reaches quota when growing at rate bytes per second. It
+// returns false if the DB size does not grow.
+func timeToQuotaExhaustion(size, quota int64, rate float64) (time.Duration, bool) {
+	if rate <= 0 {
+		return 0, false
+	}
+	if size >= quota {
+		return 0, true
+	}
+	seconds := float64(quota-size) / rate
+	if seconds >= math.MaxInt64/float64(time.Second) {
+		return 0, false
+	}
+	return time.Duration(seconds * float64(time.Second)), true
+}
+
+// watchDBSizeTrend periodically samples the DB size of etcd, exports its growth rate and projects when the backend
+// quota of etcd will be exhausted. A warning is logged and reflected in metrics while the projected time drops below
+// the configured horizon. It stops when the application context is cancelled.
+func (a *Application) watchDBSizeTrend() {
+	if a.Config.DBSizeTrend.Horizon <= 0 {
+		return
+	}
+	quota := a.cfg.QuotaBackendBytes
+	if quota < 0 {
+		a.logger.Info("Backend quota of etcd is disabled, not predicting its exhaustion")
+		return
+	}
+	if quota == 0 {
+		quota = etcdserver.DefaultQuotaBytes
+	}
+	trend := &dbSizeTrend{window: a.Config.DBSizeTrend.Window}
+	ticker := time.NewTicker(a.Config.DBSizeTrend.SampleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-ticker.C:
+			a.checkDBSizeTrend(trend, quota)
+		}
+	}
+}
+
+// checkDBSizeTrend samples the DB size into trend and updates the prediction of the exhaustion of quota.
+func (a *Application) checkDBSizeTrend(trend *dbSizeTrend, quota int64) {
+	if !a.etcdRunning() {
+		return
+	}
+	ctx, cancelFunc := context.WithTimeout(a.ctx, etcdGetTimeout)
+	defer cancelFunc()
+	status, err := a.etcdClient.Status(ctx, a.etcdClient.Endpoints()[0])
+	if err != nil {
+		a.logger.Error("failed to get etcd status for DB size trend", zap.Error(err))
+		return
+	}
+	trend.observe(time.Now(), status.DbSize)
+	rate, ok := trend.growthRate()
+	if !ok {
+		return
+	}
+	metrics.DBSizeGrowthRate.Set(rate)
+	eta, growing := timeToQuotaExhaustion(status.DbSize, quota, rate)
+	if !growing {
+		metrics.DBQuotaExhaustionSeconds.Set(math.Inf(1))
+		metrics.DBQuotaExhaustionPredicted.Set(0)
+		a.quotaExhaustionPredicted.Store(false)
+		return
+	}
+	metrics.DBQuotaExhaustionSeconds.Set(eta.Seconds())
+	if eta >= a.Config.DBSizeTrend.Horizon {
+		metrics.DBQuotaExhaustionPredicted.Set(0)
+		a.quotaExhaustionPredicted.Store(false)
+		return
+	}
+	metrics.DBQuotaExhaustionPredicted.Set(1)
+	a.quotaExhaustionPredicted.Store(true)
+	a.logger.Warn("etcd DB is projected to exceed its backend quota within the horizon", zap.Duration("timeToQuotaExhaustion", eta), zap.Duration("horizon", a.Config.DBSizeTrend.Horizon),
+		zap.Int64("dbSize", status.DbSize), zap.Int64("quotaBackendBytes", quota), zap.Float64("growthBytesPerSecond", rate))
+}
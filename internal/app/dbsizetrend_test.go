@@ -0,0 +1,67 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestDBSizeTrendGrowthRate(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	table := []struct {
+		description  string
+		window       time.Duration
+		sizes        []int64
+		expectRate   bool
+		expectedRate float64
+	}{
+		{"should not compute rate from a single sample", time.Hour, []int64{1000}, false, 0},
+		{"should not compute rate before half of the window has been sampled", time.Hour, []int64{1000, 2000, 3000}, false, 0},
+		{"should compute linear growth rate", 2 * time.Minute, []int64{0, 60, 120}, true, 1},
+		{"should compute negative rate when DB size shrinks", 2 * time.Minute, []int64{120, 60, 0}, true, -1},
+		{"should fit a line through fluctuating sizes", 4 * time.Minute, []int64{0, 120, 60, 180, 240}, true, 0.9},
+		{"should only consider samples within the window", 2 * time.Minute, []int64{100000, 0, 60, 120}, true, 1},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		trend := &dbSizeTrend{window: entry.window}
+		for i, size := range entry.sizes {
+			trend.observe(start.Add(time.Duration(i)*time.Minute), size)
+		}
+		rate, ok := trend.growthRate()
+		g.Expect(ok).To(Equal(entry.expectRate))
+		g.Expect(rate).To(BeNumerically("~", entry.expectedRate, 1e-9))
+	}
+}
+
+func TestTimeToQuotaExhaustion(t *testing.T) {
+	table := []struct {
+		description    string
+		size           int64
+		quota          int64
+		rate           float64
+		expectGrowing  bool
+		expectedResult time.Duration
+	}{
+		{"should not project exhaustion when DB size does not grow", 1000, 2000, 0, false, 0},
+		{"should not project exhaustion when DB size shrinks", 1000, 2000, -1, false, 0},
+		{"should project exhaustion at the growth rate", 1000, 4600, 1, true, time.Hour},
+		{"should project immediate exhaustion when quota is already exceeded", 3000, 2000, 1, true, 0},
+		{"should not project exhaustion beyond the representable duration", 0, 1 << 62, 1e-9, false, 0},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		eta, growing := timeToQuotaExhaustion(entry.size, entry.quota, entry.rate)
+		g.Expect(growing).To(Equal(entry.expectGrowing))
+		g.Expect(eta).To(Equal(entry.expectedResult))
+	}
+}
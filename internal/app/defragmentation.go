@@ -0,0 +1,356 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/audit"
+	"github.com/gardener/etcd-wrapper/internal/maintenance"
+	"github.com/gardener/etcd-wrapper/internal/metrics"
+
+	"go.etcd.io/etcd/clientv3"
+	"go.etcd.io/etcd/clientv3/concurrency"
+	"go.etcd.io/etcd/etcdserver/etcdserverpb"
+	"go.uber.org/zap"
+)
+
+const (
+	// maintenanceOperationDefragmentation is the name under which scheduled defragmentations are submitted to the
+	// maintenance scheduler.
+	maintenanceOperationDefragmentation = "defragmentation"
+	// defragmentationTriggerSchedule indicates in the maintenance history that a defragmentation has been triggered by
+	// the defragmentation schedule.
+	defragmentationTriggerSchedule = "schedule"
+	// defragmentationRetryInterval is the interval in which the leader checks whether the followers have defragmented.
+	defragmentationRetryInterval = 10 * time.Second
+	// defragmentationRoundFormat is the format of the scheduled time identifying a defragmentation round.
+	defragmentationRoundFormat = "20060102T1504Z"
+)
+
+// watchDefragmentation starts a defragmentation round at every time matching the defragmentation schedule, unless
+// the maintenance window is closed, in which case the round is queued till it opens. It stops when the application
+// context is cancelled.
+func (a *Application) watchDefragmentation() {
+	if a.Config.Defragmentation.Schedule == "" {
+		return
+	}
+	schedule, err := maintenance.ParseSchedule(a.Config.Defragmentation.Schedule)
+	if err != nil {
+		a.logger.Error("invalid defragmentation schedule", zap.Error(err))
+		return
+	}
+	for {
+		next := schedule.Next(time.Now())
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-a.ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		// all members derive the same round from the schedule, independent of when they get their turn.
+		round := next.UTC().Format(defragmentationRoundFormat)
+		if _, err = a.maintenance.Submit(maintenanceOperationDefragmentation, func() error { return a.defragmentRound(round) }); err != nil {
+			a.logger.Error("failed to defragment etcd backend", zap.String("round", round), zap.Error(err))
+		}
+	}
+}
+
+// defragmentRound defragments the members in the given round. If a maintenance leader is elected, it orchestrates the
+// defragmentation of all members, otherwise every member defragments itself in turn.
+func (a *Application) defragmentRound(round string) error {
+	if a.maintenanceLeaderElection.Load() {
+		return a.defragmentAsMaintenanceLeader(round)
+	}
+	return a.defragmentInTurn(round)
+}
+
+// defragmentAsMaintenanceLeader defragments the backends of all started members which have not defragmented in the
+// given round yet, one at a time, if this etcd-wrapper is the maintenance leader. Other etcd-wrappers skip the round.
+// The raft leader is defragmented last, after its leadership has been transferred to a follower. Every defragmentation
+// is recorded, so that a maintenance leader elected during the round continues where its predecessor stopped.
+func (a *Application) defragmentAsMaintenanceLeader(round string) error {
+	if !a.maintenanceLeader.Load() {
+		a.logger.Info("not the maintenance leader, leaving defragmentation to the maintenance leader", zap.String("round", round))
+		return nil
+	}
+	etcd := a.getEtcd()
+	if etcd == nil {
+		return fmt.Errorf("etcd is not running")
+	}
+	ctx, cancelFunc := context.WithTimeout(a.ctx, etcdGetTimeout)
+	memberList, err := a.etcdClient.MemberList(ctx)
+	if err != nil {
+		cancelFunc()
+		return fmt.Errorf("failed to list members: %w", err)
+	}
+	defragmented, err := a.defragmentedMembers(ctx, round)
+	cancelFunc()
+	if err != nil {
+		return err
+	}
+	raftLeader := uint64(etcd.Server.Leader())
+	for _, member := range defragmentationOrder(memberList.Members, raftLeader, defragmented) {
+		if !a.maintenanceLeader.Load() {
+			return fmt.Errorf("lost maintenance leadership during defragmentation round %s", round)
+		}
+		if member.ID == raftLeader {
+			if err = a.transferLeadership(member, memberList.Members, defragmented); err != nil {
+				return err
+			}
+		}
+		if err = a.defragmentMember(round, round+"/"+member.Name, member.ClientURLs[0]); err != nil {
+			return err
+		}
+		if err = a.recordDefragmentation(round, member.ID); err != nil {
+			return err
+		}
+		defragmented[member.ID] = true
+	}
+	return nil
+}
+
+// transferLeadership transfers the leadership of the raft leader to a follower before the raft leader defragments.
+func (a *Application) transferLeadership(raftLeader *etcdserverpb.Member, members []*etcdserverpb.Member, defragmented map[uint64]bool) error {
+	transferee, ok := leadershipTransferee(members, raftLeader.ID, defragmented)
+	if !ok {
+		return nil
+	}
+	a.logger.Info("transferring leadership before defragmentation", zap.String("leader", raftLeader.Name), zap.String("transferee", strconv.FormatUint(transferee, 16)))
+	ctx, cancelFunc := context.WithTimeout(a.ctx, etcdGetTimeout)
+	defer cancelFunc()
+	if err := a.moveLeader(ctx, raftLeader, transferee); err != nil {
+		return fmt.Errorf("failed to transfer leadership before defragmentation: %w", err)
+	}
+	return nil
+}
+
+// defragmentInTurn defragments the backend of this member in the given round while holding a cluster-wide lock in
+// etcd, so that members defragment one at a time and at most one member is blocked by a defragmentation. Followers
+// take their turn as soon as they hold the lock. The leader only takes its turn once all other members have recorded
+// their defragmentation in the round, or once the defragmentation timeout has passed, and transfers its leadership to
+// a follower first, so that the cluster does not lose its leader while the backend is blocked.
+func (a *Application) defragmentInTurn(round string) error {
+	deadline := time.Now().Add(a.Config.Defragmentation.Timeout)
+	session, err := concurrency.NewSession(a.etcdClient, concurrency.WithTTL(int(a.Config.Defragmentation.LockTTL.Seconds())), concurrency.WithContext(a.ctx))
+	if err != nil {
+		return fmt.Errorf("failed to create session for defragmentation lock: %w", err)
+	}
+	defer func() {
+		if err := session.Close(); err != nil {
+			a.logger.Warn("failed to close session of defragmentation lock", zap.Error(err))
+		}
+	}()
+	mutex := concurrency.NewMutex(session, path.Join(a.Config.Defragmentation.KeyPrefix, "lock"))
+	for {
+		if err = mutex.Lock(a.ctx); err != nil {
+			return fmt.Errorf("failed to acquire defragmentation lock: %w", err)
+		}
+		done, err := a.takeDefragmentationTurn(round, deadline)
+		ctx, cancelFunc := context.WithTimeout(context.Background(), etcdGetTimeout)
+		if unlockErr := mutex.Unlock(ctx); unlockErr != nil {
+			a.logger.Warn("failed to release defragmentation lock, it is released once its lease expires", zap.Error(unlockErr))
+		}
+		cancelFunc()
+		if err != nil || done {
+			return err
+		}
+		select {
+		case <-a.ctx.Done():
+			return a.ctx.Err()
+		case <-time.After(defragmentationRetryInterval):
+		}
+	}
+}
+
+// takeDefragmentationTurn defragments the backend of this member if it is its turn in the round. It returns true if
+// the member has defragmented in the round, and false if it has to wait for other members. The caller must hold the
+// defragmentation lock.
+func (a *Application) takeDefragmentationTurn(round string, deadline time.Time) (bool, error) {
+	etcd := a.getEtcd()
+	if etcd == nil {
+		return false, fmt.Errorf("etcd is not running")
+	}
+	self := uint64(etcd.Server.ID())
+	ctx, cancelFunc := context.WithTimeout(a.ctx, etcdGetTimeout)
+	defer cancelFunc()
+	memberList, err := a.etcdClient.MemberList(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to list members: %w", err)
+	}
+	defragmented, err := a.defragmentedMembers(ctx, round)
+	if err != nil {
+		return false, err
+	}
+	if defragmented[self] {
+		return true, nil
+	}
+
+	if uint64(etcd.Server.Leader()) == self {
+		pending := pendingDefragmentations(memberList.Members, self, defragmented)
+		if len(pending) > 0 {
+			if time.Now().Before(deadline) {
+				a.logger.Info("waiting for followers to defragment before the leader", zap.String("round", round), zap.Strings("pending", pending))
+				return false, nil
+			}
+			a.logger.Warn("defragmentation timeout has passed, defragmenting the leader before all followers", zap.String("round", round), zap.Strings("pending", pending))
+		}
+		if transferee, ok := leadershipTransferee(memberList.Members, self, defragmented); ok {
+			a.logger.Info("transferring leadership before defragmentation", zap.String("transferee", strconv.FormatUint(transferee, 16)))
+			if err = audit.Record(a.auditLogger, audit.OperationLeadershipTransfer, memberByID(memberList.Members, transferee).Name, func() error {
+				_, err := a.etcdClient.MoveLeader(ctx, transferee)
+				return err
+			}); err != nil {
+				return false, fmt.Errorf("failed to transfer leadership before defragmentation: %w", err)
+			}
+		}
+	}
+
+	if err = a.defragmentMember(round, round, a.etcdClient.Endpoints()[0]); err != nil {
+		return false, err
+	}
+	return true, a.recordDefragmentation(round, self)
+}
+
+// defragmentMember defragments the backend of the member serving endpoint in the given round and records the
+// defragmentation of target in the maintenance history.
+func (a *Application) defragmentMember(round, target, endpoint string) error {
+	a.logger.Info("defragmenting etcd backend", zap.String("round", round), zap.String("endpoint", endpoint))
+	ctx, cancelFunc := context.WithTimeout(a.ctx, etcdGetTimeout)
+	defer cancelFunc()
+	status, err := a.etcdClient.Status(ctx, endpoint)
+	if err != nil {
+		return fmt.Errorf("failed to get etcd status before defragmentation: %w", err)
+	}
+	record := maintenance.Record{
+		Operation:         maintenance.OperationDefragmentation,
+		Trigger:           defragmentationTriggerSchedule,
+		Target:            target,
+		StartedAt:         time.Now(),
+		DBSizeBefore:      status.DbSize,
+		DBSizeInUseBefore: status.DbSizeInUse,
+	}
+	defragCtx, defragCancelFunc := context.WithTimeout(a.ctx, a.Config.Defragmentation.LockTTL)
+	defer defragCancelFunc()
+	err = audit.Record(a.auditLogger, audit.OperationDefragment, target, func() error {
+		_, err := a.etcdClient.Defragment(defragCtx, endpoint)
+		return err
+	})
+	a.recordMaintenanceOf(endpoint, record, err)
+	if err != nil {
+		metrics.DefragmentationsTotal.WithLabelValues(string(audit.OutcomeFailed)).Inc()
+		return fmt.Errorf("failed to defragment etcd backend: %w", err)
+	}
+	metrics.DefragmentationsTotal.WithLabelValues(string(audit.OutcomeSucceeded)).Inc()
+	a.logger.Info("defragmented etcd backend", zap.String("round", round), zap.String("endpoint", endpoint))
+	return nil
+}
+
+// defragmentedMembers returns the IDs of the members which have recorded their defragmentation in the round.
+func (a *Application) defragmentedMembers(ctx context.Context, round string) (map[uint64]bool, error) {
+	prefix := defragmentationRoundPrefix(a.Config.Defragmentation.KeyPrefix, round)
+	response, err := a.etcdClient.Get(ctx, prefix, clientv3.WithPrefix(), clientv3.WithKeysOnly())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get defragmented members: %w", err)
+	}
+	defragmented := make(map[uint64]bool, len(response.Kvs))
+	for _, kv := range response.Kvs {
+		id, err := strconv.ParseUint(string(kv.Key[len(prefix):]), 16, 64)
+		if err != nil {
+			continue
+		}
+		defragmented[id] = true
+	}
+	return defragmented, nil
+}
+
+// recordDefragmentation records the defragmentation of the member in the round. The record is bound to a lease which
+// outlives the round, so that records of past rounds do not accumulate.
+func (a *Application) recordDefragmentation(round string, id uint64) error {
+	ctx, cancelFunc := context.WithTimeout(a.ctx, etcdGetTimeout)
+	defer cancelFunc()
+	lease, err := a.etcdClient.Grant(ctx, int64((2 * a.Config.Defragmentation.Timeout).Seconds()))
+	if err != nil {
+		return fmt.Errorf("failed to grant lease for defragmentation record: %w", err)
+	}
+	key := defragmentationRoundPrefix(a.Config.Defragmentation.KeyPrefix, round) + strconv.FormatUint(id, 16)
+	if _, err = a.etcdClient.Put(ctx, key, time.Now().UTC().Format(time.RFC3339), clientv3.WithLease(lease.ID)); err != nil {
+		return fmt.Errorf("failed to record defragmentation: %w", err)
+	}
+	return nil
+}
+
+// defragmentationRoundPrefix returns the key prefix under which the defragmentations of the members in the round are recorded.
+func defragmentationRoundPrefix(keyPrefix, round string) string {
+	return path.Join(keyPrefix, "rounds", round) + "/"
+}
+
+// pendingDefragmentations returns the names of the started members other than self which have not defragmented yet.
+func pendingDefragmentations(members []*etcdserverpb.Member, self uint64, defragmented map[uint64]bool) []string {
+	var pending []string
+	for _, member := range members {
+		// a member which has not been started yet has no backend to defragment.
+		if member.ID == self || member.Name == "" || defragmented[member.ID] {
+			continue
+		}
+		pending = append(pending, member.Name)
+	}
+	slices.Sort(pending)
+	return pending
+}
+
+// defragmentationOrder returns the started members which have not defragmented yet in the order in which the
+// maintenance leader defragments them, which is by ID with the raft leader last.
+func defragmentationOrder(members []*etcdserverpb.Member, raftLeader uint64, defragmented map[uint64]bool) []*etcdserverpb.Member {
+	var order []*etcdserverpb.Member
+	for _, member := range members {
+		// a member which has not been started yet has no backend to defragment.
+		if member.Name != "" && len(member.ClientURLs) > 0 && !defragmented[member.ID] {
+			order = append(order, member)
+		}
+	}
+	slices.SortFunc(order, func(x, y *etcdserverpb.Member) int {
+		if (x.ID == raftLeader) != (y.ID == raftLeader) {
+			if x.ID == raftLeader {
+				return 1
+			}
+			return -1
+		}
+		return cmp.Compare(x.ID, y.ID)
+	})
+	return order
+}
+
+// leadershipTransferee returns the ID of the started voting member other than self to which the leadership is
+// transferred before the leader defragments. Members which have already defragmented in the round are preferred, so
+// that the new leader is not blocked by a defragmentation later on.
+func leadershipTransferee(members []*etcdserverpb.Member, self uint64, defragmented map[uint64]bool) (uint64, bool) {
+	var candidates []*etcdserverpb.Member
+	for _, member := range members {
+		if member.ID != self && member.Name != "" && !member.IsLearner {
+			candidates = append(candidates, member)
+		}
+	}
+	if len(candidates) == 0 {
+		return 0, false
+	}
+	slices.SortFunc(candidates, func(x, y *etcdserverpb.Member) int {
+		if defragmented[x.ID] != defragmented[y.ID] {
+			if defragmented[x.ID] {
+				return -1
+			}
+			return 1
+		}
+		return cmp.Compare(x.ID, y.ID)
+	})
+	return candidates[0].ID, true
+}
@@ -0,0 +1,192 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/audit"
+	"github.com/gardener/etcd-wrapper/internal/maintenance"
+	"github.com/gardener/etcd-wrapper/internal/types"
+
+	. "github.com/onsi/gomega"
+	"go.etcd.io/etcd/clientv3"
+	"go.etcd.io/etcd/etcdserver/etcdserverpb"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestPendingDefragmentations(t *testing.T) {
+	members := []*etcdserverpb.Member{
+		{ID: 1, Name: "etcd-0"},
+		{ID: 2, Name: "etcd-2"},
+		{ID: 3, Name: "etcd-1", IsLearner: true},
+		{ID: 4},
+	}
+	table := []struct {
+		description     string
+		defragmented    map[uint64]bool
+		expectedPending []string
+	}{
+		{"should return all other started members including learners", nil, []string{"etcd-1", "etcd-2"}},
+		{"should skip members which have defragmented", map[uint64]bool{2: true}, []string{"etcd-1"}},
+		{"should return no members once all have defragmented", map[uint64]bool{2: true, 3: true}, nil},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		g.Expect(pendingDefragmentations(members, 1, entry.defragmented)).To(Equal(entry.expectedPending))
+	}
+}
+
+func TestLeadershipTransferee(t *testing.T) {
+	table := []struct {
+		description        string
+		members            []*etcdserverpb.Member
+		defragmented       map[uint64]bool
+		expectedTransferee uint64
+		expectedOK         bool
+	}{
+		{"should not transfer leadership in a single member cluster", []*etcdserverpb.Member{{ID: 1, Name: "etcd-0"}}, nil, 0, false},
+		{"should transfer leadership to the voting member with the lowest ID", []*etcdserverpb.Member{{ID: 1, Name: "etcd-0"}, {ID: 3, Name: "etcd-2"}, {ID: 2, Name: "etcd-1"}}, nil, 2, true},
+		{"should prefer members which have defragmented", []*etcdserverpb.Member{{ID: 1, Name: "etcd-0"}, {ID: 3, Name: "etcd-2"}, {ID: 2, Name: "etcd-1"}}, map[uint64]bool{3: true}, 3, true},
+		{"should not transfer leadership to learners or unstarted members", []*etcdserverpb.Member{{ID: 1, Name: "etcd-0"}, {ID: 2, Name: "etcd-1", IsLearner: true}, {ID: 3}}, nil, 0, false},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		transferee, ok := leadershipTransferee(entry.members, 1, entry.defragmented)
+		g.Expect(ok).To(Equal(entry.expectedOK))
+		g.Expect(transferee).To(Equal(entry.expectedTransferee))
+	}
+}
+
+func TestDefragmentationOrder(t *testing.T) {
+	members := []*etcdserverpb.Member{
+		{ID: 2, Name: "etcd-1", ClientURLs: []string{"http://etcd-1:2379"}},
+		{ID: 1, Name: "etcd-0", ClientURLs: []string{"http://etcd-0:2379"}},
+		{ID: 3, Name: "etcd-2", ClientURLs: []string{"http://etcd-2:2379"}},
+		{ID: 4},
+	}
+	table := []struct {
+		description   string
+		raftLeader    uint64
+		defragmented  map[uint64]bool
+		expectedOrder []uint64
+	}{
+		{"should defragment started members by ID with the raft leader last", 1, nil, []uint64{2, 3, 1}},
+		{"should skip members which have defragmented", 3, map[uint64]bool{1: true}, []uint64{2, 3}},
+		{"should return no members once all have defragmented", 1, map[uint64]bool{1: true, 2: true, 3: true}, nil},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		var order []uint64
+		for _, member := range defragmentationOrder(members, entry.raftLeader, entry.defragmented) {
+			order = append(order, member.ID)
+		}
+		g.Expect(order).To(Equal(entry.expectedOrder))
+	}
+}
+
+func TestDefragmentAsMaintenanceLeader(t *testing.T) {
+	g := NewWithT(t)
+	etcd := startTestEtcd(t, g)
+	cfg := etcd.Config()
+	cli, err := clientv3.New(clientv3.Config{Endpoints: []string{cfg.ListenClientUrls[0].String()}, DialTimeout: 5 * time.Second})
+	g.Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		_ = cli.Close()
+	}()
+	history, err := maintenance.LoadHistory("", types.DefaultMaintenanceHistorySize)
+	g.Expect(err).ToNot(HaveOccurred())
+	app := &Application{
+		Config: types.Config{Defragmentation: types.DefragmentationConfig{
+			KeyPrefix: types.DefaultDefragmentationKeyPrefix,
+			LockTTL:   time.Minute,
+			Timeout:   time.Minute,
+		}},
+		ctx:                context.Background(),
+		etcd:               etcd,
+		etcdClient:         cli,
+		auditLogger:        audit.NewNoopLogger(),
+		maintenanceHistory: history,
+		logger:             zaptest.NewLogger(t),
+	}
+	app.maintenanceLeaderElection.Store(true)
+
+	t.Log("should skip the round if not the maintenance leader")
+	g.Expect(app.defragmentRound("20240101T0200Z")).To(Succeed())
+	g.Expect(history.Records()).To(BeEmpty())
+
+	t.Log("should defragment all members and record their defragmentation as the maintenance leader")
+	app.maintenanceLeader.Store(true)
+	g.Expect(app.defragmentRound("20240101T0200Z")).To(Succeed())
+	defragmented, err := app.defragmentedMembers(context.Background(), "20240101T0200Z")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(defragmented).To(Equal(map[uint64]bool{uint64(etcd.Server.ID()): true}))
+	records := history.Records()
+	g.Expect(records).To(HaveLen(1))
+	g.Expect(records[0].Target).To(Equal("20240101T0200Z/" + cfg.Name))
+	g.Expect(records[0].Error).To(BeEmpty())
+
+	t.Log("should not defragment members again in the same round")
+	g.Expect(app.defragmentRound("20240101T0200Z")).To(Succeed())
+	g.Expect(history.Records()).To(HaveLen(1))
+}
+
+func TestDefragmentInTurn(t *testing.T) {
+	g := NewWithT(t)
+	etcd := startTestEtcd(t, g)
+	cfg := etcd.Config()
+	cli, err := clientv3.New(clientv3.Config{Endpoints: []string{cfg.ListenClientUrls[0].String()}, DialTimeout: 5 * time.Second})
+	g.Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		_ = cli.Close()
+	}()
+	history, err := maintenance.LoadHistory("", types.DefaultMaintenanceHistorySize)
+	g.Expect(err).ToNot(HaveOccurred())
+	app := &Application{
+		Config: types.Config{Defragmentation: types.DefragmentationConfig{
+			KeyPrefix: types.DefaultDefragmentationKeyPrefix,
+			LockTTL:   time.Minute,
+			Timeout:   time.Minute,
+		}},
+		ctx:                context.Background(),
+		etcd:               etcd,
+		etcdClient:         cli,
+		auditLogger:        audit.NewNoopLogger(),
+		maintenanceHistory: history,
+		logger:             zaptest.NewLogger(t),
+	}
+
+	t.Log("the leader of a single member cluster should defragment right away and record its defragmentation")
+	g.Expect(app.defragmentInTurn("20240101T0200Z")).To(Succeed())
+	defragmented, err := app.defragmentedMembers(context.Background(), "20240101T0200Z")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(defragmented).To(Equal(map[uint64]bool{uint64(etcd.Server.ID()): true}))
+
+	records := history.Records()
+	g.Expect(records).To(HaveLen(1))
+	g.Expect(records[0].Operation).To(Equal(maintenance.OperationDefragmentation))
+	g.Expect(records[0].Target).To(Equal("20240101T0200Z"))
+	g.Expect(records[0].Error).To(BeEmpty())
+	g.Expect(records[0].DBSizeBefore).To(BeNumerically(">", 0))
+	g.Expect(records[0].DBSizeAfter).To(BeNumerically(">", 0))
+
+	t.Log("the record should be bound to a lease and scoped to the round")
+	response, err := cli.Get(context.Background(), defragmentationRoundPrefix(types.DefaultDefragmentationKeyPrefix, "20240101T0200Z")+strconv.FormatUint(uint64(etcd.Server.ID()), 16))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(response.Kvs).To(HaveLen(1))
+	g.Expect(response.Kvs[0].Lease).ToNot(BeZero())
+	defragmented, err = app.defragmentedMembers(context.Background(), "20240102T0200Z")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(defragmented).To(BeEmpty())
+}
@@ -0,0 +1,138 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/metrics"
+	"github.com/gardener/etcd-wrapper/internal/types"
+
+	"go.uber.org/zap"
+)
+
+// diskLatencyProbeFileName is the name of the file to which writes are synced to probe the fsync latency of a volume.
+const diskLatencyProbeFileName = ".disk-latency-probe"
+
+// latencyWindow holds the most recent latencies, up to its capacity.
+type latencyWindow struct {
+	samples []time.Duration
+	next    int
+}
+
+func newLatencyWindow(size int) *latencyWindow {
+	return &latencyWindow{samples: make([]time.Duration, 0, size)}
+}
+
+// add adds latency to the window, replacing the oldest latency once the window is full.
+func (w *latencyWindow) add(latency time.Duration) {
+	if len(w.samples) < cap(w.samples) {
+		w.samples = append(w.samples, latency)
+		return
+	}
+	w.samples[w.next] = latency
+	w.next = (w.next + 1) % len(w.samples)
+}
+
+// percentile returns the p-th percentile of the latencies in the window using the nearest-rank method.
+func (w *latencyWindow) percentile(p float64) time.Duration {
+	if len(w.samples) == 0 {
+		return 0
+	}
+	sorted := slices.Clone(w.samples)
+	slices.Sort(sorted)
+	rank := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	return sorted[max(rank, 0)]
+}
+
+// diskLatencyProbe probes the fsync latency of a single volume.
+type diskLatencyProbe struct {
+	volume string
+	file   *os.File
+	window *latencyWindow
+	high   bool
+}
+
+// watchDiskLatency periodically syncs a small write to the data volume and, if a separate WAL directory is configured,
+// to the WAL volume, and exposes the 99th percentile of the fsync latency over the disk latency window per volume.
+// A warning is logged when it starts or stops exceeding the warning threshold. Probing stops when the application
+// context is cancelled.
+func (a *Application) watchDiskLatency() {
+	if a.Config.DiskLatency.ProbeInterval <= 0 {
+		return
+	}
+	window := a.Config.DiskLatency.Window
+	if window == 0 {
+		window = types.DefaultDiskLatencyWindow
+	}
+	dirs := map[string]string{volumeData: a.cfg.Dir}
+	if a.cfg.WalDir != "" {
+		dirs[volumeWAL] = a.cfg.WalDir
+	}
+	var probes []*diskLatencyProbe
+	for volume, dir := range dirs {
+		path := filepath.Join(dir, diskLatencyProbeFileName)
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE, 0600) // #nosec G304 -- path is in the data or WAL directory of etcd.
+		if err != nil {
+			a.logger.Error("failed to create disk latency probe file, not probing the volume", zap.String("volume", volume), zap.String("path", path), zap.Error(err))
+			continue
+		}
+		defer func() {
+			_ = f.Close()
+			_ = os.Remove(path)
+		}()
+		metrics.DiskFsyncLatencyHigh.WithLabelValues(volume).Set(0)
+		probes = append(probes, &diskLatencyProbe{volume: volume, file: f, window: newLatencyWindow(window)})
+	}
+	if len(probes) == 0 {
+		return
+	}
+	data := make([]byte, preflightProbeBytes)
+	ticker := time.NewTicker(a.Config.DiskLatency.ProbeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-ticker.C:
+			for _, probe := range probes {
+				a.probeDiskLatency(probe, data)
+			}
+		}
+	}
+}
+
+// probeDiskLatency syncs a write to the volume of probe and updates the 99th percentile of its fsync latency.
+func (a *Application) probeDiskLatency(probe *diskLatencyProbe, data []byte) {
+	latency, err := syncedWriteLatency(probe.file, data)
+	if err != nil {
+		a.logger.Error("failed to probe disk latency", zap.String("volume", probe.volume), zap.Error(err))
+		return
+	}
+	probe.window.add(latency)
+	p99 := probe.window.percentile(99)
+	metrics.DiskFsyncLatencyP99Seconds.WithLabelValues(probe.volume).Set(p99.Seconds())
+
+	threshold := a.Config.DiskLatency.WarningThreshold
+	high := threshold > 0 && p99 > threshold
+	if high == probe.high {
+		return
+	}
+	probe.high = high
+	if high {
+		metrics.DiskFsyncLatencyHigh.WithLabelValues(probe.volume).Set(1)
+		a.logger.Warn("99th percentile of fsync latency exceeds the threshold recommended for etcd, slow disks cause leader elections; "+
+			"check the disk latency of the WAL and backend (etcd_disk_wal_fsync_duration_seconds, etcd_disk_backend_commit_duration_seconds)",
+			zap.String("volume", probe.volume), zap.Duration("p99", p99), zap.Duration("threshold", threshold))
+		return
+	}
+	metrics.DiskFsyncLatencyHigh.WithLabelValues(probe.volume).Set(0)
+	a.logger.Info("99th percentile of fsync latency is below the threshold again", zap.String("volume", probe.volume), zap.Duration("p99", p99), zap.Duration("threshold", threshold))
+}
@@ -0,0 +1,66 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/types"
+
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestLatencyWindow(t *testing.T) {
+	g := NewWithT(t)
+	window := newLatencyWindow(100)
+
+	t.Log("should return zero for an empty window")
+	g.Expect(window.percentile(99)).To(BeZero())
+
+	t.Log("should return the 99th percentile using the nearest-rank method")
+	for i := 1; i <= 100; i++ {
+		window.add(time.Duration(i) * time.Millisecond)
+	}
+	g.Expect(window.percentile(99)).To(Equal(99 * time.Millisecond))
+	g.Expect(window.percentile(50)).To(Equal(50 * time.Millisecond))
+
+	t.Log("should replace the oldest latencies once the window is full")
+	for range 100 {
+		window.add(time.Millisecond)
+	}
+	g.Expect(window.samples).To(HaveLen(100))
+	g.Expect(window.percentile(99)).To(Equal(time.Millisecond))
+}
+
+func TestProbeDiskLatency(t *testing.T) {
+	g := NewWithT(t)
+	f, err := os.Create(filepath.Join(t.TempDir(), diskLatencyProbeFileName))
+	g.Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		_ = f.Close()
+	}()
+	app := &Application{logger: zaptest.NewLogger(t)}
+	probe := &diskLatencyProbe{volume: volumeData, file: f, window: newLatencyWindow(10)}
+	data := make([]byte, preflightProbeBytes)
+
+	t.Log("should not flag the volume if no warning threshold is configured")
+	app.probeDiskLatency(probe, data)
+	g.Expect(probe.window.samples).To(HaveLen(1))
+	g.Expect(probe.high).To(BeFalse())
+
+	t.Log("should flag the volume once the 99th percentile exceeds the warning threshold")
+	app.Config.DiskLatency = types.DiskLatencyConfig{WarningThreshold: time.Nanosecond}
+	app.probeDiskLatency(probe, data)
+	g.Expect(probe.high).To(BeTrue())
+
+	t.Log("should clear the flag once the 99th percentile is below the warning threshold again")
+	app.Config.DiskLatency = types.DiskLatencyConfig{WarningThreshold: time.Hour}
+	app.probeDiskLatency(probe, data)
+	g.Expect(probe.high).To(BeFalse())
+}
@@ -0,0 +1,59 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/brclient"
+	"github.com/gardener/etcd-wrapper/internal/metrics"
+
+	"go.uber.org/zap"
+)
+
+// etcdConfigPollTimeout is the time to wait for backup-restore to respond to a poll of the etcd configuration.
+const etcdConfigPollTimeout = 10 * time.Second
+
+// watchEtcdConfig periodically polls backup-restore for changes of the etcd configuration. The configuration is only
+// transferred if it has changed, in which case it is stored for the next start of etcd-wrapper. Polling stops if
+// backup-restore cannot be polled conditionally or when the application context is cancelled.
+func (a *Application) watchEtcdConfig() {
+	if a.Config.EtcdConfigPollInterval <= 0 {
+		return
+	}
+	poller, ok := a.brClient.(brclient.EtcdConfigPoller)
+	if !ok {
+		a.logger.Info("backup-restore client does not support polling the etcd configuration, not polling it")
+		return
+	}
+	ticker := time.NewTicker(a.Config.EtcdConfigPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-ticker.C:
+			a.pollEtcdConfig(poller)
+		}
+	}
+}
+
+// pollEtcdConfig polls backup-restore once for a change of the etcd configuration and reports a change.
+func (a *Application) pollEtcdConfig(poller brclient.EtcdConfigPoller) {
+	ctx, cancelFunc := context.WithTimeout(a.ctx, etcdConfigPollTimeout)
+	defer cancelFunc()
+	changed, err := poller.PollEtcdConfig(ctx)
+	if err != nil {
+		a.logger.Error("failed to poll etcd configuration from backup-restore", zap.Error(err))
+		return
+	}
+	if changed {
+		a.etcdConfigChanged.Store(true)
+		metrics.EtcdConfigChanged.Set(1)
+		a.logger.Warn("etcd configuration served by backup-restore has changed, it takes effect on the next start of etcd-wrapper")
+	}
+}
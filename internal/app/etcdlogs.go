@@ -0,0 +1,110 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"go.etcd.io/etcd/embed"
+	"go.etcd.io/etcd/pkg/logutil"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// applyEtcdLogging configures the embedded etcd to log through zap if its log entries are enriched with the fields
+// returned by etcdLogFields, or written to the etcd log outputs configured via etcd-wrapper flags instead of the log
+// outputs of the etcd configuration.
+func (a *Application) applyEtcdLogging(cfg *embed.Config) {
+	if !a.Config.EnrichEtcdLogs && a.etcdLogSink == nil {
+		return
+	}
+	if cfg.Logger != "zap" {
+		a.logger.Info("switching logger of etcd to zap", zap.String("configured", cfg.Logger), zap.Bool("enrich", a.Config.EnrichEtcdLogs))
+		cfg.Logger = "zap"
+	}
+	if a.etcdLogSink != nil {
+		a.logger.Info("writing logs of etcd to etcd-log-outputs instead of the log outputs of the etcd configuration",
+			zap.Strings("outputs", a.Config.LogSinks.Etcd.Outputs), zap.Strings("configured", cfg.LogOutputs))
+	}
+	cfg.ZapLoggerBuilder = func(c *embed.Config) error {
+		syncer, err := a.openEtcdLogOutputs(c)
+		if err != nil {
+			return err
+		}
+		// mirrors the logger etcd builds from its default zap configuration, including the sampling.
+		core := zapcore.NewCore(zapcore.NewJSONEncoder(logutil.DefaultZapLoggerConfig.EncoderConfig), syncer, logutil.ConvertToZapLevel(c.LogLevel))
+		if a.Config.EnrichEtcdLogs {
+			core = newEnrichingCore(core, a.etcdLogFields)
+		}
+		core = zapcore.NewSamplerWithOptions(core, time.Second, logutil.DefaultZapLoggerConfig.Sampling.Initial, logutil.DefaultZapLoggerConfig.Sampling.Thereafter)
+		return embed.NewZapCoreLoggerBuilder(zap.New(core, zap.AddCaller(), zap.ErrorOutput(syncer)), core, syncer)(c)
+	}
+}
+
+// openEtcdLogOutputs returns the etcd log sink if configured, and otherwise opens the log outputs of the etcd
+// configuration.
+func (a *Application) openEtcdLogOutputs(c *embed.Config) (zapcore.WriteSyncer, error) {
+	if a.etcdLogSink != nil {
+		return a.etcdLogSink, nil
+	}
+	outputPaths := make([]string, 0, len(c.LogOutputs))
+	for _, output := range c.LogOutputs {
+		switch output {
+		case embed.DefaultLogOutput:
+			outputPaths = append(outputPaths, embed.StdErrLogOutput)
+		case embed.JournalLogOutput:
+			return nil, fmt.Errorf("log output %q is not supported when enriching the logs of etcd", output)
+		default:
+			outputPaths = append(outputPaths, output)
+		}
+	}
+	if len(outputPaths) == 0 {
+		outputPaths = append(outputPaths, embed.StdErrLogOutput)
+	}
+	syncer, _, err := zap.Open(outputPaths...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log outputs of etcd: %w", err)
+	}
+	return syncer, nil
+}
+
+// etcdLogFields returns the context of etcd-wrapper with which every entry logged by the embedded etcd is enriched:
+// the name of the member, the ID of the cluster once etcd has been ready, and the current state of etcd-wrapper.
+func (a *Application) etcdLogFields() []zapcore.Field {
+	currentState, _ := a.stateMachine.Current()
+	fields := []zapcore.Field{zap.String("member", a.cfg.Name), zap.String("phase", string(currentState))}
+	if clusterID, ok := a.etcdClusterID.Load().(string); ok {
+		fields = append(fields, zap.String("clusterID", clusterID))
+	}
+	return fields
+}
+
+// enrichingCore is a zapcore.Core which adds the fields returned by fields to every entry when it is written, so that
+// fields which change over time, like the state of etcd-wrapper, are current for each entry.
+type enrichingCore struct {
+	zapcore.Core
+	fields func() []zapcore.Field
+}
+
+func newEnrichingCore(core zapcore.Core, fields func() []zapcore.Field) zapcore.Core {
+	return &enrichingCore{Core: core, fields: fields}
+}
+
+func (c *enrichingCore) With(fields []zapcore.Field) zapcore.Core {
+	return newEnrichingCore(c.Core.With(fields), c.fields)
+}
+
+func (c *enrichingCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *enrichingCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(entry, slices.Concat(fields, c.fields()))
+}
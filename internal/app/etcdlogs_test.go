@@ -0,0 +1,112 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gardener/etcd-wrapper/internal/logsink"
+	"github.com/gardener/etcd-wrapper/internal/state"
+	"github.com/gardener/etcd-wrapper/internal/types"
+
+	. "github.com/onsi/gomega"
+	"go.etcd.io/etcd/embed"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestApplyEtcdLoggingEnrichment(t *testing.T) {
+	g := NewWithT(t)
+	logger := zaptest.NewLogger(t)
+	logPath := filepath.Join(t.TempDir(), "etcd.log")
+	app := &Application{
+		Config:       types.Config{EnrichEtcdLogs: true},
+		stateMachine: state.NewMachine(logger),
+		logger:       logger,
+	}
+	etcd := startTestEtcd(t, g, func(cfg *embed.Config) {
+		cfg.Logger = "capnslog"
+		cfg.LogOutputs = []string{logPath}
+		app.cfg = cfg
+		app.applyEtcdLogging(cfg)
+		g.Expect(cfg.Logger).To(Equal("zap"))
+	})
+
+	t.Log("should enrich log entries of etcd with member name and state before etcd has been ready")
+	entries := readLogEntries(g, logPath)
+	g.Expect(entries).ToNot(BeEmpty())
+	g.Expect(entries[0]).To(HaveKeyWithValue("member", embed.DefaultName))
+	g.Expect(entries[0]).To(HaveKeyWithValue("phase", string(state.New)))
+	g.Expect(entries[0]).ToNot(HaveKey("clusterID"))
+
+	t.Log("should enrich log entries of etcd with cluster ID and current state once etcd has been ready")
+	g.Expect(app.stateMachine.TransitionTo(state.ProbingSidecar)).To(Succeed())
+	g.Expect(app.stateMachine.TransitionTo(state.StartingEtcd)).To(Succeed())
+	g.Expect(app.stateMachine.TransitionTo(state.Ready)).To(Succeed())
+	clusterID := etcd.Server.Cluster().ID().String()
+	app.etcdClusterID.Store(clusterID)
+	etcd.GetLogger().Named("test").Info("enriched entry")
+	entries = readLogEntries(g, logPath)
+	last := entries[len(entries)-1]
+	g.Expect(last).To(HaveKeyWithValue("msg", "enriched entry"))
+	g.Expect(last).To(HaveKeyWithValue("member", embed.DefaultName))
+	g.Expect(last).To(HaveKeyWithValue("phase", string(state.Ready)))
+	g.Expect(last).To(HaveKeyWithValue("clusterID", clusterID))
+}
+
+func TestApplyEtcdLoggingSink(t *testing.T) {
+	g := NewWithT(t)
+	logger := zaptest.NewLogger(t)
+	logPath := filepath.Join(t.TempDir(), "etcd.log")
+	configuredPath := filepath.Join(t.TempDir(), "configured.log")
+	sink, err := logsink.Open([]string{logPath}, types.DefaultLogMaxSizeBytes, 0)
+	g.Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		_ = sink.Close()
+	}()
+	app := &Application{
+		Config:       types.Config{LogSinks: types.LogSinksConfig{Etcd: types.LogSinkConfig{Outputs: []string{logPath}}}},
+		stateMachine: state.NewMachine(logger),
+		logger:       logger,
+		etcdLogSink:  sink,
+	}
+	startTestEtcd(t, g, func(cfg *embed.Config) {
+		cfg.Logger = "capnslog"
+		cfg.LogOutputs = []string{configuredPath}
+		app.cfg = cfg
+		app.applyEtcdLogging(cfg)
+		g.Expect(cfg.Logger).To(Equal("zap"))
+	})
+
+	t.Log("should write log entries of etcd to the etcd log sink instead of the configured log outputs")
+	entries := readLogEntries(g, logPath)
+	g.Expect(entries).ToNot(BeEmpty())
+	g.Expect(entries[0]).ToNot(HaveKey("member"))
+	g.Expect(configuredPath).ToNot(BeAnExistingFile())
+}
+
+func TestApplyEtcdLoggingDisabled(t *testing.T) {
+	g := NewWithT(t)
+	app := &Application{logger: zaptest.NewLogger(t)}
+	cfg := embed.NewConfig()
+	app.applyEtcdLogging(cfg)
+	g.Expect(cfg.Logger).To(Equal("capnslog"))
+	g.Expect(cfg.ZapLoggerBuilder).To(BeNil())
+}
+
+func readLogEntries(g *WithT, path string) []map[string]any {
+	content, err := os.ReadFile(path)
+	g.Expect(err).ToNot(HaveOccurred())
+	var entries []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+		entry := map[string]any{}
+		g.Expect(json.Unmarshal([]byte(line), &entry)).To(Succeed())
+		entries = append(entries, entry)
+	}
+	return entries
+}
@@ -0,0 +1,127 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/bootstrap"
+	"github.com/gardener/etcd-wrapper/internal/etcdprocess"
+	"github.com/gardener/etcd-wrapper/internal/state"
+
+	etcdtypes "go.etcd.io/etcd/pkg/types"
+	"go.uber.org/zap"
+)
+
+const (
+	// etcdProcessReadyPollInterval is the interval in which an etcd process is polled until it serves client requests.
+	etcdProcessReadyPollInterval = time.Second
+	// etcdProcessStopMargin is the time added to the stop grace period of an etcd process to wait for the etcd
+	// component to stop, which covers killing the process once the grace period has passed.
+	etcdProcessStopMargin = 5 * time.Second
+)
+
+// startEtcdProcess starts the external etcd binary as child process with the etcd configuration file fetched during
+// bootstrap and waits until it serves client requests. Adjustments of the etcd configuration by etcd-wrapper are only
+// applied to the embedded etcd, the etcd process is configured by the etcd configuration file only.
+func (a *Application) startEtcdProcess() error {
+	var output io.Writer
+	if a.etcdLogSink != nil {
+		output = a.etcdLogSink
+	}
+	process, err := etcdprocess.Start(a.Config.EtcdProcess.BinaryPath, a.etcdInitializer.EtcdConfigFilePath(), output, a.logger)
+	if err != nil {
+		return err
+	}
+
+	if err = a.waitForEtcdProcessReady(process); err != nil {
+		return err
+	}
+	a.etcdMu.Lock()
+	a.etcdProcess = process
+	a.etcdMu.Unlock()
+	return nil
+}
+
+// waitForEtcdProcessReady waits till the etcd process serves client requests, or it has exited, or there is a timeout
+// waiting for it to start. A zero timeout waits forever. The etcd process is stopped only on timeout.
+func (a *Application) waitForEtcdProcessReady(process *etcdprocess.Process) error {
+	var readyTimeoutCh <-chan time.Time
+	if a.waitReadyTimeout > 0 {
+		readyTimeoutCh = time.After(a.waitReadyTimeout)
+	}
+	ticker := time.NewTicker(etcdProcessReadyPollInterval)
+	defer ticker.Stop()
+	for {
+		ctx, cancelFunc := context.WithTimeout(a.ctx, etcdGetTimeout)
+		status, err := a.etcdClient.Status(ctx, a.etcdClient.Endpoints()[0])
+		cancelFunc()
+		if err == nil {
+			a.logger.Info("etcd process is now ready to serve client requests", zap.String("etcdVersion", status.Version))
+			a.transitionTo(state.Ready)
+			a.etcdInitializer.RecordOutcome(bootstrap.OutcomeSucceeded)
+			a.recordEtcdVersion(status.Version)
+			a.etcdClusterID.Store(etcdtypes.ID(status.Header.ClusterId).String())
+			a.crashReporter.Go("post-ready-hook", func() { a.runPostReadyHook(nil) })
+			return nil
+		}
+		select {
+		case <-process.Done():
+			a.logger.Error("etcd process has exited before it became ready")
+			return nil
+		case <-a.ctx.Done():
+			return nil
+		case <-readyTimeoutCh:
+			a.logger.Error("timeout waiting for etcd process to serve client requests, aborting start of etcd")
+			process.Stop(a.Config.EtcdProcess.StopGracePeriod)
+			return &bootstrap.PhaseTimeoutError{Phase: bootstrap.PhaseEtcdReady, Timeout: a.waitReadyTimeout}
+		case <-ticker.C:
+		}
+	}
+}
+
+// waitForEtcdProcessStop blocks like waitForEtcdStop, with the exit of the etcd process in place of the notifications
+// of the embedded etcd.
+func (a *Application) waitForEtcdProcessStop(process *etcdprocess.Process) bool {
+	select {
+	case <-a.ctx.Done():
+		a.logger.Error("application context has been cancelled", zap.Error(a.ctx.Err()))
+	case <-process.Done():
+		err := process.Err()
+		if err == nil {
+			err = errors.New("etcd process has exited with code 0")
+		}
+		a.logger.Error("etcd process has exited unexpectedly", zap.Error(err))
+		a.setExitReason(fmt.Sprintf("etcd has failed: %v", err))
+	case <-a.restartCh:
+		return true
+	}
+	return false
+}
+
+func (a *Application) getEtcdProcess() *etcdprocess.Process {
+	a.etcdMu.RLock()
+	defer a.etcdMu.RUnlock()
+	return a.etcdProcess
+}
+
+// etcdRunning returns whether etcd has been started, either embedded or as process.
+func (a *Application) etcdRunning() bool {
+	a.etcdMu.RLock()
+	defer a.etcdMu.RUnlock()
+	return a.etcd != nil || a.etcdProcess != nil
+}
+
+// etcdStopTimeout returns the time to wait for etcd to stop, which must cover the stop grace period of an etcd process.
+func (a *Application) etcdStopTimeout() time.Duration {
+	if !a.Config.EtcdProcess.IsProcessMode() {
+		return 0
+	}
+	return a.Config.EtcdProcess.StopGracePeriod + etcdProcessStopMargin
+}
@@ -0,0 +1,78 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/etcdprocess"
+	"github.com/gardener/etcd-wrapper/internal/types"
+
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap/zaptest"
+)
+
+// startFakeEtcdProcess starts a shell script standing in for the etcd binary with the given body.
+func startFakeEtcdProcess(t *testing.T, g *WithT, body string) *etcdprocess.Process {
+	path := filepath.Join(t.TempDir(), "etcd")
+	g.Expect(os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0700)).To(Succeed())
+	process, err := etcdprocess.Start(path, "/etc/etcd.conf.yaml", nil, zaptest.NewLogger(t))
+	g.Expect(err).ToNot(HaveOccurred())
+	return process
+}
+
+func TestWaitForEtcdProcessStop(t *testing.T) {
+	table := []struct {
+		description        string
+		body               string
+		restart            bool
+		expectRestart      bool
+		expectedExitReason string
+	}{
+		{"should not restart etcd which has exited", "exit 2", false, false, "etcd has failed: etcd process has exited: exit status 2"},
+		{"should not restart etcd which has exited with code 0", "exit 0", false, false, "etcd has failed: etcd process has exited with code 0"},
+		{"should restart etcd on request", "trap 'exit 0' TERM\nwhile true; do sleep 0.05; done", true, true, ""},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		app := &Application{
+			ctx:       context.Background(),
+			logger:    zaptest.NewLogger(t),
+			restartCh: make(chan struct{}),
+			Config:    types.Config{EtcdProcess: types.EtcdProcessConfig{Mode: types.EtcdModeProcess, StopGracePeriod: time.Second}},
+		}
+		app.etcdProcess = startFakeEtcdProcess(t, g, entry.body)
+		g.Expect(app.etcdRunning()).To(BeTrue())
+		if entry.restart {
+			go func() { app.restartCh <- struct{}{} }()
+		}
+
+		g.Expect(app.waitForEtcdStop()).To(Equal(entry.expectRestart))
+		reason, _ := app.exitReason.Load().(string)
+		g.Expect(reason).To(Equal(entry.expectedExitReason))
+
+		process := app.getEtcdProcess()
+		app.closeEtcd()
+		g.Expect(process.Done()).To(BeClosed())
+		g.Expect(app.etcdRunning()).To(BeFalse())
+	}
+}
+
+func TestEtcdStopTimeout(t *testing.T) {
+	g := NewWithT(t)
+	t.Log("should use the default stop timeout for the embedded etcd")
+	app := &Application{}
+	g.Expect(app.etcdStopTimeout()).To(BeZero())
+
+	t.Log("should cover the stop grace period of an etcd process")
+	app.Config.EtcdProcess = types.EtcdProcessConfig{Mode: types.EtcdModeProcess, StopGracePeriod: time.Minute}
+	g.Expect(app.etcdStopTimeout()).To(Equal(time.Minute + etcdProcessStopMargin))
+}
@@ -0,0 +1,114 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gardener/etcd-wrapper/internal/types"
+	"github.com/gardener/etcd-wrapper/internal/util"
+
+	"go.etcd.io/etcd/version"
+	"go.uber.org/zap"
+)
+
+// EtcdVersionSkewError is returned when the version of the embedded etcd is incompatible with the version of etcd
+// which has last run on the data directory.
+type EtcdVersionSkewError struct {
+	// Recorded is the version of etcd which has last run on the data directory.
+	Recorded string
+	// Current is the version of the embedded etcd.
+	Current string
+	// Reason describes why the versions are incompatible.
+	Reason string
+}
+
+func (e *EtcdVersionSkewError) Error() string {
+	return fmt.Sprintf("refusing to start etcd %s on data directory last used by etcd %s: %s", e.Current, e.Recorded, e.Reason)
+}
+
+// ExitCode returns the exit code with which etcd-wrapper exits because of the version skew.
+func (e *EtcdVersionSkewError) ExitCode() int {
+	return types.ExitCodeEtcdVersionSkew
+}
+
+// verifyEtcdVersion verifies that the embedded etcd may run on the data directory, based on the version of etcd
+// recorded in it by the previous run. Minor versions must not be skipped on upgrades, and downgrades of the minor
+// version are only allowed if explicitly configured. A data directory without recorded version is always accepted, as
+// is one whose recorded version cannot be parsed, e.g. because the version file has been truncated by a crash. The
+// version is recorded again once etcd has started.
+func (a *Application) verifyEtcdVersion(dataDir string) error {
+	recorded, err := os.ReadFile(filepath.Join(dataDir, types.EtcdVersionFileName)) // #nosec G304 -- path is derived from the etcd configuration.
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read etcd version recorded in data directory: %w", err)
+	}
+	recordedVersion := strings.TrimSpace(string(recorded))
+	if _, _, err = parseMajorMinor(recordedVersion); err != nil {
+		a.logger.Warn("etcd version recorded in data directory is invalid, accepting data directory as if no version had been recorded",
+			zap.String("recorded", recordedVersion), zap.Error(err))
+		return nil
+	}
+	if err = checkEtcdVersionSkew(recordedVersion, version.Version, a.Config.AllowEtcdDowngrade); err != nil {
+		return err
+	}
+	if recordedVersion != version.Version {
+		a.logger.Info("etcd version has changed since the last run", zap.String("recorded", recordedVersion), zap.String("current", version.Version))
+	}
+	return nil
+}
+
+// recordEtcdVersion records the version of the running etcd in the data directory, once it has successfully started.
+func (a *Application) recordEtcdVersion(etcdVersion string) {
+	path := filepath.Join(a.cfg.Dir, types.EtcdVersionFileName)
+	if err := util.WriteFileAtomic(path, []byte(etcdVersion+"\n"), 0600); err != nil {
+		a.logger.Warn("failed to record etcd version in data directory", zap.String("path", path), zap.Error(err))
+	}
+}
+
+// checkEtcdVersionSkew returns an EtcdVersionSkewError if etcd of version current must not run on a data directory
+// last used by etcd of version recorded.
+func checkEtcdVersionSkew(recorded, current string, allowDowngrade bool) error {
+	recordedMajor, recordedMinor, err := parseMajorMinor(recorded)
+	if err != nil {
+		return fmt.Errorf("invalid etcd version %q recorded in data directory: %w", recorded, err)
+	}
+	currentMajor, currentMinor, err := parseMajorMinor(current)
+	if err != nil {
+		return fmt.Errorf("invalid version %q of embedded etcd: %w", current, err)
+	}
+	switch {
+	case currentMajor != recordedMajor:
+		return &EtcdVersionSkewError{Recorded: recorded, Current: current, Reason: "major version has changed"}
+	case currentMinor > recordedMinor+1:
+		return &EtcdVersionSkewError{Recorded: recorded, Current: current, Reason: fmt.Sprintf("upgrades must not skip minor versions, upgrade to %d.%d first", recordedMajor, recordedMinor+1)}
+	case currentMinor < recordedMinor && !allowDowngrade:
+		return &EtcdVersionSkewError{Recorded: recorded, Current: current, Reason: "downgrades of the minor version are only allowed with --allow-etcd-downgrade"}
+	}
+	return nil
+}
+
+// parseMajorMinor parses the major and minor version of a semantic version.
+func parseMajorMinor(v string) (major, minor int, err error) {
+	parts := strings.SplitN(strings.TrimPrefix(v, "v"), ".", 3)
+	if len(parts) < 2 {
+		return 0, 0, fmt.Errorf("expected format <major>.<minor>.<patch>")
+	}
+	if major, err = strconv.Atoi(parts[0]); err != nil {
+		return 0, 0, err
+	}
+	if minor, err = strconv.Atoi(parts[1]); err != nil {
+		return 0, 0, err
+	}
+	return major, minor, nil
+}
@@ -0,0 +1,83 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gardener/etcd-wrapper/internal/types"
+
+	. "github.com/onsi/gomega"
+	"go.etcd.io/etcd/embed"
+	"go.etcd.io/etcd/version"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestCheckEtcdVersionSkew(t *testing.T) {
+	table := []struct {
+		description    string
+		recorded       string
+		current        string
+		allowDowngrade bool
+		expectSkew     bool
+		expectError    bool
+	}{
+		{"should allow same version", "3.4.34", "3.4.34", false, false, false},
+		{"should allow patch upgrade", "3.4.33", "3.4.34", false, false, false},
+		{"should allow patch downgrade", "3.4.34", "3.4.33", false, false, false},
+		{"should allow upgrade to the next minor version", "3.4.34", "3.5.0", false, false, false},
+		{"should refuse to skip minor versions", "3.4.34", "3.6.0", false, true, false},
+		{"should refuse to skip minor versions even if downgrades are allowed", "3.4.34", "3.6.0", true, true, false},
+		{"should refuse minor downgrade", "3.5.0", "3.4.34", false, true, false},
+		{"should allow minor downgrade when allowed", "3.5.0", "3.4.34", true, false, false},
+		{"should refuse major version change", "2.3.8", "3.4.34", true, true, false},
+		{"should return error for invalid recorded version", "garbage", "3.4.34", false, false, true},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		err := checkEtcdVersionSkew(entry.recorded, entry.current, entry.allowDowngrade)
+		var skewErr *EtcdVersionSkewError
+		g.Expect(errors.As(err, &skewErr)).To(Equal(entry.expectSkew))
+		g.Expect(err != nil).To(Equal(entry.expectSkew || entry.expectError))
+		if entry.expectSkew {
+			g.Expect(skewErr.ExitCode()).To(Equal(types.ExitCodeEtcdVersionSkew))
+		}
+	}
+}
+
+func TestRecordAndVerifyEtcdVersion(t *testing.T) {
+	g := NewWithT(t)
+	dataDir := t.TempDir()
+	cfg := embed.NewConfig()
+	cfg.Dir = dataDir
+	app := &Application{cfg: cfg, logger: zaptest.NewLogger(t)}
+
+	t.Log("should accept data directory without recorded version")
+	g.Expect(app.verifyEtcdVersion(dataDir)).To(Succeed())
+
+	t.Log("should accept data directory with version recorded by the embedded etcd")
+	app.recordEtcdVersion(version.Version)
+	recorded, err := os.ReadFile(filepath.Join(dataDir, types.EtcdVersionFileName))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(string(recorded)).To(Equal(version.Version + "\n"))
+	g.Expect(app.verifyEtcdVersion(dataDir)).To(Succeed())
+
+	t.Log("should refuse data directory last used by a newer minor version")
+	g.Expect(os.WriteFile(filepath.Join(dataDir, types.EtcdVersionFileName), []byte("3.5.17\n"), 0600)).To(Succeed())
+	g.Expect(app.verifyEtcdVersion(dataDir)).To(HaveOccurred())
+
+	t.Log("should accept data directory with truncated recorded version")
+	g.Expect(os.WriteFile(filepath.Join(dataDir, types.EtcdVersionFileName), nil, 0600)).To(Succeed())
+	g.Expect(app.verifyEtcdVersion(dataDir)).To(Succeed())
+
+	t.Log("should accept data directory with unparsable recorded version")
+	g.Expect(os.WriteFile(filepath.Join(dataDir, types.EtcdVersionFileName), []byte("garbage\n"), 0600)).To(Succeed())
+	g.Expect(app.verifyEtcdVersion(dataDir)).To(Succeed())
+}
@@ -0,0 +1,124 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/state"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// eventsHeartbeatInterval is the interval in which a comment is sent on an idle event stream, so that proxies do
+	// not close it.
+	eventsHeartbeatInterval = 15 * time.Second
+	// eventsBufferSize is the number of transitions buffered per event stream. A client which falls further behind is
+	// disconnected and can resume via the Last-Event-ID header.
+	eventsBufferSize = 64
+	// transitionEvent is the type of the events carrying a state transition.
+	transitionEvent = "transition"
+)
+
+// eventsHandler streams the transitions of the state machine as Server-Sent Events. All transitions made so far are
+// sent first, followed by every transition as it happens. The ID of an event is the position of its transition in
+// the history, starting at 1, so that a client can resume after the last event it has received via the Last-Event-ID
+// header. The stream ends once etcd-wrapper is stopping.
+func (a *Application) eventsHandler(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+	var lastEventID int
+	if header := r.Header.Get("Last-Event-ID"); header != "" {
+		var err error
+		if lastEventID, err = strconv.Atoi(header); err != nil || lastEventID < 0 {
+			http.Error(w, fmt.Sprintf("invalid Last-Event-ID: %s", header), http.StatusBadRequest)
+			return
+		}
+	}
+
+	// the stream lasts until the client disconnects, so the write timeout of the server must not apply to it
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	transitions := make(chan state.Transition, eventsBufferSize)
+	overflow := make(chan struct{})
+	past, cancel := a.stateMachine.Watch(func(transition state.Transition) {
+		select {
+		case transitions <- transition:
+		default:
+			select {
+			case <-overflow:
+			default:
+				close(overflow)
+			}
+		}
+	})
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	id := 0
+	send := func(transition state.Transition) bool {
+		id++
+		if id <= lastEventID {
+			return true
+		}
+		if err := writeTransitionEvent(w, id, transition); err != nil {
+			a.logger.Debug("failed to write event, closing event stream", zap.Error(err))
+			return false
+		}
+		return transition.To != state.Stopping
+	}
+	for _, transition := range past {
+		if !send(transition) {
+			return
+		}
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(eventsHeartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-a.ctx.Done():
+			return
+		case <-overflow:
+			a.logger.Warn("Event stream has fallen behind, closing it", zap.String("remoteAddr", r.RemoteAddr))
+			return
+		case transition := <-transitions:
+			if !send(transition) {
+				flusher.Flush()
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
+
+// writeTransitionEvent writes the transition as an event with the given ID in the format of Server-Sent Events.
+func writeTransitionEvent(w http.ResponseWriter, id int, transition state.Transition) error {
+	data, err := json.Marshal(transition)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", id, transitionEvent, data)
+	return err
+}
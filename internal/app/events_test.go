@@ -0,0 +1,119 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gardener/etcd-wrapper/internal/state"
+
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap/zaptest"
+)
+
+type sseEvent struct {
+	id         string
+	event      string
+	transition state.Transition
+}
+
+func TestEventsHandler(t *testing.T) {
+	table := []struct {
+		description    string
+		lastEventID    string
+		expectedIDs    []string
+		expectedStates []state.State
+	}{
+		{"should replay all transitions followed by live transitions", "", []string{"1", "2", "3", "4"}, []state.State{state.ProbingSidecar, state.StartingEtcd, state.Ready, state.Stopping}},
+		{"should resume after the last event id", "1", []string{"2", "3", "4"}, []state.State{state.StartingEtcd, state.Ready, state.Stopping}},
+	}
+
+	for _, entry := range table {
+		t.Run(entry.description, func(t *testing.T) {
+			g := NewWithT(t)
+			logger := zaptest.NewLogger(t)
+			stateMachine := state.NewMachine(logger)
+			g.Expect(stateMachine.TransitionTo(state.ProbingSidecar)).To(Succeed())
+			g.Expect(stateMachine.TransitionTo(state.StartingEtcd)).To(Succeed())
+			app := &Application{ctx: context.Background(), stateMachine: stateMachine, logger: logger}
+			server := httptest.NewServer(http.HandlerFunc(app.eventsHandler))
+			defer server.Close()
+
+			req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+			g.Expect(err).ToNot(HaveOccurred())
+			if entry.lastEventID != "" {
+				req.Header.Set("Last-Event-ID", entry.lastEventID)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			g.Expect(err).ToNot(HaveOccurred())
+			defer func() {
+				_ = resp.Body.Close()
+			}()
+			g.Expect(resp.Header.Get("Content-Type")).To(Equal("text/event-stream"))
+
+			reader := bufio.NewReader(resp.Body)
+			var events []sseEvent
+			for range len(entry.expectedIDs) - 2 {
+				events = append(events, readEvent(g, reader))
+			}
+			g.Expect(stateMachine.TransitionTo(state.Ready)).To(Succeed())
+			events = append(events, readEvent(g, reader))
+			g.Expect(stateMachine.TransitionTo(state.Stopping)).To(Succeed())
+			events = append(events, readEvent(g, reader))
+
+			var ids []string
+			var states []state.State
+			for _, event := range events {
+				g.Expect(event.event).To(Equal(transitionEvent))
+				ids = append(ids, event.id)
+				states = append(states, event.transition.To)
+			}
+			g.Expect(ids).To(Equal(entry.expectedIDs))
+			g.Expect(states).To(Equal(entry.expectedStates))
+
+			t.Log("stream should end once etcd-wrapper is stopping")
+			_, err = reader.ReadString('\n')
+			g.Expect(err).To(HaveOccurred())
+		})
+	}
+}
+
+func TestEventsHandlerInvalidLastEventID(t *testing.T) {
+	g := NewWithT(t)
+	logger := zaptest.NewLogger(t)
+	app := &Application{ctx: context.Background(), stateMachine: state.NewMachine(logger), logger: logger}
+	req := httptest.NewRequest(http.MethodGet, "/events", nil)
+	req.Header.Set("Last-Event-ID", "abc")
+	response := httptest.NewRecorder()
+	app.eventsHandler(response, req)
+	g.Expect(response.Code).To(Equal(http.StatusBadRequest))
+}
+
+func readEvent(g *WithT, reader *bufio.Reader) sseEvent {
+	var event sseEvent
+	for {
+		line, err := reader.ReadString('\n')
+		g.Expect(err).ToNot(HaveOccurred())
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			return event
+		}
+		field, value, _ := strings.Cut(line, ": ")
+		switch field {
+		case "id":
+			event.id = value
+		case "event":
+			event.event = value
+		case "data":
+			g.Expect(json.Unmarshal([]byte(value), &event.transition)).To(Succeed())
+		}
+	}
+}
@@ -0,0 +1,104 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/state"
+
+	"go.uber.org/zap"
+)
+
+const (
+	pendingOperationValidation             = "validation"
+	pendingOperationPostRestoreMaintenance = "post-restore maintenance"
+	exitReasonShutdown                     = "shutdown requested"
+)
+
+// exitSummary summarizes the lifetime of etcd-wrapper once it terminates, so that terminated pods can be analyzed from
+// their logs alone.
+type exitSummary struct {
+	// Uptime is the time since the Application has been created.
+	Uptime string `json:"uptime"`
+	// Restarts is the number of times the embedded etcd has been restarted.
+	Restarts int `json:"restarts"`
+	// LastState is the state of the Application when it terminated.
+	LastState state.State `json:"lastState"`
+	// LastStateSince is the time since when the Application has been in LastState.
+	LastStateSince time.Time `json:"lastStateSince"`
+	// PendingOperations are the operations which have been requested but not yet run, e.g. queued maintenance.
+	PendingOperations []string `json:"pendingOperations,omitempty"`
+	// Reason is the reason of the termination.
+	Reason string `json:"reason"`
+}
+
+// setExitReason records why the embedded etcd has stopped, which is reported in the exit summary unless Start returns
+// an error.
+func (a *Application) setExitReason(reason string) {
+	a.exitReason.Store(reason)
+}
+
+// exitSummary returns the exit summary of the Application terminating with err.
+func (a *Application) exitSummary(err error, now time.Time) exitSummary {
+	currentState, since := a.stateMachine.Current()
+	summary := exitSummary{
+		Uptime:         now.Sub(a.startedAt).Round(time.Second).String(),
+		Restarts:       int(a.restarts.Load()),
+		LastState:      currentState,
+		LastStateSince: since,
+		Reason:         exitReasonShutdown,
+	}
+	for _, operation := range a.maintenance.Queued() {
+		summary.PendingOperations = append(summary.PendingOperations, operation.Name)
+	}
+	a.validationMu.Lock()
+	if a.pendingValidation != "" {
+		summary.PendingOperations = append(summary.PendingOperations, fmt.Sprintf("%s (%s)", pendingOperationValidation, a.pendingValidation))
+	}
+	a.validationMu.Unlock()
+	if a.postRestoreMaintenancePending.Load() {
+		summary.PendingOperations = append(summary.PendingOperations, pendingOperationPostRestoreMaintenance)
+	}
+	if reason, ok := a.exitReason.Load().(string); ok {
+		summary.Reason = reason
+	}
+	if err != nil {
+		summary.Reason = err.Error()
+	}
+	return summary
+}
+
+// reportExit prints the exit summary of the Application terminating with err to stderr and logs it.
+func (a *Application) reportExit(err error) {
+	summary := a.exitSummary(err, time.Now())
+	if writeErr := writeExitSummary(os.Stderr, summary); writeErr != nil {
+		a.logger.Error("failed to print exit summary", zap.Error(writeErr))
+	}
+	a.logger.Info("etcd-wrapper is terminating",
+		zap.String("uptime", summary.Uptime),
+		zap.Int("restarts", summary.Restarts),
+		zap.String("lastState", string(summary.LastState)),
+		zap.Time("lastStateSince", summary.LastStateSince),
+		zap.Strings("pendingOperations", summary.PendingOperations),
+		zap.String("reason", summary.Reason),
+	)
+}
+
+// writeExitSummary writes summary as a single line of JSON onto w.
+func writeExitSummary(w io.Writer, summary exitSummary) error {
+	content, err := json.Marshal(struct {
+		ExitSummary exitSummary `json:"exitSummary"`
+	}{summary})
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(content))
+	return err
+}
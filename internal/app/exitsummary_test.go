@@ -0,0 +1,74 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/brclient"
+	"github.com/gardener/etcd-wrapper/internal/maintenance"
+	"github.com/gardener/etcd-wrapper/internal/state"
+
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestExitSummary(t *testing.T) {
+	table := []struct {
+		description               string
+		err                       error
+		exitReason                string
+		pendingValidation         brclient.ValidationType
+		expectedReason            string
+		expectedPendingOperations []string
+	}{
+		{"should report a requested shutdown", nil, "", "", exitReasonShutdown, nil},
+		{"should report why etcd has stopped", nil, "etcd server has been aborted", "", "etcd server has been aborted", nil},
+		{"should prefer the error over why etcd has stopped", errors.New("restart budget is exhausted"), "etcd server has been aborted", "", "restart budget is exhausted", nil},
+		{"should report a pending validation", nil, "", brclient.FullValidation, exitReasonShutdown, []string{"validation (full)"}},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		logger := zaptest.NewLogger(t)
+		now := time.Now()
+		app := &Application{
+			logger:            logger,
+			stateMachine:      state.NewMachine(logger),
+			maintenance:       maintenance.NewScheduler(nil, logger),
+			pendingValidation: entry.pendingValidation,
+			startedAt:         now.Add(-90 * time.Minute),
+		}
+		app.restarts.Store(2)
+		g.Expect(app.stateMachine.TransitionTo(state.ProbingSidecar)).To(Succeed())
+		if entry.exitReason != "" {
+			app.setExitReason(entry.exitReason)
+		}
+		summary := app.exitSummary(entry.err, now)
+		g.Expect(summary.Uptime).To(Equal("1h30m0s"))
+		g.Expect(summary.Restarts).To(Equal(2))
+		g.Expect(summary.LastState).To(Equal(state.ProbingSidecar))
+		g.Expect(summary.Reason).To(Equal(entry.expectedReason))
+		g.Expect(summary.PendingOperations).To(Equal(entry.expectedPendingOperations))
+	}
+}
+
+func TestWriteExitSummary(t *testing.T) {
+	g := NewWithT(t)
+	buf := &bytes.Buffer{}
+	summary := exitSummary{Uptime: "1m0s", Restarts: 1, LastState: state.Stopping, PendingOperations: []string{"defragmentation"}, Reason: exitReasonShutdown}
+	g.Expect(writeExitSummary(buf, summary)).To(Succeed())
+	g.Expect(bytes.Count(buf.Bytes(), []byte("\n"))).To(Equal(1))
+	written := struct {
+		ExitSummary exitSummary `json:"exitSummary"`
+	}{}
+	g.Expect(json.Unmarshal(buf.Bytes(), &written)).To(Succeed())
+	g.Expect(written.ExitSummary).To(Equal(summary))
+}
@@ -0,0 +1,114 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/types"
+
+	"go.etcd.io/etcd/embed"
+	"go.etcd.io/etcd/etcdserver/api/v3client"
+	"go.etcd.io/etcd/etcdserver/api/v3election"
+	"go.etcd.io/etcd/etcdserver/api/v3election/v3electionpb"
+	"go.etcd.io/etcd/etcdserver/api/v3lock"
+	"go.etcd.io/etcd/etcdserver/api/v3lock/v3lockpb"
+	"go.etcd.io/etcd/etcdserver/api/v3rpc"
+	"go.etcd.io/etcd/pkg/transport"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+)
+
+// startExternalClientListener serves the gRPC API of etcd on the external client listener, if one has been configured.
+// etcd only supports a single TLS configuration for all of its client listeners, therefore the external listener is
+// served by etcd-wrapper on top of the running etcd server with its own TLS configuration. Clients authenticating with
+// a certificate are mapped to etcd users by the common name, just like on the client listeners of etcd.
+func (a *Application) startExternalClientListener(etcd *embed.Etcd) error {
+	if a.Config.ExternalClientListener.URL == "" {
+		return nil
+	}
+	listener, tlsConfig, err := newExternalClientListener(a.Config.ExternalClientListener)
+	if err != nil {
+		return fmt.Errorf("failed to create external client listener: %w", err)
+	}
+	options := grpcKeepAliveOptions(a.cfg)
+	if a.requestSampler != nil {
+		options = append(options, grpc.ChainUnaryInterceptor(a.requestSampler.UnaryServerInterceptor()), grpc.ChainStreamInterceptor(a.requestSampler.StreamServerInterceptor()))
+	}
+	if a.clientTraffic != nil {
+		options = append(options, grpc.StatsHandler(a.clientTraffic))
+	}
+	server := v3rpc.Server(etcd.Server, tlsConfig, options...)
+	v3c := v3client.New(etcd.Server)
+	v3electionpb.RegisterElectionServer(server, v3election.NewElectionServer(v3c))
+	v3lockpb.RegisterLockServer(server, v3lock.NewLockServer(v3c))
+
+	a.etcdMu.Lock()
+	a.externalClientServer = server
+	a.etcdMu.Unlock()
+	a.logger.Info("Serving etcd on external client listener", zap.String("url", a.Config.ExternalClientListener.URL), zap.Bool("clientCertAuth", a.Config.ExternalClientListener.TrustedCAPath != ""))
+	a.crashReporter.Go("external-client-listener", func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+			a.logger.Error("external client listener stopped serving", zap.Error(err))
+		}
+	})
+	return nil
+}
+
+// stopExternalClientListener stops serving the external client listener, closing all connections. It must be called
+// with etcdMu held.
+func (a *Application) stopExternalClientListener() {
+	if a.externalClientServer != nil {
+		a.externalClientServer.Stop()
+		a.externalClientServer = nil
+	}
+}
+
+// newExternalClientListener creates the listener and TLS configuration of the external client listener from config.
+func newExternalClientListener(config types.ExternalClientListenerConfig) (net.Listener, *tls.Config, error) {
+	u, err := url.Parse(config.URL)
+	if err != nil {
+		return nil, nil, err
+	}
+	tlsInfo := transport.TLSInfo{
+		CertFile:       config.CertPath,
+		KeyFile:        config.KeyPath,
+		TrustedCAFile:  config.TrustedCAPath,
+		ClientCertAuth: config.TrustedCAPath != "",
+	}
+	tlsConfig, err := tlsInfo.ServerConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	listener, err := net.Listen("tcp", u.Host)
+	if err != nil {
+		return nil, nil, err
+	}
+	return listener, tlsConfig, nil
+}
+
+// grpcKeepAliveOptions returns the gRPC keepalive options of the client listeners of etcd configured in cfg.
+func grpcKeepAliveOptions(cfg *embed.Config) []grpc.ServerOption {
+	var opts []grpc.ServerOption
+	if cfg.GRPCKeepAliveMinTime > time.Duration(0) {
+		opts = append(opts, grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             cfg.GRPCKeepAliveMinTime,
+			PermitWithoutStream: false,
+		}))
+	}
+	if cfg.GRPCKeepAliveInterval > time.Duration(0) && cfg.GRPCKeepAliveTimeout > time.Duration(0) {
+		opts = append(opts, grpc.KeepaliveParams(keepalive.ServerParameters{
+			Time:    cfg.GRPCKeepAliveInterval,
+			Timeout: cfg.GRPCKeepAliveTimeout,
+		}))
+	}
+	return opts
+}
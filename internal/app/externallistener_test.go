@@ -0,0 +1,93 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/crashreport"
+	"github.com/gardener/etcd-wrapper/internal/testutil"
+	"github.com/gardener/etcd-wrapper/internal/types"
+
+	. "github.com/onsi/gomega"
+	"go.etcd.io/etcd/clientv3"
+	"go.etcd.io/etcd/etcdserver/api/v3client"
+	"go.etcd.io/etcd/pkg/transport"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestExternalClientListener(t *testing.T) {
+	g := NewWithT(t)
+	dir := t.TempDir()
+	tlsResourceCreator, err := testutil.NewTLSResourceCreator()
+	g.Expect(err).ToNot(HaveOccurred())
+	ca, err := tlsResourceCreator.CreateCACertAndKey()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(ca.EncodeAndWrite(dir, "ca.crt", "ca.key")).To(Succeed())
+	server, err := tlsResourceCreator.CreateServerCertAndKey(net.ParseIP("127.0.0.1"))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(server.EncodeAndWrite(dir, "server.crt", "server.key")).To(Succeed())
+	client, err := tlsResourceCreator.CreateETCDClientCertAndKey()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(client.EncodeAndWrite(dir, "client.crt", "client.key")).To(Succeed())
+
+	etcd := startTestEtcd(t, g)
+	logger := zaptest.NewLogger(t)
+	externalURL := "https://" + freeLocalAddress(g)
+	cfg := etcd.Config()
+	app := &Application{
+		Config: types.Config{ExternalClientListener: types.ExternalClientListenerConfig{
+			URL:           externalURL,
+			CertPath:      filepath.Join(dir, "server.crt"),
+			KeyPath:       filepath.Join(dir, "server.key"),
+			TrustedCAPath: filepath.Join(dir, "ca.crt"),
+		}},
+		cfg:           &cfg,
+		logger:        logger,
+		crashReporter: crashreport.NewReporter("", crashreport.NewLogBuffer(0), nil, func() any { return nil }, logger),
+	}
+	g.Expect(app.startExternalClientListener(etcd)).To(Succeed())
+	defer func() {
+		app.etcdMu.Lock()
+		app.stopExternalClientListener()
+		app.etcdMu.Unlock()
+	}()
+
+	t.Log("should serve clients presenting a certificate signed by the trusted CA")
+	tlsConfig, err := transport.TLSInfo{CertFile: filepath.Join(dir, "client.crt"), KeyFile: filepath.Join(dir, "client.key"), TrustedCAFile: filepath.Join(dir, "ca.crt")}.ClientConfig()
+	g.Expect(err).ToNot(HaveOccurred())
+	cli, err := clientv3.New(clientv3.Config{Endpoints: []string{externalURL}, TLS: tlsConfig, DialTimeout: 5 * time.Second})
+	g.Expect(err).ToNot(HaveOccurred())
+	defer func() { _ = cli.Close() }()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err = cli.Put(ctx, "/external", "value")
+	g.Expect(err).ToNot(HaveOccurred())
+	response, err := v3client.New(etcd.Server).Get(ctx, "/external")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(response.Kvs).To(HaveLen(1))
+
+	t.Log("should reject clients without a certificate")
+	tlsConfig, err = transport.TLSInfo{TrustedCAFile: filepath.Join(dir, "ca.crt")}.ClientConfig()
+	g.Expect(err).ToNot(HaveOccurred())
+	anonymous, err := clientv3.New(clientv3.Config{Endpoints: []string{externalURL}, TLS: tlsConfig, DialTimeout: 5 * time.Second})
+	g.Expect(err).ToNot(HaveOccurred())
+	defer func() { _ = anonymous.Close() }()
+	shortCtx, shortCancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer shortCancel()
+	_, err = anonymous.Get(shortCtx, "/external")
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestExternalClientListenerDisabled(t *testing.T) {
+	g := NewWithT(t)
+	app := &Application{}
+	g.Expect(app.startExternalClientListener(nil)).To(Succeed())
+	g.Expect(app.externalClientServer).To(BeNil())
+}
@@ -8,18 +8,29 @@ import (
 	"context"
 
 	"go.etcd.io/etcd/clientv3"
+	pb "go.etcd.io/etcd/etcdserver/etcdserverpb"
 )
 
 // EtcdFakeKV mocks the KV interface of etcd required to mock etcd get calls
-type EtcdFakeKV struct{}
+type EtcdFakeKV struct {
+	// Puts records the values put per key.
+	Puts map[string]string
+	// Serializable records whether the last Get was serializable.
+	Serializable bool
+}
 
 // Get gets a value for a given key.
-func (c *EtcdFakeKV) Get(_ context.Context, _ string, _ ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+func (c *EtcdFakeKV) Get(_ context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+	c.Serializable = clientv3.OpGet(key, opts...).IsSerializable()
 	return nil, nil
 }
 
 // Put puts a value for a given key.
-func (c *EtcdFakeKV) Put(_ context.Context, _, _ string, _ ...clientv3.OpOption) (*clientv3.PutResponse, error) {
+func (c *EtcdFakeKV) Put(_ context.Context, key, val string, _ ...clientv3.OpOption) (*clientv3.PutResponse, error) {
+	if c.Puts == nil {
+		c.Puts = make(map[string]string)
+	}
+	c.Puts[key] = val
 	return nil, nil
 }
 
@@ -42,3 +53,22 @@ func (c *EtcdFakeKV) Txn(_ context.Context) clientv3.Txn {
 func (c *EtcdFakeKV) Do(_ context.Context, _ clientv3.Op) (clientv3.OpResponse, error) {
 	return clientv3.OpResponse{}, nil
 }
+
+// EtcdFakeMaintenance mocks the Maintenance interface of etcd required to mock listing of alarms and member status
+type EtcdFakeMaintenance struct {
+	clientv3.Maintenance
+	// Alarms are the alarms returned by AlarmList.
+	Alarms []*pb.AlarmMember
+	// StatusResponse is the response returned by Status.
+	StatusResponse *clientv3.StatusResponse
+}
+
+// Status returns StatusResponse.
+func (m *EtcdFakeMaintenance) Status(_ context.Context, _ string) (*clientv3.StatusResponse, error) {
+	return m.StatusResponse, nil
+}
+
+// AlarmList returns Alarms.
+func (m *EtcdFakeMaintenance) AlarmList(_ context.Context) (*clientv3.AlarmResponse, error) {
+	return &clientv3.AlarmResponse{Alarms: m.Alarms}, nil
+}
@@ -0,0 +1,88 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/faultinjection"
+	"github.com/gardener/etcd-wrapper/internal/state"
+
+	"go.etcd.io/etcd/embed"
+	"go.uber.org/zap"
+)
+
+// faultsHandler serves the faults injected in fault injection mode. GET returns the injected faults, PUT replaces them.
+func (a *Application) faultsHandler(w http.ResponseWriter, req *http.Request) {
+	if a.faultInjector == nil {
+		http.NotFound(w, req)
+		return
+	}
+	switch req.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		faults := faultinjection.Faults{}
+		if err := json.NewDecoder(req.Body).Decode(&faults); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(err.Error()))
+			return
+		}
+		a.injectFaults(faults)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(a.faultInjector.Faults()); err != nil {
+		a.logger.Error("failed to write faults response", zap.Error(err))
+	}
+}
+
+// injectFaults replaces the injected faults. Peer connections are dropped by closing the peer listeners of the running
+// etcd, which can only be opened again by restarting etcd once the fault is cleared.
+func (a *Application) injectFaults(faults faultinjection.Faults) {
+	previous := a.faultInjector.SetFaults(faults)
+	a.logger.Warn("injecting faults", zap.Any("faults", faults), zap.Any("previousFaults", previous))
+	switch {
+	case faults.DropPeerConnections && !previous.DropPeerConnections:
+		if etcd := a.getEtcd(); etcd != nil {
+			a.dropPeerConnections(etcd)
+		}
+	case !faults.DropPeerConnections && previous.DropPeerConnections:
+		a.logger.Info("restarting etcd to accept connections from peers again")
+		if err := a.Restart(); err != nil {
+			a.logger.Error("failed to restart etcd to accept connections from peers again", zap.Error(err))
+		}
+	}
+}
+
+// applyPeerConnectionFault drops the peer connections of etcd if the fault is injected, e.g. after a restart.
+func (a *Application) applyPeerConnectionFault(etcd *embed.Etcd) {
+	if a.faultInjector != nil && a.faultInjector.Faults().DropPeerConnections {
+		a.dropPeerConnections(etcd)
+	}
+}
+
+// dropPeerConnections closes the peer listeners of etcd, so that it stops accepting connections from its peers.
+func (a *Application) dropPeerConnections(etcd *embed.Etcd) {
+	for _, peer := range etcd.Peers {
+		if err := peer.Close(); err != nil {
+			a.logger.Error("failed to close peer listener", zap.String("address", peer.Addr().String()), zap.Error(err))
+		}
+	}
+	a.logger.Warn("dropped peer connections by fault injection")
+}
+
+// readinessDelayed returns true while the readiness of etcd is withheld by fault injection after etcd has become ready.
+func (a *Application) readinessDelayed() bool {
+	if a.faultInjector == nil {
+		return false
+	}
+	delay := a.faultInjector.Faults().ReadinessDelay
+	current, since := a.stateMachine.Current()
+	return delay > 0 && current == state.Ready && time.Since(since) < delay
+}
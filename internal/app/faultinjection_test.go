@@ -0,0 +1,104 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/audit"
+	"github.com/gardener/etcd-wrapper/internal/faultinjection"
+	"github.com/gardener/etcd-wrapper/internal/state"
+
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestFaultsHandler(t *testing.T) {
+	table := []struct {
+		description        string
+		faultInjection     bool
+		method             string
+		body               string
+		expectedStatusCode int
+		expectedFaults     faultinjection.Faults
+	}{
+		{"should respond with 404 if fault injection is disabled", false, http.MethodGet, "", http.StatusNotFound, faultinjection.Faults{}},
+		{"should return the injected faults", true, http.MethodGet, "", http.StatusOK, faultinjection.Faults{}},
+		{"should inject faults", true, http.MethodPut, `{"sidecarLatency": "2s", "readinessDelay": "1m"}`, http.StatusOK, faultinjection.Faults{SidecarLatency: 2 * time.Second, ReadinessDelay: time.Minute}},
+		{"should reject invalid faults", true, http.MethodPut, `{"sidecarLatency": "soon"}`, http.StatusBadRequest, faultinjection.Faults{}},
+		{"should reject other methods", true, http.MethodDelete, "", http.StatusMethodNotAllowed, faultinjection.Faults{}},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		app := &Application{logger: zaptest.NewLogger(t)}
+		if entry.faultInjection {
+			app.faultInjector = faultinjection.NewInjector()
+		}
+		recorder := httptest.NewRecorder()
+		app.faultsHandler(recorder, httptest.NewRequest(entry.method, "/debug/faults", strings.NewReader(entry.body)))
+		g.Expect(recorder.Code).To(Equal(entry.expectedStatusCode))
+		if entry.expectedStatusCode != http.StatusOK {
+			continue
+		}
+		faults := faultinjection.Faults{}
+		g.Expect(json.NewDecoder(recorder.Body).Decode(&faults)).To(Succeed())
+		g.Expect(faults).To(Equal(entry.expectedFaults))
+		g.Expect(app.faultInjector.Faults()).To(Equal(entry.expectedFaults))
+	}
+}
+
+func TestInjectPeerConnectionFault(t *testing.T) {
+	g := NewWithT(t)
+	etcd := startTestEtcd(t, g)
+	app := &Application{
+		ctx:           context.Background(),
+		logger:        zaptest.NewLogger(t),
+		auditLogger:   audit.NewNoopLogger(),
+		restartCh:     make(chan struct{}, 1),
+		faultInjector: faultinjection.NewInjector(),
+		etcd:          etcd,
+	}
+	peerAddress := etcd.Peers[0].Addr().String()
+
+	t.Log("should stop accepting connections from peers")
+	app.injectFaults(faultinjection.Faults{DropPeerConnections: true})
+	_, err := net.DialTimeout("tcp", peerAddress, time.Second)
+	g.Expect(err).To(HaveOccurred())
+
+	t.Log("should restart etcd once the fault is cleared")
+	app.injectFaults(faultinjection.Faults{})
+	g.Expect(app.restartCh).To(Receive())
+}
+
+func TestReadinessDelayed(t *testing.T) {
+	g := NewWithT(t)
+	logger := zaptest.NewLogger(t)
+	app := &Application{logger: logger, stateMachine: state.NewMachine(logger)}
+	g.Expect(app.readinessDelayed()).To(BeFalse())
+
+	app.faultInjector = faultinjection.NewInjector()
+	app.faultInjector.SetFaults(faultinjection.Faults{ReadinessDelay: time.Minute})
+	t.Log("should not delay readiness before etcd is ready")
+	g.Expect(app.readinessDelayed()).To(BeFalse())
+
+	t.Log("should delay readiness once etcd is ready")
+	for _, s := range []state.State{state.ProbingSidecar, state.StartingEtcd, state.Ready} {
+		g.Expect(app.stateMachine.TransitionTo(s)).To(Succeed())
+	}
+	g.Expect(app.readinessDelayed()).To(BeTrue())
+
+	t.Log("should not delay readiness once the delay has passed")
+	app.faultInjector.SetFaults(faultinjection.Faults{ReadinessDelay: time.Nanosecond})
+	g.Expect(app.readinessDelayed()).To(BeFalse())
+}
@@ -0,0 +1,154 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/metrics"
+
+	"go.uber.org/zap"
+)
+
+// Weights of the success of a probe, its latency and the stability of the leader in the score of a probe, which sum
+// up to 1.
+const (
+	healthScoreSuccessWeight = 0.4
+	healthScoreLatencyWeight = 0.3
+	healthScoreLeaderWeight  = 0.3
+)
+
+// HealthScore is a single health signal of the member, which is smoothed over periodic probes of etcd.
+type HealthScore struct {
+	// Score is the exponentially smoothed health score between 0 (unhealthy) and 1 (healthy).
+	Score float64 `json:"score"`
+	// LastProbeSucceeded indicates whether the last probe of etcd has succeeded.
+	LastProbeSucceeded bool `json:"lastProbeSucceeded"`
+	// LastProbeLatencySeconds is the latency of the last probe of etcd.
+	LastProbeLatencySeconds float64 `json:"lastProbeLatencySeconds"`
+	// LeaderChanges is the number of changes of the leader observed by the probes.
+	LeaderChanges int `json:"leaderChanges"`
+	// ObservedAt is the time of the last probe.
+	ObservedAt time.Time `json:"observedAt"`
+}
+
+// healthProbe is the result of a single probe of etcd.
+type healthProbe struct {
+	succeeded bool
+	latency   time.Duration
+	// leader is the member ID of the leader, zero if the member has no leader.
+	leader uint64
+}
+
+// healthScorer computes the health score as exponential moving average of the scores of the individual probes. A probe
+// scores 0 if it has failed. Otherwise, its latency scores 1 up to the latency threshold and decreases inversely
+// proportional beyond it, and the leader scores 1 if it is unchanged, 0.5 if it has changed since the last probe and 0
+// if the member has no leader.
+type healthScorer struct {
+	smoothingFactor  float64
+	latencyThreshold time.Duration
+	score            HealthScore
+	observed         bool
+	lastLeader       uint64
+}
+
+// observe records the probe observed at now and returns the updated health score.
+func (s *healthScorer) observe(now time.Time, probe healthProbe) HealthScore {
+	leaderChanged := probe.leader != 0 && s.lastLeader != 0 && probe.leader != s.lastLeader
+	if leaderChanged {
+		s.score.LeaderChanges++
+	}
+	if probe.leader != 0 {
+		s.lastLeader = probe.leader
+	}
+	sample := s.probeScore(probe, leaderChanged)
+	if s.observed {
+		s.score.Score = s.smoothingFactor*sample + (1-s.smoothingFactor)*s.score.Score
+	} else {
+		s.score.Score = sample
+		s.observed = true
+	}
+	s.score.LastProbeSucceeded = probe.succeeded
+	s.score.LastProbeLatencySeconds = probe.latency.Seconds()
+	s.score.ObservedAt = now
+	return s.score
+}
+
+func (s *healthScorer) probeScore(probe healthProbe, leaderChanged bool) float64 {
+	if !probe.succeeded {
+		return 0
+	}
+	latencyScore := 1.0
+	if probe.latency > s.latencyThreshold {
+		latencyScore = float64(s.latencyThreshold) / float64(probe.latency)
+	}
+	var leaderScore float64
+	switch {
+	case probe.leader == 0:
+		leaderScore = 0
+	case leaderChanged:
+		leaderScore = 0.5
+	default:
+		leaderScore = 1
+	}
+	return healthScoreSuccessWeight + healthScoreLatencyWeight*latencyScore + healthScoreLeaderWeight*leaderScore
+}
+
+// watchHealthScore periodically probes etcd and updates the health score. Probes are skipped while etcd is not
+// running, which keeps the last health score. It stops when the application context is cancelled.
+func (a *Application) watchHealthScore() {
+	if a.Config.HealthScore.Interval <= 0 {
+		return
+	}
+	scorer := &healthScorer{
+		smoothingFactor:  a.Config.HealthScore.GetSmoothingFactor(),
+		latencyThreshold: a.Config.HealthScore.GetLatencyThreshold(),
+	}
+	ticker := time.NewTicker(a.Config.HealthScore.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-ticker.C:
+			a.updateHealthScore(scorer)
+		}
+	}
+}
+
+// updateHealthScore probes etcd via its status endpoint and records the probe in the health score.
+func (a *Application) updateHealthScore(scorer *healthScorer) {
+	if !a.etcdRunning() {
+		return
+	}
+	ctx, cancelFunc := context.WithTimeout(a.ctx, etcdGetTimeout)
+	defer cancelFunc()
+	start := time.Now()
+	status, err := a.etcdClient.Status(ctx, a.etcdClient.Endpoints()[0])
+	probe := healthProbe{succeeded: err == nil, latency: time.Since(start)}
+	if err != nil {
+		a.logger.Debug("health probe of etcd has failed", zap.Error(err))
+	} else {
+		probe.leader = status.Leader
+	}
+	score := scorer.observe(time.Now(), probe)
+	metrics.HealthScore.Set(score.Score)
+	a.healthScoreMu.Lock()
+	a.healthScore = &score
+	a.healthScoreMu.Unlock()
+}
+
+// getHealthScore returns a copy of the current health score, nil if etcd has not been probed yet.
+func (a *Application) getHealthScore() *HealthScore {
+	a.healthScoreMu.Lock()
+	defer a.healthScoreMu.Unlock()
+	if a.healthScore == nil {
+		return nil
+	}
+	score := *a.healthScore
+	return &score
+}
@@ -0,0 +1,77 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/types"
+
+	. "github.com/onsi/gomega"
+	"go.etcd.io/etcd/clientv3"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestHealthScorer(t *testing.T) {
+	healthy := healthProbe{succeeded: true, latency: 10 * time.Millisecond, leader: 1}
+	table := []struct {
+		description           string
+		probes                []healthProbe
+		expectedScore         float64
+		expectedLeaderChanges int
+	}{
+		{"should score a healthy probe with 1", []healthProbe{healthy}, 1, 0},
+		{"should score a failed probe with 0", []healthProbe{{latency: time.Second}}, 0, 0},
+		{"should score a probe without leader without the leader weight", []healthProbe{{succeeded: true, latency: 10 * time.Millisecond}}, 0.7, 0},
+		{"should score latency beyond the threshold inversely proportional", []healthProbe{{succeeded: true, latency: 400 * time.Millisecond, leader: 1}}, 0.775, 0},
+		{"should smooth a failed probe after a healthy probe", []healthProbe{healthy, {}}, 0.5, 0},
+		{"should score a changed leader with half the leader weight", []healthProbe{healthy, {succeeded: true, latency: 10 * time.Millisecond, leader: 2}}, 0.925, 1},
+		{"should not count a lost leader as leader change", []healthProbe{healthy, {succeeded: true}, healthy}, 0.925, 0},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		scorer := &healthScorer{smoothingFactor: 0.5, latencyThreshold: 100 * time.Millisecond}
+		now := time.Now()
+		var score HealthScore
+		for _, probe := range entry.probes {
+			score = scorer.observe(now, probe)
+		}
+		last := entry.probes[len(entry.probes)-1]
+		g.Expect(score.Score).To(BeNumerically("~", entry.expectedScore, 1e-9))
+		g.Expect(score.LeaderChanges).To(Equal(entry.expectedLeaderChanges))
+		g.Expect(score.LastProbeSucceeded).To(Equal(last.succeeded))
+		g.Expect(score.LastProbeLatencySeconds).To(Equal(last.latency.Seconds()))
+		g.Expect(score.ObservedAt).To(Equal(now))
+	}
+}
+
+func TestUpdateHealthScore(t *testing.T) {
+	g := NewWithT(t)
+	etcd := startTestEtcd(t, g)
+	cli, err := clientv3.New(clientv3.Config{Endpoints: []string{etcd.Config().ListenClientUrls[0].String()}, DialTimeout: 5 * time.Second})
+	g.Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		_ = cli.Close()
+	}()
+	app := &Application{ctx: context.Background(), etcdClient: cli, logger: zaptest.NewLogger(t)}
+	config := types.HealthScoreConfig{LatencyThreshold: time.Minute}
+	scorer := &healthScorer{smoothingFactor: config.GetSmoothingFactor(), latencyThreshold: config.GetLatencyThreshold()}
+
+	t.Log("should not probe etcd which is not running")
+	app.updateHealthScore(scorer)
+	g.Expect(app.getHealthScore()).To(BeNil())
+
+	t.Log("should report a healthy member once etcd has been probed")
+	app.etcd = etcd
+	app.updateHealthScore(scorer)
+	score := app.getHealthScore()
+	g.Expect(score).ToNot(BeNil())
+	g.Expect(score.Score).To(Equal(1.0))
+	g.Expect(score.LastProbeSucceeded).To(BeTrue())
+}
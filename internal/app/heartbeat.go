@@ -0,0 +1,78 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/state"
+	"github.com/gardener/etcd-wrapper/internal/util"
+
+	"go.uber.org/zap"
+)
+
+// heartbeat is the content of the heartbeat file.
+type heartbeat struct {
+	// Timestamp is the time at which the heartbeat has been written.
+	Timestamp time.Time `json:"timestamp"`
+	// State is the state of etcd-wrapper.
+	State state.State `json:"state"`
+	// StateSince is the time since when etcd-wrapper is in its state.
+	StateSince time.Time `json:"stateSince"`
+	// Ready is the readiness of etcd.
+	Ready bool `json:"ready"`
+	// PID is the process ID of etcd-wrapper.
+	PID int `json:"pid"`
+}
+
+// writeHeartbeats rewrites the heartbeat file in the configured interval till the application context is cancelled,
+// so that external liveness monitors can detect a hung etcd-wrapper from the age of the file, independently of the
+// HTTP server.
+func (a *Application) writeHeartbeats() {
+	if a.Config.Heartbeat.Path == "" {
+		return
+	}
+	a.writeHeartbeatFile()
+	ticker := time.NewTicker(a.Config.Heartbeat.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-ticker.C:
+			a.writeHeartbeatFile()
+		}
+	}
+}
+
+// writeHeartbeatFile writes the current time, state and readiness into the heartbeat file, if one has been configured.
+func (a *Application) writeHeartbeatFile() {
+	if a.Config.Heartbeat.Path == "" {
+		return
+	}
+	currentState, since := a.stateMachine.Current()
+	a.heartbeatMu.Lock()
+	defer a.heartbeatMu.Unlock()
+	if err := writeHeartbeatFile(a.Config.Heartbeat.Path, heartbeat{
+		Timestamp:  time.Now().UTC(),
+		State:      currentState,
+		StateSince: since.UTC(),
+		Ready:      a.etcdReady.Load(),
+		PID:        os.Getpid(),
+	}); err != nil {
+		a.logger.Error("failed to write heartbeat file", zap.String("path", a.Config.Heartbeat.Path), zap.Error(err))
+	}
+}
+
+// writeHeartbeatFile replaces the heartbeat file atomically, so that readers never observe a partially written file.
+func writeHeartbeatFile(path string, beat heartbeat) error {
+	content, err := json.Marshal(beat)
+	if err != nil {
+		return err
+	}
+	return util.WriteFileAtomic(path, append(content, '\n'), 0644) // #nosec G306 -- the heartbeat file is meant to be read by external monitors.
+}
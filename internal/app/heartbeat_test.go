@@ -0,0 +1,68 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/state"
+	"github.com/gardener/etcd-wrapper/internal/types"
+
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestWriteHeartbeats(t *testing.T) {
+	g := NewWithT(t)
+	path := filepath.Join(t.TempDir(), "heartbeat.json")
+	logger := zaptest.NewLogger(t)
+	stateMachine := state.NewMachine(logger)
+	g.Expect(stateMachine.TransitionTo(state.ProbingSidecar)).To(Succeed())
+	ctx, cancel := context.WithCancel(context.Background())
+	app := &Application{
+		ctx:          ctx,
+		Config:       types.Config{Heartbeat: types.HeartbeatConfig{Path: path, Interval: 10 * time.Millisecond}},
+		stateMachine: stateMachine,
+		logger:       logger,
+	}
+	done := make(chan struct{})
+	go func() {
+		app.writeHeartbeats()
+		close(done)
+	}()
+
+	t.Log("should write timestamp and state into the heartbeat file")
+	var first heartbeat
+	g.Eventually(func() error { return readHeartbeat(path, &first) }).Should(Succeed())
+	g.Expect(first.State).To(Equal(state.ProbingSidecar))
+	g.Expect(first.PID).To(Equal(os.Getpid()))
+
+	t.Log("should rewrite the heartbeat file periodically")
+	g.Eventually(func() time.Time {
+		var beat heartbeat
+		g.Expect(readHeartbeat(path, &beat)).To(Succeed())
+		return beat.Timestamp
+	}).Should(BeTemporally(">", first.Timestamp))
+
+	t.Log("should stop once the context is cancelled")
+	cancel()
+	g.Eventually(done).Should(BeClosed())
+	entries, err := os.ReadDir(filepath.Dir(path))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(entries).To(HaveLen(1))
+}
+
+func readHeartbeat(path string, beat *heartbeat) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(content, beat)
+}
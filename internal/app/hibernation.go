@@ -0,0 +1,268 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/audit"
+	"github.com/gardener/etcd-wrapper/internal/bootstrap"
+	"github.com/gardener/etcd-wrapper/internal/brclient"
+	"github.com/gardener/etcd-wrapper/internal/lifecycle"
+	"github.com/gardener/etcd-wrapper/internal/types"
+	"github.com/gardener/etcd-wrapper/internal/util"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// exitReasonHibernated is the exit reason once the member has hibernated.
+	exitReasonHibernated = "hibernated"
+	// hibernationResponseMargin is the time allowed to write the response of the hibernation endpoint once the
+	// hibernation has finished or timed out.
+	hibernationResponseMargin = 10 * time.Second
+)
+
+var (
+	errHibernationInProgress = errors.New("hibernation is already in progress")
+	errHibernating           = errors.New("hibernation in progress")
+)
+
+// hibernationMarker is written into the data directory once the member has hibernated. It records the final full
+// snapshot from which the data directory can be restored, so that the data directory is safe to delete.
+type hibernationMarker struct {
+	// HibernatedAt is the time at which the member has hibernated.
+	HibernatedAt time.Time `json:"hibernatedAt"`
+	// EtcdRevision is the etcd revision when the final full snapshot has been requested.
+	EtcdRevision int64 `json:"etcdRevision"`
+	// SnapName is the name of the final full snapshot in the snapstore.
+	SnapName string `json:"snapName"`
+	// SnapshotLastRevision is the last etcd revision contained in the final full snapshot.
+	SnapshotLastRevision int64 `json:"snapshotLastRevision"`
+}
+
+// hibernate stops client traffic to etcd, takes a final full snapshot, confirms that backup-restore has uploaded it and
+// that it covers the current etcd revision, and then marks the data directory as safe to delete. The data directory is
+// not marked if any of the steps fails, and client traffic is served again. Since etcd keeps serving the clients which
+// are still connected till it has stopped, the marker is verified by verifyHibernation once etcd has stopped.
+func (a *Application) hibernate(ctx context.Context) (*hibernationMarker, error) {
+	if !a.hibernationMu.TryLock() {
+		return nil, errHibernationInProgress
+	}
+	defer a.hibernationMu.Unlock()
+	var marker *hibernationMarker
+	err := audit.Record(a.auditLogger, audit.OperationHibernate, string(brclient.FullSnapshotKind), func() error {
+		if !a.etcdRunning() {
+			return errEtcdNotRunning
+		}
+		a.stopClientTraffic()
+		status, err := a.etcdClient.Status(ctx, a.etcdClient.Endpoints()[0])
+		if err != nil {
+			return fmt.Errorf("failed to get etcd status: %w", err)
+		}
+		revision := status.Header.Revision
+		a.logger.Info("hibernating, requesting final full snapshot from backup-restore", zap.Int64("etcdRevision", revision))
+		if _, err = a.brClient.TriggerSnapshot(ctx, brclient.FullSnapshotKind); err != nil {
+			return fmt.Errorf("failed to take final full snapshot: %w", err)
+		}
+		// confirm with the snapshots backup-restore has uploaded to the snapstore
+		latestSnapshots, err := a.brClient.GetLatestSnapshots(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to confirm upload of final full snapshot: %w", err)
+		}
+		if latestSnapshots == nil || latestSnapshots.FullSnapshot == nil {
+			return errors.New("backup-restore reports no full snapshot after taking the final full snapshot")
+		}
+		full := latestSnapshots.FullSnapshot
+		if full.LastRevision < revision {
+			return fmt.Errorf("latest full snapshot %s reported by backup-restore contains revision %d only, etcd is at revision %d", full.SnapName, full.LastRevision, revision)
+		}
+		marker = &hibernationMarker{HibernatedAt: time.Now().UTC(), EtcdRevision: revision, SnapName: full.SnapName, SnapshotLastRevision: full.LastRevision}
+		return a.writeHibernationMarker(marker)
+	})
+	if err != nil {
+		a.resumeClientTraffic()
+		return nil, err
+	}
+	a.hibernated.Store(true)
+	a.setExitReason(exitReasonHibernated)
+	a.logger.Info("hibernated, data directory is safe to delete", zap.String("snapName", marker.SnapName), zap.Int64("snapshotLastRevision", marker.SnapshotLastRevision),
+		zap.String("marker", a.hibernationMarkerPath()))
+	return marker, nil
+}
+
+// stopClientTraffic fails the readiness of the member, so that clients are no longer routed to it, and stops serving
+// the external client listener.
+func (a *Application) stopClientTraffic() {
+	a.hibernating.Store(true)
+	a.etcdMu.Lock()
+	defer a.etcdMu.Unlock()
+	a.stopExternalClientListener()
+}
+
+// resumeClientTraffic reverts stopClientTraffic after a failed hibernation.
+func (a *Application) resumeClientTraffic() {
+	a.hibernating.Store(false)
+	if etcd := a.getEtcd(); etcd != nil {
+		if err := a.startExternalClientListener(etcd); err != nil {
+			a.logger.Error("failed to serve external client listener again after failed hibernation", zap.Error(err))
+		}
+	}
+}
+
+// verifyHibernation verifies once etcd has stopped that the final full snapshot contains the revision of the data
+// directory, since etcd might have accepted writes of connected clients after the snapshot has been taken. Otherwise
+// the hibernation marker is removed, as the data directory is not safe to delete. It is a no-op if the member has not
+// hibernated.
+func (a *Application) verifyHibernation() error {
+	if !a.hibernated.Load() {
+		return nil
+	}
+	err := func() error {
+		data, err := os.ReadFile(a.hibernationMarkerPath())
+		if err != nil {
+			return fmt.Errorf("failed to read hibernation marker: %w", err)
+		}
+		var marker hibernationMarker
+		if err = json.Unmarshal(data, &marker); err != nil {
+			return fmt.Errorf("failed to parse hibernation marker: %w", err)
+		}
+		metadata, err := bootstrap.ReadDBMetadata(bootstrap.GetDBPath(a.cfg.Dir))
+		if err != nil {
+			return err
+		}
+		if metadata.Revision > marker.SnapshotLastRevision {
+			return fmt.Errorf("etcd has been written up to revision %d after the final full snapshot %s with revision %d has been taken",
+				metadata.Revision, marker.SnapName, marker.SnapshotLastRevision)
+		}
+		return nil
+	}()
+	if err == nil {
+		return nil
+	}
+	a.hibernated.Store(false)
+	a.setExitReason(fmt.Sprintf("hibernation failed: %v", err))
+	if rmErr := os.Remove(a.hibernationMarkerPath()); rmErr != nil && !errors.Is(rmErr, os.ErrNotExist) {
+		a.logger.Error("failed to remove hibernation marker, data directory is still marked as safe to delete", zap.String("path", a.hibernationMarkerPath()), zap.Error(rmErr))
+	}
+	a.logger.Error("hibernation failed after etcd has stopped, data directory is not safe to delete", zap.Error(err))
+	return err
+}
+
+// hibernationStopTimeout returns the time to wait for the monitors and etcd to stop and the hibernation to be verified.
+func (a *Application) hibernationStopTimeout() time.Duration {
+	return lifecycle.DefaultStopTimeout + max(a.etcdStopTimeout(), lifecycle.DefaultStopTimeout)
+}
+
+// hibernateHandler hibernates the member on a POST request and stops etcd-wrapper once the data directory has been
+// marked as safe to delete. It responds once etcd has stopped and the hibernation has been verified. etcd keeps
+// running if the hibernation fails before etcd-wrapper is stopped.
+func (a *Application) hibernateHandler(w http.ResponseWriter, r *http.Request) {
+	if !a.Config.Hibernation.EndpointEnabled {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "hibernation must be requested with POST", http.StatusMethodNotAllowed)
+		return
+	}
+	a.logger.Info("received hibernation request")
+	// the hibernation may take longer than the write timeout of the server, which would drop the response while the
+	// hibernation carries on.
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(a.Config.Hibernation.GetTimeout() + a.hibernationStopTimeout() + hibernationResponseMargin))
+	ctx, cancelFunc := context.WithTimeout(a.ctx, a.Config.Hibernation.GetTimeout())
+	defer cancelFunc()
+	marker, err := a.hibernate(ctx)
+	switch {
+	case errors.Is(err, errHibernationInProgress):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case errors.Is(err, errEtcdNotRunning):
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	case err != nil:
+		a.logger.Error("failed to hibernate", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// the HTTP server is stopped after etcd, so that the response can be written once the hibernation has been verified.
+	a.Stop()
+	select {
+	case err = <-a.hibernationErrCh:
+	case <-time.After(a.hibernationStopTimeout()):
+		err = errors.New("timed out waiting for etcd to stop to verify the hibernation")
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(marker); err != nil {
+		a.logger.Error("failed to write hibernation response", zap.Error(err))
+	}
+}
+
+// hibernationIntended returns whether the intent to hibernate has been signalled via the intent file.
+func (a *Application) hibernationIntended() bool {
+	if a.Config.Hibernation.IntentFilePath == "" {
+		return false
+	}
+	_, err := os.Stat(a.Config.Hibernation.IntentFilePath)
+	return err == nil
+}
+
+// snapshotOrHibernateOnShutdown hibernates the member on shutdown if hibernation is intended, and otherwise requests
+// the snapshot on shutdown. Nothing is done if the member has already hibernated. A failed hibernation is only logged
+// since it must not block the shutdown, the data directory is then not marked as safe to delete.
+func (a *Application) snapshotOrHibernateOnShutdown() {
+	switch {
+	case a.hibernated.Load():
+		return
+	case a.ctx.Err() != nil && a.hibernationIntended():
+		// the application context has been cancelled already.
+		ctx, cancelFunc := context.WithTimeout(context.Background(), a.Config.Hibernation.GetTimeout())
+		defer cancelFunc()
+		if _, err := a.hibernate(ctx); err != nil {
+			a.logger.Error("failed to hibernate on shutdown, data directory is not safe to delete", zap.Error(err))
+		}
+	default:
+		a.snapshotOnShutdown()
+	}
+}
+
+func (a *Application) hibernationMarkerPath() string {
+	return filepath.Join(a.cfg.Dir, types.HibernationMarkerFileName)
+}
+
+func (a *Application) writeHibernationMarker(marker *hibernationMarker) error {
+	data, err := json.Marshal(marker)
+	if err != nil {
+		return err
+	}
+	if err = util.WriteFileAtomic(a.hibernationMarkerPath(), data, 0600); err != nil {
+		return fmt.Errorf("failed to mark data directory as safe to delete: %w", err)
+	}
+	return nil
+}
+
+// clearHibernationMarker removes the hibernation marker from the data directory before etcd is started, since the data
+// directory is in use again once the member has woken up.
+func (a *Application) clearHibernationMarker() {
+	path := a.hibernationMarkerPath()
+	err := os.Remove(path)
+	switch {
+	case err == nil:
+		a.logger.Info("member has woken up from hibernation, removed hibernation marker", zap.String("path", path))
+	case !errors.Is(err, os.ErrNotExist):
+		a.logger.Error("failed to remove hibernation marker, data directory is still marked as safe to delete", zap.String("path", path), zap.Error(err))
+	}
+}
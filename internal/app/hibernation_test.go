@@ -0,0 +1,200 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/audit"
+	"github.com/gardener/etcd-wrapper/internal/brclient"
+	"github.com/gardener/etcd-wrapper/internal/types"
+
+	. "github.com/onsi/gomega"
+	"go.etcd.io/etcd/clientv3"
+	"go.etcd.io/etcd/embed"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestHibernate(t *testing.T) {
+	g := NewWithT(t)
+	etcd := startTestEtcd(t, g)
+	cli, err := clientv3.New(clientv3.Config{Endpoints: []string{etcd.Config().ListenClientUrls[0].String()}, DialTimeout: 5 * time.Second})
+	g.Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		_ = cli.Close()
+	}()
+	_, err = cli.Put(context.Background(), "foo", "bar")
+	g.Expect(err).ToNot(HaveOccurred())
+	status, err := cli.Status(context.Background(), cli.Endpoints()[0])
+	g.Expect(err).ToNot(HaveOccurred())
+	revision := status.Header.Revision
+
+	table := []struct {
+		description       string
+		etcdRunning       bool
+		fakeClient        *brclient.FakeClient
+		expectedErr       error
+		expectError       bool
+		expectedSnapshots []brclient.SnapshotKind
+	}{
+		{"should mark the data directory once the final full snapshot has been uploaded", true,
+			&brclient.FakeClient{LatestSnapshots: &brclient.LatestSnapshots{FullSnapshot: &brclient.Snapshot{SnapName: "Full-final", LastRevision: revision}}},
+			nil, false, []brclient.SnapshotKind{brclient.FullSnapshotKind}},
+		{"should not mark the data directory if the final full snapshot cannot be taken", true,
+			&brclient.FakeClient{TriggerSnapshotErr: errors.New("snapstore unreachable")},
+			nil, true, []brclient.SnapshotKind{brclient.FullSnapshotKind}},
+		{"should not mark the data directory if backup-restore reports no full snapshot", true,
+			&brclient.FakeClient{},
+			nil, true, []brclient.SnapshotKind{brclient.FullSnapshotKind}},
+		{"should not mark the data directory if the full snapshot does not cover the current revision", true,
+			&brclient.FakeClient{LatestSnapshots: &brclient.LatestSnapshots{FullSnapshot: &brclient.Snapshot{SnapName: "Full-stale", LastRevision: revision - 1}}},
+			nil, true, []brclient.SnapshotKind{brclient.FullSnapshotKind}},
+		{"should not hibernate if etcd is not running", false,
+			&brclient.FakeClient{},
+			errEtcdNotRunning, true, nil},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		app := &Application{
+			ctx:         context.Background(),
+			cfg:         &embed.Config{Dir: t.TempDir()},
+			etcdClient:  cli,
+			brClient:    entry.fakeClient,
+			auditLogger: audit.NewNoopLogger(),
+			logger:      zaptest.NewLogger(t),
+		}
+		if entry.etcdRunning {
+			app.etcd = etcd
+		}
+
+		marker, err := app.hibernate(context.Background())
+		g.Expect(err != nil).To(Equal(entry.expectError))
+		if entry.expectedErr != nil {
+			g.Expect(err).To(MatchError(entry.expectedErr))
+		}
+		g.Expect(entry.fakeClient.TriggeredSnapshotKinds).To(Equal(entry.expectedSnapshots))
+		g.Expect(app.hibernated.Load()).To(Equal(!entry.expectError))
+		g.Expect(app.hibernating.Load()).To(Equal(!entry.expectError))
+		if entry.expectError {
+			g.Expect(app.hibernationMarkerPath()).ToNot(BeAnExistingFile())
+			continue
+		}
+		data, err := os.ReadFile(app.hibernationMarkerPath())
+		g.Expect(err).ToNot(HaveOccurred())
+		written := hibernationMarker{}
+		g.Expect(json.Unmarshal(data, &written)).To(Succeed())
+		g.Expect(written.SnapName).To(Equal(marker.SnapName))
+		g.Expect(written.EtcdRevision).To(Equal(revision))
+		g.Expect(app.exitReason.Load()).To(Equal(exitReasonHibernated))
+
+		t.Log("should remove the hibernation marker once the member has woken up")
+		app.clearHibernationMarker()
+		g.Expect(app.hibernationMarkerPath()).ToNot(BeAnExistingFile())
+	}
+}
+
+func TestVerifyHibernation(t *testing.T) {
+	g := NewWithT(t)
+	etcd := startTestEtcd(t, g)
+	cli, err := clientv3.New(clientv3.Config{Endpoints: []string{etcd.Config().ListenClientUrls[0].String()}, DialTimeout: 5 * time.Second})
+	g.Expect(err).ToNot(HaveOccurred())
+	resp, err := cli.Put(context.Background(), "foo", "bar")
+	g.Expect(err).ToNot(HaveOccurred())
+	revision := resp.Header.Revision
+	g.Expect(cli.Close()).To(Succeed())
+	etcd.Close()
+
+	table := []struct {
+		description          string
+		hibernated           bool
+		snapshotLastRevision int64
+		expectError          bool
+	}{
+		{"should do nothing if the member has not hibernated", false, 0, false},
+		{"should keep the marker if the final full snapshot contains the revision of the data directory", true, revision, false},
+		{"should remove the marker if etcd has been written after the final full snapshot", true, revision - 1, true},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		app := &Application{cfg: &embed.Config{Dir: etcd.Config().Dir}, logger: zaptest.NewLogger(t)}
+		app.hibernated.Store(entry.hibernated)
+		if entry.hibernated {
+			g.Expect(app.writeHibernationMarker(&hibernationMarker{SnapName: "Full-final", SnapshotLastRevision: entry.snapshotLastRevision})).To(Succeed())
+		}
+
+		err := app.verifyHibernation()
+		g.Expect(err != nil).To(Equal(entry.expectError))
+		g.Expect(app.hibernated.Load()).To(Equal(entry.hibernated && !entry.expectError))
+		if entry.hibernated && !entry.expectError {
+			g.Expect(app.hibernationMarkerPath()).To(BeAnExistingFile())
+		} else {
+			g.Expect(app.hibernationMarkerPath()).ToNot(BeAnExistingFile())
+		}
+		app.clearHibernationMarker()
+	}
+}
+
+func TestHibernateHandler(t *testing.T) {
+	g := NewWithT(t)
+	app := &Application{ctx: context.Background(), cfg: &embed.Config{Dir: t.TempDir()}, brClient: &brclient.FakeClient{}, auditLogger: audit.NewNoopLogger(), logger: zaptest.NewLogger(t)}
+
+	t.Log("should respond with 404 if the endpoint is not enabled")
+	recorder := httptest.NewRecorder()
+	app.hibernateHandler(recorder, httptest.NewRequest(http.MethodPost, "/hibernate", nil))
+	g.Expect(recorder.Code).To(Equal(http.StatusNotFound))
+	g.Expect(app.hibernationMarkerPath()).ToNot(BeAnExistingFile())
+
+	app.Config.Hibernation.EndpointEnabled = true
+	t.Log("should reject requests other than POST")
+	recorder = httptest.NewRecorder()
+	app.hibernateHandler(recorder, httptest.NewRequest(http.MethodGet, "/hibernate", nil))
+	g.Expect(recorder.Code).To(Equal(http.StatusMethodNotAllowed))
+
+	t.Log("should respond with 503 if etcd is not running")
+	recorder = httptest.NewRecorder()
+	app.hibernateHandler(recorder, httptest.NewRequest(http.MethodPost, "/hibernate", nil))
+	g.Expect(recorder.Code).To(Equal(http.StatusServiceUnavailable))
+
+	t.Log("should respond with 409 while a hibernation is in progress")
+	app.hibernationMu.Lock()
+	recorder = httptest.NewRecorder()
+	app.hibernateHandler(recorder, httptest.NewRequest(http.MethodPost, "/hibernate", nil))
+	app.hibernationMu.Unlock()
+	g.Expect(recorder.Code).To(Equal(http.StatusConflict))
+}
+
+func TestHibernationIntended(t *testing.T) {
+	intentFile := filepath.Join(t.TempDir(), "hibernate")
+	table := []struct {
+		description    string
+		intentFilePath string
+		createFile     bool
+		expected       bool
+	}{
+		{"should not intend hibernation without intent file", "", false, false},
+		{"should not intend hibernation if the intent file does not exist", intentFile, false, false},
+		{"should intend hibernation if the intent file exists", intentFile, true, true},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		if entry.createFile {
+			g.Expect(os.WriteFile(entry.intentFilePath, nil, 0600)).To(Succeed())
+		}
+		app := &Application{Config: types.Config{Hibernation: types.HibernationConfig{IntentFilePath: entry.intentFilePath}}}
+		g.Expect(app.hibernationIntended()).To(Equal(entry.expected))
+	}
+}
@@ -0,0 +1,108 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/types"
+
+	"go.etcd.io/etcd/embed"
+	"go.uber.org/zap"
+)
+
+const (
+	hookPreStart  = "pre-start"
+	hookPostReady = "post-ready"
+	// hookOutputLimit is the maximum number of bytes of the output of a hook which is logged.
+	hookOutputLimit = 4096
+)
+
+// HookError is returned if a hook has failed.
+type HookError struct {
+	// Hook is the hook which has failed, either pre-start or post-ready.
+	Hook string
+	// Err is the error with which the hook has failed.
+	Err error
+}
+
+func (e *HookError) Error() string {
+	return fmt.Sprintf("%s hook failed: %v", e.Hook, e.Err)
+}
+
+func (e *HookError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode returns the exit code with which etcd-wrapper exits if etcd has not been started since the pre-start hook
+// has failed.
+func (e *HookError) ExitCode() int {
+	return types.ExitCodePreStartHookFailed
+}
+
+// runPreStartHook runs the pre-start hook, if configured, and returns a HookError if it fails.
+func (a *Application) runPreStartHook() error {
+	if a.Config.Hooks.PreStart == "" {
+		return nil
+	}
+	return a.runHook(hookPreStart, a.Config.Hooks.PreStart, a.hookEnv(hookPreStart, nil))
+}
+
+// runPostReadyHook runs the post-ready hook, if configured, once etcd is ready. Its failure is only logged.
+func (a *Application) runPostReadyHook(etcd *embed.Etcd) {
+	if a.Config.Hooks.PostReady == "" {
+		return
+	}
+	_ = a.runHook(hookPostReady, a.Config.Hooks.PostReady, a.hookEnv(hookPostReady, etcd))
+}
+
+// hookEnv returns the environment of a hook, which is the environment of etcd-wrapper extended by the hook and the
+// member, and the IDs of the cluster and member if etcd is ready.
+func (a *Application) hookEnv(hook string, etcd *embed.Etcd) []string {
+	env := append(os.Environ(),
+		"ETCD_WRAPPER_HOOK="+hook,
+		"ETCD_NAME="+a.cfg.Name,
+		"ETCD_DATA_DIR="+a.cfg.Dir,
+	)
+	if etcd != nil {
+		env = append(env,
+			"ETCD_CLUSTER_ID="+etcd.Server.Cluster().ID().String(),
+			"ETCD_MEMBER_ID="+etcd.Server.ID().String(),
+		)
+	}
+	return env
+}
+
+// runHook runs the command of the hook without a shell, which is not available in the image of etcd-wrapper, and logs
+// its combined output. The hook is killed once the hook timeout has passed.
+func (a *Application) runHook(hook, command string, env []string) error {
+	ctx, cancelFunc := context.WithTimeout(a.ctx, a.Config.Hooks.Timeout)
+	defer cancelFunc()
+	args := strings.Fields(command)
+	output := &bytes.Buffer{}
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...) // #nosec G204 -- the command is configured by the operator.
+	cmd.Stdout, cmd.Stderr = output, output
+	cmd.Env = env
+	a.logger.Info("running hook", zap.String("hook", hook), zap.String("command", command))
+	start := time.Now()
+	err := cmd.Run()
+	out := strings.TrimSpace(output.String())
+	fields := []zap.Field{zap.String("hook", hook), zap.Duration("duration", time.Since(start)), zap.String("output", out[:min(len(out), hookOutputLimit)])}
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			err = fmt.Errorf("killed after timeout of %s: %w", a.Config.Hooks.Timeout, err)
+		}
+		a.logger.Error("hook failed", append(fields, zap.Error(err))...)
+		return &HookError{Hook: hook, Err: err}
+	}
+	a.logger.Info("hook succeeded", fields...)
+	return nil
+}
@@ -0,0 +1,84 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/types"
+
+	. "github.com/onsi/gomega"
+	"go.etcd.io/etcd/embed"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestRunPreStartHook(t *testing.T) {
+	dir := t.TempDir()
+	envPath := filepath.Join(dir, "env")
+	script := filepath.Join(dir, "hook.sh")
+	g := NewWithT(t)
+	g.Expect(os.WriteFile(script, []byte("#!/bin/sh\necho \"$ETCD_WRAPPER_HOOK $ETCD_NAME $ETCD_DATA_DIR\" > \"$1\"\n"), 0700)).To(Succeed())
+
+	table := []struct {
+		description   string
+		hook          string
+		timeout       time.Duration
+		expectedError bool
+	}{
+		{"should not fail if no pre-start hook is configured", "", time.Second, false},
+		{"should run the pre-start hook with the environment of the member", script + " " + envPath, time.Second, false},
+		{"should fail if the pre-start hook exits with another status", "sh -c false", time.Second, true},
+		{"should fail if the pre-start hook does not exist", filepath.Join(dir, "missing"), time.Second, true},
+		{"should fail if the pre-start hook exceeds the timeout", "sleep 10", 100 * time.Millisecond, true},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		cfg := embed.NewConfig()
+		cfg.Dir = dir
+		app := &Application{
+			ctx:    context.Background(),
+			Config: types.Config{Hooks: types.HooksConfig{PreStart: entry.hook, Timeout: entry.timeout}},
+			cfg:    cfg,
+			logger: zaptest.NewLogger(t),
+		}
+		err := app.runPreStartHook()
+		g.Expect(err != nil).To(Equal(entry.expectedError))
+		if err != nil {
+			var hookErr *HookError
+			g.Expect(errors.As(err, &hookErr)).To(BeTrue())
+			g.Expect(hookErr.ExitCode()).To(Equal(types.ExitCodePreStartHookFailed))
+		}
+	}
+	env, err := os.ReadFile(envPath)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(string(env)).To(Equal("pre-start " + embed.DefaultName + " " + dir + "\n"))
+}
+
+func TestRunPostReadyHook(t *testing.T) {
+	g := NewWithT(t)
+	etcd := startTestEtcd(t, g)
+	dir := t.TempDir()
+	envPath := filepath.Join(dir, "env")
+	script := filepath.Join(dir, "hook.sh")
+	g.Expect(os.WriteFile(script, []byte("#!/bin/sh\necho \"$ETCD_WRAPPER_HOOK $ETCD_CLUSTER_ID $ETCD_MEMBER_ID\" > \"$1\"\n"), 0700)).To(Succeed())
+	app := &Application{
+		ctx:    context.Background(),
+		Config: types.Config{Hooks: types.HooksConfig{PostReady: script + " " + envPath, Timeout: time.Second}},
+		logger: zaptest.NewLogger(t),
+	}
+	cfg := etcd.Config()
+	app.cfg = &cfg
+	app.runPostReadyHook(etcd)
+	env, err := os.ReadFile(envPath)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(string(env)).To(Equal("post-ready " + etcd.Server.Cluster().ID().String() + " " + etcd.Server.ID().String() + "\n"))
+}
@@ -0,0 +1,51 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/metrics"
+
+	"go.uber.org/zap"
+)
+
+const hotStandbyCheckInterval = 30 * time.Second
+
+// watchHotStandby periodically verifies that the member of a hot-standby etcd-wrapper is still a raft learner.
+// It stops when the application context is cancelled.
+func (a *Application) watchHotStandby() {
+	if !a.Config.HotStandby {
+		return
+	}
+	ticker := time.NewTicker(hotStandbyCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-ticker.C:
+			a.checkHotStandby()
+		}
+	}
+}
+
+// checkHotStandby records whether the local member is a raft learner. etcd-wrapper never promotes a hot-standby
+// member, so a voting member indicates that it has been added or promoted externally and takes part in quorum.
+func (a *Application) checkHotStandby() {
+	etcd := a.getEtcd()
+	if etcd == nil {
+		return
+	}
+	isLearner := etcd.Server.IsLearner()
+	a.learner.Store(isLearner)
+	if isLearner {
+		metrics.HotStandbyLearner.Set(1)
+		return
+	}
+	metrics.HotStandbyLearner.Set(0)
+	a.logger.Error("hot-standby member is a voting member and counts towards quorum, it must be added as learner and never be promoted", zap.String("member", etcd.Server.ID().String()))
+}
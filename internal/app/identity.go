@@ -0,0 +1,46 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"fmt"
+	"github.com/gardener/etcd-wrapper/internal/metrics"
+	"github.com/gardener/etcd-wrapper/internal/util"
+
+	"go.etcd.io/etcd/embed"
+	"go.uber.org/zap"
+)
+
+const (
+	// memberIdentityEnvClusterID is the variable of the ID of the etcd cluster in the member identity file.
+	memberIdentityEnvClusterID = "ETCD_CLUSTER_ID"
+	// memberIdentityEnvMemberID is the variable of the ID of the etcd member in the member identity file.
+	memberIdentityEnvMemberID = "ETCD_MEMBER_ID"
+	// memberIdentityEnvMemberName is the variable of the name of the etcd member in the member identity file.
+	memberIdentityEnvMemberName = "ETCD_MEMBER_NAME"
+)
+
+// recordMemberIdentity records the IDs of the etcd cluster and member once etcd is ready: they are logged, added as
+// labels to all metrics and written into the member identity file, if one has been configured. Both IDs change when
+// the data directory is restored or the member is replaced, hence they are recorded after every start of etcd.
+func (a *Application) recordMemberIdentity(etcd *embed.Etcd) {
+	clusterID, memberID := etcd.Server.Cluster().ID().String(), etcd.Server.ID().String()
+	a.logger.Info("Identity of etcd member", zap.String("clusterID", clusterID), zap.String("memberID", memberID), zap.String("memberName", a.cfg.Name))
+	metrics.SetMemberIdentity(clusterID, memberID)
+	if a.Config.MemberIdentityFilePath == "" {
+		return
+	}
+	if err := writeMemberIdentityFile(a.Config.MemberIdentityFilePath, clusterID, memberID, a.cfg.Name); err != nil {
+		a.logger.Error("failed to write member identity file", zap.String("path", a.Config.MemberIdentityFilePath), zap.Error(err))
+	}
+}
+
+// writeMemberIdentityFile writes the identity of the etcd member into the file at path, one `KEY=value` pair per line,
+// such that it can be sourced by a shell or read as an env file. The file is replaced atomically so that readers never
+// observe a partially written file.
+func writeMemberIdentityFile(path, clusterID, memberID, memberName string) error {
+	content := fmt.Sprintf("%s=%s\n%s=%s\n%s=%s\n", memberIdentityEnvClusterID, clusterID, memberIdentityEnvMemberID, memberID, memberIdentityEnvMemberName, memberName)
+	return util.WriteFileAtomic(path, []byte(content), 0644) // #nosec G306 -- the identity file is meant to be read by other containers of the pod.
+}
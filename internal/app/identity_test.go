@@ -0,0 +1,36 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestWriteMemberIdentityFile(t *testing.T) {
+	g := NewWithT(t)
+	path := filepath.Join(t.TempDir(), "member_identity.env")
+
+	t.Log("should write the member identity as env file")
+	g.Expect(writeMemberIdentityFile(path, "cdf818194e3a8c32", "8e9e05c52164694d", "etcd-main-0")).To(Succeed())
+	content, err := os.ReadFile(path)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(string(content)).To(Equal("ETCD_CLUSTER_ID=cdf818194e3a8c32\nETCD_MEMBER_ID=8e9e05c52164694d\nETCD_MEMBER_NAME=etcd-main-0\n"))
+
+	t.Log("should replace the member identity after a restoration")
+	g.Expect(writeMemberIdentityFile(path, "1c45a069f3a1d796", "8e9e05c52164694d", "etcd-main-0")).To(Succeed())
+	content, err = os.ReadFile(path)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(string(content)).To(HavePrefix("ETCD_CLUSTER_ID=1c45a069f3a1d796\n"))
+	entries, err := os.ReadDir(filepath.Dir(path))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(entries).To(HaveLen(1))
+
+	t.Log("should return error when directory does not exist")
+	g.Expect(writeMemberIdentityFile(filepath.Join(t.TempDir(), "does-not-exist", "member_identity.env"), "a", "b", "c")).ToNot(Succeed())
+}
@@ -0,0 +1,200 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/audit"
+
+	"go.etcd.io/etcd/etcdserver/etcdserverpb"
+	"go.uber.org/zap"
+)
+
+const (
+	// leadershipTransferTargetParam is the query parameter of the leadership transfer endpoint carrying the name or the
+	// hexadecimal ID of the member to transfer the leadership to.
+	leadershipTransferTargetParam = "target"
+	// leadershipTransferTimeout is the time after which a leadership transfer is given up, which has to fit into the
+	// write timeout of the etcd-wrapper server.
+	leadershipTransferTimeout = 15 * time.Second
+)
+
+var (
+	errLeadershipTransferInProgress = errors.New("leadership transfer is already in progress")
+	errInvalidTransferTarget        = errors.New("invalid target of leadership transfer")
+	errNoTransferCandidate          = errors.New("no started voting member to transfer the leadership to")
+	errNoLeader                     = errors.New("etcd cluster has no leader")
+)
+
+// leadershipTransferResponse is the response of the leadership transfer endpoint.
+type leadershipTransferResponse struct {
+	// PreviousLeader is the name of the leader before the transfer.
+	PreviousLeader string `json:"previousLeader"`
+	// Leader is the name of the member to which the leadership has been transferred.
+	Leader string `json:"leader"`
+	// Transferred is false if the target has already been the leader, in which case nothing has been done.
+	Transferred bool `json:"transferred"`
+}
+
+// transferLeadershipTo transfers the leadership of the etcd cluster to the started voting member named or identified
+// by target. If target is empty, the follower which has applied the most raft entries is chosen, so that the cluster
+// is without leader for as short as possible.
+func (a *Application) transferLeadershipTo(ctx context.Context, target string) (*leadershipTransferResponse, error) {
+	if !a.leadershipTransferMu.TryLock() {
+		return nil, errLeadershipTransferInProgress
+	}
+	defer a.leadershipTransferMu.Unlock()
+	if !a.etcdRunning() {
+		return nil, errEtcdNotRunning
+	}
+	listCtx, cancelFunc := context.WithTimeout(ctx, etcdGetTimeout)
+	defer cancelFunc()
+	status, err := a.etcdClient.Status(listCtx, a.etcdClient.Endpoints()[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to get etcd status: %w", err)
+	}
+	if status.Leader == 0 {
+		return nil, errNoLeader
+	}
+	memberList, err := a.etcdClient.MemberList(listCtx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list members: %w", err)
+	}
+	raftLeader := memberByID(memberList.Members, status.Leader)
+	if raftLeader == nil {
+		return nil, fmt.Errorf("leader %s is not a member of the etcd cluster", strconv.FormatUint(status.Leader, 16))
+	}
+
+	var transferee *etcdserverpb.Member
+	if target != "" {
+		if transferee, err = transferTarget(memberList.Members, target); err != nil {
+			return nil, err
+		}
+	} else if transferee = a.bestTransferee(listCtx, memberList.Members, raftLeader.ID); transferee == nil {
+		return nil, errNoTransferCandidate
+	}
+	response := &leadershipTransferResponse{PreviousLeader: raftLeader.Name, Leader: transferee.Name}
+	if transferee.ID == raftLeader.ID {
+		a.logger.Info("target of leadership transfer is already the leader", zap.String("leader", raftLeader.Name))
+		return response, nil
+	}
+
+	a.logger.Info("transferring leadership", zap.String("leader", raftLeader.Name), zap.String("transferee", transferee.Name))
+	if err = audit.Record(a.auditLogger, audit.OperationLeadershipTransfer, transferee.Name, func() error {
+		return a.moveLeader(ctx, raftLeader, transferee.ID)
+	}); err != nil {
+		return nil, fmt.Errorf("failed to transfer leadership to %s: %w", transferee.Name, err)
+	}
+	response.Transferred = true
+	a.logger.Info("transferred leadership", zap.String("previousLeader", raftLeader.Name), zap.String("leader", transferee.Name))
+	return response, nil
+}
+
+// bestTransferee returns the started voting member other than the leader which has applied the most raft entries, nil
+// if there is no such member which is reachable.
+func (a *Application) bestTransferee(ctx context.Context, members []*etcdserverpb.Member, leader uint64) *etcdserverpb.Member {
+	var (
+		best             *etcdserverpb.Member
+		bestAppliedIndex uint64
+	)
+	for _, member := range members {
+		if member.ID == leader || member.Name == "" || member.IsLearner || len(member.ClientURLs) == 0 {
+			continue
+		}
+		status, err := a.etcdClient.Status(ctx, member.ClientURLs[0])
+		if err != nil {
+			a.logger.Warn("skipping unreachable member as target of leadership transfer", zap.String("member", member.Name), zap.Error(err))
+			continue
+		}
+		if best == nil || status.RaftAppliedIndex > bestAppliedIndex || (status.RaftAppliedIndex == bestAppliedIndex && cmp.Less(member.ID, best.ID)) {
+			best, bestAppliedIndex = member, status.RaftAppliedIndex
+		}
+	}
+	return best
+}
+
+// moveLeader requests the raft leader to transfer its leadership to transferee. The request is sent to the raft
+// leader, since only the leader can transfer its leadership.
+func (a *Application) moveLeader(ctx context.Context, raftLeader *etcdserverpb.Member, transferee uint64) error {
+	client, err := a.createEtcdClientFor(raftLeader.ClientURLs...)
+	if err != nil {
+		return fmt.Errorf("failed to create etcd client for raft leader: %w", err)
+	}
+	defer func() {
+		_ = client.Close()
+	}()
+	ctx, cancelFunc := context.WithTimeout(ctx, leadershipTransferTimeout)
+	defer cancelFunc()
+	_, err = client.MoveLeader(ctx, transferee)
+	return err
+}
+
+// transferTarget returns the member named or identified by target, which must be a started voting member.
+func transferTarget(members []*etcdserverpb.Member, target string) (*etcdserverpb.Member, error) {
+	id, parseErr := strconv.ParseUint(target, 16, 64)
+	index := slices.IndexFunc(members, func(member *etcdserverpb.Member) bool {
+		return member.Name == target || (parseErr == nil && member.ID == id)
+	})
+	if index < 0 {
+		return nil, fmt.Errorf("%w: %s is not a member of the etcd cluster", errInvalidTransferTarget, target)
+	}
+	member := members[index]
+	switch {
+	case member.IsLearner:
+		return nil, fmt.Errorf("%w: %s is a learner", errInvalidTransferTarget, target)
+	case member.Name == "":
+		return nil, fmt.Errorf("%w: %s has not been started", errInvalidTransferTarget, target)
+	}
+	return member, nil
+}
+
+func memberByID(members []*etcdserverpb.Member, id uint64) *etcdserverpb.Member {
+	index := slices.IndexFunc(members, func(member *etcdserverpb.Member) bool { return member.ID == id })
+	if index < 0 {
+		return nil
+	}
+	return members[index]
+}
+
+// leadershipTransferHandler transfers the leadership of the etcd cluster on a POST request, to the member passed with
+// the target query parameter or to the best candidate if none is passed, e.g. before an update orchestrator deletes
+// the pod of the leader.
+func (a *Application) leadershipTransferHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "leadership transfer must be requested with POST", http.StatusMethodNotAllowed)
+		return
+	}
+	target := r.URL.Query().Get(leadershipTransferTargetParam)
+	a.logger.Info("received leadership transfer request", zap.String("target", target))
+	response, err := a.transferLeadershipTo(r.Context(), target)
+	switch {
+	case errors.Is(err, errInvalidTransferTarget):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, errLeadershipTransferInProgress), errors.Is(err, errNoTransferCandidate):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case errors.Is(err, errEtcdNotRunning), errors.Is(err, errNoLeader):
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	case err != nil:
+		a.logger.Error("failed to transfer leadership", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(response); err != nil {
+		a.logger.Error("failed to write leadership transfer response", zap.Error(err))
+	}
+}
@@ -0,0 +1,103 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/audit"
+
+	. "github.com/onsi/gomega"
+	"go.etcd.io/etcd/clientv3"
+	"go.etcd.io/etcd/etcdserver/etcdserverpb"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestTransferTarget(t *testing.T) {
+	members := []*etcdserverpb.Member{
+		{ID: 0x1a, Name: "etcd-main-0"},
+		{ID: 0x2b, Name: "etcd-main-1"},
+		{ID: 0x3c, Name: "etcd-main-2", IsLearner: true},
+		{ID: 0x4d},
+	}
+	table := []struct {
+		description string
+		target      string
+		expectedID  uint64
+		expectError bool
+	}{
+		{"should find the target by name", "etcd-main-1", 0x2b, false},
+		{"should find the target by hexadecimal ID", "1a", 0x1a, false},
+		{"should reject an unknown target", "etcd-main-5", 0, true},
+		{"should reject a learner", "etcd-main-2", 0, true},
+		{"should reject a member which has not been started", "4d", 0, true},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		member, err := transferTarget(members, entry.target)
+		if entry.expectError {
+			g.Expect(errors.Is(err, errInvalidTransferTarget)).To(BeTrue())
+			continue
+		}
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(member.ID).To(Equal(entry.expectedID))
+	}
+}
+
+func TestLeadershipTransferHandler(t *testing.T) {
+	g := NewWithT(t)
+	app := &Application{ctx: context.Background(), auditLogger: audit.NewNoopLogger(), logger: zaptest.NewLogger(t)}
+
+	t.Log("should reject requests other than POST")
+	recorder := httptest.NewRecorder()
+	app.leadershipTransferHandler(recorder, httptest.NewRequest(http.MethodGet, "/transfer-leadership", nil))
+	g.Expect(recorder.Code).To(Equal(http.StatusMethodNotAllowed))
+
+	t.Log("should respond with 503 if etcd is not running")
+	recorder = httptest.NewRecorder()
+	app.leadershipTransferHandler(recorder, httptest.NewRequest(http.MethodPost, "/transfer-leadership", nil))
+	g.Expect(recorder.Code).To(Equal(http.StatusServiceUnavailable))
+
+	t.Log("should respond with 409 while a leadership transfer is in progress")
+	app.leadershipTransferMu.Lock()
+	recorder = httptest.NewRecorder()
+	app.leadershipTransferHandler(recorder, httptest.NewRequest(http.MethodPost, "/transfer-leadership", nil))
+	app.leadershipTransferMu.Unlock()
+	g.Expect(recorder.Code).To(Equal(http.StatusConflict))
+
+	etcd := startTestEtcd(t, g)
+	cli, err := clientv3.New(clientv3.Config{Endpoints: []string{etcd.Config().ListenClientUrls[0].String()}, DialTimeout: 5 * time.Second})
+	g.Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		_ = cli.Close()
+	}()
+	app.etcd, app.etcdClient = etcd, cli
+
+	t.Log("should respond with 409 if there is no other voting member")
+	recorder = httptest.NewRecorder()
+	app.leadershipTransferHandler(recorder, httptest.NewRequest(http.MethodPost, "/transfer-leadership", nil))
+	g.Expect(recorder.Code).To(Equal(http.StatusConflict))
+
+	t.Log("should respond with 400 if the target is not a member")
+	recorder = httptest.NewRecorder()
+	app.leadershipTransferHandler(recorder, httptest.NewRequest(http.MethodPost, "/transfer-leadership?target=unknown", nil))
+	g.Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+
+	t.Log("should not transfer the leadership if the target is the leader already")
+	recorder = httptest.NewRecorder()
+	app.leadershipTransferHandler(recorder, httptest.NewRequest(http.MethodPost, "/transfer-leadership?target="+etcd.Config().Name, nil))
+	g.Expect(recorder.Code).To(Equal(http.StatusOK))
+	var response leadershipTransferResponse
+	g.Expect(json.NewDecoder(recorder.Body).Decode(&response)).To(Succeed())
+	g.Expect(response).To(Equal(leadershipTransferResponse{PreviousLeader: etcd.Config().Name, Leader: etcd.Config().Name}))
+}
@@ -0,0 +1,119 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/brclient"
+	"github.com/gardener/etcd-wrapper/internal/httpserver"
+	"github.com/gardener/etcd-wrapper/internal/lifecycle"
+
+	"go.uber.org/zap"
+)
+
+// httpServerStopGracePeriod is the time granted to the HTTP server on top of its shutdown timeout to close the
+// remaining connections.
+const httpServerStopGracePeriod = time.Second
+
+// components returns the components started by Start in the order they are started. They are stopped in reverse
+// order, so that the monitors stop before etcd, and the HTTP server serving the probes keeps serving till etcd has
+// stopped.
+func (a *Application) components() []lifecycle.Component {
+	httpShutdownTimeout := a.Config.HTTPServer.ShutdownTimeout
+	if httpShutdownTimeout <= 0 {
+		httpShutdownTimeout = httpserver.DefaultShutdownTimeout
+	}
+	return []lifecycle.Component{
+		{
+			Name: "log-sinks",
+			Stop: func(context.Context) error { return a.closeLogSinks() },
+		},
+		{
+			Name: "audit-log",
+			Stop: func(context.Context) error { return a.auditLogger.Close() },
+		},
+		{
+			Name: "sidecar-client",
+			Stop: func(context.Context) error {
+				if closer, ok := a.brClient.(brclient.IdleConnectionCloser); ok {
+					closer.CloseIdleConnections()
+				}
+				return nil
+			},
+		},
+		{
+			Name: "http-server",
+			Start: func(ctx context.Context) error {
+				a.RegisterHandler()
+				a.crashReporter.Go("http-server", func() { a.startHTTPServer(ctx) })
+				return nil
+			},
+			Stop:        func(context.Context) error { return a.stopHTTPServer() },
+			StopTimeout: httpShutdownTimeout + httpServerStopGracePeriod,
+		},
+		{
+			Name: "etcd",
+			Start: func(context.Context) error {
+				cli, err := a.createEtcdClient()
+				if err != nil {
+					return err
+				}
+				a.etcdClient = cli
+				return nil
+			},
+			Stop: func(context.Context) error {
+				a.closeEtcd()
+				select {
+				case a.hibernationErrCh <- a.verifyHibernation():
+				default:
+				}
+				return a.etcdClient.Close()
+			},
+			StopTimeout: a.etcdStopTimeout(),
+		},
+		{
+			Name:  "monitors",
+			Start: func(context.Context) error { a.startMonitors(); return nil },
+			Stop:  a.stopMonitors,
+		},
+	}
+}
+
+// stopComponents stops the components started by Start in reverse order and cancels the application context if not
+// already done so.
+func (a *Application) stopComponents() {
+	if err := a.lifecycle.Stop(); err != nil {
+		a.logger.Error("failed to stop components", zap.Error(err))
+	}
+	a.cancelContext()
+}
+
+// goMonitor runs fn in a goroutine which is waited for when the monitors are stopped. fn has to return once the
+// application context is cancelled.
+func (a *Application) goMonitor(name string, fn func()) {
+	a.monitors.Add(1)
+	a.crashReporter.Go(name, func() {
+		defer a.monitors.Done()
+		fn()
+	})
+}
+
+// stopMonitors cancels the application context and waits for the monitors to return or ctx to be done.
+func (a *Application) stopMonitors(ctx context.Context) error {
+	a.cancelContext()
+	stopped := make(chan struct{})
+	go func() {
+		a.monitors.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
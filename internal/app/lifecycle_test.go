@@ -0,0 +1,55 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/crashreport"
+	"github.com/gardener/etcd-wrapper/internal/types"
+
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestComponents(t *testing.T) {
+	g := NewWithT(t)
+	app := &Application{}
+	var names []string
+	for _, component := range app.components() {
+		names = append(names, component.Name)
+	}
+
+	t.Log("should stop the HTTP server after etcd and etcd after the monitors")
+	g.Expect(names).To(Equal([]string{"log-sinks", "audit-log", "sidecar-client", "http-server", "etcd", "monitors"}))
+}
+
+func TestStopMonitors(t *testing.T) {
+	g := NewWithT(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	logger := zaptest.NewLogger(t)
+	app := &Application{ctx: ctx, cancelFn: cancel, logger: logger, crashReporter: crashreport.NewReporter("", nil, types.Config{}, nil, logger)}
+	stopped := make(chan struct{})
+	app.goMonitor("test", func() {
+		<-app.ctx.Done()
+		time.Sleep(50 * time.Millisecond)
+		close(stopped)
+	})
+
+	t.Log("should cancel the application context and wait for the monitors to return")
+	g.Expect(app.stopMonitors(context.Background())).To(Succeed())
+	g.Expect(ctx.Err()).To(MatchError(context.Canceled))
+	g.Expect(stopped).To(BeClosed())
+
+	t.Log("should give up waiting once the context is done")
+	release := make(chan struct{})
+	defer close(release)
+	app.goMonitor("stuck", func() { <-release })
+	timeoutCtx, timeoutCancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer timeoutCancel()
+	g.Expect(app.stopMonitors(timeoutCtx)).To(MatchError(context.DeadlineExceeded))
+}
@@ -0,0 +1,47 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"errors"
+
+	"github.com/gardener/etcd-wrapper/internal/bootstrap"
+	"github.com/gardener/etcd-wrapper/internal/logsink"
+	"github.com/gardener/etcd-wrapper/internal/types"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// openLogSink opens the log sink configured by c. It returns nil if no outputs are configured, in which case the
+// default output is kept.
+func openLogSink(c types.LogSinkConfig) (*logsink.Sink, error) {
+	if len(c.Outputs) == 0 {
+		return nil, nil
+	}
+	return logsink.Open(c.Outputs, c.GetMaxSizeBytes(), c.MaxBackups)
+}
+
+// redirectLogger returns a logger which writes the entries of logger to sink instead of its original output, keeping
+// its level and the encoding of etcd-wrapper.
+func redirectLogger(logger *zap.Logger, sink *logsink.Sink) *zap.Logger {
+	encoderConfig := bootstrap.SetupLoggerConfig(types.DefaultLogLevel).EncoderConfig
+	return logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return zapcore.NewCore(zapcore.NewJSONEncoder(encoderConfig), sink, core)
+	}), zap.ErrorOutput(sink))
+}
+
+// closeLogSinks flushes the log sink of etcd-wrapper and closes the log sink of etcd, which has stopped by then. The
+// log sink of etcd-wrapper is left open, since etcd-wrapper logs till it exits.
+func (a *Application) closeLogSinks() error {
+	var err error
+	if a.wrapperLogSink != nil {
+		err = errors.Join(err, a.wrapperLogSink.Sync())
+	}
+	if a.etcdLogSink != nil {
+		err = errors.Join(err, a.etcdLogSink.Close())
+	}
+	return err
+}
@@ -0,0 +1,50 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/gardener/etcd-wrapper/internal/types"
+
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestOpenLogSink(t *testing.T) {
+	g := NewWithT(t)
+	t.Log("should keep the default output if no outputs are configured")
+	sink, err := openLogSink(types.LogSinkConfig{})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(sink).To(BeNil())
+
+	t.Log("should open the configured outputs")
+	sink, err = openLogSink(types.LogSinkConfig{Outputs: []string{filepath.Join(t.TempDir(), "wrapper.log")}})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(sink).ToNot(BeNil())
+	g.Expect(sink.Close()).To(Succeed())
+}
+
+func TestRedirectLogger(t *testing.T) {
+	g := NewWithT(t)
+	logPath := filepath.Join(t.TempDir(), "wrapper.log")
+	sink, err := openLogSink(types.LogSinkConfig{Outputs: []string{logPath}})
+	g.Expect(err).ToNot(HaveOccurred())
+	app := &Application{wrapperLogSink: sink}
+	logger := redirectLogger(zaptest.NewLogger(t, zaptest.Level(zap.InfoLevel)), sink)
+
+	logger.Debug("dropped entry")
+	logger.Info("redirected entry", zap.String("member", "etcd-main-0"))
+	g.Expect(app.closeLogSinks()).To(Succeed())
+
+	t.Log("should write the entries of etcd-wrapper to the sink, keeping the level of the logger")
+	entries := readLogEntries(g, logPath)
+	g.Expect(entries).To(HaveLen(1))
+	g.Expect(entries[0]).To(HaveKeyWithValue("msg", "redirected entry"))
+	g.Expect(entries[0]).To(HaveKeyWithValue("level", "info"))
+	g.Expect(entries[0]).To(HaveKeyWithValue("member", "etcd-main-0"))
+}
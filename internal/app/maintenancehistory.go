@@ -0,0 +1,55 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/maintenance"
+
+	"go.uber.org/zap"
+)
+
+// recordMaintenance records the maintenance operation with its outcome err in the maintenance history. The record
+// must carry the start of the operation and the sizes of the etcd DB before it. The sizes after it are taken from the
+// status of the local member.
+func (a *Application) recordMaintenance(record maintenance.Record, err error) {
+	a.recordMaintenanceOf(a.etcdClient.Endpoints()[0], record, err)
+}
+
+// recordMaintenanceOf records the maintenance operation performed on the member serving endpoint like
+// recordMaintenance, taking the sizes of the etcd DB after it from the status of that member.
+func (a *Application) recordMaintenanceOf(endpoint string, record maintenance.Record, err error) {
+	record.DurationSeconds = time.Since(record.StartedAt).Seconds()
+	if err != nil {
+		record.Error = err.Error()
+	}
+	ctx, cancelFunc := context.WithTimeout(a.ctx, etcdGetTimeout)
+	defer cancelFunc()
+	if status, statusErr := a.etcdClient.Status(ctx, endpoint); statusErr != nil {
+		a.logger.Warn("failed to get DB size after maintenance operation", zap.String("operation", string(record.Operation)), zap.Error(statusErr))
+	} else {
+		record.DBSizeAfter = status.DbSize
+		record.DBSizeInUseAfter = status.DbSizeInUse
+	}
+	if err = a.maintenanceHistory.Add(record); err != nil {
+		a.logger.Error("failed to record maintenance operation", zap.String("operation", string(record.Operation)), zap.Error(err))
+	}
+}
+
+// maintenanceHistoryHandler writes the maintenance history, oldest operation first, as JSON onto the http.ResponseWriter.
+func (a *Application) maintenanceHistoryHandler(w http.ResponseWriter, _ *http.Request) {
+	records := a.maintenanceHistory.Records()
+	if records == nil {
+		records = []maintenance.Record{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(records); err != nil {
+		a.logger.Error("failed to write maintenance history response", zap.Error(err))
+	}
+}
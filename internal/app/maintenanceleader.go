@@ -0,0 +1,60 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"errors"
+	"os"
+
+	"github.com/gardener/etcd-wrapper/internal/k8slease"
+	"github.com/gardener/etcd-wrapper/internal/metrics"
+
+	"go.uber.org/zap"
+)
+
+// runMaintenanceLeaderElection competes for the maintenance leader lease till the application context is cancelled.
+// The maintenance leader orchestrates cluster-wide maintenance on behalf of all members. No maintenance leader is
+// elected if no lease is configured or if etcd-wrapper does not run in a Kubernetes cluster, in which case every
+// member takes part in cluster-wide maintenance itself.
+func (a *Application) runMaintenanceLeaderElection() {
+	config := a.Config.MaintenanceLeader
+	if config.LeaseName == "" {
+		return
+	}
+	client, err := k8slease.NewInClusterClient(a.Config.DisableProxyEnv)
+	if err != nil {
+		if errors.Is(err, k8slease.ErrNotInCluster) {
+			a.logger.Info("not running in a Kubernetes cluster, no maintenance leader is elected")
+			return
+		}
+		a.logger.Error("failed to create Kubernetes client, no maintenance leader is elected", zap.Error(err))
+		return
+	}
+	namespace := config.LeaseNamespace
+	if namespace == "" {
+		if namespace, err = k8slease.InClusterNamespace(); err != nil {
+			a.logger.Error("failed to determine namespace of maintenance leader lease, no maintenance leader is elected", zap.Error(err))
+			return
+		}
+	}
+	identity := config.Identity
+	if identity == "" {
+		if identity, err = os.Hostname(); err != nil {
+			a.logger.Error("failed to determine identity for maintenance leader election, no maintenance leader is elected", zap.Error(err))
+			return
+		}
+	}
+	elector := k8slease.NewElector(client, namespace, config.LeaseName, identity, config.LeaseDuration, config.RenewInterval, func(leader bool) {
+		a.maintenanceLeader.Store(leader)
+		if leader {
+			metrics.MaintenanceLeader.Set(1)
+			return
+		}
+		metrics.MaintenanceLeader.Set(0)
+	}, a.logger)
+	a.maintenanceLeaderElection.Store(true)
+	a.logger.Info("competing for maintenance leader lease", zap.String("namespace", namespace), zap.String("lease", config.LeaseName), zap.String("identity", identity))
+	elector.Run(a.ctx)
+}
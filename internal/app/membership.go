@@ -0,0 +1,67 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"go.etcd.io/etcd/etcdserver/api"
+	etcdtypes "go.etcd.io/etcd/pkg/types"
+)
+
+// Membership is the membership of the etcd cluster as seen by the local member.
+type Membership struct {
+	// ClusterID is the ID of the etcd cluster.
+	ClusterID string `json:"clusterID"`
+	// LocalID is the ID of the local member.
+	LocalID string `json:"localID"`
+	// LeaderID is the ID of the leader as known by the local member. It is empty if there is no leader.
+	LeaderID string `json:"leaderID,omitempty"`
+	// Members are all members of the etcd cluster, sorted by their ID.
+	Members []Member `json:"members"`
+}
+
+// Member is a member of the etcd cluster.
+type Member struct {
+	// ID is the ID of the member.
+	ID string `json:"id"`
+	// Name is the name of the member. It is empty if the member has not been started yet.
+	Name string `json:"name,omitempty"`
+	// PeerURLs are the URLs on which the member serves peer traffic.
+	PeerURLs []string `json:"peerURLs,omitempty"`
+	// ClientURLs are the URLs on which the member serves client traffic. They are empty if the member has not been started yet.
+	ClientURLs []string `json:"clientURLs,omitempty"`
+	// IsLearner indicates whether the member is a raft learner rather than a voting member.
+	IsLearner bool `json:"isLearner"`
+}
+
+// membership returns the membership of the etcd cluster as seen by the embedded etcd. It is read from the local
+// member on every call, which also works for a learner that does not serve the MemberList RPC. It returns nil if
+// etcd is not running.
+func (a *Application) membership() *Membership {
+	etcd := a.getEtcd()
+	if etcd == nil {
+		return nil
+	}
+	return newMembership(etcd.Server.Cluster(), etcd.Server.ID(), etcd.Server.Leader())
+}
+
+func newMembership(cluster api.Cluster, localID, leaderID etcdtypes.ID) *Membership {
+	m := &Membership{
+		ClusterID: cluster.ID().String(),
+		LocalID:   localID.String(),
+	}
+	if leaderID != etcdtypes.ID(0) {
+		m.LeaderID = leaderID.String()
+	}
+	for _, member := range cluster.Members() {
+		m.Members = append(m.Members, Member{
+			ID:         member.ID.String(),
+			Name:       member.Name,
+			PeerURLs:   member.PeerURLs,
+			ClientURLs: member.ClientURLs,
+			IsLearner:  member.IsLearner,
+		})
+	}
+	return m
+}
@@ -0,0 +1,58 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"go.etcd.io/etcd/etcdserver/api"
+	"go.etcd.io/etcd/etcdserver/api/membership"
+	etcdtypes "go.etcd.io/etcd/pkg/types"
+)
+
+type fakeCluster struct {
+	api.Cluster
+	members []*membership.Member
+}
+
+func (c *fakeCluster) ID() etcdtypes.ID { return 0xcafe }
+
+func (c *fakeCluster) Members() []*membership.Member { return c.members }
+
+func TestNewMembership(t *testing.T) {
+	cluster := &fakeCluster{members: []*membership.Member{
+		{
+			ID:             0x1,
+			RaftAttributes: membership.RaftAttributes{PeerURLs: []string{"https://etcd-main-0:2380"}},
+			Attributes:     membership.Attributes{Name: "etcd-main-0", ClientURLs: []string{"https://etcd-main-0:2379"}},
+		},
+		{
+			ID:             0x2,
+			RaftAttributes: membership.RaftAttributes{PeerURLs: []string{"https://etcd-main-1:2380"}, IsLearner: true},
+		},
+	}}
+	table := []struct {
+		description      string
+		leaderID         etcdtypes.ID
+		expectedLeaderID string
+	}{
+		{"should report the leader known by the local member", 0x1, "1"},
+		{"should report no leader if the local member does not know the leader", 0, ""},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		m := newMembership(cluster, 0x2, entry.leaderID)
+		g.Expect(m.ClusterID).To(Equal("cafe"))
+		g.Expect(m.LocalID).To(Equal("2"))
+		g.Expect(m.LeaderID).To(Equal(entry.expectedLeaderID))
+		g.Expect(m.Members).To(Equal([]Member{
+			{ID: "1", Name: "etcd-main-0", PeerURLs: []string{"https://etcd-main-0:2380"}, ClientURLs: []string{"https://etcd-main-0:2379"}},
+			{ID: "2", PeerURLs: []string{"https://etcd-main-1:2380"}, IsLearner: true},
+		}))
+	}
+}
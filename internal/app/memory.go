@@ -0,0 +1,70 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"os"
+	"runtime/debug"
+
+	"github.com/gardener/etcd-wrapper/internal/memlimit"
+	"github.com/gardener/etcd-wrapper/internal/types"
+
+	"go.etcd.io/etcd/embed"
+	"go.etcd.io/etcd/etcdserver"
+	"go.uber.org/zap"
+)
+
+// raftEntryMemoryBudget is the memory budgeted per raft entry which etcd keeps in memory till it takes a snapshot.
+const raftEntryMemoryBudget = 10 * 1024
+
+// applyMemoryLimits sets the Go memory limit and sizes the in-memory raft log of etcd according to the memory limit
+// of the container, which avoids OOM kills on small memory limits. Explicitly configured values take precedence.
+func (a *Application) applyMemoryLimits(cfg *embed.Config) {
+	containerLimit, limited, err := memlimit.ContainerMemoryLimit(memlimit.DefaultCgroupRoot)
+	if err != nil {
+		a.logger.Warn("failed to read memory limit of container", zap.Error(err))
+	}
+	if !limited {
+		containerLimit = 0
+	}
+	_, goMemLimitEnvSet := os.LookupEnv("GOMEMLIMIT")
+	if goMemoryLimit := resolveGoMemoryLimit(a.Config.MemoryLimit, containerLimit, goMemLimitEnvSet); goMemoryLimit > 0 {
+		debug.SetMemoryLimit(goMemoryLimit)
+	}
+	cfg.SnapshotCount = resolveSnapshotCount(a.Config.MemoryLimit, containerLimit, cfg.SnapshotCount)
+	a.logger.Info("Configured memory limits",
+		zap.Int64("containerMemoryLimit", containerLimit),
+		zap.Int64("goMemoryLimit", debug.SetMemoryLimit(-1)),
+		zap.Uint64("snapshotCount", cfg.SnapshotCount))
+}
+
+// resolveGoMemoryLimit returns the Go memory limit to set, or zero if it should be left unchanged. An explicitly
+// configured limit takes precedence over the GOMEMLIMIT environment variable, which takes precedence over the limit
+// derived from the container memory limit.
+func resolveGoMemoryLimit(config types.MemoryLimitConfig, containerLimit int64, goMemLimitEnvSet bool) int64 {
+	switch {
+	case config.GoMemoryLimit > 0:
+		return config.GoMemoryLimit
+	case goMemLimitEnvSet || containerLimit <= 0 || config.Ratio <= 0:
+		return 0
+	default:
+		return int64(float64(containerLimit) * config.Ratio)
+	}
+}
+
+// resolveSnapshotCount returns the number of committed raft entries after which etcd takes a snapshot and truncates
+// its in-memory raft log. An explicitly configured count takes precedence. Otherwise the configured count of etcd is
+// lowered such that the in-memory raft log fits into the container memory limit, but never below the number of entries
+// retained for slow followers.
+func resolveSnapshotCount(config types.MemoryLimitConfig, containerLimit int64, etcdSnapshotCount uint64) uint64 {
+	if config.SnapshotCount > 0 {
+		return config.SnapshotCount
+	}
+	if containerLimit <= 0 || config.Ratio <= 0 {
+		return etcdSnapshotCount
+	}
+	limitedCount := max(uint64(containerLimit/raftEntryMemoryBudget), etcdserver.DefaultSnapshotCatchUpEntries)
+	return min(etcdSnapshotCount, limitedCount)
+}
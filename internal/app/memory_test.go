@@ -0,0 +1,61 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"testing"
+
+	"github.com/gardener/etcd-wrapper/internal/types"
+
+	. "github.com/onsi/gomega"
+	"go.etcd.io/etcd/etcdserver"
+)
+
+const mib = 1024 * 1024
+
+func TestResolveGoMemoryLimit(t *testing.T) {
+	table := []struct {
+		description      string
+		config           types.MemoryLimitConfig
+		containerLimit   int64
+		goMemLimitEnvSet bool
+		expectedLimit    int64
+	}{
+		{"should derive limit from container memory limit", types.MemoryLimitConfig{Ratio: 0.5}, 512 * mib, false, 256 * mib},
+		{"should not set limit when container has no memory limit", types.MemoryLimitConfig{Ratio: 0.9}, 0, false, 0},
+		{"should not set limit when deriving is disabled", types.MemoryLimitConfig{}, 512 * mib, false, 0},
+		{"should respect GOMEMLIMIT environment variable", types.MemoryLimitConfig{Ratio: 0.9}, 512 * mib, true, 0},
+		{"should prefer explicitly configured limit", types.MemoryLimitConfig{Ratio: 0.9, GoMemoryLimit: 100 * mib}, 512 * mib, true, 100 * mib},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		g.Expect(resolveGoMemoryLimit(entry.config, entry.containerLimit, entry.goMemLimitEnvSet)).To(Equal(entry.expectedLimit))
+	}
+}
+
+func TestResolveSnapshotCount(t *testing.T) {
+	table := []struct {
+		description       string
+		config            types.MemoryLimitConfig
+		containerLimit    int64
+		etcdSnapshotCount uint64
+		expectedCount     uint64
+	}{
+		{"should keep etcd snapshot count when container has no memory limit", types.MemoryLimitConfig{Ratio: 0.9}, 0, 100000, 100000},
+		{"should keep etcd snapshot count when it fits into the container memory limit", types.MemoryLimitConfig{Ratio: 0.9}, 4096 * mib, 100000, 100000},
+		{"should lower etcd snapshot count to fit into the container memory limit", types.MemoryLimitConfig{Ratio: 0.9}, 500 * mib, 100000, 51200},
+		{"should not lower etcd snapshot count below the catch-up entries", types.MemoryLimitConfig{Ratio: 0.9}, 10 * mib, 100000, etcdserver.DefaultSnapshotCatchUpEntries},
+		{"should keep etcd snapshot count when deriving is disabled", types.MemoryLimitConfig{}, 10 * mib, 100000, 100000},
+		{"should prefer explicitly configured count", types.MemoryLimitConfig{Ratio: 0.9, SnapshotCount: 20000}, 10 * mib, 100000, 20000},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		g.Expect(resolveSnapshotCount(entry.config, entry.containerLimit, entry.etcdSnapshotCount)).To(Equal(entry.expectedCount))
+	}
+}
@@ -0,0 +1,201 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/metrics"
+	"github.com/gardener/etcd-wrapper/internal/peerconnectivity"
+	"github.com/gardener/etcd-wrapper/internal/util"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// peerConnectivityProbeTimeout is the time after which a peer URL is considered unreachable.
+	peerConnectivityProbeTimeout = 5 * time.Second
+	// peerConnectivityFetchTimeout is the time after which asking the etcd-wrapper of another member for its row of the
+	// connectivity matrix is abandoned.
+	peerConnectivityFetchTimeout = 3 * peerConnectivityProbeTimeout
+	// peerConnectivityScopeCluster is the value of the scope query parameter with which the peer connectivity endpoint
+	// collects the rows of all members into a connectivity matrix.
+	peerConnectivityScopeCluster = "cluster"
+)
+
+var errEtcdNotRunning = errors.New("etcd is not running")
+
+// watchPeerConnectivity periodically checks the reachability of the peer URLs of all other members, exports it as
+// metrics and logs peer URLs which have become unreachable or reachable again. It stops when the application context
+// is cancelled.
+func (a *Application) watchPeerConnectivity() {
+	if a.Config.PeerConnectivityCheckInterval <= 0 {
+		return
+	}
+	// unreachable holds the peer URLs which have been unreachable at the last check.
+	unreachable := map[string]bool{}
+	ticker := time.NewTicker(a.Config.PeerConnectivityCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-ticker.C:
+			row, _, err := a.checkPeerConnectivity(a.ctx)
+			if err != nil {
+				if !errors.Is(err, errEtcdNotRunning) {
+					a.logger.Error("failed to check peer connectivity", zap.Error(err))
+				}
+				continue
+			}
+			a.reportPeerConnectivity(row, unreachable)
+		}
+	}
+}
+
+// checkPeerConnectivity checks the reachability of the peer URLs of all other members from the local member. It
+// returns the row of the local member in the connectivity matrix along with the other members.
+func (a *Application) checkPeerConnectivity(ctx context.Context) (peerconnectivity.Row, []peerconnectivity.Member, error) {
+	etcd := a.getEtcd()
+	if etcd == nil {
+		return peerconnectivity.Row{}, nil, errEtcdNotRunning
+	}
+	listCtx, cancelFunc := context.WithTimeout(ctx, etcdGetTimeout)
+	defer cancelFunc()
+	response, err := a.etcdClient.MemberList(listCtx)
+	if err != nil {
+		return peerconnectivity.Row{}, nil, fmt.Errorf("failed to list members: %w", err)
+	}
+	localID := uint64(etcd.Server.ID())
+	var members []peerconnectivity.Member
+	for _, member := range response.Members {
+		if member.ID == localID {
+			continue
+		}
+		name := member.Name
+		if name == "" {
+			// the member has been added but not started yet
+			name = strconv.FormatUint(member.ID, 16)
+		}
+		members = append(members, peerconnectivity.Member{Name: name, PeerURLs: member.PeerURLs})
+	}
+	row := peerconnectivity.Check(ctx, a.cfg.Name, members, a.Config.DNS.NewDialer().DialContext, a.peerTLSConfig, peerConnectivityProbeTimeout)
+	return row, members, nil
+}
+
+// reportPeerConnectivity exports the reachability of every peer URL in row and logs the peer URLs which have become
+// unreachable or reachable again since the last check.
+func (a *Application) reportPeerConnectivity(row peerconnectivity.Row, unreachable map[string]bool) {
+	metrics.PeerReachable.Reset()
+	for _, probe := range row.Probes {
+		if probe.Reachable {
+			metrics.PeerReachable.WithLabelValues(probe.Member, probe.PeerURL).Set(1)
+			if unreachable[probe.PeerURL] {
+				a.logger.Info("peer URL is reachable again", zap.String("member", probe.Member), zap.String("peerURL", probe.PeerURL))
+				delete(unreachable, probe.PeerURL)
+			}
+			continue
+		}
+		metrics.PeerReachable.WithLabelValues(probe.Member, probe.PeerURL).Set(0)
+		if !unreachable[probe.PeerURL] {
+			a.logger.Warn("peer URL is unreachable, check the network policies between the members", zap.String("member", probe.Member), zap.String("peerURL", probe.PeerURL), zap.String("error", probe.Error))
+			unreachable[probe.PeerURL] = true
+		}
+	}
+}
+
+// peerTLSConfig returns the TLS configuration with which the peer URL u of another member is dialed.
+func (a *Application) peerTLSConfig(u *url.URL) (*tls.Config, error) {
+	var keyPair *util.KeyPair
+	if a.cfg.PeerTLSInfo.CertFile != "" && a.cfg.PeerTLSInfo.KeyFile != "" {
+		keyPair = &util.KeyPair{CertPath: a.cfg.PeerTLSInfo.CertFile, KeyPath: a.cfg.PeerTLSInfo.KeyFile}
+	}
+	return util.CreateTLSConfig(func() bool { return u.Scheme == "https" }, peerServerName(a.cfg, u), a.cfg.PeerTLSInfo.TrustedCAFile, keyPair)
+}
+
+// peerConnectivityHandler checks the reachability of the peer URLs of all other members from the local member and
+// responds with the result. With the query parameter scope=cluster, it additionally asks the etcd-wrapper of every other
+// member for its result and responds with the connectivity matrix of the cluster.
+func (a *Application) peerConnectivityHandler(w http.ResponseWriter, r *http.Request) {
+	row, members, err := a.checkPeerConnectivity(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	var response any = row
+	if r.URL.Query().Get("scope") == peerConnectivityScopeCluster {
+		response = peerconnectivity.NewMatrix(append(a.fetchPeerConnectivityRows(r.Context(), members), row))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(response); err != nil {
+		a.logger.Error("failed to write peer connectivity response", zap.Error(err))
+	}
+}
+
+// fetchPeerConnectivityRows asks the etcd-wrapper of every member for its row of the connectivity matrix. The
+// etcd-wrapper of a member is expected at the host of its first peer URL and the same port as the local etcd-wrapper.
+func (a *Application) fetchPeerConnectivityRows(ctx context.Context, members []peerconnectivity.Member) []peerconnectivity.Row {
+	rows := make([]peerconnectivity.Row, len(members))
+	var wg sync.WaitGroup
+	for i, member := range members {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			row, err := a.fetchPeerConnectivityRow(ctx, member)
+			if err != nil {
+				row = peerconnectivity.Row{From: member.Name, CheckedAt: time.Now(), Error: err.Error()}
+			}
+			rows[i] = row
+		}()
+	}
+	wg.Wait()
+	return rows
+}
+
+func (a *Application) fetchPeerConnectivityRow(ctx context.Context, member peerconnectivity.Member) (peerconnectivity.Row, error) {
+	if len(member.PeerURLs) == 0 {
+		return peerconnectivity.Row{}, errors.New("member has no peer URL")
+	}
+	peerURL, err := url.Parse(member.PeerURLs[0])
+	if err != nil {
+		return peerconnectivity.Row{}, err
+	}
+	tlsConfig, err := util.CreateTLSConfig(a.isTLSEnabled, peerURL.Hostname(), a.cfg.ClientTLSInfo.TrustedCAFile, nil)
+	if err != nil {
+		return peerconnectivity.Row{}, err
+	}
+	client := &http.Client{
+		Transport: &http.Transport{TLSClientConfig: tlsConfig, Proxy: util.ProxyFunc(a.Config.DisableProxyEnv), DialContext: a.Config.DNS.NewDialer().DialContext},
+		Timeout:   peerConnectivityFetchTimeout,
+	}
+	endpoint := util.ConstructBaseAddress(a.isTLSEnabled(), net.JoinHostPort(peerURL.Hostname(), strconv.Itoa(a.Config.EtcdWrapperPort))) + "/debug/peer-connectivity"
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return peerconnectivity.Row{}, err
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return peerconnectivity.Row{}, err
+	}
+	defer util.CloseResponseBody(response)
+	if response.StatusCode != http.StatusOK {
+		return peerconnectivity.Row{}, fmt.Errorf("etcd-wrapper of member responded with status %s", response.Status)
+	}
+	var row peerconnectivity.Row
+	if err = json.NewDecoder(response.Body).Decode(&row); err != nil {
+		return peerconnectivity.Row{}, fmt.Errorf("failed to decode row of member: %w", err)
+	}
+	return row, nil
+}
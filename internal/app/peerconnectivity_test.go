@@ -0,0 +1,124 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/peerconnectivity"
+	"github.com/gardener/etcd-wrapper/internal/types"
+
+	. "github.com/onsi/gomega"
+	"go.etcd.io/etcd/clientv3"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestPeerConnectivityHandler(t *testing.T) {
+	g := NewWithT(t)
+	etcd := startTestEtcd(t, g)
+	cfg := etcd.Config()
+	cli, err := clientv3.New(clientv3.Config{Endpoints: []string{cfg.ListenClientUrls[0].String()}, DialTimeout: 5 * time.Second})
+	g.Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		_ = cli.Close()
+	}()
+
+	t.Log("should respond with 503 while etcd is not running")
+	app := &Application{ctx: context.Background(), cfg: &cfg, etcdClient: cli, logger: zaptest.NewLogger(t)}
+	response := httptest.NewRecorder()
+	app.peerConnectivityHandler(response, httptest.NewRequest(http.MethodGet, "/debug/peer-connectivity", nil))
+	g.Expect(response.Code).To(Equal(http.StatusServiceUnavailable))
+
+	// a learner which has not been started does not affect the quorum, its peer URL is served by a plain listener
+	peerListener, err := net.Listen("tcp", "127.0.0.1:0")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		_ = peerListener.Close()
+	}()
+	go func() {
+		for {
+			conn, err := peerListener.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.Close()
+		}
+	}()
+	added, err := cli.MemberAddAsLearner(context.Background(), []string{"http://" + peerListener.Addr().String()})
+	g.Expect(err).ToNot(HaveOccurred())
+	learner := strconv.FormatUint(added.Member.ID, 16)
+	// the etcd-wrapper of the learner, which is reached at the host of its peer URL
+	learnerWrapper := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(peerconnectivity.Row{From: learner, Probes: []peerconnectivity.Probe{{Member: cfg.Name, PeerURL: cfg.AdvertisePeerUrls[0].String(), Error: "connection refused"}}})
+	}))
+	defer learnerWrapper.Close()
+	_, port, err := net.SplitHostPort(learnerWrapper.Listener.Addr().String())
+	g.Expect(err).ToNot(HaveOccurred())
+	wrapperPort, err := strconv.Atoi(port)
+	g.Expect(err).ToNot(HaveOccurred())
+	app.Config = types.Config{EtcdWrapperPort: wrapperPort}
+	app.etcd = etcd
+
+	t.Log("should respond with the reachability of the peer URLs of the other members")
+	response = httptest.NewRecorder()
+	app.peerConnectivityHandler(response, httptest.NewRequest(http.MethodGet, "/debug/peer-connectivity", nil))
+	g.Expect(response.Code).To(Equal(http.StatusOK))
+	var row peerconnectivity.Row
+	g.Expect(json.NewDecoder(response.Body).Decode(&row)).To(Succeed())
+	g.Expect(row.From).To(Equal(cfg.Name))
+	g.Expect(row.Probes).To(HaveLen(1))
+	g.Expect(row.Probes[0].Member).To(Equal(learner))
+	g.Expect(row.Probes[0].Reachable).To(BeTrue())
+
+	t.Log("should respond with the connectivity matrix of the cluster")
+	response = httptest.NewRecorder()
+	app.peerConnectivityHandler(response, httptest.NewRequest(http.MethodGet, "/debug/peer-connectivity?scope=cluster", nil))
+	g.Expect(response.Code).To(Equal(http.StatusOK))
+	var matrix peerconnectivity.Matrix
+	g.Expect(json.NewDecoder(response.Body).Decode(&matrix)).To(Succeed())
+	g.Expect(matrix.Rows).To(HaveLen(2))
+	g.Expect(matrix.Asymmetric).To(ConsistOf(peerconnectivity.Link{From: learner, To: cfg.Name}))
+}
+
+func TestReportPeerConnectivity(t *testing.T) {
+	g := NewWithT(t)
+	var logged []zapcore.Level
+	logger := zaptest.NewLogger(t, zaptest.WrapOptions(zap.Hooks(func(entry zapcore.Entry) error {
+		if entry.Level >= zapcore.InfoLevel {
+			logged = append(logged, entry.Level)
+		}
+		return nil
+	})))
+	app := &Application{logger: logger}
+	unreachable := map[string]bool{}
+	row := func(reachable bool) peerconnectivity.Row {
+		return peerconnectivity.Row{Probes: []peerconnectivity.Probe{{Member: "etcd-main-1", PeerURL: "https://etcd-main-1:2380", Reachable: reachable}}}
+	}
+	table := []struct {
+		description    string
+		reachable      bool
+		expectedLogged []zapcore.Level
+	}{
+		{"should not log a reachable peer URL", true, nil},
+		{"should warn once a peer URL has become unreachable", false, []zapcore.Level{zapcore.WarnLevel}},
+		{"should not repeat the warning", false, nil},
+		{"should log once the peer URL is reachable again", true, []zapcore.Level{zapcore.InfoLevel}},
+	}
+	for _, entry := range table {
+		t.Log(entry.description)
+		logged = nil
+		app.reportPeerConnectivity(row(entry.reachable), unreachable)
+		g.Expect(logged).To(Equal(entry.expectedLogged))
+	}
+}
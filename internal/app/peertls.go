@@ -0,0 +1,35 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"net/url"
+
+	"go.etcd.io/etcd/embed"
+	"go.uber.org/zap"
+)
+
+// applyPeerTLSServerName configures the TLS server name expected from the certificates of peers if one has been
+// configured via etcd-wrapper flags. This is required if peers are dialed through addresses, e.g. service VIPs, which
+// are not among the SANs of their certificates.
+func (a *Application) applyPeerTLSServerName(cfg *embed.Config) {
+	if a.Config.PeerTLSServerName == "" {
+		return
+	}
+	if cfg.PeerTLSInfo.ServerName != "" && cfg.PeerTLSInfo.ServerName != a.Config.PeerTLSServerName {
+		a.logger.Warn("Overriding peer TLS server name of the etcd configuration",
+			zap.String("configured", cfg.PeerTLSInfo.ServerName),
+			zap.String("serverName", a.Config.PeerTLSServerName))
+	}
+	cfg.PeerTLSInfo.ServerName = a.Config.PeerTLSServerName
+}
+
+// peerServerName returns the TLS server name expected from the certificate of the peer dialed via the peer URL u.
+func peerServerName(cfg *embed.Config, u *url.URL) string {
+	if cfg.PeerTLSInfo.ServerName != "" {
+		return cfg.PeerTLSInfo.ServerName
+	}
+	return u.Hostname()
+}
@@ -0,0 +1,43 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/gardener/etcd-wrapper/internal/types"
+
+	. "github.com/onsi/gomega"
+	"go.etcd.io/etcd/embed"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestPeerServerName(t *testing.T) {
+	peerURL, _ := url.Parse("https://10.0.0.10:2380")
+	table := []struct {
+		description        string
+		etcdServerName     string
+		wrapperServerName  string
+		expectedServerName string
+	}{
+		{"should use host of peer URL when no server name is configured", "", "", "10.0.0.10"},
+		{"should use server name of the etcd configuration", "etcd-main-peer", "", "etcd-main-peer"},
+		{"should prefer server name configured via etcd-wrapper", "etcd-main-peer", "etcd-main-peer.shoot.svc", "etcd-main-peer.shoot.svc"},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		cfg := embed.NewConfig()
+		cfg.PeerTLSInfo.ServerName = entry.etcdServerName
+		app := &Application{
+			Config: types.Config{PeerTLSServerName: entry.wrapperServerName},
+			logger: zaptest.NewLogger(t),
+		}
+		app.applyPeerTLSServerName(cfg)
+		g.Expect(peerServerName(cfg, peerURL)).To(Equal(entry.expectedServerName))
+	}
+}
@@ -0,0 +1,121 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/audit"
+	"github.com/gardener/etcd-wrapper/internal/maintenance"
+	"github.com/gardener/etcd-wrapper/internal/metrics"
+
+	"go.etcd.io/etcd/etcdserver/api/v3rpc/rpctypes"
+	"go.uber.org/zap"
+)
+
+const (
+	// maintenanceTriggerRestore indicates in the maintenance history that an operation has been triggered by a
+	// restoration of the data directory.
+	maintenanceTriggerRestore = "restore"
+	// postRestoreDefragmentationTimeout is the time after which the defragmentation after a restoration is abandoned.
+	postRestoreDefragmentationTimeout = 30 * time.Minute
+)
+
+// startPostRestoreMaintenance marks the post-restore maintenance as pending if it is enabled and the data directory has
+// been restored, so that readiness can be withheld from the first start of etcd on.
+func (a *Application) startPostRestoreMaintenance() {
+	a.postRestoreMaintenancePending.Store(a.Config.PostRestoreMaintenance.Enabled && a.etcdInitializer.RestoreInfo() != nil)
+}
+
+// postRestoreMaintenanceDeferringReadiness returns whether readiness is withheld till the pending post-restore
+// maintenance has finished.
+func (a *Application) postRestoreMaintenanceDeferringReadiness() bool {
+	return a.Config.PostRestoreMaintenance.DeferReadiness && a.postRestoreMaintenancePending.Load()
+}
+
+// runPostRestoreMaintenance compacts the history up to the current revision and defragments the backend of the local
+// member once etcd is ready after a restoration. Failures are logged, and the maintenance is no longer pending
+// afterwards either way, so that readiness is not withheld forever.
+func (a *Application) runPostRestoreMaintenance() {
+	defer a.postRestoreMaintenancePending.Store(false)
+	a.logger.Info("running post-restore maintenance")
+	start := time.Now()
+	if err := a.compactAfterRestore(); err != nil {
+		a.logger.Error("failed to compact etcd history after restoration", zap.Error(err))
+	}
+	if err := a.defragmentAfterRestore(); err != nil {
+		a.logger.Error("failed to defragment etcd backend after restoration", zap.Error(err))
+		return
+	}
+	a.logger.Info("finished post-restore maintenance", zap.Duration("duration", time.Since(start)))
+}
+
+// compactAfterRestore compacts the etcd history up to the current revision of the local member.
+func (a *Application) compactAfterRestore() error {
+	ctx, cancelFunc := context.WithTimeout(a.ctx, etcdGetTimeout)
+	defer cancelFunc()
+	status, err := a.etcdClient.Status(ctx, a.etcdClient.Endpoints()[0])
+	if err != nil {
+		return fmt.Errorf("failed to get etcd status: %w", err)
+	}
+	revision := status.Header.Revision
+	record := maintenance.Record{
+		Operation:         maintenance.OperationCompaction,
+		Trigger:           maintenanceTriggerRestore,
+		Target:            strconv.FormatInt(revision, 10),
+		StartedAt:         time.Now(),
+		DBSizeBefore:      status.DbSize,
+		DBSizeInUseBefore: status.DbSizeInUse,
+	}
+	err = audit.Record(a.auditLogger, audit.OperationCompact, strconv.FormatInt(revision, 10), func() error {
+		_, err := a.etcdClient.Compact(ctx, revision)
+		return err
+	})
+	if errors.Is(err, rpctypes.ErrCompacted) {
+		return nil
+	}
+	a.recordMaintenance(record, err)
+	if err != nil {
+		return err
+	}
+	metrics.ProactiveCompactionsTotal.WithLabelValues(maintenanceTriggerRestore).Inc()
+	a.logger.Info("compacted etcd history after restoration", zap.Int64("revision", revision))
+	return nil
+}
+
+// defragmentAfterRestore defragments the backend of the local member.
+func (a *Application) defragmentAfterRestore() error {
+	endpoint := a.etcdClient.Endpoints()[0]
+	ctx, cancelFunc := context.WithTimeout(a.ctx, etcdGetTimeout)
+	defer cancelFunc()
+	status, err := a.etcdClient.Status(ctx, endpoint)
+	if err != nil {
+		return fmt.Errorf("failed to get etcd status: %w", err)
+	}
+	record := maintenance.Record{
+		Operation:         maintenance.OperationDefragmentation,
+		Trigger:           maintenanceTriggerRestore,
+		Target:            a.cfg.Name,
+		StartedAt:         time.Now(),
+		DBSizeBefore:      status.DbSize,
+		DBSizeInUseBefore: status.DbSizeInUse,
+	}
+	defragCtx, defragCancelFunc := context.WithTimeout(a.ctx, postRestoreDefragmentationTimeout)
+	defer defragCancelFunc()
+	err = audit.Record(a.auditLogger, audit.OperationDefragment, a.cfg.Name, func() error {
+		_, err := a.etcdClient.Defragment(defragCtx, endpoint)
+		return err
+	})
+	a.recordMaintenance(record, err)
+	if err != nil {
+		return err
+	}
+	a.logger.Info("defragmented etcd backend after restoration", zap.Int64("dbSizeBefore", status.DbSize))
+	return nil
+}
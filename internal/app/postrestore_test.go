@@ -0,0 +1,103 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/audit"
+	"github.com/gardener/etcd-wrapper/internal/bootstrap"
+	"github.com/gardener/etcd-wrapper/internal/maintenance"
+	"github.com/gardener/etcd-wrapper/internal/types"
+
+	. "github.com/onsi/gomega"
+	"go.etcd.io/etcd/clientv3"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestStartPostRestoreMaintenance(t *testing.T) {
+	restoreInfo := &bootstrap.RestoreInfo{RestoredAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Revision: 42}
+	table := []struct {
+		description     string
+		config          types.PostRestoreMaintenanceConfig
+		restoreInfo     *bootstrap.RestoreInfo
+		expectedPending bool
+		expectedDefer   bool
+	}{
+		{"should not be pending if disabled", types.PostRestoreMaintenanceConfig{}, restoreInfo, false, false},
+		{"should not be pending without restoration", types.PostRestoreMaintenanceConfig{Enabled: true, DeferReadiness: true}, nil, false, false},
+		{"should be pending after a restoration", types.PostRestoreMaintenanceConfig{Enabled: true}, restoreInfo, true, false},
+		{"should defer readiness if configured", types.PostRestoreMaintenanceConfig{Enabled: true, DeferReadiness: true}, restoreInfo, true, true},
+	}
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		app := &Application{
+			Config:          types.Config{PostRestoreMaintenance: entry.config},
+			etcdInitializer: &fakeEtcdInitializer{restoreInfo: entry.restoreInfo},
+		}
+		app.startPostRestoreMaintenance()
+		g.Expect(app.postRestoreMaintenancePending.Load()).To(Equal(entry.expectedPending))
+		g.Expect(app.postRestoreMaintenanceDeferringReadiness()).To(Equal(entry.expectedDefer))
+	}
+}
+
+func TestRunPostRestoreMaintenance(t *testing.T) {
+	g := NewWithT(t)
+	etcd := startTestEtcd(t, g)
+	cfg := etcd.Config()
+	cli, err := clientv3.New(clientv3.Config{Endpoints: []string{cfg.ListenClientUrls[0].String()}, DialTimeout: 5 * time.Second})
+	g.Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		_ = cli.Close()
+	}()
+	for range 10 {
+		_, err = cli.Put(context.Background(), "/registry/pods/default/nginx", "spec")
+		g.Expect(err).ToNot(HaveOccurred())
+	}
+	history, err := maintenance.LoadHistory("", types.DefaultMaintenanceHistorySize)
+	g.Expect(err).ToNot(HaveOccurred())
+	app := &Application{
+		Config:             types.Config{PostRestoreMaintenance: types.PostRestoreMaintenanceConfig{Enabled: true, DeferReadiness: true}},
+		ctx:                context.Background(),
+		cfg:                &cfg,
+		etcd:               etcd,
+		etcdClient:         cli,
+		auditLogger:        audit.NewNoopLogger(),
+		maintenanceHistory: history,
+		logger:             zaptest.NewLogger(t),
+	}
+	app.etcdReady.Store(true)
+	app.postRestoreMaintenancePending.Store(true)
+
+	t.Log("should withhold readiness while the maintenance is pending")
+	response := httptest.NewRecorder()
+	app.readinessHandler(response, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	g.Expect(response.Code).To(Equal(http.StatusServiceUnavailable))
+	g.Expect(response.Body.String()).To(Equal("post-restore maintenance in progress"))
+
+	t.Log("should compact the history and defragment the backend")
+	app.runPostRestoreMaintenance()
+	g.Expect(app.postRestoreMaintenancePending.Load()).To(BeFalse())
+	records := history.Records()
+	g.Expect(records).To(HaveLen(2))
+	g.Expect(records[0].Operation).To(Equal(maintenance.OperationCompaction))
+	g.Expect(records[0].Trigger).To(Equal(maintenanceTriggerRestore))
+	g.Expect(records[0].Error).To(BeEmpty())
+	g.Expect(records[1].Operation).To(Equal(maintenance.OperationDefragmentation))
+	g.Expect(records[1].Trigger).To(Equal(maintenanceTriggerRestore))
+	g.Expect(records[1].Error).To(BeEmpty())
+	_, err = cli.Get(context.Background(), "/registry/pods/default/nginx", clientv3.WithRev(2))
+	g.Expect(err).To(HaveOccurred())
+
+	t.Log("should report readiness once the maintenance has finished")
+	response = httptest.NewRecorder()
+	app.readinessHandler(response, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	g.Expect(response.Code).To(Equal(http.StatusOK))
+}
@@ -0,0 +1,97 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/metrics"
+
+	"go.etcd.io/etcd/clientv3"
+	"go.uber.org/zap"
+)
+
+// prefixUsagePageSize is the number of keys read per range request when measuring the size of a key prefix.
+const prefixUsagePageSize = 500
+
+// prefixUsage is the usage of a key prefix.
+type prefixUsage struct {
+	// keys is the number of keys with the prefix.
+	keys int64
+	// sizeBytes is the total size of the keys with the prefix and their values. It is only measured if requested.
+	sizeBytes int64
+}
+
+// watchPrefixUsage periodically samples the number and, if enabled, the size of the keys per configured key prefix
+// and exports them as metrics. Reads are serializable, i.e. every member samples its local data without involving
+// the leader. It stops when the application context is cancelled.
+func (a *Application) watchPrefixUsage() {
+	if len(a.Config.PrefixUsage.Prefixes) == 0 {
+		return
+	}
+	ticker := time.NewTicker(a.Config.PrefixUsage.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-ticker.C:
+			a.samplePrefixUsage()
+		}
+	}
+}
+
+// samplePrefixUsage samples the usage of all configured key prefixes, skipping prefixes whose usage cannot be determined.
+func (a *Application) samplePrefixUsage() {
+	if !a.etcdRunning() {
+		return
+	}
+	for _, prefix := range a.Config.PrefixUsage.Prefixes {
+		usage, err := measurePrefixUsage(a.ctx, a.etcdClient.KV, prefix, a.Config.PrefixUsage.MeasureSize)
+		if err != nil {
+			a.logger.Error("failed to sample usage of key prefix", zap.String("prefix", prefix), zap.Error(err))
+			continue
+		}
+		metrics.PrefixKeys.WithLabelValues(prefix).Set(float64(usage.keys))
+		if a.Config.PrefixUsage.MeasureSize {
+			metrics.PrefixSizeBytes.WithLabelValues(prefix).Set(float64(usage.sizeBytes))
+		}
+	}
+}
+
+// measurePrefixUsage counts the keys with the given prefix using a count-only range request. If measureSize is true,
+// the keys and their values are additionally read page by page at the revision of the first page to sum up their sizes.
+func measurePrefixUsage(ctx context.Context, kv clientv3.KV, prefix string, measureSize bool) (prefixUsage, error) {
+	countCtx, cancelFunc := context.WithTimeout(ctx, etcdGetTimeout)
+	defer cancelFunc()
+	response, err := kv.Get(countCtx, prefix, clientv3.WithPrefix(), clientv3.WithCountOnly(), clientv3.WithSerializable())
+	if err != nil {
+		return prefixUsage{}, err
+	}
+	usage := prefixUsage{keys: response.Count}
+	if !measureSize || usage.keys == 0 {
+		return usage, nil
+	}
+
+	key, end, revision := prefix, clientv3.GetPrefixRangeEnd(prefix), response.Header.Revision
+	for {
+		pageCtx, cancelFunc := context.WithTimeout(ctx, etcdGetTimeout)
+		page, err := kv.Get(pageCtx, key, clientv3.WithRange(end), clientv3.WithRev(revision), clientv3.WithLimit(prefixUsagePageSize), clientv3.WithSerializable())
+		cancelFunc()
+		if err != nil {
+			return prefixUsage{}, err
+		}
+		for _, kv := range page.Kvs {
+			usage.sizeBytes += int64(len(kv.Key) + len(kv.Value))
+		}
+		if !page.More || len(page.Kvs) == 0 {
+			return usage, nil
+		}
+		// continue right after the last key read
+		key = string(page.Kvs[len(page.Kvs)-1].Key) + "\x00"
+	}
+}
@@ -0,0 +1,95 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"go.etcd.io/etcd/embed"
+	"go.etcd.io/etcd/etcdserver/api/v3client"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestMeasurePrefixUsage(t *testing.T) {
+	g := NewWithT(t)
+	etcd := startTestEtcd(t, g)
+	cli := v3client.New(etcd.Server)
+	defer func() {
+		_ = cli.Close()
+	}()
+	ctx := context.Background()
+
+	// more keys than fit into a single page to measure the size across pages
+	for i := range prefixUsagePageSize + 10 {
+		_, err := cli.Put(ctx, fmt.Sprintf("/tenant-a/%04d", i), "value")
+		g.Expect(err).ToNot(HaveOccurred())
+	}
+	_, err := cli.Put(ctx, "/tenant-b/key", strings.Repeat("x", 100))
+	g.Expect(err).ToNot(HaveOccurred())
+	// deleted keys are not counted
+	_, err = cli.Put(ctx, "/tenant-b/deleted", "value")
+	g.Expect(err).ToNot(HaveOccurred())
+	_, err = cli.Delete(ctx, "/tenant-b/deleted")
+	g.Expect(err).ToNot(HaveOccurred())
+
+	table := []struct {
+		description   string
+		prefix        string
+		measureSize   bool
+		expectedUsage prefixUsage
+	}{
+		{"should count keys without measuring their size", "/tenant-a/", false, prefixUsage{keys: prefixUsagePageSize + 10}},
+		{"should measure the size of keys and values across pages", "/tenant-a/", true, prefixUsage{keys: prefixUsagePageSize + 10, sizeBytes: (prefixUsagePageSize + 10) * int64(len("/tenant-a/0000")+len("value"))}},
+		{"should measure the size of a single key", "/tenant-b/", true, prefixUsage{keys: 1, sizeBytes: int64(len("/tenant-b/key") + 100)}},
+		{"should report no usage for a prefix without keys", "/tenant-c/", true, prefixUsage{}},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		usage, err := measurePrefixUsage(ctx, cli.KV, entry.prefix, entry.measureSize)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(usage).To(Equal(entry.expectedUsage))
+	}
+}
+
+func startTestEtcd(t *testing.T, g *WithT, modifiers ...func(cfg *embed.Config)) *embed.Etcd {
+	cfg := embed.NewConfig()
+	cfg.Dir = t.TempDir()
+	cfg.Logger = "zap"
+	cfg.LogOutputs = []string{"/dev/null"}
+	clientURL := url.URL{Scheme: "http", Host: freeLocalAddress(g)}
+	peerURL := url.URL{Scheme: "http", Host: freeLocalAddress(g)}
+	cfg.ListenClientUrls, cfg.AdvertiseClientUrls = []url.URL{clientURL}, []url.URL{clientURL}
+	cfg.ListenPeerUrls, cfg.AdvertisePeerUrls = []url.URL{peerURL}, []url.URL{peerURL}
+	cfg.InitialCluster = cfg.InitialClusterFromName(cfg.Name)
+	for _, modify := range modifiers {
+		modify(cfg)
+	}
+	etcd, err := embed.StartEtcd(cfg)
+	g.Expect(err).ToNot(HaveOccurred())
+	t.Cleanup(etcd.Close)
+	select {
+	case <-etcd.Server.ReadyNotify():
+	case <-time.After(time.Minute):
+		t.Fatal("timed out waiting for etcd to be ready")
+	}
+	return etcd
+}
+
+func freeLocalAddress(g *WithT) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		g.Expect(listener.Close()).To(Succeed())
+	}()
+	return listener.Addr().String()
+}
@@ -0,0 +1,214 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/metrics"
+	"github.com/gardener/etcd-wrapper/internal/types"
+
+	"go.etcd.io/etcd/embed"
+	"go.etcd.io/etcd/pkg/fileutil"
+	"go.etcd.io/etcd/wal"
+	"go.uber.org/zap"
+)
+
+const (
+	// volumeData is the name of the volume holding the data directory of etcd.
+	volumeData = "data"
+	// volumeWAL is the name of the volume holding the WAL directory of etcd.
+	volumeWAL = "wal"
+	// preflightProbeBytes is the size of a single write synced to probe the fsync latency of a volume, which is in the
+	// order of the size of a typical WAL entry batch.
+	preflightProbeBytes = 4096
+)
+
+// applyWALDir sets the WAL directory of etcd to the one configured for etcd-wrapper, if any.
+func (a *Application) applyWALDir(cfg *embed.Config) {
+	if a.Config.WALDir == "" {
+		return
+	}
+	if cfg.WalDir != "" && cfg.WalDir != a.Config.WALDir {
+		a.logger.Info("overriding WAL directory of etcd configuration", zap.String("configured", cfg.WalDir), zap.String("walDir", a.Config.WALDir))
+	}
+	cfg.WalDir = a.Config.WALDir
+}
+
+// prepareVolumes checks the data volume and, if a separate WAL directory is configured, the WAL volume independently of
+// each other, and then moves a WAL found in the data directory into the WAL directory.
+func (a *Application) prepareVolumes() error {
+	if !a.Config.Preflight.Disabled {
+		if err := a.checkVolume(volumeData, a.cfg.Dir); err != nil {
+			return fmt.Errorf("preflight check of %s volume failed: %w", volumeData, err)
+		}
+		if a.cfg.WalDir != "" {
+			if err := a.checkVolume(volumeWAL, a.cfg.WalDir); err != nil {
+				return fmt.Errorf("preflight check of %s volume failed: %w", volumeWAL, err)
+			}
+		}
+	}
+	return a.migrateWAL()
+}
+
+// checkVolume verifies that dir exists or can be created, that it is writable and that writes to it are synced within
+// the configured fsync latency threshold.
+func (a *Application) checkVolume(volume, dir string) error {
+	info, err := os.Stat(dir)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		if err = os.MkdirAll(dir, 0700); err != nil {
+			return fmt.Errorf("%s does not exist and cannot be created: %w", dir, err)
+		}
+	case err != nil:
+		return err
+	case !info.IsDir():
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+	probes := a.Config.Preflight.FsyncProbes
+	if probes == 0 {
+		probes = types.DefaultPreflightFsyncProbes
+	}
+	latency, err := probeFsyncLatency(dir, probes)
+	if err != nil {
+		return err
+	}
+	metrics.PreflightFsyncLatencySeconds.WithLabelValues(volume).Set(latency.Seconds())
+	threshold := a.Config.Preflight.FsyncLatencyThreshold
+	if threshold > 0 && latency > threshold {
+		return fmt.Errorf("fsync latency %s of %s exceeds the threshold of %s", latency, dir, threshold)
+	}
+	a.logger.Info("preflight check of volume succeeded", zap.String("volume", volume), zap.String("dir", dir), zap.Duration("fsyncLatency", latency))
+	return nil
+}
+
+// probeFsyncLatency writes to a temporary file in dir and syncs every write, and returns the highest latency of the
+// syncs. An error is returned if dir is not writable.
+func probeFsyncLatency(dir string, probes int) (time.Duration, error) {
+	f, err := os.CreateTemp(dir, ".preflight-*")
+	if err != nil {
+		return 0, fmt.Errorf("%s is not writable: %w", dir, err)
+	}
+	defer func() {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+	}()
+	data := make([]byte, preflightProbeBytes)
+	var highest time.Duration
+	for range probes {
+		latency, err := syncedWriteLatency(f, data)
+		if err != nil {
+			return 0, fmt.Errorf("%s is not writable: %w", dir, err)
+		}
+		highest = max(highest, latency)
+	}
+	return highest, nil
+}
+
+// syncedWriteLatency writes data to the beginning of f, syncs it and returns the time it took.
+func syncedWriteLatency(f *os.File, data []byte) (time.Duration, error) {
+	start := time.Now()
+	if _, err := f.WriteAt(data, 0); err != nil {
+		return 0, err
+	}
+	if err := fileutil.Fdatasync(f); err != nil {
+		return 0, fmt.Errorf("failed to sync write: %w", err)
+	}
+	return time.Since(start), nil
+}
+
+// migrateWAL moves the WAL from its default location in the data directory into the configured WAL directory. A WAL is
+// found there when a separate WAL directory has been configured for an existing member, and after backup-restore has
+// restored the data directory, since backup-restore writes the WAL of the restored member into the data directory. The
+// WAL in the data directory always belongs to the DB in the data directory, so it replaces a WAL in the WAL directory.
+func (a *Application) migrateWAL() error {
+	if a.cfg.WalDir == "" {
+		return nil
+	}
+	defaultWALDir := filepath.Join(a.cfg.Dir, "member", "wal")
+	if filepath.Clean(a.cfg.WalDir) == defaultWALDir || !wal.Exist(defaultWALDir) {
+		return nil
+	}
+	if err := os.MkdirAll(a.cfg.WalDir, 0700); err != nil {
+		return fmt.Errorf("failed to create WAL directory: %w", err)
+	}
+	if wal.Exist(a.cfg.WalDir) {
+		a.logger.Warn("replacing WAL in WAL directory with the WAL found in the data directory", zap.String("walDir", a.cfg.WalDir), zap.String("dataDirWAL", defaultWALDir))
+	}
+	// the WAL directory may be the mount point of a volume, hence its entries are replaced instead of the directory.
+	entries, err := os.ReadDir(a.cfg.WalDir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err = os.RemoveAll(filepath.Join(a.cfg.WalDir, entry.Name())); err != nil {
+			return fmt.Errorf("failed to clear WAL directory: %w", err)
+		}
+	}
+	// the WAL directory usually is on another volume, hence the files are copied instead of renamed. The WAL in the
+	// data directory is only removed once it has been copied completely, so that an interrupted migration is repeated.
+	entries, err = os.ReadDir(defaultWALDir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		if err = copyFileSynced(filepath.Join(defaultWALDir, entry.Name()), filepath.Join(a.cfg.WalDir, entry.Name())); err != nil {
+			return fmt.Errorf("failed to copy WAL into WAL directory: %w", err)
+		}
+	}
+	if err = syncDir(a.cfg.WalDir); err != nil {
+		return fmt.Errorf("failed to sync WAL directory: %w", err)
+	}
+	if err = os.RemoveAll(defaultWALDir); err != nil {
+		return fmt.Errorf("failed to remove WAL from data directory: %w", err)
+	}
+	a.logger.Info("moved WAL from data directory into WAL directory", zap.String("from", defaultWALDir), zap.String("to", a.cfg.WalDir), zap.Int("files", len(entries)))
+	return nil
+}
+
+// copyFileSynced copies the file at src to dst and syncs dst.
+func copyFileSynced(src, dst string) error {
+	in, err := os.Open(src) // #nosec G304 -- src is a file of the WAL of etcd.
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = in.Close()
+	}()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600) // #nosec G304 -- dst is in the WAL directory of etcd.
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	if err = fileutil.Fsync(out); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// syncDir syncs the directory dir, which persists the creation and removal of its entries.
+func syncDir(dir string) error {
+	d, err := os.Open(dir) // #nosec G304 -- dir is the WAL directory of etcd.
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = d.Close()
+	}()
+	return fileutil.Fsync(d)
+}
@@ -0,0 +1,108 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/types"
+
+	. "github.com/onsi/gomega"
+	"go.etcd.io/etcd/embed"
+	"go.etcd.io/etcd/wal"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestCheckVolume(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	NewWithT(t).Expect(os.WriteFile(file, nil, 0600)).To(Succeed())
+
+	table := []struct {
+		description   string
+		dir           string
+		preflight     types.PreflightConfig
+		expectedError bool
+	}{
+		{"should succeed for a writable directory", dir, types.PreflightConfig{}, false},
+		{"should create a missing directory", filepath.Join(dir, "missing", "wal"), types.PreflightConfig{}, false},
+		{"should fail if the path is not a directory", file, types.PreflightConfig{}, true},
+		{"should fail if the directory cannot be created", filepath.Join(file, "wal"), types.PreflightConfig{}, true},
+		{"should fail if the fsync latency exceeds the threshold", dir, types.PreflightConfig{FsyncProbes: 1, FsyncLatencyThreshold: time.Nanosecond}, true},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		app := &Application{Config: types.Config{Preflight: entry.preflight}, logger: zaptest.NewLogger(t)}
+		err := app.checkVolume(volumeData, entry.dir)
+		g.Expect(err != nil).To(Equal(entry.expectedError))
+		if !entry.expectedError {
+			g.Expect(entry.dir).To(BeADirectory())
+			probeFiles, err := filepath.Glob(filepath.Join(entry.dir, ".preflight-*"))
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(probeFiles).To(BeEmpty())
+		}
+	}
+}
+
+func TestApplyWALDir(t *testing.T) {
+	g := NewWithT(t)
+	app := &Application{logger: zaptest.NewLogger(t)}
+	cfg := &embed.Config{WalDir: "/var/etcd/wal"}
+
+	t.Log("should keep the WAL directory of the etcd configuration if none is configured")
+	app.applyWALDir(cfg)
+	g.Expect(cfg.WalDir).To(Equal("/var/etcd/wal"))
+
+	t.Log("should override the WAL directory of the etcd configuration")
+	app.Config.WALDir = "/var/etcd/fast-wal"
+	app.applyWALDir(cfg)
+	g.Expect(cfg.WalDir).To(Equal("/var/etcd/fast-wal"))
+}
+
+func TestMigrateWAL(t *testing.T) {
+	const walFile = "0000000000000000-0000000000000000.wal"
+	table := []struct {
+		description     string
+		dataDirWAL      bool
+		walDirWAL       bool
+		expectedContent string
+	}{
+		{"should keep the WAL in the WAL directory if the data directory holds none", false, true, "wal-dir"},
+		{"should move the WAL from the data directory into the WAL directory", true, false, "data-dir"},
+		{"should replace the WAL in the WAL directory with the WAL of the data directory", true, true, "data-dir"},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		dataDir := filepath.Join(t.TempDir(), "data")
+		walDir := filepath.Join(t.TempDir(), "wal")
+		dataDirWALDir := filepath.Join(dataDir, "member", "wal")
+		if entry.dataDirWAL {
+			g.Expect(os.MkdirAll(dataDirWALDir, 0700)).To(Succeed())
+			g.Expect(os.WriteFile(filepath.Join(dataDirWALDir, walFile), []byte("data-dir"), 0600)).To(Succeed())
+		}
+		if entry.walDirWAL {
+			g.Expect(os.MkdirAll(walDir, 0700)).To(Succeed())
+			g.Expect(os.WriteFile(filepath.Join(walDir, walFile), []byte("wal-dir"), 0600)).To(Succeed())
+			g.Expect(os.WriteFile(filepath.Join(walDir, "0000000000000001-0000000000000010.wal"), []byte("stale"), 0600)).To(Succeed())
+		}
+		app := &Application{cfg: &embed.Config{Dir: dataDir, WalDir: walDir}, logger: zaptest.NewLogger(t)}
+
+		g.Expect(app.migrateWAL()).To(Succeed())
+		g.Expect(wal.Exist(dataDirWALDir)).To(BeFalse())
+		content, err := os.ReadFile(filepath.Join(walDir, walFile))
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(string(content)).To(Equal(entry.expectedContent))
+		if entry.dataDirWAL {
+			g.Expect(filepath.Join(walDir, "0000000000000001-0000000000000010.wal")).ToNot(BeAnExistingFile())
+		}
+	}
+}
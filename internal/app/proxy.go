@@ -0,0 +1,25 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"github.com/gardener/etcd-wrapper/internal/util"
+
+	"go.uber.org/zap"
+)
+
+// logProxyEnv logs the proxy environment variables inherited by etcd-wrapper, which otherwise silently apply to its
+// outbound connections, e.g. to backup-restore within the same pod.
+func logProxyEnv(proxyEnvDisabled bool, logger *zap.Logger) {
+	proxyEnv := util.ProxyEnv()
+	switch {
+	case len(proxyEnv) == 0:
+		return
+	case proxyEnvDisabled:
+		logger.Info("Ignoring proxy environment variables for outbound connections", zap.Any("proxyEnv", proxyEnv))
+	default:
+		logger.Warn("Using proxy environment variables for outbound connections to backup-restore, peers and etcd, use --disable-proxy-env to ignore them", zap.Any("proxyEnv", proxyEnv))
+	}
+}
@@ -0,0 +1,159 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/brclient"
+	"github.com/gardener/etcd-wrapper/internal/metrics"
+
+	"go.etcd.io/etcd/etcdserver"
+	"go.etcd.io/etcd/etcdserver/etcdserverpb"
+	"go.uber.org/zap"
+)
+
+// quotaAdvisoryReportTimeout is the time to wait for backup-restore to accept a quota advisory.
+const quotaAdvisoryReportTimeout = 10 * time.Second
+
+// runningQuotaBackendBytes returns the backend quota of the running etcd, which is zero if the backend quota is
+// disabled. It returns false if etcd is not running. The backend quota only changes while etcd is stopped.
+func (a *Application) runningQuotaBackendBytes() (int64, bool) {
+	a.etcdMu.RLock()
+	defer a.etcdMu.RUnlock()
+	if a.etcd == nil && a.etcdProcess == nil {
+		return 0, false
+	}
+	switch quota := a.cfg.QuotaBackendBytes; {
+	case quota < 0:
+		return 0, true
+	case quota == 0:
+		return etcdserver.DefaultQuotaBytes, true
+	default:
+		return quota, true
+	}
+}
+
+// observeQuotaAdvisory observes the DB size of etcd relative to its backend quota and derives whether writes are at
+// risk of being rejected, which is the case if etcd has raised the NOSPACE alarm, if the DB size has reached the risk
+// threshold of the backend quota, or if it is projected to reach the backend quota within the horizon of the DB size
+// trend.
+func (a *Application) observeQuotaAdvisory(ctx context.Context) (brclient.QuotaAdvisory, error) {
+	quota, running := a.runningQuotaBackendBytes()
+	if !running {
+		return brclient.QuotaAdvisory{}, errEtcdNotRunning
+	}
+	status, err := a.etcdClient.Status(ctx, a.etcdClient.Endpoints()[0])
+	if err != nil {
+		return brclient.QuotaAdvisory{}, fmt.Errorf("failed to get etcd status: %w", err)
+	}
+	alarms, err := a.etcdClient.AlarmList(ctx)
+	if err != nil {
+		return brclient.QuotaAdvisory{}, fmt.Errorf("failed to list etcd alarms: %w", err)
+	}
+	advisory := brclient.QuotaAdvisory{
+		DBSizeBytes:       status.DbSize,
+		DBSizeInUseBytes:  status.DbSizeInUse,
+		QuotaBackendBytes: quota,
+		ObservedAt:        time.Now(),
+	}
+	for _, alarm := range alarms.Alarms {
+		if alarm.Alarm == etcdserverpb.AlarmType_NOSPACE {
+			advisory.NoSpaceAlarm = true
+			advisory.Reasons = append(advisory.Reasons, "etcd has raised the NOSPACE alarm and rejects writes")
+			break
+		}
+	}
+	if quota > 0 {
+		advisory.QuotaUsedRatio = float64(status.DbSize) / float64(quota)
+		if threshold := a.Config.QuotaAdvisory.GetRiskThreshold(); advisory.QuotaUsedRatio >= threshold {
+			advisory.Reasons = append(advisory.Reasons, fmt.Sprintf("DB size of %d bytes has reached %.0f%% of the backend quota of %d bytes, the risk threshold is %.0f%%",
+				status.DbSize, advisory.QuotaUsedRatio*100, quota, threshold*100))
+		}
+		if a.quotaExhaustionPredicted.Load() {
+			advisory.Reasons = append(advisory.Reasons, fmt.Sprintf("DB size is projected to reach the backend quota within %s", a.Config.DBSizeTrend.Horizon))
+		}
+	}
+	advisory.WritesAtRisk = len(advisory.Reasons) > 0
+	if advisory.WritesAtRisk {
+		metrics.QuotaWritesAtRisk.Set(1)
+	} else {
+		metrics.QuotaWritesAtRisk.Set(0)
+	}
+	return advisory, nil
+}
+
+// quotaAdvisoryHandler responds with the DB size of etcd relative to its backend quota and whether writes are at risk
+// of being rejected. It responds with 503 if the advisory cannot be observed, e.g. since etcd is not running.
+func (a *Application) quotaAdvisoryHandler(w http.ResponseWriter, _ *http.Request) {
+	ctx, cancelFunc := context.WithTimeout(a.ctx, etcdGetTimeout)
+	defer cancelFunc()
+	advisory, err := a.observeQuotaAdvisory(ctx)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(advisory); err != nil {
+		a.logger.Error("failed to write quota advisory response", zap.Error(err))
+	}
+}
+
+// watchQuotaAdvisory periodically reports the quota advisory to backup-restore, so that alerts driven by backup-restore
+// can fire before etcd raises the NOSPACE alarm. Reporting stops if backup-restore does not support quota advisories
+// or when the application context is cancelled.
+func (a *Application) watchQuotaAdvisory() {
+	if a.Config.QuotaAdvisory.ReportInterval <= 0 {
+		return
+	}
+	reporter, ok := a.brClient.(brclient.QuotaAdvisoryReporter)
+	if !ok {
+		a.logger.Info("backup-restore client does not support quota advisories, not reporting them")
+		return
+	}
+	ticker := time.NewTicker(a.Config.QuotaAdvisory.ReportInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-ticker.C:
+			if err := a.reportQuotaAdvisory(reporter); errors.Is(err, brclient.ErrNotSupported) {
+				a.logger.Info("backup-restore does not support quota advisories, not reporting them anymore")
+				return
+			}
+		}
+	}
+}
+
+// reportQuotaAdvisory observes the quota advisory and reports it to backup-restore. Only an error returned by
+// backup-restore is returned, all other errors are logged.
+func (a *Application) reportQuotaAdvisory(reporter brclient.QuotaAdvisoryReporter) error {
+	if !a.etcdRunning() {
+		return nil
+	}
+	ctx, cancelFunc := context.WithTimeout(a.ctx, etcdGetTimeout)
+	advisory, err := a.observeQuotaAdvisory(ctx)
+	cancelFunc()
+	if err != nil {
+		a.logger.Error("failed to observe quota advisory", zap.Error(err))
+		return nil
+	}
+	ctx, cancelFunc = context.WithTimeout(a.ctx, quotaAdvisoryReportTimeout)
+	defer cancelFunc()
+	if err = reporter.ReportQuotaAdvisory(ctx, advisory); err != nil {
+		if !errors.Is(err, brclient.ErrNotSupported) {
+			a.logger.Error("failed to report quota advisory to backup-restore", zap.Error(err))
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/brclient"
+	"github.com/gardener/etcd-wrapper/internal/types"
+
+	. "github.com/onsi/gomega"
+	"go.etcd.io/etcd/clientv3"
+	"go.etcd.io/etcd/embed"
+	"go.etcd.io/etcd/etcdserver"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestObserveQuotaAdvisory(t *testing.T) {
+	g := NewWithT(t)
+	etcd := startTestEtcd(t, g)
+	cli, err := clientv3.New(clientv3.Config{Endpoints: []string{etcd.Config().ListenClientUrls[0].String()}, DialTimeout: 5 * time.Second})
+	g.Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		_ = cli.Close()
+	}()
+	status, err := cli.Status(context.Background(), cli.Endpoints()[0])
+	g.Expect(err).ToNot(HaveOccurred())
+	dbSize := status.DbSize
+
+	table := []struct {
+		description       string
+		quotaBackendBytes int64
+		riskThreshold     float64
+		predicted         bool
+		expectedQuota     int64
+		expectedReasons   int
+	}{
+		{"should not report writes at risk below the risk threshold", 2 * dbSize, 0, false, 2 * dbSize, 0},
+		{"should report writes at risk at the risk threshold", 2 * dbSize, 0.5, false, 2 * dbSize, 1},
+		{"should report writes at risk once the DB size has reached the quota", dbSize, 0, false, dbSize, 1},
+		{"should report writes at risk if quota exhaustion is predicted", 2 * dbSize, 0, true, 2 * dbSize, 1},
+		{"should use the default quota if none is configured", 0, 0, false, etcdserver.DefaultQuotaBytes, 0},
+		{"should not report writes at risk if the quota is disabled", -1, 0, true, 0, 0},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		app := &Application{
+			Config:     types.Config{QuotaAdvisory: types.QuotaAdvisoryConfig{RiskThreshold: entry.riskThreshold}, DBSizeTrend: types.DBSizeTrendConfig{Horizon: time.Hour}},
+			ctx:        context.Background(),
+			cfg:        &embed.Config{QuotaBackendBytes: entry.quotaBackendBytes},
+			etcd:       etcd,
+			etcdClient: cli,
+			logger:     zaptest.NewLogger(t),
+		}
+		app.quotaExhaustionPredicted.Store(entry.predicted)
+
+		advisory, err := app.observeQuotaAdvisory(context.Background())
+		g.Expect(err).ToNot(HaveOccurred())
+		// etcd may still grow its DB shortly after it has started.
+		g.Expect(advisory.DBSizeBytes).To(BeNumerically(">=", dbSize))
+		g.Expect(advisory.QuotaBackendBytes).To(Equal(entry.expectedQuota))
+		g.Expect(advisory.NoSpaceAlarm).To(BeFalse())
+		g.Expect(advisory.Reasons).To(HaveLen(entry.expectedReasons))
+		g.Expect(advisory.WritesAtRisk).To(Equal(entry.expectedReasons > 0))
+	}
+}
+
+func TestQuotaAdvisoryHandler(t *testing.T) {
+	g := NewWithT(t)
+	etcd := startTestEtcd(t, g)
+	cli, err := clientv3.New(clientv3.Config{Endpoints: []string{etcd.Config().ListenClientUrls[0].String()}, DialTimeout: 5 * time.Second})
+	g.Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		_ = cli.Close()
+	}()
+	app := &Application{ctx: context.Background(), cfg: &embed.Config{}, etcdClient: cli, logger: zaptest.NewLogger(t)}
+
+	t.Log("should respond with 503 if etcd is not running")
+	recorder := httptest.NewRecorder()
+	app.quotaAdvisoryHandler(recorder, httptest.NewRequest(http.MethodGet, "/quotaz", nil))
+	g.Expect(recorder.Code).To(Equal(http.StatusServiceUnavailable))
+
+	t.Log("should respond with the quota advisory if etcd is running")
+	app.etcd = etcd
+	recorder = httptest.NewRecorder()
+	app.quotaAdvisoryHandler(recorder, httptest.NewRequest(http.MethodGet, "/quotaz", nil))
+	g.Expect(recorder.Code).To(Equal(http.StatusOK))
+	advisory := brclient.QuotaAdvisory{}
+	g.Expect(json.NewDecoder(recorder.Body).Decode(&advisory)).To(Succeed())
+	g.Expect(advisory.QuotaBackendBytes).To(Equal(int64(etcdserver.DefaultQuotaBytes)))
+	g.Expect(advisory.WritesAtRisk).To(BeFalse())
+
+	t.Log("should report the quota advisory to backup-restore")
+	fakeClient := &brclient.FakeClient{}
+	g.Expect(app.reportQuotaAdvisory(fakeClient)).To(Succeed())
+	g.Expect(fakeClient.QuotaAdvisories).To(HaveLen(1))
+
+	t.Log("should return ErrNotSupported if backup-restore does not support quota advisories")
+	fakeClient.ReportQuotaAdvisoryErr = brclient.ErrNotSupported
+	g.Expect(errors.Is(app.reportQuotaAdvisory(fakeClient), brclient.ErrNotSupported)).To(BeTrue())
+}
@@ -0,0 +1,128 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/metrics"
+	"github.com/gardener/etcd-wrapper/internal/types"
+	"github.com/gardener/etcd-wrapper/internal/util"
+
+	"go.uber.org/zap"
+)
+
+// readinessGateOutputLimit is the maximum number of bytes of the output of an exec readiness gate included in its error.
+const readinessGateOutputLimit = 256
+
+// errReadinessGatesNotEvaluated is reported by /readyz until the readiness gates have been evaluated for the first time.
+var errReadinessGatesNotEvaluated = errors.New("readiness gates have not been evaluated yet")
+
+// watchReadinessGates evaluates the configured readiness gates right away and then periodically, and records the result,
+// which is reflected in the readiness of etcd-wrapper. It stops when the application context is cancelled.
+func (a *Application) watchReadinessGates() {
+	if len(a.Config.ReadinessGates.Gates) == 0 {
+		return
+	}
+	gates := make([]types.ReadinessGate, 0, len(a.Config.ReadinessGates.Gates))
+	for _, spec := range a.Config.ReadinessGates.Gates {
+		// the readiness gates have been validated when the application has been created.
+		gate, _ := types.ParseReadinessGate(spec)
+		gates = append(gates, gate)
+	}
+	client := &http.Client{Transport: &http.Transport{Proxy: util.ProxyFunc(a.Config.DisableProxyEnv)}}
+	ticker := time.NewTicker(a.Config.ReadinessGates.Interval)
+	defer ticker.Stop()
+
+	for {
+		a.setReadinessGatesErr(a.evaluateReadinessGates(gates, client))
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// evaluateReadinessGates evaluates all readiness gates and returns the errors of those which have not passed.
+func (a *Application) evaluateReadinessGates(gates []types.ReadinessGate, client *http.Client) error {
+	var errs error
+	for _, gate := range gates {
+		ctx, cancelFunc := context.WithTimeout(a.ctx, a.Config.ReadinessGates.Timeout)
+		err := checkReadinessGate(ctx, gate, client)
+		cancelFunc()
+		if err != nil {
+			metrics.ReadinessGatePassed.WithLabelValues(gate.String()).Set(0)
+			errs = errors.Join(errs, fmt.Errorf("readiness gate %s has not passed: %w", gate, err))
+			continue
+		}
+		metrics.ReadinessGatePassed.WithLabelValues(gate.String()).Set(1)
+	}
+	if previous := a.getReadinessGatesErr(); (errs == nil) != (previous == nil) || errors.Is(previous, errReadinessGatesNotEvaluated) {
+		if errs != nil {
+			a.logger.Warn("readiness gates have not passed, etcd-wrapper will not report ready", zap.Error(errs))
+		} else {
+			a.logger.Info("all readiness gates have passed", zap.Int("count", len(gates)))
+		}
+	}
+	return errs
+}
+
+// checkReadinessGate returns an error if the readiness gate does not pass.
+func checkReadinessGate(ctx context.Context, gate types.ReadinessGate, client *http.Client) error {
+	switch gate.Kind {
+	case types.ReadinessGateKindExec:
+		// the command is run without a shell, which is not available in the image of etcd-wrapper.
+		args := strings.Fields(gate.Target)
+		output := &bytes.Buffer{}
+		cmd := exec.CommandContext(ctx, args[0], args[1:]...) // #nosec G204 -- the command is configured by the operator.
+		cmd.Stdout, cmd.Stderr = output, output
+		if err := cmd.Run(); err != nil {
+			if out := strings.TrimSpace(output.String()); out != "" {
+				return fmt.Errorf("%w: %s", err, out[:min(len(out), readinessGateOutputLimit)])
+			}
+			return err
+		}
+		return nil
+	case types.ReadinessGateKindFile:
+		_, err := os.Stat(gate.Target)
+		return err
+	case types.ReadinessGateKindHTTP:
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, gate.Target, nil)
+		if err != nil {
+			return err
+		}
+		response, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer util.CloseResponseBody(response)
+		if response.StatusCode < 200 || response.StatusCode > 299 {
+			return fmt.Errorf("unexpected response status %s", response.Status)
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported readiness gate kind %q", gate.Kind)
+}
+
+func (a *Application) setReadinessGatesErr(err error) {
+	a.readinessGatesMu.Lock()
+	defer a.readinessGatesMu.Unlock()
+	a.readinessGatesErr = err
+}
+
+func (a *Application) getReadinessGatesErr() error {
+	a.readinessGatesMu.RLock()
+	defer a.readinessGatesMu.RUnlock()
+	return a.readinessGatesErr
+}
@@ -0,0 +1,85 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/types"
+
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestCheckReadinessGate(t *testing.T) {
+	dir := t.TempDir()
+	existingFile := filepath.Join(dir, "resized")
+	NewWithT(t).Expect(os.WriteFile(existingFile, nil, 0600)).To(Succeed())
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/healthz" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	table := []struct {
+		description   string
+		gate          types.ReadinessGate
+		expectedError bool
+	}{
+		{"should pass if the command exits with status 0", types.ReadinessGate{Kind: types.ReadinessGateKindExec, Target: "sh -c true"}, false},
+		{"should not pass if the command exits with another status", types.ReadinessGate{Kind: types.ReadinessGateKindExec, Target: "sh -c false"}, true},
+		{"should not pass if the command does not exist", types.ReadinessGate{Kind: types.ReadinessGateKindExec, Target: filepath.Join(dir, "missing")}, true},
+		{"should pass if the file exists", types.ReadinessGate{Kind: types.ReadinessGateKindFile, Target: existingFile}, false},
+		{"should not pass if the file does not exist", types.ReadinessGate{Kind: types.ReadinessGateKindFile, Target: filepath.Join(dir, "missing")}, true},
+		{"should pass if the URL returns a 2xx status code", types.ReadinessGate{Kind: types.ReadinessGateKindHTTP, Target: server.URL + "/healthz"}, false},
+		{"should not pass if the URL returns another status code", types.ReadinessGate{Kind: types.ReadinessGateKindHTTP, Target: server.URL + "/other"}, true},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		ctx, cancelFunc := context.WithTimeout(context.Background(), 5*time.Second)
+		err := checkReadinessGate(ctx, entry.gate, server.Client())
+		cancelFunc()
+		g.Expect(err != nil).To(Equal(entry.expectedError))
+	}
+}
+
+func TestReadinessGatesDelayReadiness(t *testing.T) {
+	g := NewWithT(t)
+	markerFile := filepath.Join(t.TempDir(), "resized")
+	app := &Application{
+		ctx:               context.Background(),
+		Config:            types.Config{ReadinessGates: types.ReadinessGatesConfig{Gates: []string{"file:" + markerFile}, Interval: time.Second, Timeout: time.Second}},
+		readinessGatesErr: errReadinessGatesNotEvaluated,
+		logger:            zaptest.NewLogger(t),
+	}
+	app.etcdReady.Store(true)
+	gates := []types.ReadinessGate{{Kind: types.ReadinessGateKindFile, Target: markerFile}}
+	readyz := func() int {
+		response := httptest.NewRecorder()
+		app.readinessHandler(response, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		return response.Code
+	}
+
+	t.Log("should not report ready before the readiness gates have been evaluated")
+	g.Expect(readyz()).To(Equal(http.StatusServiceUnavailable))
+
+	t.Log("should not report ready while a readiness gate does not pass")
+	app.setReadinessGatesErr(app.evaluateReadinessGates(gates, http.DefaultClient))
+	g.Expect(readyz()).To(Equal(http.StatusServiceUnavailable))
+
+	t.Log("should report ready once all readiness gates pass")
+	g.Expect(os.WriteFile(markerFile, nil, 0600)).To(Succeed())
+	app.setReadinessGatesErr(app.evaluateReadinessGates(gates, http.DefaultClient))
+	g.Expect(readyz()).To(Equal(http.StatusOK))
+}
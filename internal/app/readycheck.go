@@ -6,12 +6,15 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/gardener/etcd-wrapper/internal/bootstrap"
+	"github.com/gardener/etcd-wrapper/internal/httpserver"
+	"github.com/gardener/etcd-wrapper/internal/metrics"
 	"github.com/gardener/etcd-wrapper/internal/types"
 	"github.com/gardener/etcd-wrapper/internal/util"
 
@@ -20,12 +23,13 @@ import (
 )
 
 const (
-	etcdWrapperReadHeaderTimeout = 5 * time.Second
-	etcdConnectionTimeout        = 5 * time.Second
-	etcdGetTimeout               = 5 * time.Second
-	etcdQueryInterval            = 2 * time.Second
+	etcdConnectionTimeout = 5 * time.Second
+	etcdGetTimeout        = 5 * time.Second
+	etcdQueryInterval     = 2 * time.Second
 )
 
+var errEtcdNotReady = errors.New("etcd is not ready")
+
 // queryAndUpdateEtcdReadiness periodically queries the etcd DB to check its readiness and updates the status
 // of the query into the etcdStatus struct. It stops querying when the application context is cancelled.
 func (a *Application) queryAndUpdateEtcdReadiness() {
@@ -35,7 +39,14 @@ func (a *Application) queryAndUpdateEtcdReadiness() {
 
 	for {
 		// Query etcd readiness and update the status
-		a.etcdReady = a.isEtcdReady()
+		ready := a.isEtcdReady()
+		if ready && !a.etcdReady.Load() {
+			a.warmUpOnce()
+		}
+		if ready != a.etcdReady.Load() {
+			a.etcdReady.Store(ready)
+			a.writeStateFile()
+		}
 		select {
 		// Stop querying and return when the context is cancelled
 		case <-a.ctx.Done():
@@ -47,33 +58,102 @@ func (a *Application) queryAndUpdateEtcdReadiness() {
 	}
 }
 
-// isEtcdReady checks if ETCD is ready by making a `GET` call (with a timeout).
+// isEtcdReady checks if ETCD is ready according to the configured readiness policy (with a timeout).
 // if there is an error then it returns false else it returns true.
 func (a *Application) isEtcdReady() bool {
 	etcdConnCtx, cancelFunc := context.WithTimeout(a.ctx, etcdGetTimeout)
 	defer cancelFunc()
-	_, err := a.etcdClient.Get(etcdConnCtx, "foo")
+	policy := a.Config.GetReadinessPolicy()
+	err := a.checkReadiness(etcdConnCtx, policy)
 	if err != nil {
-		a.logger.Error("failed to retrieve from etcd db", zap.Error(err))
+		a.logger.Error("etcd is not ready", zap.String("readinessPolicy", policy), zap.Error(err))
 	}
 	return err == nil
 }
 
+// checkReadiness returns an error if etcd is not ready according to the given readiness policy. A linearizable `GET`
+// call requires a quorum, whereas a serializable `GET` call is served from the local data of the member, which is
+// the only kind of read a learner serves.
+func (a *Application) checkReadiness(ctx context.Context, policy string) error {
+	switch policy {
+	case types.ReadinessPolicyClusterHasQuorum:
+		_, err := a.etcdClient.Get(ctx, "foo")
+		return err
+	case types.ReadinessPolicyLearnerServingStale:
+		_, err := a.etcdClient.Get(ctx, "foo", clientv3.WithSerializable())
+		return err
+	}
+	status, err := a.etcdClient.Status(ctx, a.etcdClient.Endpoints()[0])
+	if err != nil {
+		return err
+	}
+	if status.IsLearner {
+		return errors.New("member is a learner")
+	}
+	if policy == types.ReadinessPolicyMemberHasLeader && status.Leader == 0 {
+		return errors.New("member has no leader")
+	}
+	_, err = a.etcdClient.Get(ctx, "foo", clientv3.WithSerializable())
+	return err
+}
+
 // readinessHandler reads the etcd status from the etcdStatus struct and writes that onto the http responsewriter
 func (a *Application) readinessHandler(w http.ResponseWriter, _ *http.Request) {
-	if a.etcdReady {
-		w.WriteHeader(http.StatusOK)
+	if err := a.readinessErr(); err != nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		if !errors.Is(err, errEtcdNotReady) {
+			_, _ = w.Write([]byte(err.Error()))
+		}
 		return
 	}
-	w.WriteHeader(http.StatusServiceUnavailable)
+	w.WriteHeader(http.StatusOK)
+}
+
+// readinessErr returns why the member does not serve traffic, or nil if it is ready.
+func (a *Application) readinessErr() error {
+	if err := a.getClientURLsErr(); err != nil {
+		return err
+	}
+	if a.validationInProgress() {
+		return errors.New("validation of data directory in progress")
+	}
+	if a.Config.ProposalBackpressure.FailReadiness && a.proposalBackpressure.Load() {
+		return errors.New("sustained raft proposal backpressure detected")
+	}
+	if a.Config.ApplyLag.FailReadiness && a.applyLag.Load() {
+		return errors.New("sustained divergence between committed and applied raft index detected")
+	}
+	if err := a.getReadinessGatesErr(); err != nil {
+		return err
+	}
+	if a.postRestoreMaintenanceDeferringReadiness() {
+		return errors.New("post-restore maintenance in progress")
+	}
+	if a.hibernating.Load() {
+		return errHibernating
+	}
+	if a.readinessDelayed() {
+		return errors.New("readiness delayed by fault injection")
+	}
+	if !a.etcdReady.Load() {
+		return errEtcdNotReady
+	}
+	return nil
 }
 
 // createEtcdClient creates an ETCD client
 func (a *Application) createEtcdClient() (*clientv3.Client, error) {
+	return a.createEtcdClientFor(util.ConstructBaseAddress(a.isTLSEnabled(), fmt.Sprintf("%s:%d", a.Config.EtcdClientTLS.ServerName, a.Config.EtcdClientPort)))
+}
+
+// createEtcdClientFor creates an etcd client for the given endpoints with the TLS configuration and credentials of
+// etcd-wrapper.
+func (a *Application) createEtcdClientFor(endpoints ...string) (*clientv3.Client, error) {
 	// fetch tls configuration
 	tlsConfig, err := util.CreateTLSConfig(a.isTLSEnabled, a.Config.EtcdClientTLS.ServerName, a.cfg.ClientTLSInfo.TrustedCAFile, &util.KeyPair{
-		CertPath: a.Config.EtcdClientTLS.CertPath,
-		KeyPath:  a.Config.EtcdClientTLS.KeyPath,
+		CertPath:      a.Config.EtcdClientTLS.CertPath,
+		KeyPath:       a.Config.EtcdClientTLS.KeyPath,
+		KeyPassphrase: a.Config.EtcdClientTLS.KeyPassphrase,
 	})
 	if err != nil {
 		return nil, err
@@ -82,10 +162,13 @@ func (a *Application) createEtcdClient() (*clientv3.Client, error) {
 	// Create etcd client
 	cli, err := clientv3.New(clientv3.Config{
 		Context:     a.ctx,
-		Endpoints:   []string{util.ConstructBaseAddress(a.isTLSEnabled(), fmt.Sprintf("%s:%d", a.Config.EtcdClientTLS.ServerName, a.Config.EtcdClientPort))},
+		Endpoints:   endpoints,
 		DialTimeout: etcdConnectionTimeout,
 		LogConfig:   bootstrap.SetupLoggerConfig(types.DefaultLogLevel),
 		TLS:         tlsConfig,
+		Username:    a.Config.EtcdClientAuth.Username,
+		Password:    a.Config.EtcdClientAuth.Password,
+		DialOptions: util.GRPCProxyDialOptions(a.Config.DisableProxyEnv),
 	})
 	if err != nil {
 		return nil, err
@@ -105,35 +188,26 @@ func (a *Application) stopEtcdHandler(w http.ResponseWriter, req *http.Request)
 		return
 	}
 	a.logger.Info("received stop request, stopping etcd-wrapper...")
-	a.cancelContext()
+	a.Stop()
 	w.WriteHeader(http.StatusOK)
 }
 
-func (a *Application) startHTTPServer() {
+// startHTTPServer serves the endpoints of etcd-wrapper until ctx is cancelled or the HTTP server is stopped.
+func (a *Application) startHTTPServer(ctx context.Context) {
 	a.logger.Info(
 		"Starting HTTP server at addr",
 		zap.Int64("Port No: ", int64(a.Config.EtcdWrapperPort)),
+		zap.Bool("tls", a.server.TLSEnabled()),
 	)
-	a.RegisterHandler()
-	if !a.isTLSEnabled() {
-		err := a.server.ListenAndServe()
-		if err != nil && err != http.ErrServerClosed {
-			a.logger.Fatal("Failed to start http server: %v", zap.Error(err))
-		}
-		a.logger.Info("HTTP server closed gracefully.")
-		return
-	}
-
-	a.logger.Info("TLS enabled. Starting HTTPS server.")
-	err := a.server.ListenAndServeTLS(a.cfg.ClientTLSInfo.CertFile, a.cfg.ClientTLSInfo.KeyFile)
-	if err != nil && err != http.ErrServerClosed {
+	if err := a.server.ListenAndServe(ctx); err != nil {
 		a.logger.Fatal("Failed to start http server: %v", zap.Error(err))
 	}
-	a.logger.Info("HTTPS server closed gracefully.")
+	a.logger.Info("HTTP server closed gracefully.")
 }
 
+// stopHTTPServer shuts the HTTP server down, waiting for in-flight requests to complete for at most the shutdown timeout.
 func (a *Application) stopHTTPServer() error {
-	return a.server.Close()
+	return a.server.Shutdown()
 }
 
 // RegisterHandler registers the handler for different requests
@@ -142,10 +216,30 @@ func (a *Application) RegisterHandler() {
 
 	mux.HandleFunc("/readyz", a.readinessHandler)
 	mux.HandleFunc("/stop", a.stopEtcdHandler)
-
-	a.server = &http.Server{
-		Addr:              fmt.Sprintf(":%d", a.Config.EtcdWrapperPort),
-		Handler:           mux,
-		ReadHeaderTimeout: etcdWrapperReadHeaderTimeout,
+	mux.HandleFunc("/hibernate", a.hibernateHandler)
+	mux.HandleFunc("/transfer-leadership", a.leadershipTransferHandler)
+	mux.HandleFunc("/validate", a.validateHandler)
+	mux.HandleFunc("/status", a.statusHandler)
+	mux.HandleFunc("/safetyz", a.safetyHandler)
+	mux.HandleFunc("/rolloutz", a.rolloutHandler)
+	mux.HandleFunc("/quotaz", a.quotaAdvisoryHandler)
+	mux.HandleFunc("/events", a.eventsHandler)
+	mux.HandleFunc("/debug/requests", a.requestSamplesHandler)
+	mux.HandleFunc("/debug/clients", a.clientTrafficHandler)
+	mux.HandleFunc("/debug/peer-connectivity", a.peerConnectivityHandler)
+	mux.HandleFunc("/maintenance/history", a.maintenanceHistoryHandler)
+	mux.HandleFunc("/debug/faults", a.faultsHandler)
+	mux.Handle("/metrics", metrics.Handler())
+
+	httpConfig := httpserver.Config{
+		Addr:            fmt.Sprintf(":%d", a.Config.EtcdWrapperPort),
+		ReadTimeout:     a.Config.HTTPServer.ReadTimeout,
+		WriteTimeout:    a.Config.HTTPServer.WriteTimeout,
+		IdleTimeout:     a.Config.HTTPServer.IdleTimeout,
+		ShutdownTimeout: a.Config.HTTPServer.ShutdownTimeout,
+	}
+	if a.isTLSEnabled() {
+		httpConfig.CertFile, httpConfig.KeyFile = a.cfg.ClientTLSInfo.CertFile, a.cfg.ClientTLSInfo.KeyFile
 	}
+	a.server = httpserver.New(httpConfig, mux)
 }
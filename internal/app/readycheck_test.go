@@ -6,19 +6,25 @@ package app
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/gardener/etcd-wrapper/internal/bootstrap"
+	"github.com/gardener/etcd-wrapper/internal/state"
 	"github.com/gardener/etcd-wrapper/internal/testutil"
 	"github.com/gardener/etcd-wrapper/internal/types"
 
+	"go.etcd.io/etcd/clientv3"
 	"go.etcd.io/etcd/embed"
+	pb "go.etcd.io/etcd/etcdserver/etcdserverpb"
 	"go.uber.org/zap"
 
 	. "github.com/onsi/gomega"
@@ -40,6 +46,11 @@ func TestSuit(t *testing.T) {
 		{"readinessHandler", testReadinessHandler},
 		{"createEtcdClient", testCreateEtcdClient},
 		{"isTLSEnabled", testIsTLSEnabled},
+		{"statusHandler", testStatusHandler},
+		{"checkCorruptionAlarm", testCheckCorruptionAlarm},
+		{"applyCorruptCheckConfig", testApplyCorruptCheckConfig},
+		{"writeRestoreMarker", testWriteRestoreMarker},
+		{"selfTestAdvertisedClientURLs", testSelfTestAdvertisedClientURLs},
 	}
 
 	g := NewWithT(t)
@@ -55,12 +66,22 @@ func TestSuit(t *testing.T) {
 
 func testQueryEtcdReadiness(t *testing.T) {
 	table := []struct {
-		description  string
-		querySuccess bool
-		expectStatus bool
+		description        string
+		querySuccess       bool
+		hotStandby         bool
+		readinessPolicy    string
+		memberStatus       *clientv3.StatusResponse
+		expectStatus       bool
+		expectSerializable bool
 	}{
-		{"etcd ready status should be set to true when etcd query succeeds", true, true},
-		{"etcd ready status should be set to false when etcd query fails", false, false},
+		{"etcd ready status should be set to true when etcd query succeeds", true, false, "", nil, true, false},
+		{"etcd ready status should be set to false when etcd query fails", false, false, "", nil, false, false},
+		{"etcd query should be serializable in hot-standby mode", true, true, "", nil, true, true},
+		{"etcd query should be serializable for learner-serving-stale policy", true, false, types.ReadinessPolicyLearnerServingStale, nil, true, true},
+		{"etcd ready status should be set to true for member-serving policy when member is a voting member", true, false, types.ReadinessPolicyMemberServing, &clientv3.StatusResponse{}, true, true},
+		{"etcd ready status should be set to false for member-serving policy when member is a learner", true, false, types.ReadinessPolicyMemberServing, &clientv3.StatusResponse{IsLearner: true}, false, false},
+		{"etcd ready status should be set to true for member-has-leader policy when member knows the leader", true, false, types.ReadinessPolicyMemberHasLeader, &clientv3.StatusResponse{Leader: 1}, true, true},
+		{"etcd ready status should be set to false for member-has-leader policy when member has no leader", true, false, types.ReadinessPolicyMemberHasLeader, &clientv3.StatusResponse{}, false, false},
 	}
 
 	for _, entry := range table {
@@ -70,14 +91,21 @@ func testQueryEtcdReadiness(t *testing.T) {
 		ctx, cancel := context.WithCancel(context.Background())
 		app := createApplicationInstance(ctx, cancel, g)
 
+		app.Config.HotStandby = entry.hotStandby
+		app.Config.ReadinessPolicy = entry.readinessPolicy
+
 		cli, err := app.createEtcdClient()
 		g.Expect(err).To(BeNil())
+		fakeKV := EtcdFakeKV{}
 		if entry.querySuccess {
-			fakeKV := EtcdFakeKV{}
 			cli.KV = &fakeKV
 		}
+		if entry.memberStatus != nil {
+			cli.Maintenance = &EtcdFakeMaintenance{StatusResponse: entry.memberStatus}
+		}
 		app.etcdClient = cli
 		g.Expect(app.isEtcdReady()).To(Equal(entry.expectStatus))
+		g.Expect(fakeKV.Serializable).To(Equal(entry.expectSerializable))
 
 		app.Close()
 	}
@@ -87,10 +115,20 @@ func testReadinessHandler(t *testing.T) {
 	table := []struct {
 		description    string
 		readyStatus    bool
+		clientURLsErr  error
+		backpressure   bool
+		failReadiness  bool
+		applyLag       bool
+		failOnApplyLag bool
 		expectedStatus int
 	}{
-		{"should return http.StatusOK when etcdStatus.Ready is set to true", true, http.StatusOK},
-		{"should return http.StatusServiceUnavailable when etcdStatus.Ready is set to false", false, http.StatusServiceUnavailable},
+		{"should return http.StatusOK when etcdStatus.Ready is set to true", true, nil, false, false, false, false, http.StatusOK},
+		{"should return http.StatusServiceUnavailable when etcdStatus.Ready is set to false", false, nil, false, false, false, false, http.StatusServiceUnavailable},
+		{"should return http.StatusServiceUnavailable when advertised client URLs are not reachable", true, errors.New("advertised client URL https://etcd-main-local:2379 is not reachable"), false, false, false, false, http.StatusServiceUnavailable},
+		{"should return http.StatusOK on proposal backpressure when readiness should not fail", true, nil, true, false, false, false, http.StatusOK},
+		{"should return http.StatusServiceUnavailable on proposal backpressure when readiness should fail", true, nil, true, true, false, false, http.StatusServiceUnavailable},
+		{"should return http.StatusOK on apply lag when readiness should not fail", true, nil, false, false, true, false, http.StatusOK},
+		{"should return http.StatusServiceUnavailable on apply lag when readiness should fail", true, nil, false, false, true, true, http.StatusServiceUnavailable},
 	}
 
 	for _, entry := range table {
@@ -99,7 +137,12 @@ func testReadinessHandler(t *testing.T) {
 
 		ctx, cancel := context.WithCancel(context.Background())
 		app := createApplicationInstance(ctx, cancel, g)
-		app.etcdReady = entry.readyStatus
+		app.etcdReady.Store(entry.readyStatus)
+		app.setClientURLsErr(entry.clientURLsErr)
+		app.proposalBackpressure.Store(entry.backpressure)
+		app.Config.ProposalBackpressure.FailReadiness = entry.failReadiness
+		app.applyLag.Store(entry.applyLag)
+		app.Config.ApplyLag.FailReadiness = entry.failOnApplyLag
 
 		request, err := http.NewRequest("GET", "/readyz", nil)
 		g.Expect(err).To(BeNil())
@@ -107,6 +150,9 @@ func testReadinessHandler(t *testing.T) {
 		handler := http.HandlerFunc(app.readinessHandler)
 		handler.ServeHTTP(response, request)
 		g.Expect(response.Code).To(Equal(entry.expectedStatus))
+		if entry.clientURLsErr != nil {
+			g.Expect(response.Body.String()).To(Equal(entry.clientURLsErr.Error()))
+		}
 
 		app.Close()
 	}
@@ -185,6 +231,164 @@ func testIsTLSEnabled(t *testing.T) {
 	}
 }
 
+func testStatusHandler(t *testing.T) {
+	g := NewWithT(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	app := createApplicationInstance(ctx, cancel, g)
+	defer app.Close()
+	g.Expect(app.stateMachine.TransitionTo(state.ProbingSidecar)).To(Succeed())
+	app.etcdReady.Store(true)
+
+	request, err := http.NewRequest("GET", "/status", nil)
+	g.Expect(err).To(BeNil())
+	response := httptest.NewRecorder()
+	http.HandlerFunc(app.statusHandler).ServeHTTP(response, request)
+	g.Expect(response.Code).To(Equal(http.StatusOK))
+
+	status := Status{}
+	g.Expect(json.NewDecoder(response.Body).Decode(&status)).To(Succeed())
+	g.Expect(status.State).To(Equal(state.ProbingSidecar))
+	g.Expect(status.Transitions).To(HaveLen(1))
+	g.Expect(status.EtcdReady).To(BeTrue())
+	g.Expect(status.EtcdRunning).To(BeFalse())
+}
+
+func testCheckCorruptionAlarm(t *testing.T) {
+	table := []struct {
+		description  string
+		alarms       []*pb.AlarmMember
+		expectActive bool
+	}{
+		{"should not report a corruption alarm when there are no alarms", nil, false},
+		{"should not report a corruption alarm when only a NOSPACE alarm is active", []*pb.AlarmMember{{MemberID: 1, Alarm: pb.AlarmType_NOSPACE}}, false},
+		{"should report a corruption alarm when a CORRUPT alarm is active", []*pb.AlarmMember{{MemberID: 1, Alarm: pb.AlarmType_CORRUPT}}, true},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		app := createApplicationInstance(ctx, cancel, g)
+		cli, err := app.createEtcdClient()
+		g.Expect(err).To(BeNil())
+		cli.Maintenance = &EtcdFakeMaintenance{Alarms: entry.alarms}
+		app.etcdClient = cli
+
+		app.checkCorruptionAlarm()
+		g.Expect(app.Status().CorruptionAlarm).To(Equal(entry.expectActive))
+
+		app.Close()
+	}
+}
+
+func testApplyCorruptCheckConfig(t *testing.T) {
+	table := []struct {
+		description          string
+		corruptCheck         types.CorruptCheckConfig
+		etcdInitialCheck     bool
+		etcdCheckTime        time.Duration
+		expectedInitialCheck bool
+		expectedCheckTime    time.Duration
+	}{
+		{"should enable corruption checks configured via flags", types.CorruptCheckConfig{InitialCheck: true, CheckTime: time.Hour}, false, 0, true, time.Hour},
+		{"should not disable the initial corruption check enabled in the etcd configuration", types.CorruptCheckConfig{}, true, 0, true, 0},
+		{"should not override the corruption check time set in the etcd configuration", types.CorruptCheckConfig{CheckTime: time.Hour}, false, 10 * time.Minute, false, 10 * time.Minute},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		app := createApplicationInstance(ctx, cancel, g)
+		app.Config.CorruptCheck = entry.corruptCheck
+		cfg := embed.NewConfig()
+		cfg.ExperimentalInitialCorruptCheck = entry.etcdInitialCheck
+		cfg.ExperimentalCorruptCheckTime = entry.etcdCheckTime
+
+		app.applyCorruptCheckConfig(cfg)
+		g.Expect(cfg.ExperimentalInitialCorruptCheck).To(Equal(entry.expectedInitialCheck))
+		g.Expect(cfg.ExperimentalCorruptCheckTime).To(Equal(entry.expectedCheckTime))
+
+		app.Close()
+	}
+}
+
+func testWriteRestoreMarker(t *testing.T) {
+	restoreInfo := &bootstrap.RestoreInfo{RestoredAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Revision: 42, FullSnapshot: "Full-00000000-00000042-1704067200", SnapshotRevision: 42}
+	table := []struct {
+		description  string
+		enabled      bool
+		restoreInfo  *bootstrap.RestoreInfo
+		expectMarker bool
+	}{
+		{"should write restore marker when enabled and a restoration has been detected", true, restoreInfo, true},
+		{"should not write restore marker when no restoration has been detected", true, nil, false},
+		{"should not write restore marker when disabled", false, restoreInfo, false},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		app := createApplicationInstance(ctx, cancel, g)
+		app.Config.RestoreMarker = types.RestoreMarkerConfig{Enabled: entry.enabled, Key: types.DefaultRestoreMarkerKey}
+		app.etcdInitializer = &fakeEtcdInitializer{restoreInfo: entry.restoreInfo}
+		cli, err := app.createEtcdClient()
+		g.Expect(err).To(BeNil())
+		fakeKV := &EtcdFakeKV{}
+		cli.KV = fakeKV
+		app.etcdClient = cli
+
+		app.writeRestoreMarker()
+		marker, ok := fakeKV.Puts[types.DefaultRestoreMarkerKey]
+		g.Expect(ok).To(Equal(entry.expectMarker))
+		if entry.expectMarker {
+			g.Expect(marker).To(MatchJSON(`{"restoredAt":"2024-01-01T00:00:00Z","revision":42,"fullSnapshot":"Full-00000000-00000042-1704067200","snapshotRevision":42}`))
+		}
+
+		app.Close()
+	}
+}
+
+func testSelfTestAdvertisedClientURLs(t *testing.T) {
+	table := []struct {
+		description   string
+		skip          bool
+		trustedCAFile string
+		expectError   bool
+	}{
+		{"should fail self-test when TLS config for an https client URL cannot be created", false, filepath.Join(testdataPath, "does-not-exist.pem"), true},
+		{"should not run self-test when it is skipped", true, filepath.Join(testdataPath, "does-not-exist.pem"), false},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		app := createApplicationInstance(ctx, cancel, g)
+		app.Config.SkipClientURLSelfTest = entry.skip
+		app.cfg.AdvertiseClientUrls = []url.URL{{Scheme: "https", Host: "etcd-main-local:2379"}}
+		app.cfg.ClientTLSInfo.TrustedCAFile = entry.trustedCAFile
+
+		app.selfTestAdvertisedClientURLs()
+		g.Expect(app.getClientURLsErr() != nil).To(Equal(entry.expectError))
+		g.Expect(app.Status().ClientURLsError != "").To(Equal(entry.expectError))
+
+		if entry.expectError {
+			t.Log("should clear the failure once the self-test succeeds again")
+			app.cfg.AdvertiseClientUrls = nil
+			app.selfTestAdvertisedClientURLs()
+			g.Expect(app.getClientURLsErr()).ToNot(HaveOccurred())
+		}
+
+		app.Close()
+	}
+}
+
 func createApplicationInstance(ctx context.Context, cancelFn context.CancelFunc, g *GomegaWithT) *Application {
 	config := types.Config{
 		BackupRestore: types.BackupRestoreConfig{
@@ -223,3 +427,28 @@ func createTLSResources(g *WithT) {
 	g.Expect(err).To(BeNil())
 	g.Expect(clientCertKeyPair.EncodeAndWrite(testdataPath, "etcd-01.pem", "etcd-01-key.pem")).To(Succeed())
 }
+
+// fakeEtcdInitializer is a fake implementation of bootstrap.EtcdInitializer.
+type fakeEtcdInitializer struct {
+	cfg         *embed.Config
+	err         error
+	restoreInfo *bootstrap.RestoreInfo
+}
+
+// Run returns the preconfigured etcd configuration and error.
+func (f *fakeEtcdInitializer) Run(_ context.Context) (*embed.Config, error) {
+	return f.cfg, f.err
+}
+
+// RestoreInfo returns the preconfigured restore info.
+func (f *fakeEtcdInitializer) RestoreInfo() *bootstrap.RestoreInfo {
+	return f.restoreInfo
+}
+
+// RecordOutcome does nothing.
+func (f *fakeEtcdInitializer) RecordOutcome(_ bootstrap.AttemptOutcome) {}
+
+// EtcdConfigFilePath returns an empty path.
+func (f *fakeEtcdInitializer) EtcdConfigFilePath() string {
+	return ""
+}
@@ -0,0 +1,41 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gardener/etcd-wrapper/internal/reqsample"
+
+	"go.uber.org/zap"
+)
+
+// requestSamplesResponse is the response of the request samples endpoint.
+type requestSamplesResponse struct {
+	// Fraction is the fraction of requests which are sampled.
+	Fraction float64 `json:"fraction"`
+	// Summary aggregates the samples per method and key prefix, ordered by descending count.
+	Summary []reqsample.Summary `json:"summary"`
+	// Samples are the retained samples, oldest first.
+	Samples []reqsample.Sample `json:"samples"`
+}
+
+// requestSamplesHandler writes the sampled client requests and their summary as JSON onto the http.ResponseWriter.
+func (a *Application) requestSamplesHandler(w http.ResponseWriter, _ *http.Request) {
+	if a.requestSampler == nil {
+		http.Error(w, "request sampling is disabled", http.StatusNotFound)
+		return
+	}
+	samples := a.requestSampler.Samples()
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(requestSamplesResponse{
+		Fraction: a.requestSampler.Fraction(),
+		Summary:  reqsample.Summarize(samples),
+		Samples:  samples,
+	}); err != nil {
+		a.logger.Error("failed to write request samples response", zap.Error(err))
+	}
+}
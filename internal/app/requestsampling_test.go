@@ -0,0 +1,51 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gardener/etcd-wrapper/internal/reqsample"
+
+	. "github.com/onsi/gomega"
+	"go.etcd.io/etcd/etcdserver/etcdserverpb"
+	"go.uber.org/zap/zaptest"
+	"google.golang.org/grpc"
+)
+
+func TestRequestSamplesHandler(t *testing.T) {
+	g := NewWithT(t)
+	logger := zaptest.NewLogger(t)
+
+	t.Log("should respond with not found if request sampling is disabled")
+	app := &Application{logger: logger}
+	response := httptest.NewRecorder()
+	app.requestSamplesHandler(response, httptest.NewRequest(http.MethodGet, "/debug/requests", nil))
+	g.Expect(response.Code).To(Equal(http.StatusNotFound))
+
+	t.Log("should respond with the samples and their summary")
+	app.requestSampler = reqsample.NewSampler(1, 10, 2)
+	interceptor := app.requestSampler.UnaryServerInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: "/etcdserverpb.KV/Range"}
+	handler := func(context.Context, any) (any, error) { return &etcdserverpb.RangeResponse{}, nil }
+	for _, key := range []string{"/registry/pods/a", "/registry/pods/b"} {
+		_, err := interceptor(context.Background(), &etcdserverpb.RangeRequest{Key: []byte(key)}, info, handler)
+		g.Expect(err).ToNot(HaveOccurred())
+	}
+	response = httptest.NewRecorder()
+	app.requestSamplesHandler(response, httptest.NewRequest(http.MethodGet, "/debug/requests", nil))
+	g.Expect(response.Code).To(Equal(http.StatusOK))
+	var body requestSamplesResponse
+	g.Expect(json.Unmarshal(response.Body.Bytes(), &body)).To(Succeed())
+	g.Expect(body.Fraction).To(Equal(1.0))
+	g.Expect(body.Samples).To(HaveLen(2))
+	g.Expect(body.Summary).To(HaveLen(1))
+	g.Expect(body.Summary[0].Method).To(Equal("/etcdserverpb.KV/Range"))
+	g.Expect(body.Summary[0].Count).To(Equal(2))
+}
@@ -0,0 +1,96 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/metrics"
+
+	"go.etcd.io/etcd/clientv3"
+	"go.uber.org/zap"
+)
+
+const (
+	// requestLimitBytes is the label of the maximum size of a client request.
+	requestLimitBytes = "request-bytes"
+	// requestLimitTxnOps is the label of the maximum number of operations in a transaction.
+	requestLimitTxnOps = "txn-ops"
+	// requestSizeWarningInterval is the minimum interval in which a warning is logged per request limit, so that a
+	// client repeatedly writing large requests does not flood the log.
+	requestSizeWarningInterval = time.Minute
+	// requestSizeRewatchInterval is the time after which the watch of the keyspace is re-established once it has ended.
+	requestSizeRewatchInterval = 10 * time.Second
+)
+
+// observedRequest is a write request to etcd as observed from the events of its revision.
+type observedRequest struct {
+	revision int64
+	bytes    int
+	ops      int
+}
+
+// watchRequestSizes watches all writes to etcd and warns when a request approaches the maximum size of a client
+// request or the maximum number of operations in a transaction, so that the limits can be raised before clients
+// fail. It stops when the application context is cancelled.
+func (a *Application) watchRequestSizes() {
+	ratio := a.Config.ServerTuning.RequestSizeWarningRatio
+	if ratio <= 0 {
+		return
+	}
+	bytesThreshold := int(ratio * float64(a.cfg.MaxRequestBytes))
+	opsThreshold := int(ratio * float64(a.cfg.MaxTxnOps))
+	lastWarnings := make(map[string]time.Time)
+	warn := func(limit string, request observedRequest, observed, threshold int) {
+		metrics.RequestsNearLimitTotal.WithLabelValues(limit).Inc()
+		if time.Since(lastWarnings[limit]) < requestSizeWarningInterval {
+			return
+		}
+		lastWarnings[limit] = time.Now()
+		// the keys are not logged, since they may be sensitive. The request can be inspected by watching from its revision instead.
+		a.logger.Warn("write request approaches the limit of etcd, requests beyond the limit are rejected",
+			zap.String("limit", limit), zap.Int("observed", observed), zap.Int("threshold", threshold), zap.Int64("revision", request.revision))
+	}
+	for {
+		ctx, cancelFunc := context.WithCancel(a.ctx)
+		for response := range a.etcdClient.Watch(ctx, "", clientv3.WithPrefix()) {
+			if err := response.Err(); err != nil {
+				a.logger.Warn("watch for observing request sizes failed", zap.Error(err))
+				break
+			}
+			for _, request := range observeRequests(response.Events) {
+				if request.bytes > bytesThreshold {
+					warn(requestLimitBytes, request, request.bytes, bytesThreshold)
+				}
+				if request.ops > opsThreshold {
+					warn(requestLimitTxnOps, request, request.ops, opsThreshold)
+				}
+			}
+		}
+		cancelFunc()
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-time.After(requestSizeRewatchInterval):
+		}
+	}
+}
+
+// observeRequests groups the events by their revision into the write requests which have caused them. The size of a
+// request is estimated from the keys and values it has written, and its number of operations from its number of
+// events. Both underestimate transactions which also contain reads or write keys without effect.
+func observeRequests(events []*clientv3.Event) []observedRequest {
+	var requests []observedRequest
+	for _, event := range events {
+		if len(requests) == 0 || requests[len(requests)-1].revision != event.Kv.ModRevision {
+			requests = append(requests, observedRequest{revision: event.Kv.ModRevision})
+		}
+		request := &requests[len(requests)-1]
+		request.bytes += len(event.Kv.Key) + len(event.Kv.Value)
+		request.ops++
+	}
+	return requests
+}
@@ -0,0 +1,34 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"go.etcd.io/etcd/clientv3"
+	"go.etcd.io/etcd/mvcc/mvccpb"
+)
+
+func TestObserveRequests(t *testing.T) {
+	event := func(revision int64, key, value string) *clientv3.Event {
+		return &clientv3.Event{Kv: &mvccpb.KeyValue{Key: []byte(key), Value: []byte(value), ModRevision: revision}}
+	}
+	table := []struct {
+		description      string
+		events           []*clientv3.Event
+		expectedRequests []observedRequest
+	}{
+		{"should observe no requests without events", nil, nil},
+		{"should observe a request per revision", []*clientv3.Event{event(2, "a", "bc"), event(3, "de", "")}, []observedRequest{{revision: 2, bytes: 3, ops: 1}, {revision: 3, bytes: 2, ops: 1}}},
+		{"should observe the events of a transaction as one request", []*clientv3.Event{event(4, "a", "b"), event(4, "c", "d"), event(4, "e", "")}, []observedRequest{{revision: 4, bytes: 5, ops: 3}}},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		g.Expect(observeRequests(entry.events)).To(Equal(entry.expectedRequests))
+	}
+}
@@ -0,0 +1,74 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/types"
+)
+
+// RestartBudgetExhaustedError is returned by Start when the embedded etcd would have to be restarted more often than
+// allowed by the restart budget.
+type RestartBudgetExhaustedError struct {
+	// MaxRestarts is the maximum number of restarts within Window.
+	MaxRestarts int
+	// Window is the window within which at most MaxRestarts restarts are allowed.
+	Window time.Duration
+}
+
+func (e *RestartBudgetExhaustedError) Error() string {
+	return fmt.Sprintf("restart budget of %d restarts per %s is exhausted", e.MaxRestarts, e.Window)
+}
+
+// ExitCode returns the process exit code signalling that the restart budget is exhausted.
+func (e *RestartBudgetExhaustedError) ExitCode() int {
+	return types.ExitCodeRestartBudgetExhausted
+}
+
+// restartBudget is a token bucket which limits restarts of the embedded etcd to maxRestarts per window. The bucket
+// starts full and is refilled continuously by one token every window/maxRestarts. It is safe for concurrent use.
+type restartBudget struct {
+	mu          sync.Mutex
+	maxRestarts int
+	window      time.Duration
+	tokens      float64
+	lastRefill  time.Time
+}
+
+// newRestartBudget creates a restartBudget. A maxRestarts of zero allows an unlimited number of restarts.
+func newRestartBudget(maxRestarts int, window time.Duration, now time.Time) *restartBudget {
+	return &restartBudget{
+		maxRestarts: maxRestarts,
+		window:      window,
+		tokens:      float64(maxRestarts),
+		lastRefill:  now,
+	}
+}
+
+// take consumes one token and returns true if the budget permits another restart at now.
+func (b *restartBudget) take(now time.Time) bool {
+	if b.maxRestarts <= 0 {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if elapsed := now.Sub(b.lastRefill); elapsed > 0 {
+		b.tokens = min(float64(b.maxRestarts), b.tokens+elapsed.Seconds()*float64(b.maxRestarts)/b.window.Seconds())
+		b.lastRefill = now
+	}
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// exhaustedError returns the error reporting that the restart budget is exhausted.
+func (b *restartBudget) exhaustedError() error {
+	return &RestartBudgetExhaustedError{MaxRestarts: b.maxRestarts, Window: b.window}
+}
@@ -0,0 +1,50 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/types"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestRestartBudget(t *testing.T) {
+	start := time.Now()
+	table := []struct {
+		description     string
+		maxRestarts     int
+		restartOffsets  []time.Duration
+		expectedAllowed []bool
+	}{
+		{"should allow unlimited restarts when budget is disabled", 0, []time.Duration{0, 0, 0}, []bool{true, true, true}},
+		{"should allow restarts up to the budget", 3, []time.Duration{0, 0, 0, 0}, []bool{true, true, true, false}},
+		{"should refill one token per window divided by budget", 3, []time.Duration{0, 0, 0, 5 * time.Minute, 10 * time.Minute, 10 * time.Minute}, []bool{true, true, true, false, true, false}},
+		{"should not refill beyond the budget", 2, []time.Duration{time.Hour, time.Hour, time.Hour}, []bool{true, true, false}},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		budget := newRestartBudget(entry.maxRestarts, 30*time.Minute, start)
+		var allowed []bool
+		for _, offset := range entry.restartOffsets {
+			allowed = append(allowed, budget.take(start.Add(offset)))
+		}
+		g.Expect(allowed).To(Equal(entry.expectedAllowed))
+	}
+}
+
+func TestRestartBudgetExhaustedError(t *testing.T) {
+	g := NewWithT(t)
+	err := newRestartBudget(5, 10*time.Minute, time.Now()).exhaustedError()
+	var exhaustedErr *RestartBudgetExhaustedError
+	g.Expect(errors.As(err, &exhaustedErr)).To(BeTrue())
+	g.Expect(exhaustedErr.ExitCode()).To(Equal(types.ExitCodeRestartBudgetExhausted))
+	g.Expect(err.Error()).To(Equal("restart budget of 5 restarts per 10m0s is exhausted"))
+}
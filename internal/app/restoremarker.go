@@ -0,0 +1,53 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/audit"
+	"github.com/gardener/etcd-wrapper/internal/util"
+
+	"go.uber.org/zap"
+)
+
+const (
+	restoreMarkerMaxRetries = 5
+	restoreMarkerBackOff    = 2 * time.Second
+)
+
+// writeRestoreMarker writes the RestoreInfo of a restoration detected during initialization as JSON into the
+// configured marker key, so that consumers of etcd can detect that a restoration has happened. It is a no-op if
+// writing the marker is disabled or if no restoration has been detected.
+func (a *Application) writeRestoreMarker() {
+	if !a.Config.RestoreMarker.Enabled {
+		return
+	}
+	restoreInfo := a.etcdInitializer.RestoreInfo()
+	if restoreInfo == nil {
+		return
+	}
+	value, err := json.Marshal(restoreInfo)
+	if err != nil {
+		a.logger.Error("failed to marshal restore marker", zap.Error(err))
+		return
+	}
+	key := a.Config.RestoreMarker.Key
+	err = audit.Record(a.auditLogger, audit.OperationWriteRestoreMarker, key, func() error {
+		return util.Retry[struct{}](a.ctx, a.logger, "WriteRestoreMarker", func() (struct{}, error) {
+			ctx, cancelFunc := context.WithTimeout(a.ctx, etcdGetTimeout)
+			defer cancelFunc()
+			_, err := a.etcdClient.Put(ctx, key, string(value))
+			return struct{}{}, err
+		}, restoreMarkerMaxRetries, restoreMarkerBackOff, util.AlwaysRetry).Err
+	})
+	if err != nil {
+		a.logger.Error("failed to write restore marker", zap.String("key", key), zap.Error(err))
+		return
+	}
+	a.logger.Info("Written restore marker", zap.String("key", key), zap.ByteString("value", value))
+}
@@ -0,0 +1,141 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/metrics"
+	"github.com/gardener/etcd-wrapper/internal/util"
+
+	"go.uber.org/zap"
+)
+
+// revisionWatermark is the highest etcd revision observed by etcd-wrapper, persisted outside the data directory so that
+// it survives a restoration of the data directory.
+type revisionWatermark struct {
+	// Revision is the highest etcd revision observed.
+	Revision int64 `json:"revision"`
+	// ObservedAt is the time at which the revision has been observed.
+	ObservedAt time.Time `json:"observedAt"`
+}
+
+// checkRevisionWatermark compares the revision of a data directory restored during initialization with the persisted
+// revision watermark, and reports the restored state as regressed if it is older than the watermark by more than the
+// configured delta, i.e. if more revisions have been lost than tolerated. It is a no-op if the watermark is disabled,
+// if no restoration has been detected or if no watermark has been persisted yet.
+func (a *Application) checkRevisionWatermark() {
+	path := a.Config.RevisionWatermark.Path
+	if path == "" {
+		return
+	}
+	restoreInfo := a.etcdInitializer.RestoreInfo()
+	if restoreInfo == nil {
+		return
+	}
+	watermark, err := loadRevisionWatermark(path)
+	if err != nil {
+		a.logger.Error("failed to load revision watermark, cannot verify the revision of the restored data directory", zap.String("path", path), zap.Error(err))
+		return
+	}
+	if watermark == nil {
+		a.logger.Info("no revision watermark has been persisted yet, not verifying the revision of the restored data directory", zap.String("path", path))
+		return
+	}
+	behind := max(watermark.Revision-restoreInfo.Revision, 0)
+	metrics.RestoredRevisionsBehindWatermark.Set(float64(behind))
+	fields := []zap.Field{zap.Int64("restoredRevision", restoreInfo.Revision), zap.Int64("watermarkRevision", watermark.Revision),
+		zap.Time("watermarkObservedAt", watermark.ObservedAt), zap.Int64("revisionsBehind", behind), zap.Int64("maxDelta", a.Config.RevisionWatermark.MaxDelta),
+		zap.String("fullSnapshot", restoreInfo.FullSnapshot)}
+	if behind > a.Config.RevisionWatermark.MaxDelta {
+		metrics.RestoreRevisionRegression.Set(1)
+		a.logger.Error("restored data directory is older than the revision watermark by more than the tolerated delta, writes acknowledged before the "+
+			"last shutdown have been lost. Check whether backup-restore has uploaded the latest snapshots", fields...)
+		return
+	}
+	metrics.RestoreRevisionRegression.Set(0)
+	a.logger.Info("revision of the restored data directory is within the tolerated delta of the revision watermark", fields...)
+}
+
+// watchRevisionWatermark periodically persists the revision of etcd as revision watermark. It stops when the
+// application context is cancelled.
+func (a *Application) watchRevisionWatermark() {
+	if a.Config.RevisionWatermark.Path == "" || a.Config.RevisionWatermark.Interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(a.Config.RevisionWatermark.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-ticker.C:
+			if err := a.recordRevisionWatermark(a.ctx); err != nil {
+				a.logger.Error("failed to record revision watermark", zap.Error(err))
+			}
+		}
+	}
+}
+
+// recordRevisionWatermarkOnShutdown persists the revision of etcd as revision watermark before etcd is stopped on
+// shutdown. A failure is only logged since it must not block the shutdown.
+func (a *Application) recordRevisionWatermarkOnShutdown() {
+	if a.Config.RevisionWatermark.Path == "" {
+		return
+	}
+	// the application context has been cancelled already.
+	ctx, cancelFunc := context.WithTimeout(context.Background(), etcdGetTimeout)
+	defer cancelFunc()
+	if err := a.recordRevisionWatermark(ctx); err != nil {
+		a.logger.Error("failed to record revision watermark on shutdown", zap.Error(err))
+	}
+}
+
+// recordRevisionWatermark persists the current revision of etcd as revision watermark. It is a no-op if etcd is not
+// running.
+func (a *Application) recordRevisionWatermark(ctx context.Context) error {
+	if !a.etcdRunning() {
+		return nil
+	}
+	ctx, cancelFunc := context.WithTimeout(ctx, etcdGetTimeout)
+	defer cancelFunc()
+	status, err := a.etcdClient.Status(ctx, a.etcdClient.Endpoints()[0])
+	if err != nil {
+		return fmt.Errorf("failed to get etcd status: %w", err)
+	}
+	return writeRevisionWatermark(a.Config.RevisionWatermark.Path, revisionWatermark{Revision: status.Header.Revision, ObservedAt: time.Now().UTC()})
+}
+
+// loadRevisionWatermark loads the persisted revision watermark. It returns nil if no watermark has been persisted yet.
+func loadRevisionWatermark(path string) (*revisionWatermark, error) {
+	data, err := os.ReadFile(path) // #nosec G304 -- path is configured by the operator of etcd-wrapper.
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var watermark revisionWatermark
+	if err = json.Unmarshal(data, &watermark); err != nil {
+		return nil, err
+	}
+	return &watermark, nil
+}
+
+// writeRevisionWatermark atomically writes the revision watermark by writing it into a temporary file which is then
+// renamed.
+func writeRevisionWatermark(path string, watermark revisionWatermark) error {
+	data, err := json.Marshal(watermark)
+	if err != nil {
+		return err
+	}
+	return util.WriteFileAtomic(path, data, 0600)
+}
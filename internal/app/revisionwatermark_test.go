@@ -0,0 +1,96 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/bootstrap"
+	"github.com/gardener/etcd-wrapper/internal/metrics"
+	"github.com/gardener/etcd-wrapper/internal/types"
+
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"go.etcd.io/etcd/clientv3"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestRecordRevisionWatermark(t *testing.T) {
+	g := NewWithT(t)
+	etcd := startTestEtcd(t, g)
+	cli, err := clientv3.New(clientv3.Config{Endpoints: []string{etcd.Config().ListenClientUrls[0].String()}, DialTimeout: 5 * time.Second})
+	g.Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		_ = cli.Close()
+	}()
+	_, err = cli.Put(context.Background(), "foo", "bar")
+	g.Expect(err).ToNot(HaveOccurred())
+	path := filepath.Join(t.TempDir(), "revision_watermark.json")
+	app := &Application{
+		Config:     types.Config{RevisionWatermark: types.RevisionWatermarkConfig{Path: path}},
+		ctx:        context.Background(),
+		etcdClient: cli,
+		logger:     zaptest.NewLogger(t),
+	}
+
+	t.Log("should not record the revision watermark if etcd is not running")
+	app.recordRevisionWatermarkOnShutdown()
+	g.Expect(path).ToNot(BeAnExistingFile())
+
+	t.Log("should record the revision of etcd as revision watermark")
+	app.etcd = etcd
+	app.recordRevisionWatermarkOnShutdown()
+	watermark, err := loadRevisionWatermark(path)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(watermark).ToNot(BeNil())
+	g.Expect(watermark.Revision).To(Equal(etcd.Server.KV().Rev()))
+}
+
+func TestCheckRevisionWatermark(t *testing.T) {
+	table := []struct {
+		description        string
+		watermark          *revisionWatermark
+		restoreInfo        *bootstrap.RestoreInfo
+		maxDelta           int64
+		expectedBehind     float64
+		expectedRegression float64
+	}{
+		{"should not check without restoration", &revisionWatermark{Revision: 100}, nil, 0, -1, -1},
+		{"should not check without persisted watermark", nil, &bootstrap.RestoreInfo{Revision: 50}, 0, -1, -1},
+		{"should report a restoration within the tolerated delta", &revisionWatermark{Revision: 100}, &bootstrap.RestoreInfo{Revision: 90}, 10, 10, 0},
+		{"should report a restoration beyond the tolerated delta as regressed", &revisionWatermark{Revision: 100}, &bootstrap.RestoreInfo{Revision: 89}, 10, 11, 1},
+		{"should report a restoration newer than the watermark", &revisionWatermark{Revision: 100}, &bootstrap.RestoreInfo{Revision: 120}, 0, 0, 0},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		path := filepath.Join(t.TempDir(), "revision_watermark.json")
+		if entry.watermark != nil {
+			g.Expect(writeRevisionWatermark(path, *entry.watermark)).To(Succeed())
+		}
+		metrics.RestoredRevisionsBehindWatermark.Set(-1)
+		metrics.RestoreRevisionRegression.Set(-1)
+		app := &Application{
+			Config:          types.Config{RevisionWatermark: types.RevisionWatermarkConfig{Path: path, MaxDelta: entry.maxDelta}},
+			etcdInitializer: &fakeEtcdInitializer{restoreInfo: entry.restoreInfo},
+			logger:          zaptest.NewLogger(t),
+		}
+
+		app.checkRevisionWatermark()
+		g.Expect(gaugeValue(g, metrics.RestoredRevisionsBehindWatermark)).To(Equal(entry.expectedBehind))
+		g.Expect(gaugeValue(g, metrics.RestoreRevisionRegression)).To(Equal(entry.expectedRegression))
+	}
+}
+
+func gaugeValue(g *WithT, gauge prometheus.Gauge) float64 {
+	metric := &dto.Metric{}
+	g.Expect(gauge.Write(metric)).To(Succeed())
+	return metric.GetGauge().GetValue()
+}
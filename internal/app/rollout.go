@@ -0,0 +1,71 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"go.etcd.io/etcd/version"
+	"go.uber.org/zap"
+)
+
+// rolloutEtcdVersionParam is the query parameter of the rollout endpoint carrying the etcd version the member is
+// desired to run.
+const rolloutEtcdVersionParam = "etcdVersion"
+
+// rolloutResponse is the response of the rollout endpoint.
+type rolloutResponse struct {
+	// Serving is true if the member is ready to serve traffic, like reported by the readiness endpoint.
+	Serving bool `json:"serving"`
+	// NotServingReason explains why the member does not serve traffic. It is empty if the member is serving.
+	NotServingReason string `json:"notServingReason,omitempty"`
+	// Updated is true if the member runs the desired configuration and version.
+	Updated bool `json:"updated"`
+	// StaleReasons explain why the member does not run the desired configuration or version. It is empty if the member
+	// is updated.
+	StaleReasons []string `json:"staleReasons,omitempty"`
+	// EtcdVersion is the version of the embedded etcd.
+	EtcdVersion string `json:"etcdVersion"`
+}
+
+// rolloutHandler responds with whether the member serves traffic and whether it runs the desired configuration and
+// version, so that update orchestrators can tell a healthy but stale member from a fully reconciled one. The desired
+// etcd version can be passed with the etcdVersion query parameter. It responds with 200 only if the member is both
+// serving and updated, and with 503 otherwise.
+func (a *Application) rolloutHandler(w http.ResponseWriter, r *http.Request) {
+	response := rolloutResponse{Serving: true, EtcdVersion: version.Version}
+	if err := a.readinessErr(); err != nil {
+		response.Serving = false
+		response.NotServingReason = err.Error()
+	}
+	response.StaleReasons = a.staleReasons(r.URL.Query().Get(rolloutEtcdVersionParam))
+	response.Updated = len(response.StaleReasons) == 0
+
+	w.Header().Set("Content-Type", "application/json")
+	if !response.Serving || !response.Updated {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		a.logger.Error("failed to write rollout response", zap.Error(err))
+	}
+}
+
+// staleReasons returns why the member does not run the desired configuration or the desired etcd version, which is
+// not checked if empty.
+func (a *Application) staleReasons(desiredEtcdVersion string) []string {
+	var reasons []string
+	if desiredEtcdVersion != "" && desiredEtcdVersion != version.Version {
+		reasons = append(reasons, fmt.Sprintf("etcd version %s is running but %s is desired", version.Version, desiredEtcdVersion))
+	}
+	if a.etcdConfigChanged.Load() {
+		reasons = append(reasons, "etcd configuration served by backup-restore has changed and takes effect on the next start of etcd-wrapper")
+	}
+	if quota := a.pendingQuotaBackendBytes.Load(); quota != 0 {
+		reasons = append(reasons, fmt.Sprintf("backend quota of %d bytes derived from the resized data volume takes effect on the next restart of etcd", quota))
+	}
+	return reasons
+}
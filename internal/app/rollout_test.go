@@ -0,0 +1,58 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"go.etcd.io/etcd/version"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestRolloutHandler(t *testing.T) {
+	table := []struct {
+		description        string
+		etcdReady          bool
+		configChanged      bool
+		pendingQuota       int64
+		target             string
+		expectedStatusCode int
+		expectedServing    bool
+		expectedStale      int
+	}{
+		{"should be serving and updated", true, false, 0, "/rolloutz", http.StatusOK, true, 0},
+		{"should be serving and updated if the desired etcd version is running", true, false, 0, "/rolloutz?etcdVersion=" + version.Version, http.StatusOK, true, 0},
+		{"should not be serving if etcd is not ready", false, false, 0, "/rolloutz", http.StatusServiceUnavailable, false, 0},
+		{"should be stale if another etcd version is desired", true, false, 0, "/rolloutz?etcdVersion=3.5.0", http.StatusServiceUnavailable, true, 1},
+		{"should be stale if the etcd configuration has changed", true, true, 0, "/rolloutz", http.StatusServiceUnavailable, true, 1},
+		{"should be stale if a backend quota is pending", true, false, 8 * 1024 * 1024 * 1024, "/rolloutz", http.StatusServiceUnavailable, true, 1},
+		{"should report all stale reasons", false, true, 8 * 1024 * 1024 * 1024, "/rolloutz?etcdVersion=3.5.0", http.StatusServiceUnavailable, false, 3},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		app := &Application{ctx: context.Background(), logger: zaptest.NewLogger(t)}
+		app.etcdReady.Store(entry.etcdReady)
+		app.etcdConfigChanged.Store(entry.configChanged)
+		app.pendingQuotaBackendBytes.Store(entry.pendingQuota)
+
+		recorder := httptest.NewRecorder()
+		app.rolloutHandler(recorder, httptest.NewRequest(http.MethodGet, entry.target, nil))
+		g.Expect(recorder.Code).To(Equal(entry.expectedStatusCode))
+		response := rolloutResponse{}
+		g.Expect(json.NewDecoder(recorder.Body).Decode(&response)).To(Succeed())
+		g.Expect(response.Serving).To(Equal(entry.expectedServing))
+		g.Expect(response.NotServingReason == "").To(Equal(entry.expectedServing))
+		g.Expect(response.Updated).To(Equal(entry.expectedStale == 0))
+		g.Expect(response.StaleReasons).To(HaveLen(entry.expectedStale))
+		g.Expect(response.EtcdVersion).To(Equal(version.Version))
+	}
+}
@@ -0,0 +1,139 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/brclient"
+	"github.com/gardener/etcd-wrapper/internal/metrics"
+
+	"go.uber.org/zap"
+)
+
+// latestSnapshotsFetchTimeout is the time to wait for backup-restore to respond with the latest snapshots.
+const latestSnapshotsFetchTimeout = 10 * time.Second
+
+var (
+	errBackupNotFetched = errors.New("latest snapshots have not been fetched from backup-restore yet")
+	errNoBackup         = errors.New("backup-restore has not taken any snapshot")
+)
+
+// safetyResponse is the response of the safety endpoint.
+type safetyResponse struct {
+	// Safe is true if the member is safe to disrupt.
+	Safe bool `json:"safe"`
+	// Reason explains why the member is unsafe to disrupt. It is empty if the member is safe to disrupt.
+	Reason string `json:"reason,omitempty"`
+	// LastBackup is the creation time of the latest snapshot. It is nil if it is unknown.
+	LastBackup *time.Time `json:"lastBackup,omitempty"`
+	// BackupAge is the age of the latest snapshot. It is empty if it is unknown.
+	BackupAge string `json:"backupAge,omitempty"`
+	// MaxBackupAge is the age of the latest snapshot beyond which the member is unsafe to disrupt.
+	MaxBackupAge string `json:"maxBackupAge"`
+}
+
+// watchBackupFreshness fetches the latest snapshots from backup-restore right away and then periodically, and records
+// the creation time of the latest snapshot for the safety endpoint. It stops when the application context is cancelled.
+func (a *Application) watchBackupFreshness() {
+	if a.Config.BackupFreshness.MaxAge <= 0 {
+		return
+	}
+	ticker := time.NewTicker(a.Config.BackupFreshness.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		a.fetchLastBackup()
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// fetchLastBackup fetches the latest snapshots from backup-restore once and records the creation time of the newest
+// one, or the error of the fetch.
+func (a *Application) fetchLastBackup() {
+	ctx, cancelFunc := context.WithTimeout(a.ctx, latestSnapshotsFetchTimeout)
+	defer cancelFunc()
+	latestSnapshots, err := a.brClient.GetLatestSnapshots(ctx)
+	var lastBackup time.Time
+	if err != nil {
+		a.logger.Error("failed to fetch latest snapshots from backup-restore for the safety endpoint", zap.Error(err))
+		err = fmt.Errorf("failed to fetch latest snapshots from backup-restore: %w", err)
+	} else if lastBackup = newestSnapshotTime(latestSnapshots); lastBackup.IsZero() {
+		err = errNoBackup
+	} else {
+		metrics.LastBackupTimestampSeconds.Set(float64(lastBackup.Unix()))
+	}
+	a.lastBackupMu.Lock()
+	defer a.lastBackupMu.Unlock()
+	if err != nil && !a.lastBackup.IsZero() && !errors.Is(err, errNoBackup) {
+		// keep the last known snapshot, whose age still tells whether the member is safe to disrupt.
+		return
+	}
+	a.lastBackup, a.lastBackupErr = lastBackup, err
+}
+
+// newestSnapshotTime returns the creation time of the newest of the latest snapshots, or the zero time if there are none.
+func newestSnapshotTime(latestSnapshots *brclient.LatestSnapshots) time.Time {
+	var newest time.Time
+	if latestSnapshots == nil {
+		return newest
+	}
+	for _, snapshot := range append([]*brclient.Snapshot{latestSnapshots.FullSnapshot}, latestSnapshots.DeltaSnapshots...) {
+		if snapshot != nil && snapshot.CreatedOn.After(newest) {
+			newest = snapshot.CreatedOn
+		}
+	}
+	return newest
+}
+
+// checkSafety returns whether the member is safe to disrupt, i.e. whether the latest snapshot is at most as old as the
+// configured max backup age.
+func (a *Application) checkSafety(now time.Time) safetyResponse {
+	maxAge := a.Config.BackupFreshness.MaxAge
+	response := safetyResponse{MaxBackupAge: maxAge.String()}
+	a.lastBackupMu.RLock()
+	lastBackup, lastBackupErr := a.lastBackup, a.lastBackupErr
+	a.lastBackupMu.RUnlock()
+	if !lastBackup.IsZero() {
+		response.LastBackup = &lastBackup
+		response.BackupAge = now.Sub(lastBackup).Truncate(time.Second).String()
+	}
+	switch {
+	case lastBackup.IsZero() && lastBackupErr != nil:
+		response.Reason = lastBackupErr.Error()
+	case lastBackup.IsZero():
+		response.Reason = errBackupNotFetched.Error()
+	case now.Sub(lastBackup) > maxAge:
+		response.Reason = fmt.Sprintf("latest snapshot is older than %s", maxAge)
+	default:
+		response.Safe = true
+	}
+	return response
+}
+
+// safetyHandler responds with 200 if the member is safe to disrupt and with 503 otherwise, with the details as JSON.
+func (a *Application) safetyHandler(w http.ResponseWriter, _ *http.Request) {
+	if a.Config.BackupFreshness.MaxAge <= 0 {
+		http.Error(w, "safety endpoint is disabled", http.StatusNotFound)
+		return
+	}
+	response := a.checkSafety(time.Now())
+	w.Header().Set("Content-Type", "application/json")
+	if !response.Safe {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		a.logger.Error("failed to write safety response", zap.Error(err))
+	}
+}
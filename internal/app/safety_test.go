@@ -0,0 +1,71 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/brclient"
+	"github.com/gardener/etcd-wrapper/internal/types"
+
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestSafetyHandler(t *testing.T) {
+	now := time.Now()
+	fresh := &brclient.LatestSnapshots{
+		FullSnapshot:   &brclient.Snapshot{Kind: "Full", CreatedOn: now.Add(-2 * time.Hour)},
+		DeltaSnapshots: []*brclient.Snapshot{{Kind: "Incr", CreatedOn: now.Add(-time.Minute)}},
+	}
+	stale := &brclient.LatestSnapshots{FullSnapshot: &brclient.Snapshot{Kind: "Full", CreatedOn: now.Add(-2 * time.Hour)}}
+
+	table := []struct {
+		description        string
+		maxAge             time.Duration
+		fetches            []*brclient.FakeClient
+		expectedStatusCode int
+		expectedReason     string
+	}{
+		{"should respond with 404 if the safety endpoint is disabled", 0, nil, http.StatusNotFound, ""},
+		{"should be unsafe before the latest snapshots have been fetched", time.Hour, nil, http.StatusServiceUnavailable, errBackupNotFetched.Error()},
+		{"should be safe if a delta snapshot is fresh", time.Hour, []*brclient.FakeClient{{LatestSnapshots: fresh}}, http.StatusOK, ""},
+		{"should be unsafe if the latest snapshot is stale", time.Hour, []*brclient.FakeClient{{LatestSnapshots: stale}}, http.StatusServiceUnavailable, "latest snapshot is older than 1h0m0s"},
+		{"should be unsafe if no snapshot has been taken", time.Hour, []*brclient.FakeClient{{}}, http.StatusServiceUnavailable, errNoBackup.Error()},
+		{"should be unsafe if backup-restore cannot be reached", time.Hour, []*brclient.FakeClient{{LatestSnapshotsErr: errors.New("connection refused")}}, http.StatusServiceUnavailable, "failed to fetch latest snapshots from backup-restore: connection refused"},
+		{"should keep the last known snapshot if backup-restore cannot be reached", time.Hour, []*brclient.FakeClient{{LatestSnapshots: fresh}, {LatestSnapshotsErr: errors.New("connection refused")}}, http.StatusOK, ""},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		app := &Application{
+			ctx:    context.Background(),
+			Config: types.Config{BackupFreshness: types.BackupFreshnessConfig{MaxAge: entry.maxAge, PollInterval: time.Minute}},
+			logger: zaptest.NewLogger(t),
+		}
+		for _, brClient := range entry.fetches {
+			app.brClient = brClient
+			app.fetchLastBackup()
+		}
+		recorder := httptest.NewRecorder()
+		app.safetyHandler(recorder, httptest.NewRequest(http.MethodGet, "/safetyz", nil))
+		g.Expect(recorder.Code).To(Equal(entry.expectedStatusCode))
+		if entry.expectedStatusCode == http.StatusNotFound {
+			continue
+		}
+		response := safetyResponse{}
+		g.Expect(json.NewDecoder(recorder.Body).Decode(&response)).To(Succeed())
+		g.Expect(response.Safe).To(Equal(entry.expectedStatusCode == http.StatusOK))
+		g.Expect(response.Reason).To(Equal(entry.expectedReason))
+		g.Expect(response.MaxBackupAge).To(Equal(entry.maxAge.String()))
+	}
+}
@@ -0,0 +1,47 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"github.com/gardener/etcd-wrapper/internal/types"
+
+	"go.etcd.io/etcd/embed"
+	"go.uber.org/zap"
+)
+
+// applyServerTuning overrides the gRPC server settings of the etcd configuration with the ones configured via
+// etcd-wrapper flags, if any.
+func (a *Application) applyServerTuning(cfg *embed.Config) {
+	tuning := a.Config.ServerTuning
+	if tuning.MaxConcurrentStreams > 0 {
+		cfg.MaxConcurrentStreams = uint32(tuning.MaxConcurrentStreams) // #nosec G115 -- validated to fit into uint32.
+	}
+	if tuning.MaxRequestBytes > 0 {
+		cfg.MaxRequestBytes = tuning.MaxRequestBytes
+	}
+	if tuning.MaxTxnOps > 0 {
+		cfg.MaxTxnOps = tuning.MaxTxnOps
+	}
+	if tuning.GRPCKeepAliveMinTime > 0 {
+		cfg.GRPCKeepAliveMinTime = tuning.GRPCKeepAliveMinTime
+	}
+	if tuning.GRPCKeepAliveInterval > 0 {
+		cfg.GRPCKeepAliveInterval = tuning.GRPCKeepAliveInterval
+	}
+	if tuning.GRPCKeepAliveTimeout > 0 {
+		cfg.GRPCKeepAliveTimeout = tuning.GRPCKeepAliveTimeout
+	}
+	a.logger.Info("Configured gRPC server of etcd",
+		zap.Uint32("maxConcurrentStreams", cfg.MaxConcurrentStreams),
+		zap.Uint("maxRequestBytes", cfg.MaxRequestBytes),
+		zap.Uint("maxTxnOps", cfg.MaxTxnOps),
+		zap.Duration("grpcKeepAliveMinTime", cfg.GRPCKeepAliveMinTime),
+		zap.Duration("grpcKeepAliveInterval", cfg.GRPCKeepAliveInterval),
+		zap.Duration("grpcKeepAliveTimeout", cfg.GRPCKeepAliveTimeout))
+	if cfg.MaxRequestBytes < types.KubeAPIServerMinRequestBytes {
+		a.logger.Warn("max request bytes of etcd is below the size of objects kube-apiserver stores, writes of large objects will be rejected",
+			zap.Uint("maxRequestBytes", cfg.MaxRequestBytes), zap.Int("kubeAPIServerMinRequestBytes", types.KubeAPIServerMinRequestBytes))
+	}
+}
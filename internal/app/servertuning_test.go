@@ -0,0 +1,53 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/types"
+
+	. "github.com/onsi/gomega"
+	"go.etcd.io/etcd/embed"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestApplyServerTuning(t *testing.T) {
+	table := []struct {
+		description                   string
+		tuning                        types.ServerTuningConfig
+		expectedMaxConcurrentStreams  uint32
+		expectedMaxRequestBytes       uint
+		expectedMaxTxnOps             uint
+		expectedGRPCKeepAliveInterval time.Duration
+		expectedGRPCKeepAliveTimeout  time.Duration
+	}{
+		{"should keep settings of the etcd configuration when nothing is configured", types.ServerTuningConfig{}, 1000, 2 * 1024 * 1024, embed.DefaultMaxTxnOps, time.Hour, 10 * time.Second},
+		{"should override settings of the etcd configuration", types.ServerTuningConfig{MaxConcurrentStreams: 5000, MaxRequestBytes: 8 * 1024 * 1024, MaxTxnOps: 512, GRPCKeepAliveInterval: 30 * time.Second, GRPCKeepAliveTimeout: 5 * time.Second}, 5000, 8 * 1024 * 1024, 512, 30 * time.Second, 5 * time.Second},
+		{"should only override configured settings", types.ServerTuningConfig{GRPCKeepAliveInterval: 30 * time.Second}, 1000, 2 * 1024 * 1024, embed.DefaultMaxTxnOps, 30 * time.Second, 10 * time.Second},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		cfg := embed.NewConfig()
+		cfg.MaxConcurrentStreams = 1000
+		cfg.MaxRequestBytes = 2 * 1024 * 1024
+		cfg.GRPCKeepAliveInterval = time.Hour
+		cfg.GRPCKeepAliveTimeout = 10 * time.Second
+		app := &Application{
+			Config: types.Config{ServerTuning: entry.tuning},
+			logger: zaptest.NewLogger(t),
+		}
+		app.applyServerTuning(cfg)
+		g.Expect(cfg.MaxConcurrentStreams).To(Equal(entry.expectedMaxConcurrentStreams))
+		g.Expect(cfg.MaxRequestBytes).To(Equal(entry.expectedMaxRequestBytes))
+		g.Expect(cfg.MaxTxnOps).To(Equal(entry.expectedMaxTxnOps))
+		g.Expect(cfg.GRPCKeepAliveMinTime).To(Equal(embed.DefaultGRPCKeepAliveMinTime))
+		g.Expect(cfg.GRPCKeepAliveInterval).To(Equal(entry.expectedGRPCKeepAliveInterval))
+		g.Expect(cfg.GRPCKeepAliveTimeout).To(Equal(entry.expectedGRPCKeepAliveTimeout))
+	}
+}
@@ -0,0 +1,61 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/brclient"
+
+	"go.uber.org/zap"
+)
+
+// watchBackupRestoreCABundle periodically checks whether the CA bundle of backup-restore has been rotated and, if so,
+// reloads it into the backup-restore client, so that new connections are verified with the rotated bundle without a
+// restart. It stops when the application context is cancelled.
+func (a *Application) watchBackupRestoreCABundle() {
+	brConfig := a.Config.BackupRestore
+	if !brConfig.TLSEnabled || brConfig.CaCertBundlePath == "" || brConfig.CAReloadInterval <= 0 {
+		return
+	}
+	reloader, ok := a.brClient.(brclient.CABundleReloader)
+	if !ok {
+		a.logger.Warn("backup-restore client does not support reloading the CA bundle, rotations require a restart")
+		return
+	}
+	detector := &fileChangeDetector{path: brConfig.CaCertBundlePath}
+	if _, err := detector.changed(); err != nil {
+		a.logger.Error("failed to read backup-restore CA bundle, rotations will not be detected", zap.Error(err))
+		return
+	}
+	ticker := time.NewTicker(brConfig.CAReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-ticker.C:
+			a.reloadBackupRestoreCABundle(detector, reloader)
+		}
+	}
+}
+
+// reloadBackupRestoreCABundle reloads the CA bundle of backup-restore if detector reports a change.
+func (a *Application) reloadBackupRestoreCABundle(detector *fileChangeDetector, reloader brclient.CABundleReloader) {
+	changed, err := detector.changed()
+	if err != nil {
+		a.logger.Error("failed to read backup-restore CA bundle", zap.Error(err))
+		return
+	}
+	if !changed {
+		return
+	}
+	if err = reloader.ReloadCABundle(); err != nil {
+		a.logger.Error("failed to reload backup-restore CA bundle", zap.String("path", detector.path), zap.Error(err))
+		return
+	}
+	a.logger.Info("backup-restore CA bundle has been rotated and reloaded", zap.String("path", detector.path))
+}
@@ -0,0 +1,58 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap/zaptest"
+)
+
+type fakeCABundleReloader struct {
+	reloads int
+	err     error
+}
+
+func (r *fakeCABundleReloader) ReloadCABundle() error {
+	r.reloads++
+	return r.err
+}
+
+func TestReloadBackupRestoreCABundle(t *testing.T) {
+	g := NewWithT(t)
+	path := filepath.Join(t.TempDir(), "ca.crt")
+	g.Expect(os.WriteFile(path, []byte("old CA"), 0600)).To(Succeed())
+	app := &Application{ctx: context.Background(), logger: zaptest.NewLogger(t)}
+	detector := &fileChangeDetector{path: path}
+	_, err := detector.changed()
+	g.Expect(err).ToNot(HaveOccurred())
+	reloader := &fakeCABundleReloader{}
+
+	t.Log("should not reload an unchanged CA bundle")
+	app.reloadBackupRestoreCABundle(detector, reloader)
+	g.Expect(reloader.reloads).To(Equal(0))
+
+	t.Log("should reload a rotated CA bundle once")
+	g.Expect(os.WriteFile(path, []byte("new CA"), 0600)).To(Succeed())
+	app.reloadBackupRestoreCABundle(detector, reloader)
+	app.reloadBackupRestoreCABundle(detector, reloader)
+	g.Expect(reloader.reloads).To(Equal(1))
+
+	t.Log("should not reload a CA bundle which cannot be read")
+	g.Expect(os.Remove(path)).To(Succeed())
+	app.reloadBackupRestoreCABundle(detector, reloader)
+	g.Expect(reloader.reloads).To(Equal(1))
+
+	t.Log("should survive a failing reload")
+	g.Expect(os.WriteFile(path, []byte("newer CA"), 0600)).To(Succeed())
+	reloader.err = errors.New("invalid CA bundle")
+	app.reloadBackupRestoreCABundle(detector, reloader)
+	g.Expect(reloader.reloads).To(Equal(2))
+}
@@ -0,0 +1,64 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/brclient"
+
+	"go.uber.org/zap"
+)
+
+// watchBackupRestoreHostPort periodically reads the file publishing the host and port of backup-restore and, once they
+// have changed, moves the backup-restore client to them, so that backup-restore can move without a restart. It stops
+// when the application context is cancelled.
+func (a *Application) watchBackupRestoreHostPort() {
+	if a.Config.BackupRestore.HostPortFile == "" || a.Config.BackupRestore.HostPortRefreshInterval <= 0 {
+		return
+	}
+	updater, ok := a.brClient.(brclient.HostPortUpdater)
+	if !ok {
+		a.logger.Warn("backup-restore client does not support updating the host and port, changes require a restart")
+		return
+	}
+	hostPort := a.Config.BackupRestore.HostPort
+	ticker := time.NewTicker(a.Config.BackupRestore.HostPortRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-ticker.C:
+			hostPort = a.refreshBackupRestoreHostPort(updater, hostPort)
+		}
+	}
+}
+
+// refreshBackupRestoreHostPort moves the backup-restore client to the host and port published in the file, if they
+// differ from current and are valid. It returns the host and port the client uses afterwards.
+func (a *Application) refreshBackupRestoreHostPort(updater brclient.HostPortUpdater, current string) string {
+	brConfig := a.Config.BackupRestore
+	hostPort, err := brConfig.DiscoverHostPort()
+	if err != nil {
+		a.logger.Error("failed to discover host and port of backup-restore", zap.Error(err))
+		return current
+	}
+	if hostPort == current {
+		return current
+	}
+	brConfig.HostPort = hostPort
+	if err = brConfig.Validate(); err != nil {
+		a.logger.Error("ignoring invalid host and port of backup-restore", zap.String("hostPort", hostPort), zap.Error(err))
+		return current
+	}
+	if err = updater.UpdateHostPort(hostPort); err != nil {
+		a.logger.Error("failed to update host and port of backup-restore", zap.String("hostPort", hostPort), zap.Error(err))
+		return current
+	}
+	a.logger.Info("backup-restore has moved", zap.String("previousHostPort", current), zap.String("hostPort", hostPort))
+	return hostPort
+}
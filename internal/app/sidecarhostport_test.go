@@ -0,0 +1,59 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gardener/etcd-wrapper/internal/types"
+
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap/zaptest"
+)
+
+type fakeHostPortUpdater struct {
+	hostPorts []string
+	err       error
+}
+
+func (u *fakeHostPortUpdater) UpdateHostPort(hostPort string) error {
+	u.hostPorts = append(u.hostPorts, hostPort)
+	return u.err
+}
+
+func TestRefreshBackupRestoreHostPort(t *testing.T) {
+	table := []struct {
+		description       string
+		content           string
+		updateErr         error
+		expectedHostPort  string
+		expectedHostPorts []string
+	}{
+		{"should keep an unchanged host and port", "backup-restore:8080\n", nil, "backup-restore:8080", nil},
+		{"should move to a changed host and port", "backup-restore-2:9090\n", nil, "backup-restore-2:9090", []string{"backup-restore-2:9090"}},
+		{"should ignore an invalid host and port", "https://backup-restore-2:9090", nil, "backup-restore:8080", nil},
+		{"should keep the host and port if the file cannot be read", "", nil, "backup-restore:8080", nil},
+		{"should keep the host and port if the client cannot be updated", "backup-restore-2:9090", errors.New("not supported"), "backup-restore:8080", []string{"backup-restore-2:9090"}},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		path := filepath.Join(t.TempDir(), "host-port")
+		if entry.content != "" {
+			g.Expect(os.WriteFile(path, []byte(entry.content), 0600)).To(Succeed())
+		}
+		app := &Application{
+			Config: types.Config{BackupRestore: types.BackupRestoreConfig{HostPort: "backup-restore:8080", HostPortFile: path}},
+			logger: zaptest.NewLogger(t),
+		}
+		updater := &fakeHostPortUpdater{err: entry.updateErr}
+		g.Expect(app.refreshBackupRestoreHostPort(updater, "backup-restore:8080")).To(Equal(entry.expectedHostPort))
+		g.Expect(updater.hostPorts).To(Equal(entry.expectedHostPorts))
+	}
+}
@@ -0,0 +1,38 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+
+	"github.com/gardener/etcd-wrapper/internal/brclient"
+
+	"go.uber.org/zap"
+)
+
+// snapshotOnShutdown requests a final snapshot from backup-restore before etcd is stopped on shutdown, which reduces
+// the window of data loss during planned restarts. It waits for the confirmation of backup-restore for at most the
+// configured timeout. A failure is only logged since it must not block the shutdown.
+func (a *Application) snapshotOnShutdown() {
+	kind := a.Config.SnapshotOnShutdown.Kind
+	// only take a snapshot if shutdown has been requested, and not if etcd has stopped by itself.
+	if kind == "" || a.ctx.Err() == nil {
+		return
+	}
+	ctx, cancelFunc := context.WithTimeout(context.Background(), a.Config.SnapshotOnShutdown.Timeout)
+	defer cancelFunc()
+
+	a.logger.Info("requesting snapshot from backup-restore before stopping etcd", zap.String("kind", kind))
+	snapshot, err := a.brClient.TriggerSnapshot(ctx, brclient.SnapshotKind(kind))
+	if err != nil {
+		a.logger.Error("failed to take snapshot before stopping etcd", zap.String("kind", kind), zap.Error(err))
+		return
+	}
+	if snapshot == nil {
+		a.logger.Info("no snapshot taken before stopping etcd, there are no changes since the last snapshot", zap.String("kind", kind))
+		return
+	}
+	a.logger.Info("snapshot taken before stopping etcd", zap.String("snapName", snapshot.SnapName), zap.Int64("lastRevision", snapshot.LastRevision))
+}
@@ -0,0 +1,55 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/brclient"
+	"github.com/gardener/etcd-wrapper/internal/types"
+
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestSnapshotOnShutdown(t *testing.T) {
+	table := []struct {
+		description   string
+		kind          string
+		shutdown      bool
+		snapshotErr   error
+		expectedKinds []brclient.SnapshotKind
+	}{
+		{"should not request a snapshot when snapshot on shutdown is disabled", "", true, nil, nil},
+		{"should not request a snapshot when etcd has stopped without a shutdown request", types.SnapshotKindDelta, false, nil, nil},
+		{"should request a delta snapshot on shutdown", types.SnapshotKindDelta, true, nil, []brclient.SnapshotKind{brclient.DeltaSnapshotKind}},
+		{"should request a full snapshot on shutdown", types.SnapshotKindFull, true, nil, []brclient.SnapshotKind{brclient.FullSnapshotKind}},
+		{"should not fail when the snapshot fails", types.SnapshotKindFull, true, errors.New("not the leading member"), []brclient.SnapshotKind{brclient.FullSnapshotKind}},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		if entry.shutdown {
+			cancel()
+		}
+		brClient := &brclient.FakeClient{TriggerSnapshotErr: entry.snapshotErr}
+		app := &Application{
+			ctx:      ctx,
+			cancelFn: cancel,
+			Config:   types.Config{SnapshotOnShutdown: types.SnapshotOnShutdownConfig{Kind: entry.kind, Timeout: time.Second}},
+			brClient: brClient,
+			logger:   zaptest.NewLogger(t),
+		}
+
+		app.snapshotOnShutdown()
+		g.Expect(brClient.TriggeredSnapshotKinds).To(Equal(entry.expectedKinds))
+		cancel()
+	}
+}
@@ -0,0 +1,53 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/state"
+	"github.com/gardener/etcd-wrapper/internal/util"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// stateFileKeyPrefix is the prefix of all keys written into the state file.
+	stateFileKeyPrefix = "etcd-wrapper.gardener.cloud/"
+	// stateFileKeyState is the key of the current state of etcd-wrapper in the state file.
+	stateFileKeyState = stateFileKeyPrefix + "state"
+	// stateFileKeyStateSince is the key of the time since when etcd-wrapper is in its current state in the state file.
+	stateFileKeyStateSince = stateFileKeyPrefix + "state-since"
+	// stateFileKeyReady is the key of the readiness of etcd in the state file.
+	stateFileKeyReady = stateFileKeyPrefix + "ready"
+)
+
+// writeStateFile writes the current state of etcd-wrapper and the readiness of etcd into the state file, if one has
+// been configured. The file uses the format of the annotations file of the Kubernetes downward API, i.e. one
+// `key="value"` pair per line, and is replaced atomically so that readers never observe a partially written file.
+func (a *Application) writeStateFile() {
+	if a.Config.StateFilePath == "" {
+		return
+	}
+	currentState, since := a.stateMachine.Current()
+	if err := writeStateFile(a.Config.StateFilePath, currentState, since, a.etcdReady.Load()); err != nil {
+		a.logger.Error("failed to write state file", zap.String("path", a.Config.StateFilePath), zap.Error(err))
+	}
+}
+
+func writeStateFile(path string, currentState state.State, since time.Time, ready bool) error {
+	var content strings.Builder
+	for _, kv := range [][2]string{
+		{stateFileKeyState, string(currentState)},
+		{stateFileKeyStateSince, since.UTC().Format(time.RFC3339)},
+		{stateFileKeyReady, strconv.FormatBool(ready)},
+	} {
+		content.WriteString(fmt.Sprintf("%s=%s\n", kv[0], strconv.Quote(kv[1])))
+	}
+	return util.WriteFileAtomic(path, []byte(content.String()), 0644) // #nosec G306 -- the state file is meant to be read by other containers of the pod.
+}
@@ -0,0 +1,41 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/state"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestWriteStateFile(t *testing.T) {
+	g := NewWithT(t)
+	path := filepath.Join(t.TempDir(), "etcd-wrapper-state")
+	since := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Log("should write state and readiness in the format of the downward API annotations file")
+	g.Expect(writeStateFile(path, state.StartingEtcd, since, false)).To(Succeed())
+	content, err := os.ReadFile(path)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(string(content)).To(Equal(`etcd-wrapper.gardener.cloud/state="StartingEtcd"
+etcd-wrapper.gardener.cloud/state-since="2024-01-01T12:00:00Z"
+etcd-wrapper.gardener.cloud/ready="false"
+`))
+
+	t.Log("should replace the state file")
+	g.Expect(writeStateFile(path, state.Ready, since.Add(time.Minute), true)).To(Succeed())
+	content, err = os.ReadFile(path)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(string(content)).To(ContainSubstring(`etcd-wrapper.gardener.cloud/state="Ready"`))
+	g.Expect(string(content)).To(ContainSubstring(`etcd-wrapper.gardener.cloud/ready="true"`))
+	entries, err := os.ReadDir(filepath.Dir(path))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(entries).To(HaveLen(1))
+}
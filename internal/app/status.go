@@ -0,0 +1,98 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/maintenance"
+	"github.com/gardener/etcd-wrapper/internal/state"
+
+	"go.uber.org/zap"
+)
+
+// Status is the status of the Application.
+type Status struct {
+	// State is the current state of the Application.
+	State state.State `json:"state"`
+	// StateSince is the time since when the Application is in State.
+	StateSince time.Time `json:"stateSince"`
+	// Transitions are all state transitions of the Application, oldest first.
+	Transitions []state.Transition `json:"transitions,omitempty"`
+	// EtcdRunning indicates whether the embedded etcd has been started and not yet stopped.
+	EtcdRunning bool `json:"etcdRunning"`
+	// EtcdReady indicates whether the embedded etcd is ready to serve client requests.
+	EtcdReady bool `json:"etcdReady"`
+	// Restarts is the number of times the embedded etcd has been restarted.
+	Restarts int `json:"restarts"`
+	// CorruptionAlarm indicates whether etcd reports an active corruption alarm.
+	CorruptionAlarm bool `json:"corruptionAlarm"`
+	// ProposalBackpressure indicates whether sustained raft proposal backpressure is detected.
+	ProposalBackpressure bool `json:"proposalBackpressure"`
+	// ApplyLag indicates whether a sustained divergence between the committed and applied raft index is detected.
+	ApplyLag bool `json:"applyLag"`
+	// HotStandby indicates whether the member runs in hot-standby mode.
+	HotStandby bool `json:"hotStandby"`
+	// Learner indicates whether the member is a raft learner.
+	Learner bool `json:"learner"`
+	// MaintenanceLeader indicates whether etcd-wrapper is the elected maintenance leader.
+	MaintenanceLeader bool `json:"maintenanceLeader"`
+	// HealthScore is the health score of the member. It is nil if the health score is disabled or etcd has not been
+	// probed yet.
+	HealthScore *HealthScore `json:"healthScore,omitempty"`
+	// Membership is the membership of the etcd cluster as seen by the local member. It is nil if etcd is not running.
+	Membership *Membership `json:"membership,omitempty"`
+	// LastValidation is the result of the last on-demand validation of the data directory, nil if none has been requested.
+	LastValidation *ValidationResult `json:"lastValidation,omitempty"`
+	// QueuedMaintenance are the disruptive operations which wait for the maintenance window to open.
+	QueuedMaintenance []maintenance.QueuedOperation `json:"queuedMaintenance,omitempty"`
+	// ClientURLsError is the error of the last self-test of the advertised client URLs, empty if it has succeeded.
+	ClientURLsError string `json:"clientURLsError,omitempty"`
+	// ReadinessGatesError is the error of the last evaluation of the readiness gates, empty if all have passed.
+	ReadinessGatesError string `json:"readinessGatesError,omitempty"`
+}
+
+// Status returns the current Status of the application.
+func (a *Application) Status() Status {
+	currentState, since := a.stateMachine.Current()
+	var clientURLsError string
+	if err := a.getClientURLsErr(); err != nil {
+		clientURLsError = err.Error()
+	}
+	var readinessGatesError string
+	if err := a.getReadinessGatesErr(); err != nil {
+		readinessGatesError = err.Error()
+	}
+	return Status{
+		State:                currentState,
+		StateSince:           since,
+		Transitions:          a.stateMachine.Transitions(),
+		EtcdRunning:          a.etcdRunning(),
+		EtcdReady:            a.etcdReady.Load(),
+		Restarts:             int(a.restarts.Load()),
+		CorruptionAlarm:      a.corruptionAlarm.Load(),
+		ClientURLsError:      clientURLsError,
+		ReadinessGatesError:  readinessGatesError,
+		ProposalBackpressure: a.proposalBackpressure.Load(),
+		ApplyLag:             a.applyLag.Load(),
+		HotStandby:           a.Config.HotStandby,
+		Learner:              a.learner.Load(),
+		MaintenanceLeader:    a.maintenanceLeader.Load(),
+		HealthScore:          a.getHealthScore(),
+		Membership:           a.membership(),
+		LastValidation:       a.getLastValidation(),
+		QueuedMaintenance:    a.maintenance.Queued(),
+	}
+}
+
+// statusHandler writes the current Status of the application as JSON onto the http.ResponseWriter.
+func (a *Application) statusHandler(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(a.Status()); err != nil {
+		a.logger.Error("failed to write status response", zap.Error(err))
+	}
+}
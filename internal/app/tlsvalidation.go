@@ -0,0 +1,88 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/tlscheck"
+
+	"go.uber.org/zap"
+)
+
+// validateTLSCertificates checks the certificates of the client and peer listeners of etcd and of the external client
+// listener before etcd is started, so that expired certificates, missing key usages or advertised URLs which are not
+// covered by the SANs fail with precise messages instead of TLS handshake errors of etcd and its peers.
+func (a *Application) validateTLSCertificates() error {
+	if a.Config.TLSValidation.Disabled {
+		return nil
+	}
+	pairs := a.tlsPairs()
+	var errs []error
+	for _, pair := range pairs {
+		errs = append(errs, tlscheck.Check(pair, time.Now(), a.Config.TLSValidation.MinValidity))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("TLS validation failed: %w", err)
+	}
+	if len(pairs) > 0 {
+		a.logger.Info("TLS validation succeeded", zap.Int("certificates", len(pairs)))
+	}
+	return nil
+}
+
+// tlsPairs returns the certificate and key pairs configured for etcd and etcd-wrapper together with their requirements.
+// Certificates generated by etcd itself are skipped.
+func (a *Application) tlsPairs() []tlscheck.Pair {
+	var pairs []tlscheck.Pair
+	if !a.cfg.ClientAutoTLS && a.cfg.ClientTLSInfo.CertFile != "" {
+		pairs = append(pairs, tlscheck.Pair{
+			Name:         "client",
+			CertPath:     a.cfg.ClientTLSInfo.CertFile,
+			KeyPath:      a.cfg.ClientTLSInfo.KeyFile,
+			ExtKeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+			Hosts:        tlsHosts(a.cfg.AdvertiseClientUrls),
+		})
+	}
+	if !a.cfg.PeerAutoTLS && a.cfg.PeerTLSInfo.CertFile != "" {
+		// peers present their certificate both as server and as client, and verify it against the same CA bundle.
+		hosts := tlsHosts(a.cfg.AdvertisePeerUrls)
+		if a.cfg.PeerTLSInfo.ServerName != "" {
+			hosts = []string{a.cfg.PeerTLSInfo.ServerName}
+		}
+		pairs = append(pairs, tlscheck.Pair{
+			Name:          "peer",
+			CertPath:      a.cfg.PeerTLSInfo.CertFile,
+			KeyPath:       a.cfg.PeerTLSInfo.KeyFile,
+			TrustedCAPath: a.cfg.PeerTLSInfo.TrustedCAFile,
+			ExtKeyUsages:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+			Hosts:         hosts,
+		})
+	}
+	if a.Config.ExternalClientListener.URL != "" {
+		pairs = append(pairs, tlscheck.Pair{
+			Name:         "external client listener",
+			CertPath:     a.Config.ExternalClientListener.CertPath,
+			KeyPath:      a.Config.ExternalClientListener.KeyPath,
+			ExtKeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		})
+	}
+	return pairs
+}
+
+// tlsHosts returns the hosts of the URLs served with TLS.
+func tlsHosts(urls []url.URL) []string {
+	var hosts []string
+	for _, u := range urls {
+		if u.Scheme == "https" || u.Scheme == "unixs" {
+			hosts = append(hosts, u.Hostname())
+		}
+	}
+	return hosts
+}
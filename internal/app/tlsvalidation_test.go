@@ -0,0 +1,65 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"crypto/x509"
+	"net/url"
+	"testing"
+
+	"github.com/gardener/etcd-wrapper/internal/types"
+
+	. "github.com/onsi/gomega"
+	"go.etcd.io/etcd/embed"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestTLSPairs(t *testing.T) {
+	parseURL := func(s string) url.URL {
+		u, _ := url.Parse(s)
+		return *u
+	}
+	table := []struct {
+		description   string
+		modify        func(*Application)
+		expectedNames []string
+		expectedHosts [][]string
+	}{
+		{"should skip listeners without certificates", func(*Application) {}, nil, nil},
+		{"should require the hosts of advertised https URLs only", func(a *Application) {
+			a.cfg.ClientTLSInfo.CertFile = "client.crt"
+			a.cfg.AdvertiseClientUrls = []url.URL{parseURL("https://etcd-main-client:2379"), parseURL("http://localhost:2379")}
+			a.cfg.PeerTLSInfo.CertFile = "peer.crt"
+			a.cfg.AdvertisePeerUrls = []url.URL{parseURL("https://etcd-main-0.etcd-main-peer:2380")}
+		}, []string{"client", "peer"}, [][]string{{"etcd-main-client"}, {"etcd-main-0.etcd-main-peer"}}},
+		{"should require the peer TLS server name instead of the advertised peer URLs", func(a *Application) {
+			a.cfg.PeerTLSInfo.CertFile = "peer.crt"
+			a.cfg.PeerTLSInfo.ServerName = "etcd-main-peer"
+			a.cfg.AdvertisePeerUrls = []url.URL{parseURL("https://10.0.0.1:2380")}
+		}, []string{"peer"}, [][]string{{"etcd-main-peer"}}},
+		{"should skip certificates generated by etcd", func(a *Application) {
+			a.cfg.ClientTLSInfo.CertFile = "client.crt"
+			a.cfg.ClientAutoTLS = true
+		}, nil, nil},
+		{"should include the external client listener", func(a *Application) {
+			a.Config.ExternalClientListener = types.ExternalClientListenerConfig{URL: "https://0.0.0.0:2479", CertPath: "external.crt", KeyPath: "external.key"}
+		}, []string{"external client listener"}, [][]string{nil}},
+	}
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		app := &Application{cfg: embed.NewConfig(), logger: zaptest.NewLogger(t)}
+		app.cfg.AdvertiseClientUrls = nil
+		app.cfg.AdvertisePeerUrls = nil
+		entry.modify(app)
+		pairs := app.tlsPairs()
+		g.Expect(pairs).To(HaveLen(len(entry.expectedNames)))
+		for i, pair := range pairs {
+			g.Expect(pair.Name).To(Equal(entry.expectedNames[i]))
+			g.Expect(pair.Hosts).To(Equal(entry.expectedHosts[i]))
+			g.Expect(pair.ExtKeyUsages).To(ContainElement(x509.ExtKeyUsageServerAuth))
+		}
+	}
+}
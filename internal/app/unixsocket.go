@@ -0,0 +1,40 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+
+	"go.etcd.io/etcd/embed"
+	"go.uber.org/zap"
+)
+
+// applyClientUnixSocket adds the unix socket to the client URLs on which etcd listens, if configured. The socket is
+// not advertised, since it is only reachable from within the pod.
+func (a *Application) applyClientUnixSocket(cfg *embed.Config) {
+	if a.Config.ClientUnixSocket.Path == "" {
+		return
+	}
+	cfg.ListenClientUrls = append(cfg.ListenClientUrls, url.URL{Scheme: "unix", Path: a.Config.ClientUnixSocket.Path})
+}
+
+// restrictClientUnixSocket sets the configured file mode on the unix socket, which etcd has created while starting.
+// Clients connecting via the socket are not authenticated by TLS, so the file mode gates access to etcd.
+func (a *Application) restrictClientUnixSocket() error {
+	if a.Config.ClientUnixSocket.Path == "" {
+		return nil
+	}
+	mode, err := a.Config.ClientUnixSocket.FileMode()
+	if err != nil {
+		return err
+	}
+	if err = os.Chmod(a.Config.ClientUnixSocket.Path, mode); err != nil {
+		return fmt.Errorf("failed to set file mode of client unix socket: %w", err)
+	}
+	a.logger.Info("Serving etcd on client unix socket", zap.String("path", a.Config.ClientUnixSocket.Path), zap.Stringer("mode", mode))
+	return nil
+}
@@ -0,0 +1,66 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/types"
+
+	. "github.com/onsi/gomega"
+	"go.etcd.io/etcd/clientv3"
+	"go.etcd.io/etcd/embed"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestClientUnixSocket(t *testing.T) {
+	g := NewWithT(t)
+	// the path of a unix socket is limited to about 100 bytes, which the test directories may exceed.
+	dir, err := os.MkdirTemp("", "etcd-wrapper")
+	g.Expect(err).ToNot(HaveOccurred())
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	socketPath := filepath.Join(dir, "etcd.sock")
+	app := &Application{
+		Config: types.Config{ClientUnixSocket: types.ClientUnixSocketConfig{Path: socketPath, Mode: "0600"}},
+		logger: zaptest.NewLogger(t),
+	}
+
+	etcd := startTestEtcd(t, g, app.applyClientUnixSocket)
+	g.Expect(etcd.Config().ListenClientUrls).To(HaveLen(2))
+	g.Expect(etcd.Config().AdvertiseClientUrls).To(HaveLen(1))
+
+	t.Log("should restrict the file mode of the socket")
+	g.Expect(app.restrictClientUnixSocket()).To(Succeed())
+	info, err := os.Stat(socketPath)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(info.Mode() & os.ModeSocket).ToNot(BeZero())
+	g.Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+
+	t.Log("should serve clients via the socket")
+	cli, err := clientv3.New(clientv3.Config{Endpoints: []string{"unix://" + socketPath}, DialTimeout: 5 * time.Second})
+	g.Expect(err).ToNot(HaveOccurred())
+	defer func() { _ = cli.Close() }()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err = cli.Put(ctx, "/unix", "value")
+	g.Expect(err).ToNot(HaveOccurred())
+}
+
+func TestApplyClientUnixSocket(t *testing.T) {
+	g := NewWithT(t)
+	app := &Application{logger: zaptest.NewLogger(t)}
+	cfg := embed.NewConfig()
+	app.applyClientUnixSocket(cfg)
+	g.Expect(cfg.ListenClientUrls).To(HaveLen(1))
+
+	app.Config.ClientUnixSocket.Path = "/var/etcd/etcd.sock"
+	app.applyClientUnixSocket(cfg)
+	g.Expect(cfg.ListenClientUrls).To(HaveLen(2))
+	g.Expect(cfg.ListenClientUrls[1].String()).To(Equal("unix:///var/etcd/etcd.sock"))
+}
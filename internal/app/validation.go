@@ -0,0 +1,182 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/audit"
+	"github.com/gardener/etcd-wrapper/internal/bootstrap"
+	"github.com/gardener/etcd-wrapper/internal/brclient"
+	"github.com/gardener/etcd-wrapper/internal/state"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// validationPollInterval is the interval in which the initialization status is polled during an on-demand validation.
+	validationPollInterval = time.Second
+	// maintenanceOperationValidation is the name under which on-demand validations are submitted to the maintenance scheduler.
+	maintenanceOperationValidation = "validation"
+)
+
+// errValidationInProgress is returned when an on-demand validation is requested while another one is in progress.
+var errValidationInProgress = errors.New("validation of data directory is already in progress")
+
+// ValidationResult is the result of an on-demand validation of the data directory.
+type ValidationResult struct {
+	// Mode is the validation mode requested from backup-restore.
+	Mode brclient.ValidationType `json:"mode"`
+	// StartedAt is the time at which the validation has started.
+	StartedAt time.Time `json:"startedAt"`
+	// CompletedAt is the time at which the validation has completed. It is nil while the validation is in progress.
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+	// Error is the error of the validation, empty if it has succeeded.
+	Error string `json:"error,omitempty"`
+}
+
+// RequestValidation requests an on-demand validation of the data directory by backup-restore. The embedded etcd is
+// stopped, the data directory is validated (and restored if it is found to be invalid), after which etcd is started
+// again. It returns once the validation has been requested, without waiting for it to complete. Since the validation
+// is disruptive, it is queued till the maintenance window opens if it is requested outside of it, in which case queued
+// is true.
+func (a *Application) RequestValidation(mode brclient.ValidationType) (queued bool, err error) {
+	if mode != brclient.FullValidation && mode != brclient.SanityValidation {
+		return false, fmt.Errorf("unsupported validation mode %q, must be one of: %s, %s", mode, brclient.FullValidation, brclient.SanityValidation)
+	}
+	if a.validationInProgress() {
+		return false, errValidationInProgress
+	}
+	return a.maintenance.Submit(maintenanceOperationValidation, func() error {
+		return a.requestValidation(mode)
+	})
+}
+
+// requestValidation marks a validation with the given mode as pending and restarts etcd to run it.
+func (a *Application) requestValidation(mode brclient.ValidationType) error {
+	a.validationMu.Lock()
+	if a.pendingValidation != "" {
+		a.validationMu.Unlock()
+		return errValidationInProgress
+	}
+	a.pendingValidation = mode
+	a.validationMu.Unlock()
+	if err := a.Restart(); err != nil {
+		a.validationMu.Lock()
+		a.pendingValidation = ""
+		a.validationMu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// validationInProgress returns true while an on-demand validation is pending or in progress.
+func (a *Application) validationInProgress() bool {
+	a.validationMu.Lock()
+	defer a.validationMu.Unlock()
+	return a.pendingValidation != ""
+}
+
+// getLastValidation returns the result of the last on-demand validation, nil if none has been requested.
+func (a *Application) getLastValidation() *ValidationResult {
+	a.validationMu.Lock()
+	defer a.validationMu.Unlock()
+	if a.lastValidation == nil {
+		return nil
+	}
+	result := *a.lastValidation
+	return &result
+}
+
+// runPendingValidation runs the pending on-demand validation, if any. It must only be called while etcd is stopped.
+func (a *Application) runPendingValidation() error {
+	a.validationMu.Lock()
+	mode := a.pendingValidation
+	if mode == "" {
+		a.validationMu.Unlock()
+		return nil
+	}
+	a.lastValidation = &ValidationResult{Mode: mode, StartedAt: time.Now().UTC()}
+	a.validationMu.Unlock()
+
+	err := a.validateDataDir(mode)
+
+	a.validationMu.Lock()
+	defer a.validationMu.Unlock()
+	completedAt := time.Now().UTC()
+	a.lastValidation.CompletedAt = &completedAt
+	if err != nil {
+		a.lastValidation.Error = err.Error()
+	}
+	a.pendingValidation = ""
+	return err
+}
+
+// validateDataDir triggers the initialization of the data directory by backup-restore with the given validation mode
+// and waits till it has succeeded, bounded by the restoration wait timeout.
+func (a *Application) validateDataDir(mode brclient.ValidationType) error {
+	a.logger.Info("Validating data directory on demand", zap.String("mode", string(mode)))
+	a.transitionTo(state.Validating)
+	var timeoutCh <-chan time.Time
+	if timeout := a.Config.PhaseTimeouts.RestorationWait; timeout > 0 {
+		timeoutCh = time.After(timeout)
+	}
+	if err := audit.Record(a.auditLogger, audit.OperationTriggerInitialization, string(mode), func() error {
+		return a.brClient.TriggerInitialization(a.ctx, mode)
+	}); err != nil {
+		return fmt.Errorf("failed to trigger validation of data directory: %w", err)
+	}
+	for {
+		initStatus, err := a.brClient.GetInitializationStatus(a.ctx)
+		switch {
+		case err != nil:
+			a.logger.Error("error while fetching initialization status", zap.Error(err))
+		case initStatus == brclient.Successful:
+			a.logger.Info("On-demand validation of data directory succeeded", zap.String("mode", string(mode)))
+			return nil
+		case initStatus == brclient.InProgress:
+			a.transitionTo(state.Restoring)
+		}
+		select {
+		case <-a.ctx.Done():
+			return a.ctx.Err()
+		case <-timeoutCh:
+			return &bootstrap.PhaseTimeoutError{Phase: bootstrap.PhaseRestorationWait, Timeout: a.Config.PhaseTimeouts.RestorationWait}
+		case <-time.After(validationPollInterval):
+		}
+	}
+}
+
+// validateHandler requests an on-demand validation of the data directory with the validation mode passed as `mode`
+// query parameter, which defaults to full validation.
+func (a *Application) validateHandler(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	mode := brclient.FullValidation
+	if m := req.URL.Query().Get("mode"); m != "" {
+		mode = brclient.ValidationType(m)
+	}
+	queued, err := a.RequestValidation(mode)
+	if err != nil {
+		status := http.StatusConflict
+		if mode != brclient.FullValidation && mode != brclient.SanityValidation {
+			status = http.StatusBadRequest
+		}
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(err.Error()))
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+	if queued {
+		_, _ = fmt.Fprintf(w, "validation queued till the maintenance window opens at %s", a.maintenance.NextOpening().Format(time.RFC3339))
+		return
+	}
+	a.logger.Info("received validation request, restarting etcd to validate the data directory", zap.String("mode", string(mode)))
+}
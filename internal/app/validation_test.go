@@ -0,0 +1,114 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/audit"
+	"github.com/gardener/etcd-wrapper/internal/brclient"
+	"github.com/gardener/etcd-wrapper/internal/maintenance"
+	"github.com/gardener/etcd-wrapper/internal/state"
+	"github.com/gardener/etcd-wrapper/internal/types"
+
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestRunPendingValidation(t *testing.T) {
+	table := []struct {
+		description     string
+		pending         brclient.ValidationType
+		fakeClient      *brclient.FakeClient
+		restorationWait time.Duration
+		expectError     bool
+		expectedModes   []brclient.ValidationType
+	}{
+		{"should do nothing when no validation is pending", "", &brclient.FakeClient{}, 0, false, nil},
+		{"should trigger validation and wait till it has succeeded", brclient.SanityValidation, &brclient.FakeClient{InitStatuses: []brclient.InitStatus{brclient.InProgress, brclient.Successful}}, 0, false, []brclient.ValidationType{brclient.SanityValidation}},
+		{"should return error when validation cannot be triggered", brclient.FullValidation, &brclient.FakeClient{TriggerInitializationErr: errors.New("unavailable")}, 0, true, []brclient.ValidationType{brclient.FullValidation}},
+		{"should return error when validation does not complete within the restoration wait timeout", brclient.FullValidation, &brclient.FakeClient{InitStatuses: []brclient.InitStatus{brclient.InProgress}}, time.Millisecond, true, []brclient.ValidationType{brclient.FullValidation}},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		logger := zaptest.NewLogger(t)
+		stateMachine := state.NewMachine(logger)
+		for _, s := range []state.State{state.ProbingSidecar, state.StartingEtcd, state.Ready} {
+			g.Expect(stateMachine.TransitionTo(s)).To(Succeed())
+		}
+		app := &Application{
+			ctx:               context.Background(),
+			Config:            types.Config{PhaseTimeouts: types.PhaseTimeoutsConfig{RestorationWait: entry.restorationWait}},
+			brClient:          entry.fakeClient,
+			auditLogger:       audit.NewNoopLogger(),
+			stateMachine:      stateMachine,
+			logger:            logger,
+			pendingValidation: entry.pending,
+		}
+
+		err := app.runPendingValidation()
+		g.Expect(err != nil).To(Equal(entry.expectError))
+		g.Expect(entry.fakeClient.TriggeredValidationTypes).To(Equal(entry.expectedModes))
+		g.Expect(app.validationInProgress()).To(BeFalse())
+		if entry.pending == "" {
+			g.Expect(app.getLastValidation()).To(BeNil())
+			continue
+		}
+		lastValidation := app.getLastValidation()
+		g.Expect(lastValidation.Mode).To(Equal(entry.pending))
+		g.Expect(lastValidation.CompletedAt).ToNot(BeNil())
+		g.Expect(lastValidation.Error != "").To(Equal(entry.expectError))
+	}
+}
+
+func TestValidateHandler(t *testing.T) {
+	// closedWindow opens half an hour from now and is thus closed for the duration of the test.
+	closedWindow, err := maintenance.NewWindow(fmt.Sprintf("%d * * * *", (time.Now().UTC().Minute()+30)%60), time.Minute)
+	NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+	table := []struct {
+		description     string
+		method          string
+		query           string
+		pending         brclient.ValidationType
+		window          *maintenance.Window
+		expectedStatus  int
+		expectedPending brclient.ValidationType
+		expectedQueued  int
+	}{
+		{"should reject requests other than POST", http.MethodGet, "", "", nil, http.StatusMethodNotAllowed, "", 0},
+		{"should reject unsupported validation mode", http.MethodPost, "?mode=deep", "", nil, http.StatusBadRequest, "", 0},
+		{"should reject validation while another one is in progress", http.MethodPost, "?mode=sanity", brclient.FullValidation, nil, http.StatusConflict, brclient.FullValidation, 0},
+		{"should reject validation when etcd is not running", http.MethodPost, "", "", nil, http.StatusConflict, "", 0},
+		{"should queue validation outside of the maintenance window", http.MethodPost, "?mode=sanity", "", closedWindow, http.StatusAccepted, "", 1},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		logger := zaptest.NewLogger(t)
+		app := &Application{
+			ctx:               context.Background(),
+			auditLogger:       audit.NewNoopLogger(),
+			logger:            logger,
+			pendingValidation: entry.pending,
+			restartCh:         make(chan struct{}),
+			maintenance:       maintenance.NewScheduler(entry.window, logger),
+		}
+		recorder := httptest.NewRecorder()
+		app.validateHandler(recorder, httptest.NewRequest(entry.method, "/validate"+entry.query, nil))
+		g.Expect(recorder.Code).To(Equal(entry.expectedStatus))
+		g.Expect(app.pendingValidation).To(Equal(entry.expectedPending))
+		g.Expect(app.maintenance.Queued()).To(HaveLen(entry.expectedQueued))
+	}
+}
@@ -0,0 +1,182 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/metrics"
+	"github.com/gardener/etcd-wrapper/internal/util"
+
+	"go.uber.org/zap"
+)
+
+// maintenanceOperationQuotaBackendRestart is the name of the restart applying the backend quota derived from a resized
+// data volume in the maintenance scheduler.
+const maintenanceOperationQuotaBackendRestart = "quota-backend-restart"
+
+// volumeSizeRecord is the size of the data volume recorded at the last start of etcd-wrapper.
+type volumeSizeRecord struct {
+	// SizeBytes is the size of the data volume in bytes.
+	SizeBytes int64 `json:"sizeBytes"`
+	// RecordedAt is the time at which the size has been recorded.
+	RecordedAt time.Time `json:"recordedAt"`
+}
+
+// volumeSize returns the size in bytes of the filesystem holding dir, or of its nearest existing parent directory if
+// dir does not exist yet.
+func volumeSize(dir string) (int64, error) {
+	for {
+		var stat syscall.Statfs_t
+		err := syscall.Statfs(dir, &stat)
+		if err == nil {
+			return int64(stat.Blocks) * int64(stat.Bsize), nil // #nosec G115 -- block counts and sizes of real filesystems fit into int64.
+		}
+		parent := filepath.Dir(dir)
+		if !errors.Is(err, syscall.ENOENT) || parent == dir {
+			return 0, err
+		}
+		dir = parent
+	}
+}
+
+// quotaBackendBytes returns the backend quota which is the configured percentage of the size of the data volume.
+func quotaBackendBytes(volumeSizeBytes int64, percentage float64) int64 {
+	return int64(float64(volumeSizeBytes) * percentage / 100)
+}
+
+// applyVolumeSize detects whether the data volume has been resized since the last start of etcd-wrapper and, if the
+// backend quota is configured as a percentage of the size of the data volume, sets the backend quota of etcd
+// accordingly.
+func (a *Application) applyVolumeSize() {
+	config := a.Config.VolumeResize
+	if config.SizeRecordPath == "" && config.QuotaBackendPercentage == 0 && config.CheckInterval <= 0 {
+		return
+	}
+	size, err := volumeSize(a.cfg.Dir)
+	if err != nil {
+		a.logger.Error("failed to determine size of data volume, backend quota is not derived from it", zap.String("dir", a.cfg.Dir), zap.Error(err))
+		return
+	}
+	metrics.DataVolumeSizeBytes.Set(float64(size))
+	a.volumeSizeBytes.Store(size)
+	if config.SizeRecordPath != "" {
+		previous, err := loadVolumeSizeRecord(config.SizeRecordPath)
+		if err != nil {
+			a.logger.Warn("failed to load recorded size of data volume, resizes since the last start are not detected", zap.Error(err))
+		} else if previous != nil && previous.SizeBytes != size {
+			a.logger.Info("data volume has been resized since the last start", zap.Int64("previousSizeBytes", previous.SizeBytes), zap.Int64("sizeBytes", size), zap.Time("recordedAt", previous.RecordedAt))
+		}
+		if err = writeVolumeSizeRecord(config.SizeRecordPath, volumeSizeRecord{SizeBytes: size, RecordedAt: time.Now().UTC()}); err != nil {
+			a.logger.Error("failed to record size of data volume", zap.String("path", config.SizeRecordPath), zap.Error(err))
+		}
+	}
+	if config.QuotaBackendPercentage == 0 {
+		return
+	}
+	quota := quotaBackendBytes(size, config.QuotaBackendPercentage)
+	if a.cfg.QuotaBackendBytes != quota {
+		a.logger.Info("setting backend quota of etcd from size of data volume", zap.Int64("configured", a.cfg.QuotaBackendBytes), zap.Int64("quotaBackendBytes", quota),
+			zap.Int64("volumeSizeBytes", size), zap.Float64("percentage", config.QuotaBackendPercentage))
+	}
+	a.cfg.QuotaBackendBytes = quota
+}
+
+// watchVolumeSize periodically checks whether the data volume has been resized while etcd is running. If the backend
+// quota is derived from the size of the data volume, the new backend quota is applied at the next restart of etcd,
+// which is requested in the maintenance window if configured. It stops when the application context is cancelled.
+func (a *Application) watchVolumeSize() {
+	config := a.Config.VolumeResize
+	if config.CheckInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(config.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-ticker.C:
+			a.checkVolumeSize()
+		}
+	}
+}
+
+// checkVolumeSize compares the size of the data volume with the size last seen and handles a resize.
+func (a *Application) checkVolumeSize() {
+	config := a.Config.VolumeResize
+	size, err := volumeSize(a.cfg.Dir)
+	if err != nil {
+		a.logger.Error("failed to determine size of data volume", zap.String("dir", a.cfg.Dir), zap.Error(err))
+		return
+	}
+	previous := a.volumeSizeBytes.Swap(size)
+	if previous == size {
+		return
+	}
+	metrics.DataVolumeSizeBytes.Set(float64(size))
+	a.logger.Info("data volume has been resized", zap.Int64("previousSizeBytes", previous), zap.Int64("sizeBytes", size))
+	if config.SizeRecordPath != "" {
+		if err = writeVolumeSizeRecord(config.SizeRecordPath, volumeSizeRecord{SizeBytes: size, RecordedAt: time.Now().UTC()}); err != nil {
+			a.logger.Error("failed to record size of data volume", zap.String("path", config.SizeRecordPath), zap.Error(err))
+		}
+	}
+	if config.QuotaBackendPercentage == 0 {
+		return
+	}
+	quota := quotaBackendBytes(size, config.QuotaBackendPercentage)
+	a.pendingQuotaBackendBytes.Store(quota)
+	if !config.RestartOnResize {
+		a.logger.Warn("backend quota of etcd derived from the resized data volume takes effect on the next restart of etcd", zap.Int64("quotaBackendBytes", quota))
+		return
+	}
+	a.logger.Info("restarting etcd to apply the backend quota derived from the resized data volume", zap.Int64("quotaBackendBytes", quota))
+	if _, err = a.maintenance.Submit(maintenanceOperationQuotaBackendRestart, a.coordinatedRestart); err != nil {
+		a.logger.Error("failed to restart etcd to apply the backend quota", zap.Error(err))
+	}
+}
+
+// applyPendingQuotaBackend sets the backend quota derived from a data volume resized while etcd was running. It must
+// only be called while etcd is stopped.
+func (a *Application) applyPendingQuotaBackend() {
+	quota := a.pendingQuotaBackendBytes.Swap(0)
+	if quota == 0 || quota == a.cfg.QuotaBackendBytes {
+		return
+	}
+	a.logger.Info("applying backend quota of etcd derived from the resized data volume", zap.Int64("previous", a.cfg.QuotaBackendBytes), zap.Int64("quotaBackendBytes", quota))
+	a.cfg.QuotaBackendBytes = quota
+}
+
+// loadVolumeSizeRecord loads the recorded size of the data volume. It returns nil if no size has been recorded yet.
+func loadVolumeSizeRecord(path string) (*volumeSizeRecord, error) {
+	data, err := os.ReadFile(path) // #nosec G304 -- path is configured by the operator of etcd-wrapper.
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var record volumeSizeRecord
+	if err = json.Unmarshal(data, &record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// writeVolumeSizeRecord atomically writes the size of the data volume by writing it into a temporary file which is then
+// renamed.
+func writeVolumeSizeRecord(path string, record volumeSizeRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return util.WriteFileAtomic(path, data, 0600)
+}
@@ -0,0 +1,135 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/maintenance"
+	"github.com/gardener/etcd-wrapper/internal/types"
+
+	. "github.com/onsi/gomega"
+	"go.etcd.io/etcd/embed"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestVolumeSize(t *testing.T) {
+	g := NewWithT(t)
+	dir := t.TempDir()
+	size, err := volumeSize(dir)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(size).To(BeNumerically(">", 0))
+
+	t.Log("should determine the size of the nearest existing parent directory")
+	parentSize, err := volumeSize(filepath.Join(dir, "data", "new.etcd"))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(parentSize).To(Equal(size))
+}
+
+func TestApplyVolumeSize(t *testing.T) {
+	size, err := volumeSize(t.TempDir())
+	NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+	table := []struct {
+		description        string
+		percentage         float64
+		recordedSize       int64
+		configuredQuota    int64
+		expectedQuotaBytes int64
+	}{
+		{"should keep the configured backend quota without percentage", 0, 0, 8 * 1024 * 1024 * 1024, 8 * 1024 * 1024 * 1024},
+		{"should derive the backend quota from the size of the data volume", 80, 0, 8 * 1024 * 1024 * 1024, quotaBackendBytes(size, 80)},
+		{"should derive the backend quota from the size of a resized data volume", 50, size / 2, 0, size / 2},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		testDir := t.TempDir()
+		recordPath := filepath.Join(testDir, "volume_size.json")
+		if entry.recordedSize > 0 {
+			g.Expect(writeVolumeSizeRecord(recordPath, volumeSizeRecord{SizeBytes: entry.recordedSize, RecordedAt: time.Now()})).To(Succeed())
+		}
+		cfg := embed.NewConfig()
+		cfg.Dir = filepath.Join(testDir, "new.etcd")
+		cfg.QuotaBackendBytes = entry.configuredQuota
+		app := &Application{
+			Config: types.Config{VolumeResize: types.VolumeResizeConfig{SizeRecordPath: recordPath, QuotaBackendPercentage: entry.percentage}},
+			cfg:    cfg,
+			logger: zaptest.NewLogger(t),
+		}
+		app.applyVolumeSize()
+		g.Expect(cfg.QuotaBackendBytes).To(Equal(entry.expectedQuotaBytes))
+		g.Expect(app.volumeSizeBytes.Load()).To(Equal(size))
+		record, err := loadVolumeSizeRecord(recordPath)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(record.SizeBytes).To(Equal(size))
+	}
+}
+
+func TestCheckVolumeSize(t *testing.T) {
+	// closedWindow opens half an hour from now and is thus closed for the duration of the test.
+	closedWindow, err := maintenance.NewWindow(fmt.Sprintf("%d * * * *", (time.Now().UTC().Minute()+30)%60), time.Minute)
+	NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+	table := []struct {
+		description     string
+		percentage      float64
+		restartOnResize bool
+		resized         bool
+		expectPending   bool
+		expectedQueued  int
+	}{
+		{"should do nothing if the data volume has not been resized", 50, true, false, false, 0},
+		{"should only record the size of a resized data volume without percentage", 0, false, true, false, 0},
+		{"should apply the backend quota at the next restart", 50, false, true, true, 0},
+		{"should schedule a restart to apply the backend quota", 50, true, true, true, 1},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		testDir := t.TempDir()
+		recordPath := filepath.Join(testDir, "volume_size.json")
+		cfg := embed.NewConfig()
+		cfg.Dir = testDir
+		logger := zaptest.NewLogger(t)
+		app := &Application{
+			Config: types.Config{VolumeResize: types.VolumeResizeConfig{
+				SizeRecordPath:         recordPath,
+				QuotaBackendPercentage: entry.percentage,
+				CheckInterval:          time.Minute,
+				RestartOnResize:        entry.restartOnResize,
+			}},
+			cfg:         cfg,
+			maintenance: maintenance.NewScheduler(closedWindow, logger),
+			logger:      logger,
+		}
+		size, err := volumeSize(testDir)
+		g.Expect(err).ToNot(HaveOccurred())
+		app.volumeSizeBytes.Store(size)
+		if entry.resized {
+			app.volumeSizeBytes.Store(size / 2)
+		}
+
+		app.checkVolumeSize()
+		g.Expect(app.volumeSizeBytes.Load()).To(Equal(size))
+		g.Expect(app.maintenance.Queued()).To(HaveLen(entry.expectedQueued))
+		record, err := loadVolumeSizeRecord(recordPath)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(record != nil).To(Equal(entry.resized))
+
+		app.applyPendingQuotaBackend()
+		if entry.expectPending {
+			g.Expect(cfg.QuotaBackendBytes).To(Equal(quotaBackendBytes(size, entry.percentage)))
+		} else {
+			g.Expect(cfg.QuotaBackendBytes).To(BeZero())
+		}
+		g.Expect(app.pendingQuotaBackendBytes.Load()).To(BeZero())
+	}
+}
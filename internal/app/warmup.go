@@ -0,0 +1,70 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/metrics"
+
+	"go.etcd.io/etcd/clientv3"
+	"go.uber.org/zap"
+)
+
+// warmUpPageSize is the number of keys read per range request during the warm-up.
+const warmUpPageSize = 1000
+
+// warmUpOnce warms up the running embedded etcd unless it has already been warmed up since it has been started. It is
+// called once etcd is ready and delays reporting readiness till the warm-up has finished.
+func (a *Application) warmUpOnce() {
+	etcd := a.getEtcd()
+	if a.Config.WarmUp.MaxKeys <= 0 || etcd == nil || etcd == a.warmedUpEtcd {
+		return
+	}
+	a.warmedUpEtcd = etcd
+	start := time.Now()
+	ctx, cancelFunc := context.WithTimeout(a.ctx, a.Config.WarmUp.Timeout)
+	defer cancelFunc()
+	keys, err := warmUp(ctx, a.etcdClient.KV, a.Config.WarmUp.Prefixes, a.Config.WarmUp.MaxKeys)
+	metrics.WarmUpDurationSeconds.Set(time.Since(start).Seconds())
+	if err != nil {
+		a.logger.Warn("warm-up of etcd stopped early, reporting readiness regardless", zap.Int64("keys", keys), zap.Duration("duration", time.Since(start)), zap.Error(err))
+		return
+	}
+	a.logger.Info("warmed up etcd", zap.Int64("keys", keys), zap.Duration("duration", time.Since(start)))
+}
+
+// warmUp reads the keys with the given prefixes, or the whole keyspace if there are none, page by page in key order
+// till maxKeys have been read, so that the pages of the etcd backend holding them are loaded into the page cache. It
+// returns the number of keys read. Count-only ranges are answered from the in-memory index of etcd without touching the
+// backend, hence the keys are read. Their values are dropped by etcd, since only the backend reads are of interest.
+func warmUp(ctx context.Context, kv clientv3.KV, prefixes []string, maxKeys int64) (int64, error) {
+	if len(prefixes) == 0 {
+		// the empty key with the range end "\x00" selects the whole keyspace.
+		prefixes = []string{""}
+	}
+	var read int64
+	for _, prefix := range prefixes {
+		key, end := prefix, clientv3.GetPrefixRangeEnd(prefix)
+		if prefix == "" {
+			key, end = "\x00", "\x00"
+		}
+		for read < maxKeys {
+			limit := min(int64(warmUpPageSize), maxKeys-read)
+			page, err := kv.Get(ctx, key, clientv3.WithRange(end), clientv3.WithLimit(limit), clientv3.WithKeysOnly(), clientv3.WithSerializable())
+			if err != nil {
+				return read, err
+			}
+			read += int64(len(page.Kvs))
+			if !page.More || len(page.Kvs) == 0 {
+				break
+			}
+			// continue right after the last key read
+			key = string(page.Kvs[len(page.Kvs)-1].Key) + "\x00"
+		}
+	}
+	return read, nil
+}
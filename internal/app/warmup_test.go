@@ -0,0 +1,55 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"go.etcd.io/etcd/etcdserver/api/v3client"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestWarmUp(t *testing.T) {
+	g := NewWithT(t)
+	etcd := startTestEtcd(t, g)
+	cli := v3client.New(etcd.Server)
+	defer func() {
+		_ = cli.Close()
+	}()
+	ctx := context.Background()
+
+	// more keys than fit into a single page to read across pages
+	for i := range warmUpPageSize + 10 {
+		_, err := cli.Put(ctx, fmt.Sprintf("/registry/pods/%04d", i), "value")
+		g.Expect(err).ToNot(HaveOccurred())
+	}
+	for i := range 5 {
+		_, err := cli.Put(ctx, fmt.Sprintf("/registry/leases/%d", i), "value")
+		g.Expect(err).ToNot(HaveOccurred())
+	}
+
+	table := []struct {
+		description  string
+		prefixes     []string
+		maxKeys      int64
+		expectedKeys int64
+	}{
+		{"should read the whole keyspace across pages", nil, 10000, warmUpPageSize + 15},
+		{"should stop reading the whole keyspace at the maximum number of keys", nil, warmUpPageSize + 1, warmUpPageSize + 1},
+		{"should only read the given prefixes", []string{"/registry/leases/"}, 10000, 5},
+		{"should read the prefixes in order till the maximum number of keys", []string{"/registry/leases/", "/registry/pods/"}, 7, 7},
+		{"should read nothing for a prefix without keys", []string{"/registry/nodes/"}, 10000, 0},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		keys, err := warmUp(ctx, cli.KV, entry.prefixes, entry.maxKeys)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(keys).To(Equal(entry.expectedKeys))
+	}
+}
@@ -0,0 +1,222 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package audit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Operation is a cluster-mutating action performed by etcd-wrapper.
+type Operation string
+
+const (
+	// OperationTriggerInitialization is recorded when the wrapper asks backup-restore to initialize (validate/restore) the data directory.
+	OperationTriggerInitialization Operation = "trigger-initialization"
+	// OperationStop is recorded when the wrapper stops the embedded etcd.
+	OperationStop Operation = "stop"
+	// OperationRestart is recorded when the wrapper restarts the embedded etcd.
+	OperationRestart Operation = "restart"
+	// OperationMemberAdd is recorded when the wrapper adds a member to the etcd cluster.
+	OperationMemberAdd Operation = "member-add"
+	// OperationMemberRemove is recorded when the wrapper removes a member from the etcd cluster.
+	OperationMemberRemove Operation = "member-remove"
+	// OperationMemberPromote is recorded when the wrapper promotes a learner to a voting member.
+	OperationMemberPromote Operation = "member-promote"
+	// OperationDefragment is recorded when the wrapper defragments the etcd backend.
+	OperationDefragment Operation = "defragment"
+	// OperationLeadershipTransfer is recorded when the wrapper transfers leadership to another member.
+	OperationLeadershipTransfer Operation = "leadership-transfer"
+	// OperationWriteRestoreMarker is recorded when the wrapper writes the restore marker key into etcd after a restoration.
+	OperationWriteRestoreMarker Operation = "write-restore-marker"
+	// OperationCompact is recorded when the wrapper compacts the etcd history.
+	OperationCompact Operation = "compact"
+	// OperationAuthSync is recorded when the wrapper reconciles etcd users, roles and permissions with a declarative spec.
+	OperationAuthSync Operation = "auth-sync"
+	// OperationHibernate is recorded when the wrapper takes a final full snapshot and marks the data directory as safe to delete.
+	OperationHibernate Operation = "hibernate"
+)
+
+// Outcome is the result of an audited Operation.
+type Outcome string
+
+const (
+	// OutcomeSucceeded indicates that the operation completed successfully.
+	OutcomeSucceeded Outcome = "succeeded"
+	// OutcomeFailed indicates that the operation failed.
+	OutcomeFailed Outcome = "failed"
+)
+
+// Entry is a single record in the audit log.
+type Entry struct {
+	// Timestamp is the time at which the operation was started.
+	Timestamp time.Time `json:"timestamp"`
+	// Operation is the operation that was performed.
+	Operation Operation `json:"operation"`
+	// Target identifies what the operation was performed on, e.g. a member ID. It is optional.
+	Target string `json:"target,omitempty"`
+	// Outcome is the result of the operation.
+	Outcome Outcome `json:"outcome"`
+	// Duration is the time it took to complete the operation.
+	Duration time.Duration `json:"duration"`
+	// Error is the error message if the operation failed.
+	Error string `json:"error,omitempty"`
+}
+
+// Logger records cluster-mutating operations performed by etcd-wrapper.
+type Logger interface {
+	// Record appends the entry to the audit log.
+	Record(entry Entry) error
+	// Close releases any resources held by the Logger.
+	Close() error
+}
+
+// NewLogger creates a Logger which appends entries as JSON lines to the file at path. Once the file grows beyond
+// maxSizeBytes it is rotated, keeping at most maxBackups older files suffixed with `.1`, `.2`, ... (`.1` being the most recent).
+// If path is empty then a Logger which discards all entries is returned.
+func NewLogger(path string, maxSizeBytes int64, maxBackups int) (Logger, error) {
+	if path == "" {
+		return NewNoopLogger(), nil
+	}
+	if maxSizeBytes <= 0 {
+		return nil, fmt.Errorf("audit log max size must be greater than 0, got: %d", maxSizeBytes)
+	}
+	if maxBackups < 0 {
+		return nil, fmt.Errorf("audit log max backups must not be negative, got: %d", maxBackups)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create directory for audit log %s: %w", path, err)
+	}
+	l := &fileLogger{
+		path:         path,
+		maxSizeBytes: maxSizeBytes,
+		maxBackups:   maxBackups,
+	}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// NewNoopLogger creates a Logger which discards all entries.
+func NewNoopLogger() Logger {
+	return noopLogger{}
+}
+
+// Record invokes fn and records its outcome for operation on target into the Logger. The error returned by fn is passed
+// through unchanged. Failure to write the audit entry does not affect the returned error.
+func Record(logger Logger, operation Operation, target string, fn func() error) error {
+	start := time.Now()
+	err := fn()
+	entry := Entry{
+		Timestamp: start,
+		Operation: operation,
+		Target:    target,
+		Outcome:   OutcomeSucceeded,
+		Duration:  time.Since(start),
+	}
+	if err != nil {
+		entry.Outcome = OutcomeFailed
+		entry.Error = err.Error()
+	}
+	_ = logger.Record(entry)
+	return err
+}
+
+type noopLogger struct{}
+
+func (noopLogger) Record(_ Entry) error { return nil }
+
+func (noopLogger) Close() error { return nil }
+
+// fileLogger implements Logger by appending JSON lines to a size-rotated file.
+type fileLogger struct {
+	mu           sync.Mutex
+	path         string
+	maxSizeBytes int64
+	maxBackups   int
+	file         *os.File
+	size         int64
+}
+
+func (l *fileLogger) Record(entry Entry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return errors.New("audit log is closed")
+	}
+	if l.size > 0 && l.size+int64(len(line)) > l.maxSizeBytes {
+		if err = l.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := l.file.Write(line)
+	l.size += int64(n)
+	return err
+}
+
+func (l *fileLogger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+func (l *fileLogger) open() error {
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600) // #nosec G304 -- path is passed in as a command line flag.
+	if err != nil {
+		return fmt.Errorf("failed to open audit log %s: %w", l.path, err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to stat audit log %s: %w", l.path, err)
+	}
+	l.file = f
+	l.size = info.Size()
+	return nil
+}
+
+// rotate shifts existing backups by one, moves the current file to `.1` and opens a fresh file.
+func (l *fileLogger) rotate() error {
+	if err := l.file.Close(); err != nil {
+		return err
+	}
+	l.file = nil
+	if l.maxBackups == 0 {
+		if err := os.Remove(l.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return l.open()
+	}
+	for i := l.maxBackups - 1; i > 0; i-- {
+		if err := os.Rename(backupPath(l.path, i), backupPath(l.path, i+1)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	if err := os.Rename(l.path, backupPath(l.path, 1)); err != nil {
+		return err
+	}
+	return l.open()
+}
+
+func backupPath(path string, index int) string {
+	return fmt.Sprintf("%s.%d", path, index)
+}
@@ -0,0 +1,102 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestNewLogger(t *testing.T) {
+	table := []struct {
+		description  string
+		path         string
+		maxSizeBytes int64
+		maxBackups   int
+		expectError  bool
+	}{
+		{"should return noop logger when path is empty", "", 0, 0, false},
+		{"should return error when max size is not positive", "audit.log", 0, 1, true},
+		{"should return error when max backups is negative", "audit.log", 1024, -1, true},
+		{"should create file logger when config is valid", "audit.log", 1024, 1, false},
+	}
+
+	for _, entry := range table {
+		t.Run(entry.description, func(t *testing.T) {
+			g := NewWithT(t)
+			path := entry.path
+			if path != "" {
+				path = filepath.Join(t.TempDir(), path)
+			}
+			logger, err := NewLogger(path, entry.maxSizeBytes, entry.maxBackups)
+			g.Expect(err != nil).To(Equal(entry.expectError))
+			if err == nil {
+				g.Expect(logger.Close()).To(Succeed())
+			}
+		})
+	}
+}
+
+func TestRecord(t *testing.T) {
+	g := NewWithT(t)
+	path := filepath.Join(t.TempDir(), "audit.log")
+	logger, err := NewLogger(path, 1024*1024, 1)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	errDefrag := errors.New("defrag failed")
+	g.Expect(Record(logger, OperationStop, "", func() error { return nil })).To(Succeed())
+	g.Expect(Record(logger, OperationDefragment, "member-1", func() error { return errDefrag })).To(MatchError(errDefrag))
+	g.Expect(logger.Close()).To(Succeed())
+
+	entries := readEntries(g, path)
+	g.Expect(entries).To(HaveLen(2))
+	g.Expect(entries[0].Operation).To(Equal(OperationStop))
+	g.Expect(entries[0].Outcome).To(Equal(OutcomeSucceeded))
+	g.Expect(entries[1].Operation).To(Equal(OperationDefragment))
+	g.Expect(entries[1].Target).To(Equal("member-1"))
+	g.Expect(entries[1].Outcome).To(Equal(OutcomeFailed))
+	g.Expect(entries[1].Error).To(Equal(errDefrag.Error()))
+}
+
+func TestRotation(t *testing.T) {
+	g := NewWithT(t)
+	path := filepath.Join(t.TempDir(), "audit.log")
+	// every entry is larger than 64 bytes, so each Record call after the first rotates the file.
+	logger, err := NewLogger(path, 64, 2)
+	g.Expect(err).ToNot(HaveOccurred())
+	for _, op := range []Operation{OperationMemberAdd, OperationMemberPromote, OperationMemberRemove, OperationRestart} {
+		g.Expect(logger.Record(Entry{Operation: op, Outcome: OutcomeSucceeded})).To(Succeed())
+	}
+	g.Expect(logger.Close()).To(Succeed())
+
+	g.Expect(readEntries(g, path)[0].Operation).To(Equal(OperationRestart))
+	g.Expect(readEntries(g, backupPath(path, 1))[0].Operation).To(Equal(OperationMemberRemove))
+	g.Expect(readEntries(g, backupPath(path, 2))[0].Operation).To(Equal(OperationMemberPromote))
+	_, err = os.Stat(backupPath(path, 3))
+	g.Expect(errors.Is(err, os.ErrNotExist)).To(BeTrue())
+}
+
+func readEntries(g *WithT, path string) []Entry {
+	f, err := os.Open(path)
+	g.Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		_ = f.Close()
+	}()
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e Entry
+		g.Expect(json.Unmarshal(scanner.Bytes(), &e)).To(Succeed())
+		entries = append(entries, e)
+	}
+	g.Expect(scanner.Err()).ToNot(HaveOccurred())
+	return entries
+}
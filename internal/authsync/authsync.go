@@ -0,0 +1,255 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package authsync reconciles the users, roles and permissions of etcd with a declarative Spec.
+package authsync
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/audit"
+
+	"go.etcd.io/etcd/auth/authpb"
+	"go.etcd.io/etcd/clientv3"
+	"go.uber.org/zap"
+)
+
+// Syncer reconciles the auth state of etcd with the Spec in a file.
+type Syncer struct {
+	auth        clientv3.Auth
+	specPath    string
+	auditLogger audit.Logger
+	logger      *zap.Logger
+	// appliedPasswords holds the hashes of the passwords last set per user.
+	appliedPasswords map[string][sha256.Size]byte
+}
+
+// NewSyncer creates a Syncer which reconciles the auth state of etcd via auth with the Spec in the file at specPath.
+func NewSyncer(auth clientv3.Auth, specPath string, auditLogger audit.Logger, logger *zap.Logger) *Syncer {
+	return &Syncer{
+		auth:             auth,
+		specPath:         specPath,
+		auditLogger:      auditLogger,
+		logger:           logger,
+		appliedPasswords: make(map[string][sha256.Size]byte),
+	}
+}
+
+// Run reconciles the auth state of etcd every interval if the Spec file has changed since the last successful
+// reconciliation, including the files of the user passwords. Failed reconciliations are retried every interval.
+// It returns when ctx is cancelled.
+func (s *Syncer) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var lastReconciled [sha256.Size]byte
+	for {
+		if fingerprint, err := s.fingerprint(); err != nil {
+			s.logger.Error("failed to read auth spec", zap.String("path", s.specPath), zap.Error(err))
+		} else if fingerprint != lastReconciled {
+			if err = s.Reconcile(ctx); err != nil {
+				s.logger.Error("failed to reconcile etcd auth state, will retry", zap.String("path", s.specPath), zap.Error(err))
+			} else {
+				lastReconciled = fingerprint
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Reconcile makes the users, roles and permissions of etcd match the Spec.
+func (s *Syncer) Reconcile(ctx context.Context) error {
+	spec, err := LoadSpec(s.specPath)
+	if err != nil {
+		return err
+	}
+	return audit.Record(s.auditLogger, audit.OperationAuthSync, s.specPath, func() error {
+		if err := s.reconcileRoles(ctx, spec); err != nil {
+			return err
+		}
+		if err := s.reconcileUsers(ctx, spec); err != nil {
+			return err
+		}
+		s.logger.Info("Reconciled etcd auth state", zap.String("path", s.specPath), zap.Int("roles", len(spec.Roles)), zap.Int("users", len(spec.Users)))
+		return nil
+	})
+}
+
+func (s *Syncer) reconcileRoles(ctx context.Context, spec *Spec) error {
+	roleList, err := s.auth.RoleList(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list roles: %w", err)
+	}
+	for _, role := range spec.Roles {
+		if !slices.Contains(roleList.Roles, role.Name) {
+			if _, err = s.auth.RoleAdd(ctx, role.Name); err != nil {
+				return fmt.Errorf("failed to add role %s: %w", role.Name, err)
+			}
+			s.logger.Info("Added role", zap.String("role", role.Name))
+		}
+		if err = s.reconcilePermissions(ctx, role); err != nil {
+			return err
+		}
+	}
+	if !spec.Prune {
+		return nil
+	}
+	for _, existing := range roleList.Roles {
+		if existing == rootName || slices.ContainsFunc(spec.Roles, func(r RoleSpec) bool { return r.Name == existing }) {
+			continue
+		}
+		if _, err = s.auth.RoleDelete(ctx, existing); err != nil {
+			return fmt.Errorf("failed to delete role %s: %w", existing, err)
+		}
+		s.logger.Info("Deleted role", zap.String("role", existing))
+	}
+	return nil
+}
+
+func (s *Syncer) reconcilePermissions(ctx context.Context, role RoleSpec) error {
+	roleResponse, err := s.auth.RoleGet(ctx, role.Name)
+	if err != nil {
+		return fmt.Errorf("failed to get role %s: %w", role.Name, err)
+	}
+	for _, perm := range role.Permissions {
+		permType, _ := perm.permissionType()
+		rangeEnd := perm.rangeEnd()
+		if slices.ContainsFunc(roleResponse.Perm, func(p *authpb.Permission) bool {
+			return string(p.Key) == perm.Key && string(p.RangeEnd) == rangeEnd && p.PermType == authpb.Permission_Type(permType)
+		}) {
+			continue
+		}
+		if _, err = s.auth.RoleGrantPermission(ctx, role.Name, perm.Key, rangeEnd, permType); err != nil {
+			return fmt.Errorf("failed to grant %s permission on key %s to role %s: %w", perm.Type, perm.Key, role.Name, err)
+		}
+		s.logger.Info("Granted permission", zap.String("role", role.Name), zap.String("key", perm.Key), zap.Bool("prefix", perm.Prefix), zap.String("type", perm.Type))
+	}
+	for _, existing := range roleResponse.Perm {
+		if slices.ContainsFunc(role.Permissions, func(p PermissionSpec) bool {
+			return p.Key == string(existing.Key) && p.rangeEnd() == string(existing.RangeEnd)
+		}) {
+			continue
+		}
+		if _, err = s.auth.RoleRevokePermission(ctx, role.Name, string(existing.Key), string(existing.RangeEnd)); err != nil {
+			return fmt.Errorf("failed to revoke permission on key %s from role %s: %w", existing.Key, role.Name, err)
+		}
+		s.logger.Info("Revoked permission", zap.String("role", role.Name), zap.ByteString("key", existing.Key), zap.ByteString("rangeEnd", existing.RangeEnd))
+	}
+	return nil
+}
+
+func (s *Syncer) reconcileUsers(ctx context.Context, spec *Spec) error {
+	userList, err := s.auth.UserList(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list users: %w", err)
+	}
+	for _, user := range spec.Users {
+		password, err := readPassword(user.PasswordFile)
+		if err != nil {
+			return fmt.Errorf("failed to read password of user %s: %w", user.Name, err)
+		}
+		if err = s.reconcileUser(ctx, user, password, slices.Contains(userList.Users, user.Name)); err != nil {
+			return err
+		}
+	}
+	if !spec.Prune {
+		return nil
+	}
+	for _, existing := range userList.Users {
+		if existing == rootName || slices.ContainsFunc(spec.Users, func(u UserSpec) bool { return u.Name == existing }) {
+			continue
+		}
+		if _, err = s.auth.UserDelete(ctx, existing); err != nil {
+			return fmt.Errorf("failed to delete user %s: %w", existing, err)
+		}
+		delete(s.appliedPasswords, existing)
+		s.logger.Info("Deleted user", zap.String("user", existing))
+	}
+	return nil
+}
+
+func (s *Syncer) reconcileUser(ctx context.Context, user UserSpec, password string, exists bool) error {
+	passwordHash := sha256.Sum256([]byte(password))
+	switch {
+	case !exists:
+		if _, err := s.auth.UserAddWithOptions(ctx, user.Name, password, &clientv3.UserAddOptions{NoPassword: user.PasswordFile == ""}); err != nil {
+			return fmt.Errorf("failed to add user %s: %w", user.Name, err)
+		}
+		s.appliedPasswords[user.Name] = passwordHash
+		s.logger.Info("Added user", zap.String("user", user.Name))
+	case user.PasswordFile != "" && s.appliedPasswords[user.Name] != passwordHash:
+		if _, err := s.auth.UserChangePassword(ctx, user.Name, password); err != nil {
+			return fmt.Errorf("failed to change password of user %s: %w", user.Name, err)
+		}
+		s.appliedPasswords[user.Name] = passwordHash
+		s.logger.Info("Changed password of user", zap.String("user", user.Name))
+	}
+
+	userResponse, err := s.auth.UserGet(ctx, user.Name)
+	if err != nil {
+		return fmt.Errorf("failed to get user %s: %w", user.Name, err)
+	}
+	for _, role := range user.Roles {
+		if slices.Contains(userResponse.Roles, role) {
+			continue
+		}
+		if _, err = s.auth.UserGrantRole(ctx, user.Name, role); err != nil {
+			return fmt.Errorf("failed to grant role %s to user %s: %w", role, user.Name, err)
+		}
+		s.logger.Info("Granted role to user", zap.String("user", user.Name), zap.String("role", role))
+	}
+	for _, role := range userResponse.Roles {
+		if slices.Contains(user.Roles, role) {
+			continue
+		}
+		if _, err = s.auth.UserRevokeRole(ctx, user.Name, role); err != nil {
+			return fmt.Errorf("failed to revoke role %s from user %s: %w", role, user.Name, err)
+		}
+		s.logger.Info("Revoked role from user", zap.String("user", user.Name), zap.String("role", role))
+	}
+	return nil
+}
+
+// fingerprint returns a hash over the content of the Spec file and of all password files referenced by it.
+func (s *Syncer) fingerprint() ([sha256.Size]byte, error) {
+	data, err := os.ReadFile(s.specPath)
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	hash := sha256.New()
+	hash.Write(data)
+	if spec, err := LoadSpec(s.specPath); err == nil {
+		for _, user := range spec.Users {
+			password, _ := readPassword(user.PasswordFile)
+			hash.Write([]byte(user.Name + "\x00" + password + "\x00"))
+		}
+	}
+	return [sha256.Size]byte(hash.Sum(nil)), nil
+}
+
+func readPassword(passwordFile string) (string, error) {
+	if passwordFile == "" {
+		return "", nil
+	}
+	data, err := os.ReadFile(passwordFile) // #nosec G304 -- path is configured by the operator.
+	if err != nil {
+		return "", err
+	}
+	password := strings.TrimRight(string(data), "\r\n")
+	if password == "" {
+		return "", errors.New("password file is empty")
+	}
+	return password, nil
+}
@@ -0,0 +1,212 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package authsync
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/gardener/etcd-wrapper/internal/audit"
+
+	. "github.com/onsi/gomega"
+	"go.etcd.io/etcd/auth/authpb"
+	"go.etcd.io/etcd/clientv3"
+	"go.uber.org/zap/zaptest"
+)
+
+const testSpec = `
+roles:
+  - name: reader
+    permissions:
+      - key: /registry/
+        prefix: true
+        type: read
+  - name: writer
+    permissions:
+      - key: /registry/config
+        type: readwrite
+users:
+  - name: alice
+    passwordFile: %s
+    roles: [reader, writer]
+  - name: bob
+    roles: [reader]
+prune: true
+`
+
+func TestReconcile(t *testing.T) {
+	g := NewWithT(t)
+	testDir := t.TempDir()
+	passwordFile := filepath.Join(testDir, "alice-password")
+	g.Expect(os.WriteFile(passwordFile, []byte("secret\n"), 0600)).To(Succeed())
+	specPath := filepath.Join(testDir, "auth.yaml")
+	g.Expect(os.WriteFile(specPath, []byte(sprintfSpec(passwordFile)), 0600)).To(Succeed())
+
+	fake := newFakeAuth()
+	fake.roles["root"] = nil
+	fake.roles["stale"] = nil
+	fake.roles["reader"] = []*authpb.Permission{{Key: []byte("/old"), PermType: authpb.READ}}
+	fake.users["root"] = &fakeUser{roles: []string{"root"}}
+	fake.users["mallory"] = &fakeUser{}
+	fake.users["bob"] = &fakeUser{roles: []string{"stale"}}
+	syncer := NewSyncer(fake, specPath, audit.NewNoopLogger(), zaptest.NewLogger(t))
+
+	t.Log("should reconcile roles, permissions and users with the spec")
+	g.Expect(syncer.Reconcile(context.Background())).To(Succeed())
+	g.Expect(fake.roles).To(HaveLen(3))
+	g.Expect(fake.roles).To(HaveKey("root"))
+	g.Expect(fake.roles["reader"]).To(Equal([]*authpb.Permission{{Key: []byte("/registry/"), RangeEnd: []byte(clientv3.GetPrefixRangeEnd("/registry/")), PermType: authpb.READ}}))
+	g.Expect(fake.roles["writer"]).To(Equal([]*authpb.Permission{{Key: []byte("/registry/config"), PermType: authpb.READWRITE}}))
+	g.Expect(fake.users).To(HaveLen(3))
+	g.Expect(fake.users).To(HaveKey("root"))
+	g.Expect(fake.users["alice"].password).To(Equal("secret"))
+	g.Expect(fake.users["alice"].roles).To(ConsistOf("reader", "writer"))
+	g.Expect(fake.users["bob"].roles).To(ConsistOf("reader"))
+
+	t.Log("should not change anything when etcd already matches the spec")
+	fake.mutations = 0
+	g.Expect(syncer.Reconcile(context.Background())).To(Succeed())
+	g.Expect(fake.mutations).To(BeZero())
+
+	t.Log("should change the password when the password file changes")
+	g.Expect(os.WriteFile(passwordFile, []byte("new-secret"), 0600)).To(Succeed())
+	g.Expect(syncer.Reconcile(context.Background())).To(Succeed())
+	g.Expect(fake.users["alice"].password).To(Equal("new-secret"))
+	g.Expect(fake.mutations).To(Equal(1))
+}
+
+func TestLoadSpec(t *testing.T) {
+	table := []struct {
+		description string
+		spec        string
+		expectError bool
+	}{
+		{"should load a valid spec", sprintfSpec("/var/etcd/auth/alice"), false},
+		{"should return error for unknown fields", "roles:\n  - name: reader\n    perms: []\n", true},
+		{"should return error for invalid permission type", "roles:\n  - name: reader\n    permissions:\n      - key: /a\n        type: execute\n", true},
+		{"should return error when a user references an unspecified role", "users:\n  - name: alice\n    roles: [admin]\n", true},
+		{"should return error for duplicate users", "users:\n  - name: alice\n  - name: alice\n", true},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		specPath := filepath.Join(t.TempDir(), "auth.yaml")
+		g.Expect(os.WriteFile(specPath, []byte(entry.spec), 0600)).To(Succeed())
+		_, err := LoadSpec(specPath)
+		g.Expect(err != nil).To(Equal(entry.expectError))
+	}
+}
+
+func sprintfSpec(passwordFile string) string {
+	return fmt.Sprintf(testSpec, passwordFile)
+}
+
+type fakeUser struct {
+	password string
+	roles    []string
+}
+
+// fakeAuth is an in-memory implementation of the parts of clientv3.Auth used by Syncer.
+type fakeAuth struct {
+	clientv3.Auth
+	roles     map[string][]*authpb.Permission
+	users     map[string]*fakeUser
+	mutations int
+}
+
+func newFakeAuth() *fakeAuth {
+	return &fakeAuth{roles: make(map[string][]*authpb.Permission), users: make(map[string]*fakeUser)}
+}
+
+func (f *fakeAuth) RoleList(_ context.Context) (*clientv3.AuthRoleListResponse, error) {
+	resp := &clientv3.AuthRoleListResponse{}
+	for name := range f.roles {
+		resp.Roles = append(resp.Roles, name)
+	}
+	return resp, nil
+}
+
+func (f *fakeAuth) RoleAdd(_ context.Context, name string) (*clientv3.AuthRoleAddResponse, error) {
+	f.mutations++
+	f.roles[name] = nil
+	return &clientv3.AuthRoleAddResponse{}, nil
+}
+
+func (f *fakeAuth) RoleDelete(_ context.Context, name string) (*clientv3.AuthRoleDeleteResponse, error) {
+	f.mutations++
+	delete(f.roles, name)
+	return &clientv3.AuthRoleDeleteResponse{}, nil
+}
+
+func (f *fakeAuth) RoleGet(_ context.Context, name string) (*clientv3.AuthRoleGetResponse, error) {
+	return &clientv3.AuthRoleGetResponse{Perm: slices.Clone(f.roles[name])}, nil
+}
+
+func (f *fakeAuth) RoleGrantPermission(_ context.Context, name string, key, rangeEnd string, permType clientv3.PermissionType) (*clientv3.AuthRoleGrantPermissionResponse, error) {
+	f.mutations++
+	perm := &authpb.Permission{Key: []byte(key), PermType: authpb.Permission_Type(permType)}
+	if rangeEnd != "" {
+		perm.RangeEnd = []byte(rangeEnd)
+	}
+	f.roles[name] = append(slices.DeleteFunc(f.roles[name], func(p *authpb.Permission) bool {
+		return string(p.Key) == key && string(p.RangeEnd) == rangeEnd
+	}), perm)
+	return &clientv3.AuthRoleGrantPermissionResponse{}, nil
+}
+
+func (f *fakeAuth) RoleRevokePermission(_ context.Context, name string, key, rangeEnd string) (*clientv3.AuthRoleRevokePermissionResponse, error) {
+	f.mutations++
+	f.roles[name] = slices.DeleteFunc(f.roles[name], func(p *authpb.Permission) bool {
+		return string(p.Key) == key && string(p.RangeEnd) == rangeEnd
+	})
+	return &clientv3.AuthRoleRevokePermissionResponse{}, nil
+}
+
+func (f *fakeAuth) UserList(_ context.Context) (*clientv3.AuthUserListResponse, error) {
+	resp := &clientv3.AuthUserListResponse{}
+	for name := range f.users {
+		resp.Users = append(resp.Users, name)
+	}
+	return resp, nil
+}
+
+func (f *fakeAuth) UserAddWithOptions(_ context.Context, name string, password string, _ *clientv3.UserAddOptions) (*clientv3.AuthUserAddResponse, error) {
+	f.mutations++
+	f.users[name] = &fakeUser{password: password}
+	return &clientv3.AuthUserAddResponse{}, nil
+}
+
+func (f *fakeAuth) UserDelete(_ context.Context, name string) (*clientv3.AuthUserDeleteResponse, error) {
+	f.mutations++
+	delete(f.users, name)
+	return &clientv3.AuthUserDeleteResponse{}, nil
+}
+
+func (f *fakeAuth) UserChangePassword(_ context.Context, name string, password string) (*clientv3.AuthUserChangePasswordResponse, error) {
+	f.mutations++
+	f.users[name].password = password
+	return &clientv3.AuthUserChangePasswordResponse{}, nil
+}
+
+func (f *fakeAuth) UserGet(_ context.Context, name string) (*clientv3.AuthUserGetResponse, error) {
+	return &clientv3.AuthUserGetResponse{Roles: slices.Clone(f.users[name].roles)}, nil
+}
+
+func (f *fakeAuth) UserGrantRole(_ context.Context, user string, role string) (*clientv3.AuthUserGrantRoleResponse, error) {
+	f.mutations++
+	f.users[user].roles = append(f.users[user].roles, role)
+	return &clientv3.AuthUserGrantRoleResponse{}, nil
+}
+
+func (f *fakeAuth) UserRevokeRole(_ context.Context, name string, role string) (*clientv3.AuthUserRevokeRoleResponse, error) {
+	f.mutations++
+	f.users[name].roles = slices.DeleteFunc(f.users[name].roles, func(r string) bool { return r == role })
+	return &clientv3.AuthUserRevokeRoleResponse{}, nil
+}
@@ -0,0 +1,124 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package authsync
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"go.etcd.io/etcd/clientv3"
+	"sigs.k8s.io/yaml"
+)
+
+// rootName is the name of the etcd root user and role which are never pruned.
+const rootName = "root"
+
+// Spec is the desired auth state of etcd.
+type Spec struct {
+	// Roles are the desired roles.
+	Roles []RoleSpec `json:"roles,omitempty"`
+	// Users are the desired users.
+	Users []UserSpec `json:"users,omitempty"`
+	// Prune deletes users and roles which are not part of the Spec, except for the root user and role.
+	Prune bool `json:"prune,omitempty"`
+}
+
+// RoleSpec is a desired etcd role.
+type RoleSpec struct {
+	// Name is the name of the role.
+	Name string `json:"name"`
+	// Permissions are all permissions granted to the role. Permissions not listed are revoked.
+	Permissions []PermissionSpec `json:"permissions,omitempty"`
+}
+
+// PermissionSpec is a permission on a key or a key prefix.
+type PermissionSpec struct {
+	// Key is the key, or key prefix if Prefix is true, to which the permission applies.
+	Key string `json:"key"`
+	// Prefix indicates that the permission applies to all keys with the prefix Key.
+	Prefix bool `json:"prefix,omitempty"`
+	// Type is the type of the permission, one of: read, write, readwrite.
+	Type string `json:"type"`
+}
+
+// UserSpec is a desired etcd user.
+type UserSpec struct {
+	// Name is the name of the user.
+	Name string `json:"name"`
+	// PasswordFile is the path of the file containing the password of the user. If empty, the user is created
+	// without password and can only authenticate via TLS client certificates.
+	PasswordFile string `json:"passwordFile,omitempty"`
+	// Roles are the roles granted to the user. Roles not listed are revoked.
+	Roles []string `json:"roles,omitempty"`
+}
+
+// LoadSpec reads and validates the Spec from the YAML file at path.
+func LoadSpec(path string) (*Spec, error) {
+	data, err := os.ReadFile(path) // #nosec G304 -- path is configured by the operator.
+	if err != nil {
+		return nil, fmt.Errorf("failed to read auth spec %s: %w", path, err)
+	}
+	spec := &Spec{}
+	if err = yaml.UnmarshalStrict(data, spec); err != nil {
+		return nil, fmt.Errorf("failed to parse auth spec %s: %w", path, err)
+	}
+	if err = spec.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid auth spec %s: %w", path, err)
+	}
+	return spec, nil
+}
+
+// Validate validates the Spec.
+func (s *Spec) Validate() (err error) {
+	roles := make(map[string]struct{}, len(s.Roles))
+	for _, role := range s.Roles {
+		if strings.TrimSpace(role.Name) == "" {
+			err = errors.Join(err, errors.New("role name must not be empty"))
+			continue
+		}
+		if _, ok := roles[role.Name]; ok {
+			err = errors.Join(err, fmt.Errorf("role %s is specified more than once", role.Name))
+		}
+		roles[role.Name] = struct{}{}
+		for _, perm := range role.Permissions {
+			if perm.Key == "" {
+				err = errors.Join(err, fmt.Errorf("permission of role %s must have a key", role.Name))
+			}
+			if _, permErr := perm.permissionType(); permErr != nil {
+				err = errors.Join(err, fmt.Errorf("permission of role %s on key %s: %w", role.Name, perm.Key, permErr))
+			}
+		}
+	}
+	users := make(map[string]struct{}, len(s.Users))
+	for _, user := range s.Users {
+		if strings.TrimSpace(user.Name) == "" {
+			err = errors.Join(err, errors.New("user name must not be empty"))
+			continue
+		}
+		if _, ok := users[user.Name]; ok {
+			err = errors.Join(err, fmt.Errorf("user %s is specified more than once", user.Name))
+		}
+		users[user.Name] = struct{}{}
+		for _, role := range user.Roles {
+			if _, ok := roles[role]; !ok && role != rootName {
+				err = errors.Join(err, fmt.Errorf("role %s of user %s is not specified", role, user.Name))
+			}
+		}
+	}
+	return
+}
+
+func (p PermissionSpec) permissionType() (clientv3.PermissionType, error) {
+	return clientv3.StrToPermissionType(strings.ToUpper(p.Type))
+}
+
+func (p PermissionSpec) rangeEnd() string {
+	if p.Prefix {
+		return clientv3.GetPrefixRangeEnd(p.Key)
+	}
+	return ""
+}
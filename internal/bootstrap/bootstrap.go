@@ -15,7 +15,10 @@ import (
 
 	"github.com/gardener/etcd-wrapper/internal/types"
 
+	"github.com/gardener/etcd-wrapper/internal/audit"
 	"github.com/gardener/etcd-wrapper/internal/brclient"
+	"github.com/gardener/etcd-wrapper/internal/metrics"
+	"github.com/gardener/etcd-wrapper/internal/state"
 	"github.com/gardener/etcd-wrapper/internal/util"
 
 	"go.etcd.io/etcd/embed"
@@ -30,58 +33,301 @@ const (
 // EtcdInitializer is an interface for methods to be used to initialize etcd
 type EtcdInitializer interface {
 	Run(context.Context) (*embed.Config, error)
+	// RestoreInfo returns information about the restoration of the data directory detected during Run. It returns nil
+	// if the data directory has not been restored.
+	RestoreInfo() *RestoreInfo
+	// RecordOutcome records the outcome of the current start attempt, once etcd has been started, in the bootstrap history.
+	RecordOutcome(outcome AttemptOutcome)
+	// EtcdConfigFilePath returns the path of the etcd configuration file from which the configuration returned by Run
+	// has been loaded.
+	EtcdConfigFilePath() string
 }
 
 type initializer struct {
-	brClient brclient.BackupRestoreClient
-	logger   *zap.Logger
+	brClient                brclient.BackupRestoreClient
+	skipRestoreVerification bool
+	// skipEmptyDataDirRecovery disables the restoration of data directories which have been initialized but have no WAL.
+	skipEmptyDataDirRecovery bool
+	walDir                   string
+	phaseTimeouts            types.PhaseTimeoutsConfig
+	sidecarOptional          types.SidecarOptionalConfig
+	lastKnownGood            types.LastKnownGoodConfig
+	etcdConfigFilePath       string
+	usedEtcdConfigFilePath   string
+	stateMachine             *state.Machine
+	restoreInfo              *RestoreInfo
+	history                  *History
+	crashLoopThreshold       int
+	crashLoopWindow          time.Duration
+	auditLogger              audit.Logger
+	logger                   *zap.Logger
 }
 
 // NewEtcdInitializer creates and returns an EtcdInitializer object
-func NewEtcdInitializer(brConfig *types.BackupRestoreConfig, logger *zap.Logger) (EtcdInitializer, error) {
+func NewEtcdInitializer(config *types.Config, stateMachine *state.Machine, auditLogger audit.Logger, logger *zap.Logger) (EtcdInitializer, error) {
 	// Validate backup-restore configuration
-	if err := brConfig.Validate(); err != nil {
+	if err := config.BackupRestore.Validate(); err != nil {
 		return nil, err
 	}
 
 	//create backup-restore client
-	brClient, err := brclient.NewDefaultClient(*brConfig)
+	brClient, err := brclient.NewDefaultClient(config.BackupRestore, config.DisableProxyEnv, config.DNS.NewDialer())
 	if err != nil {
 		return nil, err
 	}
 
+	return NewEtcdInitializerWithClient(brClient, config, stateMachine, auditLogger, logger), nil
+}
+
+// NewEtcdInitializerWithClient creates and returns an EtcdInitializer object which uses the passed in BackupRestoreClient
+// to interact with backup-restore. This allows alternative implementations of the backup-restore protocol to be plugged in.
+func NewEtcdInitializerWithClient(brClient brclient.BackupRestoreClient, config *types.Config, stateMachine *state.Machine, auditLogger audit.Logger, logger *zap.Logger) EtcdInitializer {
+	history, err := LoadHistory(config.BootstrapHistory.Path, types.DefaultBootstrapHistorySize)
+	if err != nil {
+		logger.Error("failed to load bootstrap history, starting with an empty history", zap.Error(err))
+	}
+	etcdConfigFilePath, err := brclient.DefaultEtcdConfigFilePath()
+	if err != nil && config.SidecarOptional.Enabled {
+		logger.Error("failed to determine path of the etcd configuration, etcd cannot be started without backup-restore", zap.Error(err))
+	}
 	return &initializer{
-		brClient: brClient,
-		logger:   logger,
-	}, nil
+		brClient:                 brClient,
+		skipRestoreVerification:  config.SkipRestoreVerification,
+		skipEmptyDataDirRecovery: config.SkipEmptyDataDirRecovery,
+		walDir:                   config.WALDir,
+		phaseTimeouts:            config.PhaseTimeouts,
+		sidecarOptional:          config.SidecarOptional,
+		lastKnownGood:            config.LastKnownGoodConfig,
+		etcdConfigFilePath:       etcdConfigFilePath,
+		history:                  history,
+		crashLoopThreshold:       config.BootstrapHistory.CrashLoopThreshold,
+		crashLoopWindow:          config.BootstrapHistory.CrashLoopWindow,
+		stateMachine:             stateMachine,
+		auditLogger:              auditLogger,
+		logger:                   logger,
+	}
 }
 
-// Run initializes the etcd and gets the etcd configuration
+// Run initializes the etcd and gets the etcd configuration. The start attempt is recorded in the bootstrap history,
+// and full validation of the data directory is requested if a crash loop has been detected.
 func (i *initializer) Run(ctx context.Context) (*embed.Config, error) {
+	crashLooping := i.beginAttempt()
+	cfg, err := i.run(ctx, crashLooping)
+	switch {
+	case err == nil:
+		i.recordOutcome(OutcomeBootstrapped)
+	case ctx.Err() != nil:
+		i.recordOutcome(OutcomeInterrupted)
+	default:
+		i.recordOutcome(OutcomeFailed)
+	}
+	return cfg, err
+}
+
+func (i *initializer) run(ctx context.Context, crashLooping bool) (*embed.Config, error) {
 	var (
-		err        error
-		initStatus brclient.InitStatus
+		err             error
+		initStatus      brclient.InitStatus
+		initStart       = time.Now()
+		sidecarReached  bool
+		bypassAttempted bool
+		negotiated      bool
+		backOff         = defaultBackOffBetweenRetries
 	)
+	metrics.SidecarBypassed.Set(0)
+	metrics.SidecarAPIVersion.Set(0)
+	i.transitionTo(state.ProbingSidecar)
+	timer := newPhaseTimer(i.phaseTimeouts, PhaseSidecarProbe)
 	for initStatus != brclient.Successful {
-		if initStatus, err = i.brClient.GetInitializationStatus(ctx); err != nil {
-			i.logger.Error("error while fetching initialization status", zap.Error(err))
+		if err = timer.check(); err != nil {
+			return nil, err
+		}
+		if i.sidecarOptional.Enabled && !sidecarReached && !bypassAttempted && !time.Now().Before(initStart.Add(i.sidecarOptional.Window)) {
+			bypassAttempted = true
+			cfg, err := i.loadEtcdConfigWithoutSidecar()
+			if err == nil {
+				return cfg, nil
+			}
+			i.logger.Error("Cannot start etcd without backup-restore, continuing to wait for backup-restore", zap.Error(err))
+		}
+		bounded := i.sidecarOptional.Enabled && !sidecarReached && !bypassAttempted
+		if !negotiated {
+			if err = i.negotiateAPIVersion(ctx, bounded, initStart); err != nil {
+				if permanentErr := classifySidecarError("negotiate API version", err); permanentErr != nil {
+					return nil, permanentErr
+				}
+				i.logger.Error("error while negotiating API version with backup-restore", zap.Error(err), zap.Duration("backOff", backOff))
+				select {
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				case <-time.After(backOff):
+				}
+				backOff = nextBackOff(backOff, true)
+				continue
+			}
+			negotiated = true
+		}
+		failed := false
+		if initStatus, err = i.getInitializationStatus(ctx, bounded, initStart); err != nil {
+			if permanentErr := classifySidecarError("get initialization status", err); permanentErr != nil {
+				return nil, permanentErr
+			}
+			failed = true
+			i.logger.Error("error while fetching initialization status", zap.Error(err), zap.Duration("backOff", backOff))
 		}
+		sidecarReached = sidecarReached || err == nil
 		i.logger.Info("Fetched initialization status", zap.String("Status", initStatus.String()))
+		if initStatus == brclient.InProgress {
+			i.transitionTo(state.Restoring)
+			timer.enter(PhaseRestorationWait)
+		}
 		if initStatus == brclient.New {
+			i.transitionTo(state.Validating)
+			timer.enter(PhaseValidation)
 			validationMode := determineValidationMode(types.DefaultExitCodeFilePath, i.logger)
+			if crashLooping && validationMode != brclient.FullValidation {
+				i.logger.Warn("Escalating to full validation of the data directory due to the detected crash loop")
+				validationMode = brclient.FullValidation
+			}
 			i.logger.Info("Fetched initialization status is `New`. Triggering etcd initialization with validation mode", zap.Any("mode", validationMode))
-			if err = i.brClient.TriggerInitialization(ctx, validationMode); err != nil {
-				i.logger.Error("error while triggering initialization to backup-restore", zap.Error(err))
+			if err = audit.Record(i.auditLogger, audit.OperationTriggerInitialization, string(validationMode), func() error {
+				return i.brClient.TriggerInitialization(ctx, validationMode)
+			}); err != nil {
+				if permanentErr := classifySidecarError("trigger initialization", err); permanentErr != nil {
+					return nil, permanentErr
+				}
+				failed = true
+				i.logger.Error("error while triggering initialization to backup-restore", zap.Error(err), zap.Duration("backOff", backOff))
 			}
 		}
 		select {
 		case <-ctx.Done():
 			return nil, ctx.Err()
-		case <-time.After(defaultBackOffBetweenRetries):
+		case <-time.After(backOff):
 		}
+		backOff = nextBackOff(backOff, failed)
 	}
 	i.logger.Info("Etcd initialization succeeded")
-	return i.tryGetEtcdConfig(ctx, defaultBackupRestoreMaxRetries, defaultBackOffBetweenRetries)
+	cfg, err := i.tryGetEtcdConfig(ctx, defaultBackupRestoreMaxRetries, defaultBackOffBetweenRetries)
+	if err != nil {
+		return nil, err
+	}
+	i.applyWALDir(cfg)
+	tlsMode, err := ResolveTLSMode(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TLS configuration of etcd: %w", err)
+	}
+	i.logger.Info("Resolved TLS mode of etcd", zap.Stringer("tlsMode", tlsMode))
+	if !i.skipEmptyDataDirRecovery {
+		if err = i.recoverEmptyDataDir(ctx, cfg, timer); err != nil {
+			return nil, err
+		}
+	}
+	i.restoreInfo = i.detectRestoration(ctx, cfg, initStart)
+	if i.skipRestoreVerification {
+		i.logger.Warn("Restore verification is skipped")
+		return cfg, nil
+	}
+	if err = i.verifyRestoredDataDir(ctx, cfg, i.restoreInfo); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// applyWALDir sets the WAL directory of cfg to the one configured for etcd-wrapper, if any, so that the data directory
+// is inspected with the WAL where etcd will find it. etcd-wrapper moves the WAL into that directory on the first start.
+func (i *initializer) applyWALDir(cfg *embed.Config) {
+	if i.walDir != "" {
+		cfg.WalDir = i.walDir
+	}
+}
+
+// getInitializationStatus fetches the initialization status from backup-restore. If bounded is true, the request does
+// not outlast the sidecar optional window.
+func (i *initializer) getInitializationStatus(ctx context.Context, bounded bool, initStart time.Time) (brclient.InitStatus, error) {
+	if !bounded {
+		return i.brClient.GetInitializationStatus(ctx)
+	}
+	probeCtx, cancel := context.WithDeadline(ctx, initStart.Add(i.sidecarOptional.Window))
+	defer cancel()
+	return i.brClient.GetInitializationStatus(probeCtx)
+}
+
+// negotiateAPIVersion negotiates the API version with backup-restore, so that the requests during initialization match
+// the schema expected by backup-restore. It is a no-op if the client does not depend on the API version of
+// backup-restore. If bounded is true, the request does not outlast the sidecar optional window.
+func (i *initializer) negotiateAPIVersion(ctx context.Context, bounded bool, initStart time.Time) error {
+	negotiator, ok := i.brClient.(brclient.APIVersionNegotiator)
+	if !ok {
+		return nil
+	}
+	if bounded {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, initStart.Add(i.sidecarOptional.Window))
+		defer cancel()
+	}
+	version, err := negotiator.NegotiateAPIVersion(ctx)
+	if err != nil {
+		return err
+	}
+	metrics.SidecarAPIVersion.Set(float64(version))
+	i.logger.Info("Negotiated API version with backup-restore", zap.Int("apiVersion", int(version)))
+	return nil
+}
+
+// RestoreInfo returns information about the restoration of the data directory detected during Run.
+func (i *initializer) RestoreInfo() *RestoreInfo {
+	return i.restoreInfo
+}
+
+// RecordOutcome records the outcome of the current start attempt in the bootstrap history.
+func (i *initializer) RecordOutcome(outcome AttemptOutcome) {
+	i.recordOutcome(outcome)
+}
+
+// EtcdConfigFilePath returns the path of the etcd configuration file from which the configuration returned by Run has
+// been loaded.
+func (i *initializer) EtcdConfigFilePath() string {
+	return i.usedEtcdConfigFilePath
+}
+
+// beginAttempt records a new start attempt in the bootstrap history and returns true if a crash loop is detected,
+// i.e. if at least crashLoopThreshold previous attempts have failed within crashLoopWindow.
+func (i *initializer) beginAttempt() bool {
+	now := time.Now()
+	if err := i.history.Begin(now); err != nil {
+		i.logger.Error("failed to record start attempt in bootstrap history", zap.Error(err))
+	}
+	crashLooping := false
+	if i.crashLoopThreshold > 0 {
+		failures := i.history.Failures(now.Add(-i.crashLoopWindow))
+		if crashLooping = failures >= i.crashLoopThreshold; crashLooping {
+			i.logger.Warn("Detected crash loop of etcd-wrapper", zap.Int("failedAttempts", failures), zap.Duration("window", i.crashLoopWindow))
+		}
+	}
+	if crashLooping {
+		metrics.CrashLoopDetected.Set(1)
+	} else {
+		metrics.CrashLoopDetected.Set(0)
+	}
+	return crashLooping
+}
+
+func (i *initializer) recordOutcome(outcome AttemptOutcome) {
+	if outcome == OutcomeSucceeded {
+		i.saveLastKnownGoodConfig()
+	}
+	phase, _ := i.stateMachine.Current()
+	if err := i.history.Record(phase, outcome); err != nil {
+		i.logger.Error("failed to record outcome of start attempt in bootstrap history", zap.String("outcome", string(outcome)), zap.Error(err))
+	}
+}
+
+// transitionTo transitions the state machine to the given state, logging invalid transitions.
+func (i *initializer) transitionTo(s state.State) {
+	if err := i.stateMachine.TransitionTo(s); err != nil {
+		i.logger.Error("failed to transition state", zap.Error(err))
+	}
 }
 
 // ChangeFilePermissions changes the file permissions of all files in the given directory and its subdirectories recursively.
@@ -133,14 +379,22 @@ func CleanupExitCode(exitCodeFilePath string) error {
 func (i *initializer) tryGetEtcdConfig(ctx context.Context, maxRetries int, interval time.Duration) (*embed.Config, error) {
 	// Get etcd config only
 	opResult := util.Retry[string](ctx, i.logger, "GetEtcdConfig", func() (string, error) {
-		return i.brClient.GetEtcdConfig(ctx)
-	}, maxRetries, interval, util.AlwaysRetry)
+		etcdConfigFilePath, err := i.brClient.GetEtcdConfig(ctx)
+		if permanentErr := classifySidecarError("fetch etcd config", err); permanentErr != nil {
+			return "", permanentErr
+		}
+		return etcdConfigFilePath, err
+	}, maxRetries, interval, brclient.IsRetryable)
 	if opResult.IsErr() {
+		if i.lastKnownGood.UseOnFetchFailure {
+			return i.loadLastKnownGoodConfig(opResult.Err)
+		}
 		return nil, opResult.Err
 	}
 	etcdConfigFilePath := opResult.Value
 	i.logger.Info("Fetched and written etcd configuration", zap.String("path", etcdConfigFilePath))
-	return embed.ConfigFromFile(etcdConfigFilePath)
+	i.usedEtcdConfigFilePath = etcdConfigFilePath
+	return LoadEtcdConfig(etcdConfigFilePath)
 }
 
 func determineValidationMode(exitCodeFilePath string, logger *zap.Logger) brclient.ValidationType {
@@ -7,6 +7,7 @@ package bootstrap
 import (
 	"bytes"
 	"context"
+	"errors"
 	"io"
 	"net/http"
 	"os"
@@ -19,7 +20,9 @@ import (
 	"go.uber.org/zap"
 	"go.uber.org/zap/zaptest"
 
+	"github.com/gardener/etcd-wrapper/internal/audit"
 	"github.com/gardener/etcd-wrapper/internal/brclient"
+	"github.com/gardener/etcd-wrapper/internal/state"
 	. "github.com/onsi/gomega"
 )
 
@@ -287,12 +290,76 @@ func TestNewEtcdInitializer(t *testing.T) {
 			lgr, err := loggerConfig.Build()
 			g.Expect(err).ToNot(HaveOccurred())
 
-			_, err = NewEtcdInitializer(&entry.sidecarConfig, lgr)
+			_, err = NewEtcdInitializer(&types.Config{BackupRestore: entry.sidecarConfig}, state.NewMachine(lgr), audit.NewNoopLogger(), lgr)
 			g.Expect(err != nil).To(Equal(entry.expectError))
 		})
 	}
 }
 
+func TestRun(t *testing.T) {
+	table := []struct {
+		description       string
+		fakeClient        *brclient.FakeClient
+		writeConfig       bool
+		expectError       bool
+		expectedTriggered []brclient.ValidationType
+	}{
+		{"should trigger initialization and return etcd config once initialization succeeds", &brclient.FakeClient{InitStatuses: []brclient.InitStatus{brclient.New, brclient.Successful}}, true, false, []brclient.ValidationType{brclient.FullValidation}},
+		{"should not trigger initialization when it has already succeeded", &brclient.FakeClient{InitStatuses: []brclient.InitStatus{brclient.Successful}}, true, false, nil},
+		{"should return error when etcd config cannot be parsed", &brclient.FakeClient{InitStatuses: []brclient.InitStatus{brclient.Successful}}, false, true, nil},
+	}
+
+	for _, entry := range table {
+		t.Run(entry.description, func(t *testing.T) {
+			g := NewWithT(t)
+			testDir := t.TempDir()
+			etcdConfigFilePath := filepath.Join(testDir, "etcd.conf.yaml")
+			if entry.writeConfig {
+				g.Expect(os.WriteFile(etcdConfigFilePath, []byte("name: etcd-test\ndata-dir: "+filepath.Join(testDir, "data")+"\n"), 0600)).To(Succeed())
+			} else {
+				g.Expect(os.WriteFile(etcdConfigFilePath, []byte("invalid: [yaml"), 0600)).To(Succeed())
+			}
+			entry.fakeClient.EtcdConfigFilePath = etcdConfigFilePath
+
+			logger := zaptest.NewLogger(t)
+			i := NewEtcdInitializerWithClient(entry.fakeClient, &types.Config{}, state.NewMachine(logger), audit.NewNoopLogger(), logger)
+			cfg, err := i.Run(context.Background())
+			g.Expect(err != nil).To(Equal(entry.expectError))
+			if !entry.expectError {
+				g.Expect(cfg.Name).To(Equal("etcd-test"))
+			}
+			g.Expect(entry.fakeClient.TriggeredValidationTypes).To(Equal(entry.expectedTriggered))
+		})
+	}
+}
+
+func TestRunPhaseTimeouts(t *testing.T) {
+	table := []struct {
+		description   string
+		fakeClient    *brclient.FakeClient
+		phaseTimeouts types.PhaseTimeoutsConfig
+		expectedPhase Phase
+		expectedCode  int
+	}{
+		{"should fail with sidecar probe timeout when backup-restore does not respond", &brclient.FakeClient{InitStatusErr: errors.New("connection refused")}, types.PhaseTimeoutsConfig{SidecarProbe: time.Millisecond}, PhaseSidecarProbe, types.ExitCodeSidecarProbeTimeout},
+		{"should fail with validation timeout when triggered validation does not start", &brclient.FakeClient{InitStatuses: []brclient.InitStatus{brclient.New}}, types.PhaseTimeoutsConfig{Validation: time.Millisecond}, PhaseValidation, types.ExitCodeValidationTimeout},
+		{"should fail with restoration wait timeout when initialization does not complete", &brclient.FakeClient{InitStatuses: []brclient.InitStatus{brclient.InProgress}}, types.PhaseTimeoutsConfig{RestorationWait: time.Millisecond}, PhaseRestorationWait, types.ExitCodeRestorationWaitTimeout},
+	}
+
+	for _, entry := range table {
+		t.Run(entry.description, func(t *testing.T) {
+			g := NewWithT(t)
+			logger := zaptest.NewLogger(t)
+			i := NewEtcdInitializerWithClient(entry.fakeClient, &types.Config{PhaseTimeouts: entry.phaseTimeouts}, state.NewMachine(logger), audit.NewNoopLogger(), logger)
+			_, err := i.Run(context.Background())
+			var phaseTimeoutErr *PhaseTimeoutError
+			g.Expect(errors.As(err, &phaseTimeoutErr)).To(BeTrue())
+			g.Expect(phaseTimeoutErr.Phase).To(Equal(entry.expectedPhase))
+			g.Expect(phaseTimeoutErr.ExitCode()).To(Equal(entry.expectedCode))
+		})
+	}
+}
+
 func createTestDir(t *testing.T) string {
 	g := NewWithT(t)
 	testDir, err := os.MkdirTemp("", "etcd-wrapper")
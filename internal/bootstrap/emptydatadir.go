@@ -0,0 +1,166 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstrap
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/audit"
+	"github.com/gardener/etcd-wrapper/internal/brclient"
+	"github.com/gardener/etcd-wrapper/internal/state"
+
+	"go.etcd.io/etcd/embed"
+	"go.etcd.io/etcd/wal"
+	"go.uber.org/zap"
+)
+
+const (
+	// emptyMemberDirPrefix is the prefix of the member directories without WAL which have been moved aside, followed by
+	// the unix time at which they have been moved aside.
+	emptyMemberDirPrefix = "member.empty-"
+	// maxEmptyMemberDirs is the number of member directories without WAL moved aside which are kept for analysis.
+	maxEmptyMemberDirs = 3
+)
+
+// HasWAL returns whether a WAL exists in the data directory or in the WAL directory of cfg. Without WAL, etcd
+// bootstraps a new member regardless of other content of the data directory.
+func HasWAL(cfg *embed.Config) bool {
+	return wal.Exist(filepath.Join(cfg.Dir, "member", "wal")) || (cfg.WalDir != "" && wal.Exist(cfg.WalDir))
+}
+
+// isEmptyInitializedDataDir returns whether the data directory of cfg has been initialized, i.e. contains the member
+// directory, but has no WAL, e.g. after a glitch while provisioning the volume or an interrupted first start of etcd.
+// etcd would bootstrap a new member on such a data directory.
+func isEmptyInitializedDataDir(cfg *embed.Config) (bool, error) {
+	info, err := os.Stat(filepath.Join(cfg.Dir, "member"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	return info.IsDir() && !HasWAL(cfg), nil
+}
+
+// recoverEmptyDataDir detects a data directory which has been initialized but contains no WAL, and lets backup-restore
+// restore it from the latest snapshots instead of letting etcd bootstrap an empty cluster. The member directory is
+// moved aside, so that backup-restore finds the data directory missing, and the initialization is triggered again with
+// full validation. The initialization is triggered again whenever backup-restore reports the status New, e.g. after a
+// failed restoration. Only the most recent maxEmptyMemberDirs member directories moved aside are kept.
+func (i *initializer) recoverEmptyDataDir(ctx context.Context, cfg *embed.Config, timer *phaseTimer) error {
+	empty, err := isEmptyInitializedDataDir(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to inspect data directory: %w", err)
+	}
+	if !empty {
+		return nil
+	}
+	memberDir := filepath.Join(cfg.Dir, "member")
+	asideDir := filepath.Join(cfg.Dir, fmt.Sprintf("%s%d", emptyMemberDirPrefix, time.Now().Unix()))
+	i.logger.Warn("Data directory has been initialized but contains no WAL, moving it aside and triggering its restoration",
+		zap.String("memberDir", memberDir), zap.String("movedTo", asideDir))
+	if err = os.Rename(memberDir, asideDir); err != nil {
+		return fmt.Errorf("failed to move aside member directory without WAL: %w", err)
+	}
+	if err = pruneEmptyMemberDirs(cfg.Dir, maxEmptyMemberDirs); err != nil {
+		i.logger.Error("failed to remove member directories without WAL moved aside before", zap.Error(err))
+	}
+
+	i.transitionTo(state.Validating)
+	timer.enter(PhaseValidation)
+	var (
+		triggered bool
+		backOff   = defaultBackOffBetweenRetries
+	)
+	for {
+		if err = timer.check(); err != nil {
+			return err
+		}
+		failed := false
+		if !triggered {
+			if err = audit.Record(i.auditLogger, audit.OperationTriggerInitialization, string(brclient.FullValidation), func() error {
+				return i.brClient.TriggerInitialization(ctx, brclient.FullValidation)
+			}); err != nil {
+				if permanentErr := classifySidecarError("trigger initialization", err); permanentErr != nil {
+					return fmt.Errorf("failed to trigger restoration of data directory without WAL: %w", permanentErr)
+				}
+				failed = true
+				i.logger.Error("error while triggering restoration of data directory without WAL", zap.Error(err), zap.Duration("backOff", backOff))
+			}
+			triggered = err == nil
+		}
+		if triggered {
+			initStatus, err := i.brClient.GetInitializationStatus(ctx)
+			switch {
+			case err != nil:
+				if permanentErr := classifySidecarError("get initialization status", err); permanentErr != nil {
+					return permanentErr
+				}
+				failed = true
+				i.logger.Error("error while fetching initialization status", zap.Error(err), zap.Duration("backOff", backOff))
+			case initStatus == brclient.Successful:
+				i.logger.Info("Restoration of data directory without WAL succeeded")
+				return nil
+			case initStatus == brclient.New:
+				// backup-restore resets the status after a failed initialization.
+				i.logger.Warn("Restoration of data directory without WAL has not started or has failed, triggering it again")
+				i.transitionTo(state.Validating)
+				triggered = false
+			case initStatus == brclient.InProgress:
+				i.transitionTo(state.Restoring)
+				timer.enter(PhaseRestorationWait)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backOff):
+		}
+		backOff = nextBackOff(backOff, failed)
+	}
+}
+
+// pruneEmptyMemberDirs removes all but the keep most recent member directories without WAL which have been moved aside
+// into dataDir.
+func pruneEmptyMemberDirs(dataDir string, keep int) error {
+	entries, err := os.ReadDir(dataDir)
+	if err != nil {
+		return err
+	}
+	type movedAside struct {
+		name    string
+		movedAt int64
+	}
+	var dirs []movedAside
+	for _, entry := range entries {
+		suffix, ok := strings.CutPrefix(entry.Name(), emptyMemberDirPrefix)
+		if !ok || !entry.IsDir() {
+			continue
+		}
+		movedAt, err := strconv.ParseInt(suffix, 10, 64)
+		if err != nil {
+			continue
+		}
+		dirs = append(dirs, movedAside{name: entry.Name(), movedAt: movedAt})
+	}
+	if len(dirs) <= keep {
+		return nil
+	}
+	slices.SortFunc(dirs, func(x, y movedAside) int { return cmp.Compare(y.movedAt, x.movedAt) })
+	var errs []error
+	for _, dir := range dirs[keep:] {
+		errs = append(errs, os.RemoveAll(filepath.Join(dataDir, dir.name)))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,143 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/audit"
+	"github.com/gardener/etcd-wrapper/internal/brclient"
+	"github.com/gardener/etcd-wrapper/internal/state"
+	"github.com/gardener/etcd-wrapper/internal/types"
+
+	. "github.com/onsi/gomega"
+	"go.etcd.io/etcd/embed"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestRecoverEmptyDataDir(t *testing.T) {
+	table := []struct {
+		description       string
+		memberDir         bool
+		walInDataDir      bool
+		walInWALDir       bool
+		fakeClient        *brclient.FakeClient
+		expectError       bool
+		expectMovedAside  bool
+		expectedTriggered []brclient.ValidationType
+	}{
+		{"should not recover a data directory which has not been initialized", false, false, false, &brclient.FakeClient{}, false, false, nil},
+		{"should not recover a data directory with WAL", true, true, false, &brclient.FakeClient{}, false, false, nil},
+		{"should not recover a data directory whose WAL is in the WAL directory", true, false, true, &brclient.FakeClient{}, false, false, nil},
+		{"should move aside the member directory without WAL and trigger its restoration", true, false, false, &brclient.FakeClient{InitStatuses: []brclient.InitStatus{brclient.InProgress, brclient.Successful}}, false, true, []brclient.ValidationType{brclient.FullValidation}},
+		{"should trigger the restoration again once backup-restore has reset the status to New", true, false, false, &brclient.FakeClient{InitStatuses: []brclient.InitStatus{brclient.New, brclient.InProgress, brclient.Successful}}, false, true, []brclient.ValidationType{brclient.FullValidation, brclient.FullValidation}},
+		{"should return error if backup-restore rejects the restoration", true, false, false, &brclient.FakeClient{TriggerInitializationErr: &brclient.ResponseError{Operation: "trigger initialization", StatusCode: http.StatusBadRequest}}, true, true, []brclient.ValidationType{brclient.FullValidation}},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		dir := t.TempDir()
+		cfg := embed.NewConfig()
+		cfg.Dir = filepath.Join(dir, "data")
+		cfg.WalDir = filepath.Join(dir, "wal")
+		g.Expect(os.MkdirAll(cfg.WalDir, 0700)).To(Succeed())
+		if entry.memberDir {
+			g.Expect(os.MkdirAll(filepath.Join(cfg.Dir, "member", "snap"), 0700)).To(Succeed())
+			g.Expect(os.WriteFile(GetDBPath(cfg.Dir), []byte("db"), 0600)).To(Succeed())
+		}
+		if entry.walInDataDir {
+			g.Expect(os.MkdirAll(filepath.Join(cfg.Dir, "member", "wal"), 0700)).To(Succeed())
+			g.Expect(os.WriteFile(filepath.Join(cfg.Dir, "member", "wal", "0000000000000000-0000000000000000.wal"), nil, 0600)).To(Succeed())
+		}
+		if entry.walInWALDir {
+			g.Expect(os.WriteFile(filepath.Join(cfg.WalDir, "0000000000000000-0000000000000000.wal"), nil, 0600)).To(Succeed())
+		}
+		logger := zaptest.NewLogger(t)
+		stateMachine := state.NewMachine(logger)
+		g.Expect(stateMachine.TransitionTo(state.ProbingSidecar)).To(Succeed())
+		i := &initializer{brClient: entry.fakeClient, stateMachine: stateMachine, auditLogger: audit.NewNoopLogger(), logger: logger}
+
+		err := i.recoverEmptyDataDir(context.Background(), cfg, newPhaseTimer(types.PhaseTimeoutsConfig{}, PhaseSidecarProbe))
+		g.Expect(err != nil).To(Equal(entry.expectError))
+		g.Expect(entry.fakeClient.TriggeredValidationTypes).To(Equal(entry.expectedTriggered))
+		movedAside, err := filepath.Glob(filepath.Join(cfg.Dir, "member.empty-*"))
+		g.Expect(err).ToNot(HaveOccurred())
+		if entry.expectMovedAside {
+			g.Expect(movedAside).To(HaveLen(1))
+			g.Expect(filepath.Join(cfg.Dir, "member")).ToNot(BeADirectory())
+			g.Expect(filepath.Join(movedAside[0], "snap", "db")).To(BeAnExistingFile())
+		} else {
+			g.Expect(movedAside).To(BeEmpty())
+		}
+	}
+}
+
+func TestRunWithWALInOverriddenWALDir(t *testing.T) {
+	g := NewWithT(t)
+	testDir := t.TempDir()
+	dataDir := filepath.Join(testDir, "data")
+	walDir := filepath.Join(testDir, "wal")
+	g.Expect(os.MkdirAll(filepath.Join(dataDir, "member", "snap"), 0700)).To(Succeed())
+	g.Expect(os.WriteFile(GetDBPath(dataDir), []byte("db"), 0600)).To(Succeed())
+	g.Expect(os.MkdirAll(walDir, 0700)).To(Succeed())
+	g.Expect(os.WriteFile(filepath.Join(walDir, "0000000000000000-0000000000000000.wal"), nil, 0600)).To(Succeed())
+	etcdConfigFilePath := filepath.Join(testDir, "etcd.conf.yaml")
+	g.Expect(os.WriteFile(etcdConfigFilePath, []byte("name: etcd-test\ndata-dir: "+dataDir+"\n"), 0600)).To(Succeed())
+	fakeClient := &brclient.FakeClient{InitStatuses: []brclient.InitStatus{brclient.Successful}, EtcdConfigFilePath: etcdConfigFilePath}
+	logger := zaptest.NewLogger(t)
+	i := NewEtcdInitializerWithClient(fakeClient, &types.Config{WALDir: walDir}, state.NewMachine(logger), audit.NewNoopLogger(), logger)
+
+	t.Log("should find the WAL in the WAL directory configured for etcd-wrapper and not recover the data directory")
+	cfg, err := i.Run(context.Background())
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(cfg.WalDir).To(Equal(walDir))
+	g.Expect(fakeClient.TriggeredValidationTypes).To(BeEmpty())
+	g.Expect(filepath.Join(dataDir, "member")).To(BeADirectory())
+	movedAside, err := filepath.Glob(filepath.Join(dataDir, "member.empty-*"))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(movedAside).To(BeEmpty())
+}
+
+func TestRecoverEmptyDataDirRestorationWaitTimeout(t *testing.T) {
+	g := NewWithT(t)
+	cfg := embed.NewConfig()
+	cfg.Dir = t.TempDir()
+	g.Expect(os.MkdirAll(filepath.Join(cfg.Dir, "member"), 0700)).To(Succeed())
+	logger := zaptest.NewLogger(t)
+	stateMachine := state.NewMachine(logger)
+	g.Expect(stateMachine.TransitionTo(state.ProbingSidecar)).To(Succeed())
+	fakeClient := &brclient.FakeClient{InitStatuses: []brclient.InitStatus{brclient.InProgress}}
+	i := &initializer{brClient: fakeClient, stateMachine: stateMachine, auditLogger: audit.NewNoopLogger(), logger: logger}
+
+	t.Log("should fail with restoration wait timeout when the restoration does not complete")
+	err := i.recoverEmptyDataDir(context.Background(), cfg, newPhaseTimer(types.PhaseTimeoutsConfig{RestorationWait: time.Millisecond}, PhaseSidecarProbe))
+	var phaseTimeoutErr *PhaseTimeoutError
+	g.Expect(errors.As(err, &phaseTimeoutErr)).To(BeTrue())
+	g.Expect(phaseTimeoutErr.Phase).To(Equal(PhaseRestorationWait))
+}
+
+func TestPruneEmptyMemberDirs(t *testing.T) {
+	g := NewWithT(t)
+	dir := t.TempDir()
+	for _, movedAt := range []int{100, 400, 200, 300} {
+		g.Expect(os.Mkdir(filepath.Join(dir, fmt.Sprintf("%s%d", emptyMemberDirPrefix, movedAt)), 0700)).To(Succeed())
+	}
+	g.Expect(os.Mkdir(filepath.Join(dir, "member"), 0700)).To(Succeed())
+
+	t.Log("should keep only the most recent member directories moved aside")
+	g.Expect(pruneEmptyMemberDirs(dir, 2)).To(Succeed())
+	movedAside, err := filepath.Glob(filepath.Join(dir, emptyMemberDirPrefix+"*"))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(movedAside).To(ConsistOf(filepath.Join(dir, emptyMemberDirPrefix+"300"), filepath.Join(dir, emptyMemberDirPrefix+"400")))
+	g.Expect(filepath.Join(dir, "member")).To(BeADirectory())
+}
@@ -0,0 +1,150 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstrap
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+
+	"go.etcd.io/etcd/embed"
+	"go.etcd.io/etcd/pkg/types"
+	"sigs.k8s.io/yaml"
+)
+
+// etcdConfigFile mirrors the structure of the etcd configuration file as parsed by embed.ConfigFromFile, which
+// additionally accepts URLs as comma-separated strings and the transport security as nested sections.
+type etcdConfigFile struct {
+	embed.Config
+	ListenPeerURLs       string                `json:"listen-peer-urls"`
+	ListenClientURLs     string                `json:"listen-client-urls"`
+	ListenClientHTTPURLs string                `json:"listen-client-http-urls"`
+	AdvertisePeerURLs    string                `json:"initial-advertise-peer-urls"`
+	AdvertiseClientURLs  string                `json:"advertise-client-urls"`
+	CORS                 string                `json:"cors"`
+	HostWhitelist        string                `json:"host-whitelist"`
+	ClientSecurity       etcdTransportSecurity `json:"client-transport-security"`
+	PeerSecurity         etcdTransportSecurity `json:"peer-transport-security"`
+}
+
+// etcdTransportSecurity mirrors a transport security section of the etcd configuration file.
+type etcdTransportSecurity struct {
+	CertFile      string `json:"cert-file"`
+	KeyFile       string `json:"key-file"`
+	CertAuth      bool   `json:"client-cert-auth"`
+	TrustedCAFile string `json:"trusted-ca-file"`
+	AutoTLS       bool   `json:"auto-tls"`
+}
+
+// LoadEtcdConfig strictly parses the etcd configuration file at path and returns the etcd configuration. In contrast
+// to embed.ConfigFromFile, unknown fields are rejected and all malformed fields are reported at once with their names,
+// instead of failing deep inside embed.StartEtcd or, for malformed URLs, exiting the process.
+func LoadEtcdConfig(path string) (*embed.Config, error) {
+	data, err := os.ReadFile(path) // #nosec G304 -- path is the etcd configuration written by etcd-wrapper.
+	if err != nil {
+		return nil, err
+	}
+	if err = validateEtcdConfig(data); err != nil {
+		return nil, fmt.Errorf("invalid etcd configuration %s: %w", path, err)
+	}
+	return embed.ConfigFromFile(path)
+}
+
+// validateEtcdConfig validates the content of an etcd configuration file.
+func validateEtcdConfig(data []byte) error {
+	var fields map[string]any
+	if err := yaml.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("malformed YAML: %w", err)
+	}
+	var errs []error
+	for _, field := range unknownFields("", fields, reflect.TypeOf(etcdConfigFile{})) {
+		errs = append(errs, fmt.Errorf("unknown field %q", field))
+	}
+	var file etcdConfigFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		errs = append(errs, err)
+		return errors.Join(errs...)
+	}
+	urlFields := []struct {
+		name string
+		urls string
+	}{
+		{"listen-peer-urls", file.ListenPeerURLs},
+		{"listen-client-urls", file.ListenClientURLs},
+		{"listen-client-http-urls", file.ListenClientHTTPURLs},
+		{"initial-advertise-peer-urls", file.AdvertisePeerURLs},
+		{"advertise-client-urls", file.AdvertiseClientURLs},
+		{"listen-metrics-urls", file.ListenMetricsUrlsJSON},
+	}
+	for _, f := range urlFields {
+		if f.urls == "" {
+			continue
+		}
+		if _, err := types.NewURLs(strings.Split(f.urls, ",")); err != nil {
+			errs = append(errs, fmt.Errorf("malformed field %q: %w", f.name, err))
+		}
+	}
+	if file.InitialCluster != "" {
+		if _, err := types.NewURLsMap(file.InitialCluster); err != nil {
+			errs = append(errs, fmt.Errorf("malformed field %q: %w", "initial-cluster", err))
+		}
+	}
+	if state := file.ClusterState; state != "" && state != embed.ClusterStateFlagNew && state != embed.ClusterStateFlagExisting {
+		errs = append(errs, fmt.Errorf("malformed field %q: must be one of: %s, %s", "initial-cluster-state", embed.ClusterStateFlagNew, embed.ClusterStateFlagExisting))
+	}
+	return errors.Join(errs...)
+}
+
+// unknownFields returns the keys of fields, recursively and prefixed with their parent keys, which do not match a
+// field of the struct type t. As in encoding/json, keys are matched case-insensitively.
+func unknownFields(prefix string, fields map[string]any, t reflect.Type) []string {
+	known := jsonFields(t)
+	var unknown []string
+	for key, value := range fields {
+		fieldType, ok := known[strings.ToLower(key)]
+		if !ok {
+			unknown = append(unknown, prefix+key)
+			continue
+		}
+		if nested, isMap := value.(map[string]any); isMap && fieldType.Kind() == reflect.Struct {
+			unknown = append(unknown, unknownFields(prefix+key+".", nested, fieldType)...)
+		}
+	}
+	slices.Sort(unknown)
+	return unknown
+}
+
+// jsonFields returns the types of the fields of the struct type t by their lower-cased JSON names, including the
+// fields of embedded structs.
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			for embeddedName, embeddedType := range jsonFields(field.Type) {
+				if _, shadowed := fields[embeddedName]; !shadowed {
+					fields[embeddedName] = embeddedType
+				}
+			}
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[strings.ToLower(name)] = field.Type
+	}
+	return fields
+}
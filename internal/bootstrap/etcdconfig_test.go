@@ -0,0 +1,94 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstrap
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+const validEtcdConfig = `name: etcd-main-0
+data-dir: /var/etcd/data/new.etcd
+metrics: extensive
+snapshot-count: 75000
+enable-v2: false
+quota-backend-bytes: 8589934592
+heartbeat-interval: 100
+listen-client-urls: https://0.0.0.0:2379
+advertise-client-urls: https://etcd-main-0.etcd-main-peer.shoot--dev.svc:2379
+listen-peer-urls: https://0.0.0.0:2380
+initial-advertise-peer-urls: https://etcd-main-0.etcd-main-peer.shoot--dev.svc:2380
+initial-cluster-token: etcd-cluster
+initial-cluster-state: new
+initial-cluster: etcd-main-0=https://etcd-main-0.etcd-main-peer.shoot--dev.svc:2380
+auto-compaction-mode: periodic
+auto-compaction-retention: 30m
+client-transport-security:
+  cert-file: /var/etcd/ssl/server/tls.crt
+  key-file: /var/etcd/ssl/server/tls.key
+  client-cert-auth: true
+  trusted-ca-file: /var/etcd/ssl/ca/bundle.crt
+  auto-tls: false
+`
+
+func TestValidateEtcdConfig(t *testing.T) {
+	table := []struct {
+		description      string
+		config           string
+		expectedMessages []string
+	}{
+		{"should accept valid etcd configuration", validEtcdConfig, nil},
+		{"should reject malformed YAML", "name: [etcd", []string{"malformed YAML"}},
+		{"should report all unknown fields including nested ones", validEtcdConfig + "heartbeat-intervall: 100\npeer-transport-security:\n  trusted-ca: /var/etcd/ssl/ca/bundle.crt\n",
+			[]string{`unknown field "heartbeat-intervall"`, `unknown field "peer-transport-security.trusted-ca"`}},
+		{"should report field with wrong type", "name: etcd-main-0\nheartbeat-interval: fast\n", []string{"heartbeat-interval"}},
+		{"should report all malformed URLs", "listen-client-urls: 0.0.0.0:2379\ninitial-cluster: etcd-main-0\n",
+			[]string{`malformed field "listen-client-urls"`, `malformed field "initial-cluster"`}},
+		{"should report unsupported initial cluster state", "initial-cluster-state: joining\n", []string{`malformed field "initial-cluster-state"`}},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		err := validateEtcdConfig([]byte(entry.config))
+		if entry.expectedMessages == nil {
+			g.Expect(err).ToNot(HaveOccurred())
+			continue
+		}
+		g.Expect(err).To(HaveOccurred())
+		for _, message := range entry.expectedMessages {
+			g.Expect(err.Error()).To(ContainSubstring(message))
+		}
+	}
+}
+
+func TestLoadEtcdConfig(t *testing.T) {
+	g := NewWithT(t)
+	dir := t.TempDir()
+
+	t.Log("should load valid etcd configuration")
+	path := filepath.Join(dir, "etcd.conf.yaml")
+	g.Expect(os.WriteFile(path, []byte(validEtcdConfig), 0600)).To(Succeed())
+	cfg, err := LoadEtcdConfig(path)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(cfg.Name).To(Equal("etcd-main-0"))
+	g.Expect(cfg.TickMs).To(Equal(uint(100)))
+	g.Expect(cfg.ListenClientUrls[0].String()).To(Equal("https://0.0.0.0:2379"))
+	g.Expect(cfg.ClientTLSInfo.TrustedCAFile).To(Equal("/var/etcd/ssl/ca/bundle.crt"))
+	g.Expect(cfg.GRPCKeepAliveTimeout).To(Equal(20 * time.Second))
+
+	t.Log("should return error naming the configuration file when it is invalid")
+	g.Expect(os.WriteFile(path, []byte("listen-client-urls: 0.0.0.0:2379\n"), 0600)).To(Succeed())
+	_, err = LoadEtcdConfig(path)
+	g.Expect(err).To(MatchError(ContainSubstring(path)))
+
+	t.Log("should return error when configuration file does not exist")
+	_, err = LoadEtcdConfig(filepath.Join(dir, "does-not-exist.yaml"))
+	g.Expect(err).To(HaveOccurred())
+}
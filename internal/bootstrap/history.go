@@ -0,0 +1,152 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstrap
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/state"
+	"github.com/gardener/etcd-wrapper/internal/util"
+)
+
+// AttemptOutcome is the outcome of a start attempt of etcd-wrapper.
+type AttemptOutcome string
+
+const (
+	// OutcomeInProgress indicates that the start attempt is still in progress.
+	OutcomeInProgress AttemptOutcome = "InProgress"
+	// OutcomeBootstrapped indicates that the data directory has been initialized, but etcd has not (yet) been started.
+	OutcomeBootstrapped AttemptOutcome = "Bootstrapped"
+	// OutcomeSucceeded indicates that etcd has become ready to serve client requests.
+	OutcomeSucceeded AttemptOutcome = "Succeeded"
+	// OutcomeFailed indicates that bootstrapping or starting etcd has failed with an error.
+	OutcomeFailed AttemptOutcome = "Failed"
+	// OutcomeCrashed indicates that etcd-wrapper has exited while the start attempt was still in progress, e.g. due to
+	// a panic or an OOM kill, which is only detected once the next start attempt begins.
+	OutcomeCrashed AttemptOutcome = "Crashed"
+	// OutcomeInterrupted indicates that the start attempt has been interrupted because etcd-wrapper has been stopped.
+	OutcomeInterrupted AttemptOutcome = "Interrupted"
+)
+
+// Attempt is a recorded start attempt of etcd-wrapper.
+type Attempt struct {
+	// StartedAt is the time at which the start attempt began.
+	StartedAt time.Time `json:"startedAt"`
+	// Phase is the last state reached by the start attempt.
+	Phase state.State `json:"phase"`
+	// Outcome is the outcome of the start attempt.
+	Outcome AttemptOutcome `json:"outcome"`
+}
+
+// failed returns true if the attempt has failed or crashed.
+func (a Attempt) failed() bool {
+	return a.Outcome == OutcomeFailed || a.Outcome == OutcomeCrashed
+}
+
+// History is a ring buffer of the most recent start attempts which is persisted in a file, so that it survives
+// restarts of etcd-wrapper. It is safe for concurrent use.
+type History struct {
+	mu       sync.Mutex
+	path     string
+	size     int
+	attempts []Attempt
+}
+
+// LoadHistory loads the History persisted at path which retains at most size attempts. If path is empty, the History
+// is not persisted. If the persisted History cannot be read, an empty History is returned along with the error.
+func LoadHistory(path string, size int) (*History, error) {
+	h := &History{path: path, size: size}
+	if path == "" {
+		return h, nil
+	}
+	data, err := os.ReadFile(path) // #nosec G304 -- path is configured by the operator.
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return h, nil
+		}
+		return h, fmt.Errorf("failed to read bootstrap history: %w", err)
+	}
+	var attempts []Attempt
+	if err = json.Unmarshal(data, &attempts); err != nil {
+		return h, fmt.Errorf("failed to parse bootstrap history: %w", err)
+	}
+	h.attempts = attempts
+	h.trim()
+	return h, nil
+}
+
+// Attempts returns the recorded attempts, oldest first.
+func (h *History) Attempts() []Attempt {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return slices.Clone(h.attempts)
+}
+
+// Begin records a new start attempt which began at startedAt. A previous attempt which is still in progress is
+// recorded as crashed.
+func (h *History) Begin(startedAt time.Time) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if last := len(h.attempts) - 1; last >= 0 && h.attempts[last].Outcome == OutcomeInProgress {
+		h.attempts[last].Outcome = OutcomeCrashed
+	}
+	h.attempts = append(h.attempts, Attempt{StartedAt: startedAt.UTC(), Phase: state.New, Outcome: OutcomeInProgress})
+	h.trim()
+	return h.save()
+}
+
+// Record records the phase reached and the outcome of the current start attempt. Attempts with a final outcome, i.e.
+// attempts which have neither been in progress nor only bootstrapped, are not changed.
+func (h *History) Record(phase state.State, outcome AttemptOutcome) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	last := len(h.attempts) - 1
+	if last < 0 || (h.attempts[last].Outcome != OutcomeInProgress && h.attempts[last].Outcome != OutcomeBootstrapped) {
+		return nil
+	}
+	h.attempts[last].Phase = phase
+	h.attempts[last].Outcome = outcome
+	return h.save()
+}
+
+// Failures returns the number of failed or crashed attempts which began at or after since.
+func (h *History) Failures(since time.Time) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	failures := 0
+	for _, attempt := range h.attempts {
+		if attempt.failed() && !attempt.StartedAt.Before(since) {
+			failures++
+		}
+	}
+	return failures
+}
+
+func (h *History) trim() {
+	if h.size > 0 && len(h.attempts) > h.size {
+		h.attempts = slices.Clone(h.attempts[len(h.attempts)-h.size:])
+	}
+}
+
+// save atomically persists the History by writing it into a temporary file which is then renamed.
+func (h *History) save() error {
+	if h.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(h.attempts)
+	if err != nil {
+		return err
+	}
+	if err = util.WriteFileAtomic(h.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write bootstrap history: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/audit"
+	"github.com/gardener/etcd-wrapper/internal/brclient"
+	"github.com/gardener/etcd-wrapper/internal/metrics"
+	"github.com/gardener/etcd-wrapper/internal/state"
+	"github.com/gardener/etcd-wrapper/internal/types"
+
+	. "github.com/onsi/gomega"
+	dto "github.com/prometheus/client_model/go"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestHistory(t *testing.T) {
+	g := NewWithT(t)
+	historyPath := filepath.Join(t.TempDir(), "bootstrap_history.json")
+	now := time.Now()
+
+	t.Log("should start with an empty history when no history has been persisted")
+	h, err := LoadHistory(historyPath, 3)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(h.Attempts()).To(BeEmpty())
+
+	t.Log("should record attempt which never completes as crashed once the next attempt begins")
+	g.Expect(h.Begin(now.Add(-4 * time.Minute))).To(Succeed())
+	g.Expect(h.Begin(now.Add(-3 * time.Minute))).To(Succeed())
+	g.Expect(h.Record(state.Validating, OutcomeFailed)).To(Succeed())
+
+	t.Log("should not change attempt with a final outcome")
+	g.Expect(h.Record(state.Ready, OutcomeSucceeded)).To(Succeed())
+
+	t.Log("should complete a bootstrapped attempt")
+	g.Expect(h.Begin(now.Add(-2 * time.Minute))).To(Succeed())
+	g.Expect(h.Record(state.StartingEtcd, OutcomeBootstrapped)).To(Succeed())
+	g.Expect(h.Record(state.Ready, OutcomeSucceeded)).To(Succeed())
+	g.Expect(h.Failures(now.Add(-5 * time.Minute))).To(Equal(2))
+	g.Expect(h.Failures(now.Add(-210 * time.Second))).To(Equal(1))
+
+	t.Log("should retain only the most recent attempts")
+	g.Expect(h.Begin(now)).To(Succeed())
+	attempts := h.Attempts()
+	g.Expect(attempts).To(HaveLen(3))
+	g.Expect(attempts[0]).To(Equal(Attempt{StartedAt: now.Add(-3 * time.Minute).UTC(), Phase: state.Validating, Outcome: OutcomeFailed}))
+	g.Expect(attempts[1].Outcome).To(Equal(OutcomeSucceeded))
+	g.Expect(attempts[2].Outcome).To(Equal(OutcomeInProgress))
+
+	t.Log("should load the persisted history")
+	loaded, err := LoadHistory(historyPath, 3)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(loaded.Attempts()).To(HaveLen(3))
+	g.Expect(loaded.Attempts()[0].StartedAt.Equal(attempts[0].StartedAt)).To(BeTrue())
+
+	t.Log("should return an empty history along with an error when the persisted history is corrupt")
+	g.Expect(os.WriteFile(historyPath, []byte("{"), 0600)).To(Succeed())
+	corrupt, err := LoadHistory(historyPath, 3)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(corrupt.Attempts()).To(BeEmpty())
+}
+
+func TestRunRecordsAttempts(t *testing.T) {
+	table := []struct {
+		description       string
+		previousOutcomes  []AttemptOutcome
+		fakeClient        *brclient.FakeClient
+		expectedOutcome   AttemptOutcome
+		expectCrashLooped bool
+	}{
+		{"should record bootstrapped attempt", nil, &brclient.FakeClient{InitStatuses: []brclient.InitStatus{brclient.Successful}}, OutcomeBootstrapped, false},
+		{"should record failed attempt", nil, &brclient.FakeClient{InitStatuses: []brclient.InitStatus{brclient.Successful}, EtcdConfigErr: errors.New("unavailable")}, OutcomeFailed, false},
+		{"should detect crash loop when previous attempts have failed or crashed", []AttemptOutcome{OutcomeFailed, OutcomeInProgress}, &brclient.FakeClient{InitStatuses: []brclient.InitStatus{brclient.Successful}}, OutcomeBootstrapped, true},
+		{"should not detect crash loop when previous attempts have succeeded", []AttemptOutcome{OutcomeSucceeded, OutcomeInterrupted}, &brclient.FakeClient{InitStatuses: []brclient.InitStatus{brclient.Successful}}, OutcomeBootstrapped, false},
+	}
+
+	for _, entry := range table {
+		t.Run(entry.description, func(t *testing.T) {
+			g := NewWithT(t)
+			testDir := t.TempDir()
+			etcdConfigFilePath := filepath.Join(testDir, "etcd.conf.yaml")
+			g.Expect(os.WriteFile(etcdConfigFilePath, []byte("name: etcd-test\ndata-dir: "+filepath.Join(testDir, "data")+"\n"), 0600)).To(Succeed())
+			entry.fakeClient.EtcdConfigFilePath = etcdConfigFilePath
+			historyPath := filepath.Join(testDir, "bootstrap_history.json")
+			previous, err := LoadHistory(historyPath, types.DefaultBootstrapHistorySize)
+			g.Expect(err).ToNot(HaveOccurred())
+			for _, outcome := range entry.previousOutcomes {
+				g.Expect(previous.Begin(time.Now().Add(-time.Minute))).To(Succeed())
+				g.Expect(previous.Record(state.Validating, outcome)).To(Succeed())
+			}
+
+			logger := zaptest.NewLogger(t)
+			config := &types.Config{SkipRestoreVerification: true, BootstrapHistory: types.BootstrapHistoryConfig{Path: historyPath, CrashLoopThreshold: 2, CrashLoopWindow: time.Hour}}
+			i := NewEtcdInitializerWithClient(entry.fakeClient, config, state.NewMachine(logger), audit.NewNoopLogger(), logger)
+			_, _ = i.Run(context.Background())
+
+			history, err := LoadHistory(historyPath, types.DefaultBootstrapHistorySize)
+			g.Expect(err).ToNot(HaveOccurred())
+			attempts := history.Attempts()
+			g.Expect(attempts).To(HaveLen(len(entry.previousOutcomes) + 1))
+			g.Expect(attempts[len(attempts)-1].Outcome).To(Equal(entry.expectedOutcome))
+			crashLoopDetected := &dto.Metric{}
+			g.Expect(metrics.CrashLoopDetected.Write(crashLoopDetected)).To(Succeed())
+			g.Expect(crashLoopDetected.GetGauge().GetValue() == 1).To(Equal(entry.expectCrashLooped))
+		})
+	}
+}
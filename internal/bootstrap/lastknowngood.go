@@ -0,0 +1,55 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstrap
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/gardener/etcd-wrapper/internal/util"
+
+	"go.etcd.io/etcd/embed"
+	"go.uber.org/zap"
+)
+
+// SaveLastKnownGoodConfig atomically copies the etcd configuration file at etcdConfigPath to path, by writing it into a
+// temporary file which is then renamed.
+func SaveLastKnownGoodConfig(etcdConfigPath, path string) error {
+	data, err := os.ReadFile(etcdConfigPath) // #nosec G304 -- path is the etcd configuration used to start etcd.
+	if err != nil {
+		return err
+	}
+	if err = util.WriteFileAtomic(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write last known good etcd configuration: %w", err)
+	}
+	return nil
+}
+
+// loadLastKnownGoodConfig loads the etcd configuration with which etcd last became ready, as a fallback for an etcd
+// configuration which cannot be fetched from backup-restore.
+func (i *initializer) loadLastKnownGoodConfig(fetchErr error) (*embed.Config, error) {
+	cfg, err := LoadEtcdConfig(i.lastKnownGood.Path)
+	if err != nil {
+		return nil, errors.Join(fetchErr, fmt.Errorf("failed to load the last known good etcd configuration: %w", err))
+	}
+	i.logger.Warn("Failed to fetch the etcd configuration from backup-restore, using the last known good etcd configuration",
+		zap.String("path", i.lastKnownGood.Path), zap.Error(fetchErr))
+	i.usedEtcdConfigFilePath = i.lastKnownGood.Path
+	return cfg, nil
+}
+
+// saveLastKnownGoodConfig persists the etcd configuration used by the current start attempt as last known good etcd
+// configuration.
+func (i *initializer) saveLastKnownGoodConfig() {
+	if i.lastKnownGood.Path == "" || i.usedEtcdConfigFilePath == "" || i.usedEtcdConfigFilePath == i.lastKnownGood.Path {
+		return
+	}
+	if err := SaveLastKnownGoodConfig(i.usedEtcdConfigFilePath, i.lastKnownGood.Path); err != nil {
+		i.logger.Error("failed to persist the last known good etcd configuration", zap.String("path", i.lastKnownGood.Path), zap.Error(err))
+		return
+	}
+	i.logger.Info("Persisted the last known good etcd configuration", zap.String("path", i.lastKnownGood.Path))
+}
@@ -0,0 +1,88 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/audit"
+	"github.com/gardener/etcd-wrapper/internal/brclient"
+	"github.com/gardener/etcd-wrapper/internal/state"
+	"github.com/gardener/etcd-wrapper/internal/types"
+
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestTryGetEtcdConfigLastKnownGood(t *testing.T) {
+	table := []struct {
+		description       string
+		useOnFetchFailure bool
+		lastKnownGood     bool
+		expectedName      string
+		expectError       bool
+	}{
+		{"should use the last known good config if the fetch fails", true, true, "etcd-last-known-good", false},
+		{"should return error if the last known good config does not exist", true, false, "", true},
+		{"should return error if the last known good config is not used", false, true, "", true},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		lastKnownGoodPath := filepath.Join(t.TempDir(), "last_known_good_etcd_config.yaml")
+		if entry.lastKnownGood {
+			g.Expect(os.WriteFile(lastKnownGoodPath, []byte("name: etcd-last-known-good\n"), 0600)).To(Succeed())
+		}
+		logger := zaptest.NewLogger(t)
+		config := &types.Config{LastKnownGoodConfig: types.LastKnownGoodConfig{Path: lastKnownGoodPath, UseOnFetchFailure: entry.useOnFetchFailure}}
+		i := NewEtcdInitializerWithClient(&brclient.FakeClient{EtcdConfigErr: errors.New("connection refused")}, config, state.NewMachine(logger), audit.NewNoopLogger(), logger).(*initializer)
+		cfg, err := i.tryGetEtcdConfig(context.Background(), 1, time.Millisecond)
+		g.Expect(err != nil).To(Equal(entry.expectError))
+		if !entry.expectError {
+			g.Expect(cfg.Name).To(Equal(entry.expectedName))
+		}
+	}
+}
+
+func TestSaveLastKnownGoodConfig(t *testing.T) {
+	table := []struct {
+		description string
+		outcome     AttemptOutcome
+		expectSaved bool
+	}{
+		{"should persist the etcd config once etcd has become ready", OutcomeSucceeded, true},
+		{"should not persist the etcd config if etcd has failed", OutcomeFailed, false},
+		{"should not persist the etcd config if etcd has not been started yet", OutcomeBootstrapped, false},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		testDir := t.TempDir()
+		etcdConfigPath := filepath.Join(testDir, "etcd.conf.yaml")
+		g.Expect(os.WriteFile(etcdConfigPath, []byte("name: etcd-test\n"), 0600)).To(Succeed())
+		lastKnownGoodPath := filepath.Join(testDir, "last_known_good_etcd_config.yaml")
+		logger := zaptest.NewLogger(t)
+		config := &types.Config{LastKnownGoodConfig: types.LastKnownGoodConfig{Path: lastKnownGoodPath}}
+		i := NewEtcdInitializerWithClient(&brclient.FakeClient{EtcdConfigFilePath: etcdConfigPath}, config, state.NewMachine(logger), audit.NewNoopLogger(), logger).(*initializer)
+		_, err := i.tryGetEtcdConfig(context.Background(), 1, time.Millisecond)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		i.RecordOutcome(entry.outcome)
+		data, err := os.ReadFile(lastKnownGoodPath)
+		if !entry.expectSaved {
+			g.Expect(errors.Is(err, os.ErrNotExist)).To(BeTrue())
+			continue
+		}
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(string(data)).To(Equal("name: etcd-test\n"))
+	}
+}
@@ -0,0 +1,91 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstrap
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/types"
+)
+
+// Phase is a timed phase of bootstrapping etcd.
+type Phase string
+
+const (
+	// PhaseSidecarProbe is the phase in which the initialization status is fetched from backup-restore till it responds.
+	PhaseSidecarProbe Phase = "sidecar-probe"
+	// PhaseValidation is the phase in which backup-restore validates the data directory after initialization has been triggered.
+	PhaseValidation Phase = "validation"
+	// PhaseRestorationWait is the phase in which etcd-wrapper waits for backup-restore to finish an initialization in progress.
+	PhaseRestorationWait Phase = "restoration-wait"
+	// PhaseEtcdReady is the phase in which etcd-wrapper waits for the embedded etcd to be ready.
+	PhaseEtcdReady Phase = "etcd-ready"
+)
+
+var phaseExitCodes = map[Phase]int{
+	PhaseSidecarProbe:    types.ExitCodeSidecarProbeTimeout,
+	PhaseValidation:      types.ExitCodeValidationTimeout,
+	PhaseRestorationWait: types.ExitCodeRestorationWaitTimeout,
+	PhaseEtcdReady:       types.ExitCodeEtcdReadyTimeout,
+}
+
+// PhaseTimeoutError is returned when a bootstrap phase has not completed within its timeout.
+type PhaseTimeoutError struct {
+	// Phase is the phase which timed out.
+	Phase Phase
+	// Timeout is the configured timeout of the phase.
+	Timeout time.Duration
+}
+
+func (e *PhaseTimeoutError) Error() string {
+	return fmt.Sprintf("%s phase did not complete within %s", e.Phase, e.Timeout)
+}
+
+// ExitCode returns the process exit code specific to the phase which timed out.
+func (e *PhaseTimeoutError) ExitCode() int {
+	return phaseExitCodes[e.Phase]
+}
+
+// phaseTimer tracks the current phase and the time at which it has been entered.
+type phaseTimer struct {
+	timeouts types.PhaseTimeoutsConfig
+	current  Phase
+	start    time.Time
+}
+
+func newPhaseTimer(timeouts types.PhaseTimeoutsConfig, phase Phase) *phaseTimer {
+	return &phaseTimer{timeouts: timeouts, current: phase, start: time.Now()}
+}
+
+// enter switches to the given phase. The timer is only reset if the phase changes.
+func (p *phaseTimer) enter(phase Phase) {
+	if p.current != phase {
+		p.current = phase
+		p.start = time.Now()
+	}
+}
+
+// check returns a PhaseTimeoutError if the current phase has exceeded its timeout. A zero timeout never expires.
+func (p *phaseTimer) check() error {
+	timeout := p.timeout()
+	if timeout > 0 && time.Since(p.start) > timeout {
+		return &PhaseTimeoutError{Phase: p.current, Timeout: timeout}
+	}
+	return nil
+}
+
+func (p *phaseTimer) timeout() time.Duration {
+	switch p.current {
+	case PhaseSidecarProbe:
+		return p.timeouts.SidecarProbe
+	case PhaseValidation:
+		return p.timeouts.Validation
+	case PhaseRestorationWait:
+		return p.timeouts.RestorationWait
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,59 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+
+	"go.etcd.io/etcd/embed"
+	"go.uber.org/zap"
+)
+
+// RestoreInfo describes a restoration of the etcd data directory which happened during initialization.
+type RestoreInfo struct {
+	// RestoredAt is the time at which the restored etcd DB has been written.
+	RestoredAt time.Time `json:"restoredAt"`
+	// Revision is the latest revision in the restored etcd DB.
+	Revision int64 `json:"revision"`
+	// FullSnapshot is the name of the latest full snapshot known to backup-restore.
+	FullSnapshot string `json:"fullSnapshot,omitempty"`
+	// SnapshotRevision is the last revision of the latest snapshots known to backup-restore.
+	SnapshotRevision int64 `json:"snapshotRevision,omitempty"`
+}
+
+// detectRestoration detects whether the etcd DB has been (re-)written by backup-restore since initStart, which is
+// only the case if the data directory has been restored. It returns nil if no restoration has been detected.
+func (i *initializer) detectRestoration(ctx context.Context, cfg *embed.Config, initStart time.Time) *RestoreInfo {
+	dbPath := GetDBPath(cfg.Dir)
+	info, err := os.Stat(dbPath)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			i.logger.Error("failed to stat etcd db for restoration detection", zap.String("path", dbPath), zap.Error(err))
+		}
+		return nil
+	}
+	if info.ModTime().Before(initStart) {
+		return nil
+	}
+	restoreInfo := &RestoreInfo{RestoredAt: info.ModTime().UTC()}
+	if metadata, err := ReadDBMetadata(dbPath); err != nil {
+		i.logger.Error("failed to read metadata of restored etcd db", zap.String("path", dbPath), zap.Error(err))
+	} else {
+		restoreInfo.Revision = metadata.Revision
+	}
+	if latestSnapshots, err := i.brClient.GetLatestSnapshots(ctx); err != nil {
+		i.logger.Error("failed to fetch latest snapshots for restore info", zap.Error(err))
+	} else if latestSnapshots != nil {
+		if latestSnapshots.FullSnapshot != nil {
+			restoreInfo.FullSnapshot = latestSnapshots.FullSnapshot.SnapName
+		}
+		restoreInfo.SnapshotRevision = latestSnapshots.LastRevision()
+	}
+	i.logger.Info("Detected restoration of etcd data directory", zap.Any("restoreInfo", restoreInfo))
+	return restoreInfo
+}
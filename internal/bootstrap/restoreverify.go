@@ -0,0 +1,172 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstrap
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+	"go.etcd.io/etcd/embed"
+	"go.etcd.io/etcd/etcdserver/api/snap"
+	"go.uber.org/zap"
+)
+
+const (
+	dbOpenTimeout = 10 * time.Second
+	// revBytesLen is the length of a revision key in the `key` bucket, see go.etcd.io/etcd/mvcc/revision.go
+	revBytesLen = 8 + 1 + 8
+)
+
+var (
+	keyBucketName           = []byte("key")
+	metaBucketName          = []byte("meta")
+	consistentIndexName     = []byte("consistent_index")
+	finishedCompactRevName  = []byte("finishedCompactRev")
+	scheduledCompactRevName = []byte("scheduledCompactRev")
+)
+
+// DBMetadata is the metadata read from the etcd backend DB.
+type DBMetadata struct {
+	// Revision is the latest revision stored in the DB. Like etcd when restoring its store, it is the maximum of the
+	// revision of the last key and the finished and scheduled compaction revisions, since a compaction can remove
+	// trailing tombstones.
+	Revision int64
+	// ConsistentIndex is the index of the last raft entry applied to the DB.
+	ConsistentIndex uint64
+}
+
+// GetSnapDir returns the path of the directory holding the raft snapshots and the backend DB for the given data directory.
+func GetSnapDir(dataDir string) string {
+	return filepath.Join(dataDir, "member", "snap")
+}
+
+// GetDBPath returns the path of the etcd backend DB for the given data directory.
+func GetDBPath(dataDir string) string {
+	return filepath.Join(GetSnapDir(dataDir), "db")
+}
+
+// ReadDBMetadata opens the etcd backend DB at dbPath in read-only mode and reads its DBMetadata.
+func ReadDBMetadata(dbPath string) (DBMetadata, error) {
+	var metadata DBMetadata
+	db, err := bolt.Open(dbPath, 0400, &bolt.Options{ReadOnly: true, Timeout: dbOpenTimeout})
+	if err != nil {
+		return metadata, fmt.Errorf("failed to open etcd db %s: %w", dbPath, err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	err = db.View(func(tx *bolt.Tx) error {
+		if keyBucket := tx.Bucket(keyBucketName); keyBucket != nil {
+			// keys are revisions encoded in big-endian, so the last key is the latest revision.
+			if k, _ := keyBucket.Cursor().Last(); len(k) >= revBytesLen {
+				metadata.Revision = int64(binary.BigEndian.Uint64(k[0:8])) // #nosec G115 -- revisions are written by etcd as int64.
+			}
+		}
+		if metaBucket := tx.Bucket(metaBucketName); metaBucket != nil {
+			if v := metaBucket.Get(consistentIndexName); len(v) == 8 {
+				metadata.ConsistentIndex = binary.BigEndian.Uint64(v)
+			}
+			for _, compactRevName := range [][]byte{finishedCompactRevName, scheduledCompactRevName} {
+				if v := metaBucket.Get(compactRevName); len(v) >= 8 {
+					metadata.Revision = max(metadata.Revision, int64(binary.BigEndian.Uint64(v[0:8]))) // #nosec G115 -- revisions are written by etcd as int64.
+				}
+			}
+		}
+		return nil
+	})
+	return metadata, err
+}
+
+// readRaftSnapshotIndex returns the raft index of the newest raft snapshot in snapDir. It returns 0 if there is none.
+func readRaftSnapshotIndex(logger *zap.Logger, snapDir string) (uint64, error) {
+	raftSnapshot, err := snap.New(logger, snapDir).Load()
+	if err != nil {
+		if errors.Is(err, snap.ErrNoSnapshot) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to load raft snapshot from %s: %w", snapDir, err)
+	}
+	return raftSnapshot.Metadata.Index, nil
+}
+
+// verifyRestoredDataDir verifies that the etcd DB in the data directory is at least as recent as the latest snapshot
+// reported by backup-restore, if backup-restore has restored the data directory as described by restoreInfo.
+// Verification is skipped if no restoration has been detected, so that a plain restart of a healthy member does not
+// depend on backup-restore serving its latest snapshots. Backup-restore only restores the data directory of
+// single-member clusters, hence verification is skipped for multi-member clusters where a member's DB can legitimately
+// lag behind the latest snapshot.
+func (i *initializer) verifyRestoredDataDir(ctx context.Context, cfg *embed.Config, restoreInfo *RestoreInfo) error {
+	if restoreInfo == nil {
+		i.logger.Info("No restoration of the data directory detected, skipping restore verification")
+		return nil
+	}
+	if countInitialClusterMembers(cfg.InitialCluster) > 1 {
+		i.logger.Info("Skipping restore verification for multi-member etcd cluster")
+		return nil
+	}
+	latestSnapshots, err := i.brClient.GetLatestSnapshots(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch latest snapshots for restore verification: %w", err)
+	}
+	if latestSnapshots == nil {
+		i.logger.Info("No snapshots found, skipping restore verification")
+		return nil
+	}
+	snapshotRevision := latestSnapshots.LastRevision()
+
+	dbPath := GetDBPath(cfg.Dir)
+	if _, err = os.Stat(dbPath); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("restore verification failed: etcd db %s does not exist but latest snapshot has revision %d", dbPath, snapshotRevision)
+		}
+		return err
+	}
+	metadata, err := ReadDBMetadata(dbPath)
+	if err != nil {
+		return err
+	}
+	i.logger.Info("Verifying etcd db against latest snapshot",
+		zap.Int64("dbRevision", metadata.Revision),
+		zap.Uint64("dbConsistentIndex", metadata.ConsistentIndex),
+		zap.Int64("snapshotRevision", snapshotRevision))
+
+	if metadata.Revision > 0 && metadata.ConsistentIndex == 0 {
+		return fmt.Errorf("restore verification failed: etcd db %s has revision %d but no consistent index", dbPath, metadata.Revision)
+	}
+	// Snapshots of backup-restore only carry revisions and no raft index, hence the consistent index is verified
+	// against the raft snapshot written alongside the DB on restoration. etcd refuses to start if the DB has not
+	// applied all entries up to the raft snapshot.
+	raftSnapshotIndex, err := readRaftSnapshotIndex(i.logger, GetSnapDir(cfg.Dir))
+	if err != nil {
+		return err
+	}
+	if metadata.ConsistentIndex < raftSnapshotIndex {
+		return fmt.Errorf("restore verification failed: etcd db %s has consistent index %d which is older than the raft snapshot index %d", dbPath, metadata.ConsistentIndex, raftSnapshotIndex)
+	}
+	if metadata.Revision < snapshotRevision {
+		return fmt.Errorf("restore verification failed: etcd db %s has revision %d which is older than the latest snapshot revision %d", dbPath, metadata.Revision, snapshotRevision)
+	}
+	return nil
+}
+
+// countInitialClusterMembers counts the distinct member names in an initial-cluster string of the form `name=url,name=url`.
+func countInitialClusterMembers(initialCluster string) int {
+	names := make(map[string]struct{})
+	for _, member := range strings.Split(initialCluster, ",") {
+		name, _, found := strings.Cut(strings.TrimSpace(member), "=")
+		if found {
+			names[name] = struct{}{}
+		}
+	}
+	return len(names)
+}
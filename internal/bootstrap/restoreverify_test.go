@@ -0,0 +1,208 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstrap
+
+import (
+	"context"
+	"encoding/binary"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/brclient"
+	. "github.com/onsi/gomega"
+	bolt "go.etcd.io/bbolt"
+	"go.etcd.io/etcd/embed"
+	"go.etcd.io/etcd/etcdserver/api/snap"
+	"go.etcd.io/etcd/lease"
+	"go.etcd.io/etcd/mvcc"
+	"go.etcd.io/etcd/mvcc/backend"
+	"go.etcd.io/etcd/pkg/traceutil"
+	"go.etcd.io/etcd/raft/raftpb"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestReadDBMetadata(t *testing.T) {
+	g := NewWithT(t)
+	dataDir := t.TempDir()
+	createTestDB(g, dataDir, 42, 100)
+
+	metadata, err := ReadDBMetadata(GetDBPath(dataDir))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(metadata.Revision).To(Equal(int64(42)))
+	g.Expect(metadata.ConsistentIndex).To(Equal(uint64(100)))
+}
+
+type fixedConsistentIndex uint64
+
+func (i fixedConsistentIndex) ConsistentIndex() uint64 {
+	return uint64(i)
+}
+
+func TestReadDBMetadataAfterCompactionOfTombstone(t *testing.T) {
+	g := NewWithT(t)
+	dataDir := t.TempDir()
+	g.Expect(os.MkdirAll(GetSnapDir(dataDir), 0700)).To(Succeed())
+	lg := zaptest.NewLogger(t)
+	b := backend.NewDefaultBackend(GetDBPath(dataDir))
+	s := mvcc.NewStore(lg, b, &lease.FakeLessor{}, fixedConsistentIndex(7), mvcc.StoreConfig{})
+	s.Put([]byte("foo"), []byte("bar"), lease.NoLease)
+	_, deleteRev := s.DeleteRange([]byte("foo"), nil)
+	done, err := s.Compact(traceutil.TODO(), deleteRev)
+	g.Expect(err).ToNot(HaveOccurred())
+	<-done
+	g.Expect(s.Close()).To(Succeed())
+	g.Expect(b.Close()).To(Succeed())
+
+	metadata, err := ReadDBMetadata(GetDBPath(dataDir))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(metadata.Revision).To(Equal(deleteRev))
+	g.Expect(metadata.ConsistentIndex).To(Equal(uint64(7)))
+}
+
+func TestCountInitialClusterMembers(t *testing.T) {
+	table := []struct {
+		description    string
+		initialCluster string
+		expectedCount  int
+	}{
+		{"empty initial cluster has no members", "", 0},
+		{"single member", "etcd-0=https://etcd-0:2380", 1},
+		{"single member with multiple peer urls", "etcd-0=https://etcd-0:2380,etcd-0=https://10.0.0.1:2380", 1},
+		{"multiple members", "etcd-0=https://etcd-0:2380,etcd-1=https://etcd-1:2380,etcd-2=https://etcd-2:2380", 3},
+	}
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		g.Expect(countInitialClusterMembers(entry.initialCluster)).To(Equal(entry.expectedCount))
+	}
+}
+
+func TestVerifyRestoredDataDir(t *testing.T) {
+	const singleMemberCluster = "etcd-0=https://etcd-0:2380"
+	table := []struct {
+		description     string
+		initialCluster  string
+		restored        bool
+		responseCode    int
+		responseBody    []byte
+		dbRevision      int64
+		consistentIndex uint64
+		createDB        bool
+		raftIndex       uint64
+		expectError     bool
+	}{
+		{"should skip verification when the data directory has not been restored", singleMemberCluster, false, http.StatusOK, []byte(`{"fullSnapshot":{"lastRevision":10}}`), 5, 6, true, 0, false},
+		{"should succeed when latest snapshots cannot be fetched and the data directory has not been restored", singleMemberCluster, false, http.StatusInternalServerError, nil, 0, 0, false, 0, false},
+		{"should skip verification for multi-member cluster", "etcd-0=https://etcd-0:2380,etcd-1=https://etcd-1:2380", true, http.StatusInternalServerError, nil, 0, 0, false, 0, false},
+		{"should skip verification when there are no snapshots", singleMemberCluster, true, http.StatusNotFound, nil, 0, 0, false, 0, false},
+		{"should return error when latest snapshots cannot be fetched", singleMemberCluster, true, http.StatusInternalServerError, nil, 0, 0, false, 0, true},
+		{"should return error when db does not exist", singleMemberCluster, true, http.StatusOK, []byte(`{"fullSnapshot":{"lastRevision":10}}`), 0, 0, false, 0, true},
+		{"should return error when db revision is older than snapshot revision", singleMemberCluster, true, http.StatusOK, []byte(`{"fullSnapshot":{"lastRevision":10},"deltaSnapshots":[{"lastRevision":20}]}`), 15, 30, true, 0, true},
+		{"should return error when db has no consistent index", singleMemberCluster, true, http.StatusOK, []byte(`{"fullSnapshot":{"lastRevision":10}}`), 15, 0, true, 0, true},
+		{"should succeed when db revision matches snapshot revision", singleMemberCluster, true, http.StatusOK, []byte(`{"fullSnapshot":{"lastRevision":10},"deltaSnapshots":[{"lastRevision":20}]}`), 20, 30, true, 0, false},
+		{"should return error when db consistent index is older than raft snapshot index", singleMemberCluster, true, http.StatusOK, []byte(`{"fullSnapshot":{"lastRevision":20}}`), 20, 30, true, 31, true},
+		{"should succeed when db consistent index matches raft snapshot index", singleMemberCluster, true, http.StatusOK, []byte(`{"fullSnapshot":{"lastRevision":20}}`), 20, 30, true, 30, false},
+	}
+
+	for _, entry := range table {
+		t.Run(entry.description, func(t *testing.T) {
+			g := NewWithT(t)
+			dataDir := t.TempDir()
+			if entry.createDB {
+				createTestDB(g, dataDir, entry.dbRevision, entry.consistentIndex)
+			}
+			if entry.raftIndex > 0 {
+				g.Expect(snap.New(zaptest.NewLogger(t), GetSnapDir(dataDir)).SaveSnap(raftpb.Snapshot{Metadata: raftpb.SnapshotMetadata{Index: entry.raftIndex, Term: 1}})).To(Succeed())
+			}
+			brc := brclient.NewClient(getTestHttpClient(entry.responseCode, entry.responseBody), "", filepath.Join(dataDir, "etcd.conf.yaml"))
+			i := initializer{brClient: brc, logger: zaptest.NewLogger(t)}
+			cfg := embed.NewConfig()
+			cfg.Dir = dataDir
+			cfg.InitialCluster = entry.initialCluster
+
+			var restoreInfo *RestoreInfo
+			if entry.restored {
+				restoreInfo = &RestoreInfo{RestoredAt: time.Now()}
+			}
+			err := i.verifyRestoredDataDir(context.TODO(), cfg, restoreInfo)
+			g.Expect(err != nil).To(Equal(entry.expectError))
+		})
+	}
+}
+
+func TestDetectRestoration(t *testing.T) {
+	table := []struct {
+		description   string
+		createDB      bool
+		dbModTime     time.Time
+		expectRestore bool
+	}{
+		{"should not detect restoration when db does not exist", false, time.Time{}, false},
+		{"should not detect restoration when db has not been written during initialization", true, time.Now().Add(-time.Hour), false},
+		{"should detect restoration when db has been written during initialization", true, time.Now().Add(time.Minute), true},
+	}
+
+	for _, entry := range table {
+		t.Run(entry.description, func(t *testing.T) {
+			g := NewWithT(t)
+			dataDir := t.TempDir()
+			if entry.createDB {
+				createTestDB(g, dataDir, 30, 5)
+				g.Expect(os.Chtimes(GetDBPath(dataDir), entry.dbModTime, entry.dbModTime)).To(Succeed())
+			}
+			fakeClient := &brclient.FakeClient{LatestSnapshots: &brclient.LatestSnapshots{
+				FullSnapshot:   &brclient.Snapshot{SnapName: "Full-00000000-00000020-1704067200", LastRevision: 20},
+				DeltaSnapshots: []*brclient.Snapshot{{StartRevision: 21, LastRevision: 30}},
+			}}
+			i := &initializer{brClient: fakeClient, logger: zaptest.NewLogger(t)}
+			restoreInfo := i.detectRestoration(context.Background(), &embed.Config{Dir: dataDir}, time.Now())
+			if !entry.expectRestore {
+				g.Expect(restoreInfo).To(BeNil())
+				return
+			}
+			g.Expect(restoreInfo).ToNot(BeNil())
+			g.Expect(restoreInfo.Revision).To(Equal(int64(30)))
+			g.Expect(restoreInfo.FullSnapshot).To(Equal("Full-00000000-00000020-1704067200"))
+			g.Expect(restoreInfo.SnapshotRevision).To(Equal(int64(30)))
+		})
+	}
+}
+
+func createTestDB(g *WithT, dataDir string, revision int64, consistentIndex uint64) {
+	dbPath := GetDBPath(dataDir)
+	g.Expect(os.MkdirAll(filepath.Dir(dbPath), 0700)).To(Succeed())
+	db, err := bolt.Open(dbPath, 0600, nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		g.Expect(db.Close()).To(Succeed())
+	}()
+	g.Expect(db.Update(func(tx *bolt.Tx) error {
+		keyBucket, err := tx.CreateBucketIfNotExists(keyBucketName)
+		if err != nil {
+			return err
+		}
+		for rev := int64(1); rev <= revision; rev++ {
+			revKey := make([]byte, revBytesLen)
+			binary.BigEndian.PutUint64(revKey[0:8], uint64(rev))
+			revKey[8] = '_'
+			if err = keyBucket.Put(revKey, []byte("value")); err != nil {
+				return err
+			}
+		}
+		metaBucket, err := tx.CreateBucketIfNotExists(metaBucketName)
+		if err != nil {
+			return err
+		}
+		if consistentIndex == 0 {
+			return nil
+		}
+		ci := make([]byte, 8)
+		binary.BigEndian.PutUint64(ci, consistentIndex)
+		return metaBucket.Put(consistentIndexName, ci)
+	})).To(Succeed())
+}
@@ -0,0 +1,76 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstrap
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/brclient"
+	"github.com/gardener/etcd-wrapper/internal/metrics"
+	"github.com/gardener/etcd-wrapper/internal/types"
+)
+
+const (
+	// sidecarErrorClassTransient labels errors of backup-restore which are retried.
+	sidecarErrorClassTransient = "transient"
+	// sidecarErrorClassPermanent labels errors of backup-restore which fail the bootstrap.
+	sidecarErrorClassPermanent = "permanent"
+	// maxBackOffBetweenRetries is the maximum time between requests to backup-restore after consecutive transient errors.
+	maxBackOffBetweenRetries = 8 * time.Second
+)
+
+// SidecarPermanentError is returned when backup-restore has rejected a request during bootstrap with an error which
+// does not go away on retries, e.g. a 4xx status code caused by a misconfiguration, so that the bootstrap fails fast
+// instead of waiting for the phase timeouts.
+type SidecarPermanentError struct {
+	// Operation is the request which has been rejected.
+	Operation string
+	// Err is the error returned by backup-restore.
+	Err error
+}
+
+func (e *SidecarPermanentError) Error() string {
+	return fmt.Sprintf("backup-restore permanently rejected request to %s: %v", e.Operation, e.Err)
+}
+
+func (e *SidecarPermanentError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode returns the exit code with which etcd-wrapper exits because of the permanent error.
+func (e *SidecarPermanentError) ExitCode() int {
+	return types.ExitCodeSidecarPermanentError
+}
+
+// classifySidecarError records the class of an error returned by backup-restore for operation and returns a
+// SidecarPermanentError if it is permanent, nil otherwise. A brclient.IncompatibleAPIVersionError is returned as is, so
+// that etcd-wrapper exits with its exit code.
+func classifySidecarError(operation string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var incompatibleErr *brclient.IncompatibleAPIVersionError
+	if errors.As(err, &incompatibleErr) {
+		metrics.SidecarErrorsTotal.WithLabelValues(sidecarErrorClassPermanent).Inc()
+		return err
+	}
+	if brclient.IsPermanent(err) {
+		metrics.SidecarErrorsTotal.WithLabelValues(sidecarErrorClassPermanent).Inc()
+		return &SidecarPermanentError{Operation: operation, Err: err}
+	}
+	metrics.SidecarErrorsTotal.WithLabelValues(sidecarErrorClassTransient).Inc()
+	return nil
+}
+
+// nextBackOff returns the time to wait before the next request to backup-restore, which is doubled after every
+// consecutive transient error up to maxBackOffBetweenRetries and reset once a request succeeds.
+func nextBackOff(current time.Duration, failed bool) time.Duration {
+	if !failed {
+		return defaultBackOffBetweenRetries
+	}
+	return min(2*current, maxBackOffBetweenRetries)
+}
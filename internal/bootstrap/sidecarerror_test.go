@@ -0,0 +1,90 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/audit"
+	"github.com/gardener/etcd-wrapper/internal/brclient"
+	"github.com/gardener/etcd-wrapper/internal/state"
+	"github.com/gardener/etcd-wrapper/internal/types"
+
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestRunFailsFastOnPermanentSidecarErrors(t *testing.T) {
+	table := []struct {
+		description       string
+		fakeClient        *brclient.FakeClient
+		expectedOperation string
+	}{
+		{"should fail fast when fetching the initialization status is rejected", &brclient.FakeClient{InitStatusErr: &brclient.ResponseError{Operation: "get initialization status", StatusCode: http.StatusUnauthorized}}, "get initialization status"},
+		{"should fail fast when triggering initialization is rejected", &brclient.FakeClient{InitStatuses: []brclient.InitStatus{brclient.New}, TriggerInitializationErr: &brclient.ResponseError{Operation: "trigger initialization", StatusCode: http.StatusBadRequest}}, "trigger initialization"},
+		{"should fail fast when fetching the etcd config is rejected", &brclient.FakeClient{InitStatuses: []brclient.InitStatus{brclient.Successful}, EtcdConfigErr: &brclient.ResponseError{Operation: "fetch etcd config", StatusCode: http.StatusNotFound}}, "fetch etcd config"},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		logger := zaptest.NewLogger(t)
+		config := &types.Config{BootstrapHistory: types.BootstrapHistoryConfig{Path: filepath.Join(t.TempDir(), "bootstrap_history.json")}}
+		i := NewEtcdInitializerWithClient(entry.fakeClient, config, state.NewMachine(logger), audit.NewNoopLogger(), logger)
+		start := time.Now()
+		_, err := i.Run(context.Background())
+		g.Expect(time.Since(start)).To(BeNumerically("<", 2*defaultBackOffBetweenRetries))
+		var permanentErr *SidecarPermanentError
+		g.Expect(errors.As(err, &permanentErr)).To(BeTrue())
+		g.Expect(permanentErr.Operation).To(Equal(entry.expectedOperation))
+		g.Expect(permanentErr.ExitCode()).To(Equal(types.ExitCodeSidecarPermanentError))
+	}
+}
+
+func TestRunNegotiatesAPIVersion(t *testing.T) {
+	table := []struct {
+		description          string
+		fakeClient           *brclient.FakeClient
+		expectedIncompatible bool
+	}{
+		{"should fail fast when the API versions of backup-restore are incompatible", &brclient.FakeClient{NegotiateAPIVersionErr: &brclient.IncompatibleAPIVersionError{ServerVersions: []brclient.APIVersion{3}, SupportedVersions: brclient.SupportedAPIVersions}}, true},
+		{"should fail fast when negotiating the API version is rejected", &brclient.FakeClient{NegotiateAPIVersionErr: &brclient.ResponseError{Operation: "negotiate API version", StatusCode: http.StatusForbidden}}, false},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		logger := zaptest.NewLogger(t)
+		config := &types.Config{BootstrapHistory: types.BootstrapHistoryConfig{Path: filepath.Join(t.TempDir(), "bootstrap_history.json")}}
+		i := NewEtcdInitializerWithClient(entry.fakeClient, config, state.NewMachine(logger), audit.NewNoopLogger(), logger)
+		start := time.Now()
+		_, err := i.Run(context.Background())
+		g.Expect(time.Since(start)).To(BeNumerically("<", 2*defaultBackOffBetweenRetries))
+		var exitCodeErr interface{ ExitCode() int }
+		g.Expect(errors.As(err, &exitCodeErr)).To(BeTrue())
+		if entry.expectedIncompatible {
+			g.Expect(exitCodeErr.ExitCode()).To(Equal(types.ExitCodeSidecarAPIVersionIncompatible))
+		} else {
+			g.Expect(exitCodeErr.ExitCode()).To(Equal(types.ExitCodeSidecarPermanentError))
+		}
+		g.Expect(entry.fakeClient.TriggeredValidationTypes).To(BeEmpty())
+	}
+}
+
+func TestNextBackOff(t *testing.T) {
+	g := NewWithT(t)
+	backOff := defaultBackOffBetweenRetries
+	for _, expected := range []time.Duration{2 * time.Second, 4 * time.Second, 8 * time.Second, maxBackOffBetweenRetries} {
+		backOff = nextBackOff(backOff, true)
+		g.Expect(backOff).To(Equal(expected))
+	}
+	t.Log("should reset the backoff once a request succeeds")
+	g.Expect(nextBackOff(backOff, false)).To(Equal(defaultBackOffBetweenRetries))
+}
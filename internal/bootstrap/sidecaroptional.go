@@ -0,0 +1,100 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstrap
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/gardener/etcd-wrapper/internal/metrics"
+
+	bolt "go.etcd.io/bbolt"
+	"go.etcd.io/etcd/embed"
+	"go.uber.org/zap"
+)
+
+// GetWALDir returns the path of the directory of the etcd write-ahead log for the given data directory.
+func GetWALDir(dataDir string) string {
+	return filepath.Join(dataDir, "member", "wal")
+}
+
+// VerifyLocalDataDir verifies the data directory of etcd without involving backup-restore: the write-ahead log must
+// exist, in walDir if set or in the data directory otherwise, and the backend DB must exist, pass the consistency check
+// of bbolt and have a consistent index if it holds any revision.
+func VerifyLocalDataDir(dataDir, walDir string) error {
+	if walDir == "" {
+		walDir = GetWALDir(dataDir)
+	}
+	walFiles, err := filepath.Glob(filepath.Join(walDir, "*.wal"))
+	if err != nil {
+		return err
+	}
+	if len(walFiles) == 0 {
+		return fmt.Errorf("data directory %s has no write-ahead log", dataDir)
+	}
+	dbPath := GetDBPath(dataDir)
+	if _, err = os.Stat(dbPath); err != nil {
+		return fmt.Errorf("data directory %s has no etcd db: %w", dataDir, err)
+	}
+	if err = checkDBConsistency(dbPath); err != nil {
+		return err
+	}
+	metadata, err := ReadDBMetadata(dbPath)
+	if err != nil {
+		return err
+	}
+	if metadata.Revision > 0 && metadata.ConsistentIndex == 0 {
+		return fmt.Errorf("etcd db %s has revision %d but no consistent index", dbPath, metadata.Revision)
+	}
+	return nil
+}
+
+// checkDBConsistency opens the etcd backend DB at dbPath in read-only mode and runs the consistency check of bbolt on it.
+func checkDBConsistency(dbPath string) error {
+	db, err := bolt.Open(dbPath, 0400, &bolt.Options{ReadOnly: true, Timeout: dbOpenTimeout})
+	if err != nil {
+		return fmt.Errorf("failed to open etcd db %s: %w", dbPath, err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+	return db.View(func(tx *bolt.Tx) error {
+		var errs []error
+		for err := range tx.Check() {
+			errs = append(errs, err)
+		}
+		if len(errs) > 0 {
+			return fmt.Errorf("etcd db %s is inconsistent: %w", dbPath, errors.Join(errs...))
+		}
+		return nil
+	})
+}
+
+// loadEtcdConfigWithoutSidecar loads the etcd configuration last fetched from backup-restore and verifies the data
+// directory locally, so that etcd can be started while backup-restore is absent.
+func (i *initializer) loadEtcdConfigWithoutSidecar() (*embed.Config, error) {
+	i.logger.Warn("backup-restore has not responded within the sidecar optional window, verifying the data directory locally to start etcd without backup-restore", zap.Duration("window", i.sidecarOptional.Window))
+	if i.etcdConfigFilePath == "" {
+		return nil, errors.New("path of the etcd configuration is unknown")
+	}
+	cfg, err := LoadEtcdConfig(i.etcdConfigFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the etcd configuration last fetched from backup-restore: %w", err)
+	}
+	if _, err = ResolveTLSMode(cfg); err != nil {
+		return nil, fmt.Errorf("invalid TLS configuration of etcd: %w", err)
+	}
+	i.applyWALDir(cfg)
+	if err = VerifyLocalDataDir(cfg.Dir, cfg.WalDir); err != nil {
+		return nil, fmt.Errorf("local verification of the data directory failed: %w", err)
+	}
+	i.logger.Error("!!! STARTING ETCD WITHOUT BACKUP-RESTORE !!! The data directory passed local verification only, it is neither validated nor restored by backup-restore and no snapshots are taken until backup-restore is back",
+		zap.String("dataDir", cfg.Dir), zap.String("etcdConfigPath", i.etcdConfigFilePath))
+	metrics.SidecarBypassed.Set(1)
+	i.usedEtcdConfigFilePath = i.etcdConfigFilePath
+	return cfg, nil
+}
@@ -0,0 +1,113 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/audit"
+	"github.com/gardener/etcd-wrapper/internal/brclient"
+	"github.com/gardener/etcd-wrapper/internal/state"
+	"github.com/gardener/etcd-wrapper/internal/types"
+
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestVerifyLocalDataDir(t *testing.T) {
+	table := []struct {
+		description     string
+		createWAL       bool
+		separateWALDir  bool
+		createDB        bool
+		dbRevision      int64
+		consistentIndex uint64
+		expectError     bool
+	}{
+		{"should succeed for a data directory with write-ahead log and consistent db", true, false, true, 10, 12, false},
+		{"should succeed for a write-ahead log in a separate WAL directory", true, true, true, 10, 12, false},
+		{"should succeed for an empty db", true, false, true, 0, 0, false},
+		{"should fail without write-ahead log", false, false, true, 10, 12, true},
+		{"should fail without write-ahead log in the separate WAL directory", false, true, true, 10, 12, true},
+		{"should fail without db", true, false, false, 0, 0, true},
+		{"should fail for a db with revisions but without consistent index", true, false, true, 10, 0, true},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		dataDir := t.TempDir()
+		var walDir string
+		if entry.separateWALDir {
+			walDir = t.TempDir()
+		}
+		if entry.createWAL {
+			if entry.separateWALDir {
+				g.Expect(os.WriteFile(filepath.Join(walDir, "0000000000000000-0000000000000000.wal"), []byte("wal"), 0600)).To(Succeed())
+			} else {
+				createTestWAL(g, dataDir)
+			}
+		}
+		if entry.createDB {
+			createTestDB(g, dataDir, entry.dbRevision, entry.consistentIndex)
+		}
+		err := VerifyLocalDataDir(dataDir, walDir)
+		g.Expect(err != nil).To(Equal(entry.expectError))
+	}
+}
+
+func TestRunSidecarOptional(t *testing.T) {
+	table := []struct {
+		description string
+		enabled     bool
+		validDir    bool
+		expectStart bool
+	}{
+		{"should start etcd without backup-restore when the data directory is valid", true, true, true},
+		{"should keep waiting for backup-restore when the data directory is invalid", true, false, false},
+		{"should keep waiting for backup-restore when sidecar optional mode is disabled", false, true, false},
+	}
+
+	for _, entry := range table {
+		t.Run(entry.description, func(t *testing.T) {
+			g := NewWithT(t)
+			testDir := t.TempDir()
+			dataDir := filepath.Join(testDir, "data")
+			if entry.validDir {
+				createTestWAL(g, dataDir)
+				createTestDB(g, dataDir, 10, 12)
+			}
+			etcdConfigFilePath := filepath.Join(testDir, "etcd.conf.yaml")
+			g.Expect(os.WriteFile(etcdConfigFilePath, []byte("name: etcd-test\ndata-dir: "+dataDir+"\n"), 0600)).To(Succeed())
+
+			logger := zaptest.NewLogger(t)
+			config := &types.Config{
+				PhaseTimeouts:   types.PhaseTimeoutsConfig{SidecarProbe: 1500 * time.Millisecond},
+				SidecarOptional: types.SidecarOptionalConfig{Enabled: entry.enabled, Window: time.Millisecond},
+			}
+			i := NewEtcdInitializerWithClient(&brclient.FakeClient{InitStatusErr: errors.New("connection refused")}, config, state.NewMachine(logger), audit.NewNoopLogger(), logger).(*initializer)
+			i.etcdConfigFilePath = etcdConfigFilePath
+			cfg, err := i.Run(context.Background())
+			if entry.expectStart {
+				g.Expect(err).ToNot(HaveOccurred())
+				g.Expect(cfg.Dir).To(Equal(dataDir))
+				return
+			}
+			var phaseTimeoutErr *PhaseTimeoutError
+			g.Expect(errors.As(err, &phaseTimeoutErr)).To(BeTrue())
+		})
+	}
+}
+
+func createTestWAL(g *WithT, dataDir string) {
+	walDir := GetWALDir(dataDir)
+	g.Expect(os.MkdirAll(walDir, 0700)).To(Succeed())
+	g.Expect(os.WriteFile(filepath.Join(walDir, "0000000000000000-0000000000000000.wal"), []byte("wal"), 0600)).To(Succeed())
+}
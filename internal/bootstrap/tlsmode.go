@@ -0,0 +1,106 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstrap
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"go.etcd.io/etcd/embed"
+	"go.etcd.io/etcd/pkg/transport"
+)
+
+// TLSMode describes whether TLS is used for client and peer communication of etcd. Client and peer TLS are
+// independent of each other, hence all four combinations are valid.
+type TLSMode struct {
+	// ClientTLS indicates whether the client URLs of etcd are served via TLS.
+	ClientTLS bool
+	// PeerTLS indicates whether the peer URLs of etcd are served via TLS.
+	PeerTLS bool
+}
+
+func (m TLSMode) String() string {
+	return fmt.Sprintf("client: %s, peer: %s", tlsModeName(m.ClientTLS), tlsModeName(m.PeerTLS))
+}
+
+// ResolveTLSMode resolves the TLS mode of client and peer communication separately from the URL schemes in the etcd
+// configuration and validates that the corresponding transport security configuration is consistent with it.
+func ResolveTLSMode(cfg *embed.Config) (TLSMode, error) {
+	var (
+		mode TLSMode
+		err  error
+	)
+	if mode.ClientTLS, err = resolveTLS("client", urlsOf(cfg.ListenClientUrls, cfg.AdvertiseClientUrls), cfg.ClientTLSInfo, cfg.ClientAutoTLS); err != nil {
+		return mode, err
+	}
+	if mode.ClientTLS {
+		if cfg.ClientAutoTLS && cfg.ClientTLSInfo.Empty() {
+			return mode, fmt.Errorf("client URLs use https with client-transport-security.auto-tls, which etcd-wrapper cannot verify; configure client-transport-security.cert-file and key-file instead")
+		}
+		if strings.TrimSpace(cfg.ClientTLSInfo.TrustedCAFile) == "" {
+			return mode, fmt.Errorf("client URLs use https but client-transport-security.trusted-ca-file is not set, which etcd-wrapper requires to verify the etcd server certificate")
+		}
+	}
+	if mode.PeerTLS, err = resolveTLS("peer", urlsOf(cfg.ListenPeerUrls, cfg.AdvertisePeerUrls), cfg.PeerTLSInfo, cfg.PeerAutoTLS); err != nil {
+		return mode, err
+	}
+	if mode.PeerTLS && cfg.PeerTLSInfo.ClientCertAuth && strings.TrimSpace(cfg.PeerTLSInfo.TrustedCAFile) == "" {
+		return mode, fmt.Errorf("peer-transport-security.client-cert-auth is enabled but peer-transport-security.trusted-ca-file is not set")
+	}
+	return mode, nil
+}
+
+// resolveTLS determines whether the given URLs use TLS and validates the transport security configuration for them.
+func resolveTLS(kind string, urls []url.URL, tlsInfo transport.TLSInfo, autoTLS bool) (bool, error) {
+	var httpURLs, httpsURLs []string
+	for _, u := range urls {
+		switch u.Scheme {
+		case "https", "unixs":
+			httpsURLs = append(httpsURLs, u.String())
+		default:
+			httpURLs = append(httpURLs, u.String())
+		}
+	}
+	if len(httpURLs) > 0 && len(httpsURLs) > 0 {
+		return false, fmt.Errorf("%s URLs mix TLS and non-TLS schemes, non-TLS: %s, TLS: %s", kind, strings.Join(httpURLs, ","), strings.Join(httpsURLs, ","))
+	}
+	certFileSet := strings.TrimSpace(tlsInfo.CertFile) != ""
+	keyFileSet := strings.TrimSpace(tlsInfo.KeyFile) != ""
+	if len(httpsURLs) == 0 {
+		if certFileSet || keyFileSet {
+			return false, fmt.Errorf("%s-transport-security.cert-file or key-file is set but %s URLs do not use https: %s", kind, kind, strings.Join(httpURLs, ","))
+		}
+		return false, nil
+	}
+	if autoTLS && !certFileSet && !keyFileSet {
+		return true, nil
+	}
+	if !certFileSet {
+		return false, fmt.Errorf("%s URLs use https but %s-transport-security.cert-file is not set", kind, kind)
+	}
+	if !keyFileSet {
+		return false, fmt.Errorf("%s URLs use https but %s-transport-security.key-file is not set", kind, kind)
+	}
+	if tlsInfo.ClientCertAuth && strings.TrimSpace(tlsInfo.TrustedCAFile) == "" {
+		return false, fmt.Errorf("%s-transport-security.client-cert-auth is enabled but %s-transport-security.trusted-ca-file is not set", kind, kind)
+	}
+	return true, nil
+}
+
+func urlsOf(urlLists ...[]url.URL) []url.URL {
+	var urls []url.URL
+	for _, l := range urlLists {
+		urls = append(urls, l...)
+	}
+	return urls
+}
+
+func tlsModeName(enabled bool) string {
+	if enabled {
+		return "TLS"
+	}
+	return "plaintext"
+}
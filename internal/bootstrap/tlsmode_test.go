@@ -0,0 +1,73 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package bootstrap
+
+import (
+	"net/url"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"go.etcd.io/etcd/embed"
+	"go.etcd.io/etcd/pkg/transport"
+)
+
+func TestResolveTLSMode(t *testing.T) {
+	var (
+		tlsInfo        = transport.TLSInfo{CertFile: "/var/etcd/ssl/server/tls.crt", KeyFile: "/var/etcd/ssl/server/tls.key", TrustedCAFile: "/var/etcd/ssl/ca/bundle.crt"}
+		httpClientURL  = url.URL{Scheme: "http", Host: "etcd-main-0:2379"}
+		httpsClientURL = url.URL{Scheme: "https", Host: "etcd-main-0:2379"}
+		httpPeerURL    = url.URL{Scheme: "http", Host: "etcd-main-0:2380"}
+		httpsPeerURL   = url.URL{Scheme: "https", Host: "etcd-main-0:2380"}
+	)
+	table := []struct {
+		description  string
+		clientURL    url.URL
+		clientTLS    transport.TLSInfo
+		peerURL      url.URL
+		peerTLS      transport.TLSInfo
+		peerAutoTLS  bool
+		expectedMode TLSMode
+		expectError  bool
+	}{
+		{"should resolve plaintext clients and plaintext peers", httpClientURL, transport.TLSInfo{}, httpPeerURL, transport.TLSInfo{}, false, TLSMode{}, false},
+		{"should resolve TLS clients and plaintext peers", httpsClientURL, tlsInfo, httpPeerURL, transport.TLSInfo{}, false, TLSMode{ClientTLS: true}, false},
+		{"should resolve plaintext clients and TLS peers", httpClientURL, transport.TLSInfo{}, httpsPeerURL, tlsInfo, false, TLSMode{PeerTLS: true}, false},
+		{"should resolve TLS clients and TLS peers", httpsClientURL, tlsInfo, httpsPeerURL, tlsInfo, false, TLSMode{ClientTLS: true, PeerTLS: true}, false},
+		{"should resolve TLS peers with auto TLS", httpClientURL, transport.TLSInfo{}, httpsPeerURL, transport.TLSInfo{}, true, TLSMode{PeerTLS: true}, false},
+		{"should return error when client URLs use https without certificates", httpsClientURL, transport.TLSInfo{}, httpPeerURL, transport.TLSInfo{}, false, TLSMode{}, true},
+		{"should return error when client URLs use https without trusted CA", httpsClientURL, transport.TLSInfo{CertFile: tlsInfo.CertFile, KeyFile: tlsInfo.KeyFile}, httpPeerURL, transport.TLSInfo{}, false, TLSMode{}, true},
+		{"should return error when client certificates are set but client URLs use http", httpClientURL, tlsInfo, httpPeerURL, transport.TLSInfo{}, false, TLSMode{}, true},
+		{"should return error when peer URLs use https without key", httpClientURL, transport.TLSInfo{}, httpsPeerURL, transport.TLSInfo{CertFile: tlsInfo.CertFile}, false, TLSMode{}, true},
+		{"should return error when peer client cert auth is enabled without trusted CA", httpClientURL, transport.TLSInfo{}, httpsPeerURL, transport.TLSInfo{CertFile: tlsInfo.CertFile, KeyFile: tlsInfo.KeyFile, ClientCertAuth: true}, false, TLSMode{}, true},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		cfg := embed.NewConfig()
+		cfg.ListenClientUrls = []url.URL{entry.clientURL}
+		cfg.AdvertiseClientUrls = []url.URL{entry.clientURL}
+		cfg.ClientTLSInfo = entry.clientTLS
+		cfg.ListenPeerUrls = []url.URL{entry.peerURL}
+		cfg.AdvertisePeerUrls = []url.URL{entry.peerURL}
+		cfg.PeerTLSInfo = entry.peerTLS
+		cfg.PeerAutoTLS = entry.peerAutoTLS
+
+		mode, err := ResolveTLSMode(cfg)
+		g.Expect(err != nil).To(Equal(entry.expectError))
+		if !entry.expectError {
+			g.Expect(mode).To(Equal(entry.expectedMode))
+		}
+	}
+}
+
+func TestResolveTLSModeMixedSchemes(t *testing.T) {
+	g := NewWithT(t)
+	cfg := embed.NewConfig()
+	cfg.ListenClientUrls = []url.URL{{Scheme: "http", Host: "0.0.0.0:2379"}}
+	cfg.AdvertiseClientUrls = []url.URL{{Scheme: "https", Host: "etcd-main-0:2379"}}
+	_, err := ResolveTLSMode(cfg)
+	g.Expect(err).To(MatchError(ContainSubstring("client URLs mix TLS and non-TLS schemes")))
+}
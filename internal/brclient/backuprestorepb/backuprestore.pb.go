@@ -0,0 +1,813 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        v5.29.3
+// source: backuprestore.proto
+
+package backuprestorepb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type GetInitializationStatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetInitializationStatusRequest) Reset() {
+	*x = GetInitializationStatusRequest{}
+	mi := &file_backuprestore_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetInitializationStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetInitializationStatusRequest) ProtoMessage() {}
+
+func (x *GetInitializationStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_backuprestore_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetInitializationStatusRequest.ProtoReflect.Descriptor instead.
+func (*GetInitializationStatusRequest) Descriptor() ([]byte, []int) {
+	return file_backuprestore_proto_rawDescGZIP(), []int{0}
+}
+
+type GetInitializationStatusResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// status is one of `New`, `InProgress` or `Successful`.
+	Status        string `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetInitializationStatusResponse) Reset() {
+	*x = GetInitializationStatusResponse{}
+	mi := &file_backuprestore_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetInitializationStatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetInitializationStatusResponse) ProtoMessage() {}
+
+func (x *GetInitializationStatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_backuprestore_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetInitializationStatusResponse.ProtoReflect.Descriptor instead.
+func (*GetInitializationStatusResponse) Descriptor() ([]byte, []int) {
+	return file_backuprestore_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *GetInitializationStatusResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+type WatchInitializationStatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchInitializationStatusRequest) Reset() {
+	*x = WatchInitializationStatusRequest{}
+	mi := &file_backuprestore_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchInitializationStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchInitializationStatusRequest) ProtoMessage() {}
+
+func (x *WatchInitializationStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_backuprestore_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchInitializationStatusRequest.ProtoReflect.Descriptor instead.
+func (*WatchInitializationStatusRequest) Descriptor() ([]byte, []int) {
+	return file_backuprestore_proto_rawDescGZIP(), []int{2}
+}
+
+type TriggerInitializationRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// validation_mode is one of `sanity` or `full`.
+	ValidationMode string `protobuf:"bytes,1,opt,name=validation_mode,json=validationMode,proto3" json:"validation_mode,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *TriggerInitializationRequest) Reset() {
+	*x = TriggerInitializationRequest{}
+	mi := &file_backuprestore_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TriggerInitializationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TriggerInitializationRequest) ProtoMessage() {}
+
+func (x *TriggerInitializationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_backuprestore_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TriggerInitializationRequest.ProtoReflect.Descriptor instead.
+func (*TriggerInitializationRequest) Descriptor() ([]byte, []int) {
+	return file_backuprestore_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *TriggerInitializationRequest) GetValidationMode() string {
+	if x != nil {
+		return x.ValidationMode
+	}
+	return ""
+}
+
+type TriggerInitializationResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TriggerInitializationResponse) Reset() {
+	*x = TriggerInitializationResponse{}
+	mi := &file_backuprestore_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TriggerInitializationResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TriggerInitializationResponse) ProtoMessage() {}
+
+func (x *TriggerInitializationResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_backuprestore_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TriggerInitializationResponse.ProtoReflect.Descriptor instead.
+func (*TriggerInitializationResponse) Descriptor() ([]byte, []int) {
+	return file_backuprestore_proto_rawDescGZIP(), []int{4}
+}
+
+type GetEtcdConfigRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetEtcdConfigRequest) Reset() {
+	*x = GetEtcdConfigRequest{}
+	mi := &file_backuprestore_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetEtcdConfigRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetEtcdConfigRequest) ProtoMessage() {}
+
+func (x *GetEtcdConfigRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_backuprestore_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetEtcdConfigRequest.ProtoReflect.Descriptor instead.
+func (*GetEtcdConfigRequest) Descriptor() ([]byte, []int) {
+	return file_backuprestore_proto_rawDescGZIP(), []int{5}
+}
+
+type GetEtcdConfigResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// config is the etcd configuration in YAML.
+	Config        []byte `protobuf:"bytes,1,opt,name=config,proto3" json:"config,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetEtcdConfigResponse) Reset() {
+	*x = GetEtcdConfigResponse{}
+	mi := &file_backuprestore_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetEtcdConfigResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetEtcdConfigResponse) ProtoMessage() {}
+
+func (x *GetEtcdConfigResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_backuprestore_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetEtcdConfigResponse.ProtoReflect.Descriptor instead.
+func (*GetEtcdConfigResponse) Descriptor() ([]byte, []int) {
+	return file_backuprestore_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *GetEtcdConfigResponse) GetConfig() []byte {
+	if x != nil {
+		return x.Config
+	}
+	return nil
+}
+
+type GetLatestSnapshotsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetLatestSnapshotsRequest) Reset() {
+	*x = GetLatestSnapshotsRequest{}
+	mi := &file_backuprestore_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetLatestSnapshotsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetLatestSnapshotsRequest) ProtoMessage() {}
+
+func (x *GetLatestSnapshotsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_backuprestore_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetLatestSnapshotsRequest.ProtoReflect.Descriptor instead.
+func (*GetLatestSnapshotsRequest) Descriptor() ([]byte, []int) {
+	return file_backuprestore_proto_rawDescGZIP(), []int{7}
+}
+
+type GetLatestSnapshotsResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	FullSnapshot   *Snapshot              `protobuf:"bytes,1,opt,name=full_snapshot,json=fullSnapshot,proto3" json:"full_snapshot,omitempty"`
+	DeltaSnapshots []*Snapshot            `protobuf:"bytes,2,rep,name=delta_snapshots,json=deltaSnapshots,proto3" json:"delta_snapshots,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *GetLatestSnapshotsResponse) Reset() {
+	*x = GetLatestSnapshotsResponse{}
+	mi := &file_backuprestore_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetLatestSnapshotsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetLatestSnapshotsResponse) ProtoMessage() {}
+
+func (x *GetLatestSnapshotsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_backuprestore_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetLatestSnapshotsResponse.ProtoReflect.Descriptor instead.
+func (*GetLatestSnapshotsResponse) Descriptor() ([]byte, []int) {
+	return file_backuprestore_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GetLatestSnapshotsResponse) GetFullSnapshot() *Snapshot {
+	if x != nil {
+		return x.FullSnapshot
+	}
+	return nil
+}
+
+func (x *GetLatestSnapshotsResponse) GetDeltaSnapshots() []*Snapshot {
+	if x != nil {
+		return x.DeltaSnapshots
+	}
+	return nil
+}
+
+type Snapshot struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Kind          string                 `protobuf:"bytes,1,opt,name=kind,proto3" json:"kind,omitempty"`
+	StartRevision int64                  `protobuf:"varint,2,opt,name=start_revision,json=startRevision,proto3" json:"start_revision,omitempty"`
+	LastRevision  int64                  `protobuf:"varint,3,opt,name=last_revision,json=lastRevision,proto3" json:"last_revision,omitempty"`
+	// created_on is the time at which the snapshot was taken, in seconds since the unix epoch.
+	CreatedOn int64  `protobuf:"varint,4,opt,name=created_on,json=createdOn,proto3" json:"created_on,omitempty"`
+	SnapName  string `protobuf:"bytes,5,opt,name=snap_name,json=snapName,proto3" json:"snap_name,omitempty"`
+	// size_bytes is the size of the snapshot in the snapstore, 0 if unknown.
+	SizeBytes     int64 `protobuf:"varint,6,opt,name=size_bytes,json=sizeBytes,proto3" json:"size_bytes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Snapshot) Reset() {
+	*x = Snapshot{}
+	mi := &file_backuprestore_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Snapshot) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Snapshot) ProtoMessage() {}
+
+func (x *Snapshot) ProtoReflect() protoreflect.Message {
+	mi := &file_backuprestore_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Snapshot.ProtoReflect.Descriptor instead.
+func (*Snapshot) Descriptor() ([]byte, []int) {
+	return file_backuprestore_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *Snapshot) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *Snapshot) GetStartRevision() int64 {
+	if x != nil {
+		return x.StartRevision
+	}
+	return 0
+}
+
+func (x *Snapshot) GetLastRevision() int64 {
+	if x != nil {
+		return x.LastRevision
+	}
+	return 0
+}
+
+func (x *Snapshot) GetCreatedOn() int64 {
+	if x != nil {
+		return x.CreatedOn
+	}
+	return 0
+}
+
+func (x *Snapshot) GetSnapName() string {
+	if x != nil {
+		return x.SnapName
+	}
+	return ""
+}
+
+func (x *Snapshot) GetSizeBytes() int64 {
+	if x != nil {
+		return x.SizeBytes
+	}
+	return 0
+}
+
+type TriggerSnapshotRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// kind is one of `full` or `delta`.
+	Kind          string `protobuf:"bytes,1,opt,name=kind,proto3" json:"kind,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TriggerSnapshotRequest) Reset() {
+	*x = TriggerSnapshotRequest{}
+	mi := &file_backuprestore_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TriggerSnapshotRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TriggerSnapshotRequest) ProtoMessage() {}
+
+func (x *TriggerSnapshotRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_backuprestore_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TriggerSnapshotRequest.ProtoReflect.Descriptor instead.
+func (*TriggerSnapshotRequest) Descriptor() ([]byte, []int) {
+	return file_backuprestore_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *TriggerSnapshotRequest) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+type TriggerSnapshotResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// snapshot is unset if no snapshot has been taken since there are no changes to capture.
+	Snapshot      *Snapshot `protobuf:"bytes,1,opt,name=snapshot,proto3" json:"snapshot,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TriggerSnapshotResponse) Reset() {
+	*x = TriggerSnapshotResponse{}
+	mi := &file_backuprestore_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TriggerSnapshotResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TriggerSnapshotResponse) ProtoMessage() {}
+
+func (x *TriggerSnapshotResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_backuprestore_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TriggerSnapshotResponse.ProtoReflect.Descriptor instead.
+func (*TriggerSnapshotResponse) Descriptor() ([]byte, []int) {
+	return file_backuprestore_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *TriggerSnapshotResponse) GetSnapshot() *Snapshot {
+	if x != nil {
+		return x.Snapshot
+	}
+	return nil
+}
+
+type ReportChurnRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// revision is the etcd revision at the time of the observation.
+	Revision int64 `protobuf:"varint,1,opt,name=revision,proto3" json:"revision,omitempty"`
+	// revisions_per_second is the rate at which the etcd revision has grown within the window.
+	RevisionsPerSecond float64 `protobuf:"fixed64,2,opt,name=revisions_per_second,json=revisionsPerSecond,proto3" json:"revisions_per_second,omitempty"`
+	// window_seconds is the length of the window over which the rate has been observed.
+	WindowSeconds int64 `protobuf:"varint,3,opt,name=window_seconds,json=windowSeconds,proto3" json:"window_seconds,omitempty"`
+	// observed_at is the time of the observation, in seconds since the unix epoch.
+	ObservedAt    int64 `protobuf:"varint,4,opt,name=observed_at,json=observedAt,proto3" json:"observed_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReportChurnRequest) Reset() {
+	*x = ReportChurnRequest{}
+	mi := &file_backuprestore_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReportChurnRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReportChurnRequest) ProtoMessage() {}
+
+func (x *ReportChurnRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_backuprestore_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReportChurnRequest.ProtoReflect.Descriptor instead.
+func (*ReportChurnRequest) Descriptor() ([]byte, []int) {
+	return file_backuprestore_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ReportChurnRequest) GetRevision() int64 {
+	if x != nil {
+		return x.Revision
+	}
+	return 0
+}
+
+func (x *ReportChurnRequest) GetRevisionsPerSecond() float64 {
+	if x != nil {
+		return x.RevisionsPerSecond
+	}
+	return 0
+}
+
+func (x *ReportChurnRequest) GetWindowSeconds() int64 {
+	if x != nil {
+		return x.WindowSeconds
+	}
+	return 0
+}
+
+func (x *ReportChurnRequest) GetObservedAt() int64 {
+	if x != nil {
+		return x.ObservedAt
+	}
+	return 0
+}
+
+type ReportChurnResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// delta_snapshot_period_seconds is the period of delta snapshots adopted by backup-restore, zero if unknown.
+	DeltaSnapshotPeriodSeconds int64 `protobuf:"varint,1,opt,name=delta_snapshot_period_seconds,json=deltaSnapshotPeriodSeconds,proto3" json:"delta_snapshot_period_seconds,omitempty"`
+	unknownFields              protoimpl.UnknownFields
+	sizeCache                  protoimpl.SizeCache
+}
+
+func (x *ReportChurnResponse) Reset() {
+	*x = ReportChurnResponse{}
+	mi := &file_backuprestore_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReportChurnResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReportChurnResponse) ProtoMessage() {}
+
+func (x *ReportChurnResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_backuprestore_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReportChurnResponse.ProtoReflect.Descriptor instead.
+func (*ReportChurnResponse) Descriptor() ([]byte, []int) {
+	return file_backuprestore_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ReportChurnResponse) GetDeltaSnapshotPeriodSeconds() int64 {
+	if x != nil {
+		return x.DeltaSnapshotPeriodSeconds
+	}
+	return 0
+}
+
+var File_backuprestore_proto protoreflect.FileDescriptor
+
+const file_backuprestore_proto_rawDesc = "" +
+	"\n" +
+	"\x13backuprestore.proto\x12\x10backuprestore.v1\" \n" +
+	"\x1eGetInitializationStatusRequest\"9\n" +
+	"\x1fGetInitializationStatusResponse\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\"\"\n" +
+	" WatchInitializationStatusRequest\"G\n" +
+	"\x1cTriggerInitializationRequest\x12'\n" +
+	"\x0fvalidation_mode\x18\x01 \x01(\tR\x0evalidationMode\"\x1f\n" +
+	"\x1dTriggerInitializationResponse\"\x16\n" +
+	"\x14GetEtcdConfigRequest\"/\n" +
+	"\x15GetEtcdConfigResponse\x12\x16\n" +
+	"\x06config\x18\x01 \x01(\fR\x06config\"\x1b\n" +
+	"\x19GetLatestSnapshotsRequest\"\xa2\x01\n" +
+	"\x1aGetLatestSnapshotsResponse\x12?\n" +
+	"\rfull_snapshot\x18\x01 \x01(\v2\x1a.backuprestore.v1.SnapshotR\ffullSnapshot\x12C\n" +
+	"\x0fdelta_snapshots\x18\x02 \x03(\v2\x1a.backuprestore.v1.SnapshotR\x0edeltaSnapshots\"\xc5\x01\n" +
+	"\bSnapshot\x12\x12\n" +
+	"\x04kind\x18\x01 \x01(\tR\x04kind\x12%\n" +
+	"\x0estart_revision\x18\x02 \x01(\x03R\rstartRevision\x12#\n" +
+	"\rlast_revision\x18\x03 \x01(\x03R\flastRevision\x12\x1d\n" +
+	"\n" +
+	"created_on\x18\x04 \x01(\x03R\tcreatedOn\x12\x1b\n" +
+	"\tsnap_name\x18\x05 \x01(\tR\bsnapName\x12\x1d\n" +
+	"\n" +
+	"size_bytes\x18\x06 \x01(\x03R\tsizeBytes\",\n" +
+	"\x16TriggerSnapshotRequest\x12\x12\n" +
+	"\x04kind\x18\x01 \x01(\tR\x04kind\"Q\n" +
+	"\x17TriggerSnapshotResponse\x126\n" +
+	"\bsnapshot\x18\x01 \x01(\v2\x1a.backuprestore.v1.SnapshotR\bsnapshot\"\xaa\x01\n" +
+	"\x12ReportChurnRequest\x12\x1a\n" +
+	"\brevision\x18\x01 \x01(\x03R\brevision\x120\n" +
+	"\x14revisions_per_second\x18\x02 \x01(\x01R\x12revisionsPerSecond\x12%\n" +
+	"\x0ewindow_seconds\x18\x03 \x01(\x03R\rwindowSeconds\x12\x1f\n" +
+	"\vobserved_at\x18\x04 \x01(\x03R\n" +
+	"observedAt\"X\n" +
+	"\x13ReportChurnResponse\x12A\n" +
+	"\x1ddelta_snapshot_period_seconds\x18\x01 \x01(\x03R\x1adeltaSnapshotPeriodSeconds2\xa7\x06\n" +
+	"\rBackupRestore\x12~\n" +
+	"\x17GetInitializationStatus\x120.backuprestore.v1.GetInitializationStatusRequest\x1a1.backuprestore.v1.GetInitializationStatusResponse\x12\x84\x01\n" +
+	"\x19WatchInitializationStatus\x122.backuprestore.v1.WatchInitializationStatusRequest\x1a1.backuprestore.v1.GetInitializationStatusResponse0\x01\x12x\n" +
+	"\x15TriggerInitialization\x12..backuprestore.v1.TriggerInitializationRequest\x1a/.backuprestore.v1.TriggerInitializationResponse\x12`\n" +
+	"\rGetEtcdConfig\x12&.backuprestore.v1.GetEtcdConfigRequest\x1a'.backuprestore.v1.GetEtcdConfigResponse\x12o\n" +
+	"\x12GetLatestSnapshots\x12+.backuprestore.v1.GetLatestSnapshotsRequest\x1a,.backuprestore.v1.GetLatestSnapshotsResponse\x12f\n" +
+	"\x0fTriggerSnapshot\x12(.backuprestore.v1.TriggerSnapshotRequest\x1a).backuprestore.v1.TriggerSnapshotResponse\x12Z\n" +
+	"\vReportChurn\x12$.backuprestore.v1.ReportChurnRequest\x1a%.backuprestore.v1.ReportChurnResponseBDZBgithub.com/gardener/etcd-wrapper/internal/brclient/backuprestorepbb\x06proto3"
+
+var (
+	file_backuprestore_proto_rawDescOnce sync.Once
+	file_backuprestore_proto_rawDescData []byte
+)
+
+func file_backuprestore_proto_rawDescGZIP() []byte {
+	file_backuprestore_proto_rawDescOnce.Do(func() {
+		file_backuprestore_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_backuprestore_proto_rawDesc), len(file_backuprestore_proto_rawDesc)))
+	})
+	return file_backuprestore_proto_rawDescData
+}
+
+var file_backuprestore_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
+var file_backuprestore_proto_goTypes = []any{
+	(*GetInitializationStatusRequest)(nil),   // 0: backuprestore.v1.GetInitializationStatusRequest
+	(*GetInitializationStatusResponse)(nil),  // 1: backuprestore.v1.GetInitializationStatusResponse
+	(*WatchInitializationStatusRequest)(nil), // 2: backuprestore.v1.WatchInitializationStatusRequest
+	(*TriggerInitializationRequest)(nil),     // 3: backuprestore.v1.TriggerInitializationRequest
+	(*TriggerInitializationResponse)(nil),    // 4: backuprestore.v1.TriggerInitializationResponse
+	(*GetEtcdConfigRequest)(nil),             // 5: backuprestore.v1.GetEtcdConfigRequest
+	(*GetEtcdConfigResponse)(nil),            // 6: backuprestore.v1.GetEtcdConfigResponse
+	(*GetLatestSnapshotsRequest)(nil),        // 7: backuprestore.v1.GetLatestSnapshotsRequest
+	(*GetLatestSnapshotsResponse)(nil),       // 8: backuprestore.v1.GetLatestSnapshotsResponse
+	(*Snapshot)(nil),                         // 9: backuprestore.v1.Snapshot
+	(*TriggerSnapshotRequest)(nil),           // 10: backuprestore.v1.TriggerSnapshotRequest
+	(*TriggerSnapshotResponse)(nil),          // 11: backuprestore.v1.TriggerSnapshotResponse
+	(*ReportChurnRequest)(nil),               // 12: backuprestore.v1.ReportChurnRequest
+	(*ReportChurnResponse)(nil),              // 13: backuprestore.v1.ReportChurnResponse
+}
+var file_backuprestore_proto_depIdxs = []int32{
+	9,  // 0: backuprestore.v1.GetLatestSnapshotsResponse.full_snapshot:type_name -> backuprestore.v1.Snapshot
+	9,  // 1: backuprestore.v1.GetLatestSnapshotsResponse.delta_snapshots:type_name -> backuprestore.v1.Snapshot
+	9,  // 2: backuprestore.v1.TriggerSnapshotResponse.snapshot:type_name -> backuprestore.v1.Snapshot
+	0,  // 3: backuprestore.v1.BackupRestore.GetInitializationStatus:input_type -> backuprestore.v1.GetInitializationStatusRequest
+	2,  // 4: backuprestore.v1.BackupRestore.WatchInitializationStatus:input_type -> backuprestore.v1.WatchInitializationStatusRequest
+	3,  // 5: backuprestore.v1.BackupRestore.TriggerInitialization:input_type -> backuprestore.v1.TriggerInitializationRequest
+	5,  // 6: backuprestore.v1.BackupRestore.GetEtcdConfig:input_type -> backuprestore.v1.GetEtcdConfigRequest
+	7,  // 7: backuprestore.v1.BackupRestore.GetLatestSnapshots:input_type -> backuprestore.v1.GetLatestSnapshotsRequest
+	10, // 8: backuprestore.v1.BackupRestore.TriggerSnapshot:input_type -> backuprestore.v1.TriggerSnapshotRequest
+	12, // 9: backuprestore.v1.BackupRestore.ReportChurn:input_type -> backuprestore.v1.ReportChurnRequest
+	1,  // 10: backuprestore.v1.BackupRestore.GetInitializationStatus:output_type -> backuprestore.v1.GetInitializationStatusResponse
+	1,  // 11: backuprestore.v1.BackupRestore.WatchInitializationStatus:output_type -> backuprestore.v1.GetInitializationStatusResponse
+	4,  // 12: backuprestore.v1.BackupRestore.TriggerInitialization:output_type -> backuprestore.v1.TriggerInitializationResponse
+	6,  // 13: backuprestore.v1.BackupRestore.GetEtcdConfig:output_type -> backuprestore.v1.GetEtcdConfigResponse
+	8,  // 14: backuprestore.v1.BackupRestore.GetLatestSnapshots:output_type -> backuprestore.v1.GetLatestSnapshotsResponse
+	11, // 15: backuprestore.v1.BackupRestore.TriggerSnapshot:output_type -> backuprestore.v1.TriggerSnapshotResponse
+	13, // 16: backuprestore.v1.BackupRestore.ReportChurn:output_type -> backuprestore.v1.ReportChurnResponse
+	10, // [10:17] is the sub-list for method output_type
+	3,  // [3:10] is the sub-list for method input_type
+	3,  // [3:3] is the sub-list for extension type_name
+	3,  // [3:3] is the sub-list for extension extendee
+	0,  // [0:3] is the sub-list for field type_name
+}
+
+func init() { file_backuprestore_proto_init() }
+func file_backuprestore_proto_init() {
+	if File_backuprestore_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_backuprestore_proto_rawDesc), len(file_backuprestore_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   14,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_backuprestore_proto_goTypes,
+		DependencyIndexes: file_backuprestore_proto_depIdxs,
+		MessageInfos:      file_backuprestore_proto_msgTypes,
+	}.Build()
+	File_backuprestore_proto = out.File
+	file_backuprestore_proto_goTypes = nil
+	file_backuprestore_proto_depIdxs = nil
+}
@@ -0,0 +1,10 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package backuprestorepb contains the messages and service definition of the gRPC protocol spoken between etcd-wrapper
+// and backup-restore, generated from backuprestore.proto with protoc-gen-go and protoc-gen-go-grpc. Run `make generate`
+// after changing backuprestore.proto.
+package backuprestorepb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative backuprestore.proto
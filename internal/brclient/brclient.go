@@ -6,11 +6,10 @@ package brclient
 
 import (
 	"context"
-	"fmt"
-	"io"
-	"net/http"
+	"errors"
 	"os"
 	"path/filepath"
+	"slices"
 	"time"
 
 	"github.com/gardener/etcd-wrapper/internal/types"
@@ -45,6 +44,33 @@ const (
 	httpClientRequestTimeout = 1 * time.Minute
 )
 
+// SnapshotKind is the kind of snapshot which can be triggered on backup-restore.
+type SnapshotKind string
+
+const (
+	// FullSnapshotKind is a snapshot of the complete etcd DB.
+	FullSnapshotKind SnapshotKind = types.SnapshotKindFull
+	// DeltaSnapshotKind is a snapshot of the changes since the last snapshot.
+	DeltaSnapshotKind SnapshotKind = types.SnapshotKindDelta
+)
+
+// APIVersion is a version of the HTTP API of backup-restore, which determines the schema of the requests and
+// responses exchanged during initialization.
+type APIVersion int
+
+const (
+	// APIVersion1 is the API of backup-restore which does not support the negotiation of API versions. Initialization
+	// is triggered via GET with the validation mode as query parameter, and the initialization status is returned as
+	// plain text.
+	APIVersion1 APIVersion = 1
+	// APIVersion2 triggers initialization via POST with the validation mode in a JSON body, and returns the
+	// initialization status as JSON.
+	APIVersion2 APIVersion = 2
+)
+
+// SupportedAPIVersions are the API versions of backup-restore supported by etcd-wrapper, in the order of preference.
+var SupportedAPIVersions = []APIVersion{APIVersion2, APIVersion1}
+
 // BackupRestoreClient is a client to connect to the backup-restore HTTPs server.
 type BackupRestoreClient interface {
 	// GetInitializationStatus gets the latest state of initialization from the backup-restore.
@@ -53,135 +79,200 @@ type BackupRestoreClient interface {
 	TriggerInitialization(ctx context.Context, validationType ValidationType) error
 	// GetEtcdConfig gets the etcd configuration from the backup-restore, stores it into a file and returns the path to the file.
 	GetEtcdConfig(ctx context.Context) (string, error)
+	// GetLatestSnapshots gets the metadata of the latest full and delta snapshots taken by the backup-restore.
+	// It returns nil if no snapshot has been taken yet.
+	GetLatestSnapshots(ctx context.Context) (*LatestSnapshots, error)
+	// TriggerSnapshot triggers an out-of-schedule snapshot of the given kind on the backup-restore and waits till it
+	// has been taken. It returns nil if no snapshot has been taken since there are no changes to capture.
+	TriggerSnapshot(ctx context.Context, kind SnapshotKind) (*Snapshot, error)
+	// ReportChurn reports the write churn of etcd to the backup-restore, which can adapt the period of delta snapshots
+	// to it. It returns the period of delta snapshots adopted by the backup-restore, zero if unknown. ErrNotSupported
+	// is returned if the backup-restore does not support churn reports.
+	ReportChurn(ctx context.Context, report ChurnReport) (time.Duration, error)
 }
 
-// brClient implements BackupRestoreClient interface.
-type brClient struct {
-	client                   *http.Client
-	backupRestoreBaseAddress string
-	etcdConfigFilePath       string
-}
+// ErrNotSupported is returned by a BackupRestoreClient if the backup-restore does not support the requested operation.
+var ErrNotSupported = errors.New("operation is not supported by backup-restore")
 
-// NewDefaultClient creates a BackupRestoreClient using the BackupRestoreConfig and etcd configuration at etcdConfigPath.
-// It delegates the responsibility to NewClient by passing in a default implementation of HttpClientCreator.
-func NewDefaultClient(brConfig types.BackupRestoreConfig) (BackupRestoreClient, error) {
-	client, err := createClient(brConfig)
-	if err != nil {
-		return nil, err
-	}
-	userHomeDir, err := os.UserHomeDir()
-	if err != nil {
-		return nil, err
-	}
-	defaultEtcdConfigFilePath := filepath.Join(userHomeDir, "etcd.conf.yaml")
-	return NewClient(client, brConfig.GetBaseAddress(), defaultEtcdConfigFilePath), nil
+// InitStatusWatcher is implemented by a BackupRestoreClient which is able to stream changes of the initialization status.
+type InitStatusWatcher interface {
+	// WatchInitializationStatus returns a channel onto which every change of the initialization status is sent. The channel
+	// is closed once initialization has succeeded, the stream breaks or the context is cancelled.
+	WatchInitializationStatus(ctx context.Context) (<-chan InitStatus, error)
 }
 
-// NewClient creates and returns a new BackupRestoreClient object
-func NewClient(httpClient *http.Client, backupRestoreBaseAddress, etcdConfigFilePath string) BackupRestoreClient {
-	return &brClient{
-		client:                   httpClient,
-		backupRestoreBaseAddress: backupRestoreBaseAddress,
-		etcdConfigFilePath:       etcdConfigFilePath,
-	}
+// EtcdConfigPoller is implemented by a BackupRestoreClient which is able to fetch the etcd configuration conditionally,
+// so that it can be polled cheaply for changes.
+type EtcdConfigPoller interface {
+	// PollEtcdConfig fetches the etcd configuration only if it differs from the one last fetched, or from the one stored
+	// in the file returned by GetEtcdConfig if none has been fetched yet. If it differs, it is stored into that file and
+	// true is returned.
+	PollEtcdConfig(ctx context.Context) (bool, error)
 }
 
-func (c *brClient) GetInitializationStatus(ctx context.Context) (InitStatus, error) {
-	response, err := c.createAndExecuteHTTPRequest(ctx, http.MethodGet, c.backupRestoreBaseAddress+"/initialization/status")
-	if err != nil {
-		return Unknown, err
-	}
-	defer util.CloseResponseBody(response)
+// CABundleReloader is implemented by a BackupRestoreClient which is able to reload the CA bundle with which the
+// certificate of backup-restore is verified, so that a rotation of the CA does not require a restart of etcd-wrapper.
+type CABundleReloader interface {
+	// ReloadCABundle reads the CA bundle again and verifies all new connections to backup-restore with it. The CA bundle
+	// read before is kept if the CA bundle cannot be read.
+	ReloadCABundle() error
+}
 
-	if !util.ResponseHasOKCode(response) {
-		return Unknown, fmt.Errorf("server returned error response code when attempting to get initialization status: %v", response)
-	}
+// HostPortUpdater is implemented by a BackupRestoreClient which is able to move to another host and port of
+// backup-restore without being recreated, e.g. once the address of backup-restore published by druid has changed.
+type HostPortUpdater interface {
+	// UpdateHostPort sends all new requests to backup-restore at hostPort, which must be of the format <host>:<port>.
+	UpdateHostPort(hostPort string) error
+}
 
-	bodyBytes, err := io.ReadAll(response.Body)
-	if err != nil {
-		return Unknown, err
-	}
-	initializationStatus := string(bodyBytes)
+// IdleConnectionCloser is implemented by a BackupRestoreClient which keeps connections to backup-restore open between
+// requests.
+type IdleConnectionCloser interface {
+	// CloseIdleConnections closes the connections to backup-restore which are not in use by a request.
+	CloseIdleConnections()
+}
 
-	switch initializationStatus {
-	case New.String():
-		return New, nil
-	case Successful.String():
-		return Successful, nil
-	default:
-		return InProgress, nil
-	}
+// QuotaAdvisoryReporter is implemented by a BackupRestoreClient which is able to report whether writes to etcd are at
+// risk of being rejected due to its backend quota, so that alerts driven by backup-restore can fire before etcd raises
+// the NOSPACE alarm.
+type QuotaAdvisoryReporter interface {
+	// ReportQuotaAdvisory reports the advisory to backup-restore. ErrNotSupported is returned if the backup-restore
+	// does not support quota advisories.
+	ReportQuotaAdvisory(ctx context.Context, advisory QuotaAdvisory) error
 }
 
-func (c *brClient) TriggerInitialization(ctx context.Context, validationType ValidationType) error {
-	// TODO (@aaronfern): triggering initialization should not be using `GET` verb. `POST` should be used instead. This will require changes to backup-restore (to be done later).
-	url := c.backupRestoreBaseAddress + fmt.Sprintf("/initialization/start?mode=%s", validationType)
-	response, err := c.createAndExecuteHTTPRequest(ctx, http.MethodGet, url)
-	if err != nil {
-		return err
-	}
-	defer util.CloseResponseBody(response)
+// APIVersionNegotiator is implemented by a BackupRestoreClient whose schema of requests and responses depends on the
+// API version of backup-restore. Until the API version has been negotiated, APIVersion1 is used.
+type APIVersionNegotiator interface {
+	// NegotiateAPIVersion queries the API versions supported by backup-restore and adapts all further requests to the
+	// most preferred of SupportedAPIVersions which is also supported by backup-restore. An IncompatibleAPIVersionError
+	// is returned if there is no such version.
+	NegotiateAPIVersion(ctx context.Context) (APIVersion, error)
+}
 
-	if !util.ResponseHasOKCode(response) {
-		return fmt.Errorf("server returned error response code when attempting to trigger initialization: %v", response)
-	}
+// Snapshot is the metadata of a snapshot as returned from backup-restore.
+type Snapshot struct {
+	// Kind is the kind of the snapshot, either `Full` or `Incr`.
+	Kind string `json:"kind"`
+	// StartRevision is the first etcd revision contained in the snapshot.
+	StartRevision int64 `json:"startRevision"`
+	// LastRevision is the last etcd revision contained in the snapshot.
+	LastRevision int64 `json:"lastRevision"`
+	// CreatedOn is the time at which the snapshot was taken.
+	CreatedOn time.Time `json:"createdOn"`
+	// SnapName is the name of the snapshot in the snapstore.
+	SnapName string `json:"snapName"`
+	// SizeBytes is the size of the snapshot in the snapstore. It is 0 if backup-restore does not report it.
+	SizeBytes int64 `json:"sizeBytes,omitempty"`
+}
 
-	return nil
+// ChurnReport is the write churn of etcd as observed by etcd-wrapper and reported to backup-restore.
+type ChurnReport struct {
+	// Revision is the etcd revision at the time of the observation.
+	Revision int64 `json:"revision"`
+	// RevisionsPerSecond is the rate at which the etcd revision has grown within Window.
+	RevisionsPerSecond float64 `json:"revisionsPerSecond"`
+	// Window is the time window over which RevisionsPerSecond has been observed.
+	Window time.Duration `json:"-"`
+	// ObservedAt is the time of the observation.
+	ObservedAt time.Time `json:"observedAt"`
 }
 
-func (c *brClient) GetEtcdConfig(ctx context.Context) (string, error) {
-	// TODO (@aaronfern) If and when we directly mount etcd configuration to etcd-wrapper then we need to remove this and also add a command line parameter to take the path to the configuration.
-	response, err := c.createAndExecuteHTTPRequest(ctx, http.MethodGet, c.backupRestoreBaseAddress+"/config")
-	if err != nil {
-		return "", err
-	}
-	defer util.CloseResponseBody(response)
+// QuotaAdvisory is the DB size of etcd relative to its backend quota, and whether writes are at risk of being rejected.
+type QuotaAdvisory struct {
+	// DBSizeBytes is the physical size of the etcd DB, which is compared against the backend quota by etcd.
+	DBSizeBytes int64 `json:"dbSizeBytes"`
+	// DBSizeInUseBytes is the logical size of the etcd DB, which the DB shrinks to when it is defragmented.
+	DBSizeInUseBytes int64 `json:"dbSizeInUseBytes"`
+	// QuotaBackendBytes is the backend quota of etcd. It is zero if the backend quota is disabled.
+	QuotaBackendBytes int64 `json:"quotaBackendBytes"`
+	// QuotaUsedRatio is the ratio of DBSizeBytes to QuotaBackendBytes. It is zero if the backend quota is disabled.
+	QuotaUsedRatio float64 `json:"quotaUsedRatio"`
+	// NoSpaceAlarm is true if etcd has raised the NOSPACE alarm, i.e. rejects writes.
+	NoSpaceAlarm bool `json:"noSpaceAlarm"`
+	// WritesAtRisk is true if etcd rejects writes or is about to.
+	WritesAtRisk bool `json:"writesAtRisk"`
+	// Reasons explain why writes are at risk. It is empty if writes are not at risk.
+	Reasons []string `json:"reasons,omitempty"`
+	// ObservedAt is the time of the observation.
+	ObservedAt time.Time `json:"observedAt"`
+}
 
-	if !util.ResponseHasOKCode(response) {
-		return "", fmt.Errorf("server returned error response code when attempting to fetch etcd config: %v", response)
-	}
+// LatestSnapshots is the latest full snapshot and the delta snapshots taken after it, as returned from backup-restore.
+type LatestSnapshots struct {
+	// FullSnapshot is the latest full snapshot.
+	FullSnapshot *Snapshot `json:"fullSnapshot"`
+	// DeltaSnapshots are the delta snapshots taken after FullSnapshot.
+	DeltaSnapshots []*Snapshot `json:"deltaSnapshots"`
+}
 
-	etcdConfigBytes, err := io.ReadAll(response.Body)
-	if err != nil {
-		return "", err
+// LastRevision returns the last etcd revision that is captured by the latest snapshots.
+func (s *LatestSnapshots) LastRevision() int64 {
+	var lastRevision int64
+	if s.FullSnapshot != nil {
+		lastRevision = s.FullSnapshot.LastRevision
 	}
-	if err = os.WriteFile(c.etcdConfigFilePath, etcdConfigBytes, 0600); err != nil {
-		return "", err
+	for _, delta := range s.DeltaSnapshots {
+		if delta != nil && delta.LastRevision > lastRevision {
+			lastRevision = delta.LastRevision
+		}
 	}
-	return c.etcdConfigFilePath, nil
+	return lastRevision
 }
 
-func (c *brClient) createAndExecuteHTTPRequest(ctx context.Context, method, url string) (*http.Response, error) {
-	// create cancellable child context for http request
-	httpCtx, cancel := context.WithCancel(ctx)
-	defer cancel()
-
-	// create new request
-	req, err := http.NewRequestWithContext(httpCtx, method, url, nil)
+// NewDefaultClient creates a BackupRestoreClient using the BackupRestoreConfig and etcd configuration at etcdConfigPath.
+// Depending on the configured protocol it delegates the responsibility to either NewClient or NewGRPCClient. Proxies
+// configured via environment variables are used unless proxyEnvDisabled is true. Connections are dialed with dialer, or
+// with the dialer of the http or grpc package if it is nil.
+func NewDefaultClient(brConfig types.BackupRestoreConfig, proxyEnvDisabled bool, dialer *util.Dialer) (BackupRestoreClient, error) {
+	defaultEtcdConfigFilePath, err := DefaultEtcdConfigFilePath()
 	if err != nil {
 		return nil, err
 	}
 
-	// send http request
-	response, err := c.client.Do(req)
+	if brConfig.Protocol == types.BackupRestoreProtocolGRPC {
+		conn, err := createGRPCConn(brConfig, proxyEnvDisabled, dialer)
+		if err != nil {
+			return nil, err
+		}
+		return NewGRPCClient(conn, defaultEtcdConfigFilePath), nil
+	}
+
+	client, err := newDefaultClient(brConfig, proxyEnvDisabled, dialer, defaultEtcdConfigFilePath)
 	if err != nil {
 		return nil, err
 	}
-
-	return response, nil
+	return client, nil
 }
 
-func createClient(brConfig types.BackupRestoreConfig) (*http.Client, error) {
-	tlsConfig, err := util.CreateTLSConfig(func() bool { return brConfig.TLSEnabled }, brConfig.GetHost(), brConfig.CaCertBundlePath, nil)
+// DefaultEtcdConfigFilePath returns the path of the file into which the clients created by NewDefaultClient write the
+// etcd configuration fetched from backup-restore.
+func DefaultEtcdConfigFilePath() (string, error) {
+	userHomeDir, err := os.UserHomeDir()
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-	transport := &http.Transport{
-		TLSClientConfig: tlsConfig,
+	return filepath.Join(userHomeDir, "etcd.conf.yaml"), nil
+}
+
+// selectAPIVersion returns the most preferred of SupportedAPIVersions which is also contained in serverVersions.
+func selectAPIVersion(serverVersions []APIVersion) (APIVersion, error) {
+	for _, version := range SupportedAPIVersions {
+		if slices.Contains(serverVersions, version) {
+			return version, nil
+		}
 	}
-	client := &http.Client{
-		Transport: transport,
-		Timeout:   httpClientRequestTimeout,
+	return 0, &IncompatibleAPIVersionError{ServerVersions: serverVersions, SupportedVersions: SupportedAPIVersions}
+}
+
+// parseInitStatus converts the initialization status as returned from backup-restore into an InitStatus.
+func parseInitStatus(initializationStatus string) InitStatus {
+	switch initializationStatus {
+	case New.String():
+		return New
+	case Successful.String():
+		return Successful
+	default:
+		return InProgress
 	}
-	return client, nil
 }
@@ -7,9 +7,12 @@ package brclient
 import (
 	"bytes"
 	"context"
+	"encoding/pem"
 	"errors"
 	"io"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"path/filepath"
 	"testing"
@@ -37,9 +40,17 @@ func TestSuite(t *testing.T) {
 		testFn func(t *testing.T, etcdConfigFilePath string)
 	}{
 		{"getEtcdConfig", testGetEtcdConfig},
+		{"pollEtcdConfig", testPollEtcdConfig},
 		{"getInitializationStatus", testGetInitializationStatus},
 		{"triggerInitializer", testTriggerInitialization},
+		{"getLatestSnapshots", testGetLatestSnapshots},
+		{"triggerSnapshot", testTriggerSnapshot},
+		{"reportChurn", testReportChurn},
+		{"reportQuotaAdvisory", testReportQuotaAdvisory},
+		{"negotiateAPIVersion", testNegotiateAPIVersion},
 		{"createClient", testCreateSidecarClient},
+		{"reloadCABundle", testReloadCABundle},
+		{"updateHostPort", testUpdateHostPort},
 	}
 
 	g := NewWithT(t)
@@ -96,6 +107,58 @@ func testGetEtcdConfig(t *testing.T, etcdConfigFilePath string) {
 	}
 }
 
+func testPollEtcdConfig(t *testing.T, etcdConfigFilePath string) {
+	table := []struct {
+		description      string
+		etag             bool
+		expectedRequests []string
+	}{
+		{"should send the ETag of the last fetched config as If-None-Match", true, []string{"", `"v1"`, `"v1"`, `"v2"`}},
+		{"should compare the config by its digest without ETag support", false, []string{"", "", "", ""}},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		config, version := "config-v1", "v1"
+		var ifNoneMatch []string
+		httpClient := &http.Client{Transport: TestRoundTripper(func(req *http.Request) *http.Response {
+			ifNoneMatch = append(ifNoneMatch, req.Header.Get("If-None-Match"))
+			header := http.Header{}
+			if entry.etag {
+				etag := `"` + version + `"`
+				if req.Header.Get("If-None-Match") == etag {
+					return &http.Response{StatusCode: http.StatusNotModified, Header: header, Body: io.NopCloser(bytes.NewReader(nil))}
+				}
+				header.Set("ETag", etag)
+			}
+			return &http.Response{StatusCode: http.StatusOK, Header: header, Body: io.NopCloser(bytes.NewBufferString(config))}
+		})}
+		brc := NewClient(httpClient, "", etcdConfigFilePath)
+		poller, ok := brc.(EtcdConfigPoller)
+		g.Expect(ok).To(BeTrue())
+
+		_, err := brc.GetEtcdConfig(context.TODO())
+		g.Expect(err).ToNot(HaveOccurred())
+		changed, err := poller.PollEtcdConfig(context.TODO())
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(changed).To(BeFalse())
+
+		config, version = "config-v2", "v2"
+		changed, err = poller.PollEtcdConfig(context.TODO())
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(changed).To(BeTrue())
+		content, err := os.ReadFile(etcdConfigFilePath)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(string(content)).To(Equal("config-v2"))
+
+		changed, err = poller.PollEtcdConfig(context.TODO())
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(changed).To(BeFalse())
+		g.Expect(ifNoneMatch).To(Equal(entry.expectedRequests))
+	}
+}
+
 func testGetInitializationStatus(t *testing.T, etcdConfigFilePath string) {
 	table := []struct {
 		description             string
@@ -161,6 +224,192 @@ func testTriggerInitialization(t *testing.T, etcdConfigFilePath string) {
 	}
 }
 
+func testGetLatestSnapshots(t *testing.T, etcdConfigFilePath string) {
+	table := []struct {
+		description          string
+		responseCode         int
+		responseBody         []byte
+		expectError          bool
+		expectNil            bool
+		expectedLastRevision int64
+	}{
+		{"should return latest snapshots when server returns them", http.StatusOK, []byte(`{"fullSnapshot":{"kind":"Full","lastRevision":10},"deltaSnapshots":[{"kind":"Incr","startRevision":11,"lastRevision":20}]}`), false, false, 20},
+		{"should return nil when no snapshot has been taken yet", http.StatusOK, []byte(`{"fullSnapshot":null,"deltaSnapshots":null}`), false, true, 0},
+		{"should return nil when server returns 404", http.StatusNotFound, []byte("not found"), false, true, 0},
+		{"should return an error when server returns an error code", http.StatusInternalServerError, []byte("error"), true, true, 0},
+		{"should return an error when response cannot be decoded", http.StatusOK, []byte("not json"), true, true, 0},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+
+		httpClient := getTestHttpClient(entry.responseCode, entry.responseBody)
+		brc := NewClient(httpClient, "", etcdConfigFilePath)
+		latestSnapshots, err := brc.GetLatestSnapshots(context.TODO())
+		g.Expect(err != nil).To(Equal(entry.expectError))
+		g.Expect(latestSnapshots == nil).To(Equal(entry.expectNil))
+		if latestSnapshots != nil {
+			g.Expect(latestSnapshots.LastRevision()).To(Equal(entry.expectedLastRevision))
+		}
+	}
+}
+
+func testTriggerSnapshot(t *testing.T, etcdConfigFilePath string) {
+	table := []struct {
+		description          string
+		responseCode         int
+		responseBody         []byte
+		expectError          bool
+		expectNil            bool
+		expectedLastRevision int64
+	}{
+		{"should return the snapshot when server has taken it", http.StatusOK, []byte(`{"kind":"Incr","startRevision":11,"lastRevision":20,"snapName":"Incr-11-20"}`), false, false, 20},
+		{"should return nil when there are no changes to capture", http.StatusOK, []byte("null"), false, true, 0},
+		{"should return nil when server returns an empty response", http.StatusOK, nil, false, true, 0},
+		{"should return an error when server returns an error code", http.StatusInternalServerError, []byte("error"), true, true, 0},
+		{"should return an error when response cannot be decoded", http.StatusOK, []byte("not json"), true, true, 0},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+
+		httpClient := getTestHttpClient(entry.responseCode, entry.responseBody)
+		brc := NewClient(httpClient, "", etcdConfigFilePath)
+		snapshot, err := brc.TriggerSnapshot(context.TODO(), DeltaSnapshotKind)
+		g.Expect(err != nil).To(Equal(entry.expectError))
+		g.Expect(snapshot == nil).To(Equal(entry.expectNil))
+		if snapshot != nil {
+			g.Expect(snapshot.LastRevision).To(Equal(entry.expectedLastRevision))
+		}
+	}
+}
+
+func testReportChurn(t *testing.T, etcdConfigFilePath string) {
+	table := []struct {
+		description    string
+		responseCode   int
+		responseBody   []byte
+		expectedErr    error
+		expectError    bool
+		expectedPeriod time.Duration
+	}{
+		{"should return the adopted delta snapshot period", http.StatusOK, []byte(`{"deltaSnapshotPeriodSeconds":30}`), nil, false, 30 * time.Second},
+		{"should return zero when server returns an empty response", http.StatusOK, nil, nil, false, 0},
+		{"should return ErrNotSupported when server does not serve churn reports", http.StatusNotFound, nil, ErrNotSupported, true, 0},
+		{"should return an error when server returns an error code", http.StatusInternalServerError, []byte("error"), nil, true, 0},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+
+		httpClient := getTestHttpClient(entry.responseCode, entry.responseBody)
+		brc := NewClient(httpClient, "", etcdConfigFilePath)
+		period, err := brc.ReportChurn(context.TODO(), ChurnReport{Revision: 10, RevisionsPerSecond: 1.5, Window: time.Minute, ObservedAt: time.Now()})
+		g.Expect(err != nil).To(Equal(entry.expectError))
+		if entry.expectedErr != nil {
+			g.Expect(err).To(MatchError(entry.expectedErr))
+		}
+		g.Expect(period).To(Equal(entry.expectedPeriod))
+	}
+}
+
+func testReportQuotaAdvisory(t *testing.T, etcdConfigFilePath string) {
+	table := []struct {
+		description  string
+		responseCode int
+		expectedErr  error
+		expectError  bool
+	}{
+		{"should report the quota advisory", http.StatusOK, nil, false},
+		{"should return ErrNotSupported when server does not serve quota advisories", http.StatusNotFound, ErrNotSupported, true},
+		{"should return ErrNotSupported when server does not accept posted quota advisories", http.StatusMethodNotAllowed, ErrNotSupported, true},
+		{"should return an error when server returns an error code", http.StatusInternalServerError, nil, true},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+
+		httpClient := getTestHttpClient(entry.responseCode, nil)
+		brc := NewClient(httpClient, "", etcdConfigFilePath)
+		reporter, ok := brc.(QuotaAdvisoryReporter)
+		g.Expect(ok).To(BeTrue())
+		err := reporter.ReportQuotaAdvisory(context.TODO(), QuotaAdvisory{DBSizeBytes: 1024, QuotaBackendBytes: 2048, QuotaUsedRatio: 0.5, ObservedAt: time.Now()})
+		g.Expect(err != nil).To(Equal(entry.expectError))
+		if entry.expectedErr != nil {
+			g.Expect(err).To(MatchError(entry.expectedErr))
+		}
+	}
+}
+
+func testNegotiateAPIVersion(t *testing.T, etcdConfigFilePath string) {
+	table := []struct {
+		description       string
+		versionsCode      int
+		versionsBody      []byte
+		expectedVersion   APIVersion
+		expectError       bool
+		expectPermanent   bool
+		expectedStartCall string
+	}{
+		{"should negotiate the most preferred common API version", http.StatusOK, []byte(`{"versions":[1,2,3]}`), APIVersion2, false, false, "POST /initialization/start"},
+		{"should fall back to API version 1 when server supports no newer version", http.StatusOK, []byte(`{"versions":[1]}`), APIVersion1, false, false, "GET /initialization/start?mode=full"},
+		{"should fall back to API version 1 when server does not support negotiation", http.StatusNotFound, nil, APIVersion1, false, false, "GET /initialization/start?mode=full"},
+		{"should return an incompatibility error when server supports no common API version", http.StatusOK, []byte(`{"versions":[3]}`), 0, true, true, "GET /initialization/start?mode=full"},
+		{"should return an error when server returns an error code", http.StatusServiceUnavailable, nil, 0, true, false, "GET /initialization/start?mode=full"},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		var (
+			startCall  string
+			startBody  []byte
+			statusBody = []byte(New.String())
+		)
+		if entry.expectedVersion == APIVersion2 {
+			statusBody = []byte(`{"status":"New"}`)
+		}
+		httpClient := &http.Client{
+			Transport: TestRoundTripper(func(req *http.Request) *http.Response {
+				code, body := http.StatusOK, []byte(nil)
+				switch req.URL.Path {
+				case "/api/versions":
+					code, body = entry.versionsCode, entry.versionsBody
+				case "/initialization/status":
+					body = statusBody
+				case "/initialization/start":
+					startCall = req.Method + " " + req.URL.RequestURI()
+					if req.Body != nil {
+						startBody, _ = io.ReadAll(req.Body)
+					}
+				}
+				return &http.Response{StatusCode: code, Body: io.NopCloser(bytes.NewReader(body))}
+			}),
+		}
+		brc := NewClient(httpClient, "", etcdConfigFilePath)
+		negotiator, ok := brc.(APIVersionNegotiator)
+		g.Expect(ok).To(BeTrue())
+		version, err := negotiator.NegotiateAPIVersion(context.TODO())
+		g.Expect(err != nil).To(Equal(entry.expectError))
+		g.Expect(IsPermanent(err)).To(Equal(entry.expectPermanent))
+		g.Expect(version).To(Equal(entry.expectedVersion))
+
+		t.Log("should adapt the schema of initialization requests to the negotiated API version")
+		status, err := brc.GetInitializationStatus(context.TODO())
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(status).To(Equal(New))
+		g.Expect(brc.TriggerInitialization(context.TODO(), FullValidation)).To(Succeed())
+		g.Expect(startCall).To(Equal(entry.expectedStartCall))
+		if entry.expectedVersion == APIVersion2 {
+			g.Expect(startBody).To(MatchJSON(`{"mode":"full"}`))
+		}
+	}
+}
+
 func testCreateSidecarClient(t *testing.T, _ string) {
 	incorrectCAFilePath := testdataPath + "/wrong-path"
 	table := []struct {
@@ -174,11 +423,78 @@ func testCreateSidecarClient(t *testing.T, _ string) {
 	g := NewWithT(t)
 	for _, entry := range table {
 		t.Log(entry.description)
-		_, err := createClient(entry.sidecarConfig)
+		_, err := createClient(entry.sidecarConfig, false, nil)
 		g.Expect(err != nil).To(Equal(entry.expectError))
 	}
 }
 
+func testReloadCABundle(t *testing.T, etcdConfigFilePath string) {
+	g := NewWithT(t)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("New"))
+	}))
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	g.Expect(err).ToNot(HaveOccurred())
+	caBundlePath := filepath.Join(t.TempDir(), "bundle.crt")
+	unrelatedCA, err := os.ReadFile(etcdCACertFilePath)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(os.WriteFile(caBundlePath, unrelatedCA, 0600)).To(Succeed())
+	client, err := createClient(types.BackupRestoreConfig{TLSEnabled: true, HostPort: serverURL.Host, CaCertBundlePath: caBundlePath, ServerName: "example.com"}, true, nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	brc := NewClient(client, server.URL, etcdConfigFilePath)
+
+	t.Log("should fail to verify backup-restore before the CA bundle has been rotated")
+	_, err = brc.GetInitializationStatus(context.Background())
+	g.Expect(err).To(HaveOccurred())
+
+	t.Log("should keep the CA bundle if the rotated CA bundle cannot be read")
+	g.Expect(os.Remove(caBundlePath)).To(Succeed())
+	g.Expect(brc.(CABundleReloader).ReloadCABundle()).ToNot(Succeed())
+
+	t.Log("should verify backup-restore with the rotated CA bundle once it has been reloaded")
+	g.Expect(os.WriteFile(caBundlePath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0600)).To(Succeed())
+	g.Expect(brc.(CABundleReloader).ReloadCABundle()).To(Succeed())
+	status, err := brc.GetInitializationStatus(context.Background())
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(status).To(Equal(New))
+
+	t.Log("should fail to reload the CA bundle of an HTTP client which does not support it")
+	g.Expect(NewClient(&http.Client{}, server.URL, etcdConfigFilePath).(CABundleReloader).ReloadCABundle()).ToNot(Succeed())
+}
+
+func testUpdateHostPort(t *testing.T, etcdConfigFilePath string) {
+	g := NewWithT(t)
+	newServer := func(status string) (*httptest.Server, string) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte(status))
+		}))
+		serverURL, err := url.Parse(server.URL)
+		g.Expect(err).ToNot(HaveOccurred())
+		return server, serverURL.Host
+	}
+	previousServer, previousHostPort := newServer("New")
+	defer previousServer.Close()
+	movedServer, movedHostPort := newServer("Successful")
+	defer movedServer.Close()
+	brc, err := newDefaultClient(types.BackupRestoreConfig{HostPort: previousHostPort}, true, nil, etcdConfigFilePath)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	t.Log("should send requests to the configured host and port")
+	status, err := brc.GetInitializationStatus(context.Background())
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(status).To(Equal(New))
+
+	t.Log("should send requests to the updated host and port")
+	g.Expect(brc.UpdateHostPort(movedHostPort)).To(Succeed())
+	status, err = brc.GetInitializationStatus(context.Background())
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(status).To(Equal(Successful))
+
+	t.Log("should fail to update the host and port of an HTTP client which does not support it")
+	g.Expect(NewClient(&http.Client{}, previousServer.URL, etcdConfigFilePath).(HostPortUpdater).UpdateHostPort(movedHostPort)).ToNot(Succeed())
+}
+
 func TestNewDefaultClient(t *testing.T) {
 	incorrectCAFilePath := etcdCACertFilePath + "/wrong-path"
 	table := []struct {
@@ -197,7 +513,7 @@ func TestNewDefaultClient(t *testing.T) {
 
 	for _, entry := range table {
 		t.Log(entry.description)
-		_, err := NewDefaultClient(entry.sidecarConfig)
+		_, err := NewDefaultClient(entry.sidecarConfig, false, nil)
 		g.Expect(err != nil).To(Equal(entry.expectError))
 	}
 }
@@ -0,0 +1,96 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package brclient
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gardener/etcd-wrapper/internal/types"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ResponseError is returned by a BackupRestoreClient if the HTTP server of backup-restore responds with an error status
+// code.
+type ResponseError struct {
+	// Operation is the operation which has failed, e.g. "get initialization status".
+	Operation string
+	// StatusCode is the status code of the response.
+	StatusCode int
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("server returned error response code %d when attempting to %s", e.StatusCode, e.Operation)
+}
+
+// IncompatibleAPIVersionError is returned by an APIVersionNegotiator if backup-restore supports none of the API
+// versions supported by etcd-wrapper, e.g. during a rollout of incompatible versions of etcd-wrapper and backup-restore.
+type IncompatibleAPIVersionError struct {
+	// ServerVersions are the API versions supported by backup-restore.
+	ServerVersions []APIVersion
+	// SupportedVersions are the API versions supported by etcd-wrapper.
+	SupportedVersions []APIVersion
+}
+
+func (e *IncompatibleAPIVersionError) Error() string {
+	return fmt.Sprintf("backup-restore supports API versions %v, none of which is supported by etcd-wrapper supporting API versions %v, "+
+		"etcd-wrapper and backup-restore have to be updated to compatible versions", e.ServerVersions, e.SupportedVersions)
+}
+
+// ExitCode returns the exit code with which etcd-wrapper exits because of the incompatible API versions.
+func (e *IncompatibleAPIVersionError) ExitCode() int {
+	return types.ExitCodeSidecarAPIVersionIncompatible
+}
+
+// newResponseError creates a ResponseError for the response to the failed operation.
+func newResponseError(operation string, response *http.Response) *ResponseError {
+	return &ResponseError{Operation: operation, StatusCode: response.StatusCode}
+}
+
+// permanentGRPCCodes are the gRPC status codes with which backup-restore rejects a request regardless of how often it
+// is retried.
+var permanentGRPCCodes = map[codes.Code]struct{}{
+	codes.InvalidArgument:    {},
+	codes.NotFound:           {},
+	codes.AlreadyExists:      {},
+	codes.PermissionDenied:   {},
+	codes.Unauthenticated:    {},
+	codes.Unimplemented:      {},
+	codes.FailedPrecondition: {},
+	codes.OutOfRange:         {},
+}
+
+// IsPermanent returns true if err has been returned by a BackupRestoreClient for a request which backup-restore has
+// rejected and which fails again if it is retried: HTTP responses with a 4xx status code other than 408 (Request
+// Timeout) and 429 (Too Many Requests), gRPC status codes like InvalidArgument or PermissionDenied, ErrNotSupported
+// and IncompatibleAPIVersionError.
+// All other errors, e.g. 5xx status codes, timeouts and connection failures, are transient.
+func IsPermanent(err error) bool {
+	if err == nil {
+		return false
+	}
+	var incompatibleErr *IncompatibleAPIVersionError
+	if errors.Is(err, ErrNotSupported) || errors.As(err, &incompatibleErr) {
+		return true
+	}
+	var responseErr *ResponseError
+	if errors.As(err, &responseErr) {
+		return responseErr.StatusCode >= 400 && responseErr.StatusCode < 500 &&
+			responseErr.StatusCode != http.StatusRequestTimeout && responseErr.StatusCode != http.StatusTooManyRequests
+	}
+	if s, ok := status.FromError(err); ok {
+		_, permanent := permanentGRPCCodes[s.Code()]
+		return permanent
+	}
+	return false
+}
+
+// IsRetryable returns true if err is not permanent, see IsPermanent. It can be used as util.CanRetryPredicate.
+func IsRetryable(err error) bool {
+	return !IsPermanent(err)
+}
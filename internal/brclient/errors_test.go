@@ -0,0 +1,46 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package brclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestIsPermanent(t *testing.T) {
+	table := []struct {
+		description     string
+		err             error
+		expectPermanent bool
+	}{
+		{"should not classify nil as permanent", nil, false},
+		{"should classify 4xx responses as permanent", &ResponseError{Operation: "fetch etcd config", StatusCode: http.StatusNotFound}, true},
+		{"should classify wrapped 4xx responses as permanent", fmt.Errorf("bootstrap failed: %w", &ResponseError{StatusCode: http.StatusForbidden}), true},
+		{"should classify request timeouts as transient", &ResponseError{StatusCode: http.StatusRequestTimeout}, false},
+		{"should classify rate limiting as transient", &ResponseError{StatusCode: http.StatusTooManyRequests}, false},
+		{"should classify 5xx responses as transient", &ResponseError{StatusCode: http.StatusServiceUnavailable}, false},
+		{"should classify unsupported operations as permanent", ErrNotSupported, true},
+		{"should classify rejecting gRPC status codes as permanent", fmt.Errorf("failed to trigger initialization: %w", status.Error(codes.InvalidArgument, "unknown mode")), true},
+		{"should classify unavailability via gRPC as transient", fmt.Errorf("failed to get initialization status: %w", status.Error(codes.Unavailable, "connection refused")), false},
+		{"should classify timeouts as transient", context.DeadlineExceeded, false},
+		{"should classify connection failures as transient", errors.New("dial tcp 127.0.0.1:8080: connect: connection refused"), false},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		g.Expect(IsPermanent(entry.err)).To(Equal(entry.expectPermanent))
+		if entry.err != nil {
+			g.Expect(IsRetryable(entry.err)).To(Equal(!entry.expectPermanent))
+		}
+	}
+}
@@ -0,0 +1,124 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package brclient
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// FakeClient is a fake implementation of BackupRestoreClient which returns preconfigured responses.
+// It can be used to test components which interact with backup-restore without running a backup-restore server.
+type FakeClient struct {
+	mu sync.Mutex
+	// InitStatuses are the statuses returned by successive calls to GetInitializationStatus.
+	// Once all statuses are consumed, the last status is returned for all subsequent calls.
+	InitStatuses []InitStatus
+	// InitStatusErr is the error returned by GetInitializationStatus.
+	InitStatusErr error
+	// TriggerInitializationErr is the error returned by TriggerInitialization.
+	TriggerInitializationErr error
+	// EtcdConfigFilePath is the path returned by GetEtcdConfig.
+	EtcdConfigFilePath string
+	// EtcdConfigErr is the error returned by GetEtcdConfig.
+	EtcdConfigErr error
+	// LatestSnapshots is the value returned by GetLatestSnapshots.
+	LatestSnapshots *LatestSnapshots
+	// LatestSnapshotsErr is the error returned by GetLatestSnapshots.
+	LatestSnapshotsErr error
+	// TriggeredValidationTypes records the validation types passed to TriggerInitialization.
+	TriggeredValidationTypes []ValidationType
+	// Snapshot is the value returned by TriggerSnapshot.
+	Snapshot *Snapshot
+	// TriggerSnapshotErr is the error returned by TriggerSnapshot.
+	TriggerSnapshotErr error
+	// TriggeredSnapshotKinds records the snapshot kinds passed to TriggerSnapshot.
+	TriggeredSnapshotKinds []SnapshotKind
+	// DeltaSnapshotPeriod is the value returned by ReportChurn.
+	DeltaSnapshotPeriod time.Duration
+	// ReportChurnErr is the error returned by ReportChurn.
+	ReportChurnErr error
+	// ChurnReports records the reports passed to ReportChurn.
+	ChurnReports []ChurnReport
+	// APIVersion is the value returned by NegotiateAPIVersion. APIVersion1 is returned if it is zero.
+	APIVersion APIVersion
+	// NegotiateAPIVersionErr is the error returned by NegotiateAPIVersion.
+	NegotiateAPIVersionErr error
+	// ReportQuotaAdvisoryErr is the error returned by ReportQuotaAdvisory.
+	ReportQuotaAdvisoryErr error
+	// QuotaAdvisories records the advisories passed to ReportQuotaAdvisory.
+	QuotaAdvisories []QuotaAdvisory
+}
+
+// GetInitializationStatus returns the next status from InitStatuses.
+func (f *FakeClient) GetInitializationStatus(_ context.Context) (InitStatus, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.InitStatusErr != nil {
+		return Unknown, f.InitStatusErr
+	}
+	if len(f.InitStatuses) == 0 {
+		return Unknown, nil
+	}
+	status := f.InitStatuses[0]
+	if len(f.InitStatuses) > 1 {
+		f.InitStatuses = f.InitStatuses[1:]
+	}
+	return status, nil
+}
+
+// TriggerInitialization records the validationType and returns TriggerInitializationErr.
+func (f *FakeClient) TriggerInitialization(_ context.Context, validationType ValidationType) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.TriggeredValidationTypes = append(f.TriggeredValidationTypes, validationType)
+	return f.TriggerInitializationErr
+}
+
+// GetEtcdConfig returns EtcdConfigFilePath.
+func (f *FakeClient) GetEtcdConfig(_ context.Context) (string, error) {
+	return f.EtcdConfigFilePath, f.EtcdConfigErr
+}
+
+// GetLatestSnapshots returns LatestSnapshots.
+func (f *FakeClient) GetLatestSnapshots(_ context.Context) (*LatestSnapshots, error) {
+	return f.LatestSnapshots, f.LatestSnapshotsErr
+}
+
+// TriggerSnapshot records the kind and returns Snapshot.
+func (f *FakeClient) TriggerSnapshot(_ context.Context, kind SnapshotKind) (*Snapshot, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.TriggeredSnapshotKinds = append(f.TriggeredSnapshotKinds, kind)
+	return f.Snapshot, f.TriggerSnapshotErr
+}
+
+// ReportChurn records the report and returns DeltaSnapshotPeriod.
+func (f *FakeClient) ReportChurn(_ context.Context, report ChurnReport) (time.Duration, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.ChurnReports = append(f.ChurnReports, report)
+	return f.DeltaSnapshotPeriod, f.ReportChurnErr
+}
+
+// ReportQuotaAdvisory records the advisory and returns ReportQuotaAdvisoryErr.
+func (f *FakeClient) ReportQuotaAdvisory(_ context.Context, advisory QuotaAdvisory) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.QuotaAdvisories = append(f.QuotaAdvisories, advisory)
+	return f.ReportQuotaAdvisoryErr
+}
+
+// NegotiateAPIVersion returns APIVersion.
+func (f *FakeClient) NegotiateAPIVersion(_ context.Context) (APIVersion, error) {
+	if f.NegotiateAPIVersionErr != nil {
+		return 0, f.NegotiateAPIVersionErr
+	}
+	if f.APIVersion == 0 {
+		return APIVersion1, nil
+	}
+	return f.APIVersion, nil
+}
@@ -0,0 +1,176 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package brclient
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/brclient/backuprestorepb"
+	"github.com/gardener/etcd-wrapper/internal/types"
+	"github.com/gardener/etcd-wrapper/internal/util"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// grpcClient implements BackupRestoreClient and InitStatusWatcher interfaces by talking to the gRPC server of backup-restore.
+// It does not implement APIVersionNegotiator, since the gRPC service is versioned by its package, nor the optional
+// interfaces HostPortUpdater, CABundleReloader, EtcdConfigPoller and QuotaAdvisoryReporter. Configurations relying on
+// them are rejected by types.Config.Validate.
+type grpcClient struct {
+	client             backuprestorepb.BackupRestoreClient
+	etcdConfigFilePath string
+}
+
+// NewGRPCClient creates and returns a new BackupRestoreClient object which uses the gRPC protocol to talk to backup-restore.
+func NewGRPCClient(conn grpc.ClientConnInterface, etcdConfigFilePath string) BackupRestoreClient {
+	return &grpcClient{
+		client:             backuprestorepb.NewBackupRestoreClient(conn),
+		etcdConfigFilePath: etcdConfigFilePath,
+	}
+}
+
+func (c *grpcClient) GetInitializationStatus(ctx context.Context) (InitStatus, error) {
+	response, err := c.client.GetInitializationStatus(ctx, &backuprestorepb.GetInitializationStatusRequest{})
+	if err != nil {
+		return Unknown, fmt.Errorf("failed to get initialization status: %w", err)
+	}
+	return parseInitStatus(response.Status), nil
+}
+
+func (c *grpcClient) WatchInitializationStatus(ctx context.Context) (<-chan InitStatus, error) {
+	stream, err := c.client.WatchInitializationStatus(ctx, &backuprestorepb.WatchInitializationStatusRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch initialization status: %w", err)
+	}
+
+	statusCh := make(chan InitStatus)
+	go func() {
+		defer close(statusCh)
+		for {
+			response, err := stream.Recv()
+			if err != nil {
+				return
+			}
+			initStatus := parseInitStatus(response.Status)
+			select {
+			case statusCh <- initStatus:
+			case <-ctx.Done():
+				return
+			}
+			if initStatus == Successful {
+				return
+			}
+		}
+	}()
+	return statusCh, nil
+}
+
+func (c *grpcClient) TriggerInitialization(ctx context.Context, validationType ValidationType) error {
+	request := &backuprestorepb.TriggerInitializationRequest{ValidationMode: string(validationType)}
+	if _, err := c.client.TriggerInitialization(ctx, request); err != nil {
+		return fmt.Errorf("failed to trigger initialization: %w", err)
+	}
+	return nil
+}
+
+func (c *grpcClient) GetEtcdConfig(ctx context.Context) (string, error) {
+	response, err := c.client.GetEtcdConfig(ctx, &backuprestorepb.GetEtcdConfigRequest{})
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch etcd config: %w", err)
+	}
+	if err = os.WriteFile(c.etcdConfigFilePath, response.Config, 0600); err != nil {
+		return "", err
+	}
+	return c.etcdConfigFilePath, nil
+}
+
+func (c *grpcClient) GetLatestSnapshots(ctx context.Context) (*LatestSnapshots, error) {
+	response, err := c.client.GetLatestSnapshots(ctx, &backuprestorepb.GetLatestSnapshotsRequest{})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to fetch latest snapshots: %w", err)
+	}
+	if response.FullSnapshot == nil && len(response.DeltaSnapshots) == 0 {
+		return nil, nil
+	}
+	latestSnapshots := &LatestSnapshots{FullSnapshot: convertSnapshot(response.FullSnapshot)}
+	for _, delta := range response.DeltaSnapshots {
+		latestSnapshots.DeltaSnapshots = append(latestSnapshots.DeltaSnapshots, convertSnapshot(delta))
+	}
+	return latestSnapshots, nil
+}
+
+func (c *grpcClient) TriggerSnapshot(ctx context.Context, kind SnapshotKind) (*Snapshot, error) {
+	response, err := c.client.TriggerSnapshot(ctx, &backuprestorepb.TriggerSnapshotRequest{Kind: string(kind)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to trigger %s snapshot: %w", kind, err)
+	}
+	return convertSnapshot(response.Snapshot), nil
+}
+
+func (c *grpcClient) ReportChurn(ctx context.Context, report ChurnReport) (time.Duration, error) {
+	request := &backuprestorepb.ReportChurnRequest{
+		Revision:           report.Revision,
+		RevisionsPerSecond: report.RevisionsPerSecond,
+		WindowSeconds:      int64(report.Window.Seconds()),
+		ObservedAt:         report.ObservedAt.Unix(),
+	}
+	response, err := c.client.ReportChurn(ctx, request)
+	if err != nil {
+		if status.Code(err) == codes.Unimplemented {
+			return 0, ErrNotSupported
+		}
+		return 0, fmt.Errorf("failed to report churn: %w", err)
+	}
+	return time.Duration(response.DeltaSnapshotPeriodSeconds) * time.Second, nil
+}
+
+func convertSnapshot(snapshot *backuprestorepb.Snapshot) *Snapshot {
+	if snapshot == nil {
+		return nil
+	}
+	return &Snapshot{
+		Kind:          snapshot.Kind,
+		StartRevision: snapshot.StartRevision,
+		LastRevision:  snapshot.LastRevision,
+		CreatedOn:     time.Unix(snapshot.CreatedOn, 0).UTC(),
+		SnapName:      snapshot.SnapName,
+		SizeBytes:     snapshot.SizeBytes,
+	}
+}
+
+// createGRPCConn creates the connection to the gRPC server of backup-restore. Connections are dialed with dialer, or
+// with the dialer of the grpc package if it is nil.
+func createGRPCConn(brConfig types.BackupRestoreConfig, proxyEnvDisabled bool, dialer *util.Dialer) (*grpc.ClientConn, error) {
+	_, port, err := net.SplitHostPort(brConfig.HostPort)
+	if err != nil {
+		return nil, err
+	}
+	transportCredentials := insecure.NewCredentials()
+	if brConfig.TLSEnabled {
+		tlsConfig, err := util.CreateTLSConfig(func() bool { return true }, brConfig.GetServerName(), brConfig.CaCertBundlePath, nil)
+		if err != nil {
+			return nil, err
+		}
+		transportCredentials = credentials.NewTLS(tlsConfig)
+	}
+	dialOptions := append([]grpc.DialOption{grpc.WithTransportCredentials(transportCredentials)}, util.GRPCProxyDialOptions(proxyEnvDisabled)...)
+	if dialer != nil {
+		dialOptions = append(dialOptions, grpc.WithContextDialer(func(ctx context.Context, address string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "tcp", address)
+		}))
+	}
+	return grpc.NewClient(net.JoinHostPort(brConfig.GetHost(), port), dialOptions...)
+}
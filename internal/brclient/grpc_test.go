@@ -0,0 +1,212 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package brclient
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/brclient/backuprestorepb"
+	"github.com/gardener/etcd-wrapper/internal/types"
+	"github.com/gardener/etcd-wrapper/internal/util"
+
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+type testBackupRestoreServer struct {
+	backuprestorepb.UnimplementedBackupRestoreServer
+	statuses        []string
+	etcdConfig      []byte
+	latestSnapshots *backuprestorepb.GetLatestSnapshotsResponse
+	triggeredModes  []string
+	triggeredKinds  []string
+	churnReports    []*backuprestorepb.ReportChurnRequest
+}
+
+func (s *testBackupRestoreServer) GetInitializationStatus(_ context.Context, _ *backuprestorepb.GetInitializationStatusRequest) (*backuprestorepb.GetInitializationStatusResponse, error) {
+	return &backuprestorepb.GetInitializationStatusResponse{Status: s.statuses[0]}, nil
+}
+
+func (s *testBackupRestoreServer) WatchInitializationStatus(_ *backuprestorepb.WatchInitializationStatusRequest, stream grpc.ServerStreamingServer[backuprestorepb.GetInitializationStatusResponse]) error {
+	for _, st := range s.statuses {
+		if err := stream.Send(&backuprestorepb.GetInitializationStatusResponse{Status: st}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *testBackupRestoreServer) TriggerInitialization(_ context.Context, request *backuprestorepb.TriggerInitializationRequest) (*backuprestorepb.TriggerInitializationResponse, error) {
+	s.triggeredModes = append(s.triggeredModes, request.ValidationMode)
+	return &backuprestorepb.TriggerInitializationResponse{}, nil
+}
+
+func (s *testBackupRestoreServer) GetEtcdConfig(_ context.Context, _ *backuprestorepb.GetEtcdConfigRequest) (*backuprestorepb.GetEtcdConfigResponse, error) {
+	return &backuprestorepb.GetEtcdConfigResponse{Config: s.etcdConfig}, nil
+}
+
+func (s *testBackupRestoreServer) GetLatestSnapshots(_ context.Context, _ *backuprestorepb.GetLatestSnapshotsRequest) (*backuprestorepb.GetLatestSnapshotsResponse, error) {
+	if s.latestSnapshots == nil {
+		return nil, status.Error(codes.NotFound, "no snapshots found")
+	}
+	return s.latestSnapshots, nil
+}
+
+func (s *testBackupRestoreServer) TriggerSnapshot(_ context.Context, request *backuprestorepb.TriggerSnapshotRequest) (*backuprestorepb.TriggerSnapshotResponse, error) {
+	s.triggeredKinds = append(s.triggeredKinds, request.Kind)
+	return &backuprestorepb.TriggerSnapshotResponse{Snapshot: &backuprestorepb.Snapshot{Kind: "Full", LastRevision: 30, CreatedOn: 1700000100}}, nil
+}
+
+func (s *testBackupRestoreServer) ReportChurn(_ context.Context, request *backuprestorepb.ReportChurnRequest) (*backuprestorepb.ReportChurnResponse, error) {
+	s.churnReports = append(s.churnReports, request)
+	return &backuprestorepb.ReportChurnResponse{DeltaSnapshotPeriodSeconds: 30}, nil
+}
+
+func TestGRPCClient(t *testing.T) {
+	g := NewWithT(t)
+	server := &testBackupRestoreServer{
+		statuses:   []string{New.String(), InProgress.String(), Successful.String()},
+		etcdConfig: []byte("name: etcd-test"),
+		latestSnapshots: &backuprestorepb.GetLatestSnapshotsResponse{
+			FullSnapshot:   &backuprestorepb.Snapshot{Kind: "Full", LastRevision: 10, CreatedOn: 1700000000},
+			DeltaSnapshots: []*backuprestorepb.Snapshot{{Kind: "Incr", StartRevision: 11, LastRevision: 25}},
+		},
+	}
+	conn := startTestGRPCServer(t, server)
+	etcdConfigFilePath := filepath.Join(t.TempDir(), "etcd.conf.yaml")
+	client := NewGRPCClient(conn, etcdConfigFilePath)
+	ctx := context.Background()
+
+	t.Log("should fetch initialization status")
+	initStatus, err := client.GetInitializationStatus(ctx)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(initStatus).To(Equal(New))
+
+	t.Log("should stream initialization status till initialization has succeeded")
+	statusCh, err := client.(InitStatusWatcher).WatchInitializationStatus(ctx)
+	g.Expect(err).ToNot(HaveOccurred())
+	var streamed []InitStatus
+	for st := range statusCh {
+		streamed = append(streamed, st)
+	}
+	g.Expect(streamed).To(Equal([]InitStatus{New, InProgress, Successful}))
+
+	t.Log("should trigger initialization with the validation mode")
+	g.Expect(client.TriggerInitialization(ctx, SanityValidation)).To(Succeed())
+	g.Expect(server.triggeredModes).To(Equal([]string{string(SanityValidation)}))
+
+	t.Log("should fetch and write etcd config")
+	path, err := client.GetEtcdConfig(ctx)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(path).To(Equal(etcdConfigFilePath))
+	config, err := os.ReadFile(path)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(config).To(Equal(server.etcdConfig))
+
+	t.Log("should fetch latest snapshots")
+	latestSnapshots, err := client.GetLatestSnapshots(ctx)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(latestSnapshots.FullSnapshot.CreatedOn.Unix()).To(Equal(int64(1700000000)))
+	g.Expect(latestSnapshots.LastRevision()).To(Equal(int64(25)))
+
+	t.Log("should trigger a snapshot and return it")
+	snapshot, err := client.TriggerSnapshot(ctx, FullSnapshotKind)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(server.triggeredKinds).To(Equal([]string{string(FullSnapshotKind)}))
+	g.Expect(snapshot.LastRevision).To(Equal(int64(30)))
+
+	t.Log("should report churn and return the adopted delta snapshot period")
+	period, err := client.ReportChurn(ctx, ChurnReport{Revision: 30, RevisionsPerSecond: 2.5, Window: time.Minute, ObservedAt: time.Unix(1700000200, 0)})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(period).To(Equal(30 * time.Second))
+	g.Expect(server.churnReports).To(HaveLen(1))
+	g.Expect(server.churnReports[0].RevisionsPerSecond).To(Equal(2.5))
+	g.Expect(server.churnReports[0].WindowSeconds).To(Equal(int64(60)))
+	g.Expect(server.churnReports[0].ObservedAt).To(Equal(int64(1700000200)))
+
+	t.Log("should return nil when there are no snapshots")
+	server.latestSnapshots = nil
+	latestSnapshots, err = client.GetLatestSnapshots(ctx)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(latestSnapshots).To(BeNil())
+}
+
+func TestCreateGRPCConn(t *testing.T) {
+	table := []struct {
+		description   string
+		sidecarConfig types.BackupRestoreConfig
+		expectError   bool
+	}{
+		{"should return error when host port is invalid", types.BackupRestoreConfig{HostPort: "localhost"}, true},
+		{"should return error when CA cert bundle cannot be read", types.BackupRestoreConfig{HostPort: ":8080", TLSEnabled: true, CaCertBundlePath: "/does/not/exist"}, true},
+		{"should create connection when TLS is disabled", types.BackupRestoreConfig{HostPort: ":8080"}, false},
+	}
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		conn, err := createGRPCConn(entry.sidecarConfig, false, nil)
+		g.Expect(err != nil).To(Equal(entry.expectError))
+		if conn != nil {
+			g.Expect(conn.Close()).To(Succeed())
+		}
+	}
+}
+
+func startTestGRPCServer(t *testing.T, server backuprestorepb.BackupRestoreServer) *grpc.ClientConn {
+	g := NewWithT(t)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	g.Expect(err).ToNot(HaveOccurred())
+	grpcServer := grpc.NewServer()
+	backuprestorepb.RegisterBackupRestoreServer(grpcServer, server)
+	go func() {
+		_ = grpcServer.Serve(listener)
+	}()
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	g.Expect(err).ToNot(HaveOccurred())
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+	return conn
+}
+
+func TestCreateGRPCConnWithDialer(t *testing.T) {
+	g := NewWithT(t)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	g.Expect(err).ToNot(HaveOccurred())
+	grpcServer := grpc.NewServer()
+	backuprestorepb.RegisterBackupRestoreServer(grpcServer, &testBackupRestoreServer{statuses: []string{Successful.String()}})
+	go func() {
+		_ = grpcServer.Serve(listener)
+	}()
+	t.Cleanup(grpcServer.Stop)
+	var dialed atomic.Int32
+	dialer := util.NewDialer(nil, 0, time.Second)
+	dialer.SetConnWrapper(func(conn net.Conn) net.Conn {
+		dialed.Add(1)
+		return conn
+	})
+
+	t.Log("should dial connections to backup-restore with the passed dialer")
+	conn, err := createGRPCConn(types.BackupRestoreConfig{HostPort: listener.Addr().String()}, true, dialer)
+	g.Expect(err).ToNot(HaveOccurred())
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+	initStatus, err := NewGRPCClient(conn, filepath.Join(t.TempDir(), "etcd.conf.yaml")).GetInitializationStatus(context.Background())
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(initStatus).To(Equal(Successful))
+	g.Expect(dialed.Load()).To(BeNumerically(">", 0))
+}
@@ -0,0 +1,475 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package brclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/types"
+	"github.com/gardener/etcd-wrapper/internal/util"
+)
+
+// brClient implements BackupRestoreClient and EtcdConfigPoller interfaces by talking to the HTTP(S) server of backup-restore.
+type brClient struct {
+	client             *http.Client
+	etcdConfigFilePath string
+	// addressMu guards backupRestoreBaseAddress and brConfig, which change once backup-restore has moved to another
+	// host and port. brConfig is nil unless the client has been created by NewDefaultClient.
+	addressMu                sync.RWMutex
+	backupRestoreBaseAddress string
+	brConfig                 *types.BackupRestoreConfig
+	// etcdConfigMu guards etcdConfigETag and etcdConfigDigest, which identify the etcd configuration last fetched.
+	etcdConfigMu     sync.Mutex
+	etcdConfigETag   string
+	etcdConfigDigest *[sha256.Size]byte
+	// apiVersion is the negotiated API version of backup-restore, zero until it has been negotiated.
+	apiVersion atomic.Int32
+}
+
+// NewClient creates and returns a new BackupRestoreClient object
+func NewClient(httpClient *http.Client, backupRestoreBaseAddress, etcdConfigFilePath string) BackupRestoreClient {
+	return &brClient{
+		client:                   httpClient,
+		backupRestoreBaseAddress: backupRestoreBaseAddress,
+		etcdConfigFilePath:       etcdConfigFilePath,
+	}
+}
+
+// newDefaultClient creates a brClient talking to the backup-restore configured by brConfig, which is able to reload its
+// CA bundle and to move to another host and port of backup-restore.
+func newDefaultClient(brConfig types.BackupRestoreConfig, proxyEnvDisabled bool, dialer *util.Dialer, etcdConfigFilePath string) (*brClient, error) {
+	c := &brClient{
+		etcdConfigFilePath:       etcdConfigFilePath,
+		backupRestoreBaseAddress: brConfig.GetBaseAddress(),
+		brConfig:                 &brConfig,
+	}
+	client, err := createReloadableClient(c.getBRConfig, proxyEnvDisabled, dialer)
+	if err != nil {
+		return nil, err
+	}
+	c.client = client
+	return c, nil
+}
+
+func (c *brClient) baseAddress() string {
+	c.addressMu.RLock()
+	defer c.addressMu.RUnlock()
+	return c.backupRestoreBaseAddress
+}
+
+func (c *brClient) getBRConfig() types.BackupRestoreConfig {
+	c.addressMu.RLock()
+	defer c.addressMu.RUnlock()
+	return *c.brConfig
+}
+
+func (c *brClient) UpdateHostPort(hostPort string) error {
+	c.addressMu.Lock()
+	if c.brConfig == nil {
+		c.addressMu.Unlock()
+		return errors.New("HTTP client of backup-restore does not support updating the host and port")
+	}
+	brConfig := *c.brConfig
+	brConfig.HostPort = hostPort
+	c.brConfig = &brConfig
+	c.backupRestoreBaseAddress = brConfig.GetBaseAddress()
+	c.addressMu.Unlock()
+	// the server name expected in the TLS certificate may be derived from the host, and idle connections to the previous
+	// host are of no use anymore.
+	return c.ReloadCABundle()
+}
+
+// apiVersionsResponse is the response of backup-restore to a query of its API versions.
+type apiVersionsResponse struct {
+	Versions []APIVersion `json:"versions"`
+}
+
+// initializationStatusResponse is the initialization status as returned from backup-restore with APIVersion2.
+type initializationStatusResponse struct {
+	Status string `json:"status"`
+}
+
+// initializationStartRequest is the request to trigger initialization on backup-restore with APIVersion2.
+type initializationStartRequest struct {
+	Mode ValidationType `json:"mode"`
+}
+
+func (c *brClient) NegotiateAPIVersion(ctx context.Context) (APIVersion, error) {
+	response, err := c.createAndExecuteHTTPRequest(ctx, http.MethodGet, c.baseAddress()+"/api/versions")
+	if err != nil {
+		return 0, err
+	}
+	defer util.CloseResponseBody(response)
+
+	var serverVersions []APIVersion
+	switch {
+	case response.StatusCode == http.StatusNotFound || response.StatusCode == http.StatusMethodNotAllowed:
+		// backup-restore predates the negotiation of API versions.
+		serverVersions = []APIVersion{APIVersion1}
+	case !util.ResponseHasOKCode(response):
+		return 0, newResponseError("negotiate API version", response)
+	default:
+		var versions apiVersionsResponse
+		if err = json.NewDecoder(response.Body).Decode(&versions); err != nil {
+			return 0, fmt.Errorf("failed to decode API versions of backup-restore: %w", err)
+		}
+		serverVersions = versions.Versions
+	}
+	version, err := selectAPIVersion(serverVersions)
+	if err != nil {
+		return 0, err
+	}
+	c.apiVersion.Store(int32(version)) // #nosec G115 -- API versions are small positive numbers.
+	return version, nil
+}
+
+// getAPIVersion returns the negotiated API version, APIVersion1 if it has not been negotiated yet.
+func (c *brClient) getAPIVersion() APIVersion {
+	if version := APIVersion(c.apiVersion.Load()); version != 0 {
+		return version
+	}
+	return APIVersion1
+}
+
+func (c *brClient) GetInitializationStatus(ctx context.Context) (InitStatus, error) {
+	response, err := c.createAndExecuteHTTPRequest(ctx, http.MethodGet, c.baseAddress()+"/initialization/status")
+	if err != nil {
+		return Unknown, err
+	}
+	defer util.CloseResponseBody(response)
+
+	if !util.ResponseHasOKCode(response) {
+		return Unknown, newResponseError("get initialization status", response)
+	}
+
+	bodyBytes, err := io.ReadAll(response.Body)
+	if err != nil {
+		return Unknown, err
+	}
+	if c.getAPIVersion() == APIVersion1 {
+		return parseInitStatus(string(bodyBytes)), nil
+	}
+	var status initializationStatusResponse
+	if err = json.Unmarshal(bodyBytes, &status); err != nil {
+		return Unknown, fmt.Errorf("failed to decode initialization status: %w", err)
+	}
+	return parseInitStatus(status.Status), nil
+}
+
+func (c *brClient) TriggerInitialization(ctx context.Context, validationType ValidationType) error {
+	var (
+		response *http.Response
+		err      error
+	)
+	if c.getAPIVersion() == APIVersion1 {
+		url := c.baseAddress() + fmt.Sprintf("/initialization/start?mode=%s", validationType)
+		response, err = c.createAndExecuteHTTPRequest(ctx, http.MethodGet, url)
+	} else {
+		var body []byte
+		if body, err = json.Marshal(initializationStartRequest{Mode: validationType}); err != nil {
+			return err
+		}
+		response, err = c.createAndExecuteHTTPRequestWithBody(ctx, http.MethodPost, c.baseAddress()+"/initialization/start", bytes.NewReader(body))
+	}
+	if err != nil {
+		return err
+	}
+	defer util.CloseResponseBody(response)
+
+	if !util.ResponseHasOKCode(response) {
+		return newResponseError("trigger initialization", response)
+	}
+
+	return nil
+}
+
+func (c *brClient) GetEtcdConfig(ctx context.Context) (string, error) {
+	// TODO (@aaronfern) If and when we directly mount etcd configuration to etcd-wrapper then we need to remove this and also add a command line parameter to take the path to the configuration.
+	if _, err := c.fetchEtcdConfig(ctx, false); err != nil {
+		return "", err
+	}
+	return c.etcdConfigFilePath, nil
+}
+
+func (c *brClient) PollEtcdConfig(ctx context.Context) (bool, error) {
+	return c.fetchEtcdConfig(ctx, true)
+}
+
+// fetchEtcdConfig fetches the etcd configuration, stores it into the etcd configuration file and returns whether it
+// differs from the configuration last fetched. If conditional is set, the ETag of the configuration last fetched is
+// sent as If-None-Match, so that backup-restore does not send an unchanged configuration, and an unchanged
+// configuration is not stored again. Backup-restore not supporting ETags sends the configuration regardless, which is
+// then compared by its digest.
+func (c *brClient) fetchEtcdConfig(ctx context.Context, conditional bool) (bool, error) {
+	c.etcdConfigMu.Lock()
+	defer c.etcdConfigMu.Unlock()
+	if conditional && c.etcdConfigDigest == nil {
+		// the configuration etcd has been started with may have been stored by an earlier run of etcd-wrapper.
+		if etcdConfigBytes, err := os.ReadFile(c.etcdConfigFilePath); err == nil {
+			digest := sha256.Sum256(etcdConfigBytes)
+			c.etcdConfigDigest = &digest
+		}
+	}
+	header := http.Header{}
+	if conditional && c.etcdConfigETag != "" {
+		header.Set("If-None-Match", c.etcdConfigETag)
+	}
+	response, err := c.createAndExecuteHTTPRequestWithHeader(ctx, http.MethodGet, c.baseAddress()+"/config", nil, header)
+	if err != nil {
+		return false, err
+	}
+	defer util.CloseResponseBody(response)
+
+	if response.StatusCode == http.StatusNotModified {
+		return false, nil
+	}
+	if !util.ResponseHasOKCode(response) {
+		return false, newResponseError("fetch etcd config", response)
+	}
+
+	etcdConfigBytes, err := io.ReadAll(response.Body)
+	if err != nil {
+		return false, err
+	}
+	digest := sha256.Sum256(etcdConfigBytes)
+	changed := c.etcdConfigDigest == nil || *c.etcdConfigDigest != digest
+	if conditional && !changed {
+		c.etcdConfigETag = response.Header.Get("ETag")
+		return false, nil
+	}
+	if err = os.WriteFile(c.etcdConfigFilePath, etcdConfigBytes, 0600); err != nil {
+		return false, err
+	}
+	c.etcdConfigETag = response.Header.Get("ETag")
+	c.etcdConfigDigest = &digest
+	return changed, nil
+}
+
+func (c *brClient) GetLatestSnapshots(ctx context.Context) (*LatestSnapshots, error) {
+	response, err := c.createAndExecuteHTTPRequest(ctx, http.MethodGet, c.baseAddress()+"/snapshot/latest")
+	if err != nil {
+		return nil, err
+	}
+	defer util.CloseResponseBody(response)
+
+	if response.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if !util.ResponseHasOKCode(response) {
+		return nil, newResponseError("fetch latest snapshots", response)
+	}
+
+	latestSnapshots := &LatestSnapshots{}
+	if err = json.NewDecoder(response.Body).Decode(latestSnapshots); err != nil {
+		return nil, fmt.Errorf("failed to decode latest snapshots: %w", err)
+	}
+	if latestSnapshots.FullSnapshot == nil && len(latestSnapshots.DeltaSnapshots) == 0 {
+		return nil, nil
+	}
+	return latestSnapshots, nil
+}
+
+func (c *brClient) TriggerSnapshot(ctx context.Context, kind SnapshotKind) (*Snapshot, error) {
+	response, err := c.createAndExecuteHTTPRequest(ctx, http.MethodGet, c.baseAddress()+"/snapshot/"+string(kind))
+	if err != nil {
+		return nil, err
+	}
+	defer util.CloseResponseBody(response)
+
+	if !util.ResponseHasOKCode(response) {
+		return nil, newResponseError("trigger "+string(kind)+" snapshot", response)
+	}
+
+	var snapshot *Snapshot
+	if err = json.NewDecoder(response.Body).Decode(&snapshot); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to decode %s snapshot: %w", kind, err)
+	}
+	return snapshot, nil
+}
+
+func (c *brClient) ReportChurn(ctx context.Context, report ChurnReport) (time.Duration, error) {
+	body, err := json.Marshal(churnReportRequest{ChurnReport: report, WindowSeconds: int64(report.Window.Seconds())})
+	if err != nil {
+		return 0, err
+	}
+	response, err := c.createAndExecuteHTTPRequestWithBody(ctx, http.MethodPost, c.baseAddress()+"/snapshot/churn", bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	defer util.CloseResponseBody(response)
+
+	if response.StatusCode == http.StatusNotFound || response.StatusCode == http.StatusMethodNotAllowed {
+		return 0, ErrNotSupported
+	}
+	if !util.ResponseHasOKCode(response) {
+		return 0, newResponseError("report churn", response)
+	}
+
+	churnResponse := churnReportResponse{}
+	if err = json.NewDecoder(response.Body).Decode(&churnResponse); err != nil && err != io.EOF {
+		return 0, fmt.Errorf("failed to decode churn report response: %w", err)
+	}
+	return time.Duration(churnResponse.DeltaSnapshotPeriodSeconds) * time.Second, nil
+}
+
+// churnReportRequest is the body of a churn report sent to backup-restore.
+type churnReportRequest struct {
+	ChurnReport
+	WindowSeconds int64 `json:"windowSeconds"`
+}
+
+// churnReportResponse is the body of the response of backup-restore to a churn report.
+type churnReportResponse struct {
+	DeltaSnapshotPeriodSeconds int64 `json:"deltaSnapshotPeriodSeconds"`
+}
+
+func (c *brClient) ReportQuotaAdvisory(ctx context.Context, advisory QuotaAdvisory) error {
+	body, err := json.Marshal(advisory)
+	if err != nil {
+		return err
+	}
+	response, err := c.createAndExecuteHTTPRequestWithBody(ctx, http.MethodPost, c.baseAddress()+"/quota/advisory", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer util.CloseResponseBody(response)
+
+	if response.StatusCode == http.StatusNotFound || response.StatusCode == http.StatusMethodNotAllowed {
+		return ErrNotSupported
+	}
+	if !util.ResponseHasOKCode(response) {
+		return newResponseError("report quota advisory", response)
+	}
+	return nil
+}
+
+func (c *brClient) createAndExecuteHTTPRequest(ctx context.Context, method, url string) (*http.Response, error) {
+	return c.createAndExecuteHTTPRequestWithBody(ctx, method, url, nil)
+}
+
+func (c *brClient) createAndExecuteHTTPRequestWithBody(ctx context.Context, method, url string, body io.Reader) (*http.Response, error) {
+	return c.createAndExecuteHTTPRequestWithHeader(ctx, method, url, body, nil)
+}
+
+func (c *brClient) createAndExecuteHTTPRequestWithHeader(ctx context.Context, method, url string, body io.Reader, header http.Header) (*http.Response, error) {
+	// create cancellable child context for http request
+	httpCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// create new request
+	req, err := http.NewRequestWithContext(httpCtx, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	// send http request
+	response, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func createClient(brConfig types.BackupRestoreConfig, proxyEnvDisabled bool, dialer *util.Dialer) (*http.Client, error) {
+	return createReloadableClient(func() types.BackupRestoreConfig { return brConfig }, proxyEnvDisabled, dialer)
+}
+
+// createReloadableClient creates an http.Client whose transport is built from the configuration returned by brConfig,
+// again whenever it is reloaded.
+func createReloadableClient(brConfig func() types.BackupRestoreConfig, proxyEnvDisabled bool, dialer *util.Dialer) (*http.Client, error) {
+	transport, err := newReloadableTransport(func() (*http.Transport, error) {
+		return createTransport(brConfig(), proxyEnvDisabled, dialer)
+	})
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{
+		Transport: transport,
+		Timeout:   httpClientRequestTimeout,
+	}
+	return client, nil
+}
+
+func createTransport(brConfig types.BackupRestoreConfig, proxyEnvDisabled bool, dialer *util.Dialer) (*http.Transport, error) {
+	tlsConfig, err := util.CreateTLSConfig(func() bool { return brConfig.TLSEnabled }, brConfig.GetServerName(), brConfig.CaCertBundlePath, nil)
+	if err != nil {
+		return nil, err
+	}
+	transport := &http.Transport{
+		TLSClientConfig: tlsConfig,
+		Proxy:           util.ProxyFunc(proxyEnvDisabled),
+	}
+	if dialer != nil {
+		transport.DialContext = dialer.DialContext
+	}
+	return transport, nil
+}
+
+func (c *brClient) ReloadCABundle() error {
+	transport, ok := c.client.Transport.(*reloadableTransport)
+	if !ok {
+		return errors.New("HTTP client of backup-restore does not support reloading the CA bundle")
+	}
+	return transport.reload()
+}
+
+func (c *brClient) CloseIdleConnections() {
+	c.client.CloseIdleConnections()
+}
+
+// reloadableTransport is an http.RoundTripper which sends requests via a transport that can be rebuilt, e.g. with the
+// rotated CA bundle, without recreating the http.Client. Requests in flight complete on the previous transport.
+type reloadableTransport struct {
+	build   func() (*http.Transport, error)
+	current atomic.Pointer[http.Transport]
+}
+
+func newReloadableTransport(build func() (*http.Transport, error)) (*reloadableTransport, error) {
+	t := &reloadableTransport{build: build}
+	transport, err := build()
+	if err != nil {
+		return nil, err
+	}
+	t.current.Store(transport)
+	return t, nil
+}
+
+func (t *reloadableTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.current.Load().RoundTrip(req)
+}
+
+// CloseIdleConnections closes the idle connections of the current transport.
+func (t *reloadableTransport) CloseIdleConnections() {
+	t.current.Load().CloseIdleConnections()
+}
+
+// reload rebuilds the transport and closes the idle connections of the previous one. The previous transport is kept if
+// the transport cannot be rebuilt.
+func (t *reloadableTransport) reload() error {
+	transport, err := t.build()
+	if err != nil {
+		return err
+	}
+	t.current.Swap(transport).CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,159 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package clienttraffic tracks the open connections and the RPC rate per client of gRPC servers of etcd, to locate
+// clients which put an unexpected load onto etcd, e.g. misbehaving control-plane components.
+package clienttraffic
+
+import (
+	"cmp"
+	"context"
+	"net"
+	"slices"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/stats"
+)
+
+// Client describes the traffic of a client, identified by its host, as of the last sample.
+type Client struct {
+	// Address is the host of the client. Connections from different ports of the same host are aggregated.
+	Address string `json:"address"`
+	// OpenConnections is the number of connections of the client which are currently open.
+	OpenConnections int `json:"openConnections"`
+	// TotalRPCs is the number of RPCs started by the client since it has first been seen.
+	TotalRPCs uint64 `json:"totalRPCs"`
+	// RPCRate is the number of RPCs per second started by the client between the last two samples.
+	RPCRate float64 `json:"rpcRate"`
+}
+
+// Tracker is a stats.Handler which counts the open connections and the RPCs per client of the gRPC servers it is
+// registered with. The RPC rate of every client is computed by Sample. It is safe for concurrent use.
+type Tracker struct {
+	mu        sync.Mutex
+	counters  map[string]*counter
+	clients   []Client
+	sampledAt time.Time
+}
+
+// counter holds the running counts of a client.
+type counter struct {
+	openConnections int
+	totalRPCs       uint64
+	sampledRPCs     uint64
+}
+
+// clientKey is the context key under which the address of the client of a connection is stored.
+type clientKey struct{}
+
+// NewTracker creates a Tracker which has seen no client.
+func NewTracker() *Tracker {
+	return &Tracker{counters: map[string]*counter{}}
+}
+
+// TagConn stores the address of the client of the connection in the context of the connection.
+func (t *Tracker) TagConn(ctx context.Context, info *stats.ConnTagInfo) context.Context {
+	return context.WithValue(ctx, clientKey{}, clientAddress(info.RemoteAddr))
+}
+
+// HandleConn counts the connection as open from its begin until its end.
+func (t *Tracker) HandleConn(ctx context.Context, s stats.ConnStats) {
+	address, ok := ctx.Value(clientKey{}).(string)
+	if !ok {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	switch s.(type) {
+	case *stats.ConnBegin:
+		t.counterOf(address).openConnections++
+	case *stats.ConnEnd:
+		t.counterOf(address).openConnections--
+	}
+}
+
+// TagRPC returns ctx unchanged, as the client of an RPC is taken from the context of its connection.
+func (t *Tracker) TagRPC(ctx context.Context, _ *stats.RPCTagInfo) context.Context {
+	return ctx
+}
+
+// HandleRPC counts every RPC once it has begun.
+func (t *Tracker) HandleRPC(ctx context.Context, s stats.RPCStats) {
+	if _, ok := s.(*stats.Begin); !ok {
+		return
+	}
+	address, ok := ctx.Value(clientKey{}).(string)
+	if !ok {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.counterOf(address).totalRPCs++
+}
+
+// Sample computes the RPC rate of every client since the previous sample. Clients without open connections and
+// without RPCs since the previous sample are forgotten.
+func (t *Tracker) Sample(now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	elapsed := now.Sub(t.sampledAt).Seconds()
+	clients := make([]Client, 0, len(t.counters))
+	for address, c := range t.counters {
+		client := Client{Address: address, OpenConnections: c.openConnections, TotalRPCs: c.totalRPCs}
+		if !t.sampledAt.IsZero() && elapsed > 0 {
+			client.RPCRate = float64(c.totalRPCs-c.sampledRPCs) / elapsed
+		}
+		if c.openConnections <= 0 && c.totalRPCs == c.sampledRPCs && !t.sampledAt.IsZero() {
+			delete(t.counters, address)
+			continue
+		}
+		c.sampledRPCs = c.totalRPCs
+		clients = append(clients, client)
+	}
+	t.clients = clients
+	t.sampledAt = now
+}
+
+// Top returns at most n clients of the last sample ordered by descending RPC rate, and the time of the last sample.
+func (t *Tracker) Top(n int) ([]Client, time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	clients := slices.Clone(t.clients)
+	slices.SortFunc(clients, func(a, b Client) int {
+		if c := cmp.Compare(b.RPCRate, a.RPCRate); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(b.OpenConnections, a.OpenConnections); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Address, b.Address)
+	})
+	if n >= 0 && len(clients) > n {
+		clients = clients[:n]
+	}
+	return clients, t.sampledAt
+}
+
+// counterOf returns the counter of the client, creating it if needed. It must be called with mu held.
+func (t *Tracker) counterOf(address string) *counter {
+	c, ok := t.counters[address]
+	if !ok {
+		c = &counter{}
+		t.counters[address] = c
+	}
+	return c
+}
+
+// clientAddress returns the host of addr, or addr as a whole if it has no port, e.g. for unix sockets.
+func clientAddress(addr net.Addr) string {
+	if addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String()
+	}
+	return host
+}
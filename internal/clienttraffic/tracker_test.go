@@ -0,0 +1,93 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package clienttraffic
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc/stats"
+)
+
+func TestClientAddress(t *testing.T) {
+	table := []struct {
+		description     string
+		addr            net.Addr
+		expectedAddress string
+	}{
+		{"should strip the port of TCP addresses", &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 4711}, "10.0.0.1"},
+		{"should strip the port of IPv6 addresses", &net.TCPAddr{IP: net.ParseIP("fd00::1"), Port: 4711}, "fd00::1"},
+		{"should keep addresses without port", &net.UnixAddr{Name: "@", Net: "unix"}, "@"},
+		{"should handle missing addresses", nil, ""},
+	}
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		g.Expect(clientAddress(entry.addr)).To(Equal(entry.expectedAddress))
+	}
+}
+
+func TestTracker(t *testing.T) {
+	g := NewWithT(t)
+	tracker := NewTracker()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	apiServer := openConn(tracker, "10.0.0.1", 4711)
+	apiServer2 := openConn(tracker, "10.0.0.1", 4712)
+	controller := openConn(tracker, "10.0.0.2", 4711)
+
+	t.Log("should report open connections but no rate on the first sample")
+	startRPCs(apiServer, tracker, 3)
+	tracker.Sample(start)
+	clients, sampledAt := tracker.Top(10)
+	g.Expect(sampledAt).To(Equal(start))
+	g.Expect(clients).To(Equal([]Client{
+		{Address: "10.0.0.1", OpenConnections: 2, TotalRPCs: 3},
+		{Address: "10.0.0.2", OpenConnections: 1},
+	}))
+
+	t.Log("should order clients by their RPC rate since the previous sample")
+	startRPCs(apiServer2, tracker, 10)
+	startRPCs(controller, tracker, 40)
+	tracker.Sample(start.Add(10 * time.Second))
+	clients, _ = tracker.Top(10)
+	g.Expect(clients).To(Equal([]Client{
+		{Address: "10.0.0.2", OpenConnections: 1, TotalRPCs: 40, RPCRate: 4},
+		{Address: "10.0.0.1", OpenConnections: 2, TotalRPCs: 13, RPCRate: 1},
+	}))
+
+	t.Log("should limit the number of clients")
+	clients, _ = tracker.Top(1)
+	g.Expect(clients).To(HaveLen(1))
+	g.Expect(clients[0].Address).To(Equal("10.0.0.2"))
+
+	t.Log("should forget clients without open connections once they are idle")
+	startRPCs(controller, tracker, 5)
+	tracker.HandleConn(controller, &stats.ConnEnd{})
+	tracker.Sample(start.Add(15 * time.Second))
+	clients, _ = tracker.Top(10)
+	g.Expect(clients).To(ContainElement(Client{Address: "10.0.0.2", TotalRPCs: 45, RPCRate: 1}))
+	tracker.Sample(start.Add(20 * time.Second))
+	clients, _ = tracker.Top(10)
+	g.Expect(clients).To(Equal([]Client{{Address: "10.0.0.1", OpenConnections: 2, TotalRPCs: 13}}))
+}
+
+// openConn tags and begins a connection from the given host and port and returns its context.
+func openConn(tracker *Tracker, host string, port int) context.Context {
+	ctx := tracker.TagConn(context.Background(), &stats.ConnTagInfo{RemoteAddr: &net.TCPAddr{IP: net.ParseIP(host), Port: port}})
+	tracker.HandleConn(ctx, &stats.ConnBegin{})
+	return ctx
+}
+
+// startRPCs begins and ends n RPCs on the connection with the given context.
+func startRPCs(ctx context.Context, tracker *Tracker, n int) {
+	for range n {
+		rpcCtx := tracker.TagRPC(ctx, &stats.RPCTagInfo{FullMethodName: "/etcdserverpb.KV/Range"})
+		tracker.HandleRPC(rpcCtx, &stats.Begin{})
+		tracker.HandleRPC(rpcCtx, &stats.End{})
+	}
+}
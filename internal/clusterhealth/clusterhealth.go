@@ -0,0 +1,267 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package clusterhealth summarizes the health of all members of an etcd cluster.
+package clusterhealth
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/types"
+
+	"go.etcd.io/etcd/clientv3"
+	"go.etcd.io/etcd/etcdserver/api/v3rpc/rpctypes"
+	"go.etcd.io/etcd/etcdserver/etcdserverpb"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+// healthKey is the key read to check whether a member serves linearizable requests, like etcdctl endpoint health does.
+const healthKey = "health"
+
+// Config is the configuration of the clients used to check the health of the cluster.
+type Config struct {
+	// Endpoints are the client URLs of the members from which the membership of the cluster is read.
+	Endpoints []string
+	// TLS is the TLS configuration of the clients, nil if TLS is disabled.
+	TLS *tls.Config
+	// Username and Password are the credentials of the clients if auth is enabled in etcd.
+	Username string
+	Password string
+	// Timeout is the time after which a request to a single member fails.
+	Timeout time.Duration
+	// DialOptions are additional dial options of the clients.
+	DialOptions []grpc.DialOption
+	// LogConfig is the configuration of the loggers of the clients.
+	LogConfig *zap.Config
+}
+
+// MemberHealth is the health of a single member.
+type MemberHealth struct {
+	// ID is the hexadecimal ID of the member.
+	ID string `json:"id"`
+	// Name is the name of the member, empty if it has not been started yet.
+	Name string `json:"name"`
+	// Endpoint is the client URL through which the member has been checked.
+	Endpoint string `json:"endpoint,omitempty"`
+	// Learner is true if the member is a raft learner, which does not count towards quorum.
+	Learner bool `json:"learner"`
+	// Healthy is true if the member serves linearizable requests.
+	Healthy bool `json:"healthy"`
+	// Leader is the hexadecimal ID of the leader as seen by the member, empty if it sees no leader.
+	Leader string `json:"leader,omitempty"`
+	// Version is the version of etcd run by the member.
+	Version string `json:"version,omitempty"`
+	// DBSize is the physically allocated size of the DB of the member in bytes.
+	DBSize int64 `json:"dbSize,omitempty"`
+	// RaftTerm and RaftIndex are the current raft term and index of the member.
+	RaftTerm  uint64 `json:"raftTerm,omitempty"`
+	RaftIndex uint64 `json:"raftIndex,omitempty"`
+	// Error is the reason why the member is not healthy.
+	Error string `json:"error,omitempty"`
+}
+
+// Alarm is an alarm raised in the cluster.
+type Alarm struct {
+	// MemberID is the hexadecimal ID of the member for which the alarm has been raised.
+	MemberID string `json:"memberID"`
+	// Type is the type of the alarm, e.g. NOSPACE or CORRUPT.
+	Type string `json:"type"`
+}
+
+// Summary is the aggregated health of all members of the cluster.
+type Summary struct {
+	// Members is the health of every member of the cluster, in the order of the membership.
+	Members []MemberHealth `json:"members"`
+	// Alarms are the alarms raised in the cluster. Nil if they could not be listed.
+	Alarms []Alarm `json:"alarms"`
+	// AlarmsError is the reason why the alarms could not be listed.
+	AlarmsError string `json:"alarmsError,omitempty"`
+	// Voters is the number of voting members, HealthyVoters the number of healthy voting members.
+	Voters        int `json:"voters"`
+	HealthyVoters int `json:"healthyVoters"`
+	// Quorum is the number of voting members required for quorum.
+	Quorum int `json:"quorum"`
+	// FaultTolerance is the number of healthy voting members which can fail before quorum is lost. It is negative if
+	// quorum has been lost.
+	FaultTolerance int `json:"faultTolerance"`
+	// Risks are the reasons why quorum is at risk.
+	Risks []string `json:"risks,omitempty"`
+}
+
+// QuorumAtRisk returns true if quorum has been lost or is at risk.
+func (s *Summary) QuorumAtRisk() bool {
+	return len(s.Risks) > 0
+}
+
+// QuorumAtRiskError is returned if quorum has been lost or is at risk.
+type QuorumAtRiskError struct {
+	// Risks are the reasons why quorum is at risk.
+	Risks []string
+}
+
+func (e *QuorumAtRiskError) Error() string {
+	return fmt.Sprintf("quorum of the etcd cluster is at risk: %s", strings.Join(e.Risks, "; "))
+}
+
+// ExitCode returns the exit code with which the cluster-health command exits if quorum is at risk.
+func (e *QuorumAtRiskError) ExitCode() int {
+	return types.ExitCodeQuorumAtRisk
+}
+
+// Check reads the membership of the cluster from the configured endpoints and checks the health of every member
+// through its own client URLs. An error is only returned if the membership cannot be read.
+func Check(ctx context.Context, config Config) (*Summary, error) {
+	client, err := newClient(ctx, config, config.Endpoints)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = client.Close()
+	}()
+	listCtx, cancel := context.WithTimeout(ctx, config.Timeout)
+	members, err := client.MemberList(listCtx)
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list members of the etcd cluster: %w", err)
+	}
+
+	summary := &Summary{Members: make([]MemberHealth, len(members.Members))}
+	var wg sync.WaitGroup
+	for i, member := range members.Members {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			summary.Members[i] = checkMember(ctx, config, member)
+		}()
+	}
+	alarmsCtx, cancel := context.WithTimeout(ctx, config.Timeout)
+	alarms, err := client.AlarmList(alarmsCtx)
+	cancel()
+	if err != nil {
+		summary.AlarmsError = err.Error()
+	} else {
+		summary.Alarms = make([]Alarm, 0, len(alarms.Alarms))
+		for _, alarm := range alarms.Alarms {
+			summary.Alarms = append(summary.Alarms, Alarm{MemberID: fmt.Sprintf("%x", alarm.MemberID), Type: alarm.Alarm.String()})
+		}
+	}
+	wg.Wait()
+	assess(summary)
+	return summary, nil
+}
+
+// checkMember checks the health of a single member through the first of its client URLs which responds.
+func checkMember(ctx context.Context, config Config, member *etcdserverpb.Member) MemberHealth {
+	health := MemberHealth{ID: fmt.Sprintf("%x", member.ID), Name: member.Name, Learner: member.IsLearner}
+	if len(member.ClientURLs) == 0 {
+		health.Error = "member has not been started yet"
+		return health
+	}
+	client, err := newClient(ctx, config, member.ClientURLs)
+	if err != nil {
+		health.Error = err.Error()
+		return health
+	}
+	defer func() {
+		_ = client.Close()
+	}()
+	var errs error
+	for _, endpoint := range member.ClientURLs {
+		statusCtx, cancel := context.WithTimeout(ctx, config.Timeout)
+		status, err := client.Status(statusCtx, endpoint)
+		cancel()
+		if err != nil {
+			errs = errors.Join(errs, fmt.Errorf("%s: %w", endpoint, err))
+			continue
+		}
+		health.Endpoint = endpoint
+		health.Version = status.Version
+		health.DBSize = status.DbSize
+		health.RaftTerm = status.RaftTerm
+		health.RaftIndex = status.RaftIndex
+		if status.Leader != 0 {
+			health.Leader = fmt.Sprintf("%x", status.Leader)
+		}
+		if len(status.Errors) > 0 {
+			health.Error = strings.Join(status.Errors, "; ")
+			return health
+		}
+		break
+	}
+	if health.Endpoint == "" {
+		health.Error = errs.Error()
+		return health
+	}
+	// the member is healthy if it serves linearizable reads, which requires a leader and quorum. Learners only serve
+	// serializable reads.
+	var opts []clientv3.OpOption
+	if member.IsLearner {
+		opts = append(opts, clientv3.WithSerializable())
+	}
+	getCtx, cancel := context.WithTimeout(clientv3.WithRequireLeader(ctx), config.Timeout)
+	_, err = clientv3.NewKV(client).Get(getCtx, healthKey, opts...)
+	cancel()
+	// a permission denied response proves that the member serves requests.
+	if err != nil && !errors.Is(err, rpctypes.ErrPermissionDenied) {
+		health.Error = err.Error()
+		return health
+	}
+	health.Healthy = true
+	return health
+}
+
+// assess computes the quorum of the cluster from the health of its members and records the risks to it.
+func assess(summary *Summary) {
+	leaders := make(map[string]struct{})
+	for _, member := range summary.Members {
+		if member.Learner {
+			continue
+		}
+		summary.Voters++
+		if member.Healthy {
+			summary.HealthyVoters++
+			if member.Leader != "" {
+				leaders[member.Leader] = struct{}{}
+			}
+		}
+	}
+	summary.Quorum = summary.Voters/2 + 1
+	summary.FaultTolerance = summary.HealthyVoters - summary.Quorum
+
+	switch {
+	case summary.FaultTolerance < 0:
+		summary.Risks = append(summary.Risks, fmt.Sprintf("quorum is lost, %d of %d voting members are healthy but %d are required", summary.HealthyVoters, summary.Voters, summary.Quorum))
+	case summary.FaultTolerance == 0 && summary.Voters > 1:
+		summary.Risks = append(summary.Risks, fmt.Sprintf("quorum is lost if another voting member fails, %d of %d voting members are healthy", summary.HealthyVoters, summary.Voters))
+	}
+	if summary.HealthyVoters > 0 && len(leaders) == 0 {
+		summary.Risks = append(summary.Risks, "no healthy member sees a leader")
+	}
+	if len(leaders) > 1 {
+		summary.Risks = append(summary.Risks, fmt.Sprintf("healthy members see %d different leaders", len(leaders)))
+	}
+	for _, alarm := range summary.Alarms {
+		summary.Risks = append(summary.Risks, fmt.Sprintf("alarm %s is raised for member %s", alarm.Type, alarm.MemberID))
+	}
+}
+
+func newClient(ctx context.Context, config Config, endpoints []string) (*clientv3.Client, error) {
+	return clientv3.New(clientv3.Config{
+		Context:     ctx,
+		Endpoints:   endpoints,
+		DialTimeout: config.Timeout,
+		TLS:         config.TLS,
+		Username:    config.Username,
+		Password:    config.Password,
+		DialOptions: config.DialOptions,
+		LogConfig:   config.LogConfig,
+	})
+}
@@ -0,0 +1,120 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package clusterhealth
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"go.etcd.io/etcd/clientv3"
+	"go.etcd.io/etcd/embed"
+)
+
+func TestAssess(t *testing.T) {
+	healthy := func(leader string) MemberHealth { return MemberHealth{Healthy: true, Leader: leader} }
+	unhealthy := MemberHealth{Error: "context deadline exceeded"}
+	learner := MemberHealth{Learner: true}
+
+	table := []struct {
+		description            string
+		members                []MemberHealth
+		alarms                 []Alarm
+		expectedQuorum         int
+		expectedFaultTolerance int
+		expectedRisks          int
+	}{
+		{"should not report risks for a healthy single member", []MemberHealth{healthy("1")}, nil, 1, 0, 0},
+		{"should not report risks for a healthy cluster", []MemberHealth{healthy("1"), healthy("1"), healthy("1")}, nil, 2, 1, 0},
+		{"should not count learners towards quorum", []MemberHealth{healthy("1"), healthy("1"), healthy("1"), learner}, nil, 2, 1, 0},
+		{"should report a cluster which cannot tolerate another failure", []MemberHealth{healthy("1"), healthy("1"), unhealthy}, nil, 2, 0, 1},
+		{"should report a lost quorum", []MemberHealth{healthy("1"), unhealthy, unhealthy}, nil, 2, -1, 1},
+		{"should report members without a leader", []MemberHealth{healthy(""), healthy(""), healthy("")}, nil, 2, 1, 1},
+		{"should report members seeing different leaders", []MemberHealth{healthy("1"), healthy("2"), healthy("1")}, nil, 2, 1, 1},
+		{"should report alarms", []MemberHealth{healthy("1")}, []Alarm{{MemberID: "1", Type: "NOSPACE"}}, 1, 0, 1},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		summary := &Summary{Members: entry.members, Alarms: entry.alarms}
+		assess(summary)
+		g.Expect(summary.Quorum).To(Equal(entry.expectedQuorum))
+		g.Expect(summary.FaultTolerance).To(Equal(entry.expectedFaultTolerance))
+		g.Expect(summary.Risks).To(HaveLen(entry.expectedRisks))
+		g.Expect(summary.QuorumAtRisk()).To(Equal(entry.expectedRisks > 0))
+	}
+}
+
+func TestCheck(t *testing.T) {
+	g := NewWithT(t)
+	etcd := startTestEtcd(t, g)
+	endpoint := etcd.Config().AdvertiseClientUrls[0].String()
+	config := Config{Endpoints: []string{endpoint}, Timeout: 2 * time.Second}
+
+	t.Log("should report a healthy single member")
+	summary, err := Check(context.Background(), config)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(summary.Members).To(HaveLen(1))
+	g.Expect(summary.Members[0].Healthy).To(BeTrue())
+	g.Expect(summary.Members[0].Endpoint).To(Equal(endpoint))
+	g.Expect(summary.Members[0].Leader).To(Equal(summary.Members[0].ID))
+	g.Expect(summary.Alarms).To(BeEmpty())
+	g.Expect(summary.QuorumAtRisk()).To(BeFalse())
+
+	t.Log("should report a lost quorum if a voting member has not been started")
+	client, err := clientv3.New(clientv3.Config{Endpoints: []string{endpoint}, DialTimeout: time.Second})
+	g.Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		_ = client.Close()
+	}()
+	_, err = client.MemberAdd(context.Background(), []string{"http://" + freeLocalAddress(g)})
+	g.Expect(err).ToNot(HaveOccurred())
+	summary, err = Check(context.Background(), config)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(summary.Members).To(HaveLen(2))
+	g.Expect(summary.Voters).To(Equal(2))
+	g.Expect(summary.Members[1].Healthy).To(BeFalse())
+	g.Expect(summary.QuorumAtRisk()).To(BeTrue())
+
+	t.Log("should fail if the membership cannot be read")
+	_, err = Check(context.Background(), Config{Endpoints: []string{"http://" + freeLocalAddress(g)}, Timeout: 500 * time.Millisecond})
+	g.Expect(err).To(HaveOccurred())
+}
+
+func startTestEtcd(t *testing.T, g *WithT) *embed.Etcd {
+	cfg := embed.NewConfig()
+	cfg.Dir = t.TempDir()
+	cfg.Logger = "zap"
+	cfg.LogOutputs = []string{"/dev/null"}
+	// allows adding a member which is never started to lose quorum.
+	cfg.StrictReconfigCheck = false
+	clientURL := url.URL{Scheme: "http", Host: freeLocalAddress(g)}
+	peerURL := url.URL{Scheme: "http", Host: freeLocalAddress(g)}
+	cfg.ListenClientUrls, cfg.AdvertiseClientUrls = []url.URL{clientURL}, []url.URL{clientURL}
+	cfg.ListenPeerUrls, cfg.AdvertisePeerUrls = []url.URL{peerURL}, []url.URL{peerURL}
+	cfg.InitialCluster = cfg.InitialClusterFromName(cfg.Name)
+	etcd, err := embed.StartEtcd(cfg)
+	g.Expect(err).ToNot(HaveOccurred())
+	t.Cleanup(etcd.Close)
+	select {
+	case <-etcd.Server.ReadyNotify():
+	case <-time.After(time.Minute):
+		t.Fatal("timed out waiting for etcd to be ready")
+	}
+	return etcd
+}
+
+func freeLocalAddress(g *WithT) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		g.Expect(listener.Close()).To(Succeed())
+	}()
+	return listener.Addr().String()
+}
@@ -0,0 +1,75 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cpulimit
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	// cgroupV2CPUMax is the file containing the CPU quota and period in the unified (v2) cgroup hierarchy.
+	cgroupV2CPUMax = "cpu.max"
+	// cgroupV1CPUQuota is the file containing the CPU quota in the v1 cgroup hierarchy.
+	cgroupV1CPUQuota = "cpu/cpu.cfs_quota_us"
+	// cgroupV1CPUPeriod is the file containing the CPU period in the v1 cgroup hierarchy.
+	cgroupV1CPUPeriod = "cpu/cpu.cfs_period_us"
+	// cgroupV2Unlimited is the quota in cgroupV2CPUMax if no CPU limit is set.
+	cgroupV2Unlimited = "max"
+)
+
+// ContainerCPULimit returns the CPU limit of the container in CPUs, i.e. the CPU quota divided by the CPU period, as
+// configured in the cgroup v2 or v1 hierarchy mounted at cgroupRoot. It returns false if no CPU limit is configured or
+// no cgroup hierarchy is found.
+func ContainerCPULimit(cgroupRoot string) (float64, bool, error) {
+	content, err := os.ReadFile(filepath.Join(cgroupRoot, cgroupV2CPUMax))
+	if err == nil {
+		fields := strings.Fields(string(content))
+		if len(fields) != 2 {
+			return 0, false, fmt.Errorf("failed to parse CPU limit %q from %s", strings.TrimSpace(string(content)), cgroupV2CPUMax)
+		}
+		if fields[0] == cgroupV2Unlimited {
+			return 0, false, nil
+		}
+		return parseLimit(cgroupV2CPUMax, fields[0], fields[1])
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return 0, false, err
+	}
+
+	quota, err := os.ReadFile(filepath.Join(cgroupRoot, cgroupV1CPUQuota))
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	period, err := os.ReadFile(filepath.Join(cgroupRoot, cgroupV1CPUPeriod))
+	if err != nil {
+		return 0, false, err
+	}
+	return parseLimit(cgroupV1CPUQuota, strings.TrimSpace(string(quota)), strings.TrimSpace(string(period)))
+}
+
+// parseLimit parses the CPU limit from quota and period. A negative quota, which cgroup v1 reports if no CPU limit is
+// set, means no limit.
+func parseLimit(file, quota, period string) (float64, bool, error) {
+	quotaValue, err := strconv.ParseInt(quota, 10, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to parse CPU quota %q from %s: %w", quota, file, err)
+	}
+	periodValue, err := strconv.ParseInt(period, 10, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to parse CPU period %q from %s: %w", period, file, err)
+	}
+	if quotaValue <= 0 || periodValue <= 0 {
+		return 0, false, nil
+	}
+	return float64(quotaValue) / float64(periodValue), true, nil
+}
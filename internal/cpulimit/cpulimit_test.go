@@ -0,0 +1,48 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cpulimit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestContainerCPULimit(t *testing.T) {
+	table := []struct {
+		description   string
+		files         map[string]string
+		expectedLimit float64
+		expectedOK    bool
+		expectError   bool
+	}{
+		{"should return no limit when there is no cgroup hierarchy", nil, 0, false, false},
+		{"should read cgroup v2 CPU limit", map[string]string{cgroupV2CPUMax: "150000 100000\n"}, 1.5, true, false},
+		{"should return no limit when cgroup v2 CPU quota is max", map[string]string{cgroupV2CPUMax: "max 100000\n"}, 0, false, false},
+		{"should read cgroup v1 CPU limit", map[string]string{cgroupV1CPUQuota: "50000\n", cgroupV1CPUPeriod: "100000\n"}, 0.5, true, false},
+		{"should return no limit when cgroup v1 CPU quota is unset", map[string]string{cgroupV1CPUQuota: "-1\n", cgroupV1CPUPeriod: "100000\n"}, 0, false, false},
+		{"should prefer cgroup v2 over cgroup v1", map[string]string{cgroupV2CPUMax: "200000 100000", cgroupV1CPUQuota: "50000", cgroupV1CPUPeriod: "100000"}, 2, true, false},
+		{"should return error for an invalid cgroup v2 CPU limit", map[string]string{cgroupV2CPUMax: "lots"}, 0, false, true},
+		{"should return error for an invalid cgroup v1 CPU quota", map[string]string{cgroupV1CPUQuota: "lots", cgroupV1CPUPeriod: "100000"}, 0, false, true},
+		{"should return error for a missing cgroup v1 CPU period", map[string]string{cgroupV1CPUQuota: "50000"}, 0, false, true},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		cgroupRoot := t.TempDir()
+		for file, content := range entry.files {
+			path := filepath.Join(cgroupRoot, file)
+			g.Expect(os.MkdirAll(filepath.Dir(path), 0700)).To(Succeed())
+			g.Expect(os.WriteFile(path, []byte(content), 0600)).To(Succeed())
+		}
+		limit, ok, err := ContainerCPULimit(cgroupRoot)
+		g.Expect(err != nil).To(Equal(entry.expectError))
+		g.Expect(limit).To(Equal(entry.expectedLimit))
+		g.Expect(ok).To(Equal(entry.expectedOK))
+	}
+}
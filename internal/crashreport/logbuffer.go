@@ -0,0 +1,78 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package crashreport
+
+import (
+	"io"
+	"slices"
+	"sync"
+
+	"github.com/gardener/etcd-wrapper/internal/bootstrap"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// LogBuffer retains the most recent log lines written to it. It is safe for concurrent use.
+type LogBuffer struct {
+	mu    sync.Mutex
+	lines [][]byte
+	next  int
+	full  bool
+}
+
+// NewLogBuffer creates a LogBuffer which retains the last size log lines. It retains nothing if size is not positive.
+func NewLogBuffer(size int) *LogBuffer {
+	return &LogBuffer{lines: make([][]byte, max(size, 0))}
+}
+
+// Write retains p as one log line, dropping the oldest line once the buffer is full.
+func (b *LogBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.lines) == 0 {
+		return len(p), nil
+	}
+	b.lines[b.next] = slices.Clone(p)
+	b.next = (b.next + 1) % len(b.lines)
+	b.full = b.full || b.next == 0
+	return len(p), nil
+}
+
+// Sync implements zapcore.WriteSyncer, there is nothing to flush.
+func (b *LogBuffer) Sync() error {
+	return nil
+}
+
+// WriteTo writes the retained log lines to w, oldest first.
+func (b *LogBuffer) WriteTo(w io.Writer) (int64, error) {
+	b.mu.Lock()
+	var lines [][]byte
+	if b.full {
+		lines = append(lines, b.lines[b.next:]...)
+	}
+	lines = append(lines, b.lines[:b.next]...)
+	b.mu.Unlock()
+
+	var written int64
+	for _, line := range lines {
+		n, err := w.Write(line)
+		written += int64(n)
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// Tee returns a logger which additionally writes all entries logged through it to the LogBuffer, encoded as JSON
+// lines in the same format as the production logger.
+func (b *LogBuffer) Tee(logger *zap.Logger) *zap.Logger {
+	encoderConfig := bootstrap.SetupLoggerConfig(logger.Level()).EncoderConfig
+	bufferCore := zapcore.NewCore(zapcore.NewJSONEncoder(encoderConfig), b, logger.Level())
+	return logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return zapcore.NewTee(core, bufferCore)
+	}))
+}
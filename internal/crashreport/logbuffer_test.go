@@ -0,0 +1,59 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package crashreport
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestLogBuffer(t *testing.T) {
+	table := []struct {
+		description   string
+		size          int
+		lines         []string
+		expectedLines string
+	}{
+		{"should retain nothing if size is zero", 0, []string{"a\n", "b\n"}, ""},
+		{"should retain all lines while the buffer is not full", 3, []string{"a\n", "b\n"}, "a\nb\n"},
+		{"should retain all lines once the buffer is full", 3, []string{"a\n", "b\n", "c\n"}, "a\nb\nc\n"},
+		{"should drop the oldest lines once the buffer overflows", 3, []string{"a\n", "b\n", "c\n", "d\n", "e\n"}, "c\nd\ne\n"},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		buffer := NewLogBuffer(entry.size)
+		for _, line := range entry.lines {
+			g.Expect(buffer.Write([]byte(line))).To(Equal(len(line)))
+		}
+		out := &bytes.Buffer{}
+		_, err := buffer.WriteTo(out)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(out.String()).To(Equal(entry.expectedLines))
+	}
+}
+
+func TestLogBufferTee(t *testing.T) {
+	g := NewWithT(t)
+	buffer := NewLogBuffer(10)
+	logger := buffer.Tee(zaptest.NewLogger(t, zaptest.Level(zap.InfoLevel)))
+
+	logger.Debug("not retained below the level of the logger")
+	logger.Info("retained", zap.String("key", "value"))
+
+	out := &bytes.Buffer{}
+	_, err := buffer.WriteTo(out)
+	g.Expect(err).ToNot(HaveOccurred())
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	g.Expect(lines).To(HaveLen(1))
+	g.Expect(lines[0]).To(ContainSubstring(`"msg":"retained"`))
+	g.Expect(lines[0]).To(ContainSubstring(`"key":"value"`))
+}
@@ -0,0 +1,160 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package crashreport recovers panics of etcd-wrapper and writes a crash bundle describing the state of the process
+// before it exits, so that crashes can be analysed after the container has been restarted.
+package crashreport
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"runtime/pprof"
+	"slices"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// bundleDirPrefix is the prefix of the names of crash bundle directories, which are suffixed with the crash time.
+	bundleDirPrefix = "crash-"
+	// bundleTimeFormat is the format of the crash time in the names of crash bundle directories, which sorts chronologically.
+	bundleTimeFormat = "20060102T150405.000Z"
+	// MaxBundles is the number of crash bundles retained in the crash report directory. Older bundles are removed
+	// whenever a new bundle is written, so that a crash loop does not fill up the volume.
+	MaxBundles = 5
+)
+
+// Reporter recovers panics and writes a crash bundle into a directory before the panic continues to crash the process.
+// A bundle is a directory holding the following files:
+//   - panic.txt: the panic value, the name of the goroutine and its stack trace.
+//   - goroutines.txt: the stack traces of all goroutines.
+//   - logs.jsonl: the most recent log lines of etcd-wrapper.
+//   - config.json: the configuration of etcd-wrapper. Secrets are excluded from its JSON representation.
+//   - status.json: the status of etcd-wrapper and etcd as returned by the status function.
+type Reporter struct {
+	dir    string
+	logs   *LogBuffer
+	config any
+	status func() any
+	logger *zap.Logger
+	now    func() time.Time
+}
+
+// NewReporter creates a Reporter which writes crash bundles into dir, holding the lines retained by logs, config and
+// the value returned by status at the time of the crash. No bundles are written if dir is empty.
+func NewReporter(dir string, logs *LogBuffer, config any, status func() any, logger *zap.Logger) *Reporter {
+	return &Reporter{
+		dir:    dir,
+		logs:   logs,
+		config: config,
+		status: status,
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+// Go runs fn in a new goroutine whose panics are reported under name.
+func (r *Reporter) Go(name string, fn func()) {
+	go func() {
+		defer r.Recover(name)
+		fn()
+	}()
+}
+
+// Recover must be deferred. If the goroutine panics, it writes a crash bundle and continues panicking, which crashes the
+// process with the original panic value. It does nothing if the goroutine does not panic.
+func (r *Reporter) Recover(name string) {
+	recovered := recover()
+	if recovered == nil {
+		return
+	}
+	stack := debug.Stack()
+	r.logger.Error("recovered panic, writing crash bundle before exiting", zap.String("goroutine", name), zap.Any("panic", recovered))
+	if r.dir != "" {
+		bundleDir, err := r.WriteBundle(name, recovered, stack)
+		if err != nil {
+			r.logger.Error("failed to write crash bundle", zap.String("dir", bundleDir), zap.Error(err))
+		} else {
+			r.logger.Error("wrote crash bundle", zap.String("dir", bundleDir))
+		}
+	}
+	_ = r.logger.Sync()
+	panic(recovered)
+}
+
+// WriteBundle writes a crash bundle for the panic of the goroutine name with the recovered value and stack and removes
+// all but the newest MaxBundles bundles. It returns the directory of the bundle. Errors writing individual files are
+// joined, so that the bundle is as complete as possible.
+func (r *Reporter) WriteBundle(name string, recovered any, stack []byte) (string, error) {
+	bundleDir := filepath.Join(r.dir, bundleDirPrefix+r.now().UTC().Format(bundleTimeFormat))
+	if err := os.MkdirAll(bundleDir, 0700); err != nil {
+		return bundleDir, err
+	}
+	errs := []error{
+		writeBundleFile(bundleDir, "panic.txt", func(f *os.File) error {
+			_, err := fmt.Fprintf(f, "goroutine: %s\npanic: %v\n\n%s", name, recovered, stack)
+			return err
+		}),
+		writeBundleFile(bundleDir, "goroutines.txt", func(f *os.File) error {
+			return pprof.Lookup("goroutine").WriteTo(f, 2)
+		}),
+		writeBundleFile(bundleDir, "logs.jsonl", func(f *os.File) error {
+			_, err := r.logs.WriteTo(f)
+			return err
+		}),
+		writeBundleFile(bundleDir, "config.json", func(f *os.File) error {
+			return writeJSON(f, r.config)
+		}),
+		writeBundleFile(bundleDir, "status.json", func(f *os.File) error {
+			return writeJSON(f, r.status())
+		}),
+		r.pruneBundles(),
+	}
+	return bundleDir, errors.Join(errs...)
+}
+
+// pruneBundles removes all but the newest MaxBundles crash bundles.
+func (r *Reporter) pruneBundles() error {
+	entries, err := os.ReadDir(r.dir)
+	if err != nil {
+		return err
+	}
+	var bundles []string
+	for _, entry := range entries {
+		if entry.IsDir() && strings.HasPrefix(entry.Name(), bundleDirPrefix) {
+			bundles = append(bundles, entry.Name())
+		}
+	}
+	slices.Sort(bundles)
+	var errs []error
+	for len(bundles) > MaxBundles {
+		errs = append(errs, os.RemoveAll(filepath.Join(r.dir, bundles[0])))
+		bundles = bundles[1:]
+	}
+	return errors.Join(errs...)
+}
+
+func writeBundleFile(bundleDir, name string, write func(f *os.File) error) error {
+	f, err := os.OpenFile(filepath.Join(bundleDir, name), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600) // #nosec G304 -- path is derived from the configured crash report directory.
+	if err != nil {
+		return err
+	}
+	if err = write(f); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return f.Close()
+}
+
+func writeJSON(f *os.File, v any) error {
+	encoder := json.NewEncoder(f)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
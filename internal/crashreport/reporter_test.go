@@ -0,0 +1,92 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package crashreport
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest"
+)
+
+type testConfig struct {
+	Name     string
+	Password string `json:"-"`
+}
+
+func TestReporterRecover(t *testing.T) {
+	g := NewWithT(t)
+	dir := t.TempDir()
+	logs := NewLogBuffer(10)
+	logger := logs.Tee(zaptest.NewLogger(t))
+	reporter := NewReporter(dir, logs, testConfig{Name: "etcd-main-0", Password: "secret"}, func() any { return map[string]string{"state": "Ready"} }, logger)
+	logger.Info("before the crash")
+
+	t.Log("should continue panicking with the original value")
+	var repanicked any
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer func() { repanicked = recover() }()
+		defer reporter.Recover("compaction")
+		panic("boom")
+	}()
+	<-done
+	g.Expect(repanicked).To(Equal("boom"))
+
+	t.Log("should write a crash bundle")
+	bundles, err := filepath.Glob(filepath.Join(dir, bundleDirPrefix+"*"))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(bundles).To(HaveLen(1))
+	g.Expect(readFile(g, bundles[0], "panic.txt")).To(And(ContainSubstring("goroutine: compaction"), ContainSubstring("panic: boom"), ContainSubstring("TestReporterRecover")))
+	g.Expect(readFile(g, bundles[0], "goroutines.txt")).To(ContainSubstring("goroutine"))
+	g.Expect(readFile(g, bundles[0], "logs.jsonl")).To(ContainSubstring("before the crash"))
+	g.Expect(readFile(g, bundles[0], "config.json")).To(And(ContainSubstring("etcd-main-0"), Not(ContainSubstring("secret"))))
+	g.Expect(readFile(g, bundles[0], "status.json")).To(ContainSubstring(`"state": "Ready"`))
+}
+
+func TestReporterRecoverWithoutPanic(t *testing.T) {
+	g := NewWithT(t)
+	dir := t.TempDir()
+	reporter := NewReporter(dir, NewLogBuffer(10), nil, func() any { return nil }, zap.NewNop())
+
+	func() {
+		defer reporter.Recover("readiness")
+	}()
+
+	entries, err := os.ReadDir(dir)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(entries).To(BeEmpty())
+}
+
+func TestReporterPrunesBundles(t *testing.T) {
+	g := NewWithT(t)
+	dir := t.TempDir()
+	reporter := NewReporter(dir, NewLogBuffer(10), nil, func() any { return nil }, zap.NewNop())
+	crashTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	reporter.now = func() time.Time { return crashTime }
+
+	var bundleDirs []string
+	for range MaxBundles + 2 {
+		bundleDir, err := reporter.WriteBundle("readiness", "boom", nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		bundleDirs = append(bundleDirs, bundleDir)
+		crashTime = crashTime.Add(time.Minute)
+	}
+
+	bundles, err := filepath.Glob(filepath.Join(dir, bundleDirPrefix+"*"))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(bundles).To(Equal(bundleDirs[2:]))
+}
+
+func readFile(g *WithT, dir, name string) string {
+	content, err := os.ReadFile(filepath.Join(dir, name)) // #nosec G304 -- test file path
+	g.Expect(err).ToNot(HaveOccurred())
+	return string(content)
+}
@@ -0,0 +1,176 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package devmode prepares a throwaway environment for running a single-member etcd with TLS locally: self-signed
+// certificates, an etcd configuration using them and a fake backup-restore serving this configuration. Ephemeral mode
+// prepares the same environment without TLS in a directory backed by memory.
+package devmode
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/fakesidecar"
+	"github.com/gardener/etcd-wrapper/internal/httpserver"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// MemberName is the name of the etcd member run in dev mode.
+	MemberName = "etcd-dev"
+	// ServerName is the name under which etcd is reached in dev mode, which is among the SANs of the generated certificates.
+	ServerName = "localhost"
+
+	// tmpfsDir is the directory backed by memory in which the directory of ephemeral mode is created if it exists.
+	tmpfsDir = "/dev/shm"
+
+	shutdownTimeout = 5 * time.Second
+)
+
+// Environment is the environment prepared for dev mode. All of its files are located in Dir.
+type Environment struct {
+	// Dir is the directory holding the certificates, the etcd configuration and the data directory.
+	Dir string
+	// CACertPath is the path of the certificate of the self-signed CA which has issued all other certificates.
+	CACertPath string
+	// ServerCertPath is the path of the server certificate of etcd.
+	ServerCertPath string
+	// ServerKeyPath is the path of the key of the server certificate of etcd.
+	ServerKeyPath string
+	// PeerCertPath is the path of the peer certificate of etcd.
+	PeerCertPath string
+	// PeerKeyPath is the path of the key of the peer certificate of etcd.
+	PeerKeyPath string
+	// ClientCertPath is the path of a client certificate accepted by etcd.
+	ClientCertPath string
+	// ClientKeyPath is the path of the key of the client certificate.
+	ClientKeyPath string
+	// EtcdConfigPath is the path of the etcd configuration.
+	EtcdConfigPath string
+	// DataDir is the data directory of etcd.
+	DataDir string
+	// ClientURL is the URL at which etcd serves clients.
+	ClientURL string
+}
+
+// Prepare generates fresh certificates and an etcd configuration of a single-member cluster using TLS for client and
+// peer communication in dir. etcd listens on the loopback interface at clientPort and peerPort. The data directory is
+// kept across calls with the same dir.
+func Prepare(dir string, clientPort, peerPort int) (*Environment, error) {
+	certDir := filepath.Join(dir, "pki")
+	if err := os.MkdirAll(certDir, 0700); err != nil {
+		return nil, err
+	}
+	env := &Environment{
+		Dir:            dir,
+		CACertPath:     filepath.Join(certDir, "ca.crt"),
+		ServerCertPath: filepath.Join(certDir, "server.crt"),
+		ServerKeyPath:  filepath.Join(certDir, "server.key"),
+		PeerCertPath:   filepath.Join(certDir, "peer.crt"),
+		PeerKeyPath:    filepath.Join(certDir, "peer.key"),
+		ClientCertPath: filepath.Join(certDir, "client.crt"),
+		ClientKeyPath:  filepath.Join(certDir, "client.key"),
+		EtcdConfigPath: filepath.Join(dir, "etcd.conf.yaml"),
+		DataDir:        filepath.Join(dir, MemberName+".etcd"),
+		ClientURL:      fmt.Sprintf("https://%s:%d", ServerName, clientPort),
+	}
+	ca, err := newCertAuthority(env.CACertPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate CA: %w", err)
+	}
+	if err = ca.issue("etcd-server", []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}, env.ServerCertPath, env.ServerKeyPath); err != nil {
+		return nil, fmt.Errorf("failed to generate server certificate: %w", err)
+	}
+	if err = ca.issue("etcd-peer", []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}, env.PeerCertPath, env.PeerKeyPath); err != nil {
+		return nil, fmt.Errorf("failed to generate peer certificate: %w", err)
+	}
+	if err = ca.issue("root", []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}, env.ClientCertPath, env.ClientKeyPath); err != nil {
+		return nil, fmt.Errorf("failed to generate client certificate: %w", err)
+	}
+	peerURL := fmt.Sprintf("https://%s:%d", ServerName, peerPort)
+	etcdConfig := fmt.Sprintf(`name: %[1]s
+data-dir: %[2]s
+listen-client-urls: https://127.0.0.1:%[3]d
+advertise-client-urls: %[4]s
+listen-peer-urls: https://127.0.0.1:%[5]d
+initial-advertise-peer-urls: %[6]s
+initial-cluster: %[1]s=%[6]s
+initial-cluster-token: etcd-dev
+initial-cluster-state: new
+client-transport-security:
+  cert-file: %[7]s
+  key-file: %[8]s
+  client-cert-auth: true
+  trusted-ca-file: %[9]s
+peer-transport-security:
+  cert-file: %[10]s
+  key-file: %[11]s
+  client-cert-auth: true
+  trusted-ca-file: %[9]s
+`, MemberName, env.DataDir, clientPort, env.ClientURL, peerPort, peerURL, env.ServerCertPath, env.ServerKeyPath, env.CACertPath, env.PeerCertPath, env.PeerKeyPath)
+	if err = os.WriteFile(env.EtcdConfigPath, []byte(etcdConfig), 0600); err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
+// NewEphemeralDir creates a new directory for ephemeral mode in /dev/shm, which is backed by memory, or in the
+// temporary directory of the system if /dev/shm does not exist. It must be removed by the caller.
+func NewEphemeralDir() (string, error) {
+	parent := os.TempDir()
+	if info, err := os.Stat(tmpfsDir); err == nil && info.IsDir() {
+		parent = tmpfsDir
+	}
+	return os.MkdirTemp(parent, "etcd-wrapper-ephemeral-")
+}
+
+// PrepareEphemeral writes an etcd configuration of a single-member cluster without TLS in dir, whose data directory is
+// located in dir as well. etcd listens on the loopback interface at clientPort and peerPort. No certificates are
+// generated, so the certificate paths of the returned Environment are empty.
+func PrepareEphemeral(dir string, clientPort, peerPort int) (*Environment, error) {
+	env := &Environment{
+		Dir:            dir,
+		EtcdConfigPath: filepath.Join(dir, "etcd.conf.yaml"),
+		DataDir:        filepath.Join(dir, MemberName+".etcd"),
+		ClientURL:      fmt.Sprintf("http://%s:%d", ServerName, clientPort),
+	}
+	peerURL := fmt.Sprintf("http://%s:%d", ServerName, peerPort)
+	etcdConfig := fmt.Sprintf(`name: %[1]s
+data-dir: %[2]s
+listen-client-urls: http://127.0.0.1:%[3]d
+advertise-client-urls: %[4]s
+listen-peer-urls: http://127.0.0.1:%[5]d
+initial-advertise-peer-urls: %[6]s
+initial-cluster: %[1]s=%[6]s
+initial-cluster-token: etcd-ephemeral
+initial-cluster-state: new
+`, MemberName, env.DataDir, clientPort, env.ClientURL, peerPort, peerURL)
+	if err := os.WriteFile(env.EtcdConfigPath, []byte(etcdConfig), 0600); err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
+// ServeBackupRestore serves a fake backup-restore, which serves the etcd configuration of the Environment and
+// initializes the data directory as soon as it is requested, on a free port of the loopback interface until ctx is
+// cancelled. It returns the host and port at which the fake backup-restore is listening.
+func (e *Environment) ServeBackupRestore(ctx context.Context, logger *zap.Logger) (string, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+	server := httpserver.New(httpserver.Config{ShutdownTimeout: shutdownTimeout}, fakesidecar.NewServer(fakesidecar.Script{EtcdConfigPath: e.EtcdConfigPath}))
+	go func() {
+		if err := server.Serve(ctx, listener); err != nil {
+			logger.Error("fake backup-restore of dev mode stopped", zap.Error(err))
+		}
+	}()
+	return listener.Addr().String(), nil
+}
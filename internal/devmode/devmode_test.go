@@ -0,0 +1,100 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package devmode
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gardener/etcd-wrapper/internal/bootstrap"
+
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestPrepare(t *testing.T) {
+	g := NewWithT(t)
+	env, err := Prepare(t.TempDir(), 2379, 2380)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	t.Log("should issue certificates for localhost signed by the generated CA")
+	caCert, err := os.ReadFile(env.CACertPath)
+	g.Expect(err).ToNot(HaveOccurred())
+	roots := x509.NewCertPool()
+	g.Expect(roots.AppendCertsFromPEM(caCert)).To(BeTrue())
+	for _, pair := range []struct {
+		certPath, keyPath string
+		usage             x509.ExtKeyUsage
+	}{
+		{env.ServerCertPath, env.ServerKeyPath, x509.ExtKeyUsageServerAuth},
+		{env.PeerCertPath, env.PeerKeyPath, x509.ExtKeyUsageServerAuth},
+		{env.PeerCertPath, env.PeerKeyPath, x509.ExtKeyUsageClientAuth},
+		{env.ClientCertPath, env.ClientKeyPath, x509.ExtKeyUsageClientAuth},
+	} {
+		keyPair, err := tls.LoadX509KeyPair(pair.certPath, pair.keyPath)
+		g.Expect(err).ToNot(HaveOccurred())
+		cert, err := x509.ParseCertificate(keyPair.Certificate[0])
+		g.Expect(err).ToNot(HaveOccurred())
+		_, err = cert.Verify(x509.VerifyOptions{DNSName: ServerName, Roots: roots, KeyUsages: []x509.ExtKeyUsage{pair.usage}})
+		g.Expect(err).ToNot(HaveOccurred())
+	}
+
+	t.Log("should write an etcd configuration using TLS for client and peer communication")
+	cfg, err := bootstrap.LoadEtcdConfig(env.EtcdConfigPath)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(cfg.Name).To(Equal(MemberName))
+	g.Expect(cfg.Dir).To(Equal(env.DataDir))
+	tlsMode, err := bootstrap.ResolveTLSMode(cfg)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(tlsMode).To(Equal(bootstrap.TLSMode{ClientTLS: true, PeerTLS: true}))
+}
+
+func TestPrepareEphemeral(t *testing.T) {
+	g := NewWithT(t)
+	dir, err := NewEphemeralDir()
+	g.Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		g.Expect(os.RemoveAll(dir)).To(Succeed())
+	}()
+	env, err := PrepareEphemeral(dir, 2379, 2380)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	t.Log("should write an etcd configuration without TLS with the data directory in the ephemeral directory")
+	cfg, err := bootstrap.LoadEtcdConfig(env.EtcdConfigPath)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(cfg.Name).To(Equal(MemberName))
+	g.Expect(cfg.Dir).To(Equal(env.DataDir))
+	g.Expect(filepath.Dir(cfg.Dir)).To(Equal(dir))
+	tlsMode, err := bootstrap.ResolveTLSMode(cfg)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(tlsMode).To(Equal(bootstrap.TLSMode{}))
+	g.Expect(env.ClientURL).To(Equal("http://localhost:2379"))
+}
+
+func TestServeBackupRestore(t *testing.T) {
+	g := NewWithT(t)
+	env, err := Prepare(t.TempDir(), 2379, 2380)
+	g.Expect(err).ToNot(HaveOccurred())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	hostPort, err := env.ServeBackupRestore(ctx, zaptest.NewLogger(t))
+	g.Expect(err).ToNot(HaveOccurred())
+	resp, err := http.Get("http://" + hostPort + "/config")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	g.Expect(resp.StatusCode).To(Equal(http.StatusOK))
+	body, err := io.ReadAll(resp.Body)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(string(body)).To(ContainSubstring("name: " + MemberName))
+}
@@ -0,0 +1,154 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package etcdprocess
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ForwardedSignals are the signals received by etcd-wrapper which are forwarded to the etcd process. Shutdown signals
+// are not forwarded but handled by Stop, so that etcd-wrapper controls the order of the shutdown.
+var ForwardedSignals = []os.Signal{syscall.SIGHUP, syscall.SIGUSR1, syscall.SIGUSR2}
+
+// Process is an etcd binary run and supervised by etcd-wrapper as child process.
+type Process struct {
+	cmd     *exec.Cmd
+	done    chan struct{}
+	err     error // set before done is closed
+	sigCh   chan os.Signal
+	stopMu  sync.Mutex
+	stopped bool
+	logger  *zap.Logger
+}
+
+// Start starts the etcd binary at binaryPath with the etcd configuration file at configFilePath. The output of etcd is
+// written to output, or passed through to the output of etcd-wrapper if output is nil, and the signals in
+// ForwardedSignals are forwarded to etcd until it has exited.
+func Start(binaryPath, configFilePath string, output io.Writer, logger *zap.Logger) (*Process, error) {
+	cmd := exec.Command(binaryPath, "--config-file", configFilePath) // #nosec G204 -- binary path is configured by the operator.
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if output != nil {
+		cmd.Stdout = output
+		cmd.Stderr = output
+	}
+	cmd.Env = os.Environ()
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start etcd process %s: %w", binaryPath, err)
+	}
+	p := &Process{
+		cmd:    cmd,
+		done:   make(chan struct{}),
+		sigCh:  make(chan os.Signal, 1),
+		logger: logger.With(zap.Int("pid", cmd.Process.Pid)),
+	}
+	p.logger.Info("Started etcd process", zap.String("binary", binaryPath), zap.String("configFile", configFilePath))
+	signal.Notify(p.sigCh, ForwardedSignals...)
+	go p.forwardSignals()
+	go p.wait()
+	return p, nil
+}
+
+// Pid returns the process ID of etcd.
+func (p *Process) Pid() int {
+	return p.cmd.Process.Pid
+}
+
+// Done returns a channel which is closed once etcd has exited.
+func (p *Process) Done() <-chan struct{} {
+	return p.done
+}
+
+// Err returns why etcd has exited. It returns nil if etcd has not exited yet, or has exited with code 0.
+func (p *Process) Err() error {
+	select {
+	case <-p.done:
+		return p.err
+	default:
+		return nil
+	}
+}
+
+// Signal sends sig to etcd.
+func (p *Process) Signal(sig os.Signal) error {
+	if err := p.cmd.Process.Signal(sig); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		return err
+	}
+	return nil
+}
+
+// Stop stops etcd with SIGTERM, and kills it if it has not exited within gracePeriod. It returns once etcd has
+// exited. Stopping etcd which has already exited or is being stopped is a no-op.
+func (p *Process) Stop(gracePeriod time.Duration) {
+	p.stopMu.Lock()
+	stopped := p.stopped
+	p.stopped = true
+	p.stopMu.Unlock()
+	if stopped {
+		<-p.done
+		return
+	}
+	select {
+	case <-p.done:
+		return
+	default:
+	}
+	p.logger.Info("Stopping etcd process", zap.Duration("gracePeriod", gracePeriod))
+	if err := p.Signal(syscall.SIGTERM); err != nil {
+		p.logger.Error("failed to send SIGTERM to etcd process", zap.Error(err))
+	}
+	select {
+	case <-p.done:
+	case <-time.After(gracePeriod):
+		p.logger.Warn("etcd process has not exited within the grace period, killing it", zap.Duration("gracePeriod", gracePeriod))
+		if err := p.Signal(syscall.SIGKILL); err != nil {
+			p.logger.Error("failed to kill etcd process", zap.Error(err))
+		}
+		<-p.done
+	}
+}
+
+// Stopping returns whether Stop has been called, so that its exit is expected.
+func (p *Process) Stopping() bool {
+	p.stopMu.Lock()
+	defer p.stopMu.Unlock()
+	return p.stopped
+}
+
+func (p *Process) wait() {
+	err := p.cmd.Wait()
+	signal.Stop(p.sigCh)
+	if err != nil {
+		p.err = fmt.Errorf("etcd process has exited: %w", err)
+		p.logger.Error("etcd process has exited", zap.Error(err))
+	} else {
+		p.logger.Info("etcd process has exited")
+	}
+	close(p.done)
+}
+
+func (p *Process) forwardSignals() {
+	for {
+		select {
+		case <-p.done:
+			return
+		case sig := <-p.sigCh:
+			p.logger.Info("Forwarding signal to etcd process", zap.Stringer("signal", sig))
+			if err := p.Signal(sig); err != nil {
+				p.logger.Error("failed to forward signal to etcd process", zap.Stringer("signal", sig), zap.Error(err))
+			}
+		}
+	}
+}
@@ -0,0 +1,95 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package etcdprocess
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap/zaptest"
+)
+
+// writeFakeEtcd writes a shell script standing in for the etcd binary, which records its arguments and the signals it
+// receives into dir.
+func writeFakeEtcd(g *WithT, dir, body string) string {
+	path := filepath.Join(dir, "etcd")
+	script := "#!/bin/sh\necho \"$@\" > " + filepath.Join(dir, "args") + "\n" + body + "\n"
+	g.Expect(os.WriteFile(path, []byte(script), 0700)).To(Succeed())
+	return path
+}
+
+func TestProcess(t *testing.T) {
+	const runUntilTerminated = "trap 'exit 0' TERM\nwhile true; do sleep 0.05; done"
+	table := []struct {
+		description   string
+		body          string
+		stop          bool
+		expectErr     bool
+		expectStopped bool
+	}{
+		{"should report an error if etcd exits with a non-zero code", "exit 3", false, true, false},
+		{"should report no error if etcd exits with code 0", "exit 0", false, false, false},
+		{"should stop etcd gracefully with SIGTERM", runUntilTerminated, true, false, true},
+		{"should kill etcd which does not exit within the grace period", "trap '' TERM\nwhile true; do sleep 0.05; done", true, true, true},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		dir := t.TempDir()
+		p, err := Start(writeFakeEtcd(g, dir, entry.body), "/etc/etcd.conf.yaml", nil, zaptest.NewLogger(t))
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(p.Pid()).To(BeNumerically(">", 0))
+		if entry.stop {
+			g.Eventually(filepath.Join(dir, "args")).Should(BeAnExistingFile())
+			p.Stop(200 * time.Millisecond)
+		}
+		g.Eventually(p.Done()).Should(BeClosed())
+		g.Expect(p.Err() != nil).To(Equal(entry.expectErr))
+		g.Expect(p.Stopping()).To(Equal(entry.expectStopped))
+		args, err := os.ReadFile(filepath.Join(dir, "args"))
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(string(args)).To(Equal("--config-file /etc/etcd.conf.yaml\n"))
+	}
+}
+
+func TestProcessSignal(t *testing.T) {
+	g := NewWithT(t)
+	dir := t.TempDir()
+	p, err := Start(writeFakeEtcd(g, dir, "trap 'echo HUP > "+filepath.Join(dir, "signals")+"' HUP\nwhile true; do sleep 0.05; done"), "/etc/etcd.conf.yaml", nil, zaptest.NewLogger(t))
+	g.Expect(err).ToNot(HaveOccurred())
+	defer p.Stop(time.Second)
+	g.Eventually(filepath.Join(dir, "args")).Should(BeAnExistingFile())
+
+	t.Log("should send signals to etcd")
+	g.Expect(p.Signal(syscall.SIGHUP)).To(Succeed())
+	g.Eventually(func() (string, error) {
+		signals, err := os.ReadFile(filepath.Join(dir, "signals"))
+		return string(signals), err
+	}).Should(Equal("HUP\n"))
+}
+
+func TestProcessOutput(t *testing.T) {
+	g := NewWithT(t)
+	output := &bytes.Buffer{}
+	p, err := Start(writeFakeEtcd(g, t.TempDir(), "echo out\necho err >&2"), "/etc/etcd.conf.yaml", output, zaptest.NewLogger(t))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Eventually(p.Done()).Should(BeClosed())
+
+	t.Log("should write stdout and stderr of etcd to the given output")
+	g.Expect(output.String()).To(Equal("out\nerr\n"))
+}
+
+func TestStartFailsForMissingBinary(t *testing.T) {
+	g := NewWithT(t)
+	t.Log("should return an error if the etcd binary does not exist")
+	_, err := Start(filepath.Join(t.TempDir(), "etcd"), "/etc/etcd.conf.yaml", nil, zaptest.NewLogger(t))
+	g.Expect(err).To(HaveOccurred())
+}
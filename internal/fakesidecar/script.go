@@ -0,0 +1,142 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package fakesidecar
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/brclient"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// InitStatusNew is the initialization status reported before initialization has been triggered.
+	InitStatusNew = "New"
+	// InitStatusInProgress is the initialization status reported while the data directory is validated or restored.
+	InitStatusInProgress = "InProgress"
+	// InitStatusSuccessful is the initialization status reported once the data directory has been initialized.
+	InitStatusSuccessful = "Successful"
+)
+
+// Script describes the responses of the fake backup-restore server. Without a Script, the server behaves like a
+// backup-restore whose data directory is valid: initialization succeeds as soon as it is triggered and there are
+// no snapshots.
+type Script struct {
+	// InitStatuses are the initialization statuses returned by successive requests of the initialization status,
+	// regardless of whether initialization has been triggered. Once all statuses are consumed, the last status is
+	// returned for all subsequent requests. If empty, the status is derived from the triggered initialization.
+	InitStatuses []string `json:"initStatuses,omitempty"`
+	// Validation maps validation modes (sanity, full) to the behaviour of initializations triggered with them.
+	Validation map[string]ValidationScript `json:"validation,omitempty"`
+	// EtcdConfigPath is the path of the etcd configuration file which is served. It is read on every request.
+	EtcdConfigPath string `json:"etcdConfigPath,omitempty"`
+	// LatestSnapshots are the latest snapshots which are served. No snapshots are served if nil.
+	LatestSnapshots *brclient.LatestSnapshots `json:"latestSnapshots,omitempty"`
+	// Snapshot is the snapshot returned when a snapshot is triggered. No snapshot is returned if nil.
+	Snapshot *brclient.Snapshot `json:"snapshot,omitempty"`
+	// DeltaSnapshotPeriod is the period of delta snapshots returned in response to churn reports. Churn reports are
+	// not supported, i.e. answered with status code 404, if zero.
+	DeltaSnapshotPeriod Duration `json:"deltaSnapshotPeriod,omitempty"`
+	// Endpoints maps request paths, e.g. /config, to delays and failures of their responses.
+	Endpoints map[string]EndpointScript `json:"endpoints,omitempty"`
+}
+
+// ValidationScript describes the behaviour of an initialization triggered with a validation mode.
+type ValidationScript struct {
+	// Duration is the time for which the initialization is reported to be in progress before it succeeds.
+	Duration Duration `json:"duration,omitempty"`
+	// Reject rejects triggering initialization with the validation mode with status code 400.
+	Reject bool `json:"reject,omitempty"`
+}
+
+// EndpointScript describes delays and failures of the responses of an endpoint.
+type EndpointScript struct {
+	// Delay is the time by which every response is delayed.
+	Delay Duration `json:"delay,omitempty"`
+	// StatusCode is the status code with which requests fail. Requests do not fail if zero.
+	StatusCode int `json:"statusCode,omitempty"`
+	// Failures is the number of requests which fail with StatusCode, after which requests succeed again. All requests
+	// fail if zero.
+	Failures int `json:"failures,omitempty"`
+}
+
+// Duration is a time.Duration which is read from and written as a string such as 1m30s.
+type Duration struct {
+	time.Duration
+}
+
+// UnmarshalJSON parses the Duration from a string as accepted by time.ParseDuration.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as 1m30s: %w", err)
+	}
+	duration, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	d.Duration = duration
+	return nil
+}
+
+// MarshalJSON writes the Duration as a string.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// LoadScript reads and validates the Script from the YAML file at path.
+func LoadScript(path string) (*Script, error) {
+	data, err := os.ReadFile(path) // #nosec G304 -- path is configured by the developer.
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fake-sidecar script %s: %w", path, err)
+	}
+	script := &Script{}
+	if err = yaml.UnmarshalStrict(data, script); err != nil {
+		return nil, fmt.Errorf("failed to parse fake-sidecar script %s: %w", path, err)
+	}
+	if err = script.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid fake-sidecar script %s: %w", path, err)
+	}
+	return script, nil
+}
+
+// Validate validates the Script.
+func (s *Script) Validate() (err error) {
+	for _, status := range s.InitStatuses {
+		if status != InitStatusNew && status != InitStatusInProgress && status != InitStatusSuccessful {
+			err = errors.Join(err, fmt.Errorf("initialization status %q is not one of: %s, %s, %s", status, InitStatusNew, InitStatusInProgress, InitStatusSuccessful))
+		}
+	}
+	for mode, validation := range s.Validation {
+		if mode != string(brclient.SanityValidation) && mode != string(brclient.FullValidation) {
+			err = errors.Join(err, fmt.Errorf("validation mode %q is not one of: %s, %s", mode, brclient.SanityValidation, brclient.FullValidation))
+		}
+		if validation.Duration.Duration < 0 {
+			err = errors.Join(err, fmt.Errorf("duration of validation mode %s must not be negative", mode))
+		}
+	}
+	for path, endpoint := range s.Endpoints {
+		if !strings.HasPrefix(path, "/") {
+			err = errors.Join(err, fmt.Errorf("endpoint %q must be a path starting with /", path))
+		}
+		if endpoint.Delay.Duration < 0 {
+			err = errors.Join(err, fmt.Errorf("delay of endpoint %s must not be negative", path))
+		}
+		if endpoint.StatusCode != 0 && (endpoint.StatusCode < 100 || endpoint.StatusCode > 599 || endpoint.StatusCode == http.StatusOK) {
+			err = errors.Join(err, fmt.Errorf("status code %d of endpoint %s is not an error status code", endpoint.StatusCode, path))
+		}
+		if endpoint.Failures < 0 {
+			err = errors.Join(err, fmt.Errorf("failures of endpoint %s must not be negative", path))
+		}
+	}
+	return
+}
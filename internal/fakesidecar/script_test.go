@@ -0,0 +1,73 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package fakesidecar
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestLoadScript(t *testing.T) {
+	table := []struct {
+		description string
+		script      string
+		expectError bool
+	}{
+		{"should return error when script is malformed", "initStatuses: [New", true},
+		{"should return error when script contains unknown fields", "unknown: true", true},
+		{"should return error when duration is not a string", "validation: {full: {duration: 10}}", true},
+		{"should return error when initialization status is unknown", "initStatuses: [Restoring]", true},
+		{"should return error when validation mode is unknown", "validation: {quick: {duration: 1s}}", true},
+		{"should return error when endpoint is not a path", "endpoints: {config: {statusCode: 503}}", true},
+		{"should return error when status code is not an error status code", "endpoints: {/config: {statusCode: 200}}", true},
+		{"should return error when delay is negative", "endpoints: {/config: {delay: -1s}}", true},
+		{"should load empty script", "", false},
+		{"should load script", `
+initStatuses: [New, InProgress, Successful]
+validation:
+  full:
+    duration: 10s
+  sanity:
+    reject: true
+etcdConfigPath: /tmp/etcd.conf.yaml
+latestSnapshots:
+  fullSnapshot:
+    kind: Full
+    lastRevision: 10
+    createdOn: "2024-01-01T00:00:00Z"
+endpoints:
+  /config:
+    delay: 500ms
+    statusCode: 503
+    failures: 2
+`, false},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		path := filepath.Join(t.TempDir(), "script.yaml")
+		g.Expect(os.WriteFile(path, []byte(entry.script), 0600)).To(Succeed())
+		script, err := LoadScript(path)
+		g.Expect(err != nil).To(Equal(entry.expectError))
+		if entry.expectError || entry.script == "" {
+			continue
+		}
+		g.Expect(script.Validation["full"].Duration.Duration).To(Equal(10 * time.Second))
+		g.Expect(script.Validation["sanity"].Reject).To(BeTrue())
+		g.Expect(script.LatestSnapshots.LastRevision()).To(Equal(int64(10)))
+		g.Expect(script.Endpoints["/config"]).To(Equal(EndpointScript{Delay: Duration{500 * time.Millisecond}, StatusCode: 503, Failures: 2}))
+	}
+}
+
+func TestLoadScriptMissingFile(t *testing.T) {
+	g := NewWithT(t)
+	_, err := LoadScript(filepath.Join(t.TempDir(), "does-not-exist.yaml"))
+	g.Expect(err).To(HaveOccurred())
+}
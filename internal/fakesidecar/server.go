@@ -0,0 +1,271 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package fakesidecar provides a fake of the HTTP server of the backup-restore sidecar whose responses are scripted.
+// It allows running etcd-wrapper locally and in tests without backup-restore.
+package fakesidecar
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/brclient"
+)
+
+// Server is a fake of the HTTP server of backup-restore which responds as described by a Script. The Script can be
+// changed while the Server is running. It is safe for concurrent use.
+type Server struct {
+	mu                 sync.Mutex
+	script             Script
+	etcdConfig         []byte
+	initializedAt      *time.Time
+	initDuration       time.Duration
+	failures           map[string]int
+	triggeredModes     []string
+	triggeredSnapshots []string
+	churnReports       []brclient.ChurnReport
+	requests           map[string]int
+	now                func() time.Time
+	mux                *http.ServeMux
+}
+
+// NewServer creates a Server which responds as described by script.
+func NewServer(script Script) *Server {
+	s := &Server{
+		script:   script,
+		failures: map[string]int{},
+		requests: map[string]int{},
+		now:      time.Now,
+		mux:      http.NewServeMux(),
+	}
+	s.mux.HandleFunc("/initialization/status", s.initializationStatusHandler)
+	s.mux.HandleFunc("/initialization/start", s.initializationStartHandler)
+	s.mux.HandleFunc("/config", s.configHandler)
+	s.mux.HandleFunc("/snapshot/latest", s.latestSnapshotsHandler)
+	s.mux.HandleFunc("/snapshot/churn", s.churnHandler)
+	s.mux.HandleFunc("/snapshot/", s.snapshotHandler)
+	return s
+}
+
+// ServeHTTP serves a request to the backup-restore API after applying the delays and failures of the Script.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests[r.URL.Path]++
+	endpoint := s.script.Endpoints[r.URL.Path]
+	fail := endpoint.StatusCode != 0 && (endpoint.Failures == 0 || s.failures[r.URL.Path] < endpoint.Failures)
+	if fail {
+		s.failures[r.URL.Path]++
+	}
+	s.mu.Unlock()
+
+	if endpoint.Delay.Duration > 0 {
+		select {
+		case <-time.After(endpoint.Delay.Duration):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	if fail {
+		w.WriteHeader(endpoint.StatusCode)
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// SetInitStatuses overrides the initialization statuses returned by successive requests, see Script.InitStatuses.
+func (s *Server) SetInitStatuses(statuses ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.script.InitStatuses = statuses
+}
+
+// SetEtcdConfig sets the etcd configuration which is served, taking precedence over Script.EtcdConfigPath.
+func (s *Server) SetEtcdConfig(etcdConfig []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.etcdConfig = etcdConfig
+}
+
+// SetLatestSnapshots sets the latest snapshots which are served. No snapshots are served if nil.
+func (s *Server) SetLatestSnapshots(latestSnapshots *brclient.LatestSnapshots) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.script.LatestSnapshots = latestSnapshots
+}
+
+// SetSnapshot sets the snapshot returned when a snapshot is triggered. No snapshot is returned if nil.
+func (s *Server) SetSnapshot(snapshot *brclient.Snapshot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.script.Snapshot = snapshot
+}
+
+// SetDeltaSnapshotPeriod sets the period of delta snapshots returned in response to churn reports. Churn reports are
+// not supported if zero.
+func (s *Server) SetDeltaSnapshotPeriod(period time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.script.DeltaSnapshotPeriod = Duration{period}
+}
+
+// SetEndpoint sets the delays and failures of the responses of the endpoint at path.
+func (s *Server) SetEndpoint(path string, endpoint EndpointScript) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.script.Endpoints = maps.Clone(s.script.Endpoints)
+	if s.script.Endpoints == nil {
+		s.script.Endpoints = map[string]EndpointScript{}
+	}
+	s.script.Endpoints[path] = endpoint
+	delete(s.failures, path)
+}
+
+// TriggeredValidationModes returns the validation modes with which initialization has been triggered, oldest first.
+func (s *Server) TriggeredValidationModes() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.triggeredModes)
+}
+
+// TriggeredSnapshotKinds returns the kinds of the snapshots which have been triggered, oldest first.
+func (s *Server) TriggeredSnapshotKinds() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.triggeredSnapshots)
+}
+
+// ChurnReports returns the churn reports which have been received, oldest first.
+func (s *Server) ChurnReports() []brclient.ChurnReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.churnReports)
+}
+
+// Requests returns the number of requests made to path, including failed requests.
+func (s *Server) Requests(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[path]
+}
+
+func (s *Server) initializationStatusHandler(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status := InitStatusNew
+	switch {
+	case len(s.script.InitStatuses) > 0:
+		status = s.script.InitStatuses[0]
+		if len(s.script.InitStatuses) > 1 {
+			s.script.InitStatuses = s.script.InitStatuses[1:]
+		}
+	case s.initializedAt != nil && s.now().Before(s.initializedAt.Add(s.initDuration)):
+		status = InitStatusInProgress
+	case s.initializedAt != nil:
+		status = InitStatusSuccessful
+	}
+	_, _ = w.Write([]byte(status))
+}
+
+func (s *Server) initializationStartHandler(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	mode := r.URL.Query().Get("mode")
+	s.triggeredModes = append(s.triggeredModes, mode)
+	validation := s.script.Validation[mode]
+	if validation.Reject {
+		http.Error(w, "validation mode "+mode+" is rejected", http.StatusBadRequest)
+		return
+	}
+	now := s.now()
+	s.initializedAt, s.initDuration = &now, validation.Duration.Duration
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) configHandler(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	etcdConfig, etcdConfigPath := s.etcdConfig, s.script.EtcdConfigPath
+	s.mu.Unlock()
+	if etcdConfig == nil && etcdConfigPath != "" {
+		var err error
+		if etcdConfig, err = os.ReadFile(etcdConfigPath); err != nil { // #nosec G304 -- path is configured by the developer.
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	if etcdConfig == nil {
+		http.Error(w, "no etcd configuration configured", http.StatusInternalServerError)
+		return
+	}
+	digest := sha256.Sum256(etcdConfig)
+	etag := `"` + hex.EncodeToString(digest[:8]) + `"`
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	_, _ = w.Write(etcdConfig)
+}
+
+func (s *Server) latestSnapshotsHandler(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.script.LatestSnapshots == nil {
+		http.Error(w, "no snapshots found", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, s.script.LatestSnapshots)
+}
+
+func (s *Server) snapshotHandler(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	kind := strings.TrimPrefix(r.URL.Path, "/snapshot/")
+	if kind != string(brclient.FullSnapshotKind) && kind != string(brclient.DeltaSnapshotKind) {
+		http.NotFound(w, r)
+		return
+	}
+	s.triggeredSnapshots = append(s.triggeredSnapshots, kind)
+	if s.script.Snapshot == nil {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	writeJSON(w, s.script.Snapshot)
+}
+
+func (s *Server) churnHandler(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.script.DeltaSnapshotPeriod.Duration == 0 {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "churn reports must be posted", http.StatusMethodNotAllowed)
+		return
+	}
+	var request struct {
+		brclient.ChurnReport
+		WindowSeconds int64 `json:"windowSeconds"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	request.Window = time.Duration(request.WindowSeconds) * time.Second
+	s.churnReports = append(s.churnReports, request.ChurnReport)
+	writeJSON(w, map[string]int64{"deltaSnapshotPeriodSeconds": int64(s.script.DeltaSnapshotPeriod.Seconds())})
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
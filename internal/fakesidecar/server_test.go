@@ -0,0 +1,158 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package fakesidecar
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/brclient"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestServerInitialization(t *testing.T) {
+	g := NewWithT(t)
+	now := time.Now()
+	server := NewServer(Script{Validation: map[string]ValidationScript{
+		string(brclient.FullValidation):   {Duration: Duration{time.Minute}},
+		string(brclient.SanityValidation): {Reject: true},
+	}})
+	server.now = func() time.Time { return now }
+	client := startTestServer(t, server)
+	ctx := context.Background()
+
+	t.Log("should report status New till initialization is triggered")
+	g.Expect(client.GetInitializationStatus(ctx)).To(Equal(brclient.New))
+
+	t.Log("should reject a validation mode as scripted")
+	g.Expect(client.TriggerInitialization(ctx, brclient.SanityValidation)).ToNot(Succeed())
+	g.Expect(client.GetInitializationStatus(ctx)).To(Equal(brclient.New))
+
+	t.Log("should report initialization in progress for the scripted duration")
+	g.Expect(client.TriggerInitialization(ctx, brclient.FullValidation)).To(Succeed())
+	g.Expect(client.GetInitializationStatus(ctx)).To(Equal(brclient.InProgress))
+	now = now.Add(time.Minute)
+	g.Expect(client.GetInitializationStatus(ctx)).To(Equal(brclient.Successful))
+	g.Expect(server.TriggeredValidationModes()).To(Equal([]string{"sanity", "full"}))
+
+	t.Log("should return scripted initialization statuses")
+	server.SetInitStatuses(InitStatusInProgress, InitStatusNew)
+	g.Expect(client.GetInitializationStatus(ctx)).To(Equal(brclient.InProgress))
+	g.Expect(client.GetInitializationStatus(ctx)).To(Equal(brclient.New))
+	g.Expect(client.GetInitializationStatus(ctx)).To(Equal(brclient.New))
+}
+
+func TestServerEtcdConfig(t *testing.T) {
+	g := NewWithT(t)
+	etcdConfigPath := filepath.Join(t.TempDir(), "etcd.conf.yaml")
+	server := NewServer(Script{EtcdConfigPath: etcdConfigPath})
+	client := startTestServer(t, server)
+	ctx := context.Background()
+
+	t.Log("should fail when the etcd config file does not exist")
+	_, err := client.GetEtcdConfig(ctx)
+	g.Expect(err).To(HaveOccurred())
+
+	t.Log("should serve the etcd config file")
+	g.Expect(os.WriteFile(etcdConfigPath, []byte("name: etcd-test"), 0600)).To(Succeed())
+	path, err := client.GetEtcdConfig(ctx)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(os.ReadFile(path)).To(Equal([]byte("name: etcd-test")))
+
+	t.Log("should serve the etcd config set explicitly")
+	server.SetEtcdConfig([]byte("name: etcd-other"))
+	path, err = client.GetEtcdConfig(ctx)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(os.ReadFile(path)).To(Equal([]byte("name: etcd-other")))
+
+	t.Log("should not serve an unchanged etcd config to conditional requests")
+	poller := client.(brclient.EtcdConfigPoller)
+	changed, err := poller.PollEtcdConfig(ctx)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(changed).To(BeFalse())
+	server.SetEtcdConfig([]byte("name: etcd-changed"))
+	changed, err = poller.PollEtcdConfig(ctx)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(changed).To(BeTrue())
+	g.Expect(os.ReadFile(path)).To(Equal([]byte("name: etcd-changed")))
+}
+
+func TestServerSnapshots(t *testing.T) {
+	g := NewWithT(t)
+	server := NewServer(Script{})
+	client := startTestServer(t, server)
+	ctx := context.Background()
+
+	t.Log("should serve no snapshots by default")
+	g.Expect(client.GetLatestSnapshots(ctx)).To(BeNil())
+
+	t.Log("should serve the scripted snapshots")
+	server.SetLatestSnapshots(&brclient.LatestSnapshots{FullSnapshot: &brclient.Snapshot{Kind: "Full", LastRevision: 10}})
+	latestSnapshots, err := client.GetLatestSnapshots(ctx)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(latestSnapshots.LastRevision()).To(Equal(int64(10)))
+
+	t.Log("should record triggered snapshots")
+	g.Expect(client.TriggerSnapshot(ctx, brclient.DeltaSnapshotKind)).To(BeNil())
+	server.SetSnapshot(&brclient.Snapshot{Kind: "Full", LastRevision: 20})
+	snapshot, err := client.TriggerSnapshot(ctx, brclient.FullSnapshotKind)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(snapshot.LastRevision).To(Equal(int64(20)))
+	g.Expect(server.TriggeredSnapshotKinds()).To(Equal([]string{"delta", "full"}))
+
+	t.Log("should not support churn reports by default")
+	report := brclient.ChurnReport{Revision: 20, RevisionsPerSecond: 0.5, Window: time.Minute, ObservedAt: time.Now()}
+	_, err = client.ReportChurn(ctx, report)
+	g.Expect(err).To(MatchError(brclient.ErrNotSupported))
+
+	t.Log("should record churn reports and return the scripted delta snapshot period")
+	server.SetDeltaSnapshotPeriod(20 * time.Second)
+	g.Expect(client.ReportChurn(ctx, report)).To(Equal(20 * time.Second))
+	g.Expect(server.ChurnReports()).To(HaveLen(1))
+	g.Expect(server.ChurnReports()[0].RevisionsPerSecond).To(Equal(0.5))
+	g.Expect(server.ChurnReports()[0].Window).To(Equal(time.Minute))
+}
+
+func TestServerEndpoints(t *testing.T) {
+	g := NewWithT(t)
+	server := NewServer(Script{Endpoints: map[string]EndpointScript{
+		"/initialization/status": {StatusCode: http.StatusServiceUnavailable, Failures: 2},
+		"/snapshot/latest":       {Delay: Duration{100 * time.Millisecond}},
+	}})
+	client := startTestServer(t, server)
+	ctx := context.Background()
+
+	t.Log("should fail the scripted number of requests")
+	for range 2 {
+		_, err := client.GetInitializationStatus(ctx)
+		g.Expect(err).To(HaveOccurred())
+	}
+	g.Expect(client.GetInitializationStatus(ctx)).To(Equal(brclient.New))
+	g.Expect(server.Requests("/initialization/status")).To(Equal(3))
+
+	t.Log("should delay responses")
+	start := time.Now()
+	g.Expect(client.GetLatestSnapshots(ctx)).To(BeNil())
+	g.Expect(time.Since(start)).To(BeNumerically(">=", 100*time.Millisecond))
+
+	t.Log("should fail all requests when the number of failures is not limited")
+	server.SetEndpoint("/config", EndpointScript{StatusCode: http.StatusInternalServerError})
+	for range 3 {
+		_, err := client.GetEtcdConfig(ctx)
+		g.Expect(err).To(HaveOccurred())
+	}
+}
+
+func startTestServer(t *testing.T, server *Server) brclient.BackupRestoreClient {
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+	return brclient.NewClient(httpServer.Client(), httpServer.URL, filepath.Join(t.TempDir(), "etcd.conf.yaml"))
+}
@@ -0,0 +1,123 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package faultinjection injects artificial faults into etcd-wrapper, e.g. latency of backup-restore or dropped peer
+// connections, so that orchestration layers driving etcd-wrapper can be tested against them. It is meant for
+// development and chaos testing only.
+package faultinjection
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// Faults are the faults injected into etcd-wrapper. The zero value injects no fault.
+type Faults struct {
+	// SidecarLatency is the latency added to every write onto a connection to backup-restore.
+	SidecarLatency time.Duration
+	// DropPeerConnections causes the embedded etcd to stop accepting connections from its peers.
+	DropPeerConnections bool
+	// ReadinessDelay is the time for which readiness is withheld after etcd has become ready.
+	ReadinessDelay time.Duration
+}
+
+// faultsJSON is the JSON representation of Faults, with durations formatted like `1.5s`.
+type faultsJSON struct {
+	SidecarLatency      string `json:"sidecarLatency,omitempty"`
+	DropPeerConnections bool   `json:"dropPeerConnections"`
+	ReadinessDelay      string `json:"readinessDelay,omitempty"`
+}
+
+// MarshalJSON marshals the Faults with durations formatted like `1.5s`.
+func (f Faults) MarshalJSON() ([]byte, error) {
+	out := faultsJSON{DropPeerConnections: f.DropPeerConnections}
+	if f.SidecarLatency > 0 {
+		out.SidecarLatency = f.SidecarLatency.String()
+	}
+	if f.ReadinessDelay > 0 {
+		out.ReadinessDelay = f.ReadinessDelay.String()
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON unmarshals the Faults from durations formatted like `1.5s`. Omitted faults are not injected.
+func (f *Faults) UnmarshalJSON(data []byte) error {
+	in := faultsJSON{}
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	faults := Faults{DropPeerConnections: in.DropPeerConnections}
+	var err error
+	if faults.SidecarLatency, err = parseDuration("sidecarLatency", in.SidecarLatency); err != nil {
+		return err
+	}
+	if faults.ReadinessDelay, err = parseDuration("readinessDelay", in.ReadinessDelay); err != nil {
+		return err
+	}
+	*f = faults
+	return nil
+}
+
+func parseDuration(field, value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", field, err)
+	}
+	if duration < 0 {
+		return 0, fmt.Errorf("invalid %s: must not be negative", field)
+	}
+	return duration, nil
+}
+
+// Injector holds the Faults currently injected. It is safe for concurrent use.
+type Injector struct {
+	mu     sync.RWMutex
+	faults Faults
+}
+
+// NewInjector creates an Injector which injects no fault.
+func NewInjector() *Injector {
+	return &Injector{}
+}
+
+// Faults returns the Faults currently injected.
+func (i *Injector) Faults() Faults {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return i.faults
+}
+
+// SetFaults replaces the Faults currently injected and returns the ones injected before.
+func (i *Injector) SetFaults(faults Faults) Faults {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	previous := i.faults
+	i.faults = faults
+	return previous
+}
+
+// WrapSidecarConn returns a connection to backup-restore which delays every write by the SidecarLatency injected at
+// the time of the write.
+func (i *Injector) WrapSidecarConn(conn net.Conn) net.Conn {
+	return &delayedConn{Conn: conn, injector: i}
+}
+
+// delayedConn is a net.Conn delaying every write by the injected SidecarLatency.
+type delayedConn struct {
+	net.Conn
+	injector *Injector
+}
+
+func (c *delayedConn) Write(b []byte) (int, error) {
+	if latency := c.injector.Faults().SidecarLatency; latency > 0 {
+		time.Sleep(latency)
+	}
+	return c.Conn.Write(b)
+}
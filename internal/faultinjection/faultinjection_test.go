@@ -0,0 +1,77 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package faultinjection
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestFaultsJSON(t *testing.T) {
+	table := []struct {
+		description    string
+		json           string
+		expectedFaults Faults
+		expectError    bool
+	}{
+		{"should unmarshal all faults", `{"sidecarLatency": "1.5s", "dropPeerConnections": true, "readinessDelay": "1m"}`, Faults{SidecarLatency: 1500 * time.Millisecond, DropPeerConnections: true, ReadinessDelay: time.Minute}, false},
+		{"should clear omitted faults", `{}`, Faults{}, false},
+		{"should fail for an invalid duration", `{"sidecarLatency": "soon"}`, Faults{}, true},
+		{"should fail for a negative duration", `{"readinessDelay": "-1s"}`, Faults{}, true},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		faults := Faults{}
+		err := json.Unmarshal([]byte(entry.json), &faults)
+		g.Expect(err != nil).To(Equal(entry.expectError))
+		g.Expect(faults).To(Equal(entry.expectedFaults))
+		if err != nil {
+			continue
+		}
+		marshalled, err := json.Marshal(faults)
+		g.Expect(err).ToNot(HaveOccurred())
+		roundTripped := Faults{}
+		g.Expect(json.Unmarshal(marshalled, &roundTripped)).To(Succeed())
+		g.Expect(roundTripped).To(Equal(entry.expectedFaults))
+	}
+}
+
+func TestSetFaults(t *testing.T) {
+	g := NewWithT(t)
+	injector := NewInjector()
+	g.Expect(injector.Faults()).To(Equal(Faults{}))
+	g.Expect(injector.SetFaults(Faults{DropPeerConnections: true})).To(Equal(Faults{}))
+	g.Expect(injector.SetFaults(Faults{})).To(Equal(Faults{DropPeerConnections: true}))
+}
+
+func TestWrapSidecarConn(t *testing.T) {
+	g := NewWithT(t)
+	injector := NewInjector()
+	client, server := net.Pipe()
+	defer func() { _ = server.Close() }()
+	conn := injector.WrapSidecarConn(client)
+	defer func() { _ = conn.Close() }()
+	go func() { _, _ = io.Copy(io.Discard, server) }()
+
+	t.Log("should not delay writes without injected latency")
+	start := time.Now()
+	_, err := conn.Write([]byte("request"))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(time.Since(start)).To(BeNumerically("<", 100*time.Millisecond))
+
+	t.Log("should delay writes by the injected latency")
+	injector.SetFaults(Faults{SidecarLatency: 200 * time.Millisecond})
+	start = time.Now()
+	_, err = conn.Write([]byte("request"))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(time.Since(start)).To(BeNumerically(">=", 200*time.Millisecond))
+}
@@ -0,0 +1,138 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package httpserver runs the HTTP servers of etcd-wrapper with timeouts, optional TLS and a graceful shutdown, so that
+// slow or stuck clients cannot exhaust the resources of etcd-wrapper and in-flight requests complete on termination.
+package httpserver
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultReadHeaderTimeout is the default time allowed to read the headers of a request.
+	DefaultReadHeaderTimeout = 5 * time.Second
+	// DefaultReadTimeout is the default time allowed to read a whole request.
+	DefaultReadTimeout = 10 * time.Second
+	// DefaultWriteTimeout is the default time allowed to write a response. Streaming handlers have to lift it via
+	// http.ResponseController.
+	DefaultWriteTimeout = 30 * time.Second
+	// DefaultIdleTimeout is the default time after which idle keep-alive connections are closed.
+	DefaultIdleTimeout = 2 * time.Minute
+	// DefaultShutdownTimeout is the default time to wait for in-flight requests on shutdown before connections are closed.
+	DefaultShutdownTimeout = 10 * time.Second
+)
+
+// Config is the configuration of a Server.
+type Config struct {
+	// Addr is the TCP address the server listens on, e.g. `:9095`.
+	Addr string
+	// CertFile and KeyFile are the paths of the certificate and key served by the server. TLS is disabled if CertFile is empty.
+	CertFile string
+	KeyFile  string
+	// ReadHeaderTimeout, ReadTimeout, WriteTimeout and IdleTimeout are the timeouts of the http.Server. Zero uses the
+	// respective default.
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	// ShutdownTimeout is the time to wait for in-flight requests on shutdown. Zero uses DefaultShutdownTimeout.
+	ShutdownTimeout time.Duration
+}
+
+// Server is an HTTP server which shuts down gracefully once the context it is serving with is cancelled or Shutdown
+// is called.
+type Server struct {
+	server          *http.Server
+	config          Config
+	shutdownOnce    sync.Once
+	shutdownErr     error
+	shutdownDone    chan struct{}
+	shutdownTimeout time.Duration
+}
+
+// New creates a Server which serves handler according to config.
+func New(config Config, handler http.Handler) *Server {
+	return &Server{
+		server: &http.Server{
+			Addr:              config.Addr,
+			Handler:           handler,
+			ReadHeaderTimeout: orDefault(config.ReadHeaderTimeout, DefaultReadHeaderTimeout),
+			ReadTimeout:       orDefault(config.ReadTimeout, DefaultReadTimeout),
+			WriteTimeout:      orDefault(config.WriteTimeout, DefaultWriteTimeout),
+			IdleTimeout:       orDefault(config.IdleTimeout, DefaultIdleTimeout),
+			TLSConfig:         &tls.Config{MinVersion: tls.VersionTLS12},
+		},
+		config:          config,
+		shutdownDone:    make(chan struct{}),
+		shutdownTimeout: orDefault(config.ShutdownTimeout, DefaultShutdownTimeout),
+	}
+}
+
+// TLSEnabled returns whether the Server serves TLS.
+func (s *Server) TLSEnabled() bool {
+	return s.config.CertFile != ""
+}
+
+// ListenAndServe listens on the configured address and serves until ctx is cancelled or Shutdown is called. It
+// returns nil once the Server has been shut down.
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	listener, err := net.Listen("tcp", s.config.Addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ctx, listener)
+}
+
+// Serve serves on listener until ctx is cancelled or Shutdown is called. It returns nil once the Server has been shut
+// down, i.e. after the in-flight requests have completed or the shutdown timeout has passed.
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	stopped := make(chan struct{})
+	defer close(stopped)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = s.Shutdown()
+		case <-stopped:
+		}
+	}()
+	var err error
+	if s.TLSEnabled() {
+		err = s.server.ServeTLS(listener, s.config.CertFile, s.config.KeyFile)
+	} else {
+		err = s.server.Serve(listener)
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		<-s.shutdownDone
+		return nil
+	}
+	return err
+}
+
+// Shutdown stops accepting new connections and waits for in-flight requests to complete for at most the shutdown
+// timeout, after which the remaining connections are closed. It is safe to call Shutdown multiple times.
+func (s *Server) Shutdown() error {
+	s.shutdownOnce.Do(func() {
+		defer close(s.shutdownDone)
+		ctx, cancelFunc := context.WithTimeout(context.Background(), s.shutdownTimeout)
+		defer cancelFunc()
+		if s.shutdownErr = s.server.Shutdown(ctx); errors.Is(s.shutdownErr, context.DeadlineExceeded) {
+			s.shutdownErr = errors.Join(s.shutdownErr, s.server.Close())
+		}
+	})
+	return s.shutdownErr
+}
+
+func orDefault(value, defaultValue time.Duration) time.Duration {
+	if value > 0 {
+		return value
+	}
+	return defaultValue
+}
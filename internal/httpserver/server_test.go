@@ -0,0 +1,169 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package httpserver
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/testutil"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestNew(t *testing.T) {
+	table := []struct {
+		description               string
+		config                    Config
+		expectedReadTimeout       time.Duration
+		expectedWriteTimeout      time.Duration
+		expectedIdleTimeout       time.Duration
+		expectedShutdownTimeout   time.Duration
+		expectedReadHeaderTimeout time.Duration
+	}{
+		{"should use the defaults for zero timeouts", Config{}, DefaultReadTimeout, DefaultWriteTimeout, DefaultIdleTimeout, DefaultShutdownTimeout, DefaultReadHeaderTimeout},
+		{"should use the configured timeouts", Config{ReadHeaderTimeout: time.Second, ReadTimeout: 2 * time.Second, WriteTimeout: 3 * time.Second, IdleTimeout: 4 * time.Second, ShutdownTimeout: 5 * time.Second}, 2 * time.Second, 3 * time.Second, 4 * time.Second, 5 * time.Second, time.Second},
+	}
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		server := New(entry.config, http.NotFoundHandler())
+		g.Expect(server.server.ReadHeaderTimeout).To(Equal(entry.expectedReadHeaderTimeout))
+		g.Expect(server.server.ReadTimeout).To(Equal(entry.expectedReadTimeout))
+		g.Expect(server.server.WriteTimeout).To(Equal(entry.expectedWriteTimeout))
+		g.Expect(server.server.IdleTimeout).To(Equal(entry.expectedIdleTimeout))
+		g.Expect(server.shutdownTimeout).To(Equal(entry.expectedShutdownTimeout))
+		g.Expect(server.TLSEnabled()).To(BeFalse())
+	}
+}
+
+func TestServeShutsDownGracefully(t *testing.T) {
+	g := NewWithT(t)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		close(started)
+		<-release
+		_, _ = w.Write([]byte("done"))
+	})
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	g.Expect(err).ToNot(HaveOccurred())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	server := New(Config{}, handler)
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Serve(ctx, listener)
+	}()
+
+	responses := make(chan string, 1)
+	go func() {
+		resp, err := http.Get("http://" + listener.Addr().String())
+		if err != nil {
+			responses <- err.Error()
+			return
+		}
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+		body, _ := io.ReadAll(resp.Body)
+		responses <- string(body)
+	}()
+	<-started
+
+	t.Log("should stop accepting connections once the context is cancelled")
+	cancel()
+	g.Eventually(func() error {
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		if err == nil {
+			_ = conn.Close()
+		}
+		return err
+	}, 5*time.Second, 10*time.Millisecond).Should(HaveOccurred())
+
+	t.Log("should complete in-flight requests before returning")
+	g.Consistently(served).ShouldNot(Receive())
+	close(release)
+	g.Eventually(responses, 5*time.Second).Should(Receive(Equal("done")))
+	g.Eventually(served, 5*time.Second).Should(Receive(BeNil()))
+	g.Expect(server.Shutdown()).To(Succeed())
+}
+
+func TestShutdownClosesConnectionsAfterTimeout(t *testing.T) {
+	g := NewWithT(t)
+	release := make(chan struct{})
+	defer close(release)
+	started := make(chan struct{})
+	handler := http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {
+		close(started)
+		<-release
+	})
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	g.Expect(err).ToNot(HaveOccurred())
+	server := New(Config{ShutdownTimeout: 100 * time.Millisecond}, handler)
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Serve(context.Background(), listener)
+	}()
+	go func() {
+		if resp, err := http.Get("http://" + listener.Addr().String()); err == nil {
+			_ = resp.Body.Close()
+		}
+	}()
+	<-started
+
+	t.Log("should close stuck connections once the shutdown timeout has passed")
+	g.Expect(server.Shutdown()).To(MatchError(context.DeadlineExceeded))
+	g.Eventually(served, 5*time.Second).Should(Receive(BeNil()))
+}
+
+func TestServeTLS(t *testing.T) {
+	g := NewWithT(t)
+	dir := t.TempDir()
+	tlsResourceCreator, err := testutil.NewTLSResourceCreator()
+	g.Expect(err).ToNot(HaveOccurred())
+	ca, err := tlsResourceCreator.CreateCACertAndKey()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(ca.EncodeAndWrite(dir, "ca.crt", "ca.key")).To(Succeed())
+	server, err := tlsResourceCreator.CreateServerCertAndKey(net.ParseIP("127.0.0.1"))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(server.EncodeAndWrite(dir, "server.crt", "server.key")).To(Succeed())
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	g.Expect(err).ToNot(HaveOccurred())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tlsServer := New(Config{CertFile: filepath.Join(dir, "server.crt"), KeyFile: filepath.Join(dir, "server.key")}, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	g.Expect(tlsServer.TLSEnabled()).To(BeTrue())
+	go func() {
+		_ = tlsServer.Serve(ctx, listener)
+	}()
+
+	caPEM, err := os.ReadFile(filepath.Join(dir, "ca.crt"))
+	g.Expect(err).ToNot(HaveOccurred())
+	pool := x509.NewCertPool()
+	g.Expect(pool.AppendCertsFromPEM(caPEM)).To(BeTrue())
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}}}
+
+	t.Log("should serve TLS with the configured certificate")
+	resp, err := client.Get("https://" + listener.Addr().String())
+	g.Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	body, err := io.ReadAll(resp.Body)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(string(body)).To(Equal("ok"))
+}
@@ -0,0 +1,233 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package k8slease elects a leader among the etcd-wrappers of an etcd cluster using a coordination.k8s.io/v1 Lease.
+// It talks to the Kubernetes API server directly with the credentials of the service account of the pod, since only
+// the Lease resource is needed.
+package k8slease
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/util"
+)
+
+const (
+	// serviceAccountDir is the directory into which Kubernetes mounts the credentials of the service account of the pod.
+	serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+	// microTimeFormat is the format of the timestamps of a Lease.
+	microTimeFormat = "2006-01-02T15:04:05.000000Z07:00"
+	// requestTimeout is the time after which a request to the Kubernetes API server fails.
+	requestTimeout = 10 * time.Second
+)
+
+var (
+	// ErrNotInCluster is returned by NewInClusterClient if etcd-wrapper does not run in a Kubernetes pod.
+	ErrNotInCluster = errors.New("not running in a Kubernetes cluster")
+	// ErrConflict is returned when a Lease has been modified since it has been read.
+	ErrConflict = errors.New("lease has been modified concurrently")
+	// errNotFound is returned by do if the requested resource does not exist.
+	errNotFound = errors.New("not found")
+)
+
+// MicroTime is a timestamp of a Lease, which is serialized with microsecond precision.
+type MicroTime struct {
+	time.Time
+}
+
+// MarshalJSON serializes the timestamp with microsecond precision, or as null if it is zero.
+func (t MicroTime) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(t.UTC().Format(microTimeFormat))
+}
+
+// UnmarshalJSON parses a timestamp serialized with microsecond precision.
+func (t *MicroTime) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		t.Time = time.Time{}
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	parsed, err := time.Parse(microTimeFormat, s)
+	if err != nil {
+		// timestamps written by other clients may have a lower precision.
+		if parsed, err = time.Parse(time.RFC3339Nano, s); err != nil {
+			return err
+		}
+	}
+	t.Time = parsed
+	return nil
+}
+
+// Lease is the subset of a coordination.k8s.io/v1 Lease which is used for leader election.
+type Lease struct {
+	APIVersion string        `json:"apiVersion"`
+	Kind       string        `json:"kind"`
+	Metadata   LeaseMetadata `json:"metadata"`
+	Spec       LeaseSpec     `json:"spec"`
+}
+
+// LeaseMetadata is the metadata of a Lease.
+type LeaseMetadata struct {
+	Name            string `json:"name"`
+	Namespace       string `json:"namespace,omitempty"`
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+}
+
+// LeaseSpec is the specification of a Lease.
+type LeaseSpec struct {
+	// HolderIdentity is the identity of the current leader. It is empty if the lease has been released.
+	HolderIdentity *string `json:"holderIdentity,omitempty"`
+	// LeaseDurationSeconds is the time for which the leader holds the lease after its last renewal.
+	LeaseDurationSeconds *int32 `json:"leaseDurationSeconds,omitempty"`
+	// AcquireTime is the time at which the current leader has acquired the lease.
+	AcquireTime *MicroTime `json:"acquireTime,omitempty"`
+	// RenewTime is the time at which the current leader has last renewed the lease.
+	RenewTime *MicroTime `json:"renewTime,omitempty"`
+	// LeaseTransitions is the number of times the lease has changed its holder.
+	LeaseTransitions *int32 `json:"leaseTransitions,omitempty"`
+}
+
+// Client reads and writes Leases through the Kubernetes API server.
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+	tokenPath  string
+}
+
+// NewInClusterClient creates a Client which authenticates with the token of the service account of the pod. It
+// returns ErrNotInCluster if etcd-wrapper does not run in a Kubernetes pod.
+func NewInClusterClient(proxyEnvDisabled bool) (*Client, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, ErrNotInCluster
+	}
+	caCert, err := os.ReadFile(serviceAccountDir + "/ca.crt")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA certificate of the Kubernetes API server: %w", err)
+	}
+	caPool := x509.NewCertPool()
+	if !caPool.AppendCertsFromPEM(caCert) {
+		return nil, fmt.Errorf("failed to parse CA certificate of the Kubernetes API server")
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = util.ProxyFunc(proxyEnvDisabled)
+	transport.TLSClientConfig = &tls.Config{RootCAs: caPool, MinVersion: tls.VersionTLS12}
+	return NewClient("https://"+net.JoinHostPort(host, port), serviceAccountDir+"/token", &http.Client{Transport: transport}), nil
+}
+
+// NewClient creates a Client which sends requests to the Kubernetes API server at baseURL, authenticated with the
+// bearer token read from tokenPath on every request, since projected service account tokens are rotated.
+func NewClient(baseURL, tokenPath string, httpClient *http.Client) *Client {
+	return &Client{httpClient: httpClient, baseURL: strings.TrimSuffix(baseURL, "/"), tokenPath: tokenPath}
+}
+
+// InClusterNamespace returns the namespace of the pod, as mounted with the credentials of its service account.
+func InClusterNamespace() (string, error) {
+	namespace, err := os.ReadFile(serviceAccountDir + "/namespace")
+	if err != nil {
+		return "", fmt.Errorf("failed to read namespace of the pod: %w", err)
+	}
+	return strings.TrimSpace(string(namespace)), nil
+}
+
+// Get returns the Lease with the given name, or nil if it does not exist.
+func (c *Client) Get(ctx context.Context, namespace, name string) (*Lease, error) {
+	lease := &Lease{}
+	if err := c.do(ctx, http.MethodGet, c.leaseURL(namespace, name), nil, lease); err != nil {
+		if errors.Is(err, errNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return lease, nil
+}
+
+// Create creates the Lease. It returns ErrConflict if the Lease already exists.
+func (c *Client) Create(ctx context.Context, lease *Lease) (*Lease, error) {
+	lease.APIVersion, lease.Kind = "coordination.k8s.io/v1", "Lease"
+	created := &Lease{}
+	if err := c.do(ctx, http.MethodPost, c.leaseURL(lease.Metadata.Namespace, ""), lease, created); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// Update updates the Lease. It returns ErrConflict if the Lease has been modified since it has been read.
+func (c *Client) Update(ctx context.Context, lease *Lease) (*Lease, error) {
+	lease.APIVersion, lease.Kind = "coordination.k8s.io/v1", "Lease"
+	updated := &Lease{}
+	if err := c.do(ctx, http.MethodPut, c.leaseURL(lease.Metadata.Namespace, lease.Metadata.Name), lease, updated); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+func (c *Client) leaseURL(namespace, name string) string {
+	u := c.baseURL + "/apis/coordination.k8s.io/v1/namespaces/" + url.PathEscape(namespace) + "/leases"
+	if name != "" {
+		u += "/" + url.PathEscape(name)
+	}
+	return u
+}
+
+func (c *Client) do(ctx context.Context, method, target string, body, result any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	ctx, cancelFunc := context.WithTimeout(ctx, requestTimeout)
+	defer cancelFunc()
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	token, err := os.ReadFile(c.tokenPath)
+	if err != nil {
+		return fmt.Errorf("failed to read service account token: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	response, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer util.CloseResponseBody(response)
+
+	switch {
+	case response.StatusCode == http.StatusNotFound:
+		return errNotFound
+	case response.StatusCode == http.StatusConflict:
+		return ErrConflict
+	case response.StatusCode < 200 || response.StatusCode > 299:
+		message, _ := io.ReadAll(io.LimitReader(response.Body, 1024))
+		return fmt.Errorf("kubernetes API server returned %s for %s %s: %s", response.Status, method, target, strings.TrimSpace(string(message)))
+	}
+	return json.NewDecoder(response.Body).Decode(result)
+}
@@ -0,0 +1,174 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package k8slease
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Elector elects a leader among the holders of a Lease. A candidate holds the Lease by renewing it, and takes it over
+// once the current holder has not renewed it for the lease duration.
+type Elector struct {
+	client        *Client
+	namespace     string
+	name          string
+	identity      string
+	leaseDuration time.Duration
+	renewInterval time.Duration
+	onChange      func(leader bool)
+	logger        *zap.Logger
+	leader        atomic.Bool
+}
+
+// NewElector creates an Elector which competes as identity for the Lease with the given namespace and name. The
+// elected leader renews the Lease every renewInterval and loses it if it has not renewed it for leaseDuration.
+// onChange is called whenever this candidate becomes or stops being the leader.
+func NewElector(client *Client, namespace, name, identity string, leaseDuration, renewInterval time.Duration, onChange func(leader bool), logger *zap.Logger) *Elector {
+	return &Elector{
+		client:        client,
+		namespace:     namespace,
+		name:          name,
+		identity:      identity,
+		leaseDuration: leaseDuration,
+		renewInterval: renewInterval,
+		onChange:      onChange,
+		logger:        logger,
+	}
+}
+
+// IsLeader returns true if this candidate currently holds the Lease.
+func (e *Elector) IsLeader() bool {
+	return e.leader.Load()
+}
+
+// Run tries to acquire or renew the Lease every renew interval. It returns when ctx is cancelled, releasing the Lease
+// if it is held, so that another candidate can take over without waiting for the lease duration.
+func (e *Elector) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.renewInterval)
+	defer ticker.Stop()
+
+	var renewedAt time.Time
+	for {
+		if err := e.tryAcquireOrRenew(ctx, time.Now()); err != nil {
+			if !errors.Is(err, ErrConflict) {
+				e.logger.Error("failed to acquire or renew leader lease", zap.String("lease", e.namespace+"/"+e.name), zap.Error(err))
+			}
+			// a leader which cannot renew the lease must stop acting as leader before another candidate takes over.
+			if e.IsLeader() && time.Since(renewedAt) >= e.leaseDuration-e.renewInterval {
+				e.setLeader(false)
+			}
+		} else if e.IsLeader() {
+			renewedAt = time.Now()
+		}
+		select {
+		case <-ctx.Done():
+			e.release()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// tryAcquireOrRenew acquires the Lease if it does not exist, has been released or has expired, and renews it if it is
+// held by this candidate.
+func (e *Elector) tryAcquireOrRenew(ctx context.Context, now time.Time) error {
+	lease, err := e.client.Get(ctx, e.namespace, e.name)
+	if err != nil {
+		return err
+	}
+	durationSeconds := int32(e.leaseDuration.Seconds())
+	if lease == nil {
+		lease = &Lease{
+			Metadata: LeaseMetadata{Name: e.name, Namespace: e.namespace},
+			Spec: LeaseSpec{
+				HolderIdentity:       &e.identity,
+				LeaseDurationSeconds: &durationSeconds,
+				AcquireTime:          &MicroTime{now},
+				RenewTime:            &MicroTime{now},
+				LeaseTransitions:     new(int32),
+			},
+		}
+		if _, err = e.client.Create(ctx, lease); err != nil {
+			return err
+		}
+		e.setLeader(true)
+		return nil
+	}
+
+	holder := ""
+	if lease.Spec.HolderIdentity != nil {
+		holder = *lease.Spec.HolderIdentity
+	}
+	if holder != e.identity {
+		if holder != "" && !expired(lease, now) {
+			e.setLeader(false)
+			return nil
+		}
+		transitions := int32(0)
+		if lease.Spec.LeaseTransitions != nil {
+			transitions = *lease.Spec.LeaseTransitions
+		}
+		if holder != "" {
+			transitions++
+		}
+		lease.Spec.HolderIdentity = &e.identity
+		lease.Spec.AcquireTime = &MicroTime{now}
+		lease.Spec.LeaseTransitions = &transitions
+	}
+	lease.Spec.LeaseDurationSeconds = &durationSeconds
+	lease.Spec.RenewTime = &MicroTime{now}
+	if _, err = e.client.Update(ctx, lease); err != nil {
+		return err
+	}
+	e.setLeader(true)
+	return nil
+}
+
+// release gives up the Lease if it is held by this candidate.
+func (e *Elector) release() {
+	if !e.IsLeader() {
+		return
+	}
+	e.setLeader(false)
+	ctx, cancelFunc := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancelFunc()
+	lease, err := e.client.Get(ctx, e.namespace, e.name)
+	if err != nil || lease == nil || lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != e.identity {
+		return
+	}
+	lease.Spec.HolderIdentity = nil
+	lease.Spec.AcquireTime = nil
+	lease.Spec.RenewTime = nil
+	if _, err = e.client.Update(ctx, lease); err != nil {
+		e.logger.Warn("failed to release leader lease, it is taken over once it expires", zap.String("lease", e.namespace+"/"+e.name), zap.Error(err))
+	}
+}
+
+func (e *Elector) setLeader(leader bool) {
+	if e.leader.Swap(leader) == leader {
+		return
+	}
+	if leader {
+		e.logger.Info("became leader", zap.String("lease", e.namespace+"/"+e.name), zap.String("identity", e.identity))
+	} else {
+		e.logger.Info("stopped being leader", zap.String("lease", e.namespace+"/"+e.name), zap.String("identity", e.identity))
+	}
+	if e.onChange != nil {
+		e.onChange(leader)
+	}
+}
+
+// expired returns true if the holder of the lease has not renewed it within its lease duration.
+func expired(lease *Lease, now time.Time) bool {
+	if lease.Spec.RenewTime == nil || lease.Spec.LeaseDurationSeconds == nil {
+		return true
+	}
+	return now.After(lease.Spec.RenewTime.Add(time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second))
+}
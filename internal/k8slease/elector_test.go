@@ -0,0 +1,191 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package k8slease
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap/zaptest"
+)
+
+// fakeLeaseAPI serves the Lease API of the Kubernetes API server from memory.
+type fakeLeaseAPI struct {
+	mu              sync.Mutex
+	leases          map[string]*Lease
+	resourceVersion int
+}
+
+func (f *fakeLeaseAPI) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if req.Header.Get("Authorization") != "Bearer token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	name := req.URL.Path[strings.LastIndex(req.URL.Path, "/")+1:]
+	var body Lease
+	if req.Body != nil {
+		_ = json.NewDecoder(req.Body).Decode(&body)
+	}
+	switch req.Method {
+	case http.MethodGet:
+		lease, ok := f.leases[name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(lease)
+	case http.MethodPost:
+		if _, ok := f.leases[body.Metadata.Name]; ok {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		f.store(w, &body)
+	case http.MethodPut:
+		if lease, ok := f.leases[name]; !ok || lease.Metadata.ResourceVersion != body.Metadata.ResourceVersion {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		f.store(w, &body)
+	}
+}
+
+func (f *fakeLeaseAPI) store(w http.ResponseWriter, lease *Lease) {
+	f.resourceVersion++
+	lease.Metadata.ResourceVersion = strconv.Itoa(f.resourceVersion)
+	f.leases[lease.Metadata.Name] = lease
+	_ = json.NewEncoder(w).Encode(lease)
+}
+
+func (f *fakeLeaseAPI) holder(name string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if lease, ok := f.leases[name]; ok && lease.Spec.HolderIdentity != nil {
+		return *lease.Spec.HolderIdentity
+	}
+	return ""
+}
+
+func newFakeClient(t *testing.T) (*Client, *fakeLeaseAPI) {
+	api := &fakeLeaseAPI{leases: make(map[string]*Lease)}
+	server := httptest.NewServer(api)
+	t.Cleanup(server.Close)
+	tokenPath := filepath.Join(t.TempDir(), "token")
+	NewWithT(t).Expect(os.WriteFile(tokenPath, []byte("token\n"), 0600)).To(Succeed())
+	return NewClient(server.URL, tokenPath, server.Client()), api
+}
+
+func TestTryAcquireOrRenew(t *testing.T) {
+	now := time.Date(2024, time.March, 15, 12, 0, 0, 0, time.UTC)
+	table := []struct {
+		description       string
+		holder            string
+		renewedAgo        time.Duration
+		expectLeader      bool
+		expectHolder      string
+		expectTransitions int32
+	}{
+		{"should create the lease if it does not exist", "", -1, true, "a", 0},
+		{"should renew the lease held by itself", "a", time.Second, true, "a", 3},
+		{"should not take over the lease held by another candidate", "b", time.Second, false, "b", 3},
+		{"should take over the lease once it has expired", "b", 20 * time.Second, true, "a", 4},
+		{"should take over a released lease", "", 0, true, "a", 3},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		client, api := newFakeClient(t)
+		if entry.renewedAgo >= 0 {
+			duration, transitions := int32(15), int32(3)
+			lease := &Lease{Metadata: LeaseMetadata{Name: "lease", Namespace: "default", ResourceVersion: "1"}, Spec: LeaseSpec{
+				LeaseDurationSeconds: &duration,
+				RenewTime:            &MicroTime{now.Add(-entry.renewedAgo)},
+				LeaseTransitions:     &transitions,
+			}}
+			if entry.holder != "" {
+				lease.Spec.HolderIdentity = &entry.holder
+			}
+			api.leases["lease"] = lease
+		}
+		elector := NewElector(client, "default", "lease", "a", 15*time.Second, 5*time.Second, nil, zaptest.NewLogger(t))
+		g.Expect(elector.tryAcquireOrRenew(context.Background(), now)).To(Succeed())
+		g.Expect(elector.IsLeader()).To(Equal(entry.expectLeader))
+		g.Expect(api.holder("lease")).To(Equal(entry.expectHolder))
+		g.Expect(*api.leases["lease"].Spec.LeaseTransitions).To(Equal(entry.expectTransitions))
+	}
+}
+
+func TestTryAcquireOrRenewConflict(t *testing.T) {
+	g := NewWithT(t)
+	client, api := newFakeClient(t)
+	elector := NewElector(client, "default", "lease", "a", 15*time.Second, 5*time.Second, nil, zaptest.NewLogger(t))
+	g.Expect(elector.tryAcquireOrRenew(context.Background(), time.Now())).To(Succeed())
+	// another candidate modifies the lease between the read and the write of the next renewal.
+	client.httpClient.Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if req.Method == http.MethodPut {
+			api.mu.Lock()
+			api.leases["lease"].Metadata.ResourceVersion = "other"
+			api.mu.Unlock()
+		}
+		return http.DefaultTransport.RoundTrip(req)
+	})
+	g.Expect(elector.tryAcquireOrRenew(context.Background(), time.Now())).To(MatchError(ErrConflict))
+}
+
+func TestElectorRun(t *testing.T) {
+	g := NewWithT(t)
+	client, api := newFakeClient(t)
+	var changes []bool
+	var mu sync.Mutex
+	elector := NewElector(client, "default", "lease", "a", 15*time.Second, 10*time.Millisecond, func(leader bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		changes = append(changes, leader)
+	}, zaptest.NewLogger(t))
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		elector.Run(ctx)
+	}()
+	g.Eventually(elector.IsLeader).Should(BeTrue())
+	cancelFunc()
+	<-done
+	g.Expect(elector.IsLeader()).To(BeFalse())
+	// the lease is released on shutdown, so that another candidate can take over immediately.
+	g.Expect(api.holder("lease")).To(BeEmpty())
+	mu.Lock()
+	defer mu.Unlock()
+	g.Expect(changes).To(Equal([]bool{true, false}))
+}
+
+func TestMicroTime(t *testing.T) {
+	g := NewWithT(t)
+	var parsed MicroTime
+	g.Expect(json.Unmarshal([]byte(`"2024-03-15T12:00:00.123456Z"`), &parsed)).To(Succeed())
+	g.Expect(parsed.Equal(time.Date(2024, time.March, 15, 12, 0, 0, 123456000, time.UTC))).To(BeTrue())
+	g.Expect(json.Unmarshal([]byte(`"2024-03-15T12:00:00Z"`), &parsed)).To(Succeed())
+	data, err := json.Marshal(parsed)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(string(data)).To(Equal(`"2024-03-15T12:00:00.000000Z"`))
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
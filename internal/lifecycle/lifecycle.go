@@ -0,0 +1,132 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package lifecycle starts the components of etcd-wrapper in order and stops them in reverse order, so that a component
+// is only stopped once every component started after it, and possibly depending on it, has been stopped.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DefaultStopTimeout is the default time to wait for a component to stop.
+const DefaultStopTimeout = 10 * time.Second
+
+// ErrStopped is returned by Manager.Start once the Manager has been stopped.
+var ErrStopped = errors.New("lifecycle manager has been stopped")
+
+// Component is a part of etcd-wrapper with a lifecycle, e.g. a server or a set of monitors.
+type Component struct {
+	// Name identifies the component in logs and errors.
+	Name string
+	// Start starts the component and must not block. ctx carries the values of the context of the Manager but is only
+	// cancelled once the component is stopped, so that the component outlives the components started after it. Start
+	// is optional.
+	Start func(ctx context.Context) error
+	// Stop stops the component and blocks till it has stopped or ctx is done. Stop is optional.
+	Stop func(ctx context.Context) error
+	// StopTimeout is the time to wait for Stop to return. Zero uses DefaultStopTimeout.
+	StopTimeout time.Duration
+}
+
+// startedComponent is a Component which has been started along with the function cancelling its context.
+type startedComponent struct {
+	Component
+	cancelFn context.CancelFunc
+}
+
+// Manager starts Components in order and stops the started Components in reverse order.
+type Manager struct {
+	ctx     context.Context
+	logger  *zap.Logger
+	mu      sync.Mutex
+	started []startedComponent
+	stopped bool
+}
+
+// NewManager creates a Manager whose Components receive contexts carrying the values of ctx. Cancelling ctx does not
+// stop the Components, Stop has to be called for that.
+func NewManager(ctx context.Context, logger *zap.Logger) *Manager {
+	return &Manager{
+		ctx:    context.WithoutCancel(ctx),
+		logger: logger,
+	}
+}
+
+// Start starts the passed Components in order. It returns at the first Component failing to start, the Components
+// started until then are stopped by Stop.
+func (m *Manager) Start(components ...Component) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, component := range components {
+		if m.stopped {
+			return ErrStopped
+		}
+		ctx, cancelFn := context.WithCancel(m.ctx)
+		if component.Start != nil {
+			if err := component.Start(ctx); err != nil {
+				cancelFn()
+				return fmt.Errorf("failed to start %s: %w", component.Name, err)
+			}
+		}
+		m.started = append(m.started, startedComponent{Component: component, cancelFn: cancelFn})
+		m.logger.Debug("started component", zap.String("component", component.Name))
+	}
+	return nil
+}
+
+// Stop stops the started Components in reverse order. A Component not stopping within its stop timeout is abandoned
+// and the next Component is stopped. The errors of all Components are returned. Calling Stop more than once has no
+// effect.
+func (m *Manager) Stop() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.stopped {
+		return nil
+	}
+	m.stopped = true
+	var errs []error
+	for i := len(m.started) - 1; i >= 0; i-- {
+		if err := m.stop(m.started[i]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	m.started = nil
+	return errors.Join(errs...)
+}
+
+// stop stops component and cancels its context.
+func (m *Manager) stop(component startedComponent) error {
+	defer component.cancelFn()
+	if component.Stop == nil {
+		return nil
+	}
+	timeout := component.StopTimeout
+	if timeout <= 0 {
+		timeout = DefaultStopTimeout
+	}
+	ctx, cancelFn := context.WithTimeout(m.ctx, timeout)
+	defer cancelFn()
+	start := time.Now()
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- component.Stop(ctx)
+	}()
+	select {
+	case err := <-stopped:
+		if err != nil {
+			return fmt.Errorf("failed to stop %s: %w", component.Name, err)
+		}
+		m.logger.Debug("stopped component", zap.String("component", component.Name), zap.Duration("duration", time.Since(start)))
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%s did not stop within %s", component.Name, timeout)
+	}
+}
@@ -0,0 +1,107 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap/zaptest"
+)
+
+type testKey struct{}
+
+func TestManager(t *testing.T) {
+	table := []struct {
+		description      string
+		failingStart     string
+		failingStop      string
+		stuckStop        string
+		expectedStartErr error
+		expectedEvents   []string
+		expectedStopErr  string
+	}{
+		{"should start components in order and stop them in reverse order", "", "", "", nil, []string{"start a", "start b", "start c", "stop c", "stop b", "stop a"}, ""},
+		{"should only stop the components started before a component failed to start", "b", "", "", errStart, []string{"start a", "start b", "stop a"}, ""},
+		{"should stop the remaining components if a component fails to stop", "", "b", "", nil, []string{"start a", "start b", "start c", "stop c", "stop b", "stop a"}, "failed to stop b: stop failed"},
+		{"should abandon a component which does not stop in time", "", "", "b", nil, []string{"start a", "start b", "start c", "stop c", "stop b", "stop a"}, "b did not stop within 50ms"},
+	}
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		parent, cancel := context.WithCancel(context.WithValue(context.Background(), testKey{}, "value"))
+		var (
+			eventsMu sync.Mutex
+			events   []string
+		)
+		record := func(event string) {
+			eventsMu.Lock()
+			defer eventsMu.Unlock()
+			events = append(events, event)
+		}
+		contexts := map[string]context.Context{}
+		component := func(name string) Component {
+			return Component{
+				Name: name,
+				Start: func(ctx context.Context) error {
+					record("start " + name)
+					contexts[name] = ctx
+					if name == entry.failingStart {
+						return errStart
+					}
+					return nil
+				},
+				Stop: func(ctx context.Context) error {
+					record("stop " + name)
+					if name == entry.stuckStop {
+						<-ctx.Done()
+						return nil
+					}
+					if name == entry.failingStop {
+						return errors.New("stop failed")
+					}
+					return nil
+				},
+				StopTimeout: 50 * time.Millisecond,
+			}
+		}
+		manager := NewManager(parent, zaptest.NewLogger(t))
+		err := manager.Start(component("a"), component("b"), component("c"))
+		if entry.expectedStartErr != nil {
+			g.Expect(err).To(MatchError(entry.expectedStartErr))
+		} else {
+			g.Expect(err).ToNot(HaveOccurred())
+		}
+
+		cancel()
+		for _, ctx := range contexts {
+			g.Expect(ctx.Value(testKey{})).To(Equal("value"))
+			if entry.failingStart == "" {
+				g.Expect(ctx.Err()).ToNot(HaveOccurred())
+			}
+		}
+
+		err = manager.Stop()
+		if entry.expectedStopErr == "" {
+			g.Expect(err).ToNot(HaveOccurred())
+		} else {
+			g.Expect(err).To(MatchError(entry.expectedStopErr))
+		}
+		g.Expect(events).To(Equal(entry.expectedEvents))
+		for _, ctx := range contexts {
+			g.Expect(ctx.Err()).To(MatchError(context.Canceled))
+		}
+
+		g.Expect(manager.Stop()).To(Succeed())
+		g.Expect(manager.Start(component("d"))).To(MatchError(ErrStopped))
+		g.Expect(events).To(Equal(entry.expectedEvents))
+	}
+}
+
+var errStart = errors.New("start failed")
@@ -0,0 +1,153 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package logsampling aggregates repetitive warnings and errors, e.g. backup-restore being unreachable on every retry
+// during an outage, into periodic summary entries, so that long incidents do not flood the log.
+package logsampling
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/metrics"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Sampler writes the first of repetitive log entries of level warn or error and suppresses the repetitions within the
+// following interval. Once the interval has passed, a summary entry with the number of suppressed repetitions is
+// written. Entries are repetitions of each other if they have the same level, logger name and message, regardless of
+// their fields. It is safe for concurrent use.
+type Sampler struct {
+	interval time.Duration
+	now      func() time.Time
+	mu       sync.Mutex
+	windows  map[entryKey]*window
+}
+
+// entryKey identifies repetitive log entries.
+type entryKey struct {
+	level      zapcore.Level
+	loggerName string
+	message    string
+}
+
+// window holds the repetitions of a log entry suppressed since start.
+type window struct {
+	start      time.Time
+	suppressed int
+	// core, entry and fields are the ones of the last suppressed repetition, from which the summary is written.
+	core   zapcore.Core
+	entry  zapcore.Entry
+	fields []zapcore.Field
+}
+
+// NewSampler creates a Sampler which suppresses repetitions within interval.
+func NewSampler(interval time.Duration) *Sampler {
+	return &Sampler{interval: interval, now: time.Now, windows: make(map[entryKey]*window)}
+}
+
+// Wrap returns a logger which writes all entries logged through it via the Sampler.
+func (s *Sampler) Wrap(logger *zap.Logger) *zap.Logger {
+	return logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return &samplingCore{Core: core, sampler: s}
+	}))
+}
+
+// Run writes the summaries of windows whose interval has passed every interval until ctx is done, so that the
+// repetitions of an entry are summarized even if the entry is not logged anymore.
+func (s *Sampler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			s.flush(true)
+			return
+		case <-ticker.C:
+			s.flush(false)
+		}
+	}
+}
+
+// admit returns whether entry is to be written. If a window has passed, its summary is written first.
+func (s *Sampler) admit(core zapcore.Core, entry zapcore.Entry, fields []zapcore.Field) bool {
+	key := entryKey{level: entry.Level, loggerName: entry.LoggerName, message: entry.Message}
+	now := s.now()
+	s.mu.Lock()
+	w, ok := s.windows[key]
+	if !ok {
+		s.windows[key] = &window{start: now}
+		s.mu.Unlock()
+		return true
+	}
+	if now.Sub(w.start) < s.interval {
+		w.suppressed++
+		w.core, w.entry, w.fields = core, entry, fields
+		s.mu.Unlock()
+		metrics.LogEntriesSuppressedTotal.WithLabelValues(entry.Level.String()).Inc()
+		return false
+	}
+	expired := *w
+	s.windows[key] = &window{start: now}
+	s.mu.Unlock()
+	s.writeSummary(&expired)
+	return true
+}
+
+// flush writes the summaries of all windows whose interval has passed, or of all windows if all is set, and forgets
+// them, so that the next repetition is written again.
+func (s *Sampler) flush(all bool) {
+	now := s.now()
+	var expired []*window
+	s.mu.Lock()
+	for key, w := range s.windows {
+		if all || now.Sub(w.start) >= s.interval {
+			expired = append(expired, w)
+			delete(s.windows, key)
+		}
+	}
+	s.mu.Unlock()
+	for _, w := range expired {
+		s.writeSummary(w)
+	}
+}
+
+// writeSummary writes the last suppressed repetition of the window together with the number of suppressed
+// repetitions, if any.
+func (s *Sampler) writeSummary(w *window) {
+	if w.suppressed == 0 {
+		return
+	}
+	entry := w.entry
+	entry.Time = s.now()
+	fields := append(w.fields[:len(w.fields):len(w.fields)], zap.Int("suppressedRepetitions", w.suppressed), zap.Duration("samplingInterval", s.interval))
+	_ = w.core.Write(entry, fields)
+}
+
+// samplingCore is a zapcore.Core which writes warnings and errors via a Sampler.
+type samplingCore struct {
+	zapcore.Core
+	sampler *Sampler
+}
+
+func (c *samplingCore) With(fields []zapcore.Field) zapcore.Core {
+	return &samplingCore{Core: c.Core.With(fields), sampler: c.sampler}
+}
+
+func (c *samplingCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *samplingCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	if entry.Level < zapcore.WarnLevel || entry.Level > zapcore.ErrorLevel || c.sampler.admit(c.Core, entry, fields) {
+		return c.Core.Write(entry, fields)
+	}
+	return nil
+}
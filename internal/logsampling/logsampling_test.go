@@ -0,0 +1,94 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package logsampling
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestSampler(t *testing.T) {
+	g := NewWithT(t)
+	now := time.Now()
+	sampler := NewSampler(time.Minute)
+	sampler.now = func() time.Time { return now }
+	output := &bytes.Buffer{}
+	logger := sampler.Wrap(newLogger(output))
+
+	t.Log("should write the first entry and suppress its repetitions within the interval")
+	for range 5 {
+		logger.Error("error while fetching initialization status", zap.String("attempt", "any"))
+	}
+	logger.Warn("another warning")
+	logger.Info("info entries are never sampled")
+	logger.Info("info entries are never sampled")
+	entries := readEntries(g, output)
+	g.Expect(entries).To(HaveLen(4))
+	g.Expect(entries[0]).To(HaveKeyWithValue("msg", "error while fetching initialization status"))
+	g.Expect(entries[0]).ToNot(HaveKey("suppressedRepetitions"))
+	g.Expect(entries[1]).To(HaveKeyWithValue("msg", "another warning"))
+
+	t.Log("should summarize the suppressed repetitions once the interval has passed")
+	now = now.Add(time.Minute)
+	sampler.flush(false)
+	entries = readEntries(g, output)
+	g.Expect(entries).To(HaveLen(1))
+	g.Expect(entries[0]).To(HaveKeyWithValue("msg", "error while fetching initialization status"))
+	g.Expect(entries[0]).To(HaveKeyWithValue("level", "error"))
+	g.Expect(entries[0]).To(HaveKeyWithValue("attempt", "any"))
+	g.Expect(entries[0]).To(HaveKeyWithValue("suppressedRepetitions", BeNumerically("==", 4)))
+	g.Expect(entries[0]).To(HaveKeyWithValue("samplingInterval", "1m0s"))
+
+	t.Log("should write the next repetition after the summary")
+	logger.Error("error while fetching initialization status")
+	g.Expect(readEntries(g, output)).To(HaveLen(1))
+
+	t.Log("should summarize suppressed repetitions before writing the first entry after the interval")
+	logger.Error("error while fetching initialization status")
+	now = now.Add(2 * time.Minute)
+	logger.Error("error while fetching initialization status")
+	entries = readEntries(g, output)
+	g.Expect(entries).To(HaveLen(2))
+	g.Expect(entries[0]).To(HaveKeyWithValue("suppressedRepetitions", BeNumerically("==", 1)))
+	g.Expect(entries[1]).ToNot(HaveKey("suppressedRepetitions"))
+
+	t.Log("should sample entries of loggers with fields")
+	child := logger.With(zap.String("component", "bootstrap"))
+	child.Warn("backup-restore unreachable")
+	child.Warn("backup-restore unreachable")
+	sampler.flush(true)
+	entries = readEntries(g, output)
+	g.Expect(entries).To(HaveLen(2))
+	g.Expect(entries[1]).To(HaveKeyWithValue("component", "bootstrap"))
+	g.Expect(entries[1]).To(HaveKeyWithValue("suppressedRepetitions", BeNumerically("==", 1)))
+}
+
+func newLogger(output *bytes.Buffer) *zap.Logger {
+	encoderConfig := zap.NewProductionEncoderConfig()
+	encoderConfig.EncodeDuration = zapcore.StringDurationEncoder
+	return zap.New(zapcore.NewCore(zapcore.NewJSONEncoder(encoderConfig), zapcore.AddSync(output), zapcore.DebugLevel))
+}
+
+// readEntries decodes the entries written to output since it has last been read.
+func readEntries(g *WithT, output *bytes.Buffer) []map[string]any {
+	var entries []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(output.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		entry := map[string]any{}
+		g.Expect(json.Unmarshal([]byte(line), &entry)).To(Succeed())
+		entries = append(entries, entry)
+	}
+	output.Reset()
+	return entries
+}
@@ -0,0 +1,215 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package logsink provides log outputs which are independent of each other, so that the logs of etcd-wrapper and of
+// etcd can be written to separate destinations instead of being interleaved on stdout.
+package logsink
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	// Stdout is the output writing to the standard output of etcd-wrapper.
+	Stdout = "stdout"
+	// Stderr is the output writing to the standard error of etcd-wrapper.
+	Stderr = "stderr"
+)
+
+// Sink writes every log line to each of its outputs. It implements zapcore.WriteSyncer and is safe for concurrent use.
+type Sink struct {
+	outputs []output
+}
+
+// output is a single destination of a Sink.
+type output interface {
+	io.Writer
+	Sync() error
+	Close() error
+}
+
+// Open opens a Sink writing to the given outputs, each of which is either Stdout, Stderr or the path of a file. Files
+// are appended to and rotated once they grow beyond maxSizeBytes, keeping at most maxBackups older files suffixed with
+// `.1`, `.2`, ... (`.1` being the most recent).
+func Open(outputs []string, maxSizeBytes int64, maxBackups int) (*Sink, error) {
+	if len(outputs) == 0 {
+		return nil, errors.New("at least one log output is required")
+	}
+	if maxSizeBytes <= 0 {
+		return nil, fmt.Errorf("log max size must be greater than 0, got: %d", maxSizeBytes)
+	}
+	if maxBackups < 0 {
+		return nil, fmt.Errorf("log max backups must not be negative, got: %d", maxBackups)
+	}
+	s := &Sink{}
+	for _, path := range outputs {
+		switch path {
+		case Stdout:
+			s.outputs = append(s.outputs, stdStream{os.Stdout})
+		case Stderr:
+			s.outputs = append(s.outputs, stdStream{os.Stderr})
+		default:
+			f, err := openRotatingFile(path, maxSizeBytes, maxBackups)
+			if err != nil {
+				_ = s.Close()
+				return nil, err
+			}
+			s.outputs = append(s.outputs, f)
+		}
+	}
+	return s, nil
+}
+
+// Write writes p to all outputs. All outputs are written to even if writing to one of them fails.
+func (s *Sink) Write(p []byte) (int, error) {
+	var errs error
+	for _, o := range s.outputs {
+		if _, err := o.Write(p); err != nil {
+			errs = errors.Join(errs, err)
+		}
+	}
+	if errs != nil {
+		return 0, errs
+	}
+	return len(p), nil
+}
+
+// Sync flushes all outputs.
+func (s *Sink) Sync() error {
+	var errs error
+	for _, o := range s.outputs {
+		errs = errors.Join(errs, o.Sync())
+	}
+	return errs
+}
+
+// Close closes all files written to. The standard streams are left open.
+func (s *Sink) Close() error {
+	var errs error
+	for _, o := range s.outputs {
+		errs = errors.Join(errs, o.Close())
+	}
+	return errs
+}
+
+// stdStream is an output writing to a standard stream, which is never closed.
+type stdStream struct {
+	*os.File
+}
+
+// Sync ignores the error returned when syncing a standard stream which is connected to a pipe or a terminal.
+func (s stdStream) Sync() error {
+	_ = s.File.Sync()
+	return nil
+}
+
+func (stdStream) Close() error { return nil }
+
+// rotatingFile is an output appending to a size-rotated file.
+type rotatingFile struct {
+	mu           sync.Mutex
+	path         string
+	maxSizeBytes int64
+	maxBackups   int
+	file         *os.File
+	size         int64
+}
+
+func openRotatingFile(path string, maxSizeBytes int64, maxBackups int) (*rotatingFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create directory for log file %s: %w", path, err)
+	}
+	f := &rotatingFile{
+		path:         path,
+		maxSizeBytes: maxSizeBytes,
+		maxBackups:   maxBackups,
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *rotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return 0, fmt.Errorf("log file %s is closed", f.path)
+	}
+	if f.size > 0 && f.size+int64(len(p)) > f.maxSizeBytes {
+		if err := f.rotate(); err != nil {
+			return 0, fmt.Errorf("failed to rotate log file %s: %w", f.path, err)
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+func (f *rotatingFile) Sync() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	return f.file.Sync()
+}
+
+func (f *rotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+func (f *rotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600) // #nosec G304 -- path is passed in as a command line flag.
+	if err != nil {
+		return fmt.Errorf("failed to open log file %s: %w", f.path, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to stat log file %s: %w", f.path, err)
+	}
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+// rotate shifts existing backups by one, moves the current file to `.1` and opens a fresh file.
+func (f *rotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	f.file = nil
+	if f.maxBackups == 0 {
+		if err := os.Remove(f.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return f.open()
+	}
+	for i := f.maxBackups - 1; i > 0; i-- {
+		if err := os.Rename(backupPath(f.path, i), backupPath(f.path, i+1)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	if err := os.Rename(f.path, backupPath(f.path, 1)); err != nil {
+		return err
+	}
+	return f.open()
+}
+
+func backupPath(path string, index int) string {
+	return fmt.Sprintf("%s.%d", path, index)
+}
@@ -0,0 +1,102 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package logsink
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestOpen(t *testing.T) {
+	table := []struct {
+		description  string
+		outputs      []string
+		maxSizeBytes int64
+		maxBackups   int
+		expectError  bool
+	}{
+		{"should return error when no output is given", nil, 1024, 1, true},
+		{"should return error when max size is not positive", []string{Stderr}, 0, 1, true},
+		{"should return error when max backups is negative", []string{Stderr}, 1024, -1, true},
+		{"should open standard streams", []string{Stdout, Stderr}, 1024, 1, false},
+		{"should open files", []string{"logs/wrapper.log"}, 1024, 0, false},
+	}
+
+	for _, entry := range table {
+		t.Run(entry.description, func(t *testing.T) {
+			g := NewWithT(t)
+			outputs := make([]string, 0, len(entry.outputs))
+			for _, output := range entry.outputs {
+				if output != Stdout && output != Stderr {
+					output = filepath.Join(t.TempDir(), output)
+				}
+				outputs = append(outputs, output)
+			}
+			sink, err := Open(outputs, entry.maxSizeBytes, entry.maxBackups)
+			g.Expect(err != nil).To(Equal(entry.expectError))
+			if err == nil {
+				g.Expect(sink.Sync()).To(Succeed())
+				g.Expect(sink.Close()).To(Succeed())
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	g := NewWithT(t)
+	dir := t.TempDir()
+	first, second := filepath.Join(dir, "first.log"), filepath.Join(dir, "second.log")
+	sink, err := Open([]string{first, second}, 1024, 1)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	n, err := sink.Write([]byte("line\n"))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(n).To(Equal(5))
+	g.Expect(sink.Close()).To(Succeed())
+
+	for _, path := range []string{first, second} {
+		g.Expect(os.ReadFile(path)).To(Equal([]byte("line\n")))
+	}
+
+	t.Log("should fail to write once closed")
+	_, err = sink.Write([]byte("line\n"))
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestRotation(t *testing.T) {
+	table := []struct {
+		description     string
+		maxBackups      int
+		expectedCurrent string
+		expectedBackups []string
+	}{
+		{"should keep at most max backups", 2, "4\n", []string{"3\n", "2\n"}},
+		{"should truncate the file without backups", 0, "4\n", nil},
+	}
+
+	for _, entry := range table {
+		t.Run(entry.description, func(t *testing.T) {
+			g := NewWithT(t)
+			path := filepath.Join(t.TempDir(), "etcd.log")
+			// every line fills the file, so each write after the first rotates the file.
+			sink, err := Open([]string{path}, 2, entry.maxBackups)
+			g.Expect(err).ToNot(HaveOccurred())
+			for _, line := range []string{"1\n", "2\n", "3\n", "4\n"} {
+				_, err = sink.Write([]byte(line))
+				g.Expect(err).ToNot(HaveOccurred())
+			}
+			g.Expect(sink.Close()).To(Succeed())
+
+			g.Expect(os.ReadFile(path)).To(Equal([]byte(entry.expectedCurrent)))
+			for i, expected := range entry.expectedBackups {
+				g.Expect(os.ReadFile(backupPath(path, i+1))).To(Equal([]byte(expected)))
+			}
+			g.Expect(backupPath(path, len(entry.expectedBackups)+1)).ToNot(BeAnExistingFile())
+		})
+	}
+}
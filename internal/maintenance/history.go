@@ -0,0 +1,119 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package maintenance
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/util"
+)
+
+// OperationType is the type of a maintenance operation performed on the etcd backend.
+type OperationType string
+
+const (
+	// OperationCompaction is a compaction of the etcd history.
+	OperationCompaction OperationType = "compaction"
+	// OperationDefragmentation is a defragmentation of the etcd backend.
+	OperationDefragmentation OperationType = "defragmentation"
+)
+
+// Record is a maintenance operation performed on the etcd backend by etcd-wrapper.
+type Record struct {
+	// Operation is the type of the operation.
+	Operation OperationType `json:"operation"`
+	// Trigger is the reason for which the operation has been performed, e.g. the threshold which required a compaction.
+	Trigger string `json:"trigger"`
+	// Target identifies what the operation has been performed on, e.g. the revision compacted to or the defragmentation round.
+	Target string `json:"target,omitempty"`
+	// StartedAt is the time at which the operation started.
+	StartedAt time.Time `json:"startedAt"`
+	// DurationSeconds is the duration of the operation in seconds.
+	DurationSeconds float64 `json:"durationSeconds"`
+	// DBSizeBefore and DBSizeAfter are the physically allocated size of the etcd DB in bytes before and after the operation.
+	DBSizeBefore int64 `json:"dbSizeBefore"`
+	DBSizeAfter  int64 `json:"dbSizeAfter"`
+	// DBSizeInUseBefore and DBSizeInUseAfter are the logically used size of the etcd DB in bytes before and after the operation.
+	DBSizeInUseBefore int64 `json:"dbSizeInUseBefore"`
+	DBSizeInUseAfter  int64 `json:"dbSizeInUseAfter"`
+	// Error is the error of the operation, empty if it has succeeded.
+	Error string `json:"error,omitempty"`
+}
+
+// History is a ring buffer of the most recent maintenance operations which is persisted in a file, so that the
+// effectiveness of maintenance can be audited across restarts of etcd-wrapper. It is safe for concurrent use.
+type History struct {
+	mu      sync.Mutex
+	path    string
+	size    int
+	records []Record
+}
+
+// LoadHistory loads the History persisted at path which retains at most size records. If path is empty, the History
+// is not persisted. If the persisted History cannot be read, an empty History is returned along with the error.
+func LoadHistory(path string, size int) (*History, error) {
+	h := &History{path: path, size: size}
+	if path == "" {
+		return h, nil
+	}
+	data, err := os.ReadFile(path) // #nosec G304 -- path is configured by the operator.
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return h, nil
+		}
+		return h, fmt.Errorf("failed to read maintenance history: %w", err)
+	}
+	var records []Record
+	if err = json.Unmarshal(data, &records); err != nil {
+		return h, fmt.Errorf("failed to parse maintenance history: %w", err)
+	}
+	h.records = records
+	h.trim()
+	return h, nil
+}
+
+// Records returns the recorded maintenance operations, oldest first.
+func (h *History) Records() []Record {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return slices.Clone(h.records)
+}
+
+// Add records a maintenance operation, dropping the oldest record if the History is full.
+func (h *History) Add(record Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	record.StartedAt = record.StartedAt.UTC()
+	h.records = append(h.records, record)
+	h.trim()
+	return h.save()
+}
+
+func (h *History) trim() {
+	if h.size > 0 && len(h.records) > h.size {
+		h.records = slices.Clone(h.records[len(h.records)-h.size:])
+	}
+}
+
+// save atomically persists the History by writing it into a temporary file which is then renamed.
+func (h *History) save() error {
+	if h.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(h.records)
+	if err != nil {
+		return err
+	}
+	if err = util.WriteFileAtomic(h.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write maintenance history: %w", err)
+	}
+	return nil
+}
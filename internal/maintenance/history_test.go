@@ -0,0 +1,52 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package maintenance
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestHistory(t *testing.T) {
+	g := NewWithT(t)
+	historyPath := filepath.Join(t.TempDir(), "maintenance_history.json")
+	now := time.Now()
+
+	t.Log("should start with an empty history when no history has been persisted")
+	h, err := LoadHistory(historyPath, 2)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(h.Records()).To(BeEmpty())
+
+	t.Log("should retain only the most recent records")
+	for i, operation := range []OperationType{OperationCompaction, OperationDefragmentation, OperationCompaction} {
+		g.Expect(h.Add(Record{Operation: operation, Trigger: "revision", StartedAt: now.Add(time.Duration(i) * time.Minute), DBSizeBefore: 200, DBSizeAfter: 100})).To(Succeed())
+	}
+	records := h.Records()
+	g.Expect(records).To(HaveLen(2))
+	g.Expect(records[0].Operation).To(Equal(OperationDefragmentation))
+	g.Expect(records[1].StartedAt).To(Equal(now.Add(2 * time.Minute).UTC()))
+
+	t.Log("should load the persisted history")
+	loaded, err := LoadHistory(historyPath, 2)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(loaded.Records()).To(HaveLen(2))
+	g.Expect(loaded.Records()[1].StartedAt.Equal(records[1].StartedAt)).To(BeTrue())
+
+	t.Log("should return an empty history along with an error when the persisted history is corrupt")
+	g.Expect(os.WriteFile(historyPath, []byte("{"), 0600)).To(Succeed())
+	corrupt, err := LoadHistory(historyPath, 2)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(corrupt.Records()).To(BeEmpty())
+
+	t.Log("should not persist a history without path")
+	inMemory, err := LoadHistory("", 2)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(inMemory.Add(Record{Operation: OperationCompaction})).To(Succeed())
+	g.Expect(inMemory.Records()).To(HaveLen(1))
+}
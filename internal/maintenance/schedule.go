@@ -0,0 +1,159 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package maintenance
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// scheduleSearchLimit bounds the search for the next time matching a Schedule, so that schedules which can never match
+// (e.g. `0 0 30 2 *`) do not loop forever. It covers a full leap year cycle.
+const scheduleSearchLimit = 5
+
+// field is the set of values allowed for one field of a cron expression, as bit set.
+type field uint64
+
+func (f field) has(value int) bool {
+	return f&(1<<uint(value)) != 0
+}
+
+// fieldBounds are the bounds of the values of a field of a cron expression.
+type fieldBounds struct {
+	name     string
+	min, max int
+}
+
+var (
+	minuteBounds = fieldBounds{"minute", 0, 59}
+	hourBounds   = fieldBounds{"hour", 0, 23}
+	domBounds    = fieldBounds{"day of month", 1, 31}
+	monthBounds  = fieldBounds{"month", 1, 12}
+	// day of week allows 7 as alias for Sunday.
+	dowBounds = fieldBounds{"day of week", 0, 7}
+)
+
+// Schedule is a parsed cron expression in the standard 5-field format `minute hour day-of-month month day-of-week`.
+// Each field is either `*` or a comma-separated list of values and ranges, each with an optional step, e.g.
+// `0 2 * * 1-5` or `*/15 22-23,0-4 * * *`. As in cron, a time matches if either the day of month or the day of week
+// matches when both fields are restricted.
+type Schedule struct {
+	minute, hour, dom, month, dow field
+	domRestricted, dowRestricted  bool
+}
+
+// ParseSchedule parses the cron expression spec.
+func ParseSchedule(spec string) (*Schedule, error) {
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron expression %q: expected 5 fields, found %d", spec, len(fields))
+	}
+	var (
+		s   = &Schedule{}
+		err error
+	)
+	if s.minute, err = parseField(fields[0], minuteBounds); err != nil {
+		return nil, fmt.Errorf("invalid cron expression %q: %w", spec, err)
+	}
+	if s.hour, err = parseField(fields[1], hourBounds); err != nil {
+		return nil, fmt.Errorf("invalid cron expression %q: %w", spec, err)
+	}
+	if s.dom, err = parseField(fields[2], domBounds); err != nil {
+		return nil, fmt.Errorf("invalid cron expression %q: %w", spec, err)
+	}
+	if s.month, err = parseField(fields[3], monthBounds); err != nil {
+		return nil, fmt.Errorf("invalid cron expression %q: %w", spec, err)
+	}
+	if s.dow, err = parseField(fields[4], dowBounds); err != nil {
+		return nil, fmt.Errorf("invalid cron expression %q: %w", spec, err)
+	}
+	if s.dow.has(7) {
+		s.dow |= 1
+	}
+	s.domRestricted = fields[2] != "*"
+	s.dowRestricted = fields[4] != "*"
+	if s.Next(time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)).IsZero() {
+		return nil, fmt.Errorf("invalid cron expression %q: never matches", spec)
+	}
+	return s, nil
+}
+
+// parseField parses one comma-separated field of a cron expression.
+func parseField(spec string, bounds fieldBounds) (field, error) {
+	var f field
+	for _, part := range strings.Split(spec, ",") {
+		rangeSpec, stepSpec, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepSpec); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step %q in %s field", stepSpec, bounds.name)
+			}
+		}
+		low, high := bounds.min, bounds.max
+		if rangeSpec != "*" {
+			lowSpec, highSpec, isRange := strings.Cut(rangeSpec, "-")
+			var err error
+			if low, err = parseValue(lowSpec, bounds); err != nil {
+				return 0, err
+			}
+			high = low
+			if isRange {
+				if high, err = parseValue(highSpec, bounds); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				high = bounds.max
+			}
+			if low > high {
+				return 0, fmt.Errorf("invalid range %q in %s field", rangeSpec, bounds.name)
+			}
+		}
+		for value := low; value <= high; value += step {
+			f |= 1 << uint(value)
+		}
+	}
+	return f, nil
+}
+
+func parseValue(spec string, bounds fieldBounds) (int, error) {
+	value, err := strconv.Atoi(spec)
+	if err != nil || value < bounds.min || value > bounds.max {
+		return 0, fmt.Errorf("invalid value %q in %s field, must be between %d and %d", spec, bounds.name, bounds.min, bounds.max)
+	}
+	return value, nil
+}
+
+// Next returns the first time after t, at a full minute, which matches the Schedule. The time is computed in the
+// location of t. It returns the zero time if there is no such time within the next years.
+func (s *Schedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(scheduleSearchLimit, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case !s.month.has(int(t.Month())):
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !s.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case !s.hour.has(t.Hour()):
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case !s.minute.has(t.Minute()):
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func (s *Schedule) matchesDay(t time.Time) bool {
+	domMatches, dowMatches := s.dom.has(t.Day()), s.dow.has(int(t.Weekday()))
+	if s.domRestricted && s.dowRestricted {
+		return domMatches || dowMatches
+	}
+	return domMatches && dowMatches
+}
@@ -0,0 +1,64 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package maintenance
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestParseSchedule(t *testing.T) {
+	table := []struct {
+		description string
+		spec        string
+		expectError bool
+	}{
+		{"should parse schedule with wildcards", "* * * * *", false},
+		{"should parse schedule with lists, ranges and steps", "*/15 22-23,0-4 1,15 */2 1-5", false},
+		{"should parse schedule with 7 as Sunday", "0 2 * * 7", false},
+		{"should return error when number of fields is wrong", "0 2 * *", true},
+		{"should return error when value is out of bounds", "60 2 * * *", true},
+		{"should return error when value is not a number", "0 two * * *", true},
+		{"should return error when range is inverted", "0 4-2 * * *", true},
+		{"should return error when step is not positive", "*/0 * * * *", true},
+		{"should return error when schedule never matches", "0 0 30 2 *", true},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		_, err := ParseSchedule(entry.spec)
+		g.Expect(err != nil).To(Equal(entry.expectError))
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	// 2024-03-15 is a Friday.
+	from := time.Date(2024, time.March, 15, 10, 30, 20, 0, time.UTC)
+	table := []struct {
+		description  string
+		spec         string
+		expectedNext time.Time
+	}{
+		{"should return the next full minute for a wildcard schedule", "* * * * *", time.Date(2024, time.March, 15, 10, 31, 0, 0, time.UTC)},
+		{"should return a time later on the same day", "0 22 * * *", time.Date(2024, time.March, 15, 22, 0, 0, 0, time.UTC)},
+		{"should return a time on the next day", "0 2 * * *", time.Date(2024, time.March, 16, 2, 0, 0, 0, time.UTC)},
+		{"should return the next matching day of week", "0 2 * * 1", time.Date(2024, time.March, 18, 2, 0, 0, 0, time.UTC)},
+		{"should match either day of month or day of week when both are restricted", "0 2 20 * 0", time.Date(2024, time.March, 17, 2, 0, 0, 0, time.UTC)},
+		{"should return the next matching month", "15 3 1 6 *", time.Date(2024, time.June, 1, 3, 15, 0, 0, time.UTC)},
+		{"should return the next leap day", "0 0 29 2 *", time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"should apply steps", "*/20 10 * * *", time.Date(2024, time.March, 15, 10, 40, 0, 0, time.UTC)},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		schedule, err := ParseSchedule(entry.spec)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(schedule.Next(from)).To(Equal(entry.expectedNext))
+	}
+}
@@ -0,0 +1,201 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package maintenance confines disruptive operations initiated by etcd-wrapper to configured maintenance windows and
+// keeps the history of the maintenance operations performed on the etcd backend.
+package maintenance
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/metrics"
+
+	"go.uber.org/zap"
+)
+
+// Window is a recurring maintenance window which opens at every time matching a Schedule and stays open for a
+// fixed duration. Times are evaluated in UTC.
+type Window struct {
+	schedule *Schedule
+	duration time.Duration
+}
+
+// NewWindow creates a Window which opens at every time matching the cron expression spec and stays open for duration.
+func NewWindow(spec string, duration time.Duration) (*Window, error) {
+	if duration <= 0 {
+		return nil, fmt.Errorf("duration of maintenance window must be positive")
+	}
+	schedule, err := ParseSchedule(spec)
+	if err != nil {
+		return nil, err
+	}
+	return &Window{schedule: schedule, duration: duration}, nil
+}
+
+// Contains returns true if the Window is open at t.
+func (w *Window) Contains(t time.Time) bool {
+	t = t.UTC()
+	// the window is open at t if it has opened within the last duration.
+	opening := w.schedule.Next(t.Add(-w.duration))
+	return !opening.IsZero() && !opening.After(t)
+}
+
+// NextOpening returns t if the Window is open at t, and otherwise the time at which it opens next.
+func (w *Window) NextOpening(t time.Time) time.Time {
+	if w.Contains(t) {
+		return t
+	}
+	return w.schedule.Next(t.UTC())
+}
+
+// QueuedOperation is a disruptive operation which has been requested outside of the maintenance window and waits for
+// the window to open.
+type QueuedOperation struct {
+	// Name is the name of the operation.
+	Name string `json:"name"`
+	// QueuedAt is the time at which the operation has been requested.
+	QueuedAt time.Time `json:"queuedAt"`
+	// run performs the operation.
+	run func() error
+}
+
+// Scheduler runs disruptive operations only while the maintenance window is open. Operations requested outside of the
+// window are queued and run in the order of their request once the window opens. It is safe for concurrent use.
+type Scheduler struct {
+	window   *Window
+	logger   *zap.Logger
+	mu       sync.Mutex
+	queue    []QueuedOperation
+	notifyCh chan struct{}
+	now      func() time.Time
+}
+
+// NewScheduler creates a Scheduler for window. A nil window is always open, i.e. operations are never queued.
+func NewScheduler(window *Window, logger *zap.Logger) *Scheduler {
+	return &Scheduler{
+		window:   window,
+		logger:   logger,
+		notifyCh: make(chan struct{}, 1),
+		now:      time.Now,
+	}
+}
+
+// Submit runs op with the given name right away if the maintenance window is open, returning the error of op.
+// Otherwise, op is queued till the window opens and queued is true. An operation which is already queued under the
+// same name is replaced by op, keeping its position in the queue.
+func (s *Scheduler) Submit(name string, op func() error) (queued bool, err error) {
+	s.mu.Lock()
+	now := s.now()
+	if s.window == nil || s.window.Contains(now) {
+		s.removeLocked(name)
+		s.mu.Unlock()
+		return false, op()
+	}
+	defer s.mu.Unlock()
+	replaced := false
+	for i := range s.queue {
+		if s.queue[i].Name == name {
+			s.queue[i].run = op
+			replaced = true
+		}
+	}
+	if !replaced {
+		s.queue = append(s.queue, QueuedOperation{Name: name, QueuedAt: now.UTC(), run: op})
+	}
+	metrics.MaintenanceOperationsQueued.Set(float64(len(s.queue)))
+	s.logger.Info("maintenance window is closed, queued disruptive operation", zap.String("operation", name), zap.Time("nextOpening", s.window.NextOpening(now)))
+	select {
+	case s.notifyCh <- struct{}{}:
+	default:
+	}
+	return true, nil
+}
+
+// Queued returns the operations waiting for the maintenance window to open, in the order in which they will run.
+func (s *Scheduler) Queued() []QueuedOperation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.queue) == 0 {
+		return nil
+	}
+	queued := make([]QueuedOperation, len(s.queue))
+	copy(queued, s.queue)
+	return queued
+}
+
+// NextOpening returns the time at which the maintenance window opens next, or the current time if it is open.
+func (s *Scheduler) NextOpening() time.Time {
+	now := s.now()
+	if s.window == nil {
+		return now
+	}
+	return s.window.NextOpening(now)
+}
+
+// Run runs the queued operations whenever the maintenance window opens. It blocks till ctx is cancelled.
+func (s *Scheduler) Run(ctx context.Context) {
+	for {
+		// timerCh stays nil, i.e. blocks forever, till an operation is queued.
+		var (
+			timer   *time.Timer
+			timerCh <-chan time.Time
+		)
+		s.mu.Lock()
+		if len(s.queue) > 0 {
+			if opening := s.window.NextOpening(s.now()); !opening.IsZero() {
+				timer = time.NewTimer(opening.Sub(s.now()))
+				timerCh = timer.C
+			}
+		}
+		s.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			stopTimer(timer)
+			return
+		case <-s.notifyCh:
+			stopTimer(timer)
+		case <-timerCh:
+			s.runQueued()
+		}
+	}
+}
+
+// runQueued runs the queued operations one after the other as long as the maintenance window is open.
+func (s *Scheduler) runQueued() {
+	for {
+		s.mu.Lock()
+		if len(s.queue) == 0 || !s.window.Contains(s.now()) {
+			s.mu.Unlock()
+			return
+		}
+		op := s.queue[0]
+		s.queue = s.queue[1:]
+		metrics.MaintenanceOperationsQueued.Set(float64(len(s.queue)))
+		s.mu.Unlock()
+
+		s.logger.Info("maintenance window is open, running queued disruptive operation", zap.String("operation", op.Name), zap.Time("queuedAt", op.QueuedAt))
+		if err := op.run(); err != nil {
+			s.logger.Error("queued disruptive operation failed", zap.String("operation", op.Name), zap.Error(err))
+		}
+	}
+}
+
+func (s *Scheduler) removeLocked(name string) {
+	for i := range s.queue {
+		if s.queue[i].Name == name {
+			s.queue = append(s.queue[:i], s.queue[i+1:]...)
+			metrics.MaintenanceOperationsQueued.Set(float64(len(s.queue)))
+			return
+		}
+	}
+}
+
+func stopTimer(timer *time.Timer) {
+	if timer != nil {
+		timer.Stop()
+	}
+}
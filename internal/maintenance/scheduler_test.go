@@ -0,0 +1,108 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package maintenance
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestWindow(t *testing.T) {
+	// the window opens daily at 02:00 UTC for two hours.
+	window, err := NewWindow("0 2 * * *", 2*time.Hour)
+	NewWithT(t).Expect(err).ToNot(HaveOccurred())
+	day := time.Date(2024, time.March, 15, 0, 0, 0, 0, time.UTC)
+	table := []struct {
+		description         string
+		at                  time.Time
+		expectOpen          bool
+		expectedNextOpening time.Time
+	}{
+		{"should be closed before the window opens", day.Add(time.Hour + 59*time.Minute), false, day.Add(2 * time.Hour)},
+		{"should be open when the window opens", day.Add(2 * time.Hour), true, day.Add(2 * time.Hour)},
+		{"should be open within the window", day.Add(3*time.Hour + 59*time.Minute), true, day.Add(3*time.Hour + 59*time.Minute)},
+		{"should be closed when the window closes", day.Add(4 * time.Hour), false, day.Add(26 * time.Hour)},
+		{"should evaluate the window in UTC", day.Add(2 * time.Hour).In(time.FixedZone("UTC+5", 5*60*60)), true, day.Add(2 * time.Hour)},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		g.Expect(window.Contains(entry.at)).To(Equal(entry.expectOpen))
+		g.Expect(window.NextOpening(entry.at).Equal(entry.expectedNextOpening)).To(BeTrue())
+	}
+}
+
+func TestNewWindow(t *testing.T) {
+	g := NewWithT(t)
+	_, err := NewWindow("0 2 * * *", 0)
+	g.Expect(err).To(HaveOccurred())
+	_, err = NewWindow("0 2 * *", time.Hour)
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestSchedulerSubmit(t *testing.T) {
+	g := NewWithT(t)
+	window, err := NewWindow("0 2 * * *", time.Hour)
+	g.Expect(err).ToNot(HaveOccurred())
+	now := time.Date(2024, time.March, 15, 12, 0, 0, 0, time.UTC)
+	scheduler := NewScheduler(window, zaptest.NewLogger(t))
+	scheduler.now = func() time.Time { return now }
+	var ran []string
+	op := func(name string) func() error {
+		return func() error {
+			ran = append(ran, name)
+			return nil
+		}
+	}
+
+	t.Log("should queue operations outside of the window")
+	queued, err := scheduler.Submit("restart", op("restart"))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(queued).To(BeTrue())
+	_, _ = scheduler.Submit("validation", op("validation-sanity"))
+	_, _ = scheduler.Submit("validation", op("validation-full"))
+	g.Expect(ran).To(BeEmpty())
+	g.Expect(scheduler.Queued()).To(HaveLen(2))
+	g.Expect(scheduler.Queued()[0].Name).To(Equal("restart"))
+	g.Expect(scheduler.NextOpening()).To(Equal(time.Date(2024, time.March, 16, 2, 0, 0, 0, time.UTC)))
+
+	t.Log("should not run queued operations while the window is closed")
+	scheduler.runQueued()
+	g.Expect(ran).To(BeEmpty())
+
+	t.Log("should run queued operations in order once the window opens, replacing operations with the same name")
+	now = time.Date(2024, time.March, 16, 2, 0, 0, 0, time.UTC)
+	scheduler.runQueued()
+	g.Expect(ran).To(Equal([]string{"restart", "validation-full"}))
+	g.Expect(scheduler.Queued()).To(BeEmpty())
+
+	t.Log("should run operations right away within the window")
+	queued, err = scheduler.Submit("restart", func() error { return errors.New("restart failed") })
+	g.Expect(queued).To(BeFalse())
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestSchedulerWithoutWindow(t *testing.T) {
+	g := NewWithT(t)
+	scheduler := NewScheduler(nil, zaptest.NewLogger(t))
+	ran := false
+	queued, err := scheduler.Submit("validation", func() error {
+		ran = true
+		return nil
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(queued).To(BeFalse())
+	g.Expect(ran).To(BeTrue())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	scheduler.Run(ctx)
+}
@@ -0,0 +1,66 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package memlimit
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultCgroupRoot is the path at which the cgroup filesystem of the container is mounted.
+	DefaultCgroupRoot = "/sys/fs/cgroup"
+	// cgroupV2MemoryMax is the file containing the memory limit in the unified (v2) cgroup hierarchy.
+	cgroupV2MemoryMax = "memory.max"
+	// cgroupV1MemoryLimit is the file containing the memory limit in the v1 cgroup hierarchy.
+	cgroupV1MemoryLimit = "memory/memory.limit_in_bytes"
+	// cgroupV2Unlimited is the content of cgroupV2MemoryMax if no memory limit is set.
+	cgroupV2Unlimited = "max"
+	// cgroupV1UnlimitedThreshold is the value from which on a cgroup v1 memory limit is treated as no limit. cgroup v1
+	// reports no limit as the largest page-aligned int64, which depends on the page size of the architecture, e.g.
+	// 0x7FFFFFFFFFFFF000 with 4KiB pages on amd64 and 0x7FFFFFFFFFFF0000 with 64KiB pages on some arm64 and ppc64le kernels.
+	cgroupV1UnlimitedThreshold = 1 << 62
+)
+
+// ContainerMemoryLimit returns the memory limit in bytes of the container as configured in the cgroup v2 or v1 hierarchy
+// mounted at cgroupRoot. It returns false if no memory limit is configured or no cgroup hierarchy is found.
+func ContainerMemoryLimit(cgroupRoot string) (int64, bool, error) {
+	content, err := os.ReadFile(filepath.Join(cgroupRoot, cgroupV2MemoryMax))
+	if err == nil {
+		value := strings.TrimSpace(string(content))
+		if value == cgroupV2Unlimited {
+			return 0, false, nil
+		}
+		return parseLimit(cgroupV2MemoryMax, value)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return 0, false, err
+	}
+
+	content, err = os.ReadFile(filepath.Join(cgroupRoot, cgroupV1MemoryLimit))
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	limit, ok, err := parseLimit(cgroupV1MemoryLimit, strings.TrimSpace(string(content)))
+	if err != nil || limit >= cgroupV1UnlimitedThreshold {
+		return 0, false, err
+	}
+	return limit, ok, nil
+}
+
+func parseLimit(file, value string) (int64, bool, error) {
+	limit, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to parse memory limit %q from %s: %w", value, file, err)
+	}
+	return limit, limit > 0, nil
+}
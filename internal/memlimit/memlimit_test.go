@@ -0,0 +1,47 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package memlimit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestContainerMemoryLimit(t *testing.T) {
+	table := []struct {
+		description   string
+		files         map[string]string
+		expectedLimit int64
+		expectedOK    bool
+		expectError   bool
+	}{
+		{"should return no limit when there is no cgroup hierarchy", nil, 0, false, false},
+		{"should read cgroup v2 memory limit", map[string]string{cgroupV2MemoryMax: "536870912\n"}, 536870912, true, false},
+		{"should return no limit when cgroup v2 memory limit is max", map[string]string{cgroupV2MemoryMax: "max\n"}, 0, false, false},
+		{"should read cgroup v1 memory limit", map[string]string{cgroupV1MemoryLimit: "1073741824\n"}, 1073741824, true, false},
+		{"should return no limit for cgroup v1 with 4KiB pages", map[string]string{cgroupV1MemoryLimit: "9223372036854771712\n"}, 0, false, false},
+		{"should return no limit for cgroup v1 with 64KiB pages", map[string]string{cgroupV1MemoryLimit: "9223372036854710272\n"}, 0, false, false},
+		{"should prefer cgroup v2 over cgroup v1", map[string]string{cgroupV2MemoryMax: "268435456", cgroupV1MemoryLimit: "1073741824"}, 268435456, true, false},
+		{"should return error for an invalid memory limit", map[string]string{cgroupV2MemoryMax: "lots"}, 0, false, true},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		cgroupRoot := t.TempDir()
+		for file, content := range entry.files {
+			path := filepath.Join(cgroupRoot, file)
+			g.Expect(os.MkdirAll(filepath.Dir(path), 0700)).To(Succeed())
+			g.Expect(os.WriteFile(path, []byte(content), 0600)).To(Succeed())
+		}
+		limit, ok, err := ContainerMemoryLimit(cgroupRoot)
+		g.Expect(err != nil).To(Equal(entry.expectError))
+		g.Expect(limit).To(Equal(entry.expectedLimit))
+		g.Expect(ok).To(Equal(entry.expectedOK))
+	}
+}
@@ -0,0 +1,313 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package metrics
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	namespace = "etcd_wrapper"
+	// clusterIDLabel is the label carrying the ID of the etcd cluster on all metrics once it is known.
+	clusterIDLabel = "cluster_id"
+	// memberIDLabel is the label carrying the ID of the etcd member on all metrics once it is known.
+	memberIDLabel = "member_id"
+)
+
+// identityLabels are the labels identifying the etcd cluster and member which are added to all metrics.
+var identityLabels atomic.Pointer[[]*dto.LabelPair]
+
+var (
+	// Registry is the registry of all metrics exposed by etcd-wrapper. It is kept separate from the default registry
+	// which is used by the embedded etcd to register its own metrics.
+	Registry = prometheus.NewRegistry()
+
+	// State is 1 for the current state of etcd-wrapper and 0 for all other states.
+	State = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "state",
+		Help:      "Current state of etcd-wrapper. The value is 1 for the current state and 0 for all other states.",
+	}, []string{"state"})
+	// StateTransitionsTotal is the number of state transitions of etcd-wrapper.
+	StateTransitionsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "state_transitions_total",
+		Help:      "Total number of state transitions of etcd-wrapper.",
+	}, []string{"from", "to"})
+	// CorruptionAlarmActive is 1 while etcd reports an active corruption alarm and 0 otherwise.
+	CorruptionAlarmActive = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "corruption_alarm_active",
+		Help:      "Whether etcd reports an active corruption alarm. The value is 1 if the alarm is active and 0 otherwise.",
+	})
+	// CorruptionAlarmsTotal is the number of times a corruption alarm has been detected.
+	CorruptionAlarmsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "corruption_alarms_total",
+		Help:      "Total number of times a corruption alarm raised by etcd has been detected.",
+	})
+	// ProposalBackpressure is 1 while sustained raft proposal backpressure is detected and 0 otherwise.
+	ProposalBackpressure = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "proposal_backpressure",
+		Help:      "Whether sustained raft proposal backpressure is detected. The value is 1 if backpressure is detected and 0 otherwise.",
+	})
+	// SidecarBypassed is 1 if etcd has been started without backup-restore in sidecar optional mode and 0 otherwise.
+	SidecarBypassed = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "sidecar_bypassed",
+		Help:      "Whether etcd has been started without backup-restore since it could not be reached within the sidecar optional window. The value is 1 if it has and 0 otherwise.",
+	})
+	// SidecarAPIVersion is the API version negotiated with backup-restore.
+	SidecarAPIVersion = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "sidecar_api_version",
+		Help:      "API version negotiated with backup-restore. The value is 0 if it has not been negotiated.",
+	})
+	// SidecarErrorsTotal is the number of failed requests to backup-restore during bootstrap, by the class of the error.
+	SidecarErrorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "sidecar_errors_total",
+		Help:      "Total number of failed requests to backup-restore during bootstrap, by the class of the error. Transient errors are retried with backoff, permanent errors fail the bootstrap.",
+	}, []string{"class"})
+	// LogEntriesSuppressedTotal is the number of repetitive log entries of etcd-wrapper which have been suppressed by
+	// log sampling, by level.
+	LogEntriesSuppressedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "log_entries_suppressed_total",
+		Help:      "Total number of repetitive log entries of etcd-wrapper which have been suppressed by log sampling, by level. Suppressed entries are summarized in periodic log entries with their number of repetitions.",
+	}, []string{"level"})
+	// LastBackupTimestampSeconds is the creation time of the latest snapshot taken by backup-restore.
+	LastBackupTimestampSeconds = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "last_backup_timestamp_seconds",
+		Help:      "Creation time of the latest full or delta snapshot taken by backup-restore as unix timestamp in seconds. Only set if the safety endpoint is enabled.",
+	})
+	// ApplyLag is the number of committed raft entries which have not yet been applied by the local member.
+	ApplyLag = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "raft_apply_lag_entries",
+		Help:      "Number of raft entries committed but not yet applied by the local member.",
+	})
+	// ApplyLagSustained is 1 while a sustained divergence between the committed and applied raft index is detected and 0 otherwise.
+	ApplyLagSustained = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "raft_apply_lag_sustained",
+		Help:      "Whether the divergence between the committed and applied raft index has been above the threshold for the sustained duration. The value is 1 if it has and 0 otherwise.",
+	})
+	// PeerClockSkewSeconds is the measured clock skew to other members of the etcd cluster.
+	PeerClockSkewSeconds = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "peer_clock_skew_seconds",
+		Help:      "Measured clock skew in seconds to other members of the etcd cluster. The value is positive if the clock of the member is ahead.",
+	}, []string{"member"})
+	// PeerReachable is the reachability of the peer URLs of other members of the etcd cluster.
+	PeerReachable = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "peer_reachable",
+		Help:      "Whether a peer URL of another member of the etcd cluster is reachable via TCP and, for https URLs, a TLS handshake. The value is 1 if it is and 0 otherwise.",
+	}, []string{"member", "peer_url"})
+	// ProactiveCompactionsTotal is the number of compactions of the etcd history triggered by etcd-wrapper.
+	ProactiveCompactionsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "proactive_compactions_total",
+		Help:      "Total number of compactions of the etcd history triggered by etcd-wrapper, by the trigger which required the compaction.",
+	}, []string{"trigger"})
+	// DefragmentationsTotal is the number of scheduled defragmentations of the etcd backend performed by etcd-wrapper.
+	DefragmentationsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "defragmentations_total",
+		Help:      "Total number of scheduled defragmentations of the etcd backend performed by etcd-wrapper, by the result of the defragmentation.",
+	}, []string{"result"})
+	// MaintenanceLeader indicates whether etcd-wrapper is the elected maintenance leader.
+	MaintenanceLeader = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "maintenance_leader",
+		Help:      "Whether etcd-wrapper holds the lease which elects the maintenance leader orchestrating cluster-wide maintenance (1) or not (0).",
+	})
+	// RequestsNearLimitTotal is the number of observed write requests which approach a request limit of etcd.
+	RequestsNearLimitTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "requests_near_limit_total",
+		Help:      "Total number of observed write requests to etcd beyond the request size warning ratio of a request limit, by the limit (request-bytes or txn-ops).",
+	}, []string{"limit"})
+	// WarmUpDurationSeconds is the duration of the last warm-up of etcd.
+	WarmUpDurationSeconds = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "warm_up_duration_seconds",
+		Help:      "Duration of the last warm-up of etcd, during which the keyspace is read before readiness is reported, in seconds.",
+	})
+	// DBSizeGrowthRate is the growth rate of the DB size of etcd.
+	DBSizeGrowthRate = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "db_size_growth_bytes_per_second",
+		Help:      "Growth rate of the DB size of etcd in bytes per second over the DB size trend window. Negative if the DB size shrinks.",
+	})
+	// RevisionChurnRate is the rate at which the etcd revision grows.
+	RevisionChurnRate = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "revision_churn_per_second",
+		Help:      "Rate at which the etcd revision grows in revisions per second over the last churn report interval, as reported to backup-restore.",
+	})
+	// DBQuotaExhaustionSeconds is the projected time until the DB size of etcd reaches its backend quota.
+	DBQuotaExhaustionSeconds = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "db_quota_exhaustion_seconds",
+		Help:      "Projected time in seconds until the DB size of etcd reaches its backend quota at the current growth rate. +Inf if the DB size does not grow.",
+	})
+	// DBQuotaExhaustionPredicted is 1 while the projected time until quota exhaustion is below the horizon and 0 otherwise.
+	DBQuotaExhaustionPredicted = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "db_quota_exhaustion_predicted",
+		Help:      "1 if the DB size of etcd is projected to reach its backend quota within the configured horizon, and 0 otherwise.",
+	})
+	// QuotaWritesAtRisk is 1 while writes to etcd are at risk of being rejected due to the backend quota and 0 otherwise.
+	QuotaWritesAtRisk = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "quota_writes_at_risk",
+		Help:      "1 if writes to etcd are at risk of being rejected due to its backend quota, and 0 otherwise.",
+	})
+	// RestoredRevisionsBehindWatermark is the number of revisions by which a restored data directory is older than the revision watermark.
+	RestoredRevisionsBehindWatermark = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "restored_revisions_behind_watermark",
+		Help:      "Number of revisions by which the data directory restored at the last start is older than the highest revision observed by etcd-wrapper before.",
+	})
+	// RestoreRevisionRegression is 1 if a restored data directory is older than the revision watermark by more than the tolerated delta.
+	RestoreRevisionRegression = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "restore_revision_regression",
+		Help:      "1 if the data directory restored at the last start is older than the highest revision observed by etcd-wrapper before by more than the tolerated delta, and 0 otherwise.",
+	})
+	// HealthScore is the exponentially smoothed health score of the member.
+	HealthScore = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "health_score",
+		Help:      "Exponentially smoothed health score of the member between 0 (unhealthy) and 1 (healthy), combining the success and the latency of periodic probes of etcd and the stability of its leader.",
+	})
+	// DataVolumeSizeBytes is the size of the data volume.
+	DataVolumeSizeBytes = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "data_volume_size_bytes",
+		Help:      "Size in bytes of the filesystem holding the data directory of etcd, as last seen by etcd-wrapper.",
+	})
+	// PrefixKeys is the number of keys per configured key prefix.
+	PrefixKeys = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "prefix_keys",
+		Help:      "Number of keys per configured key prefix, as last sampled by etcd-wrapper.",
+	}, []string{"prefix"})
+	// PrefixSizeBytes is the total size of the keys and values per configured key prefix.
+	PrefixSizeBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "prefix_size_bytes",
+		Help:      "Total size in bytes of the keys and values per configured key prefix, as last sampled by etcd-wrapper. Only sampled if enabled.",
+	}, []string{"prefix"})
+	// HotStandbyLearner is 1 while the member of a hot-standby etcd-wrapper is a raft learner and 0 otherwise.
+	HotStandbyLearner = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "hot_standby_learner",
+		Help:      "1 if the member of a hot-standby etcd-wrapper is a raft learner, 0 if it has been promoted to a voting member.",
+	})
+	// CrashLoopDetected is 1 if a crash loop has been detected at the start of etcd-wrapper and 0 otherwise.
+	CrashLoopDetected = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "crash_loop_detected",
+		Help:      "1 if a crash loop of etcd-wrapper has been detected when it started, which escalates to a full validation of the data directory, and 0 otherwise.",
+	})
+	// EtcdConfigChanged is 1 once the etcd configuration served by backup-restore has changed since etcd has been started.
+	EtcdConfigChanged = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "etcd_config_changed",
+		Help:      "1 if the etcd configuration served by backup-restore has changed since etcd has been started, which takes effect on the next start of etcd-wrapper, and 0 otherwise.",
+	})
+	// ReadinessGatePassed is 1 per readiness gate which has passed its last evaluation and 0 otherwise.
+	ReadinessGatePassed = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "readiness_gate_passed",
+		Help:      "1 if the readiness gate has passed its last evaluation, and 0 otherwise. etcd-wrapper only reports ready while all readiness gates pass.",
+	}, []string{"gate"})
+	// PreflightFsyncLatencySeconds is the fsync latency probed per volume before etcd has been started.
+	PreflightFsyncLatencySeconds = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "preflight_fsync_latency_seconds",
+		Help:      "Highest latency in seconds of the writes synced to the data or WAL volume by the preflight checks before etcd has been started.",
+	}, []string{"volume"})
+	// DiskFsyncLatencyP99Seconds is the 99th percentile of the fsync latency probed periodically per volume.
+	DiskFsyncLatencyP99Seconds = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "disk_fsync_latency_p99_seconds",
+		Help:      "99th percentile in seconds of the latency of the writes synced periodically to the data or WAL volume over the disk latency window.",
+	}, []string{"volume"})
+	// DiskFsyncLatencyHigh is 1 per volume while the 99th percentile of its fsync latency exceeds the warning threshold.
+	DiskFsyncLatencyHigh = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "disk_fsync_latency_high",
+		Help:      "1 if the 99th percentile of the fsync latency of the data or WAL volume exceeds the disk latency warning threshold, and 0 otherwise.",
+	}, []string{"volume"})
+	// CertificateDaysUntilExpiry is the number of days until the earliest expiry of the certificates in each certificate
+	// file used by etcd and etcd-wrapper. It is negative once a certificate has expired.
+	CertificateDaysUntilExpiry = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "certificate_days_until_expiry",
+		Help:      "Number of days until the earliest expiry of the certificates in a certificate file used by etcd or etcd-wrapper, by the purpose of the certificate. Negative once a certificate has expired.",
+	}, []string{"certificate"})
+	// MaintenanceOperationsQueued is the number of disruptive operations waiting for the maintenance window to open.
+	MaintenanceOperationsQueued = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "maintenance_operations_queued",
+		Help:      "Number of disruptive operations requested outside of the maintenance window which wait for the window to open.",
+	})
+)
+
+func init() {
+	Registry.MustRegister(State, StateTransitionsTotal, CorruptionAlarmActive, CorruptionAlarmsTotal, ProposalBackpressure, SidecarBypassed, SidecarAPIVersion, SidecarErrorsTotal, LogEntriesSuppressedTotal, LastBackupTimestampSeconds, ApplyLag, ApplyLagSustained, PeerClockSkewSeconds, PeerReachable, ProactiveCompactionsTotal, DefragmentationsTotal, MaintenanceLeader, RequestsNearLimitTotal, WarmUpDurationSeconds, DBSizeGrowthRate, DBQuotaExhaustionSeconds, DBQuotaExhaustionPredicted, QuotaWritesAtRisk, HealthScore, RestoredRevisionsBehindWatermark, RestoreRevisionRegression, DataVolumeSizeBytes, RevisionChurnRate, PrefixKeys, PrefixSizeBytes, HotStandbyLearner, CrashLoopDetected, MaintenanceOperationsQueued, EtcdConfigChanged, ReadinessGatePassed, PreflightFsyncLatencySeconds, DiskFsyncLatencyP99Seconds, DiskFsyncLatencyHigh, CertificateDaysUntilExpiry)
+}
+
+// SetMemberIdentity sets the IDs of the etcd cluster and member, with which all metrics are labelled from then on.
+// The IDs change when the data directory is restored or the member is replaced, which allows correlating metrics
+// across restarts and restorations.
+func SetMemberIdentity(clusterID, memberID string) {
+	identityLabels.Store(&[]*dto.LabelPair{
+		{Name: proto.String(clusterIDLabel), Value: proto.String(clusterID)},
+		{Name: proto.String(memberIDLabel), Value: proto.String(memberID)},
+	})
+}
+
+// Handler returns a http.Handler which serves all metrics registered with Registry.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(identityGatherer{Gatherer: Registry}, promhttp.HandlerOpts{})
+}
+
+// identityGatherer labels all metrics gathered by the Gatherer with the identity of the etcd cluster and member.
+type identityGatherer struct {
+	prometheus.Gatherer
+}
+
+// Gather gathers all metrics and adds the identity labels set via SetMemberIdentity to them, if any.
+func (g identityGatherer) Gather() ([]*dto.MetricFamily, error) {
+	metricFamilies, err := g.Gatherer.Gather()
+	labels := identityLabels.Load()
+	if labels == nil {
+		return metricFamilies, err
+	}
+	for _, metricFamily := range metricFamilies {
+		for _, metric := range metricFamily.Metric {
+			metric.Label = append(metric.Label, *labels...)
+			slices.SortFunc(metric.Label, func(a, b *dto.LabelPair) int {
+				return strings.Compare(a.GetName(), b.GetName())
+			})
+		}
+	}
+	return metricFamilies, err
+}
@@ -0,0 +1,54 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package metrics
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestIdentityGatherer(t *testing.T) {
+	g := NewWithT(t)
+	registry := prometheus.NewRegistry()
+	gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "test_gauge", Help: "test"}, []string{"state"})
+	registry.MustRegister(gauge)
+	gauge.WithLabelValues("Ready").Set(1)
+	gatherer := identityGatherer{Gatherer: registry}
+	t.Cleanup(func() {
+		identityLabels.Store(nil)
+	})
+
+	t.Log("should not add labels while the identity is unknown")
+	metricFamilies, err := gatherer.Gather()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(labelNames(metricFamilies[0].Metric[0].Label)).To(Equal([]string{"state"}))
+
+	t.Log("should add the identity labels in sorted order")
+	SetMemberIdentity("cdf818194e3a8c32", "8e9e05c52164694d")
+	metricFamilies, err = gatherer.Gather()
+	g.Expect(err).ToNot(HaveOccurred())
+	labels := metricFamilies[0].Metric[0].Label
+	g.Expect(labelNames(labels)).To(Equal([]string{"cluster_id", "member_id", "state"}))
+	g.Expect(labels[0].GetValue()).To(Equal("cdf818194e3a8c32"))
+	g.Expect(labels[1].GetValue()).To(Equal("8e9e05c52164694d"))
+
+	t.Log("should replace the identity labels when the identity changes")
+	SetMemberIdentity("1c45a069f3a1d796", "8e9e05c52164694d")
+	metricFamilies, err = gatherer.Gather()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(metricFamilies[0].Metric[0].Label).To(HaveLen(3))
+	g.Expect(metricFamilies[0].Metric[0].Label[0].GetValue()).To(Equal("1c45a069f3a1d796"))
+}
+
+func labelNames(labels []*dto.LabelPair) []string {
+	names := make([]string, 0, len(labels))
+	for _, label := range labels {
+		names = append(names, label.GetName())
+	}
+	return names
+}
@@ -0,0 +1,176 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package peerconnectivity checks whether the peer URLs of the members of an etcd cluster are reachable via TCP and,
+// for https URLs, a TLS handshake, and assembles the results of all members into a connectivity matrix. The matrix
+// reveals asymmetric network policies, which let a member reach a peer but not the other way round.
+package peerconnectivity
+
+import (
+	"cmp"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+)
+
+// DialFunc dials a TCP connection to address.
+type DialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// TLSConfigFunc returns the TLS configuration with which the handshake with the peer URL u is performed.
+type TLSConfigFunc func(u *url.URL) (*tls.Config, error)
+
+// Member is a member of the etcd cluster whose peer URLs are checked.
+type Member struct {
+	// Name is the name of the member.
+	Name string
+	// PeerURLs are the URLs at which the member listens for peers.
+	PeerURLs []string
+}
+
+// Probe is the result of checking a single peer URL.
+type Probe struct {
+	// Member is the name of the member listening at PeerURL.
+	Member string `json:"member"`
+	// PeerURL is the checked peer URL.
+	PeerURL string `json:"peerURL"`
+	// Reachable is true if a TCP connection to the peer URL has been established and, for https URLs, the TLS handshake
+	// has succeeded.
+	Reachable bool `json:"reachable"`
+	// Latency is the time taken to connect and complete the TLS handshake.
+	Latency string `json:"latency,omitempty"`
+	// Error is the reason why the peer URL is not reachable.
+	Error string `json:"error,omitempty"`
+}
+
+// Row holds the results of checking the peer URLs of all other members from a single member.
+type Row struct {
+	// From is the name of the member from which the peer URLs have been checked.
+	From string `json:"from"`
+	// CheckedAt is the time of the check.
+	CheckedAt time.Time `json:"checkedAt"`
+	// Probes are the results per peer URL, ordered by member and peer URL.
+	Probes []Probe `json:"probes,omitempty"`
+	// Error is the reason why the row could not be determined, e.g. because the member could not be asked for it.
+	Error string `json:"error,omitempty"`
+}
+
+// Link is a directed connection between two members.
+type Link struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Matrix is the connectivity between all members of the etcd cluster.
+type Matrix struct {
+	// Rows are the rows of all members, ordered by member.
+	Rows []Row `json:"rows"`
+	// Unreachable are the links over which a member cannot reach all peer URLs of another member.
+	Unreachable []Link `json:"unreachable,omitempty"`
+	// Asymmetric are the unreachable links whose reverse link is reachable.
+	Asymmetric []Link `json:"asymmetric,omitempty"`
+}
+
+// Check checks the peer URLs of members concurrently from the member from, each within timeout.
+func Check(ctx context.Context, from string, members []Member, dial DialFunc, tlsConfig TLSConfigFunc, timeout time.Duration) Row {
+	var probes []Probe
+	for _, member := range members {
+		for _, peerURL := range member.PeerURLs {
+			probes = append(probes, Probe{Member: member.Name, PeerURL: peerURL})
+		}
+	}
+	var wg sync.WaitGroup
+	for i := range probes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			if err := probe(ctx, probes[i].PeerURL, dial, tlsConfig, timeout); err != nil {
+				probes[i].Error = err.Error()
+				return
+			}
+			probes[i].Reachable = true
+			probes[i].Latency = time.Since(start).Round(time.Microsecond).String()
+		}()
+	}
+	wg.Wait()
+	slices.SortFunc(probes, func(a, b Probe) int {
+		return cmp.Or(cmp.Compare(a.Member, b.Member), cmp.Compare(a.PeerURL, b.PeerURL))
+	})
+	return Row{From: from, CheckedAt: time.Now(), Probes: probes}
+}
+
+// probe connects to peerURL and performs a TLS handshake if its scheme is https.
+func probe(ctx context.Context, peerURL string, dial DialFunc, tlsConfig TLSConfigFunc, timeout time.Duration) error {
+	u, err := url.Parse(peerURL)
+	if err != nil {
+		return err
+	}
+	ctx, cancelFunc := context.WithTimeout(ctx, timeout)
+	defer cancelFunc()
+	conn, err := dial(ctx, "tcp", u.Host)
+	if err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	if u.Scheme != "https" {
+		return nil
+	}
+	config, err := tlsConfig(u)
+	if err != nil {
+		return fmt.Errorf("failed to create TLS configuration: %w", err)
+	}
+	if err = tls.Client(conn, config).HandshakeContext(ctx); err != nil {
+		return fmt.Errorf("TLS handshake failed: %w", err)
+	}
+	return nil
+}
+
+// NewMatrix assembles rows into a Matrix and determines its unreachable and asymmetric links. A link is unreachable if
+// any peer URL of the target member is unreachable. Rows which could not be determined contribute no links.
+func NewMatrix(rows []Row) Matrix {
+	rows = slices.Clone(rows)
+	slices.SortFunc(rows, func(a, b Row) int { return cmp.Compare(a.From, b.From) })
+	reachable := map[Link]bool{}
+	for _, row := range rows {
+		if row.Error != "" {
+			continue
+		}
+		for _, probe := range row.Probes {
+			link := Link{From: row.From, To: probe.Member}
+			if ok, seen := reachable[link]; !seen || ok {
+				reachable[link] = probe.Reachable
+			}
+		}
+	}
+	matrix := Matrix{Rows: rows}
+	for _, row := range rows {
+		if row.Error != "" {
+			continue
+		}
+		var targets []string
+		for _, probe := range row.Probes {
+			if !slices.Contains(targets, probe.Member) {
+				targets = append(targets, probe.Member)
+			}
+		}
+		for _, target := range targets {
+			link := Link{From: row.From, To: target}
+			if reachable[link] {
+				continue
+			}
+			matrix.Unreachable = append(matrix.Unreachable, link)
+			if reachable[Link{From: target, To: row.From}] {
+				matrix.Asymmetric = append(matrix.Asymmetric, link)
+			}
+		}
+	}
+	return matrix
+}
@@ -0,0 +1,125 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package peerconnectivity
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestCheck(t *testing.T) {
+	g := NewWithT(t)
+	plain, err := net.Listen("tcp", "127.0.0.1:0")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		_ = plain.Close()
+	}()
+	go func() {
+		for {
+			conn, err := plain.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.Close()
+		}
+	}()
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	g.Expect(err).ToNot(HaveOccurred())
+	closedAddr := closed.Addr().String()
+	g.Expect(closed.Close()).To(Succeed())
+	tlsServer := httptest.NewTLSServer(http.NotFoundHandler())
+	defer tlsServer.Close()
+	trusted := x509.NewCertPool()
+	trusted.AddCert(tlsServer.Certificate())
+
+	members := []Member{
+		{Name: "etcd-main-2", PeerURLs: []string{"https://" + tlsServer.Listener.Addr().String()}},
+		{Name: "etcd-main-1", PeerURLs: []string{"http://" + plain.Addr().String(), "http://" + closedAddr}},
+	}
+	table := []struct {
+		description       string
+		rootCAs           *x509.CertPool
+		expectedReachable map[string]bool
+	}{
+		{"should report reachable and unreachable peer URLs", trusted, map[string]bool{"http://" + plain.Addr().String(): true, "http://" + closedAddr: false, "https://" + tlsServer.Listener.Addr().String(): true}},
+		{"should report peer URLs whose TLS handshake fails as unreachable", x509.NewCertPool(), map[string]bool{"http://" + plain.Addr().String(): true, "http://" + closedAddr: false, "https://" + tlsServer.Listener.Addr().String(): false}},
+	}
+	for _, entry := range table {
+		t.Log(entry.description)
+		tlsConfig := func(u *url.URL) (*tls.Config, error) {
+			return &tls.Config{RootCAs: entry.rootCAs, ServerName: u.Hostname(), MinVersion: tls.VersionTLS12}, nil
+		}
+		row := Check(context.Background(), "etcd-main-0", members, (&net.Dialer{}).DialContext, tlsConfig, time.Second)
+		g.Expect(row.From).To(Equal("etcd-main-0"))
+		g.Expect(row.Probes).To(HaveLen(3))
+		g.Expect(row.Probes[0].Member).To(Equal("etcd-main-1"))
+		g.Expect(row.Probes[2].Member).To(Equal("etcd-main-2"))
+		for _, probe := range row.Probes {
+			g.Expect(probe.Reachable).To(Equal(entry.expectedReachable[probe.PeerURL]), probe.PeerURL)
+			if probe.Reachable {
+				g.Expect(probe.Error).To(BeEmpty())
+				g.Expect(probe.Latency).ToNot(BeEmpty())
+			} else {
+				g.Expect(probe.Error).ToNot(BeEmpty())
+			}
+		}
+	}
+}
+
+func TestNewMatrix(t *testing.T) {
+	row := func(from string, reachable map[string]bool) Row {
+		r := Row{From: from}
+		for member, ok := range reachable {
+			r.Probes = append(r.Probes, Probe{Member: member, PeerURL: "https://" + member + ":2380", Reachable: ok})
+		}
+		return r
+	}
+	table := []struct {
+		description         string
+		rows                []Row
+		expectedUnreachable []Link
+		expectedAsymmetric  []Link
+	}{
+		{"should report no links if all members reach each other", []Row{
+			row("b", map[string]bool{"a": true}),
+			row("a", map[string]bool{"b": true}),
+		}, nil, nil},
+		{"should report a link blocked in one direction as asymmetric", []Row{
+			row("a", map[string]bool{"b": false, "c": true}),
+			row("b", map[string]bool{"a": true, "c": true}),
+			row("c", map[string]bool{"a": true, "b": true}),
+		}, []Link{{From: "a", To: "b"}}, []Link{{From: "a", To: "b"}}},
+		{"should report a link blocked in both directions as unreachable only", []Row{
+			row("a", map[string]bool{"b": false}),
+			row("b", map[string]bool{"a": false}),
+		}, []Link{{From: "a", To: "b"}, {From: "b", To: "a"}}, nil},
+		{"should ignore rows which could not be determined", []Row{
+			row("a", map[string]bool{"b": false}),
+			{From: "b", Error: "connection refused"},
+		}, []Link{{From: "a", To: "b"}}, nil},
+		{"should report a member as unreachable if one of its peer URLs is unreachable", []Row{
+			{From: "a", Probes: []Probe{{Member: "b", PeerURL: "https://b-0:2380", Reachable: true}, {Member: "b", PeerURL: "https://b-1:2380"}}},
+			row("b", map[string]bool{"a": true}),
+		}, []Link{{From: "a", To: "b"}}, []Link{{From: "a", To: "b"}}},
+	}
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		matrix := NewMatrix(entry.rows)
+		g.Expect(matrix.Rows).To(HaveLen(len(entry.rows)))
+		g.Expect(matrix.Rows[0].From).To(Equal("a"))
+		g.Expect(matrix.Unreachable).To(Equal(entry.expectedUnreachable))
+		g.Expect(matrix.Asymmetric).To(Equal(entry.expectedAsymmetric))
+	}
+}
@@ -0,0 +1,87 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package recovery provides last-resort recovery procedures for etcd clusters which have lost quorum.
+package recovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/bootstrap"
+
+	"go.etcd.io/etcd/embed"
+	"go.uber.org/zap"
+)
+
+// RecoverSingleMember starts the embedded etcd from the existing data directory configured in the etcd configuration
+// at etcdConfigFilePath with force-new-cluster semantics. This turns the member into a single-member cluster, removing
+// all other members from the cluster membership, while keeping its data. Once etcd is ready the member is stopped again,
+// after which it can be started normally. readyTimeout is the time to wait for etcd to be ready, a zero value waits forever.
+func RecoverSingleMember(ctx context.Context, etcdConfigFilePath string, readyTimeout time.Duration, logger *zap.Logger) error {
+	cfg, err := bootstrap.LoadEtcdConfig(etcdConfigFilePath)
+	if err != nil {
+		return fmt.Errorf("failed to load etcd config from %s: %w", etcdConfigFilePath, err)
+	}
+	dbPath := bootstrap.GetDBPath(cfg.Dir)
+	if _, err = os.Stat(dbPath); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("etcd db %s does not exist, recovery requires an existing data directory", dbPath)
+		}
+		return err
+	}
+	metadata, err := bootstrap.ReadDBMetadata(dbPath)
+	if err != nil {
+		return err
+	}
+	logger.Warn("Starting etcd with force-new-cluster from existing data directory",
+		zap.String("dataDir", cfg.Dir),
+		zap.Int64("dbRevision", metadata.Revision),
+		zap.Uint64("dbConsistentIndex", metadata.ConsistentIndex))
+
+	cfg.ForceNewCluster = true
+	etcd, err := embed.StartEtcd(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to start etcd with force-new-cluster: %w", err)
+	}
+	if err = waitForReady(ctx, etcd, readyTimeout); err != nil {
+		// etcd.Close blocks till the client servers have been started, which only happens once etcd is ready.
+		etcd.Server.HardStop()
+		return err
+	}
+	defer etcd.Close()
+
+	members := etcd.Server.Cluster().Members()
+	memberNames := make([]string, 0, len(members))
+	for _, m := range members {
+		memberNames = append(memberNames, m.Name)
+	}
+	logger.Warn("Recovered etcd as a single-member cluster, start etcd-wrapper normally to continue serving",
+		zap.String("memberID", etcd.Server.ID().String()),
+		zap.Strings("members", memberNames),
+		zap.Int64("dbRevision", metadata.Revision))
+	return nil
+}
+
+func waitForReady(ctx context.Context, etcd *embed.Etcd, readyTimeout time.Duration) error {
+	var readyTimeoutCh <-chan time.Time
+	if readyTimeout > 0 {
+		readyTimeoutCh = time.After(readyTimeout)
+	}
+	select {
+	case <-etcd.Server.ReadyNotify():
+		return nil
+	case <-etcd.Server.StopNotify():
+		return errors.New("etcd server has been aborted before it became ready")
+	case err := <-etcd.Err():
+		return fmt.Errorf("etcd failed before it became ready: %w", err)
+	case <-readyTimeoutCh:
+		return fmt.Errorf("etcd did not become ready within %s", readyTimeout)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
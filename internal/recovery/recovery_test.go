@@ -0,0 +1,125 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package recovery
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"go.etcd.io/etcd/embed"
+	"go.uber.org/zap/zaptest"
+)
+
+const testReadyTimeout = time.Minute
+
+func TestRecoverSingleMember(t *testing.T) {
+	g := NewWithT(t)
+	testDir := t.TempDir()
+	logger := zaptest.NewLogger(t)
+	configFilePaths := writeTestClusterConfigs(g, testDir, 3)
+
+	t.Log("should return error when data directory does not exist")
+	g.Expect(RecoverSingleMember(context.Background(), configFilePaths[0], testReadyTimeout, logger)).To(MatchError(ContainSubstring("does not exist")))
+
+	t.Log("should recover a member of a three-member cluster as a single-member cluster")
+	startAndStopTestCluster(t, g, configFilePaths)
+	g.Expect(RecoverSingleMember(context.Background(), configFilePaths[0], testReadyTimeout, logger)).To(Succeed())
+
+	t.Log("should be ready as a single-member cluster when started normally after recovery")
+	etcd := startTestMember(g, configFilePaths[0])
+	defer etcd.Close()
+	g.Expect(waitForReady(context.Background(), etcd, testReadyTimeout)).To(Succeed())
+	g.Expect(etcd.Server.Cluster().Members()).To(HaveLen(1))
+}
+
+// writeTestClusterConfigs writes the etcd configuration files of a cluster with the given number of members.
+func writeTestClusterConfigs(g *WithT, testDir string, size int) []string {
+	var (
+		names          = make([]string, size)
+		clientPorts    = make([]int, size)
+		peerPorts      = make([]int, size)
+		initialCluster []string
+	)
+	for i := 0; i < size; i++ {
+		names[i] = fmt.Sprintf("etcd-%d", i)
+		clientPorts[i], peerPorts[i] = getFreePort(g), getFreePort(g)
+		initialCluster = append(initialCluster, fmt.Sprintf("%s=http://127.0.0.1:%d", names[i], peerPorts[i]))
+	}
+	configFilePaths := make([]string, size)
+	for i := 0; i < size; i++ {
+		config := fmt.Sprintf(`name: %s
+data-dir: %s
+listen-client-urls: http://127.0.0.1:%d
+advertise-client-urls: http://127.0.0.1:%d
+listen-peer-urls: http://127.0.0.1:%d
+initial-advertise-peer-urls: http://127.0.0.1:%d
+initial-cluster: %s
+initial-cluster-state: new
+logger: zap
+log-outputs: [stderr]
+`, names[i], filepath.Join(testDir, names[i]), clientPorts[i], clientPorts[i], peerPorts[i], peerPorts[i], strings.Join(initialCluster, ","))
+		configFilePaths[i] = filepath.Join(testDir, names[i]+".conf.yaml")
+		g.Expect(os.WriteFile(configFilePaths[i], []byte(config), 0600)).To(Succeed())
+	}
+	return configFilePaths
+}
+
+// startAndStopTestCluster starts all members of the cluster, waits till all of them are ready and stops them again.
+// The configurations of all members are loaded before the first member is started, since loading a configuration
+// sets up the logging of etcd, which replaces the global gRPC logger used by running members. With the zap logger,
+// the global gRPC logger is only replaced once per process.
+func startAndStopTestCluster(t *testing.T, g *WithT, configFilePaths []string) {
+	configs := make([]*embed.Config, len(configFilePaths))
+	for i, configFilePath := range configFilePaths {
+		cfg, err := embed.ConfigFromFile(configFilePath)
+		g.Expect(err).ToNot(HaveOccurred())
+		configs[i] = cfg
+	}
+	members := make([]*embed.Etcd, len(configs))
+	for i, cfg := range configs {
+		etcd, err := embed.StartEtcd(cfg)
+		g.Expect(err).ToNot(HaveOccurred())
+		members[i] = etcd
+	}
+	var wg sync.WaitGroup
+	for _, member := range members {
+		wg.Add(1)
+		go func(member *embed.Etcd) {
+			defer wg.Done()
+			if err := waitForReady(context.Background(), member, testReadyTimeout); err != nil {
+				t.Errorf("member %s did not become ready: %v", member.Config().Name, err)
+			}
+		}(member)
+	}
+	wg.Wait()
+	for _, member := range members {
+		member.Close()
+	}
+}
+
+func startTestMember(g *WithT, configFilePath string) *embed.Etcd {
+	cfg, err := embed.ConfigFromFile(configFilePath)
+	g.Expect(err).ToNot(HaveOccurred())
+	etcd, err := embed.StartEtcd(cfg)
+	g.Expect(err).ToNot(HaveOccurred())
+	return etcd
+}
+
+func getFreePort(g *WithT) int {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		g.Expect(listener.Close()).To(Succeed())
+	}()
+	return listener.Addr().(*net.TCPAddr).Port
+}
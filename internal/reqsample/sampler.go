@@ -0,0 +1,262 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package reqsample samples client requests served by gRPC servers of etcd into a rolling in-memory buffer, to debug
+// which clients and key prefixes put load onto etcd without external tooling.
+package reqsample
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	pb "go.etcd.io/etcd/etcdserver/etcdserverpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Sample is a sampled client request.
+type Sample struct {
+	// Timestamp is the time at which the request has been received.
+	Timestamp time.Time `json:"timestamp"`
+	// Method is the full gRPC method of the request, e.g. /etcdserverpb.KV/Range.
+	Method string `json:"method"`
+	// KeyPrefixHash is the FNV-1a hash of the key prefix of the request, so that keys do not leak into the samples.
+	// It is empty if the request has no key.
+	KeyPrefixHash string `json:"keyPrefixHash,omitempty"`
+	// Peer is the address of the client.
+	Peer string `json:"peer,omitempty"`
+	// Code is the gRPC status code of the response.
+	Code string `json:"code"`
+	// LatencySeconds is the time taken to serve the request, or the lifetime of the stream for streaming requests.
+	LatencySeconds float64 `json:"latencySeconds"`
+	// ResponseBytes is the size of the response, or of all messages sent on the stream for streaming requests.
+	ResponseBytes int `json:"responseBytes"`
+}
+
+// Summary aggregates the samples of a method and key prefix.
+type Summary struct {
+	// Method is the full gRPC method of the requests.
+	Method string `json:"method"`
+	// KeyPrefixHash is the hash of the key prefix of the requests.
+	KeyPrefixHash string `json:"keyPrefixHash,omitempty"`
+	// Count is the number of sampled requests.
+	Count int `json:"count"`
+	// TotalLatencySeconds is the sum of the latencies of the sampled requests.
+	TotalLatencySeconds float64 `json:"totalLatencySeconds"`
+	// TotalResponseBytes is the sum of the response sizes of the sampled requests.
+	TotalResponseBytes int `json:"totalResponseBytes"`
+}
+
+// Sampler samples a fraction of the requests served by gRPC servers it intercepts into a rolling buffer. It is safe
+// for concurrent use.
+type Sampler struct {
+	fraction    float64
+	prefixDepth int
+	random      func() float64
+	now         func() time.Time
+
+	mu      sync.Mutex
+	samples []Sample
+	next    int
+	full    bool
+}
+
+// NewSampler creates a Sampler which samples the given fraction of requests and retains the last bufferSize samples.
+// The key prefix of a request consists of its first prefixDepth segments separated by `/`.
+func NewSampler(fraction float64, bufferSize, prefixDepth int) *Sampler {
+	return &Sampler{
+		fraction:    fraction,
+		prefixDepth: prefixDepth,
+		random:      rand.Float64,
+		now:         time.Now,
+		samples:     make([]Sample, max(bufferSize, 0)),
+	}
+}
+
+// Fraction returns the fraction of requests which are sampled.
+func (s *Sampler) Fraction() float64 {
+	return s.fraction
+}
+
+// UnaryServerInterceptor returns an interceptor which samples unary requests.
+func (s *Sampler) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if !s.sample() {
+			return handler(ctx, req)
+		}
+		start := s.now()
+		resp, err := handler(ctx, req)
+		s.record(ctx, start, info.FullMethod, keyOf(req), err, messageSize(resp))
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor returns an interceptor which samples streams once they have ended. The key of a stream is
+// the key of the first message received on it.
+func (s *Sampler) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if !s.sample() {
+			return handler(srv, ss)
+		}
+		start := s.now()
+		stream := &sampledStream{ServerStream: ss}
+		err := handler(srv, stream)
+		s.record(ss.Context(), start, info.FullMethod, stream.key, err, stream.sentBytes)
+		return err
+	}
+}
+
+// Samples returns the retained samples, oldest first.
+func (s *Sampler) Samples() []Sample {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var samples []Sample
+	if s.full {
+		samples = append(samples, s.samples[s.next:]...)
+	}
+	return append(samples, s.samples[:s.next]...)
+}
+
+// Summarize aggregates samples per method and key prefix, ordered by descending count.
+func Summarize(samples []Sample) []Summary {
+	index := map[[2]string]int{}
+	var summaries []Summary
+	for _, sample := range samples {
+		key := [2]string{sample.Method, sample.KeyPrefixHash}
+		i, ok := index[key]
+		if !ok {
+			i = len(summaries)
+			index[key] = i
+			summaries = append(summaries, Summary{Method: sample.Method, KeyPrefixHash: sample.KeyPrefixHash})
+		}
+		summaries[i].Count++
+		summaries[i].TotalLatencySeconds += sample.LatencySeconds
+		summaries[i].TotalResponseBytes += sample.ResponseBytes
+	}
+	slices.SortStableFunc(summaries, func(a, b Summary) int {
+		return cmp.Compare(b.Count, a.Count)
+	})
+	return summaries
+}
+
+func (s *Sampler) sample() bool {
+	return len(s.samples) > 0 && s.fraction > 0 && s.random() < s.fraction
+}
+
+func (s *Sampler) record(ctx context.Context, start time.Time, method string, key []byte, err error, responseBytes int) {
+	sample := Sample{
+		Timestamp:      start,
+		Method:         method,
+		Code:           status.Code(err).String(),
+		LatencySeconds: s.now().Sub(start).Seconds(),
+		ResponseBytes:  responseBytes,
+	}
+	if key != nil {
+		sample.KeyPrefixHash = hashPrefix(keyPrefix(key, s.prefixDepth))
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		sample.Peer = p.Addr.String()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.samples[s.next] = sample
+	s.next = (s.next + 1) % len(s.samples)
+	s.full = s.full || s.next == 0
+}
+
+// sampledStream records the key of the first received message and the size of all sent messages of a stream.
+type sampledStream struct {
+	grpc.ServerStream
+	key       []byte
+	received  bool
+	sentBytes int
+}
+
+func (s *sampledStream) RecvMsg(m any) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil && !s.received {
+		s.received = true
+		s.key = keyOf(m)
+	}
+	return err
+}
+
+func (s *sampledStream) SendMsg(m any) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		s.sentBytes += messageSize(m)
+	}
+	return err
+}
+
+// keyOf returns the key addressed by an etcd request, or nil if it has none.
+func keyOf(req any) []byte {
+	switch r := req.(type) {
+	case *pb.RangeRequest:
+		return r.Key
+	case *pb.PutRequest:
+		return r.Key
+	case *pb.DeleteRangeRequest:
+		return r.Key
+	case *pb.TxnRequest:
+		if len(r.Compare) > 0 {
+			return r.Compare[0].Key
+		}
+		for _, op := range slices.Concat(r.Success, r.Failure) {
+			switch {
+			case op.GetRequestRange() != nil:
+				return op.GetRequestRange().Key
+			case op.GetRequestPut() != nil:
+				return op.GetRequestPut().Key
+			case op.GetRequestDeleteRange() != nil:
+				return op.GetRequestDeleteRange().Key
+			}
+		}
+	case *pb.WatchRequest:
+		if r.GetCreateRequest() != nil {
+			return r.GetCreateRequest().Key
+		}
+	}
+	return nil
+}
+
+// keyPrefix returns the first depth segments of key separated by `/`, including the trailing separator, e.g.
+// /registry/pods/ for /registry/pods/default/nginx with depth 2.
+func keyPrefix(key []byte, depth int) string {
+	k := string(key)
+	offset := 0
+	if strings.HasPrefix(k, "/") {
+		offset = 1
+	}
+	for i := 0; i < depth; i++ {
+		next := strings.IndexByte(k[offset:], '/')
+		if next < 0 {
+			return k
+		}
+		offset += next + 1
+	}
+	return k[:offset]
+}
+
+func hashPrefix(prefix string) string {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(prefix))
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+// messageSize returns the encoded size of a protobuf message, or zero if it cannot be determined.
+func messageSize(m any) int {
+	if sized, ok := m.(interface{ Size() int }); ok {
+		return sized.Size()
+	}
+	return 0
+}
@@ -0,0 +1,157 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package reqsample
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	pb "go.etcd.io/etcd/etcdserver/etcdserverpb"
+	"go.etcd.io/etcd/mvcc/mvccpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestKeyPrefix(t *testing.T) {
+	table := []struct {
+		description    string
+		key            string
+		depth          int
+		expectedPrefix string
+	}{
+		{"should cut key after the given number of segments", "/registry/pods/default/nginx", 2, "/registry/pods/"},
+		{"should handle keys without leading separator", "registry/pods/default/nginx", 1, "registry/"},
+		{"should return the whole key if it has fewer segments", "/registry/pods", 2, "/registry/pods"},
+	}
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		g.Expect(keyPrefix([]byte(entry.key), entry.depth)).To(Equal(entry.expectedPrefix))
+	}
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	g := NewWithT(t)
+	sampler := newTestSampler(0.5, 2)
+	interceptor := sampler.UnaryServerInterceptor()
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 4711}})
+	info := &grpc.UnaryServerInfo{FullMethod: "/etcdserverpb.KV/Range"}
+	resp := &pb.RangeResponse{Kvs: []*mvccpb.KeyValue{{Key: []byte("/registry/pods/default/nginx"), Value: []byte("spec")}}}
+	handler := func(context.Context, any) (any, error) { return resp, nil }
+
+	t.Log("should not sample requests above the fraction")
+	sampler.random = func() float64 { return 0.7 }
+	_, err := interceptor(ctx, &pb.RangeRequest{Key: []byte("/registry/pods/default/nginx")}, info, handler)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(sampler.Samples()).To(BeEmpty())
+
+	t.Log("should sample method, key prefix hash, peer, latency and response size")
+	sampler.random = func() float64 { return 0.2 }
+	_, err = interceptor(ctx, &pb.RangeRequest{Key: []byte("/registry/pods/default/nginx")}, info, handler)
+	g.Expect(err).ToNot(HaveOccurred())
+	samples := sampler.Samples()
+	g.Expect(samples).To(HaveLen(1))
+	g.Expect(samples[0].Method).To(Equal("/etcdserverpb.KV/Range"))
+	g.Expect(samples[0].KeyPrefixHash).To(Equal(hashPrefix("/registry/pods/")))
+	g.Expect(samples[0].Peer).To(Equal("10.0.0.1:4711"))
+	g.Expect(samples[0].Code).To(Equal(codes.OK.String()))
+	g.Expect(samples[0].LatencySeconds).To(Equal(1.0))
+	g.Expect(samples[0].ResponseBytes).To(Equal(resp.Size()))
+
+	t.Log("should record the status code of failed requests and drop the oldest samples")
+	failing := func(context.Context, any) (any, error) {
+		return nil, status.Error(codes.ResourceExhausted, "too many requests")
+	}
+	_, err = interceptor(ctx, &pb.PutRequest{Key: []byte("/registry/leases/kube-system/a")}, &grpc.UnaryServerInfo{FullMethod: "/etcdserverpb.KV/Put"}, failing)
+	g.Expect(err).To(HaveOccurred())
+	_, _ = interceptor(ctx, &pb.LeaseGrantRequest{}, &grpc.UnaryServerInfo{FullMethod: "/etcdserverpb.Lease/LeaseGrant"}, handler)
+	samples = sampler.Samples()
+	g.Expect(samples).To(HaveLen(2))
+	g.Expect(samples[0].Method).To(Equal("/etcdserverpb.KV/Put"))
+	g.Expect(samples[0].Code).To(Equal(codes.ResourceExhausted.String()))
+	g.Expect(samples[1].Method).To(Equal("/etcdserverpb.Lease/LeaseGrant"))
+	g.Expect(samples[1].KeyPrefixHash).To(BeEmpty())
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	g := NewWithT(t)
+	sampler := newTestSampler(1, 10)
+	sampler.random = func() float64 { return 0 }
+	stream := &fakeServerStream{
+		ctx:      context.Background(),
+		received: []*pb.WatchRequest{{RequestUnion: &pb.WatchRequest_CreateRequest{CreateRequest: &pb.WatchCreateRequest{Key: []byte("/registry/events/default/e1")}}}},
+	}
+	response := &pb.WatchResponse{WatchId: 1, Created: true}
+	handler := func(_ any, ss grpc.ServerStream) error {
+		req := &pb.WatchRequest{}
+		if err := ss.RecvMsg(req); err != nil {
+			return err
+		}
+		if err := ss.SendMsg(response); err != nil {
+			return err
+		}
+		return ss.SendMsg(response)
+	}
+
+	err := sampler.StreamServerInterceptor()(nil, stream, &grpc.StreamServerInfo{FullMethod: "/etcdserverpb.Watch/Watch"}, handler)
+	g.Expect(err).ToNot(HaveOccurred())
+	samples := sampler.Samples()
+	g.Expect(samples).To(HaveLen(1))
+	g.Expect(samples[0].KeyPrefixHash).To(Equal(hashPrefix("/registry/events/")))
+	g.Expect(samples[0].ResponseBytes).To(Equal(2 * response.Size()))
+}
+
+func TestSummarize(t *testing.T) {
+	g := NewWithT(t)
+	summaries := Summarize([]Sample{
+		{Method: "/etcdserverpb.KV/Put", KeyPrefixHash: "a", LatencySeconds: 1, ResponseBytes: 10},
+		{Method: "/etcdserverpb.KV/Range", KeyPrefixHash: "b", LatencySeconds: 1, ResponseBytes: 100},
+		{Method: "/etcdserverpb.KV/Range", KeyPrefixHash: "b", LatencySeconds: 2, ResponseBytes: 200},
+	})
+	g.Expect(summaries).To(Equal([]Summary{
+		{Method: "/etcdserverpb.KV/Range", KeyPrefixHash: "b", Count: 2, TotalLatencySeconds: 3, TotalResponseBytes: 300},
+		{Method: "/etcdserverpb.KV/Put", KeyPrefixHash: "a", Count: 1, TotalLatencySeconds: 1, TotalResponseBytes: 10},
+	}))
+}
+
+// newTestSampler creates a Sampler whose clock advances by one second on every reading.
+func newTestSampler(fraction float64, bufferSize int) *Sampler {
+	sampler := NewSampler(fraction, bufferSize, 2)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	sampler.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+	return sampler
+}
+
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx      context.Context
+	received []*pb.WatchRequest
+}
+
+func (s *fakeServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *fakeServerStream) RecvMsg(m any) error {
+	if len(s.received) == 0 {
+		return errors.New("no more messages")
+	}
+	*m.(*pb.WatchRequest) = *s.received[0]
+	s.received = s.received[1:]
+	return nil
+}
+
+func (s *fakeServerStream) SendMsg(any) error {
+	return nil
+}
@@ -0,0 +1,120 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package secret resolves sensitive configuration values from files, named pipes, environment variables or stdin, so
+// that they never need to be passed as command line flags, which are visible to every process on the node.
+package secret
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+const (
+	// FileSource reads the secret from the file or named pipe at the given path, e.g. `file:/etc/etcd/passphrase`.
+	FileSource = "file"
+	// EnvSource reads the secret from the given environment variable, e.g. `env:ETCD_CLIENT_PASSWORD`.
+	EnvSource = "env"
+	// StdinSource reads the secret with the given name from stdin, e.g. `stdin:password`. Stdin is read till EOF once,
+	// each line holding one secret in the format `<name>=<value>`.
+	StdinSource = "stdin"
+)
+
+// Resolver resolves references to secrets of the form `<source>:<name>`, where source is one of FileSource, EnvSource
+// or StdinSource. Every file and stdin is read at most once, so that named pipes and stdin can be used as sources for
+// more than one reference. It is safe for concurrent use.
+type Resolver struct {
+	mu          sync.Mutex
+	stdin       io.Reader
+	stdinSecret map[string]string
+	stdinErr    error
+	files       map[string]string
+}
+
+// NewResolver creates a Resolver which reads secrets referencing StdinSource from stdin.
+func NewResolver(stdin io.Reader) *Resolver {
+	return &Resolver{
+		stdin: stdin,
+		files: make(map[string]string),
+	}
+}
+
+// Resolve returns the value of the secret referenced by ref. An empty ref resolves to an empty value. Trailing line
+// breaks are removed from values read from files.
+func (r *Resolver) Resolve(ref string) (string, error) {
+	if ref == "" {
+		return "", nil
+	}
+	source, name, found := strings.Cut(ref, ":")
+	if !found || name == "" {
+		return "", fmt.Errorf("invalid secret reference %q, must be of the form <source>:<name> with source one of: %s, %s, %s", ref, FileSource, EnvSource, StdinSource)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch source {
+	case FileSource:
+		return r.resolveFile(name)
+	case EnvSource:
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s referenced by secret is not set", name)
+		}
+		return value, nil
+	case StdinSource:
+		return r.resolveStdin(name)
+	default:
+		return "", fmt.Errorf("unsupported secret source %q, must be one of: %s, %s, %s", source, FileSource, EnvSource, StdinSource)
+	}
+}
+
+func (r *Resolver) resolveFile(path string) (string, error) {
+	if value, ok := r.files[path]; ok {
+		return value, nil
+	}
+	data, err := os.ReadFile(path) // #nosec G304 -- path is configured by the operator.
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret file: %w", err)
+	}
+	value := strings.TrimRight(string(data), "\r\n")
+	r.files[path] = value
+	return value, nil
+}
+
+func (r *Resolver) resolveStdin(name string) (string, error) {
+	if r.stdinSecret == nil && r.stdinErr == nil {
+		r.stdinSecret, r.stdinErr = parseSecrets(r.stdin)
+	}
+	if r.stdinErr != nil {
+		return "", fmt.Errorf("failed to read secrets from stdin: %w", r.stdinErr)
+	}
+	value, ok := r.stdinSecret[name]
+	if !ok {
+		return "", fmt.Errorf("secret %s is not provided via stdin", name)
+	}
+	return value, nil
+}
+
+func parseSecrets(reader io.Reader) (map[string]string, error) {
+	secrets := make(map[string]string)
+	if reader == nil {
+		return secrets, nil
+	}
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		name, value, found := strings.Cut(line, "=")
+		if !found || name == "" {
+			return nil, fmt.Errorf("invalid line, must be of the form <name>=<value>")
+		}
+		secrets[name] = value
+	}
+	return secrets, scanner.Err()
+}
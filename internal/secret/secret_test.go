@@ -0,0 +1,59 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package secret
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestResolve(t *testing.T) {
+	g := NewWithT(t)
+	secretFilePath := filepath.Join(t.TempDir(), "passphrase")
+	g.Expect(os.WriteFile(secretFilePath, []byte("from-file\n"), 0600)).To(Succeed())
+	t.Setenv("ETCD_WRAPPER_TEST_SECRET", "from-env")
+
+	table := []struct {
+		description   string
+		ref           string
+		stdin         string
+		expectedValue string
+		expectError   bool
+	}{
+		{"should resolve empty reference to empty value", "", "", "", false},
+		{"should read secret from file without trailing line break", "file:" + secretFilePath, "", "from-file", false},
+		{"should return error when file does not exist", "file:/does/not/exist", "", "", true},
+		{"should read secret from environment variable", "env:ETCD_WRAPPER_TEST_SECRET", "", "from-env", false},
+		{"should return error when environment variable is not set", "env:ETCD_WRAPPER_TEST_UNSET", "", "", true},
+		{"should read named secret from stdin", "stdin:password", "user=root\npassword=p=ss\n", "p=ss", false},
+		{"should return error when secret is not provided via stdin", "stdin:password", "user=root\n", "", true},
+		{"should return error when stdin is malformed", "stdin:password", "password\n", "", true},
+		{"should return error for unsupported source", "vault:password", "", "", true},
+		{"should return error for reference without name", "file:", "", "", true},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		value, err := NewResolver(strings.NewReader(entry.stdin)).Resolve(entry.ref)
+		g.Expect(err != nil).To(Equal(entry.expectError))
+		g.Expect(value).To(Equal(entry.expectedValue))
+	}
+}
+
+func TestResolveReadsStdinOnce(t *testing.T) {
+	g := NewWithT(t)
+	resolver := NewResolver(strings.NewReader("passphrase=foo\npassword=bar\n"))
+
+	passphrase, err := resolver.Resolve("stdin:passphrase")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(passphrase).To(Equal("foo"))
+	password, err := resolver.Resolve("stdin:password")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(password).To(Equal("bar"))
+}
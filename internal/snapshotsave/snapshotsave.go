@@ -0,0 +1,164 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package snapshotsave saves a snapshot of the etcd DB of a single member through its maintenance API, independent of
+// backup-restore, e.g. for an ad-hoc backup before a risky operation.
+package snapshotsave
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"time"
+
+	"go.etcd.io/etcd/clientv3"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+// ErrHashMismatch is returned if the SHA-256 hash appended to the snapshot by etcd does not match its content.
+var ErrHashMismatch = errors.New("snapshot is corrupted, its SHA-256 hash does not match its content")
+
+// Config is the configuration of the client used to save the snapshot.
+type Config struct {
+	// Endpoint is the client URL of the member whose DB is saved.
+	Endpoint string
+	// TLS is the TLS configuration of the client, nil if TLS is disabled.
+	TLS *tls.Config
+	// Username and Password are the credentials of the client if auth is enabled in etcd.
+	Username string
+	Password string
+	// DialTimeout is the time after which connecting to the member, and getting its status, fails.
+	DialTimeout time.Duration
+	// DialOptions are additional dial options of the client.
+	DialOptions []grpc.DialOption
+	// LogConfig is the configuration of the logger of the client.
+	LogConfig *zap.Config
+}
+
+// Result describes a saved snapshot.
+type Result struct {
+	// Path is the path of the saved snapshot.
+	Path string `json:"path"`
+	// Revision is the revision of the member when the snapshot was requested.
+	Revision int64 `json:"revision"`
+	// SizeBytes is the size of the saved snapshot including the appended hash.
+	SizeBytes int64 `json:"sizeBytes"`
+	// SHA256 is the hexadecimal SHA-256 hash of the snapshot as appended by etcd, which has been verified.
+	SHA256 string `json:"sha256"`
+	// Duration is the time taken to stream and verify the snapshot.
+	Duration string `json:"duration"`
+}
+
+// Save streams a snapshot of the DB of the member at config.Endpoint into path and verifies it against the SHA-256
+// hash appended by etcd. The snapshot is written into a temporary file next to path, which is only renamed to path once
+// it has been verified and synced, so that path never holds a partial or corrupted snapshot.
+func Save(ctx context.Context, config Config, path string) (*Result, error) {
+	client, err := clientv3.New(clientv3.Config{
+		Context:     ctx,
+		Endpoints:   []string{config.Endpoint},
+		DialTimeout: config.DialTimeout,
+		TLS:         config.TLS,
+		Username:    config.Username,
+		Password:    config.Password,
+		DialOptions: config.DialOptions,
+		LogConfig:   config.LogConfig,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = client.Close()
+	}()
+
+	start := time.Now()
+	// the client does not wait for the connection, so that an unreachable member would only fail once ctx is done.
+	statusCtx, cancelFunc := context.WithTimeout(ctx, config.DialTimeout)
+	status, err := client.Status(statusCtx, config.Endpoint)
+	cancelFunc()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status of %s: %w", config.Endpoint, err)
+	}
+	snapshot, err := client.Snapshot(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request snapshot from %s: %w", config.Endpoint, err)
+	}
+	defer func() {
+		_ = snapshot.Close()
+	}()
+	size, digest, err := write(snapshot, path)
+	if err != nil {
+		return nil, err
+	}
+	return &Result{
+		Path:      path,
+		Revision:  status.Header.Revision,
+		SizeBytes: size,
+		SHA256:    hex.EncodeToString(digest),
+		Duration:  time.Since(start).Round(time.Millisecond).String(),
+	}, nil
+}
+
+// write writes the snapshot read from r into path, verifies it and returns its size and the verified hash.
+func write(r io.Reader, path string) (int64, []byte, error) {
+	partPath := path + ".part"
+	file, err := os.OpenFile(partPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600) // #nosec G304 -- path is passed by the operator.
+	if err != nil {
+		return 0, nil, err
+	}
+	defer func() {
+		_ = file.Close()
+		_ = os.Remove(partPath)
+	}()
+	verifier := &hashVerifier{hash: sha256.New()}
+	size, err := io.Copy(io.MultiWriter(file, verifier), r)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to stream snapshot: %w", err)
+	}
+	if err = verifier.verify(); err != nil {
+		return 0, nil, err
+	}
+	if err = file.Sync(); err != nil {
+		return 0, nil, err
+	}
+	if err = file.Close(); err != nil {
+		return 0, nil, err
+	}
+	if err = os.Rename(partPath, path); err != nil {
+		return 0, nil, err
+	}
+	return size, verifier.tail, nil
+}
+
+// hashVerifier hashes everything written to it except the last sha256.Size bytes, which etcd appends as the hash of the
+// preceding content of a snapshot.
+type hashVerifier struct {
+	hash hash.Hash
+	tail []byte
+}
+
+func (v *hashVerifier) Write(p []byte) (int, error) {
+	buf := append(v.tail, p...)
+	if excess := len(buf) - sha256.Size; excess > 0 {
+		_, _ = v.hash.Write(buf[:excess])
+		buf = buf[excess:]
+	}
+	v.tail = append([]byte(nil), buf...)
+	return len(p), nil
+}
+
+// verify returns ErrHashMismatch unless the last sha256.Size bytes are the hash of the preceding content.
+func (v *hashVerifier) verify() error {
+	if len(v.tail) < sha256.Size || !bytes.Equal(v.hash.Sum(nil), v.tail) {
+		return ErrHashMismatch
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package snapshotsave
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"go.etcd.io/etcd/clientv3"
+	"go.etcd.io/etcd/embed"
+)
+
+func TestWrite(t *testing.T) {
+	content := bytes.Repeat([]byte("etcd"), 100000)
+	digest := sha256.Sum256(content)
+	valid := append(append([]byte(nil), content...), digest[:]...)
+	corrupted := append([]byte(nil), valid...)
+	corrupted[42]++
+
+	table := []struct {
+		description string
+		snapshot    []byte
+		expectError bool
+	}{
+		{"should write a snapshot whose hash matches", valid, false},
+		{"should reject a snapshot whose hash does not match", corrupted, true},
+		{"should reject a snapshot shorter than a hash", digest[:10], true},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		path := filepath.Join(t.TempDir(), "snapshot.db")
+		// read in small chunks, so that the hash spans several writes.
+		size, verified, err := write(iotest.HalfReader(bytes.NewReader(entry.snapshot)), path)
+		g.Expect(err != nil).To(Equal(entry.expectError))
+		g.Expect(path + ".part").ToNot(BeAnExistingFile())
+		if entry.expectError {
+			g.Expect(err).To(MatchError(ErrHashMismatch))
+			g.Expect(path).ToNot(BeAnExistingFile())
+			continue
+		}
+		g.Expect(size).To(Equal(int64(len(entry.snapshot))))
+		g.Expect(verified).To(Equal(digest[:]))
+		written, err := os.ReadFile(path)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(written).To(Equal(entry.snapshot))
+	}
+}
+
+func TestSave(t *testing.T) {
+	g := NewWithT(t)
+	cfg := embed.NewConfig()
+	cfg.Dir = t.TempDir()
+	cfg.Logger = "zap"
+	cfg.LogOutputs = []string{"/dev/null"}
+	clientURL := url.URL{Scheme: "http", Host: freeLocalAddress(g)}
+	peerURL := url.URL{Scheme: "http", Host: freeLocalAddress(g)}
+	cfg.ListenClientUrls, cfg.AdvertiseClientUrls = []url.URL{clientURL}, []url.URL{clientURL}
+	cfg.ListenPeerUrls, cfg.AdvertisePeerUrls = []url.URL{peerURL}, []url.URL{peerURL}
+	cfg.InitialCluster = cfg.InitialClusterFromName(cfg.Name)
+	etcd, err := embed.StartEtcd(cfg)
+	g.Expect(err).ToNot(HaveOccurred())
+	defer etcd.Close()
+	select {
+	case <-etcd.Server.ReadyNotify():
+	case <-time.After(time.Minute):
+		t.Fatal("timed out waiting for etcd to be ready")
+	}
+	client, err := clientv3.New(clientv3.Config{Endpoints: []string{clientURL.String()}, DialTimeout: time.Second})
+	g.Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		_ = client.Close()
+	}()
+	put, err := client.Put(context.Background(), "foo", "bar")
+	g.Expect(err).ToNot(HaveOccurred())
+
+	path := filepath.Join(t.TempDir(), "snapshot.db")
+	result, err := Save(context.Background(), Config{Endpoint: clientURL.String(), DialTimeout: time.Second}, path)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(result.Path).To(Equal(path))
+	g.Expect(result.Revision).To(Equal(put.Header.Revision))
+	written, err := os.ReadFile(path)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(result.SizeBytes).To(Equal(int64(len(written))))
+	g.Expect(result.SHA256).To(Equal(hex.EncodeToString(written[len(written)-sha256.Size:])))
+
+	t.Log("should fail if the member cannot be reached")
+	_, err = Save(context.Background(), Config{Endpoint: "http://" + freeLocalAddress(g), DialTimeout: 500 * time.Millisecond}, filepath.Join(t.TempDir(), "snapshot.db"))
+	g.Expect(err).To(HaveOccurred())
+}
+
+func freeLocalAddress(g *WithT) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		g.Expect(listener.Close()).To(Succeed())
+	}()
+	return listener.Addr().String()
+}
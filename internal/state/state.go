@@ -0,0 +1,183 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package state
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/metrics"
+
+	"go.uber.org/zap"
+)
+
+// State is a state of etcd-wrapper during its lifecycle.
+type State string
+
+const (
+	// New is the initial state of etcd-wrapper before bootstrapping has started.
+	New State = "New"
+	// ProbingSidecar indicates that etcd-wrapper is fetching the initialization status from backup-restore.
+	ProbingSidecar State = "ProbingSidecar"
+	// Validating indicates that etcd-wrapper has triggered the validation of the data directory by backup-restore.
+	Validating State = "Validating"
+	// Restoring indicates that backup-restore reports initialization to be in progress, which includes restoring
+	// the data directory if its validation has failed.
+	Restoring State = "Restoring"
+	// StartingEtcd indicates that the data directory has been initialized and the embedded etcd is being started.
+	StartingEtcd State = "StartingEtcd"
+	// Ready indicates that the embedded etcd is ready to serve client requests.
+	Ready State = "Ready"
+	// Stopping indicates that etcd-wrapper is stopping. It is a terminal state.
+	Stopping State = "Stopping"
+	// Failed indicates that bootstrapping or running the embedded etcd has failed.
+	Failed State = "Failed"
+)
+
+// AllStates is the list of all states.
+var AllStates = []State{New, ProbingSidecar, Validating, Restoring, StartingEtcd, Ready, Stopping, Failed}
+
+// validTransitions maps a state to all states that can be transitioned to from it.
+var validTransitions = map[State][]State{
+	New:            {ProbingSidecar, Failed, Stopping},
+	ProbingSidecar: {Validating, Restoring, StartingEtcd, Failed, Stopping},
+	Validating:     {Restoring, StartingEtcd, Failed, Stopping},
+	Restoring:      {Validating, StartingEtcd, Failed, Stopping},
+	StartingEtcd:   {Ready, Failed, Stopping},
+	Ready:          {StartingEtcd, Validating, Failed, Stopping},
+	Failed:         {Stopping},
+	Stopping:       {},
+}
+
+// Transition is a recorded change from one state to another.
+type Transition struct {
+	// From is the state before the transition.
+	From State `json:"from"`
+	// To is the state after the transition.
+	To State `json:"to"`
+	// Timestamp is the time at which the transition happened.
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Machine models the lifecycle of etcd-wrapper as a state machine with explicit states and transitions.
+// Every transition is logged and reflected in metrics. It is safe for concurrent use.
+type Machine struct {
+	mu          sync.RWMutex
+	current     State
+	since       time.Time
+	transitions []Transition
+	listeners   []subscription
+	nextID      uint64
+	logger      *zap.Logger
+}
+
+// subscription is a listener registered with a Machine.
+type subscription struct {
+	id       uint64
+	listener func(Transition)
+}
+
+// NewMachine creates a Machine in state New.
+func NewMachine(logger *zap.Logger) *Machine {
+	m := &Machine{
+		current: New,
+		since:   time.Now(),
+		logger:  logger,
+	}
+	updateStateMetric(New)
+	return m
+}
+
+// Current returns the current state and the time since when the Machine is in that state.
+func (m *Machine) Current() (State, time.Time) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.current, m.since
+}
+
+// Transitions returns all transitions made so far, oldest first.
+func (m *Machine) Transitions() []Transition {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return slices.Clone(m.transitions)
+}
+
+// Subscribe registers a listener which is called with every transition made after it has been registered. Listeners
+// are called synchronously, in order of registration, after the transition has been made.
+func (m *Machine) Subscribe(listener func(Transition)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.subscribe(listener)
+}
+
+// Watch registers a listener like Subscribe and atomically returns all transitions made before, so that a watcher
+// neither misses nor sees a transition twice. The returned cancel function deregisters the listener. A transition in
+// progress while cancelling may still be passed to the listener.
+func (m *Machine) Watch(listener func(Transition)) ([]Transition, func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	id := m.subscribe(listener)
+	return slices.Clone(m.transitions), func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.listeners = slices.DeleteFunc(m.listeners, func(s subscription) bool { return s.id == id })
+	}
+}
+
+func (m *Machine) subscribe(listener func(Transition)) uint64 {
+	m.nextID++
+	m.listeners = append(m.listeners, subscription{id: m.nextID, listener: listener})
+	return m.nextID
+}
+
+// TransitionTo transitions the Machine to the given state. Transitioning to the current state is a no-op.
+// An error is returned if the transition is not allowed from the current state.
+func (m *Machine) TransitionTo(to State) error {
+	transition, listeners, err := m.transitionTo(to)
+	if transition == nil {
+		return err
+	}
+	for _, listener := range listeners {
+		listener(*transition)
+	}
+	return nil
+}
+
+func (m *Machine) transitionTo(to State) (*Transition, []func(Transition), error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	from := m.current
+	if from == to {
+		return nil, nil, nil
+	}
+	if !slices.Contains(validTransitions[from], to) {
+		return nil, nil, fmt.Errorf("invalid state transition from %s to %s", from, to)
+	}
+	now := time.Now()
+	m.logger.Info("etcd-wrapper state transition", zap.String("from", string(from)), zap.String("to", string(to)), zap.Duration("timeInPreviousState", now.Sub(m.since)))
+	m.current = to
+	m.since = now
+	transition := Transition{From: from, To: to, Timestamp: now}
+	m.transitions = append(m.transitions, transition)
+	updateStateMetric(to)
+	metrics.StateTransitionsTotal.WithLabelValues(string(from), string(to)).Inc()
+	listeners := make([]func(Transition), 0, len(m.listeners))
+	for _, s := range m.listeners {
+		listeners = append(listeners, s.listener)
+	}
+	return &transition, listeners, nil
+}
+
+func updateStateMetric(current State) {
+	for _, s := range AllStates {
+		value := 0.0
+		if s == current {
+			value = 1
+		}
+		metrics.State.WithLabelValues(string(s)).Set(value)
+	}
+}
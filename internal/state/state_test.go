@@ -0,0 +1,107 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package state
+
+import (
+	"testing"
+
+	"github.com/gardener/etcd-wrapper/internal/metrics"
+
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestTransitionTo(t *testing.T) {
+	table := []struct {
+		description   string
+		transitions   []State
+		expectError   bool
+		expectedState State
+	}{
+		{"should transition through a successful bootstrap", []State{ProbingSidecar, Validating, Restoring, StartingEtcd, Ready}, false, Ready},
+		{"should allow restarting etcd once ready", []State{ProbingSidecar, StartingEtcd, Ready, StartingEtcd, Ready}, false, Ready},
+		{"should allow validating the data directory on demand once ready", []State{ProbingSidecar, StartingEtcd, Ready, Validating, Restoring, StartingEtcd, Ready}, false, Ready},
+		{"should treat transition to current state as no-op", []State{ProbingSidecar, ProbingSidecar}, false, ProbingSidecar},
+		{"should allow failing from any non-terminal state", []State{ProbingSidecar, Validating, Failed}, false, Failed},
+		{"should disallow skipping bootstrap", []State{Ready}, true, New},
+		{"should disallow leaving the terminal state", []State{Stopping, ProbingSidecar}, true, Stopping},
+	}
+
+	for _, entry := range table {
+		t.Run(entry.description, func(t *testing.T) {
+			g := NewWithT(t)
+			m := NewMachine(zaptest.NewLogger(t))
+			var err error
+			for _, s := range entry.transitions {
+				if err = m.TransitionTo(s); err != nil {
+					break
+				}
+			}
+			g.Expect(err != nil).To(Equal(entry.expectError))
+			current, _ := m.Current()
+			g.Expect(current).To(Equal(entry.expectedState))
+		})
+	}
+}
+
+func TestTransitions(t *testing.T) {
+	g := NewWithT(t)
+	m := NewMachine(zaptest.NewLogger(t))
+	g.Expect(m.TransitionTo(ProbingSidecar)).To(Succeed())
+	g.Expect(m.TransitionTo(StartingEtcd)).To(Succeed())
+
+	transitions := m.Transitions()
+	g.Expect(transitions).To(HaveLen(2))
+	g.Expect(transitions[0].From).To(Equal(New))
+	g.Expect(transitions[0].To).To(Equal(ProbingSidecar))
+	g.Expect(transitions[1].From).To(Equal(ProbingSidecar))
+	g.Expect(transitions[1].To).To(Equal(StartingEtcd))
+
+	g.Expect(gaugeValue(g, metrics.State.WithLabelValues(string(StartingEtcd)))).To(Equal(1.0))
+	g.Expect(gaugeValue(g, metrics.State.WithLabelValues(string(ProbingSidecar)))).To(Equal(0.0))
+}
+
+func TestSubscribe(t *testing.T) {
+	g := NewWithT(t)
+	m := NewMachine(zaptest.NewLogger(t))
+	var notified []Transition
+	m.Subscribe(func(transition Transition) {
+		current, _ := m.Current()
+		g.Expect(current).To(Equal(transition.To))
+		notified = append(notified, transition)
+	})
+
+	g.Expect(m.TransitionTo(ProbingSidecar)).To(Succeed())
+	g.Expect(m.TransitionTo(ProbingSidecar)).To(Succeed())
+	g.Expect(m.TransitionTo(Ready)).ToNot(Succeed())
+	g.Expect(m.TransitionTo(StartingEtcd)).To(Succeed())
+	g.Expect(notified).To(Equal(m.Transitions()))
+}
+
+func TestWatch(t *testing.T) {
+	g := NewWithT(t)
+	m := NewMachine(zaptest.NewLogger(t))
+	g.Expect(m.TransitionTo(ProbingSidecar)).To(Succeed())
+
+	var notified []Transition
+	past, cancel := m.Watch(func(transition Transition) {
+		notified = append(notified, transition)
+	})
+	g.Expect(past).To(Equal(m.Transitions()))
+
+	g.Expect(m.TransitionTo(StartingEtcd)).To(Succeed())
+	cancel()
+	g.Expect(m.TransitionTo(Ready)).To(Succeed())
+	g.Expect(notified).To(HaveLen(1))
+	g.Expect(notified[0].To).To(Equal(StartingEtcd))
+}
+
+func gaugeValue(g *WithT, gauge prometheus.Gauge) float64 {
+	m := &dto.Metric{}
+	g.Expect(gauge.Write(m)).To(Succeed())
+	return m.GetGauge().GetValue()
+}
@@ -12,6 +12,7 @@ import (
 	"encoding/pem"
 	"fmt"
 	"math/big"
+	"net"
 	"os"
 	"path/filepath"
 	"time"
@@ -47,6 +48,22 @@ func pemEncode(bytes []byte, blockType string) []byte {
 	return pem.EncodeToMemory(&block)
 }
 
+// EncodeAndWriteEncrypted encodes the certificates and private key in PEM format, encrypting the private key with the
+// passphrase, and writes them to the provided directory.
+func (c *CertKeyPair) EncodeAndWriteEncrypted(dir string, certFileName, keyFileName, passphrase string) error {
+	keyBlock, err := x509.EncryptPEMBlock(rand.Reader, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(&c.PrivateKey), []byte(passphrase), x509.PEMCipherAES256) //nolint:staticcheck
+	if err != nil {
+		return fmt.Errorf("failed to encrypt private key: %v", err)
+	}
+	if err = os.WriteFile(filepath.Join(dir, keyFileName), pem.EncodeToMemory(keyBlock), 0600); err != nil {
+		return fmt.Errorf("failed to write private key to dir: %s: err: %v", dir, err)
+	}
+	if err = os.WriteFile(filepath.Join(dir, certFileName), pemEncode(c.CertBytes, "CERTIFICATE"), 0600); err != nil {
+		return fmt.Errorf("failed to write certificate to dir: %s: err: %v", dir, err)
+	}
+	return nil
+}
+
 // EncodeAndWrite encodes the certificates and private key in PEM format and writes it to the provided directory.
 func (c *CertKeyPair) EncodeAndWrite(dir string, certFileName, keyFileName string) error {
 	key := x509.MarshalPKCS1PrivateKey(&c.PrivateKey)
@@ -116,6 +133,31 @@ func (t *TLSResourceCreator) CreateETCDClientCertAndKey() (*CertKeyPair, error)
 	}, nil
 }
 
+// CreateServerCertAndKey creates a server certificate for the given IP addresses and its private key.
+func (t *TLSResourceCreator) CreateServerCertAndKey(ipAddresses ...net.IP) (*CertKeyPair, error) {
+	serverCertTemplate, err := createCertTemplate("etcd-server")
+	if err != nil {
+		return nil, err
+	}
+	serverCertTemplate.KeyUsage = x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
+	serverCertTemplate.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	serverCertTemplate.IPAddresses = ipAddresses
+
+	serverPrivateKey, err := rsa.GenerateKey(rand.Reader, 4096)
+	if err != nil {
+		return nil, err
+	}
+
+	serverCertBytes, err := x509.CreateCertificate(rand.Reader, serverCertTemplate, t.caCert, serverPrivateKey.Public(), t.caPrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	return &CertKeyPair{
+		CertBytes:  serverCertBytes,
+		PrivateKey: *serverPrivateKey,
+	}, nil
+}
+
 func createCACertTemplate() (*x509.Certificate, error) {
 	caTemplate, err := createCertTemplate("etcd-ca")
 	if err != nil {
@@ -0,0 +1,160 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package tlscheck validates configured certificate and key pairs before they are used, so that misconfigurations
+// surface with precise messages instead of opaque TLS handshake errors once etcd is running, and determines when
+// configured certificates expire.
+package tlscheck
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+)
+
+// Pair is a certificate and key pair and the requirements it has to fulfill.
+type Pair struct {
+	// Name describes the purpose of the pair in error messages, e.g. `peer`.
+	Name string
+	// CertPath is the path of the PEM encoded certificate, optionally followed by intermediate certificates.
+	CertPath string
+	// KeyPath is the path of the PEM encoded private key of the certificate.
+	KeyPath string
+	// TrustedCAPath is the path of the CA bundle the certificate has to be signed by. It is not verified if empty.
+	TrustedCAPath string
+	// ExtKeyUsages are the extended key usages the certificate has to permit.
+	ExtKeyUsages []x509.ExtKeyUsage
+	// Hosts are the host names or IP addresses the certificate has to be valid for.
+	Hosts []string
+}
+
+// Check verifies that the certificate and key of pair can be loaded and match each other, that the certificate is
+// valid for at least minValidity after now, that it permits the required key usages, that it is valid for all hosts of
+// pair and that it is signed by the trusted CA. All violations are returned joined into a single error.
+func Check(pair Pair, now time.Time, minValidity time.Duration) error {
+	certificate, err := tls.LoadX509KeyPair(pair.CertPath, pair.KeyPath)
+	if err != nil {
+		return fmt.Errorf("%s certificate %s and key %s cannot be loaded: %w", pair.Name, pair.CertPath, pair.KeyPath, err)
+	}
+	leaf, err := x509.ParseCertificate(certificate.Certificate[0])
+	if err != nil {
+		return fmt.Errorf("%s certificate %s cannot be parsed: %w", pair.Name, pair.CertPath, err)
+	}
+	var errs []error
+	switch {
+	case now.Before(leaf.NotBefore):
+		errs = append(errs, fmt.Errorf("%s certificate %s is not valid before %s", pair.Name, pair.CertPath, leaf.NotBefore.UTC().Format(time.RFC3339)))
+	case !now.Before(leaf.NotAfter):
+		errs = append(errs, fmt.Errorf("%s certificate %s has expired at %s", pair.Name, pair.CertPath, leaf.NotAfter.UTC().Format(time.RFC3339)))
+	case leaf.NotAfter.Sub(now) < minValidity:
+		errs = append(errs, fmt.Errorf("%s certificate %s expires at %s, which is within the minimum validity of %s", pair.Name, pair.CertPath, leaf.NotAfter.UTC().Format(time.RFC3339), minValidity))
+	}
+	if leaf.KeyUsage != 0 && leaf.KeyUsage&x509.KeyUsageDigitalSignature == 0 {
+		errs = append(errs, fmt.Errorf("%s certificate %s does not permit the key usage digital signature", pair.Name, pair.CertPath))
+	}
+	for _, usage := range pair.ExtKeyUsages {
+		if !permitsExtKeyUsage(leaf, usage) {
+			errs = append(errs, fmt.Errorf("%s certificate %s does not permit the extended key usage %s", pair.Name, pair.CertPath, extKeyUsageName(usage)))
+		}
+	}
+	for _, host := range pair.Hosts {
+		if err = leaf.VerifyHostname(host); err != nil {
+			errs = append(errs, fmt.Errorf("%s certificate %s does not cover %s in its SANs %v", pair.Name, pair.CertPath, host, sans(leaf)))
+		}
+	}
+	if pair.TrustedCAPath != "" {
+		if err = verifyChain(certificate, leaf, pair.TrustedCAPath, now); err != nil {
+			errs = append(errs, fmt.Errorf("%s certificate %s is not signed by the CA bundle %s: %w", pair.Name, pair.CertPath, pair.TrustedCAPath, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Expiry returns the earliest expiry of the PEM encoded certificates in the file at path, e.g. of the certificate and
+// its intermediates, or of all CAs of a bundle.
+func Expiry(path string) (time.Time, error) {
+	content, err := os.ReadFile(path) // #nosec G304 -- path is configured by the operator.
+	if err != nil {
+		return time.Time{}, err
+	}
+	var expiry time.Time
+	for block, rest := pem.Decode(content); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		certificate, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%s contains an unparseable certificate: %w", path, err)
+		}
+		if expiry.IsZero() || certificate.NotAfter.Before(expiry) {
+			expiry = certificate.NotAfter
+		}
+	}
+	if expiry.IsZero() {
+		return time.Time{}, fmt.Errorf("%s does not contain any PEM encoded certificate", path)
+	}
+	return expiry, nil
+}
+
+// verifyChain verifies that leaf chains up to a CA of the bundle at caPath via the intermediates of certificate. Key
+// usages and validity are checked by Check with more precise messages.
+func verifyChain(certificate tls.Certificate, leaf *x509.Certificate, caPath string, now time.Time) error {
+	bundle, err := os.ReadFile(caPath) // #nosec G304 -- path is configured by the operator.
+	if err != nil {
+		return err
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(bundle) {
+		return errors.New("the CA bundle does not contain any certificate")
+	}
+	intermediates := x509.NewCertPool()
+	for _, der := range certificate.Certificate[1:] {
+		if intermediate, err := x509.ParseCertificate(der); err == nil {
+			intermediates.AddCert(intermediate)
+		}
+	}
+	currentTime := now
+	if now.Before(leaf.NotBefore) || !now.Before(leaf.NotAfter) {
+		currentTime = leaf.NotBefore
+	}
+	_, err = leaf.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   currentTime,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	return err
+}
+
+// permitsExtKeyUsage returns whether leaf permits usage. Certificates without extended key usages permit any usage.
+func permitsExtKeyUsage(leaf *x509.Certificate, usage x509.ExtKeyUsage) bool {
+	return len(leaf.ExtKeyUsage) == 0 && len(leaf.UnknownExtKeyUsage) == 0 ||
+		slices.Contains(leaf.ExtKeyUsage, x509.ExtKeyUsageAny) ||
+		slices.Contains(leaf.ExtKeyUsage, usage)
+}
+
+func extKeyUsageName(usage x509.ExtKeyUsage) string {
+	switch usage {
+	case x509.ExtKeyUsageServerAuth:
+		return "server auth"
+	case x509.ExtKeyUsageClientAuth:
+		return "client auth"
+	default:
+		return fmt.Sprintf("%d", usage)
+	}
+}
+
+// sans returns the DNS names and IP addresses of leaf.
+func sans(leaf *x509.Certificate) []string {
+	names := slices.Clone(leaf.DNSNames)
+	for _, ip := range leaf.IPAddresses {
+		names = append(names, ip.String())
+	}
+	return names
+}
@@ -0,0 +1,148 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package tlscheck
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+var now = time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+func TestCheck(t *testing.T) {
+	g := NewWithT(t)
+	dir := t.TempDir()
+	ca, caKey := newCA(g)
+	caPath := writePEM(g, dir, "ca.crt", "CERTIFICATE", ca.Raw)
+	otherCA, _ := newCA(g)
+	otherCAPath := writePEM(g, dir, "other-ca.crt", "CERTIFICATE", otherCA.Raw)
+
+	peer := func(modify func(*x509.Certificate)) *x509.Certificate {
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(2),
+			Subject:      pkix.Name{CommonName: "etcd-main-peer"},
+			NotBefore:    now.Add(-time.Hour),
+			NotAfter:     now.Add(30 * 24 * time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+			DNSNames:     []string{"*.etcd-main-peer.default.svc"},
+			IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		}
+		if modify != nil {
+			modify(template)
+		}
+		return template
+	}
+
+	table := []struct {
+		description    string
+		template       *x509.Certificate
+		caPath         string
+		minValidity    time.Duration
+		expectedErrors []string
+	}{
+		{"should accept a valid certificate", peer(nil), caPath, 0, nil},
+		{"should reject a certificate which is not yet valid", peer(func(c *x509.Certificate) { c.NotBefore = now.Add(time.Hour) }), caPath, 0,
+			[]string{"is not valid before 2024-06-01T01:00:00Z"}},
+		{"should reject an expired certificate", peer(func(c *x509.Certificate) { c.NotAfter = now.Add(-time.Minute) }), caPath, 0,
+			[]string{"has expired at 2024-05-31T23:59:00Z"}},
+		{"should reject a certificate expiring within the minimum validity", peer(nil), caPath, 60 * 24 * time.Hour,
+			[]string{"expires at 2024-07-01T00:00:00Z, which is within the minimum validity of 1440h0m0s"}},
+		{"should reject a certificate without digital signature", peer(func(c *x509.Certificate) { c.KeyUsage = x509.KeyUsageKeyEncipherment }), caPath, 0,
+			[]string{"does not permit the key usage digital signature"}},
+		{"should reject a certificate missing an extended key usage", peer(func(c *x509.Certificate) { c.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth} }), caPath, 0,
+			[]string{"does not permit the extended key usage client auth"}},
+		{"should accept a certificate without extended key usages", peer(func(c *x509.Certificate) { c.ExtKeyUsage = nil }), caPath, 0, nil},
+		{"should reject a certificate not covering a host", peer(func(c *x509.Certificate) { c.DNSNames = []string{"etcd-main-0.etcd-main-peer.default.svc"} }), caPath, 0,
+			[]string{"does not cover etcd-main-1.etcd-main-peer.default.svc in its SANs [etcd-main-0.etcd-main-peer.default.svc 127.0.0.1]"}},
+		{"should reject a certificate not signed by the trusted CA", peer(nil), otherCAPath, 0,
+			[]string{"is not signed by the CA bundle " + otherCAPath}},
+		{"should skip the CA verification without trusted CA", peer(nil), "", 0, nil},
+		{"should report all violations", peer(func(c *x509.Certificate) { c.NotAfter = now.Add(-time.Minute); c.IPAddresses = nil }), otherCAPath, 0,
+			[]string{"has expired", "does not cover 127.0.0.1", "is not signed by the CA bundle"}},
+	}
+	for i, entry := range table {
+		t.Log(entry.description)
+		certPath, keyPath := writeCertAndKey(g, filepath.Join(dir, string(rune('a'+i))), entry.template, ca, caKey)
+		err := Check(Pair{
+			Name:          "peer",
+			CertPath:      certPath,
+			KeyPath:       keyPath,
+			TrustedCAPath: entry.caPath,
+			ExtKeyUsages:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+			Hosts:         []string{"etcd-main-1.etcd-main-peer.default.svc", "127.0.0.1"},
+		}, now, entry.minValidity)
+		if entry.expectedErrors == nil {
+			g.Expect(err).ToNot(HaveOccurred())
+			continue
+		}
+		g.Expect(err).To(HaveOccurred())
+		for _, expectedError := range entry.expectedErrors {
+			g.Expect(err.Error()).To(ContainSubstring("peer certificate " + certPath + " " + expectedError))
+		}
+	}
+}
+
+func TestCheckMismatchingKey(t *testing.T) {
+	g := NewWithT(t)
+	dir := t.TempDir()
+	ca, caKey := newCA(g)
+	template := &x509.Certificate{SerialNumber: big.NewInt(2), NotBefore: now.Add(-time.Hour), NotAfter: now.Add(time.Hour)}
+	certPath, _ := writeCertAndKey(g, filepath.Join(dir, "a"), template, ca, caKey)
+	_, keyPath := writeCertAndKey(g, filepath.Join(dir, "b"), template, ca, caKey)
+
+	err := Check(Pair{Name: "client", CertPath: certPath, KeyPath: keyPath}, now, 0)
+	g.Expect(err).To(MatchError(ContainSubstring("client certificate " + certPath + " and key " + keyPath + " cannot be loaded")))
+}
+
+// newCA creates a self-signed CA valid around now.
+func newCA(g *WithT) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	g.Expect(err).ToNot(HaveOccurred())
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "etcd-ca"},
+		NotBefore:             now.Add(-24 * time.Hour),
+		NotAfter:              now.Add(365 * 24 * time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	g.Expect(err).ToNot(HaveOccurred())
+	ca, err := x509.ParseCertificate(der)
+	g.Expect(err).ToNot(HaveOccurred())
+	return ca, key
+}
+
+// writeCertAndKey signs template with the CA and writes the certificate and its key into dir.
+func writeCertAndKey(g *WithT, dir string, template, ca *x509.Certificate, caKey *ecdsa.PrivateKey) (string, string) {
+	g.Expect(os.MkdirAll(dir, 0700)).To(Succeed())
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	g.Expect(err).ToNot(HaveOccurred())
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, key.Public(), caKey)
+	g.Expect(err).ToNot(HaveOccurred())
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	g.Expect(err).ToNot(HaveOccurred())
+	return writePEM(g, dir, "tls.crt", "CERTIFICATE", der), writePEM(g, dir, "tls.key", "EC PRIVATE KEY", keyDER)
+}
+
+func writePEM(g *WithT, dir, name, blockType string, der []byte) string {
+	path := filepath.Join(dir, name)
+	g.Expect(os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600)).To(Succeed())
+	return path
+}
@@ -5,10 +5,22 @@
 package types
 
 import (
+	"crypto/x509"
+	"encoding/pem"
 	"errors"
 	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/gardener/etcd-wrapper/internal/httpserver"
+	"github.com/gardener/etcd-wrapper/internal/maintenance"
 	"github.com/gardener/etcd-wrapper/internal/util"
 )
 
@@ -18,10 +30,1621 @@ type Config struct {
 	BackupRestore BackupRestoreConfig
 	// EtcdClientTLS is the TLS configuration required to configure a client when TLS is enabled when interacting with the embedded etcd.
 	EtcdClientTLS EtcdClientTLSConfig
+	// EtcdClientAuth are the credentials used to authenticate against the embedded etcd when auth is enabled.
+	EtcdClientAuth EtcdClientAuthConfig
 	// EtcdClientPort is port when talking to etcd.
 	EtcdClientPort int
 	// EtcdWrapperPort is the server port for etcd-wrapper.
 	EtcdWrapperPort int
+	// HTTPServer is the configuration of the timeouts of the HTTP server of etcd-wrapper.
+	HTTPServer HTTPServerConfig
+	// SkipRestoreVerification disables the verification of the etcd DB against the latest snapshot after initialization.
+	SkipRestoreVerification bool
+	// SkipEmptyDataDirRecovery disables the restoration of a data directory which has been initialized but contains no
+	// WAL, on which etcd would otherwise bootstrap an empty cluster.
+	SkipEmptyDataDirRecovery bool
+	// SkipClientURLSelfTest disables the verification that the advertised client URLs are reachable once etcd is ready.
+	SkipClientURLSelfTest bool
+	// AuditLog is the configuration for the audit log of cluster-mutating operations performed by etcd-wrapper.
+	AuditLog AuditLogConfig
+	// BootstrapHistory is the configuration of the persisted history of start attempts and of the crash loop detection.
+	BootstrapHistory BootstrapHistoryConfig
+	// RestartBudget limits the number of restarts of the embedded etcd by etcd-wrapper.
+	RestartBudget RestartBudgetConfig
+	// CertRotation is the configuration of the restarts required by rotations of the peer CA, which are coordinated across the cluster.
+	CertRotation CertRotationConfig
+	// MaintenanceWindow confines disruptive operations initiated by etcd-wrapper to a recurring maintenance window.
+	MaintenanceWindow MaintenanceWindowConfig
+	// PhaseTimeouts are the timeouts of the individual bootstrap phases.
+	PhaseTimeouts PhaseTimeoutsConfig
+	// SidecarOptional is the configuration of starting etcd without backup-restore if it cannot be reached.
+	SidecarOptional SidecarOptionalConfig
+	// LastKnownGoodConfig is the configuration of the persisted etcd configuration with which etcd last became ready.
+	LastKnownGoodConfig LastKnownGoodConfig
+	// CorruptCheck is the configuration of the corruption checks performed by etcd.
+	CorruptCheck CorruptCheckConfig
+	// RestoreMarker is the configuration of the marker key written into etcd after a restoration.
+	RestoreMarker RestoreMarkerConfig
+	// PostRestoreMaintenance is the configuration of the compaction and defragmentation of the etcd DB after a restoration.
+	PostRestoreMaintenance PostRestoreMaintenanceConfig
+	// RevisionWatermark is the configuration of the persisted highest etcd revision, against which the revision of a
+	// restored data directory is verified.
+	RevisionWatermark RevisionWatermarkConfig
+	// ProposalBackpressure is the configuration of the detection of raft proposal backpressure.
+	ProposalBackpressure ProposalBackpressureConfig
+	// ApplyLag is the configuration of the detection of a divergence between the committed and applied raft index.
+	ApplyLag ApplyLagConfig
+	// StateFilePath is the file path into which the current state of etcd-wrapper and the readiness of etcd are written
+	// on every change, in the format of the annotations file of the Kubernetes downward API. Disabled if empty.
+	StateFilePath string
+	// MemberIdentityFilePath is the file path into which the IDs of the etcd cluster and member are written every time
+	// etcd has become ready, in the format of an env file. Disabled if empty.
+	MemberIdentityFilePath string
+	// ClusterIDPinPath is the file path into which the cluster ID is pinned once etcd has become ready. Before etcd is
+	// started, the cluster ID reported by the peers is verified against the pinned cluster ID. Disabled if empty.
+	ClusterIDPinPath string
+	// InitialClusterToken overrides the initial-cluster-token of the etcd configuration, which is mixed into the IDs of
+	// the cluster and its members when a new cluster is bootstrapped. If empty, the token of the etcd configuration is used.
+	InitialClusterToken string
+	// InitialClusterTokenPath is the file path into which a token generated on the first bootstrap of a single-member
+	// cluster without a configured token is persisted. No token is generated if empty.
+	InitialClusterTokenPath string
+	// RequestSampling is the configuration of the sampling of client requests served by etcd-wrapper for debugging.
+	RequestSampling RequestSamplingConfig
+	// ClientTraffic is the configuration of the tracking of open connections and RPC rates per client.
+	ClientTraffic ClientTrafficConfig
+	// Heartbeat is the configuration of the heartbeat file for external liveness monitors.
+	Heartbeat HeartbeatConfig
+	// BackupFreshness is the configuration of the safety endpoint which reports whether the member is safe to disrupt
+	// based on the age of the latest snapshot taken by backup-restore.
+	BackupFreshness BackupFreshnessConfig
+	// DisableProxyEnv disables the proxies configured via the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables
+	// for all outbound connections of etcd-wrapper, i.e. to backup-restore, to peers and to the embedded etcd.
+	DisableProxyEnv bool
+	// DNS is the configuration of the resolution of host names by the HTTP clients of etcd-wrapper for peers and backup-restore.
+	DNS DNSConfig
+	// AllowEtcdDowngrade allows starting the embedded etcd on a data directory last used by etcd of a newer minor version.
+	AllowEtcdDowngrade bool
+	// PeerTLSServerName is the name expected in the TLS certificates of peers, overriding the host of their peer URLs.
+	PeerTLSServerName string
+	// ExternalClientListener is the configuration of an additional client listener with its own TLS settings, e.g. for
+	// operator access via the service network.
+	ExternalClientListener ExternalClientListenerConfig
+	// ClientUnixSocket is the configuration of an additional client listener of the embedded etcd on a unix socket,
+	// e.g. for sidecars in the same pod.
+	ClientUnixSocket ClientUnixSocketConfig
+	// LogSamplingInterval is the interval within which repetitions of a warning or error of etcd-wrapper are suppressed
+	// and then summarized with their number. Zero disables log sampling.
+	LogSamplingInterval time.Duration
+	// EnrichEtcdLogs switches the embedded etcd to a zap logger which enriches every entry with the member name, the
+	// cluster ID and the state of etcd-wrapper.
+	EnrichEtcdLogs bool
+	// ClockSkewThreshold is the clock skew to other members above which a warning is logged. Zero disables the detection.
+	ClockSkewThreshold time.Duration
+	// PeerConnectivityCheckInterval is the interval in which the reachability of the peer URLs of all other members is
+	// checked. Zero disables the periodic check, the check can still be run on demand.
+	PeerConnectivityCheckInterval time.Duration
+	// ServerTuning overrides the gRPC server settings of the embedded etcd.
+	ServerTuning ServerTuningConfig
+	// WALDir is the directory into which etcd writes its WAL, e.g. on a separate volume backed by faster storage. It
+	// overrides the WAL directory of the etcd configuration. If empty, the WAL directory of the etcd configuration is used.
+	WALDir string
+	// DiskLatency is the configuration of the periodic probe of the fsync latency of the data and WAL volumes.
+	DiskLatency DiskLatencyConfig
+	// Preflight is the configuration of the checks of the data and WAL volumes before etcd is started.
+	Preflight PreflightConfig
+	// TLSValidation is the configuration of the validation of the configured certificates before etcd is started.
+	TLSValidation TLSValidationConfig
+	// CertExpiryCheckInterval is the interval in which the expiry of the certificates used by etcd and etcd-wrapper is
+	// checked and exported as metrics. Zero disables the check.
+	CertExpiryCheckInterval time.Duration
+	// MemoryLimit is the configuration of the memory-aware tuning of etcd-wrapper and etcd.
+	MemoryLimit MemoryLimitConfig
+	// CPULimit is the configuration of the CPU-aware tuning of etcd-wrapper and etcd.
+	CPULimit CPULimitConfig
+	// Defragmentation is the configuration of the scheduled defragmentation of the etcd backend, which is coordinated
+	// across the members of the cluster.
+	Defragmentation DefragmentationConfig
+	// MaintenanceLeader is the configuration of the election of the wrapper which orchestrates cluster-wide maintenance.
+	MaintenanceLeader MaintenanceLeaderConfig
+	// MaintenanceHistory is the configuration of the persisted history of compactions and defragmentations.
+	MaintenanceHistory MaintenanceHistoryConfig
+	// Compaction is the configuration of the proactive compaction of the etcd history by etcd-wrapper.
+	Compaction CompactionConfig
+	// VolumeResize is the configuration of the detection of resizes of the data volume and of the backend quota derived from its size.
+	VolumeResize VolumeResizeConfig
+	// DBSizeTrend is the configuration of the tracking of the DB size growth and of the prediction of the quota exhaustion.
+	DBSizeTrend DBSizeTrendConfig
+	// QuotaAdvisory is the configuration of the advisory on whether writes are at risk of being rejected due to the backend quota.
+	QuotaAdvisory QuotaAdvisoryConfig
+	// HealthScore is the configuration of the health score of the member, which is smoothed over periodic probes of etcd.
+	HealthScore HealthScoreConfig
+	// WarmUp is the configuration of the warm-up of etcd before readiness is reported.
+	WarmUp WarmUpConfig
+	// PrefixUsage is the configuration of the periodic sampling of the number and size of keys per key prefix.
+	PrefixUsage PrefixUsageConfig
+	// AuthSync is the configuration of the reconciliation of etcd users and roles with a declarative spec.
+	AuthSync AuthSyncConfig
+	// SnapshotOnShutdown is the configuration of the final snapshot requested from backup-restore before etcd is stopped.
+	SnapshotOnShutdown SnapshotOnShutdownConfig
+	// Hibernation is the configuration of the hibernation of the member, in which a final full snapshot is taken before
+	// the data directory is marked as safe to delete.
+	Hibernation HibernationConfig
+	// LogSinks is the configuration of the outputs of the logs of etcd-wrapper and of etcd, which are independent of
+	// each other.
+	LogSinks LogSinksConfig
+	// ChurnReportInterval is the interval in which the write churn of etcd is reported to backup-restore. Zero disables
+	// the reports.
+	ChurnReportInterval time.Duration
+	// EtcdConfigPollInterval is the interval in which backup-restore is polled for changes of the etcd configuration.
+	// Zero disables polling.
+	EtcdConfigPollInterval time.Duration
+	// CrashReport is the configuration of the crash bundles written when etcd-wrapper panics.
+	CrashReport CrashReportConfig
+	// HotStandby runs the member as a permanent, non-voting raft learner which serves serializable reads only.
+	HotStandby bool
+	// ReadinessPolicy defines what readiness of etcd means. If empty, ReadinessPolicyLearnerServingStale is used in
+	// hot-standby mode and ReadinessPolicyClusterHasQuorum otherwise.
+	ReadinessPolicy string
+	// ReadinessGates is the configuration of additional conditions which must hold for etcd-wrapper to report readiness.
+	ReadinessGates ReadinessGatesConfig
+	// Hooks is the configuration of the commands run before etcd is started and once it is ready.
+	Hooks HooksConfig
+	// EtcdProcess is the configuration of running etcd as child process from an external etcd binary instead of
+	// embedding it.
+	EtcdProcess EtcdProcessConfig
+	// FaultInjection enables the injection of artificial faults via the /debug/faults endpoint. For development only.
+	FaultInjection bool
+}
+
+// NewDefaultConfig returns the configuration of etcd-wrapper with the defaults of all options.
+func NewDefaultConfig() Config {
+	return Config{
+		BackupRestore: BackupRestoreConfig{
+			HostPortRefreshInterval: DefaultBackupRestoreHostPortRefreshInterval,
+			CAReloadInterval:        DefaultBackupRestoreCAReloadInterval,
+			TLSEnabled:              DefaultBackupRestoreTLSEnabled,
+			HostPort:                DefaultBackupRestoreHostPort,
+			Protocol:                DefaultBackupRestoreProtocol,
+		},
+		EtcdClientPort:  DefaultEtcdClientPort,
+		EtcdWrapperPort: DefaultEtcdWrapperPort,
+		HTTPServer: HTTPServerConfig{
+			ReadTimeout:     httpserver.DefaultReadTimeout,
+			WriteTimeout:    httpserver.DefaultWriteTimeout,
+			IdleTimeout:     httpserver.DefaultIdleTimeout,
+			ShutdownTimeout: httpserver.DefaultShutdownTimeout,
+		},
+		AuditLog: AuditLogConfig{
+			MaxSizeBytes: DefaultAuditLogMaxSizeBytes,
+			MaxBackups:   DefaultAuditLogMaxBackups,
+		},
+		BootstrapHistory: BootstrapHistoryConfig{
+			Path:               DefaultBootstrapHistoryFilePath,
+			CrashLoopThreshold: DefaultCrashLoopThreshold,
+			CrashLoopWindow:    DefaultCrashLoopWindow,
+		},
+		RestartBudget: RestartBudgetConfig{
+			MaxRestarts: DefaultRestartBudgetMaxRestarts,
+			Window:      DefaultRestartBudgetWindow,
+		},
+		CertRotation: CertRotationConfig{
+			LockKey: DefaultCertRotationLockKey,
+			LockTTL: DefaultCertRotationLockTTL,
+		},
+		MaintenanceWindow: MaintenanceWindowConfig{
+			Duration: DefaultMaintenanceWindowDuration,
+		},
+		SidecarOptional: SidecarOptionalConfig{
+			Window: DefaultSidecarOptionalWindow,
+		},
+		LastKnownGoodConfig: LastKnownGoodConfig{
+			Path: DefaultLastKnownGoodConfigFilePath,
+		},
+		CorruptCheck: CorruptCheckConfig{
+			InitialCheck: DefaultInitialCorruptCheck,
+			CheckTime:    DefaultCorruptCheckTime,
+		},
+		RestoreMarker: RestoreMarkerConfig{
+			Key: DefaultRestoreMarkerKey,
+		},
+		RevisionWatermark: RevisionWatermarkConfig{
+			Path:     DefaultRevisionWatermarkFilePath,
+			Interval: DefaultRevisionWatermarkInterval,
+		},
+		ProposalBackpressure: ProposalBackpressureConfig{
+			PendingThreshold:  DefaultProposalBackpressurePendingThreshold,
+			SustainedDuration: DefaultProposalBackpressureSustainedDuration,
+		},
+		ApplyLag: ApplyLagConfig{
+			Threshold:         DefaultApplyLagThreshold,
+			SustainedDuration: DefaultApplyLagSustainedDuration,
+		},
+		MemberIdentityFilePath:  DefaultMemberIdentityFilePath,
+		ClusterIDPinPath:        DefaultClusterIDPinFilePath,
+		InitialClusterTokenPath: DefaultInitialClusterTokenFilePath,
+		RequestSampling: RequestSamplingConfig{
+			BufferSize:  DefaultRequestSamplingBufferSize,
+			PrefixDepth: DefaultRequestSamplingPrefixDepth,
+		},
+		ClientTraffic: ClientTrafficConfig{
+			TopClients: DefaultClientTrafficTopClients,
+		},
+		Heartbeat: HeartbeatConfig{
+			Interval: DefaultHeartbeatInterval,
+		},
+		BackupFreshness: BackupFreshnessConfig{
+			PollInterval: DefaultBackupFreshnessPollInterval,
+		},
+		ClientUnixSocket: ClientUnixSocketConfig{
+			Mode: "0660",
+		},
+		ClockSkewThreshold: DefaultClockSkewThreshold,
+		DiskLatency: DiskLatencyConfig{
+			Window:           DefaultDiskLatencyWindow,
+			WarningThreshold: DefaultDiskLatencyWarningThreshold,
+		},
+		Preflight: PreflightConfig{
+			FsyncProbes: DefaultPreflightFsyncProbes,
+		},
+		CertExpiryCheckInterval: DefaultCertExpiryCheckInterval,
+		MemoryLimit: MemoryLimitConfig{
+			Ratio: DefaultMemoryLimitRatio,
+		},
+		CPULimit: CPULimitConfig{
+			Enabled: true,
+		},
+		Defragmentation: DefragmentationConfig{
+			KeyPrefix: DefaultDefragmentationKeyPrefix,
+			LockTTL:   DefaultDefragmentationLockTTL,
+			Timeout:   DefaultDefragmentationTimeout,
+		},
+		MaintenanceLeader: MaintenanceLeaderConfig{
+			LeaseDuration: DefaultMaintenanceLeaderLeaseDuration,
+			RenewInterval: DefaultMaintenanceLeaderRenewInterval,
+		},
+		MaintenanceHistory: MaintenanceHistoryConfig{
+			Path: DefaultMaintenanceHistoryFilePath,
+			Size: DefaultMaintenanceHistorySize,
+		},
+		Compaction: CompactionConfig{
+			RetainedRevisions: DefaultCompactionRetainedRevisions,
+			CheckInterval:     DefaultCompactionCheckInterval,
+		},
+		VolumeResize: VolumeResizeConfig{
+			SizeRecordPath: DefaultVolumeSizeRecordFilePath,
+		},
+		DBSizeTrend: DBSizeTrendConfig{
+			Horizon:        DefaultDBSizeTrendHorizon,
+			Window:         DefaultDBSizeTrendWindow,
+			SampleInterval: DefaultDBSizeTrendSampleInterval,
+		},
+		QuotaAdvisory: QuotaAdvisoryConfig{
+			RiskThreshold: DefaultQuotaRiskThreshold,
+		},
+		HealthScore: HealthScoreConfig{
+			Interval:         DefaultHealthScoreInterval,
+			SmoothingFactor:  DefaultHealthScoreSmoothingFactor,
+			LatencyThreshold: DefaultHealthScoreLatencyThreshold,
+		},
+		WarmUp: WarmUpConfig{
+			Timeout: DefaultWarmUpTimeout,
+		},
+		PrefixUsage: PrefixUsageConfig{
+			Interval: DefaultPrefixUsageInterval,
+		},
+		AuthSync: AuthSyncConfig{
+			Interval: DefaultAuthSyncInterval,
+		},
+		SnapshotOnShutdown: SnapshotOnShutdownConfig{
+			Timeout: DefaultSnapshotOnShutdownTimeout,
+		},
+		Hibernation: HibernationConfig{
+			Timeout: DefaultHibernationTimeout,
+		},
+		LogSinks: LogSinksConfig{
+			Wrapper: LogSinkConfig{
+				MaxSizeBytes: DefaultLogMaxSizeBytes,
+				MaxBackups:   DefaultLogMaxBackups,
+			},
+			Etcd: LogSinkConfig{
+				MaxSizeBytes: DefaultLogMaxSizeBytes,
+				MaxBackups:   DefaultLogMaxBackups,
+			},
+		},
+		CrashReport: CrashReportConfig{
+			LogLines: DefaultCrashReportLogLines,
+		},
+		ReadinessGates: ReadinessGatesConfig{
+			Interval: DefaultReadinessGateInterval,
+			Timeout:  DefaultReadinessGateTimeout,
+		},
+		Hooks: HooksConfig{
+			Timeout: DefaultHookTimeout,
+		},
+		EtcdProcess: EtcdProcessConfig{
+			Mode:            EtcdModeEmbedded,
+			BinaryPath:      DefaultEtcdBinaryPath,
+			StopGracePeriod: DefaultEtcdStopGracePeriod,
+		},
+	}
+}
+
+// RedirectDefaultPaths moves the files which etcd-wrapper writes next to the data directory into dir, unless their
+// paths are set explicitly, so that etcd-wrapper does not require /var/etcd/data.
+func (c *Config) RedirectDefaultPaths(dir string) {
+	for _, path := range []struct {
+		value       *string
+		defaultPath string
+		name        string
+	}{
+		{&c.BootstrapHistory.Path, DefaultBootstrapHistoryFilePath, "bootstrap_history.json"},
+		{&c.MaintenanceHistory.Path, DefaultMaintenanceHistoryFilePath, "maintenance_history.json"},
+		{&c.ClusterIDPinPath, DefaultClusterIDPinFilePath, "cluster_id_pin.json"},
+		{&c.InitialClusterTokenPath, DefaultInitialClusterTokenFilePath, "initial_cluster_token"},
+		{&c.MemberIdentityFilePath, DefaultMemberIdentityFilePath, "member_identity.env"},
+		{&c.LastKnownGoodConfig.Path, DefaultLastKnownGoodConfigFilePath, "last_known_good_etcd_config.yaml"},
+		{&c.VolumeResize.SizeRecordPath, DefaultVolumeSizeRecordFilePath, "volume_size.json"},
+		{&c.RevisionWatermark.Path, DefaultRevisionWatermarkFilePath, "revision_watermark.json"},
+	} {
+		if *path.value == path.defaultPath {
+			*path.value = filepath.Join(dir, path.name)
+		}
+	}
+}
+
+// Validate validates all sections of the configuration.
+func (c *Config) Validate() error {
+	return errors.Join(
+		c.BackupRestore.Validate(),
+		c.DNS.Validate(),
+		c.SnapshotOnShutdown.Validate(),
+		c.Hibernation.Validate(),
+		c.Compaction.Validate(),
+		c.PostRestoreMaintenance.Validate(),
+		c.PrefixUsage.Validate(),
+		c.WarmUp.Validate(),
+		c.DBSizeTrend.Validate(),
+		c.QuotaAdvisory.Validate(),
+		c.HealthScore.Validate(),
+		c.RevisionWatermark.Validate(),
+		c.MemoryLimit.Validate(),
+		c.CPULimit.Validate(),
+		c.BootstrapHistory.Validate(),
+		c.SidecarOptional.Validate(),
+		c.LastKnownGoodConfig.Validate(),
+		c.RestartBudget.Validate(),
+		c.MaintenanceWindow.Validate(),
+		c.MaintenanceHistory.Validate(),
+		c.Defragmentation.Validate(),
+		c.MaintenanceLeader.Validate(),
+		c.ServerTuning.Validate(),
+		c.VolumeResize.Validate(),
+		c.Preflight.Validate(),
+		c.TLSValidation.Validate(),
+		c.DiskLatency.Validate(),
+		c.CertRotation.Validate(),
+		c.CrashReport.Validate(),
+		c.Heartbeat.Validate(),
+		c.BackupFreshness.Validate(),
+		c.RequestSampling.Validate(),
+		c.ClientTraffic.Validate(),
+		c.ExternalClientListener.Validate(),
+		c.ClientUnixSocket.Validate(),
+		c.HTTPServer.Validate(),
+		c.ValidateReadinessPolicy(),
+		c.ValidateBackupRestoreProtocol(),
+		c.ReadinessGates.Validate(),
+		c.Hooks.Validate(),
+		c.EtcdProcess.Validate(),
+		c.LogSinks.Validate(),
+	)
+}
+
+// GetReadinessPolicy returns the configured readiness policy, or the default readiness policy if none is configured.
+func (c *Config) GetReadinessPolicy() string {
+	switch {
+	case c.ReadinessPolicy != "":
+		return c.ReadinessPolicy
+	case c.HotStandby:
+		return ReadinessPolicyLearnerServingStale
+	default:
+		return ReadinessPolicyClusterHasQuorum
+	}
+}
+
+// ValidateReadinessPolicy validates the readiness policy.
+func (c *Config) ValidateReadinessPolicy() error {
+	switch policy := c.GetReadinessPolicy(); policy {
+	case ReadinessPolicyMemberServing, ReadinessPolicyMemberHasLeader, ReadinessPolicyClusterHasQuorum:
+		if c.HotStandby {
+			return fmt.Errorf("readiness policy %q can never be satisfied by a hot-standby member, use %s", policy, ReadinessPolicyLearnerServingStale)
+		}
+		return nil
+	case ReadinessPolicyLearnerServingStale:
+		return nil
+	default:
+		return fmt.Errorf("unsupported readiness policy %q, must be one of: %s, %s, %s, %s", policy, ReadinessPolicyMemberServing, ReadinessPolicyMemberHasLeader, ReadinessPolicyClusterHasQuorum, ReadinessPolicyLearnerServingStale)
+	}
+}
+
+// ValidateBackupRestoreProtocol validates that the features talking to backup-restore are supported by the configured
+// sidecar protocol. The gRPC protocol supports neither polling the etcd configuration nor reporting quota advisories.
+func (c *Config) ValidateBackupRestoreProtocol() (err error) {
+	if c.BackupRestore.Protocol != BackupRestoreProtocolGRPC {
+		return nil
+	}
+	if c.EtcdConfigPollInterval > 0 {
+		err = errors.Join(err, fmt.Errorf("etcd-config-poll-interval is not supported with sidecar protocol %s", BackupRestoreProtocolGRPC))
+	}
+	if c.QuotaAdvisory.ReportInterval > 0 {
+		err = errors.Join(err, fmt.Errorf("quota-advisory-report-interval is not supported with sidecar protocol %s", BackupRestoreProtocolGRPC))
+	}
+	return
+}
+
+// ReadinessGatesConfig holds the configuration of additional readiness gates, which must all pass in addition to the
+// readiness policy for etcd-wrapper to report readiness.
+type ReadinessGatesConfig struct {
+	// Gates are the readiness gates in the form <kind>:<target>, see ParseReadinessGate.
+	Gates []string
+	// Interval is the interval in which the readiness gates are evaluated.
+	Interval time.Duration
+	// Timeout is the time after which the evaluation of a single readiness gate fails.
+	Timeout time.Duration
+}
+
+// ReadinessGate is a condition which must hold for etcd-wrapper to report readiness.
+type ReadinessGate struct {
+	// Kind is the kind of the readiness gate, one of ReadinessGateKindExec, ReadinessGateKindFile or ReadinessGateKindHTTP.
+	Kind string
+	// Target is the command of an exec gate, the path of a file gate or the URL of an http gate.
+	Target string
+}
+
+// ParseReadinessGate parses a readiness gate in the form <kind>:<target>, e.g. `file:/var/run/resized`,
+// `exec:/bin/check --volume data` or `http:http://localhost:9000/healthz`.
+func ParseReadinessGate(spec string) (ReadinessGate, error) {
+	kind, target, ok := strings.Cut(spec, ":")
+	if !ok || strings.TrimSpace(target) == "" {
+		return ReadinessGate{}, fmt.Errorf("readiness gate %q must be of the form <kind>:<target>", spec)
+	}
+	gate := ReadinessGate{Kind: kind, Target: strings.TrimSpace(target)}
+	switch kind {
+	case ReadinessGateKindExec, ReadinessGateKindFile:
+	case ReadinessGateKindHTTP:
+		u, err := url.Parse(gate.Target)
+		if err != nil {
+			return ReadinessGate{}, fmt.Errorf("readiness gate %q has an invalid URL: %w", spec, err)
+		}
+		if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return ReadinessGate{}, fmt.Errorf("readiness gate %q must have an absolute http or https URL", spec)
+		}
+	default:
+		return ReadinessGate{}, fmt.Errorf("readiness gate %q has unsupported kind %q, must be one of: %s, %s, %s", spec, kind, ReadinessGateKindExec, ReadinessGateKindFile, ReadinessGateKindHTTP)
+	}
+	return gate, nil
+}
+
+// String returns the readiness gate in the form <kind>:<target>.
+func (g ReadinessGate) String() string {
+	return g.Kind + ":" + g.Target
+}
+
+// Validate validates the readiness gates configuration.
+func (c *ReadinessGatesConfig) Validate() (err error) {
+	for _, spec := range c.Gates {
+		if _, parseErr := ParseReadinessGate(spec); parseErr != nil {
+			err = errors.Join(err, parseErr)
+		}
+	}
+	if len(c.Gates) > 0 && c.Interval <= 0 {
+		err = errors.Join(err, fmt.Errorf("readiness-gate-interval must be positive"))
+	}
+	if len(c.Gates) > 0 && c.Timeout <= 0 {
+		err = errors.Join(err, fmt.Errorf("readiness-gate-timeout must be positive"))
+	}
+	return
+}
+
+// HooksConfig holds the configuration of site-specific commands run by etcd-wrapper every time the embedded etcd is
+// started. The commands are run without a shell and their arguments are split at white space.
+type HooksConfig struct {
+	// PreStart is the command run before etcd is started. etcd is not started if it fails. Disabled if empty.
+	PreStart string
+	// PostReady is the command run once etcd is ready. Its failure is only logged. Disabled if empty.
+	PostReady string
+	// Timeout is the time after which a hook is killed.
+	Timeout time.Duration
+}
+
+// Validate validates the hooks configuration.
+func (c *HooksConfig) Validate() (err error) {
+	for flag, command := range map[string]string{"pre-start-hook": c.PreStart, "post-ready-hook": c.PostReady} {
+		if command != "" && strings.TrimSpace(command) == "" {
+			err = errors.Join(err, fmt.Errorf("%s must not be blank", flag))
+		}
+	}
+	if (c.PreStart != "" || c.PostReady != "") && c.Timeout <= 0 {
+		err = errors.Join(err, fmt.Errorf("hook-timeout must be positive"))
+	}
+	return
+}
+
+// EtcdProcessConfig holds the configuration of how etcd is run.
+type EtcdProcessConfig struct {
+	// Mode is either EtcdModeEmbedded to run etcd embedded into etcd-wrapper, or EtcdModeProcess to run the etcd binary
+	// at BinaryPath as child process. An empty mode is treated as EtcdModeEmbedded.
+	Mode string
+	// BinaryPath is the path of the etcd binary run in process mode.
+	BinaryPath string
+	// StopGracePeriod is the time the etcd process is given to exit after SIGTERM before it is killed.
+	StopGracePeriod time.Duration
+}
+
+// IsProcessMode returns whether etcd is run as child process.
+func (c *EtcdProcessConfig) IsProcessMode() bool {
+	return c.Mode == EtcdModeProcess
+}
+
+// Validate validates the configuration of how etcd is run.
+func (c *EtcdProcessConfig) Validate() error {
+	switch c.Mode {
+	case "", EtcdModeEmbedded:
+		return nil
+	case EtcdModeProcess:
+		var err error
+		if c.BinaryPath == "" {
+			err = errors.Join(err, fmt.Errorf("etcd-binary-path must be set in %s mode", EtcdModeProcess))
+		}
+		if c.StopGracePeriod <= 0 {
+			err = errors.Join(err, fmt.Errorf("etcd-stop-grace-period must be positive"))
+		}
+		return err
+	default:
+		return fmt.Errorf("unsupported etcd mode %q, must be one of: %s, %s", c.Mode, EtcdModeEmbedded, EtcdModeProcess)
+	}
+}
+
+// BootstrapHistoryConfig holds the configuration of the persisted history of start attempts and of the crash loop
+// detection based on it.
+type BootstrapHistoryConfig struct {
+	// Path is the file path of the bootstrap history. The history is not persisted if it is empty.
+	Path string
+	// CrashLoopThreshold is the number of failed start attempts within CrashLoopWindow from which on a crash loop is
+	// detected, which escalates to a full validation of the data directory. Zero disables the crash loop detection.
+	CrashLoopThreshold int
+	// CrashLoopWindow is the window within which failed start attempts are counted.
+	CrashLoopWindow time.Duration
+}
+
+// Validate validates the bootstrap history configuration.
+func (c *BootstrapHistoryConfig) Validate() (err error) {
+	if c.CrashLoopThreshold < 0 || c.CrashLoopThreshold > DefaultBootstrapHistorySize {
+		err = errors.Join(err, fmt.Errorf("crash-loop-threshold must be between 0 and %d", DefaultBootstrapHistorySize))
+	}
+	if c.CrashLoopThreshold > 0 && c.CrashLoopWindow <= 0 {
+		err = errors.Join(err, fmt.Errorf("crash-loop-window must be positive"))
+	}
+	return
+}
+
+// RestartBudgetConfig holds the configuration of the budget which limits restarts of the embedded etcd, so that tight
+// restart loops cannot thrash the disk. Once the budget is exhausted, etcd-wrapper exits and leaves further restarts to
+// the back-off of the kubelet.
+type RestartBudgetConfig struct {
+	// MaxRestarts is the maximum number of restarts within Window. Zero allows an unlimited number of restarts.
+	MaxRestarts int
+	// Window is the window within which at most MaxRestarts restarts are allowed.
+	Window time.Duration
+}
+
+// Validate validates the restart budget configuration.
+func (c *RestartBudgetConfig) Validate() (err error) {
+	if c.MaxRestarts < 0 {
+		err = errors.Join(err, fmt.Errorf("restart-budget-max-restarts must not be negative"))
+	}
+	if c.MaxRestarts > 0 && c.Window <= 0 {
+		err = errors.Join(err, fmt.Errorf("restart-budget-window must be positive"))
+	}
+	return
+}
+
+// CertRotationConfig holds the configuration of the detection of rotations of the peer CA bundle and of the restarts
+// required by them. Restarts are serialized across the cluster via a lock in etcd, so that quorum is retained.
+type CertRotationConfig struct {
+	// CheckInterval is the interval in which the peer CA bundle is checked for changes. Zero disables the detection.
+	CheckInterval time.Duration
+	// LockKey is the key prefix of the lock in etcd which serializes the restarts of the members.
+	LockKey string
+	// LockTTL is the TTL of the lease to which the lock is bound, after which the lock is released if its holder has died.
+	// It must exceed the time needed to restart a member.
+	LockTTL time.Duration
+}
+
+// Validate validates the certificate rotation configuration.
+func (c *CertRotationConfig) Validate() (err error) {
+	if c.CheckInterval <= 0 {
+		return
+	}
+	if strings.TrimSpace(c.LockKey) == "" {
+		err = errors.Join(err, fmt.Errorf("cert-rotation-lock-key must not be empty"))
+	}
+	if c.LockTTL < time.Second {
+		err = errors.Join(err, fmt.Errorf("cert-rotation-lock-ttl must be at least 1s"))
+	}
+	return
+}
+
+// DefragmentationConfig holds the configuration of the scheduled defragmentation of the etcd backend. The members of
+// the cluster defragment one at a time, coordinated via a lock in etcd, with the leader defragmenting last.
+type DefragmentationConfig struct {
+	// Schedule is the cron expression, evaluated in UTC, at which a defragmentation round starts. If it is empty, the
+	// backend is not defragmented by etcd-wrapper.
+	Schedule string
+	// KeyPrefix is the key prefix in etcd under which the lock and the defragmentations of the members are recorded.
+	KeyPrefix string
+	// LockTTL is the TTL of the lease to which the lock is bound, after which the lock is released if its holder has died.
+	// It must exceed the time needed to defragment a member.
+	LockTTL time.Duration
+	// Timeout is the time for which the leader waits for the followers to defragment in a round before it defragments
+	// regardless, e.g. if a follower is down.
+	Timeout time.Duration
+}
+
+// Validate validates the defragmentation configuration.
+func (c *DefragmentationConfig) Validate() (err error) {
+	if c.Schedule == "" {
+		return
+	}
+	if _, parseErr := maintenance.ParseSchedule(c.Schedule); parseErr != nil {
+		err = errors.Join(err, fmt.Errorf("invalid defragmentation-schedule: %w", parseErr))
+	}
+	if strings.TrimSpace(c.KeyPrefix) == "" {
+		err = errors.Join(err, fmt.Errorf("defragmentation-key-prefix must not be empty"))
+	}
+	if c.LockTTL < time.Second {
+		err = errors.Join(err, fmt.Errorf("defragmentation-lock-ttl must be at least 1s"))
+	}
+	if c.Timeout <= 0 {
+		err = errors.Join(err, fmt.Errorf("defragmentation-timeout must be positive"))
+	}
+	return
+}
+
+// MaintenanceLeaderConfig holds the configuration of the election of the maintenance leader, the etcd-wrapper which
+// orchestrates cluster-wide maintenance on behalf of all members, via a coordination.k8s.io/v1 Lease.
+type MaintenanceLeaderConfig struct {
+	// LeaseName is the name of the Lease via which the maintenance leader is elected. If it is empty, no maintenance
+	// leader is elected and every member takes part in cluster-wide maintenance itself.
+	LeaseName string
+	// LeaseNamespace is the namespace of the Lease. If it is empty, the namespace of the pod is used.
+	LeaseNamespace string
+	// Identity is the identity with which etcd-wrapper competes for the Lease. If it is empty, the hostname is used.
+	Identity string
+	// LeaseDuration is the time after its last renewal after which the Lease is taken over by another etcd-wrapper.
+	LeaseDuration time.Duration
+	// RenewInterval is the interval in which the maintenance leader renews the Lease.
+	RenewInterval time.Duration
+}
+
+// Validate validates the maintenance leader configuration.
+func (c *MaintenanceLeaderConfig) Validate() (err error) {
+	if c.LeaseName == "" {
+		return
+	}
+	if c.LeaseDuration < time.Second {
+		err = errors.Join(err, fmt.Errorf("maintenance-leader-lease-duration must be at least 1s"))
+	}
+	if c.RenewInterval <= 0 || c.RenewInterval >= c.LeaseDuration {
+		err = errors.Join(err, fmt.Errorf("maintenance-leader-renew-interval must be positive and less than maintenance-leader-lease-duration"))
+	}
+	return
+}
+
+// MaintenanceHistoryConfig holds the configuration of the persisted history of the compactions and defragmentations
+// performed by etcd-wrapper.
+type MaintenanceHistoryConfig struct {
+	// Path is the file path of the history. The history is not persisted if it is empty.
+	Path string
+	// Size is the number of most recent operations retained in the history. Zero retains DefaultMaintenanceHistorySize operations.
+	Size int
+}
+
+// Validate validates the maintenance history configuration.
+func (c *MaintenanceHistoryConfig) Validate() (err error) {
+	if c.Size < 0 {
+		err = errors.Join(err, fmt.Errorf("maintenance-history-size must not be negative"))
+	}
+	return
+}
+
+// MaintenanceWindowConfig holds the configuration of the recurring maintenance window to which disruptive operations
+// initiated by etcd-wrapper, e.g. on-demand validations of the data directory, are confined. Operations requested
+// outside of the window are queued till the window opens.
+type MaintenanceWindowConfig struct {
+	// Schedule is the cron expression, evaluated in UTC, at which the maintenance window opens. If it is empty, disruptive
+	// operations are never queued.
+	Schedule string
+	// Duration is the duration for which the maintenance window stays open.
+	Duration time.Duration
+}
+
+// Validate validates the maintenance window configuration.
+func (c *MaintenanceWindowConfig) Validate() (err error) {
+	if c.Schedule == "" {
+		return
+	}
+	if _, parseErr := maintenance.ParseSchedule(c.Schedule); parseErr != nil {
+		err = errors.Join(err, fmt.Errorf("invalid maintenance-window-schedule: %w", parseErr))
+	}
+	if c.Duration <= 0 {
+		err = errors.Join(err, fmt.Errorf("maintenance-window-duration must be positive"))
+	}
+	return
+}
+
+// SnapshotOnShutdownConfig holds the configuration of the final snapshot requested from backup-restore before etcd is stopped.
+type SnapshotOnShutdownConfig struct {
+	// Kind is the kind of snapshot to request, either `full` or `delta`. No snapshot is requested if it is empty.
+	Kind string
+	// Timeout is the maximum time to wait for backup-restore to confirm that the snapshot has been taken.
+	Timeout time.Duration
+}
+
+// Validate validates the snapshot on shutdown configuration.
+func (c *SnapshotOnShutdownConfig) Validate() (err error) {
+	if c.Kind == "" {
+		return
+	}
+	if c.Kind != SnapshotKindFull && c.Kind != SnapshotKindDelta {
+		err = errors.Join(err, fmt.Errorf("unsupported snapshot kind %q, must be one of: %s, %s", c.Kind, SnapshotKindFull, SnapshotKindDelta))
+	}
+	if c.Timeout <= 0 {
+		err = errors.Join(err, fmt.Errorf("snapshot-on-shutdown-timeout must be positive"))
+	}
+	return
+}
+
+// HibernationConfig holds the configuration of the hibernation of the member, e.g. before the cluster is scaled to zero.
+type HibernationConfig struct {
+	// IntentFilePath is the file path whose existence on shutdown signals the intent to hibernate, so that the member
+	// hibernates instead of only stopping. Disabled if empty.
+	IntentFilePath string
+	// EndpointEnabled enables the /hibernate endpoint of the HTTP server, which is not authenticated. Disabled by
+	// default, so that clients which can reach the HTTP server cannot stop a member and mark its data directory as safe
+	// to delete.
+	EndpointEnabled bool
+	// Timeout is the maximum time to wait for backup-restore to take and upload the final full snapshot. Zero uses
+	// DefaultHibernationTimeout.
+	Timeout time.Duration
+}
+
+// GetTimeout returns the configured timeout, or DefaultHibernationTimeout if none is configured.
+func (c *HibernationConfig) GetTimeout() time.Duration {
+	if c.Timeout == 0 {
+		return DefaultHibernationTimeout
+	}
+	return c.Timeout
+}
+
+// Validate validates the hibernation configuration.
+func (c *HibernationConfig) Validate() error {
+	if c.Timeout < 0 {
+		return fmt.Errorf("hibernation-timeout must not be negative")
+	}
+	return nil
+}
+
+// LogSinksConfig holds the configuration of the outputs of the logs of etcd-wrapper and of etcd.
+type LogSinksConfig struct {
+	// Wrapper is the output of the logs of etcd-wrapper.
+	Wrapper LogSinkConfig
+	// Etcd is the output of the logs of etcd, which replaces the log outputs of the etcd configuration.
+	Etcd LogSinkConfig
+}
+
+// LogSinkConfig holds the configuration of the output of a log.
+type LogSinkConfig struct {
+	// Outputs are the outputs to which the log is written, each of which is either stdout, stderr or the path of a file.
+	// The default output is kept if empty.
+	Outputs []string
+	// MaxSizeBytes is the size in bytes after which a log file is rotated. Zero uses DefaultLogMaxSizeBytes.
+	MaxSizeBytes int64
+	// MaxBackups is the maximum number of rotated log files to retain.
+	MaxBackups int
+}
+
+// GetMaxSizeBytes returns the configured size after which a log file is rotated, or DefaultLogMaxSizeBytes if none is
+// configured.
+func (c *LogSinkConfig) GetMaxSizeBytes() int64 {
+	if c.MaxSizeBytes == 0 {
+		return DefaultLogMaxSizeBytes
+	}
+	return c.MaxSizeBytes
+}
+
+// Validate validates the configuration of the log outputs.
+func (c *LogSinksConfig) Validate() (err error) {
+	err = errors.Join(c.Wrapper.validate("wrapper"), c.Etcd.validate("etcd"))
+	// a file rotated independently by both sinks would lose log entries on rotation.
+	for _, output := range c.Wrapper.Outputs {
+		if output != "stdout" && output != "stderr" && slices.Contains(c.Etcd.Outputs, output) {
+			err = errors.Join(err, fmt.Errorf("log file %s must not be an output of both wrapper-log-outputs and etcd-log-outputs", output))
+		}
+	}
+	return
+}
+
+func (c *LogSinkConfig) validate(name string) (err error) {
+	if c.MaxSizeBytes < 0 {
+		err = errors.Join(err, fmt.Errorf("%s-log-max-size-bytes must not be negative", name))
+	}
+	if c.MaxBackups < 0 {
+		err = errors.Join(err, fmt.Errorf("%s-log-max-backups must not be negative", name))
+	}
+	if slices.Contains(c.Outputs, "") {
+		err = errors.Join(err, fmt.Errorf("%s-log-outputs must not contain empty outputs", name))
+	}
+	return
+}
+
+// ServerTuningConfig holds overrides of the gRPC server settings of the embedded etcd, e.g. to tune it for
+// high-throughput kube-apiserver workloads. Zero values keep the settings of the etcd configuration.
+type ServerTuningConfig struct {
+	// MaxConcurrentStreams is the maximum number of concurrent gRPC streams per client connection.
+	MaxConcurrentStreams uint
+	// MaxRequestBytes is the maximum size of a client request, from which the maximum size of a message received by
+	// the gRPC server is derived.
+	MaxRequestBytes uint
+	// MaxTxnOps is the maximum number of operations in a transaction.
+	MaxTxnOps uint
+	// RequestSizeWarningRatio is the fraction of the maximum size of a client request and of the maximum number of
+	// operations in a transaction beyond which observed requests are warned about. Zero disables the observation.
+	RequestSizeWarningRatio float64
+	// GRPCKeepAliveMinTime is the minimum interval in which clients may send keepalive pings.
+	GRPCKeepAliveMinTime time.Duration
+	// GRPCKeepAliveInterval is the interval in which the server pings idle connections to check whether they are alive.
+	GRPCKeepAliveInterval time.Duration
+	// GRPCKeepAliveTimeout is the time the server waits for the response to a keepalive ping before closing the connection.
+	GRPCKeepAliveTimeout time.Duration
+}
+
+// maxRequestBytesLimit is the largest supported max request bytes, since etcd adds an overhead of 512KiB to it to
+// derive the maximum size of a gRPC message, which must fit into an int32.
+const maxRequestBytesLimit = math.MaxInt32 - 512*1024
+
+// Validate validates the server tuning configuration.
+func (c *ServerTuningConfig) Validate() (err error) {
+	if c.MaxConcurrentStreams > math.MaxUint32 {
+		err = errors.Join(err, fmt.Errorf("etcd-max-concurrent-streams must not exceed %d", uint32(math.MaxUint32)))
+	}
+	if c.MaxRequestBytes > maxRequestBytesLimit {
+		err = errors.Join(err, fmt.Errorf("etcd-max-request-bytes must not exceed %d", maxRequestBytesLimit))
+	}
+	if c.MaxRequestBytes > 0 && c.MaxRequestBytes < KubeAPIServerMinRequestBytes {
+		err = errors.Join(err, fmt.Errorf("etcd-max-request-bytes must be at least %d, since kube-apiserver stores objects of up to that size", KubeAPIServerMinRequestBytes))
+	}
+	if c.MaxTxnOps > math.MaxInt32 {
+		err = errors.Join(err, fmt.Errorf("etcd-max-txn-ops must not exceed %d", math.MaxInt32))
+	}
+	if c.RequestSizeWarningRatio < 0 || c.RequestSizeWarningRatio > 1 {
+		err = errors.Join(err, fmt.Errorf("request-size-warning-ratio must be between 0 and 1"))
+	}
+	if c.GRPCKeepAliveMinTime < 0 || c.GRPCKeepAliveInterval < 0 || c.GRPCKeepAliveTimeout < 0 {
+		err = errors.Join(err, fmt.Errorf("etcd-grpc-keepalive-min-time, etcd-grpc-keepalive-interval and etcd-grpc-keepalive-timeout must not be negative"))
+	}
+	return
+}
+
+// PreflightConfig holds the configuration of the checks of the data and WAL volumes which are performed before etcd is
+// started.
+type PreflightConfig struct {
+	// Disabled disables the preflight checks.
+	Disabled bool
+	// FsyncProbes is the number of writes which are synced to probe the fsync latency of a volume. Zero uses
+	// DefaultPreflightFsyncProbes.
+	FsyncProbes int
+	// FsyncLatencyThreshold is the fsync latency of a volume beyond which etcd is not started. Zero only reports the
+	// fsync latency.
+	FsyncLatencyThreshold time.Duration
+}
+
+// Validate validates the preflight configuration.
+func (c *PreflightConfig) Validate() (err error) {
+	if c.FsyncProbes < 0 {
+		err = errors.Join(err, fmt.Errorf("preflight-fsync-probes must not be negative"))
+	}
+	if c.FsyncLatencyThreshold < 0 {
+		err = errors.Join(err, fmt.Errorf("preflight-fsync-latency-threshold must not be negative"))
+	}
+	return
+}
+
+// TLSValidationConfig holds the configuration of the validation of the certificates of etcd and etcd-wrapper before
+// etcd is started.
+type TLSValidationConfig struct {
+	// Disabled disables the validation.
+	Disabled bool
+	// MinValidity is the time for which every certificate has to remain valid when etcd is started. Zero only rejects
+	// certificates which are expired or not yet valid.
+	MinValidity time.Duration
+}
+
+// Validate validates the TLS validation configuration.
+func (c *TLSValidationConfig) Validate() (err error) {
+	if c.MinValidity < 0 {
+		err = errors.Join(err, fmt.Errorf("tls-min-validity must not be negative"))
+	}
+	return
+}
+
+// HTTPServerConfig holds the timeouts of the HTTP server of etcd-wrapper. Zero uses the respective default of the
+// httpserver package.
+type HTTPServerConfig struct {
+	// ReadTimeout is the time allowed to read a whole request.
+	ReadTimeout time.Duration
+	// WriteTimeout is the time allowed to write a response. It does not apply to streamed events.
+	WriteTimeout time.Duration
+	// IdleTimeout is the time after which idle keep-alive connections are closed.
+	IdleTimeout time.Duration
+	// ShutdownTimeout is the time to wait for in-flight requests on shutdown before connections are closed.
+	ShutdownTimeout time.Duration
+}
+
+// Validate validates the HTTP server configuration.
+func (c *HTTPServerConfig) Validate() (err error) {
+	if c.ReadTimeout < 0 {
+		err = errors.Join(err, fmt.Errorf("http-read-timeout must not be negative"))
+	}
+	if c.WriteTimeout < 0 {
+		err = errors.Join(err, fmt.Errorf("http-write-timeout must not be negative"))
+	}
+	if c.IdleTimeout < 0 {
+		err = errors.Join(err, fmt.Errorf("http-idle-timeout must not be negative"))
+	}
+	if c.ShutdownTimeout < 0 {
+		err = errors.Join(err, fmt.Errorf("http-shutdown-timeout must not be negative"))
+	}
+	return
+}
+
+// DiskLatencyConfig holds the configuration of the periodic probe of the fsync latency of the data and WAL volumes.
+type DiskLatencyConfig struct {
+	// ProbeInterval is the interval in which a write is synced to each volume. Zero disables the probe.
+	ProbeInterval time.Duration
+	// Window is the number of most recent probes from which the 99th percentile of the fsync latency is computed. Zero
+	// uses DefaultDiskLatencyWindow.
+	Window int
+	// WarningThreshold is the 99th percentile of the fsync latency beyond which a warning is logged. Zero disables the
+	// warning.
+	WarningThreshold time.Duration
+}
+
+// Validate validates the disk latency configuration.
+func (c *DiskLatencyConfig) Validate() (err error) {
+	if c.ProbeInterval < 0 {
+		err = errors.Join(err, fmt.Errorf("disk-latency-probe-interval must not be negative"))
+	}
+	if c.Window < 0 {
+		err = errors.Join(err, fmt.Errorf("disk-latency-window must not be negative"))
+	}
+	if c.WarningThreshold < 0 {
+		err = errors.Join(err, fmt.Errorf("disk-latency-warning-threshold must not be negative"))
+	}
+	return
+}
+
+// MemoryLimitConfig holds the configuration of the memory-aware tuning of etcd-wrapper and etcd, which is derived from the
+// memory limit of the container unless overridden.
+type MemoryLimitConfig struct {
+	// Ratio is the fraction of the container memory limit which is set as Go memory limit. Zero disables deriving settings from the container memory limit.
+	Ratio float64
+	// GoMemoryLimit is the Go memory limit in bytes. If set, it overrides the limit derived from the container memory limit and the GOMEMLIMIT environment variable.
+	GoMemoryLimit int64
+	// SnapshotCount is the number of committed raft entries after which etcd takes a snapshot and truncates its in-memory raft log.
+	// If set, it overrides the count derived from the container memory limit and the etcd configuration.
+	SnapshotCount uint64
+}
+
+// Validate validates the memory limit configuration.
+func (c *MemoryLimitConfig) Validate() (err error) {
+	if c.Ratio < 0 || c.Ratio > 1 {
+		err = errors.Join(err, fmt.Errorf("memory-limit-ratio must be between 0 and 1"))
+	}
+	if c.GoMemoryLimit < 0 {
+		err = errors.Join(err, fmt.Errorf("go-memory-limit must not be negative"))
+	}
+	return
+}
+
+// CPULimitConfig holds the configuration of the CPU-aware tuning of etcd-wrapper and etcd, which is derived from the
+// CPU limit of the container unless overridden.
+type CPULimitConfig struct {
+	// Enabled enables deriving settings from the container CPU limit.
+	Enabled bool
+	// GoMaxProcs is the maximum number of CPUs executing Go code simultaneously. If set, it overrides the value derived
+	// from the container CPU limit and the GOMAXPROCS environment variable.
+	GoMaxProcs int
+	// BackendBatchLimit is the maximum number of operations etcd batches into one backend transaction. If set, it
+	// overrides the limit derived from the container CPU limit and the etcd configuration.
+	BackendBatchLimit int
+	// BackendBatchInterval is the maximum time after which etcd commits a backend transaction. If set, it overrides
+	// the interval derived from the container CPU limit and the etcd configuration.
+	BackendBatchInterval time.Duration
+}
+
+// Validate validates the CPU limit configuration.
+func (c *CPULimitConfig) Validate() (err error) {
+	if c.GoMaxProcs < 0 {
+		err = errors.Join(err, fmt.Errorf("go-max-procs must not be negative"))
+	}
+	if c.BackendBatchLimit < 0 || c.BackendBatchInterval < 0 {
+		err = errors.Join(err, fmt.Errorf("etcd-backend-batch-limit and etcd-backend-batch-interval must not be negative"))
+	}
+	return
+}
+
+// CompactionConfig holds the configuration of the proactive compaction of the etcd history by etcd-wrapper, which is
+// independent of the auto-compaction of etcd. It is disabled if neither RevisionThreshold nor DBSizeGrowthPercent is set.
+type CompactionConfig struct {
+	// RevisionThreshold is the number of revisions since the last compaction above which a compaction is triggered. Zero disables this trigger.
+	RevisionThreshold int64
+	// DBSizeGrowthPercent is the growth in percent of the logically used DB size since the last compaction above which a compaction is triggered. Zero disables this trigger.
+	DBSizeGrowthPercent int
+	// RetainedRevisions is the number of most recent revisions retained by a compaction.
+	RetainedRevisions int64
+	// CheckInterval is the interval in which the growth of the revision and of the DB size is checked.
+	CheckInterval time.Duration
+}
+
+// Validate validates the compaction configuration.
+func (c *CompactionConfig) Validate() (err error) {
+	if c.RevisionThreshold < 0 || c.DBSizeGrowthPercent < 0 || c.RetainedRevisions < 0 {
+		err = errors.Join(err, fmt.Errorf("compaction thresholds and retained revisions must not be negative"))
+	}
+	if (c.RevisionThreshold > 0 || c.DBSizeGrowthPercent > 0) && c.CheckInterval <= 0 {
+		err = errors.Join(err, fmt.Errorf("compaction-check-interval must be positive"))
+	}
+	return
+}
+
+// DBSizeTrendConfig holds the configuration of the tracking of the growth of the etcd DB size, from which the time
+// until the backend quota of etcd is exhausted is projected.
+type DBSizeTrendConfig struct {
+	// Horizon is the projected time until quota exhaustion below which a warning is raised. Zero disables the tracking.
+	Horizon time.Duration
+	// Window is the sliding time window over which the growth rate of the DB size is computed.
+	Window time.Duration
+	// SampleInterval is the interval in which the DB size is sampled.
+	SampleInterval time.Duration
+}
+
+// Validate validates the DB size trend configuration.
+func (c *DBSizeTrendConfig) Validate() (err error) {
+	if c.Horizon < 0 {
+		err = errors.Join(err, fmt.Errorf("db-size-trend-horizon must not be negative"))
+	}
+	if c.Horizon > 0 {
+		if c.SampleInterval <= 0 {
+			err = errors.Join(err, fmt.Errorf("db-size-trend-sample-interval must be positive"))
+		}
+		if c.Window < 2*c.SampleInterval {
+			err = errors.Join(err, fmt.Errorf("db-size-trend-window must be at least twice the db-size-trend-sample-interval"))
+		}
+	}
+	return
+}
+
+// PrefixUsageConfig holds the configuration of the periodic sampling of the number and size of keys per key prefix,
+// which allows spotting tenants filling the etcd DB.
+type PrefixUsageConfig struct {
+	// Prefixes are the key prefixes whose usage is sampled. Sampling is disabled if empty.
+	Prefixes []string
+	// MeasureSize additionally measures the total size of the keys and values per prefix. Unlike counting the keys,
+	// this requires reading all keys and values of the prefixes.
+	MeasureSize bool
+	// Interval is the interval in which the usage is sampled.
+	Interval time.Duration
+}
+
+// Validate validates the prefix usage configuration.
+func (c *PrefixUsageConfig) Validate() (err error) {
+	seen := make(map[string]struct{}, len(c.Prefixes))
+	for _, prefix := range c.Prefixes {
+		if prefix == "" {
+			err = errors.Join(err, fmt.Errorf("prefix-usage-prefixes must not contain an empty prefix"))
+			continue
+		}
+		if _, ok := seen[prefix]; ok {
+			err = errors.Join(err, fmt.Errorf("prefix-usage-prefixes contains prefix %q more than once", prefix))
+		}
+		seen[prefix] = struct{}{}
+	}
+	if len(c.Prefixes) > 0 && c.Interval <= 0 {
+		err = errors.Join(err, fmt.Errorf("prefix-usage-interval must be positive"))
+	}
+	return
+}
+
+// QuotaAdvisoryConfig holds the configuration of the advisory on whether writes are at risk of being rejected due to
+// the backend quota of etcd.
+type QuotaAdvisoryConfig struct {
+	// RiskThreshold is the ratio of DB size to backend quota at or above which writes are considered at risk. Zero uses
+	// DefaultQuotaRiskThreshold.
+	RiskThreshold float64
+	// ReportInterval is the interval in which the advisory is reported to backup-restore. Zero disables the reports.
+	ReportInterval time.Duration
+}
+
+// GetRiskThreshold returns the configured risk threshold, or DefaultQuotaRiskThreshold if none is configured.
+func (c *QuotaAdvisoryConfig) GetRiskThreshold() float64 {
+	if c.RiskThreshold == 0 {
+		return DefaultQuotaRiskThreshold
+	}
+	return c.RiskThreshold
+}
+
+// Validate validates the quota advisory configuration.
+func (c *QuotaAdvisoryConfig) Validate() (err error) {
+	if c.RiskThreshold < 0 || c.RiskThreshold > 1 {
+		err = errors.Join(err, fmt.Errorf("quota-risk-threshold must be between 0 and 1"))
+	}
+	if c.ReportInterval < 0 {
+		err = errors.Join(err, fmt.Errorf("quota-advisory-report-interval must not be negative"))
+	}
+	return
+}
+
+// HealthScoreConfig holds the configuration of the health score of the member, which combines the success and the
+// latency of periodic probes of etcd and the stability of its leader into a single exponentially smoothed value.
+type HealthScoreConfig struct {
+	// Interval is the interval in which etcd is probed. Zero disables the health score.
+	Interval time.Duration
+	// SmoothingFactor is the weight of the latest probe in the health score, between 0 and 1. Higher values make the
+	// health score react faster. Zero uses DefaultHealthScoreSmoothingFactor.
+	SmoothingFactor float64
+	// LatencyThreshold is the probe latency up to which the latency is considered healthy. Zero uses
+	// DefaultHealthScoreLatencyThreshold.
+	LatencyThreshold time.Duration
+}
+
+// GetSmoothingFactor returns the configured smoothing factor, or DefaultHealthScoreSmoothingFactor if none is configured.
+func (c *HealthScoreConfig) GetSmoothingFactor() float64 {
+	if c.SmoothingFactor == 0 {
+		return DefaultHealthScoreSmoothingFactor
+	}
+	return c.SmoothingFactor
+}
+
+// GetLatencyThreshold returns the configured latency threshold, or DefaultHealthScoreLatencyThreshold if none is
+// configured.
+func (c *HealthScoreConfig) GetLatencyThreshold() time.Duration {
+	if c.LatencyThreshold == 0 {
+		return DefaultHealthScoreLatencyThreshold
+	}
+	return c.LatencyThreshold
+}
+
+// Validate validates the health score configuration.
+func (c *HealthScoreConfig) Validate() (err error) {
+	if c.Interval < 0 {
+		err = errors.Join(err, fmt.Errorf("health-score-interval must not be negative"))
+	}
+	if c.SmoothingFactor < 0 || c.SmoothingFactor > 1 {
+		err = errors.Join(err, fmt.Errorf("health-score-smoothing-factor must be between 0 and 1"))
+	}
+	if c.LatencyThreshold < 0 {
+		err = errors.Join(err, fmt.Errorf("health-score-latency-threshold must not be negative"))
+	}
+	return
+}
+
+// WarmUpConfig holds the configuration of the warm-up of etcd, in which the keyspace is read once after etcd has
+// started and before readiness is reported, so that the first client requests are served from the page cache.
+type WarmUpConfig struct {
+	// MaxKeys is the maximum number of keys read during the warm-up. Zero disables the warm-up.
+	MaxKeys int64
+	// Prefixes are the key prefixes which are read, in the given order. If empty, the whole keyspace is read.
+	Prefixes []string
+	// Timeout is the time after which the warm-up is stopped and readiness is reported regardless.
+	Timeout time.Duration
+}
+
+// Validate validates the warm-up configuration.
+func (c *WarmUpConfig) Validate() (err error) {
+	if c.MaxKeys < 0 {
+		err = errors.Join(err, fmt.Errorf("warm-up-max-keys must not be negative"))
+	}
+	if c.MaxKeys == 0 {
+		return
+	}
+	if slices.Contains(c.Prefixes, "") {
+		err = errors.Join(err, fmt.Errorf("warm-up-prefixes must not contain an empty prefix"))
+	}
+	if c.Timeout <= 0 {
+		err = errors.Join(err, fmt.Errorf("warm-up-timeout must be positive"))
+	}
+	return
+}
+
+// CrashReportConfig holds the configuration of the crash bundles which are written when etcd-wrapper panics.
+type CrashReportConfig struct {
+	// Dir is the directory into which crash bundles are written. No crash bundles are written if empty.
+	Dir string
+	// LogLines is the number of most recent log lines which are included in a crash bundle.
+	LogLines int
+}
+
+// Validate validates the crash report configuration.
+func (c *CrashReportConfig) Validate() (err error) {
+	if c.LogLines < 0 {
+		err = errors.Join(err, fmt.Errorf("crash-report-log-lines must not be negative"))
+	}
+	return
+}
+
+// RequestSamplingConfig holds the configuration of the sampling of client requests served by gRPC servers of
+// etcd-wrapper into a rolling in-memory buffer.
+type RequestSamplingConfig struct {
+	// Fraction is the fraction of requests which are sampled. Zero disables sampling.
+	Fraction float64
+	// BufferSize is the number of most recent samples which are retained.
+	BufferSize int
+	// PrefixDepth is the number of segments separated by `/` which make up the key prefix of a request.
+	PrefixDepth int
+}
+
+// Validate validates the request sampling configuration.
+func (c *RequestSamplingConfig) Validate() (err error) {
+	if c.Fraction < 0 || c.Fraction > 1 {
+		err = errors.Join(err, fmt.Errorf("request-sampling-fraction must be between 0 and 1"))
+	}
+	if c.Fraction > 0 && c.BufferSize <= 0 {
+		err = errors.Join(err, fmt.Errorf("request-sampling-buffer-size must be positive"))
+	}
+	if c.Fraction > 0 && c.PrefixDepth <= 0 {
+		err = errors.Join(err, fmt.Errorf("request-sampling-prefix-depth must be positive"))
+	}
+	return
+}
+
+// ClientTrafficConfig holds the configuration of the tracking of the open connections and the RPC rate per client of
+// gRPC servers of etcd-wrapper.
+type ClientTrafficConfig struct {
+	// SampleInterval is the interval in which the RPC rate per client is computed. Zero disables the tracking.
+	SampleInterval time.Duration
+	// TopClients is the number of clients with the highest RPC rate which are reported.
+	TopClients int
+}
+
+// Validate validates the client traffic configuration.
+func (c *ClientTrafficConfig) Validate() (err error) {
+	if c.SampleInterval < 0 {
+		err = errors.Join(err, fmt.Errorf("client-traffic-sample-interval must not be negative"))
+	}
+	if c.SampleInterval > 0 && c.TopClients <= 0 {
+		err = errors.Join(err, fmt.Errorf("client-traffic-top-clients must be positive"))
+	}
+	return
+}
+
+// VolumeResizeConfig holds the configuration of the detection of resizes of the data volume and of the backend quota
+// of etcd derived from the size of the data volume.
+type VolumeResizeConfig struct {
+	// SizeRecordPath is the file path into which the size of the data volume is recorded, to detect resizes across
+	// restarts of etcd-wrapper. Disabled if empty.
+	SizeRecordPath string
+	// QuotaBackendPercentage is the percentage of the size of the data volume which is set as backend quota of etcd,
+	// overriding the quota-backend-bytes of the etcd configuration. Zero keeps the backend quota of the etcd configuration.
+	QuotaBackendPercentage float64
+	// CheckInterval is the interval in which the size of the data volume is checked while etcd is running. Zero
+	// disables the check.
+	CheckInterval time.Duration
+	// RestartOnResize restarts etcd, in the maintenance window and one member at a time, to apply the backend quota
+	// derived from a data volume resized while etcd is running.
+	RestartOnResize bool
+}
+
+// Validate validates the volume resize configuration.
+func (c *VolumeResizeConfig) Validate() (err error) {
+	if c.QuotaBackendPercentage < 0 || c.QuotaBackendPercentage > 100 {
+		err = errors.Join(err, fmt.Errorf("quota-backend-volume-percentage must be between 0 and 100"))
+	}
+	if c.CheckInterval < 0 {
+		err = errors.Join(err, fmt.Errorf("volume-resize-check-interval must not be negative"))
+	}
+	if c.RestartOnResize && (c.QuotaBackendPercentage == 0 || c.CheckInterval == 0) {
+		err = errors.Join(err, fmt.Errorf("restart-on-volume-resize requires quota-backend-volume-percentage and volume-resize-check-interval to be set"))
+	}
+	return
+}
+
+// HeartbeatConfig holds the configuration of the heartbeat file, which is rewritten periodically so that external
+// liveness monitors can detect a hung etcd-wrapper from its age.
+type HeartbeatConfig struct {
+	// Path is the path of the heartbeat file. No heartbeat file is written if empty.
+	Path string
+	// Interval is the interval in which the heartbeat file is rewritten.
+	Interval time.Duration
+}
+
+// Validate validates the heartbeat configuration.
+func (c *HeartbeatConfig) Validate() (err error) {
+	if c.Path != "" && c.Interval <= 0 {
+		err = errors.Join(err, fmt.Errorf("heartbeat-interval must be positive"))
+	}
+	return
+}
+
+// BackupFreshnessConfig holds the configuration of the safety endpoint, which reports a member as unsafe to disrupt
+// while the latest snapshot taken by backup-restore is older than MaxAge.
+type BackupFreshnessConfig struct {
+	// MaxAge is the age of the latest snapshot beyond which the member is reported as unsafe to disrupt. Zero disables
+	// the safety endpoint.
+	MaxAge time.Duration
+	// PollInterval is the interval in which the latest snapshots are fetched from backup-restore.
+	PollInterval time.Duration
+}
+
+// Validate validates the backup freshness configuration.
+func (c *BackupFreshnessConfig) Validate() (err error) {
+	if c.MaxAge < 0 {
+		err = errors.Join(err, fmt.Errorf("safety-max-backup-age must not be negative"))
+	}
+	if c.MaxAge > 0 && c.PollInterval <= 0 {
+		err = errors.Join(err, fmt.Errorf("safety-backup-poll-interval must be positive"))
+	}
+	return
+}
+
+// DNSConfig holds the configuration of the resolution of host names and of the dial of connections by the HTTP clients
+// of etcd-wrapper for peers and backup-restore.
+type DNSConfig struct {
+	// Servers are the DNS servers, in the form <host>:<port>, which are queried in turn instead of the DNS servers of
+	// the system. The DNS servers of the system are used if empty.
+	Servers []string
+	// LookupTimeout is the time after which the resolution of a host name fails. Zero leaves the timeout of the
+	// resolver in place.
+	LookupTimeout time.Duration
+	// DialTimeout is the time after which establishing a connection to a resolved address fails. Zero leaves the
+	// timeout of the operating system in place.
+	DialTimeout time.Duration
+}
+
+// Validate validates the DNS configuration.
+func (c *DNSConfig) Validate() (err error) {
+	for _, server := range c.Servers {
+		host, port, splitErr := net.SplitHostPort(server)
+		if splitErr != nil || host == "" || port == "" {
+			err = errors.Join(err, fmt.Errorf("dns-servers must be of the form <host>:<port>, got %q", server))
+		}
+	}
+	if c.LookupTimeout < 0 {
+		err = errors.Join(err, fmt.Errorf("dns-lookup-timeout must not be negative"))
+	}
+	if c.DialTimeout < 0 {
+		err = errors.Join(err, fmt.Errorf("dial-timeout must not be negative"))
+	}
+	return
+}
+
+// NewDialer creates the dialer of the HTTP clients for peers and backup-restore.
+func (c *DNSConfig) NewDialer() *util.Dialer {
+	return util.NewDialer(c.Servers, c.LookupTimeout, c.DialTimeout)
+}
+
+// AuthSyncConfig holds the configuration of the reconciliation of etcd users and roles with a declarative spec.
+type AuthSyncConfig struct {
+	// SpecPath is the path of the YAML file describing the desired users, roles and permissions. Reconciliation is disabled if it is empty.
+	SpecPath string
+	// Interval is the interval in which the spec file is checked for changes.
+	Interval time.Duration
+}
+
+// ProposalBackpressureConfig holds the configuration of the detection of raft proposal backpressure.
+type ProposalBackpressureConfig struct {
+	// PendingThreshold is the number of pending proposals from which on backpressure is observed.
+	PendingThreshold int
+	// SustainedDuration is the duration for which backpressure must be observed before it is reported.
+	SustainedDuration time.Duration
+	// FailReadiness makes the readiness probe fail while sustained backpressure is reported.
+	FailReadiness bool
+}
+
+// ApplyLagConfig holds the configuration of the detection of a sustained divergence between the raft committed index
+// and the applied index of the local member.
+type ApplyLagConfig struct {
+	// Threshold is the number of committed but not yet applied entries from which on a lag is observed. Zero disables
+	// the detection.
+	Threshold uint64
+	// SustainedDuration is the duration for which the lag must be observed before it is reported.
+	SustainedDuration time.Duration
+	// FailReadiness makes the readiness probe fail while a sustained lag is reported.
+	FailReadiness bool
+}
+
+// RestoreMarkerConfig holds the configuration of the marker key written into etcd after a restoration.
+type RestoreMarkerConfig struct {
+	// Enabled enables writing the marker key after a restoration of the data directory.
+	Enabled bool
+	// Key is the key into which metadata about the restoration is written.
+	Key string
+}
+
+// RevisionWatermarkConfig holds the configuration of the revision watermark, the highest etcd revision observed by
+// etcd-wrapper, against which the revision of a restored data directory is verified.
+type RevisionWatermarkConfig struct {
+	// Path is the file path into which the revision watermark is persisted. It must be outside the data directory,
+	// which is replaced by a restoration. Disabled if empty.
+	Path string
+	// Interval is the interval in which the revision watermark is updated while etcd is running. It is always updated
+	// before etcd is stopped on shutdown. Zero only updates it on shutdown.
+	Interval time.Duration
+	// MaxDelta is the number of revisions by which a restored data directory may be older than the revision watermark
+	// before the restoration is reported as regressed.
+	MaxDelta int64
+}
+
+// Validate validates the revision watermark configuration.
+func (c *RevisionWatermarkConfig) Validate() (err error) {
+	if c.Interval < 0 {
+		err = errors.Join(err, fmt.Errorf("revision-watermark-interval must not be negative"))
+	}
+	if c.MaxDelta < 0 {
+		err = errors.Join(err, fmt.Errorf("revision-watermark-max-delta must not be negative"))
+	}
+	return
+}
+
+// PostRestoreMaintenanceConfig holds the configuration of the compaction and defragmentation of the etcd DB once etcd
+// is ready after a restoration of the data directory, which replays delta snapshots and hence leaves a long history.
+type PostRestoreMaintenanceConfig struct {
+	// Enabled enables compacting the history and defragmenting the backend after a restoration.
+	Enabled bool
+	// DeferReadiness withholds readiness until the compaction and defragmentation after a restoration have finished.
+	DeferReadiness bool
+}
+
+// Validate validates the post-restore maintenance configuration.
+func (c *PostRestoreMaintenanceConfig) Validate() (err error) {
+	if c.DeferReadiness && !c.Enabled {
+		err = errors.Join(err, fmt.Errorf("post-restore-defer-readiness requires post-restore-maintenance to be enabled"))
+	}
+	return
+}
+
+// CorruptCheckConfig holds the configuration of the corruption checks performed by etcd.
+type CorruptCheckConfig struct {
+	// InitialCheck enables the check of the data of a member against its peers before it serves client requests.
+	InitialCheck bool
+	// CheckTime is the interval of the periodic corruption check across members, used only if the etcd configuration
+	// does not set one. Zero leaves the etcd configuration unchanged.
+	CheckTime time.Duration
+}
+
+// PhaseTimeoutsConfig holds the timeouts of the bootstrap phases. A zero timeout means waiting forever.
+type PhaseTimeoutsConfig struct {
+	// SidecarProbe is the time to wait for backup-restore to respond with an initialization status.
+	SidecarProbe time.Duration
+	// Validation is the time to wait for the data directory validation to complete once it has been triggered.
+	Validation time.Duration
+	// RestorationWait is the time to wait for an initialization in progress, including restoration, to complete.
+	RestorationWait time.Duration
+}
+
+// SidecarOptionalConfig holds the configuration of starting etcd without backup-restore, for environments in which
+// backup-restore is temporarily absent.
+type SidecarOptionalConfig struct {
+	// Enabled allows starting etcd without backup-restore if it has not responded at all within Window and the data
+	// directory passes local verification.
+	Enabled bool
+	// Window is the time within which backup-restore must respond at least once to be waited for.
+	Window time.Duration
+}
+
+// Validate validates the sidecar optional configuration.
+func (c *SidecarOptionalConfig) Validate() (err error) {
+	if c.Enabled && c.Window <= 0 {
+		err = errors.Join(err, fmt.Errorf("sidecar-optional-window must be positive"))
+	}
+	return
+}
+
+// LastKnownGoodConfig holds the configuration of the last known good etcd configuration, which is the etcd
+// configuration with which etcd has last become ready.
+type LastKnownGoodConfig struct {
+	// Path is the file path into which the last known good etcd configuration is written. Disabled if empty.
+	Path string
+	// UseOnFetchFailure starts etcd with the last known good etcd configuration if the etcd configuration cannot be
+	// fetched from backup-restore.
+	UseOnFetchFailure bool
+}
+
+// Validate validates the last known good configuration.
+func (c *LastKnownGoodConfig) Validate() (err error) {
+	if c.UseOnFetchFailure && c.Path == "" {
+		err = errors.Join(err, fmt.Errorf("last-known-good-config-path must be set to use the last known good config"))
+	}
+	return
+}
+
+// AuditLogConfig holds the configuration for the audit log.
+type AuditLogConfig struct {
+	// Path is the path of the audit log file. Audit logging is disabled if it is empty.
+	Path string
+	// MaxSizeBytes is the size in bytes after which the audit log file is rotated.
+	MaxSizeBytes int64
+	// MaxBackups is the maximum number of rotated audit log files to retain.
+	MaxBackups int
+}
+
+// ExternalClientListenerConfig holds the configuration of an additional gRPC client listener of etcd served by
+// etcd-wrapper, whose TLS settings are independent of the client listeners configured for etcd.
+type ExternalClientListenerConfig struct {
+	// URL is the https URL on which the listener listens. The listener is disabled if empty.
+	URL string
+	// CertPath is the path of the server certificate presented by the listener.
+	CertPath string
+	// KeyPath is the path of the key of the server certificate.
+	KeyPath string
+	// TrustedCAPath is the path of the CA bundle against which client certificates are verified. If set, clients must
+	// present a certificate signed by one of the CAs.
+	TrustedCAPath string
+}
+
+// Validate validates the external client listener configuration.
+func (c *ExternalClientListenerConfig) Validate() (err error) {
+	if c.URL == "" {
+		return
+	}
+	u, parseErr := url.Parse(c.URL)
+	switch {
+	case parseErr != nil:
+		err = errors.Join(err, fmt.Errorf("external-client-listen-url is invalid: %w", parseErr))
+	case u.Scheme != "https" || u.Port() == "":
+		err = errors.Join(err, fmt.Errorf("external-client-listen-url must be an https URL with a port, got %q", c.URL))
+	}
+	if c.CertPath == "" || c.KeyPath == "" {
+		err = errors.Join(err, fmt.Errorf("external-client-cert-path and external-client-key-path must be set if external-client-listen-url is set"))
+	}
+	return
+}
+
+// ClientUnixSocketConfig holds the configuration of an additional client listener of the embedded etcd on a unix
+// socket. Clients connecting via the socket are not authenticated by TLS, so access is gated by the permissions of
+// the socket file.
+type ClientUnixSocketConfig struct {
+	// Path is the absolute path of the unix socket. The listener is disabled if empty.
+	Path string
+	// Mode is the octal file mode of the unix socket, e.g. 0660. If empty, DefaultClientUnixSocketMode is used.
+	Mode string
+}
+
+// Validate validates the client unix socket configuration.
+func (c *ClientUnixSocketConfig) Validate() (err error) {
+	if c.Path == "" {
+		return
+	}
+	if !filepath.IsAbs(c.Path) {
+		err = errors.Join(err, fmt.Errorf("client-unix-socket-path must be an absolute path, got %q", c.Path))
+	}
+	if _, parseErr := c.FileMode(); parseErr != nil {
+		err = errors.Join(err, parseErr)
+	}
+	return
+}
+
+// FileMode returns the file mode of the unix socket.
+func (c *ClientUnixSocketConfig) FileMode() (os.FileMode, error) {
+	if c.Mode == "" {
+		return DefaultClientUnixSocketMode, nil
+	}
+	mode, err := strconv.ParseUint(c.Mode, 8, 32)
+	if err != nil || mode > 0777 {
+		return 0, fmt.Errorf("client-unix-socket-mode must be an octal file mode, e.g. 0660, got %q", c.Mode)
+	}
+	return os.FileMode(mode), nil
 }
 
 // EtcdClientTLSConfig holds the TLS configuration to configure a etcd client.
@@ -33,6 +1656,16 @@ type EtcdClientTLSConfig struct {
 	CertPath string
 	// KeyPath is the path to the client key
 	KeyPath string
+	// KeyPassphrase is the passphrase of the client key if it is encrypted. It is never logged.
+	KeyPassphrase string `json:"-"`
+}
+
+// EtcdClientAuthConfig holds the credentials used by etcd-wrapper to authenticate against etcd when auth is enabled.
+type EtcdClientAuthConfig struct {
+	// Username is the name of the etcd user. Password authentication is disabled if it is empty.
+	Username string
+	// Password is the password of the etcd user. It is never logged.
+	Password string `json:"-"`
 }
 
 // BackupRestoreConfig defines parameters needed to interact with the backup-restore container
@@ -40,31 +1673,139 @@ type BackupRestoreConfig struct {
 	HostPort         string
 	TLSEnabled       bool
 	CaCertBundlePath string
+	// ServerName is the name expected in the TLS certificate of backup-restore. Defaults to the host of HostPort if empty.
+	ServerName string
+	// Protocol is the protocol used to communicate with backup-restore, either `http` or `grpc`. Defaults to `http` if empty.
+	Protocol string
+	// CAReloadInterval is the interval in which CaCertBundlePath is checked for changes, which are then used for new
+	// connections to backup-restore. Disabled if not positive.
+	CAReloadInterval time.Duration
+	// HostPortEnv is the name of the environment variable from which HostPort is taken at start, if set.
+	HostPortEnv string
+	// HostPortFile is the path of a file, e.g. projected by the downward API, from which HostPort is taken at start, if
+	// set. It is read again every HostPortRefreshInterval, so that backup-restore can move without a restart.
+	HostPortFile string
+	// HostPortRefreshInterval is the interval in which HostPortFile is read again. Disabled if not positive.
+	HostPortRefreshInterval time.Duration
 }
 
-// Validate validates backup-restore configuration.
+// Validate validates backup-restore configuration. All problems are returned at once, joined into a single error.
 func (c *BackupRestoreConfig) Validate() (err error) {
-	splits := strings.Split(c.HostPort, ":")
-	if len(splits) < 2 {
-		err = errors.Join(err, fmt.Errorf("both host and port needs to be specified and should be adhere to format: <host>:<port>"))
-	}
-
 	if strings.HasPrefix(c.HostPort, "http:") || strings.HasPrefix(c.HostPort, "https:") {
 		err = errors.Join(err, fmt.Errorf("backup-restore-host-port should not contain scheme"))
+	} else if hostPortErr := validateHostPort(c.HostPort); hostPortErr != nil {
+		err = errors.Join(err, fmt.Errorf("invalid backup-restore-host-port %q, must adhere to format <host>:<port>: %w", c.HostPort, hostPortErr))
+	}
+	if c.Protocol != "" && c.Protocol != BackupRestoreProtocolHTTP && c.Protocol != BackupRestoreProtocolGRPC {
+		err = errors.Join(err, fmt.Errorf("unsupported sidecar protocol %q, must be one of: %s, %s", c.Protocol, BackupRestoreProtocolHTTP, BackupRestoreProtocolGRPC))
+	}
+	if c.Protocol == BackupRestoreProtocolGRPC && c.HostPortFile != "" && c.HostPortRefreshInterval > 0 {
+		err = errors.Join(err, fmt.Errorf("backup-restore-host-port-file cannot be read again with sidecar protocol %s, set backup-restore-host-port-refresh-interval to 0", BackupRestoreProtocolGRPC))
 	}
 	if c.TLSEnabled {
 		if strings.TrimSpace(c.CaCertBundlePath) == "" {
 			err = errors.Join(err, fmt.Errorf("certificate bundle path cannot be nil or empty when TLS is enabled"))
+		} else if caErr := validateCACertBundle(c.CaCertBundlePath); caErr != nil {
+			err = errors.Join(err, fmt.Errorf("invalid backup-restore-ca-cert-bundle-path: %w", caErr))
+		}
+	} else {
+		if c.CaCertBundlePath != "" {
+			err = errors.Join(err, fmt.Errorf("backup-restore-ca-cert-bundle-path is only used when TLS is enabled via backup-restore-tls-enabled"))
+		}
+		if c.ServerName != "" {
+			err = errors.Join(err, fmt.Errorf("backup-restore-server-name is only used when TLS is enabled via backup-restore-tls-enabled"))
 		}
 	}
 	return
 }
 
+// validateHostPort validates that hostPort consists of an optional host and a valid port.
+func validateHostPort(hostPort string) error {
+	_, port, err := net.SplitHostPort(hostPort)
+	if err != nil {
+		return err
+	}
+	if portNumber, err := strconv.Atoi(port); err != nil || portNumber < 1 || portNumber > 65535 {
+		return fmt.Errorf("port %q must be a number between 1 and 65535", port)
+	}
+	return nil
+}
+
+// validateCACertBundle validates that the file at path exists and only contains PEM encoded certificates, at least one.
+func validateCACertBundle(path string) error {
+	caCertBundle, err := os.ReadFile(path) // #nosec G304 -- path is passed by the operator of etcd-wrapper
+	if err != nil {
+		return err
+	}
+	numCerts := 0
+	for rest := caCertBundle; ; {
+		var block *pem.Block
+		if block, rest = pem.Decode(rest); block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			return fmt.Errorf("%s contains an unexpected PEM block of type %q", path, block.Type)
+		}
+		if _, err = x509.ParseCertificate(block.Bytes); err != nil {
+			return fmt.Errorf("%s contains an unparseable certificate: %w", path, err)
+		}
+		numCerts++
+	}
+	if numCerts == 0 {
+		return fmt.Errorf("%s does not contain any PEM encoded certificate", path)
+	}
+	return nil
+}
+
+// DiscoverHostPort returns the host and port of backup-restore published in HostPortFile or in the environment variable
+// HostPortEnv. It returns an empty string if neither is configured.
+func (c *BackupRestoreConfig) DiscoverHostPort() (string, error) {
+	switch {
+	case c.HostPortFile != "" && c.HostPortEnv != "":
+		return "", fmt.Errorf("backup-restore-host-port-file and backup-restore-host-port-env are mutually exclusive")
+	case c.HostPortFile != "":
+		content, err := os.ReadFile(c.HostPortFile) // #nosec G304 -- path is configured by the operator.
+		if err != nil {
+			return "", fmt.Errorf("failed to read host and port of backup-restore: %w", err)
+		}
+		if hostPort := strings.TrimSpace(string(content)); hostPort != "" {
+			return hostPort, nil
+		}
+		return "", fmt.Errorf("%s does not contain the host and port of backup-restore", c.HostPortFile)
+	case c.HostPortEnv != "":
+		if hostPort := strings.TrimSpace(os.Getenv(c.HostPortEnv)); hostPort != "" {
+			return hostPort, nil
+		}
+		return "", fmt.Errorf("environment variable %s does not contain the host and port of backup-restore", c.HostPortEnv)
+	}
+	return "", nil
+}
+
+// ResolveHostPort replaces HostPort by the one published in HostPortFile or HostPortEnv, if either is configured.
+func (c *BackupRestoreConfig) ResolveHostPort() error {
+	hostPort, err := c.DiscoverHostPort()
+	if err != nil {
+		return err
+	}
+	if hostPort != "" {
+		c.HostPort = hostPort
+	}
+	return nil
+}
+
 // GetBaseAddress returns the complete address of the backup restore container.
 func (c *BackupRestoreConfig) GetBaseAddress() string {
 	return util.ConstructBaseAddress(c.TLSEnabled, c.HostPort)
 }
 
+// GetServerName returns the name expected in the TLS certificate of backup-restore.
+func (c *BackupRestoreConfig) GetServerName() string {
+	if c.ServerName != "" {
+		return c.ServerName
+	}
+	return c.GetHost()
+}
+
 // GetHost extracts the backup-restore server host from host-port string.
 func (c *BackupRestoreConfig) GetHost() string {
 	host := "localhost"
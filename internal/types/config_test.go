@@ -6,7 +6,13 @@ package types
 
 import (
 	"fmt"
+	"math"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/testutil"
 
 	. "github.com/onsi/gomega"
 )
@@ -30,26 +36,742 @@ func TestGetBaseAddressWithTLSDisabled(t *testing.T) {
 	g.Expect(config.GetBaseAddress()).To(Equal(expectedBaseAddress))
 }
 
+func TestGetServerName(t *testing.T) {
+	table := []struct {
+		description        string
+		config             BackupRestoreConfig
+		expectedServerName string
+	}{
+		{"should default to host of host-port", BackupRestoreConfig{HostPort: "etcd-main-local:8080"}, "etcd-main-local"},
+		{"should default to localhost when host-port has no host", BackupRestoreConfig{HostPort: ":8080"}, "localhost"},
+		{"should prefer configured server name", BackupRestoreConfig{HostPort: "10.0.0.10:8080", ServerName: "etcd-backup-restore"}, "etcd-backup-restore"},
+	}
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		g.Expect(entry.config.GetServerName()).To(Equal(entry.expectedServerName))
+	}
+}
+
+func TestResolveHostPort(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "host-port")
+	emptyFilePath := filepath.Join(dir, "empty")
+	g := NewWithT(t)
+	g.Expect(os.WriteFile(filePath, []byte("etcd-main-local:8080\n"), 0600)).To(Succeed())
+	g.Expect(os.WriteFile(emptyFilePath, nil, 0600)).To(Succeed())
+	t.Setenv("BACKUP_RESTORE_HOST_PORT", "etcd-main-client:8080")
+
+	table := []struct {
+		description      string
+		config           BackupRestoreConfig
+		expectError      bool
+		expectedHostPort string
+	}{
+		{"should keep host-port if discovery is not configured", BackupRestoreConfig{HostPort: ":8080"}, false, ":8080"},
+		{"should take host-port from the file", BackupRestoreConfig{HostPort: ":8080", HostPortFile: filePath}, false, "etcd-main-local:8080"},
+		{"should take host-port from the environment variable", BackupRestoreConfig{HostPort: ":8080", HostPortEnv: "BACKUP_RESTORE_HOST_PORT"}, false, "etcd-main-client:8080"},
+		{"should fail if the file does not exist", BackupRestoreConfig{HostPort: ":8080", HostPortFile: filepath.Join(dir, "missing")}, true, ":8080"},
+		{"should fail if the file is empty", BackupRestoreConfig{HostPort: ":8080", HostPortFile: emptyFilePath}, true, ":8080"},
+		{"should fail if the environment variable is not set", BackupRestoreConfig{HostPort: ":8080", HostPortEnv: "UNSET_BACKUP_RESTORE_HOST_PORT"}, true, ":8080"},
+		{"should fail if both file and environment variable are configured", BackupRestoreConfig{HostPort: ":8080", HostPortFile: filePath, HostPortEnv: "BACKUP_RESTORE_HOST_PORT"}, true, ":8080"},
+	}
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		err := entry.config.ResolveHostPort()
+		g.Expect(err != nil).To(Equal(entry.expectError))
+		g.Expect(entry.config.HostPort).To(Equal(entry.expectedHostPort))
+	}
+}
+
 func TestValidate(t *testing.T) {
+	g := NewWithT(t)
+	dir := t.TempDir()
+	tlsResourceCreator, err := testutil.NewTLSResourceCreator()
+	g.Expect(err).ToNot(HaveOccurred())
+	caCertKeyPair, err := tlsResourceCreator.CreateCACertAndKey()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(caCertKeyPair.EncodeAndWrite(dir, "ca.crt", "ca.key")).To(Succeed())
+	caCertBundlePath := filepath.Join(dir, "ca.crt")
+	caKeyPath := filepath.Join(dir, "ca.key")
+	invalidPEMPath := filepath.Join(dir, "invalid.crt")
+	g.Expect(os.WriteFile(invalidPEMPath, []byte("not a certificate"), 0600)).To(Succeed())
+
 	table := []struct {
 		description      string
 		tlsEnabled       bool
 		hostPort         string
 		caCertBundlePath string
-		expectedError    bool
+		serverName       string
+		expectedErrors   int
 	}{
-		{"missing host should result in error", false, "2379", "", true},
-		{"missing port should result in error", false, "localhost", "", true},
-		{"should allow empty host", false, ":2379", "", false},
-		{"should disallow specifying scheme", false, "http://localhost:2379", "", true},
-		{"should disallow empty caCertBundlePath when TLS is enabled", true, ":2379", "", true},
+		{"missing host should result in error", false, "2379", "", "", 1},
+		{"missing port should result in error", false, "localhost", "", "", 1},
+		{"should allow empty host", false, ":2379", "", "", 0},
+		{"should disallow non-numeric port", false, "localhost:http", "", "", 1},
+		{"should disallow port out of range", false, "localhost:65536", "", "", 1},
+		{"should disallow specifying scheme", false, "http://localhost:2379", "", "", 1},
+		{"should disallow empty caCertBundlePath when TLS is enabled", true, ":2379", "", "", 1},
+		{"should allow existing CA cert bundle when TLS is enabled", true, ":2379", caCertBundlePath, "etcd-backup-restore", 0},
+		{"should disallow non-existing CA cert bundle", true, ":2379", "/does/not/exist", "", 1},
+		{"should disallow CA cert bundle without certificates", true, ":2379", invalidPEMPath, "", 1},
+		{"should disallow CA cert bundle containing a private key", true, ":2379", caKeyPath, "", 1},
+		{"should disallow caCertBundlePath and serverName when TLS is disabled", false, ":2379", caCertBundlePath, "etcd-backup-restore", 2},
+		{"should report all problems at once", true, "localhost", "/does/not/exist", "", 2},
 	}
 	for _, entry := range table {
 		g := NewWithT(t)
 		t.Log(entry.description)
 		c := createSidecarConfig(entry.tlsEnabled, entry.hostPort)
 		c.CaCertBundlePath = entry.caCertBundlePath
+		c.ServerName = entry.serverName
 		err := c.Validate()
+		if entry.expectedErrors == 0 {
+			g.Expect(err).ToNot(HaveOccurred())
+			continue
+		}
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(err.(interface{ Unwrap() []error }).Unwrap()).To(HaveLen(entry.expectedErrors))
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	g := NewWithT(t)
+
+	t.Log("should accept the default configuration")
+	config := NewDefaultConfig()
+	g.Expect(config.Validate()).To(Succeed())
+
+	t.Log("should report the problems of all sections")
+	config.Hibernation.Timeout = -time.Second
+	config.DNS.Servers = []string{"localhost"}
+	err := config.Validate()
+	g.Expect(err).To(MatchError(ContainSubstring("hibernation-timeout must not be negative")))
+	g.Expect(err).To(MatchError(ContainSubstring("dns-servers must be of the form <host>:<port>")))
+}
+
+func TestValidateProtocol(t *testing.T) {
+	table := []struct {
+		description   string
+		protocol      string
+		expectedError bool
+	}{
+		{"should allow empty protocol", "", false},
+		{"should allow http protocol", BackupRestoreProtocolHTTP, false},
+		{"should allow grpc protocol", BackupRestoreProtocolGRPC, false},
+		{"should disallow unknown protocol", "websocket", true},
+	}
+	for _, entry := range table {
+		g := NewWithT(t)
+		t.Log(entry.description)
+		c := createSidecarConfig(false, defaultTestHostPort)
+		c.Protocol = entry.protocol
+		err := c.Validate()
+		g.Expect(err != nil).To(Equal(entry.expectedError))
+	}
+}
+
+func TestValidateHostPortFileWithProtocol(t *testing.T) {
+	table := []struct {
+		description             string
+		protocol                string
+		hostPortRefreshInterval time.Duration
+		expectedError           bool
+	}{
+		{"should allow refreshing the host port file with http protocol", BackupRestoreProtocolHTTP, time.Minute, false},
+		{"should disallow refreshing the host port file with grpc protocol", BackupRestoreProtocolGRPC, time.Minute, true},
+		{"should allow reading the host port file once with grpc protocol", BackupRestoreProtocolGRPC, 0, false},
+	}
+	for _, entry := range table {
+		g := NewWithT(t)
+		t.Log(entry.description)
+		c := createSidecarConfig(false, defaultTestHostPort)
+		c.Protocol = entry.protocol
+		c.HostPortFile = "/var/run/backup-restore/host-port"
+		c.HostPortRefreshInterval = entry.hostPortRefreshInterval
+		err := c.Validate()
+		g.Expect(err != nil).To(Equal(entry.expectedError))
+	}
+}
+
+func TestValidateBackupRestoreProtocol(t *testing.T) {
+	table := []struct {
+		description            string
+		protocol               string
+		etcdConfigPollInterval time.Duration
+		quotaReportInterval    time.Duration
+		expectedError          bool
+	}{
+		{"should allow polling and reporting with http protocol", BackupRestoreProtocolHTTP, time.Minute, time.Minute, false},
+		{"should allow grpc protocol without polling and reporting", BackupRestoreProtocolGRPC, 0, 0, false},
+		{"should disallow polling the etcd configuration with grpc protocol", BackupRestoreProtocolGRPC, time.Minute, 0, true},
+		{"should disallow reporting the quota advisory with grpc protocol", BackupRestoreProtocolGRPC, 0, time.Minute, true},
+	}
+	for _, entry := range table {
+		g := NewWithT(t)
+		t.Log(entry.description)
+		c := &Config{
+			BackupRestore:          BackupRestoreConfig{Protocol: entry.protocol},
+			EtcdConfigPollInterval: entry.etcdConfigPollInterval,
+			QuotaAdvisory:          QuotaAdvisoryConfig{ReportInterval: entry.quotaReportInterval},
+		}
+		err := c.ValidateBackupRestoreProtocol()
+		g.Expect(err != nil).To(Equal(entry.expectedError))
+	}
+}
+
+func TestValidateSnapshotOnShutdown(t *testing.T) {
+	table := []struct {
+		description   string
+		config        SnapshotOnShutdownConfig
+		expectedError bool
+	}{
+		{"should allow disabled snapshot on shutdown", SnapshotOnShutdownConfig{}, false},
+		{"should allow full snapshot", SnapshotOnShutdownConfig{Kind: SnapshotKindFull, Timeout: time.Minute}, false},
+		{"should allow delta snapshot", SnapshotOnShutdownConfig{Kind: SnapshotKindDelta, Timeout: time.Minute}, false},
+		{"should disallow unknown snapshot kind", SnapshotOnShutdownConfig{Kind: "incremental", Timeout: time.Minute}, true},
+		{"should disallow zero timeout", SnapshotOnShutdownConfig{Kind: SnapshotKindDelta}, true},
+	}
+	for _, entry := range table {
+		g := NewWithT(t)
+		t.Log(entry.description)
+		err := entry.config.Validate()
+		g.Expect(err != nil).To(Equal(entry.expectedError))
+	}
+}
+
+func TestValidateCompaction(t *testing.T) {
+	table := []struct {
+		description   string
+		config        CompactionConfig
+		expectedError bool
+	}{
+		{"should allow disabled compaction", CompactionConfig{}, false},
+		{"should allow revision threshold with check interval", CompactionConfig{RevisionThreshold: 1000, CheckInterval: time.Minute}, false},
+		{"should disallow negative thresholds", CompactionConfig{DBSizeGrowthPercent: -1, CheckInterval: time.Minute}, true},
+		{"should disallow zero check interval when enabled", CompactionConfig{DBSizeGrowthPercent: 20}, true},
+	}
+	for _, entry := range table {
+		g := NewWithT(t)
+		t.Log(entry.description)
+		err := entry.config.Validate()
+		g.Expect(err != nil).To(Equal(entry.expectedError))
+	}
+}
+
+func TestValidateDBSizeTrend(t *testing.T) {
+	table := []struct {
+		description   string
+		config        DBSizeTrendConfig
+		expectedError bool
+	}{
+		{"should allow disabled DB size trend", DBSizeTrendConfig{}, false},
+		{"should allow DB size trend with window and sample interval", DBSizeTrendConfig{Horizon: 72 * time.Hour, Window: 6 * time.Hour, SampleInterval: time.Minute}, false},
+		{"should disallow negative horizon", DBSizeTrendConfig{Horizon: -time.Hour}, true},
+		{"should disallow zero sample interval when enabled", DBSizeTrendConfig{Horizon: time.Hour, Window: time.Hour}, true},
+		{"should disallow window shorter than two sample intervals", DBSizeTrendConfig{Horizon: time.Hour, Window: time.Minute, SampleInterval: time.Minute}, true},
+	}
+	for _, entry := range table {
+		g := NewWithT(t)
+		t.Log(entry.description)
+		err := entry.config.Validate()
+		g.Expect(err != nil).To(Equal(entry.expectedError))
+	}
+}
+
+func TestValidateHibernation(t *testing.T) {
+	table := []struct {
+		description     string
+		config          HibernationConfig
+		expectedError   bool
+		expectedTimeout time.Duration
+	}{
+		{"should default the timeout", HibernationConfig{}, false, DefaultHibernationTimeout},
+		{"should allow intent file and timeout", HibernationConfig{IntentFilePath: "/var/run/etcd/hibernate", Timeout: time.Minute}, false, time.Minute},
+		{"should disallow negative timeout", HibernationConfig{Timeout: -time.Minute}, true, -time.Minute},
+	}
+	for _, entry := range table {
+		g := NewWithT(t)
+		t.Log(entry.description)
+		err := entry.config.Validate()
+		g.Expect(err != nil).To(Equal(entry.expectedError))
+		g.Expect(entry.config.GetTimeout()).To(Equal(entry.expectedTimeout))
+	}
+}
+
+func TestValidateLogSinks(t *testing.T) {
+	table := []struct {
+		description   string
+		config        LogSinksConfig
+		expectedError bool
+	}{
+		{"should allow default outputs", LogSinksConfig{}, false},
+		{"should allow separate outputs", LogSinksConfig{
+			Wrapper: LogSinkConfig{Outputs: []string{"stdout", "/var/log/etcd-wrapper.log"}},
+			Etcd:    LogSinkConfig{Outputs: []string{"stdout", "/var/log/etcd.log"}, MaxSizeBytes: 1024, MaxBackups: 1},
+		}, false},
+		{"should disallow negative max size", LogSinksConfig{Etcd: LogSinkConfig{MaxSizeBytes: -1}}, true},
+		{"should disallow negative max backups", LogSinksConfig{Wrapper: LogSinkConfig{MaxBackups: -1}}, true},
+		{"should disallow empty outputs", LogSinksConfig{Wrapper: LogSinkConfig{Outputs: []string{""}}}, true},
+		{"should disallow a file shared by both outputs", LogSinksConfig{
+			Wrapper: LogSinkConfig{Outputs: []string{"/var/log/etcd.log"}},
+			Etcd:    LogSinkConfig{Outputs: []string{"/var/log/etcd.log"}},
+		}, true},
+	}
+	for _, entry := range table {
+		g := NewWithT(t)
+		t.Log(entry.description)
+		err := entry.config.Validate()
+		g.Expect(err != nil).To(Equal(entry.expectedError))
+	}
+}
+
+func TestValidateRevisionWatermark(t *testing.T) {
+	table := []struct {
+		description   string
+		config        RevisionWatermarkConfig
+		expectedError bool
+	}{
+		{"should allow disabled revision watermark", RevisionWatermarkConfig{}, false},
+		{"should allow path, interval and max delta", RevisionWatermarkConfig{Path: DefaultRevisionWatermarkFilePath, Interval: time.Minute, MaxDelta: 100}, false},
+		{"should disallow negative interval", RevisionWatermarkConfig{Interval: -time.Minute}, true},
+		{"should disallow negative max delta", RevisionWatermarkConfig{MaxDelta: -1}, true},
+	}
+	for _, entry := range table {
+		g := NewWithT(t)
+		t.Log(entry.description)
+		g.Expect(entry.config.Validate() != nil).To(Equal(entry.expectedError))
+	}
+}
+
+func TestValidateHealthScore(t *testing.T) {
+	table := []struct {
+		description              string
+		config                   HealthScoreConfig
+		expectedError            bool
+		expectedSmoothingFactor  float64
+		expectedLatencyThreshold time.Duration
+	}{
+		{"should default smoothing factor and latency threshold", HealthScoreConfig{}, false, DefaultHealthScoreSmoothingFactor, DefaultHealthScoreLatencyThreshold},
+		{"should allow interval, smoothing factor and latency threshold", HealthScoreConfig{Interval: time.Second, SmoothingFactor: 0.5, LatencyThreshold: time.Second}, false, 0.5, time.Second},
+		{"should disallow negative interval", HealthScoreConfig{Interval: -time.Second}, true, DefaultHealthScoreSmoothingFactor, DefaultHealthScoreLatencyThreshold},
+		{"should disallow smoothing factor above 1", HealthScoreConfig{SmoothingFactor: 1.5}, true, 1.5, DefaultHealthScoreLatencyThreshold},
+		{"should disallow negative latency threshold", HealthScoreConfig{LatencyThreshold: -time.Second}, true, DefaultHealthScoreSmoothingFactor, -time.Second},
+	}
+	for _, entry := range table {
+		g := NewWithT(t)
+		t.Log(entry.description)
+		err := entry.config.Validate()
+		g.Expect(err != nil).To(Equal(entry.expectedError))
+		g.Expect(entry.config.GetSmoothingFactor()).To(Equal(entry.expectedSmoothingFactor))
+		g.Expect(entry.config.GetLatencyThreshold()).To(Equal(entry.expectedLatencyThreshold))
+	}
+}
+
+func TestValidateQuotaAdvisory(t *testing.T) {
+	table := []struct {
+		description       string
+		config            QuotaAdvisoryConfig
+		expectedError     bool
+		expectedThreshold float64
+	}{
+		{"should default the risk threshold", QuotaAdvisoryConfig{}, false, DefaultQuotaRiskThreshold},
+		{"should allow risk threshold and report interval", QuotaAdvisoryConfig{RiskThreshold: 0.9, ReportInterval: time.Minute}, false, 0.9},
+		{"should disallow risk threshold above 1", QuotaAdvisoryConfig{RiskThreshold: 1.5}, true, 1.5},
+		{"should disallow negative report interval", QuotaAdvisoryConfig{ReportInterval: -time.Minute}, true, DefaultQuotaRiskThreshold},
+	}
+	for _, entry := range table {
+		g := NewWithT(t)
+		t.Log(entry.description)
+		err := entry.config.Validate()
+		g.Expect(err != nil).To(Equal(entry.expectedError))
+		g.Expect(entry.config.GetRiskThreshold()).To(Equal(entry.expectedThreshold))
+	}
+}
+
+func TestValidatePrefixUsage(t *testing.T) {
+	table := []struct {
+		description   string
+		config        PrefixUsageConfig
+		expectedError bool
+	}{
+		{"should allow disabled prefix usage sampling", PrefixUsageConfig{}, false},
+		{"should allow prefixes with interval", PrefixUsageConfig{Prefixes: []string{"/registry/tenant-a/", "/registry/tenant-b/"}, Interval: time.Minute}, false},
+		{"should disallow empty prefix", PrefixUsageConfig{Prefixes: []string{""}, Interval: time.Minute}, true},
+		{"should disallow duplicate prefixes", PrefixUsageConfig{Prefixes: []string{"/registry/", "/registry/"}, Interval: time.Minute}, true},
+		{"should disallow zero interval when enabled", PrefixUsageConfig{Prefixes: []string{"/registry/"}}, true},
+	}
+	for _, entry := range table {
+		g := NewWithT(t)
+		t.Log(entry.description)
+		err := entry.config.Validate()
+		g.Expect(err != nil).To(Equal(entry.expectedError))
+	}
+}
+
+func TestValidateServerTuning(t *testing.T) {
+	table := []struct {
+		description   string
+		config        ServerTuningConfig
+		expectedError bool
+	}{
+		{"should allow empty server tuning", ServerTuningConfig{}, false},
+		{"should allow tuned settings", ServerTuningConfig{MaxConcurrentStreams: 1000, MaxRequestBytes: 8 * 1024 * 1024, GRPCKeepAliveInterval: time.Minute, GRPCKeepAliveTimeout: 10 * time.Second}, false},
+		{"should disallow max concurrent streams exceeding uint32", ServerTuningConfig{MaxConcurrentStreams: math.MaxUint32 + 1}, true},
+		{"should disallow max request bytes exceeding the gRPC message limit", ServerTuningConfig{MaxRequestBytes: math.MaxInt32}, true},
+		{"should disallow negative keepalive settings", ServerTuningConfig{GRPCKeepAliveTimeout: -time.Second}, true},
+		{"should disallow max request bytes below the requirement of kube-apiserver", ServerTuningConfig{MaxRequestBytes: 1024 * 1024}, true},
+		{"should allow max request bytes required by kube-apiserver", ServerTuningConfig{MaxRequestBytes: KubeAPIServerMinRequestBytes, MaxTxnOps: 256}, false},
+		{"should disallow max txn ops exceeding int32", ServerTuningConfig{MaxTxnOps: math.MaxInt32 + 1}, true},
+		{"should allow request size warning ratio", ServerTuningConfig{RequestSizeWarningRatio: 0.8}, false},
+		{"should disallow request size warning ratio above one", ServerTuningConfig{RequestSizeWarningRatio: 1.5}, true},
+	}
+	for _, entry := range table {
+		g := NewWithT(t)
+		t.Log(entry.description)
+		err := entry.config.Validate()
+		g.Expect(err != nil).To(Equal(entry.expectedError))
+	}
+}
+
+func TestValidateCertRotation(t *testing.T) {
+	table := []struct {
+		description   string
+		config        CertRotationConfig
+		expectedError bool
+	}{
+		{"should allow disabled cert rotation", CertRotationConfig{}, false},
+		{"should allow cert rotation with lock", CertRotationConfig{CheckInterval: time.Minute, LockKey: DefaultCertRotationLockKey, LockTTL: DefaultCertRotationLockTTL}, false},
+		{"should disallow empty lock key", CertRotationConfig{CheckInterval: time.Minute, LockTTL: DefaultCertRotationLockTTL}, true},
+		{"should disallow lock TTL below one second", CertRotationConfig{CheckInterval: time.Minute, LockKey: DefaultCertRotationLockKey, LockTTL: time.Millisecond}, true},
+	}
+	for _, entry := range table {
+		g := NewWithT(t)
+		t.Log(entry.description)
+		err := entry.config.Validate()
+		g.Expect(err != nil).To(Equal(entry.expectedError))
+	}
+}
+
+func TestValidateMaintenanceHistory(t *testing.T) {
+	table := []struct {
+		description   string
+		config        MaintenanceHistoryConfig
+		expectedError bool
+	}{
+		{"should allow default history size", MaintenanceHistoryConfig{}, false},
+		{"should allow persisted history", MaintenanceHistoryConfig{Path: DefaultMaintenanceHistoryFilePath, Size: DefaultMaintenanceHistorySize}, false},
+		{"should disallow negative history size", MaintenanceHistoryConfig{Size: -1}, true},
+	}
+	for _, entry := range table {
+		g := NewWithT(t)
+		t.Log(entry.description)
+		err := entry.config.Validate()
+		g.Expect(err != nil).To(Equal(entry.expectedError))
+	}
+}
+
+func TestValidateDiskLatency(t *testing.T) {
+	table := []struct {
+		description   string
+		config        DiskLatencyConfig
+		expectedError bool
+	}{
+		{"should allow disabled probe", DiskLatencyConfig{}, false},
+		{"should allow enabled probe", DiskLatencyConfig{ProbeInterval: time.Second, Window: DefaultDiskLatencyWindow, WarningThreshold: DefaultDiskLatencyWarningThreshold}, false},
+		{"should disallow negative probe interval", DiskLatencyConfig{ProbeInterval: -time.Second}, true},
+		{"should disallow negative window", DiskLatencyConfig{Window: -1}, true},
+		{"should disallow negative warning threshold", DiskLatencyConfig{WarningThreshold: -time.Millisecond}, true},
+	}
+	for _, entry := range table {
+		g := NewWithT(t)
+		t.Log(entry.description)
+		err := entry.config.Validate()
+		g.Expect(err != nil).To(Equal(entry.expectedError))
+	}
+}
+
+func TestValidateDefragmentation(t *testing.T) {
+	table := []struct {
+		description   string
+		config        DefragmentationConfig
+		expectedError bool
+	}{
+		{"should allow disabled defragmentation", DefragmentationConfig{}, false},
+		{"should allow scheduled defragmentation", DefragmentationConfig{Schedule: "0 3 * * 0", KeyPrefix: DefaultDefragmentationKeyPrefix, LockTTL: DefaultDefragmentationLockTTL, Timeout: DefaultDefragmentationTimeout}, false},
+		{"should disallow invalid schedule", DefragmentationConfig{Schedule: "0 25 * * *", KeyPrefix: DefaultDefragmentationKeyPrefix, LockTTL: DefaultDefragmentationLockTTL, Timeout: DefaultDefragmentationTimeout}, true},
+		{"should disallow empty key prefix", DefragmentationConfig{Schedule: "0 3 * * 0", LockTTL: DefaultDefragmentationLockTTL, Timeout: DefaultDefragmentationTimeout}, true},
+		{"should disallow lock TTL below one second", DefragmentationConfig{Schedule: "0 3 * * 0", KeyPrefix: DefaultDefragmentationKeyPrefix, LockTTL: time.Millisecond, Timeout: DefaultDefragmentationTimeout}, true},
+		{"should disallow non-positive timeout", DefragmentationConfig{Schedule: "0 3 * * 0", KeyPrefix: DefaultDefragmentationKeyPrefix, LockTTL: DefaultDefragmentationLockTTL}, true},
+	}
+	for _, entry := range table {
+		g := NewWithT(t)
+		t.Log(entry.description)
+		err := entry.config.Validate()
+		g.Expect(err != nil).To(Equal(entry.expectedError))
+	}
+}
+
+func TestValidateMaintenanceLeader(t *testing.T) {
+	table := []struct {
+		description   string
+		config        MaintenanceLeaderConfig
+		expectedError bool
+	}{
+		{"should allow disabled maintenance leader election", MaintenanceLeaderConfig{}, false},
+		{"should allow maintenance leader election", MaintenanceLeaderConfig{LeaseName: "etcd-main-maintenance", LeaseDuration: DefaultMaintenanceLeaderLeaseDuration, RenewInterval: DefaultMaintenanceLeaderRenewInterval}, false},
+		{"should disallow lease duration below one second", MaintenanceLeaderConfig{LeaseName: "etcd-main-maintenance", LeaseDuration: time.Millisecond, RenewInterval: time.Microsecond}, true},
+		{"should disallow renew interval not less than lease duration", MaintenanceLeaderConfig{LeaseName: "etcd-main-maintenance", LeaseDuration: DefaultMaintenanceLeaderLeaseDuration, RenewInterval: DefaultMaintenanceLeaderLeaseDuration}, true},
+	}
+	for _, entry := range table {
+		g := NewWithT(t)
+		t.Log(entry.description)
+		err := entry.config.Validate()
+		g.Expect(err != nil).To(Equal(entry.expectedError))
+	}
+}
+
+func TestValidateWarmUp(t *testing.T) {
+	table := []struct {
+		description   string
+		config        WarmUpConfig
+		expectedError bool
+	}{
+		{"should allow disabled warm-up", WarmUpConfig{}, false},
+		{"should allow warm-up of the whole keyspace", WarmUpConfig{MaxKeys: 100000, Timeout: DefaultWarmUpTimeout}, false},
+		{"should allow warm-up of prefixes", WarmUpConfig{MaxKeys: 100000, Prefixes: []string{"/registry/"}, Timeout: DefaultWarmUpTimeout}, false},
+		{"should disallow negative max keys", WarmUpConfig{MaxKeys: -1}, true},
+		{"should disallow empty prefixes", WarmUpConfig{MaxKeys: 100000, Prefixes: []string{""}, Timeout: DefaultWarmUpTimeout}, true},
+		{"should disallow non-positive timeout", WarmUpConfig{MaxKeys: 100000}, true},
+	}
+	for _, entry := range table {
+		g := NewWithT(t)
+		t.Log(entry.description)
+		err := entry.config.Validate()
+		g.Expect(err != nil).To(Equal(entry.expectedError))
+	}
+}
+
+func TestValidateSidecarOptional(t *testing.T) {
+	table := []struct {
+		description   string
+		config        SidecarOptionalConfig
+		expectedError bool
+	}{
+		{"should allow disabled sidecar optional mode", SidecarOptionalConfig{}, false},
+		{"should allow sidecar optional mode with window", SidecarOptionalConfig{Enabled: true, Window: DefaultSidecarOptionalWindow}, false},
+		{"should disallow sidecar optional mode without window", SidecarOptionalConfig{Enabled: true}, true},
+	}
+	for _, entry := range table {
+		g := NewWithT(t)
+		t.Log(entry.description)
+		err := entry.config.Validate()
+		g.Expect(err != nil).To(Equal(entry.expectedError))
+	}
+}
+
+func TestValidateVolumeResize(t *testing.T) {
+	table := []struct {
+		description   string
+		config        VolumeResizeConfig
+		expectedError bool
+	}{
+		{"should allow disabled volume resize handling", VolumeResizeConfig{}, false},
+		{"should allow restart on resize with percentage and check interval", VolumeResizeConfig{QuotaBackendPercentage: 80, CheckInterval: time.Minute, RestartOnResize: true}, false},
+		{"should disallow percentage above 100", VolumeResizeConfig{QuotaBackendPercentage: 120}, true},
+		{"should disallow negative check interval", VolumeResizeConfig{CheckInterval: -time.Minute}, true},
+		{"should disallow restart on resize without percentage", VolumeResizeConfig{CheckInterval: time.Minute, RestartOnResize: true}, true},
+		{"should disallow restart on resize without check interval", VolumeResizeConfig{QuotaBackendPercentage: 80, RestartOnResize: true}, true},
+	}
+	for _, entry := range table {
+		g := NewWithT(t)
+		t.Log(entry.description)
+		err := entry.config.Validate()
+		g.Expect(err != nil).To(Equal(entry.expectedError))
+	}
+}
+
+func TestValidateDNS(t *testing.T) {
+	table := []struct {
+		description   string
+		config        DNSConfig
+		expectedError bool
+	}{
+		{"should allow the DNS servers of the system", DNSConfig{}, false},
+		{"should allow DNS servers with timeouts", DNSConfig{Servers: []string{"10.96.0.10:53", "[fd00::a]:53"}, LookupTimeout: 2 * time.Second, DialTimeout: 3 * time.Second}, false},
+		{"should disallow DNS servers without port", DNSConfig{Servers: []string{"10.96.0.10"}}, true},
+		{"should disallow negative lookup timeout", DNSConfig{LookupTimeout: -time.Second}, true},
+		{"should disallow negative dial timeout", DNSConfig{DialTimeout: -time.Second}, true},
+	}
+	for _, entry := range table {
+		g := NewWithT(t)
+		t.Log(entry.description)
+		err := entry.config.Validate()
+		g.Expect(err != nil).To(Equal(entry.expectedError))
+	}
+}
+
+func TestValidateLastKnownGoodConfig(t *testing.T) {
+	table := []struct {
+		description   string
+		config        LastKnownGoodConfig
+		expectedError bool
+	}{
+		{"should allow disabled last known good config", LastKnownGoodConfig{}, false},
+		{"should allow using the last known good config with path", LastKnownGoodConfig{Path: DefaultLastKnownGoodConfigFilePath, UseOnFetchFailure: true}, false},
+		{"should disallow using the last known good config without path", LastKnownGoodConfig{UseOnFetchFailure: true}, true},
+	}
+	for _, entry := range table {
+		g := NewWithT(t)
+		t.Log(entry.description)
+		err := entry.config.Validate()
+		g.Expect(err != nil).To(Equal(entry.expectedError))
+	}
+}
+
+func TestValidateBackupFreshness(t *testing.T) {
+	table := []struct {
+		description   string
+		config        BackupFreshnessConfig
+		expectedError bool
+	}{
+		{"should allow disabled safety endpoint", BackupFreshnessConfig{}, false},
+		{"should allow max backup age with poll interval", BackupFreshnessConfig{MaxAge: time.Hour, PollInterval: DefaultBackupFreshnessPollInterval}, false},
+		{"should disallow negative max backup age", BackupFreshnessConfig{MaxAge: -time.Hour, PollInterval: DefaultBackupFreshnessPollInterval}, true},
+		{"should disallow max backup age without poll interval", BackupFreshnessConfig{MaxAge: time.Hour}, true},
+	}
+	for _, entry := range table {
+		g := NewWithT(t)
+		t.Log(entry.description)
+		err := entry.config.Validate()
+		g.Expect(err != nil).To(Equal(entry.expectedError))
+	}
+}
+
+func TestValidateHooks(t *testing.T) {
+	table := []struct {
+		description   string
+		config        HooksConfig
+		expectedError bool
+	}{
+		{"should allow disabled hooks", HooksConfig{}, false},
+		{"should allow hooks with timeout", HooksConfig{PreStart: "/hooks/fetch-keys", PostReady: "/hooks/notify", Timeout: DefaultHookTimeout}, false},
+		{"should disallow hooks without timeout", HooksConfig{PostReady: "/hooks/notify"}, true},
+		{"should disallow blank hooks", HooksConfig{PreStart: "  ", Timeout: DefaultHookTimeout}, true},
+	}
+	for _, entry := range table {
+		g := NewWithT(t)
+		t.Log(entry.description)
+		err := entry.config.Validate()
+		g.Expect(err != nil).To(Equal(entry.expectedError))
+	}
+}
+
+func TestValidateEtcdProcess(t *testing.T) {
+	table := []struct {
+		description   string
+		config        EtcdProcessConfig
+		expectedError bool
+	}{
+		{"should allow the default mode", EtcdProcessConfig{}, false},
+		{"should allow embedded mode", EtcdProcessConfig{Mode: EtcdModeEmbedded}, false},
+		{"should allow process mode with binary path and grace period", EtcdProcessConfig{Mode: EtcdModeProcess, BinaryPath: DefaultEtcdBinaryPath, StopGracePeriod: DefaultEtcdStopGracePeriod}, false},
+		{"should disallow process mode without binary path", EtcdProcessConfig{Mode: EtcdModeProcess, StopGracePeriod: DefaultEtcdStopGracePeriod}, true},
+		{"should disallow process mode without grace period", EtcdProcessConfig{Mode: EtcdModeProcess, BinaryPath: DefaultEtcdBinaryPath}, true},
+		{"should disallow unknown modes", EtcdProcessConfig{Mode: "container"}, true},
+	}
+	for _, entry := range table {
+		g := NewWithT(t)
+		t.Log(entry.description)
+		err := entry.config.Validate()
+		g.Expect(err != nil).To(Equal(entry.expectedError))
+	}
+}
+
+func TestValidateClientUnixSocket(t *testing.T) {
+	table := []struct {
+		description   string
+		config        ClientUnixSocketConfig
+		expectedError bool
+	}{
+		{"should allow disabled client unix socket", ClientUnixSocketConfig{}, false},
+		{"should allow client unix socket with default mode", ClientUnixSocketConfig{Path: "/var/run/etcd/etcd.sock"}, false},
+		{"should allow client unix socket with mode", ClientUnixSocketConfig{Path: "/var/run/etcd/etcd.sock", Mode: "0600"}, false},
+		{"should disallow relative path", ClientUnixSocketConfig{Path: "etcd.sock"}, true},
+		{"should disallow non-octal mode", ClientUnixSocketConfig{Path: "/var/run/etcd/etcd.sock", Mode: "rw-rw----"}, true},
+		{"should disallow mode beyond permission bits", ClientUnixSocketConfig{Path: "/var/run/etcd/etcd.sock", Mode: "4777"}, true},
+	}
+	for _, entry := range table {
+		g := NewWithT(t)
+		t.Log(entry.description)
+		err := entry.config.Validate()
+		g.Expect(err != nil).To(Equal(entry.expectedError))
+	}
+}
+
+func TestValidateExternalClientListener(t *testing.T) {
+	table := []struct {
+		description   string
+		config        ExternalClientListenerConfig
+		expectedError bool
+	}{
+		{"should allow disabled external client listener", ExternalClientListenerConfig{}, false},
+		{"should allow external client listener with server certificate", ExternalClientListenerConfig{URL: "https://0.0.0.0:2381", CertPath: "tls.crt", KeyPath: "tls.key"}, false},
+		{"should allow external client listener requiring client certificates", ExternalClientListenerConfig{URL: "https://0.0.0.0:2381", CertPath: "tls.crt", KeyPath: "tls.key", TrustedCAPath: "ca.crt"}, false},
+		{"should disallow http URL", ExternalClientListenerConfig{URL: "http://0.0.0.0:2381", CertPath: "tls.crt", KeyPath: "tls.key"}, true},
+		{"should disallow URL without port", ExternalClientListenerConfig{URL: "https://0.0.0.0", CertPath: "tls.crt", KeyPath: "tls.key"}, true},
+		{"should disallow missing server certificate", ExternalClientListenerConfig{URL: "https://0.0.0.0:2381", KeyPath: "tls.key"}, true},
+	}
+	for _, entry := range table {
+		g := NewWithT(t)
+		t.Log(entry.description)
+		err := entry.config.Validate()
+		g.Expect(err != nil).To(Equal(entry.expectedError))
+	}
+}
+
+func TestReadinessPolicy(t *testing.T) {
+	table := []struct {
+		description    string
+		config         Config
+		expectedPolicy string
+		expectedError  bool
+	}{
+		{"should default to cluster-has-quorum", Config{}, ReadinessPolicyClusterHasQuorum, false},
+		{"should default to learner-serving-stale in hot-standby mode", Config{HotStandby: true}, ReadinessPolicyLearnerServingStale, false},
+		{"should allow member-has-leader", Config{ReadinessPolicy: ReadinessPolicyMemberHasLeader}, ReadinessPolicyMemberHasLeader, false},
+		{"should disallow unknown policy", Config{ReadinessPolicy: "always"}, "always", true},
+		{"should disallow policies requiring a voting member in hot-standby mode", Config{HotStandby: true, ReadinessPolicy: ReadinessPolicyMemberServing}, ReadinessPolicyMemberServing, true},
+	}
+	for _, entry := range table {
+		g := NewWithT(t)
+		t.Log(entry.description)
+		g.Expect(entry.config.GetReadinessPolicy()).To(Equal(entry.expectedPolicy))
+		err := entry.config.ValidateReadinessPolicy()
+		g.Expect(err != nil).To(Equal(entry.expectedError))
+	}
+}
+
+func TestValidateReadinessGates(t *testing.T) {
+	table := []struct {
+		description   string
+		config        ReadinessGatesConfig
+		expectedError bool
+	}{
+		{"should allow no readiness gates", ReadinessGatesConfig{}, false},
+		{"should allow exec, file and http readiness gates", ReadinessGatesConfig{Gates: []string{"exec:/bin/check --volume data", "file:/var/run/resized", "http:http://localhost:9000/healthz"}, Interval: time.Second, Timeout: time.Second}, false},
+		{"should return error for a readiness gate without target", ReadinessGatesConfig{Gates: []string{"file:"}, Interval: time.Second, Timeout: time.Second}, true},
+		{"should return error for an unsupported kind", ReadinessGatesConfig{Gates: []string{"tcp:localhost:9000"}, Interval: time.Second, Timeout: time.Second}, true},
+		{"should return error for a relative URL", ReadinessGatesConfig{Gates: []string{"http:/healthz"}, Interval: time.Second, Timeout: time.Second}, true},
+		{"should return error for a non-positive interval", ReadinessGatesConfig{Gates: []string{"file:/var/run/resized"}, Timeout: time.Second}, true},
+		{"should return error for a non-positive timeout", ReadinessGatesConfig{Gates: []string{"file:/var/run/resized"}, Interval: time.Second}, true},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		err := entry.config.Validate()
 		g.Expect(err != nil).To(Equal(entry.expectedError))
 	}
 }
@@ -4,17 +4,225 @@
 
 package types
 
-import "go.uber.org/zap/zapcore"
+import (
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
 
 const (
 	// DefaultBackupRestoreTLSEnabled defines the default TLS state of the application
 	DefaultBackupRestoreTLSEnabled = false
+	// BackupRestoreProtocolHTTP is the protocol used to communicate with the HTTP(S) server of backup-restore
+	BackupRestoreProtocolHTTP = "http"
+	// BackupRestoreProtocolGRPC is the protocol used to communicate with the gRPC server of backup-restore
+	BackupRestoreProtocolGRPC = "grpc"
+	// DefaultBackupRestoreProtocol defines the default protocol used to communicate with backup-restore
+	DefaultBackupRestoreProtocol = BackupRestoreProtocolHTTP
 	// DefaultBackupRestoreHostPort defines the default sidecar host and port
 	DefaultBackupRestoreHostPort = ":8080"
+	// DefaultEtcdWrapperPort defines the default port of the HTTP server of etcd-wrapper
+	DefaultEtcdWrapperPort = 9095
+	// DefaultEtcdClientPort defines the default port at which etcd serves client requests
+	DefaultEtcdClientPort = 2379
 	// DefaultExitCodeFilePath defines the default file path for the file that stores the exit code of the previous run
 	DefaultExitCodeFilePath = "/var/etcd/data/exit_code"
+	// DefaultBootstrapHistoryFilePath defines the default file path for the file that stores the history of the most recent start attempts
+	DefaultBootstrapHistoryFilePath = "/var/etcd/data/bootstrap_history.json"
+	// DefaultMemberIdentityFilePath defines the default file path for the file that stores the IDs of the etcd cluster and member
+	DefaultMemberIdentityFilePath = "/var/etcd/data/member_identity.env"
+	// DefaultClusterIDPinFilePath defines the default file path for the file that stores the pinned cluster ID and peer set
+	DefaultClusterIDPinFilePath = "/var/etcd/data/cluster_id_pin.json"
+	// DefaultInitialClusterTokenFilePath defines the default file path for the file that stores the generated initial cluster token
+	DefaultInitialClusterTokenFilePath = "/var/etcd/data/initial_cluster_token"
+	// DefaultVolumeSizeRecordFilePath defines the default file path for the file that stores the size of the data volume at the last start
+	DefaultVolumeSizeRecordFilePath = "/var/etcd/data/volume_size.json"
+	// DefaultLastKnownGoodConfigFilePath defines the default file path for the file that stores the etcd configuration with which etcd last became ready
+	DefaultLastKnownGoodConfigFilePath = "/var/etcd/data/last_known_good_etcd_config.yaml"
+	// DefaultMaintenanceHistoryFilePath defines the default file path for the file that stores the history of compactions and defragmentations
+	DefaultMaintenanceHistoryFilePath = "/var/etcd/data/maintenance_history.json"
+	// DefaultRevisionWatermarkFilePath defines the default file path for the file that stores the highest etcd revision observed by etcd-wrapper
+	DefaultRevisionWatermarkFilePath = "/var/etcd/data/revision_watermark.json"
+	// DefaultRevisionWatermarkInterval defines the default interval in which the revision watermark is updated while etcd is running
+	DefaultRevisionWatermarkInterval = time.Minute
+	// DefaultMaintenanceHistorySize defines the default number of most recent compactions and defragmentations retained in the maintenance history
+	DefaultMaintenanceHistorySize = 100
+	// DefaultBootstrapHistorySize defines the number of most recent start attempts retained in the bootstrap history
+	DefaultBootstrapHistorySize = 10
+	// DefaultCrashLoopThreshold defines the default number of failed start attempts within the crash loop window from which on a crash loop is detected
+	DefaultCrashLoopThreshold = 3
+	// DefaultCrashLoopWindow defines the default window within which failed start attempts are counted for crash loop detection
+	DefaultCrashLoopWindow = 10 * time.Minute
 	// ValidationMarkerFilePath defines the file path to the legacy file that was used to record exit code of the previous run
 	ValidationMarkerFilePath = "/var/etcd/data/validation_marker"
+	// DefaultAuditLogMaxSizeBytes defines the default size in bytes after which the audit log is rotated
+	DefaultAuditLogMaxSizeBytes = 10 * 1024 * 1024
+	// DefaultAuditLogMaxBackups defines the default number of rotated audit log files that are retained
+	DefaultAuditLogMaxBackups = 3
+	// DefaultInitialCorruptCheck defines whether the initial corruption check of etcd is enabled by default
+	DefaultInitialCorruptCheck = true
+	// DefaultCorruptCheckTime defines the default interval of the periodic corruption check of etcd
+	DefaultCorruptCheckTime = time.Hour
+	// DefaultRestoreMarkerKey defines the default key into which metadata about a restoration is written
+	DefaultRestoreMarkerKey = "/_wrapper/restored-at"
+	// DefaultProposalBackpressurePendingThreshold defines the default number of pending raft proposals from which on backpressure is observed
+	DefaultProposalBackpressurePendingThreshold = 100
+	// DefaultProposalBackpressureSustainedDuration defines the default duration for which backpressure must be observed before it is reported
+	DefaultProposalBackpressureSustainedDuration = 30 * time.Second
+	// DefaultSidecarOptionalWindow defines the default time within which backup-restore must respond at least once to be waited for in sidecar optional mode
+	DefaultSidecarOptionalWindow = 2 * time.Minute
+	// DefaultApplyLagThreshold defines the default number of committed but not yet applied raft entries from which on a lag is observed
+	DefaultApplyLagThreshold = 1000
+	// DefaultApplyLagSustainedDuration defines the default duration for which the apply lag must be observed before it is reported
+	DefaultApplyLagSustainedDuration = 30 * time.Second
+	// DefaultClockSkewThreshold defines the default clock skew to other members above which a warning is logged
+	DefaultClockSkewThreshold = time.Second
+	// DefaultMemoryLimitRatio defines the default fraction of the container memory limit which is set as Go memory limit
+	DefaultMemoryLimitRatio = 0.9
+	// DefaultCompactionRetainedRevisions defines the default number of most recent revisions retained by a proactive compaction
+	DefaultCompactionRetainedRevisions = 1000
+	// DefaultCompactionCheckInterval defines the default interval in which the need for a proactive compaction is checked
+	DefaultCompactionCheckInterval = time.Minute
+	// DefaultDBSizeTrendHorizon defines the default projected time until the backend quota is exhausted below which a warning is raised
+	DefaultDBSizeTrendHorizon = 72 * time.Hour
+	// DefaultDBSizeTrendWindow defines the default time window over which the growth rate of the DB size is computed
+	DefaultDBSizeTrendWindow = 6 * time.Hour
+	// DefaultDBSizeTrendSampleInterval defines the default interval in which the DB size is sampled
+	DefaultDBSizeTrendSampleInterval = time.Minute
+	// DefaultQuotaRiskThreshold defines the default ratio of DB size to backend quota at or above which writes are considered at risk
+	DefaultQuotaRiskThreshold = 0.8
+	// DefaultHealthScoreInterval defines the default interval in which the health of etcd is probed to compute the health score
+	DefaultHealthScoreInterval = 10 * time.Second
+	// DefaultHealthScoreSmoothingFactor defines the default weight of the latest probe in the exponentially smoothed health score
+	DefaultHealthScoreSmoothingFactor = 0.2
+	// DefaultHealthScoreLatencyThreshold defines the default probe latency up to which the latency is considered healthy
+	DefaultHealthScoreLatencyThreshold = 100 * time.Millisecond
+	// DefaultPrefixUsageInterval defines the default interval in which the usage of the configured key prefixes is sampled
+	DefaultPrefixUsageInterval = 5 * time.Minute
+	// DefaultWarmUpTimeout defines the default time after which the warm-up of etcd is stopped and readiness is reported regardless
+	DefaultWarmUpTimeout = 30 * time.Second
+	// DefaultRequestSamplingBufferSize defines the default number of most recent request samples which are retained
+	DefaultRequestSamplingBufferSize = 1000
+	// DefaultRequestSamplingPrefixDepth defines the default number of key segments which make up the key prefix of a sampled request
+	DefaultRequestSamplingPrefixDepth = 2
+	// DefaultCertExpiryCheckInterval defines the default interval in which the expiry of the certificates used by etcd and etcd-wrapper is checked
+	DefaultCertExpiryCheckInterval = time.Hour
+	// DefaultClientTrafficTopClients defines the default number of clients with the highest RPC rate which are reported
+	DefaultClientTrafficTopClients = 10
+	// DefaultPreflightFsyncProbes defines the default number of writes which are synced to probe the fsync latency of a volume
+	DefaultPreflightFsyncProbes = 5
+	// DefaultDiskLatencyWindow defines the default number of most recent disk latency probes from which the 99th percentile is computed
+	DefaultDiskLatencyWindow = 100
+	// DefaultDiskLatencyWarningThreshold defines the default 99th percentile of the fsync latency beyond which a warning is logged, which is the
+	// 99th percentile of the WAL fsync duration recommended by etcd
+	DefaultDiskLatencyWarningThreshold = 10 * time.Millisecond
+	// KubeAPIServerMinRequestBytes defines the smallest maximum size of a client request to etcd with which kube-apiserver can store
+	// objects of the maximum size it accepts, which is the default of etcd
+	KubeAPIServerMinRequestBytes = 1536 * 1024
+	// DefaultClientUnixSocketMode defines the default file mode of the unix socket client listener, which grants access to the
+	// containers of the pod sharing its fsGroup
+	DefaultClientUnixSocketMode = 0660
+	// DefaultHeartbeatInterval defines the default interval in which the heartbeat file is rewritten
+	DefaultHeartbeatInterval = 10 * time.Second
+	// DefaultBackupFreshnessPollInterval defines the default interval in which the latest snapshots are fetched from
+	// backup-restore for the safety endpoint
+	DefaultBackupFreshnessPollInterval = 30 * time.Second
+	// DefaultCrashReportLogLines defines the default number of most recent log lines included in a crash bundle
+	DefaultCrashReportLogLines = 1000
+	// DefaultAuthSyncInterval defines the default interval in which the auth spec file is checked for changes
+	DefaultAuthSyncInterval = 30 * time.Second
+	// SnapshotKindFull is the kind of a full snapshot taken by backup-restore
+	SnapshotKindFull = "full"
+	// SnapshotKindDelta is the kind of a delta snapshot taken by backup-restore
+	SnapshotKindDelta = "delta"
+	// DefaultSnapshotOnShutdownTimeout defines the default time to wait for backup-restore to take the snapshot requested on shutdown
+	DefaultSnapshotOnShutdownTimeout = 20 * time.Second
+	// ReadinessPolicyMemberServing reports readiness once the member is a voting member which serves serializable reads
+	ReadinessPolicyMemberServing = "member-serving"
+	// ReadinessPolicyMemberHasLeader reports readiness once the member is a voting member which serves serializable reads and knows the leader
+	ReadinessPolicyMemberHasLeader = "member-has-leader"
+	// ReadinessPolicyClusterHasQuorum reports readiness once the member serves linearizable reads, which requires a quorum
+	ReadinessPolicyClusterHasQuorum = "cluster-has-quorum"
+	// ReadinessPolicyLearnerServingStale reports readiness once the member, which may be a learner, serves serializable reads
+	ReadinessPolicyLearnerServingStale = "learner-serving-stale"
+	// ReadinessGateKindExec is the kind of a readiness gate which passes if its command exits with status 0
+	ReadinessGateKindExec = "exec"
+	// ReadinessGateKindFile is the kind of a readiness gate which passes if its file exists
+	ReadinessGateKindFile = "file"
+	// ReadinessGateKindHTTP is the kind of a readiness gate which passes if a GET request to its URL returns a 2xx status code
+	ReadinessGateKindHTTP = "http"
+	// DefaultReadinessGateInterval defines the default interval in which the readiness gates are evaluated
+	DefaultReadinessGateInterval = 10 * time.Second
+	// DefaultReadinessGateTimeout defines the default time after which the evaluation of a readiness gate fails
+	DefaultReadinessGateTimeout = 5 * time.Second
+	// DefaultHookTimeout defines the default time after which a pre-start or post-ready hook is killed
+	DefaultHookTimeout = time.Minute
+	// EtcdModeEmbedded runs etcd embedded into etcd-wrapper
+	EtcdModeEmbedded = "embedded"
+	// EtcdModeProcess runs etcd as child process of etcd-wrapper from an external etcd binary
+	EtcdModeProcess = "process"
+	// DefaultEtcdBinaryPath defines the default path of the etcd binary run in process mode
+	DefaultEtcdBinaryPath = "/usr/local/bin/etcd"
+	// DefaultEtcdStopGracePeriod defines the default time an etcd process is given to exit after SIGTERM before it is killed
+	DefaultEtcdStopGracePeriod = 30 * time.Second
+	// ExitCodeSidecarProbeTimeout is the exit code when backup-restore did not respond within the sidecar probe timeout
+	ExitCodeSidecarProbeTimeout = 10
+	// ExitCodeValidationTimeout is the exit code when the data directory validation did not complete within the validation timeout
+	ExitCodeValidationTimeout = 11
+	// ExitCodeRestorationWaitTimeout is the exit code when the initialization did not complete within the restoration wait timeout
+	ExitCodeRestorationWaitTimeout = 12
+	// ExitCodeEtcdReadyTimeout is the exit code when the embedded etcd did not become ready within the etcd ready timeout
+	ExitCodeEtcdReadyTimeout = 13
+	// ExitCodeRestartBudgetExhausted is the exit code when the embedded etcd would have to be restarted more often than allowed by the restart budget
+	ExitCodeRestartBudgetExhausted = 14
+	// ExitCodeEtcdVersionSkew is the exit code when the version of the embedded etcd must not run on the data directory last used by another version
+	ExitCodeEtcdVersionSkew = 15
+	// ExitCodeClusterIDMismatch is the exit code when the peers report another cluster ID than the one pinned for them
+	ExitCodeClusterIDMismatch = 16
+	// ExitCodeQuorumAtRisk is the exit code of the cluster-health command when quorum of the etcd cluster has been lost or is at risk
+	ExitCodeQuorumAtRisk = 17
+	// ExitCodeSidecarPermanentError is the exit code when backup-restore has rejected a request during bootstrap with an error which does not go away on retries
+	ExitCodeSidecarPermanentError = 18
+	// ExitCodePreStartHookFailed is the exit code when the pre-start hook has failed, so that etcd has not been started
+	ExitCodePreStartHookFailed = 19
+	// ExitCodeWaitUntilReadyTimeout is the exit code of the wait-until-ready command when etcd has not become ready within the timeout
+	ExitCodeWaitUntilReadyTimeout = 20
+	// ExitCodeSidecarAPIVersionIncompatible is the exit code when backup-restore supports none of the API versions supported by etcd-wrapper
+	ExitCodeSidecarAPIVersionIncompatible = 21
+	// EtcdVersionFileName is the name of the file in the data directory into which the version of etcd is recorded once it has started
+	EtcdVersionFileName = "etcd-wrapper-etcd-version"
+	// HibernationMarkerFileName is the name of the file in the data directory which marks it as safe to delete once the member has hibernated
+	HibernationMarkerFileName = "etcd-wrapper-safe-to-delete.json"
+	// DefaultHibernationTimeout defines the default time to wait for backup-restore to take and upload the final full snapshot on hibernation
+	DefaultHibernationTimeout = 5 * time.Minute
+	// DefaultLogMaxSizeBytes defines the default size in bytes after which a log file of etcd-wrapper or etcd is rotated
+	DefaultLogMaxSizeBytes = 100 * 1024 * 1024
+	// DefaultLogMaxBackups defines the default number of rotated log files of etcd-wrapper or etcd that are retained
+	DefaultLogMaxBackups = 5
+	// DefaultRestartBudgetMaxRestarts defines the default maximum number of restarts of the embedded etcd within the restart budget window
+	DefaultRestartBudgetMaxRestarts = 5
+	// DefaultRestartBudgetWindow defines the default window within which the number of restarts of the embedded etcd is limited
+	DefaultRestartBudgetWindow = 10 * time.Minute
+	// DefaultBackupRestoreCAReloadInterval defines the default interval in which the CA bundle of backup-restore is checked for changes
+	DefaultBackupRestoreCAReloadInterval = time.Minute
+	// DefaultBackupRestoreHostPortRefreshInterval defines the default interval in which the file publishing the host and port of backup-restore is read again
+	DefaultBackupRestoreHostPortRefreshInterval = 30 * time.Second
+	// DefaultCertRotationLockKey defines the default key prefix of the lock which serializes restarts of members after a rotation of the peer CA
+	DefaultCertRotationLockKey = "/_wrapper/cert-rotation-lock"
+	// DefaultCertRotationLockTTL defines the default TTL of the lease which binds the lock serializing restarts of members
+	DefaultCertRotationLockTTL = 5 * time.Minute
+	// DefaultDefragmentationKeyPrefix defines the default key prefix in etcd under which the defragmentation of members is coordinated
+	DefaultDefragmentationKeyPrefix = "/_wrapper/defragmentation"
+	// DefaultDefragmentationLockTTL defines the default TTL of the lease which binds the lock serializing the defragmentation of members
+	DefaultDefragmentationLockTTL = 5 * time.Minute
+	// DefaultDefragmentationTimeout defines the default time for which the leader waits for the followers to defragment in a defragmentation round
+	DefaultDefragmentationTimeout = time.Hour
+	// DefaultMaintenanceLeaderLeaseDuration defines the default time after its last renewal after which the maintenance leader lease is taken over
+	DefaultMaintenanceLeaderLeaseDuration = 15 * time.Second
+	// DefaultMaintenanceLeaderRenewInterval defines the default interval in which the maintenance leader renews its lease
+	DefaultMaintenanceLeaderRenewInterval = 5 * time.Second
+	// DefaultMaintenanceWindowDuration defines the default duration for which the maintenance window stays open
+	DefaultMaintenanceWindowDuration = time.Hour
 	// DefaultLogLevel defines the default log level for any zap loggers created
 	DefaultLogLevel = zapcore.InfoLevel
 )
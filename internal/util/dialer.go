@@ -0,0 +1,118 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package util
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+)
+
+// Dialer dials the outbound connections of etcd-wrapper to peers and backup-restore. Host names are resolved within a
+// lookup timeout, optionally against dedicated DNS servers instead of the ones configured in /etc/resolv.conf, so that
+// a stalling cluster DNS does not stall the dial for the timeouts of the system resolver.
+type Dialer struct {
+	dialer        net.Dialer
+	resolvers     []*net.Resolver
+	lookupTimeout time.Duration
+	wrapConn      func(net.Conn) net.Conn
+}
+
+// NewDialer creates a Dialer which resolves host names against the given DNS servers, in the form <host>:<port>, or
+// against the DNS servers of the system if none are given. The DNS servers are queried in turn, each within an equal
+// share of lookupTimeout, till one of them answers. A host name lookup fails after lookupTimeout and establishing a
+// connection to a resolved address after dialTimeout. Zero timeouts leave the timeouts of the system resolver and of
+// the operating system in place.
+func NewDialer(dnsServers []string, lookupTimeout, dialTimeout time.Duration) *Dialer {
+	d := &Dialer{
+		dialer:        net.Dialer{Timeout: dialTimeout},
+		resolvers:     []*net.Resolver{net.DefaultResolver},
+		lookupTimeout: lookupTimeout,
+	}
+	if len(dnsServers) > 0 {
+		d.resolvers = make([]*net.Resolver, 0, len(dnsServers))
+		for _, server := range dnsServers {
+			d.resolvers = append(d.resolvers, newResolver(server))
+		}
+	}
+	return d
+}
+
+// SetConnWrapper sets a function which wraps every connection established by the Dialer, e.g. to inject faults.
+func (d *Dialer) SetConnWrapper(wrapConn func(net.Conn) net.Conn) {
+	d.wrapConn = wrapConn
+}
+
+// newResolver creates a resolver which queries only the given DNS server, skipping the servers configured in
+// /etc/resolv.conf and with them any caching resolver, e.g. a node-local DNS cache, holding stale negative answers.
+func newResolver(dnsServer string) *net.Resolver {
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, network, dnsServer)
+		},
+	}
+}
+
+// DialContext connects to address on the named network. It has the signature of the DialContext of an http.Transport.
+func (d *Dialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	conn, err := d.dial(ctx, network, address)
+	if err != nil || d.wrapConn == nil {
+		return conn, err
+	}
+	return d.wrapConn(conn), nil
+}
+
+func (d *Dialer) dial(ctx context.Context, network, address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	if net.ParseIP(host) != nil {
+		return d.dialer.DialContext(ctx, network, address)
+	}
+	addresses, err := d.lookupHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	var errs error
+	for _, resolved := range addresses {
+		conn, err := d.dialer.DialContext(ctx, network, net.JoinHostPort(resolved, port))
+		if err == nil {
+			return conn, nil
+		}
+		errs = errors.Join(errs, err)
+	}
+	return nil, errs
+}
+
+// lookupHost resolves host against the resolvers in turn. It fails over to the next resolver unless the host is known
+// not to exist, since the answer of the next DNS server would be the same.
+func (d *Dialer) lookupHost(ctx context.Context, host string) ([]string, error) {
+	var errs error
+	for _, resolver := range d.resolvers {
+		addresses, err := d.lookupHostWith(ctx, resolver, host)
+		if err == nil {
+			return addresses, nil
+		}
+		errs = errors.Join(errs, err)
+		var dnsErr *net.DNSError
+		if ctx.Err() != nil || (errors.As(err, &dnsErr) && dnsErr.IsNotFound) {
+			break
+		}
+	}
+	return nil, errs
+}
+
+func (d *Dialer) lookupHostWith(ctx context.Context, resolver *net.Resolver, host string) ([]string, error) {
+	if d.lookupTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.lookupTimeout/time.Duration(len(d.resolvers)))
+		defer cancel()
+	}
+	return resolver.LookupHost(ctx, host)
+}
@@ -0,0 +1,138 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package util
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+// startFakeDNSServer serves DNS queries on UDP with the responses created by respond, or drops all queries if respond
+// is nil. It returns its address and the number of queries received.
+func startFakeDNSServer(t *testing.T, respond func(query []byte) []byte) (string, *atomic.Int32) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	NewWithT(t).Expect(err).ToNot(HaveOccurred())
+	t.Cleanup(func() { _ = conn.Close() })
+	queries := &atomic.Int32{}
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			queries.Add(1)
+			if respond != nil {
+				_, _ = conn.WriteTo(respond(buf[:n]), addr)
+			}
+		}
+	}()
+	return conn.LocalAddr().String(), queries
+}
+
+// dnsResponseHeader creates a response to a DNS query with the given flags which repeats the question of the query
+// without any records. Records following the question, e.g. the EDNS record of the query, are not repeated.
+func dnsResponseHeader(query []byte, flags uint16) []byte {
+	questionEnd := 12
+	for query[questionEnd] != 0 {
+		questionEnd += int(query[questionEnd]) + 1
+	}
+	questionEnd += 5
+	response := append([]byte{}, query[:questionEnd]...)
+	binary.BigEndian.PutUint16(response[2:], flags)
+	binary.BigEndian.PutUint16(response[4:], 1)
+	binary.BigEndian.PutUint16(response[6:], 0)
+	binary.BigEndian.PutUint16(response[8:], 0)
+	binary.BigEndian.PutUint16(response[10:], 0)
+	return response
+}
+
+// dnsResponse creates the response to a DNS query, answering an A query with 127.0.0.1 and any other query with no
+// records.
+func dnsResponse(query []byte) []byte {
+	// QR, RD and RA set, no error.
+	response := dnsResponseHeader(query, 0x8180)
+	if binary.BigEndian.Uint16(response[len(response)-4:]) == 1 {
+		binary.BigEndian.PutUint16(response[6:], 1)
+		// name pointing to the question, type A, class IN, TTL 60s, 4 bytes of data.
+		response = append(response, 0xc0, 0x0c, 0, 1, 0, 1, 0, 0, 0, 60, 0, 4, 127, 0, 0, 1)
+	}
+	return response
+}
+
+// dnsServerFailure creates a response to a DNS query which reports a failure of the server.
+func dnsServerFailure(query []byte) []byte {
+	// QR, RD and RA set, server failure.
+	return dnsResponseHeader(query, 0x8182)
+}
+
+func TestDialerDialContext(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	NewWithT(t).Expect(err).ToNot(HaveOccurred())
+	defer func() { _ = listener.Close() }()
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+	peerAddress := net.JoinHostPort("etcd-main-0.etcd-main-peer.test", port)
+
+	table := []struct {
+		description   string
+		responders    []func([]byte) []byte
+		address       string
+		expectError   bool
+		expectQueries bool
+	}{
+		{"should dial IP addresses without resolution", []func([]byte) []byte{nil}, listener.Addr().String(), false, false},
+		{"should resolve host names against the DNS servers", []func([]byte) []byte{dnsResponse}, peerAddress, false, true},
+		{"should fail over to the next DNS server", []func([]byte) []byte{dnsServerFailure, dnsResponse}, peerAddress, false, true},
+		{"should fail over to the next DNS server if a DNS server does not respond", []func([]byte) []byte{nil, dnsResponse}, peerAddress, false, true},
+		{"should fail once the lookup timeout has expired", []func([]byte) []byte{nil}, peerAddress, true, true},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		var servers []string
+		var queries []*atomic.Int32
+		for _, respond := range entry.responders {
+			server, received := startFakeDNSServer(t, respond)
+			servers = append(servers, server)
+			queries = append(queries, received)
+		}
+		lookupTimeout := 2 * time.Second
+		dialer := NewDialer(servers, lookupTimeout, time.Second)
+		start := time.Now()
+		conn, err := dialer.DialContext(context.Background(), "tcp", entry.address)
+		g.Expect(err != nil).To(Equal(entry.expectError))
+		g.Expect(time.Since(start)).To(BeNumerically("<", 2*lookupTimeout))
+		if conn != nil {
+			_ = conn.Close()
+		}
+		for _, received := range queries {
+			g.Expect(received.Load() > 0).To(Equal(entry.expectQueries))
+		}
+	}
+}
+
+type wrappedConn struct {
+	net.Conn
+}
+
+func TestDialerSetConnWrapper(t *testing.T) {
+	g := NewWithT(t)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer func() { _ = listener.Close() }()
+	dialer := NewDialer(nil, 0, time.Second)
+	dialer.SetConnWrapper(func(conn net.Conn) net.Conn { return &wrappedConn{Conn: conn} })
+	conn, err := dialer.DialContext(context.Background(), "tcp", listener.Addr().String())
+	g.Expect(err).ToNot(HaveOccurred())
+	defer func() { _ = conn.Close() }()
+	g.Expect(conn).To(BeAssignableToTypeOf(&wrappedConn{}))
+}
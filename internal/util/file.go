@@ -0,0 +1,51 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package util
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// WriteFileAtomic writes data to the file at path, creating it with perm if it does not exist, such that readers never
+// observe a partially written file and the file survives a crash once WriteFileAtomic has returned. The data is written
+// into a temporary file next to path which is synced and then renamed to path, and the rename is synced by syncing the
+// directory.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) (err error) {
+	dir := filepath.Dir(path)
+	tmpPath := filepath.Join(dir, "."+filepath.Base(path)+".tmp")
+	f, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm) // #nosec G304 -- path is chosen by the caller.
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(tmpPath)
+		}
+	}()
+	if _, err = f.Write(data); err != nil {
+		return errors.Join(err, f.Close())
+	}
+	if err = f.Sync(); err != nil {
+		return errors.Join(err, f.Close())
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(tmpPath, path); err != nil {
+		return err
+	}
+	return syncDir(dir)
+}
+
+// syncDir syncs the directory at dir, which persists the entries of the directory such as a renamed file.
+func syncDir(dir string) error {
+	d, err := os.Open(dir) // #nosec G304 -- dir is the directory of a file written by WriteFileAtomic.
+	if err != nil {
+		return err
+	}
+	return errors.Join(d.Sync(), d.Close())
+}
@@ -0,0 +1,40 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	g := NewWithT(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.json")
+
+	t.Log("should create the file with the given permissions")
+	g.Expect(WriteFileAtomic(path, []byte("first"), 0600)).To(Succeed())
+	data, err := os.ReadFile(path)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(string(data)).To(Equal("first"))
+	info, err := os.Stat(path)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+
+	t.Log("should replace the file and leave no temporary file behind")
+	g.Expect(WriteFileAtomic(path, []byte("second"), 0600)).To(Succeed())
+	data, err = os.ReadFile(path)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(string(data)).To(Equal("second"))
+	entries, err := os.ReadDir(dir)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(entries).To(HaveLen(1))
+
+	t.Log("should fail if the directory does not exist")
+	g.Expect(WriteFileAtomic(filepath.Join(dir, "missing", "state.json"), []byte("data"), 0600)).ToNot(Succeed())
+}
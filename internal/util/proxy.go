@@ -0,0 +1,45 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package util
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+
+	"google.golang.org/grpc"
+)
+
+// proxyEnvVars are the environment variables which configure proxies for outbound HTTP and gRPC connections.
+var proxyEnvVars = []string{"HTTP_PROXY", "http_proxy", "HTTPS_PROXY", "https_proxy", "NO_PROXY", "no_proxy"}
+
+// ProxyFunc returns the function selecting the proxy of an http.Transport. Unless proxyEnvDisabled is true, proxies
+// are selected according to the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables, otherwise no proxy is used.
+func ProxyFunc(proxyEnvDisabled bool) func(*http.Request) (*url.URL, error) {
+	if proxyEnvDisabled {
+		return nil
+	}
+	return http.ProxyFromEnvironment
+}
+
+// GRPCProxyDialOptions returns the dial options of gRPC clients which disable the proxies configured via the
+// HTTPS_PROXY and NO_PROXY environment variables if proxyEnvDisabled is true.
+func GRPCProxyDialOptions(proxyEnvDisabled bool) []grpc.DialOption {
+	if proxyEnvDisabled {
+		return []grpc.DialOption{grpc.WithNoProxy()}
+	}
+	return nil
+}
+
+// ProxyEnv returns all environment variables configuring proxies which are set, along with their values.
+func ProxyEnv() map[string]string {
+	env := make(map[string]string)
+	for _, name := range proxyEnvVars {
+		if value, ok := os.LookupEnv(name); ok {
+			env[name] = value
+		}
+	}
+	return env
+}
@@ -0,0 +1,48 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package util
+
+import (
+	"net/http"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestProxyFunc(t *testing.T) {
+	t.Setenv("HTTPS_PROXY", "http://proxy.example.com:3128")
+	t.Setenv("NO_PROXY", "localhost")
+	table := []struct {
+		description      string
+		proxyEnvDisabled bool
+		target           string
+		expectedProxy    string
+	}{
+		{"should use proxy from environment", false, "https://etcd-main-peer.shoot.svc:2380", "http://proxy.example.com:3128"},
+		{"should not use proxy for hosts excluded via NO_PROXY", false, "https://localhost:8080", ""},
+		{"should not use proxy when proxy environment is disabled", true, "https://etcd-main-peer.shoot.svc:2380", ""},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		proxyFunc := ProxyFunc(entry.proxyEnvDisabled)
+		if proxyFunc == nil {
+			g.Expect(entry.expectedProxy).To(BeEmpty())
+			g.Expect(GRPCProxyDialOptions(entry.proxyEnvDisabled)).To(HaveLen(1))
+			continue
+		}
+		request, err := http.NewRequest(http.MethodGet, entry.target, nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		proxyURL, err := proxyFunc(request)
+		g.Expect(err).ToNot(HaveOccurred())
+		if entry.expectedProxy == "" {
+			g.Expect(proxyURL).To(BeNil())
+		} else {
+			g.Expect(proxyURL.String()).To(Equal(entry.expectedProxy))
+		}
+		g.Expect(GRPCProxyDialOptions(entry.proxyEnvDisabled)).To(BeEmpty())
+	}
+}
@@ -0,0 +1,141 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package util
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	// Redacted replaces the values of secrets in logged configurations.
+	Redacted = "<redacted>"
+	// maxRedactDepth bounds the nesting of values converted by Redact, which guards against cyclic pointers.
+	maxRedactDepth = 8
+)
+
+// secretFieldName matches the names of struct fields holding secrets.
+var secretFieldName = regexp.MustCompile(`(?i)password|passphrase|secret|credential|privatekey`)
+
+// Redact converts v into a value which can be logged and serialized as JSON, with the values of secrets replaced by
+// Redacted. Struct fields are named after their json tag and are considered secret if their name matches a secret,
+// e.g. Password or KeyPassphrase. Passwords in URLs are redacted as well. Functions, channels and unexported
+// fields are left out, so that configurations holding callbacks, e.g. embed.Config of etcd, can be logged.
+func Redact(v any) any {
+	value, _ := redactValue(reflect.ValueOf(v), 0)
+	return value
+}
+
+// RedactURL returns rawURL with the password of its user info redacted. Values which cannot be parsed as URL are
+// returned unchanged.
+func RedactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	return u.Redacted()
+}
+
+// redactValue converts v as described for Redact. It returns false if v is left out.
+func redactValue(v reflect.Value, depth int) (any, bool) {
+	if !v.IsValid() || !v.CanInterface() || depth > maxRedactDepth {
+		return nil, false
+	}
+	switch v.Kind() {
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return nil, false
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil, true
+		}
+		return redactValue(v.Elem(), depth+1)
+	case reflect.Struct:
+		return redactStruct(v, depth)
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil, true
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return fmt.Sprintf("<%d bytes>", v.Len()), true
+		}
+		values := make([]any, 0, v.Len())
+		for i := range v.Len() {
+			if value, ok := redactValue(v.Index(i), depth+1); ok {
+				values = append(values, value)
+			}
+		}
+		return values, true
+	case reflect.Map:
+		if v.IsNil() {
+			return nil, true
+		}
+		values := make(map[string]any, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			if value, ok := redactValue(iter.Value(), depth+1); ok {
+				values[fmt.Sprint(iter.Key().Interface())] = value
+			}
+		}
+		return values, true
+	default:
+		// types with their own representation, e.g. durations, are more readable in it
+		if stringer, ok := v.Interface().(fmt.Stringer); ok && v.Kind() != reflect.String {
+			return stringer.String(), true
+		}
+		return v.Interface(), true
+	}
+}
+
+func redactStruct(v reflect.Value, depth int) (any, bool) {
+	switch value := v.Interface().(type) {
+	case url.URL:
+		return value.Redacted(), true
+	case time.Time:
+		return value, true
+	}
+	t := v.Type()
+	fields := make(map[string]any)
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := field.Name
+		if tag, _, _ := strings.Cut(field.Tag.Get("json"), ","); tag != "" && tag != "-" {
+			name = tag
+		}
+		if secretFieldName.MatchString(field.Name) {
+			if v.Field(i).IsZero() {
+				fields[name] = ""
+			} else {
+				fields[name] = Redacted
+			}
+			continue
+		}
+		if field.Tag.Get("json") == "-" {
+			continue
+		}
+		if value, ok := redactValue(v.Field(i), depth+1); ok {
+			if field.Anonymous {
+				if embedded, isMap := value.(map[string]any); isMap {
+					for k, embeddedValue := range embedded {
+						fields[k] = embeddedValue
+					}
+					continue
+				}
+			}
+			fields[name] = value
+		}
+	}
+	if len(fields) == 0 && t.NumField() > 0 {
+		// structs with unexported state only, e.g. loggers, carry no configuration.
+		return nil, false
+	}
+	return fields, true
+}
@@ -7,6 +7,8 @@ package util
 import (
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/pem"
+	"errors"
 	"os"
 )
 
@@ -31,6 +33,8 @@ type KeyPair struct {
 	CertPath string
 	// KeyPath is the path to the private key
 	KeyPath string
+	// KeyPassphrase is the passphrase of the private key if it is encrypted.
+	KeyPassphrase string
 }
 
 // CreateTLSConfig creates a TLS Config to be used for TLS communication.
@@ -48,7 +52,7 @@ func CreateTLSConfig(tlsEnabledFn IsTLSEnabledFn, serverName, caCertPath string,
 	tlsConf.RootCAs = caCertPool
 	tlsConf.ServerName = serverName
 	if keyPair != nil {
-		certificate, err := tls.LoadX509KeyPair(keyPair.CertPath, keyPair.KeyPath)
+		certificate, err := loadX509KeyPair(keyPair)
 		if err != nil {
 			return nil, err
 		}
@@ -56,3 +60,28 @@ func CreateTLSConfig(tlsEnabledFn IsTLSEnabledFn, serverName, caCertPath string,
 	}
 	return &tlsConf, nil
 }
+
+// loadX509KeyPair loads the certificate and private key of the keyPair, decrypting the private key if a passphrase is set.
+func loadX509KeyPair(keyPair *KeyPair) (tls.Certificate, error) {
+	if keyPair.KeyPassphrase == "" {
+		return tls.LoadX509KeyPair(keyPair.CertPath, keyPair.KeyPath)
+	}
+	certPEM, err := os.ReadFile(keyPair.CertPath)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	keyPEM, err := os.ReadFile(keyPair.KeyPath)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return tls.Certificate{}, errors.New("failed to decode PEM block of private key")
+	}
+	// Only the legacy PEM encryption (RFC 1423) is supported, which is what `openssl` produces for encrypted PKCS#1 keys.
+	keyDER, err := x509.DecryptPEMBlock(block, []byte(keyPair.KeyPassphrase)) //nolint:staticcheck
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.X509KeyPair(certPEM, pem.EncodeToMemory(&pem.Block{Type: block.Type, Bytes: keyDER}))
+}
@@ -19,6 +19,10 @@ var (
 	etcdCACertFilePath = filepath.Join(testdataPath, "ca.pem")
 	etcdClientCertPath = filepath.Join(testdataPath, "etcd-01-client.pem")
 	etcdClientKeyPath  = filepath.Join(testdataPath, "etcd-01-client-key.pem")
+
+	etcdClientEncryptedCertPath = filepath.Join(testdataPath, "etcd-01-client-encrypted.pem")
+	etcdClientEncryptedKeyPath  = filepath.Join(testdataPath, "etcd-01-client-encrypted-key.pem")
+	etcdClientKeyPassphrase     = "passphrase"
 )
 
 func TestCreateCACertPool(t *testing.T) {
@@ -64,6 +68,9 @@ func TestCreateTLSConfig(t *testing.T) {
 		{"should successfully create valid TLS config with only CA cert", "etcd-main-local", etcdCACertFilePath, nil, false},
 		{"should successfully create valid TLS config with CA cert and client cert-key pair", "etcd-main-local", etcdCACertFilePath, &KeyPair{CertPath: etcdClientCertPath, KeyPath: etcdClientKeyPath}, false},
 		{"should error out due to wrong cert path", "etcd-main-local", etcdCACertFilePath, &KeyPair{CertPath: etcdClientCertPath + "/wrong-path", KeyPath: etcdClientKeyPath}, true},
+		{"should successfully create valid TLS config with encrypted client key and its passphrase", "etcd-main-local", etcdCACertFilePath, &KeyPair{CertPath: etcdClientEncryptedCertPath, KeyPath: etcdClientEncryptedKeyPath, KeyPassphrase: etcdClientKeyPassphrase}, false},
+		{"should error out due to wrong passphrase of encrypted client key", "etcd-main-local", etcdCACertFilePath, &KeyPair{CertPath: etcdClientEncryptedCertPath, KeyPath: etcdClientEncryptedKeyPath, KeyPassphrase: "wrong"}, true},
+		{"should error out due to encrypted client key without passphrase", "etcd-main-local", etcdCACertFilePath, &KeyPair{CertPath: etcdClientEncryptedCertPath, KeyPath: etcdClientEncryptedKeyPath}, true},
 	}
 
 	defer func() {
@@ -108,4 +115,5 @@ func createTLSResources(g *WithT) {
 	clientCertKeyPair, err = tlsResCreator.CreateETCDClientCertAndKey()
 	g.Expect(err).To(BeNil())
 	g.Expect(clientCertKeyPair.EncodeAndWrite(testdataPath, "etcd-01-client.pem", "etcd-01-client-key.pem")).To(Succeed())
+	g.Expect(clientCertKeyPair.EncodeAndWriteEncrypted(testdataPath, "etcd-01-client-encrypted.pem", "etcd-01-client-encrypted-key.pem", etcdClientKeyPassphrase)).To(Succeed())
 }
@@ -5,6 +5,7 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"log"
@@ -34,8 +35,9 @@ func main() {
 	ctx, cancelFn := signal.SetupHandler(logger, bootstrap.CaptureExitCode, types.DefaultExitCodeFilePath)
 
 	// Add flags
+	command := cmd.GetCommand(args[0])
 	fs := flag.CommandLine
-	cmd.EtcdCmd.AddFlags(fs)
+	command.AddFlags(fs)
 	if err = fs.Parse(args[1:]); err != nil {
 		logger.Fatal("error parsing command flags", zap.Error(err))
 	}
@@ -43,9 +45,15 @@ func main() {
 	// Print all flags
 	printFlags(logger)
 
-	// InitAndStartEtcd command
-	if err = cmd.EtcdCmd.Run(ctx, cancelFn, logger); err != nil {
-		logger.Fatal("error during start or run of etcd", zap.Error(err))
+	// Run command
+	if err = command.Run(ctx, cancelFn, logger); err != nil {
+		var exitCodeErr interface{ ExitCode() int }
+		if errors.As(err, &exitCodeErr) {
+			logger.Error("error during start or run of etcd", zap.Error(err), zap.Int("exitCode", exitCodeErr.ExitCode()))
+			_ = logger.Sync()
+			os.Exit(exitCodeErr.ExitCode())
+		}
+		logger.Fatal("error during run of command", zap.String("command", command.Name), zap.Error(err))
 	}
 }
 
@@ -0,0 +1,56 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package test
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gardener/etcd-wrapper/internal/brclient"
+	"github.com/gardener/etcd-wrapper/internal/fakesidecar"
+)
+
+const (
+	// InitStatusNew is the initialization status reported by backup-restore before initialization has been triggered.
+	InitStatusNew = fakesidecar.InitStatusNew
+	// InitStatusInProgress is the initialization status reported by backup-restore while the data directory is validated or restored.
+	InitStatusInProgress = fakesidecar.InitStatusInProgress
+	// InitStatusSuccessful is the initialization status reported by backup-restore once the data directory has been initialized.
+	InitStatusSuccessful = fakesidecar.InitStatusSuccessful
+)
+
+// Snapshot is the metadata of a snapshot as served by MockBackupRestore.
+type Snapshot = brclient.Snapshot
+
+// LatestSnapshots is the latest full snapshot and the delta snapshots taken after it, as served by MockBackupRestore.
+type LatestSnapshots = brclient.LatestSnapshots
+
+// MockBackupRestore is a mock of the HTTP server of the backup-restore sidecar. By default, it behaves like a
+// backup-restore whose data directory is valid: it reports the initialization status New until initialization is
+// triggered and Successful afterwards, serves the configured etcd configuration and has no snapshots.
+// It is safe for concurrent use.
+type MockBackupRestore struct {
+	*fakesidecar.Server
+	server *httptest.Server
+}
+
+// NewMockBackupRestore starts a MockBackupRestore which is closed once the test has finished.
+func NewMockBackupRestore(t testing.TB) *MockBackupRestore {
+	m := &MockBackupRestore{Server: fakesidecar.NewServer(fakesidecar.Script{})}
+	m.server = httptest.NewServer(m.Server)
+	t.Cleanup(m.server.Close)
+	return m
+}
+
+// HostPort returns the host and port at which the MockBackupRestore is listening.
+func (m *MockBackupRestore) HostPort() string {
+	return strings.TrimPrefix(m.server.URL, "http://")
+}
+
+// FailRequests makes all subsequent requests to path fail with statusCode. A statusCode of zero stops failing them.
+func (m *MockBackupRestore) FailRequests(path string, statusCode int) {
+	m.SetEndpoint(path, fakesidecar.EndpointScript{StatusCode: statusCode})
+}
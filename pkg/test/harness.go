@@ -0,0 +1,215 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package test provides helpers for integration tests against the behaviour of etcd-wrapper, e.g. in projects which
+// deploy or embed etcd-wrapper.
+//
+// A Harness runs a wrapper.Wrapper with a single-member embedded etcd whose data directory lives in a temporary
+// directory of the test, and with a MockBackupRestore in place of the backup-restore sidecar. The behaviour of the
+// MockBackupRestore can be adjusted before the Harness is started to exercise the different bootstrap paths.
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/pkg/wrapper"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest"
+)
+
+const (
+	// DefaultMemberName is the name of the etcd member run by a Harness if no name is configured.
+	DefaultMemberName = "etcd-test"
+	// DefaultWaitReadyTimeout is the time to wait for the embedded etcd to be ready if no timeout is configured.
+	DefaultWaitReadyTimeout = time.Minute
+	// pollInterval is the interval in which the state of the Wrapper is checked while waiting for a state.
+	pollInterval = 50 * time.Millisecond
+)
+
+// Options are the options of a Harness.
+type Options struct {
+	// MemberName is the name of the etcd member. Defaults to DefaultMemberName if empty.
+	MemberName string
+	// WaitReadyTimeout is the time to wait for the embedded etcd to be ready. Defaults to DefaultWaitReadyTimeout if zero.
+	WaitReadyTimeout time.Duration
+	// Logger is the logger of the Wrapper. Defaults to a logger writing to the test log if nil.
+	Logger *zap.Logger
+	// ConfigureWrapper is called with the configuration of the Wrapper before it is created and may modify it.
+	ConfigureWrapper func(config *wrapper.Config)
+}
+
+// Harness runs a wrapper.Wrapper against a MockBackupRestore for the duration of a test.
+type Harness struct {
+	// Wrapper is the Wrapper under test. It is created by Start.
+	Wrapper *wrapper.Wrapper
+	// BackupRestore is the mock of the backup-restore sidecar used by the Wrapper.
+	BackupRestore *MockBackupRestore
+	// Config is the configuration with which the Wrapper is created.
+	Config wrapper.Config
+	// DataDir is the data directory of the embedded etcd.
+	DataDir string
+
+	options   Options
+	clientURL string
+	cancelFn  context.CancelFunc
+	startErr  chan error
+}
+
+// NewHarness creates a Harness which serves the configuration of a single-member etcd cluster, listening on free local
+// ports, via its MockBackupRestore. The Wrapper is stopped once the test has finished.
+//
+// NewHarness sets the HOME environment variable to a temporary directory for the duration of the test, since the
+// etcd configuration fetched from backup-restore is written into the home directory. Hence, it must not be used in
+// parallel tests.
+func NewHarness(t testing.TB, options Options) (*Harness, error) {
+	if options.MemberName == "" {
+		options.MemberName = DefaultMemberName
+	}
+	if options.WaitReadyTimeout == 0 {
+		options.WaitReadyTimeout = DefaultWaitReadyTimeout
+	}
+	if options.Logger == nil {
+		options.Logger = zaptest.NewLogger(t)
+	}
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+
+	ports, err := freePorts(3)
+	if err != nil {
+		return nil, err
+	}
+	clientPort, peerPort, wrapperPort := ports[0], ports[1], ports[2]
+	h := &Harness{
+		BackupRestore: NewMockBackupRestore(t),
+		DataDir:       filepath.Join(dir, options.MemberName+".etcd"),
+		options:       options,
+		clientURL:     fmt.Sprintf("http://127.0.0.1:%d", clientPort),
+	}
+	peerURL := fmt.Sprintf("http://127.0.0.1:%d", peerPort)
+	h.BackupRestore.SetEtcdConfig([]byte(fmt.Sprintf(`name: %[1]s
+data-dir: %[2]s
+listen-client-urls: %[3]s
+advertise-client-urls: %[3]s
+listen-peer-urls: %[4]s
+initial-advertise-peer-urls: %[4]s
+initial-cluster: %[1]s=%[4]s
+initial-cluster-state: new
+unsafe-no-fsync: true
+`, options.MemberName, h.DataDir, h.clientURL, peerURL)))
+	h.Config = wrapper.Config{
+		BackupRestore:        wrapper.BackupRestoreConfig{HostPort: h.BackupRestore.HostPort()},
+		EtcdClientTLS:        wrapper.EtcdClientTLSConfig{ServerName: "127.0.0.1"},
+		EtcdClientPort:       clientPort,
+		EtcdWrapperPort:      wrapperPort,
+		StateDir:             dir,
+		BootstrapHistoryPath: filepath.Join(dir, "bootstrap-history.json"),
+	}
+	t.Cleanup(func() {
+		if err := h.Stop(); err != nil {
+			t.Logf("wrapper stopped with error: %v", err)
+		}
+	})
+	return h, nil
+}
+
+// ClientURL returns the URL at which the embedded etcd serves client requests.
+func (h *Harness) ClientURL() string {
+	return h.clientURL
+}
+
+// WrapperURL returns the URL of the HTTP server of the Wrapper, which serves e.g. the readiness and status endpoints.
+func (h *Harness) WrapperURL() string {
+	return fmt.Sprintf("http://127.0.0.1:%d", h.Config.EtcdWrapperPort)
+}
+
+// Start creates the Wrapper from Config, after ConfigureWrapper has been applied, sets it up and starts it. It returns
+// once the embedded etcd is ready, or with an error if the Wrapper could not be set up or has failed before.
+func (h *Harness) Start() error {
+	if h.Wrapper != nil {
+		return errors.New("harness has already been started")
+	}
+	if h.options.ConfigureWrapper != nil {
+		h.options.ConfigureWrapper(&h.Config)
+	}
+	ctx, cancelFn := context.WithCancel(context.Background())
+	w, err := wrapper.New(ctx, h.Config, h.options.WaitReadyTimeout, h.options.Logger)
+	if err != nil {
+		cancelFn()
+		return err
+	}
+	h.Wrapper, h.cancelFn = w, cancelFn
+	if err = w.Setup(); err != nil {
+		return err
+	}
+	h.startErr = make(chan error, 1)
+	go func() {
+		h.startErr <- w.Start()
+	}()
+	return h.WaitForState(wrapper.StateReady, h.options.WaitReadyTimeout)
+}
+
+// WaitForState waits till the Wrapper is in the given state. It returns an error if the state has not been reached
+// within timeout, or if the Wrapper has stopped or failed before.
+func (h *Harness) WaitForState(s wrapper.State, timeout time.Duration) error {
+	if h.Wrapper == nil {
+		return errors.New("harness has not been started")
+	}
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		current := h.Wrapper.Status().State
+		if current == s {
+			return nil
+		}
+		if current == wrapper.StateFailed || current == wrapper.StateStopping {
+			return fmt.Errorf("wrapper is in state %s while waiting for state %s", current, s)
+		}
+		select {
+		case <-ticker.C:
+		case <-timer.C:
+			return fmt.Errorf("timed out after %s waiting for state %s, wrapper is in state %s", timeout, s, current)
+		}
+	}
+}
+
+// Stop stops the Wrapper and waits till it has stopped. It returns the error with which Start of the Wrapper has
+// returned, if any. Calling Stop on a Harness which has not been started, or more than once, is a no-op.
+func (h *Harness) Stop() error {
+	if h.Wrapper == nil || h.cancelFn == nil {
+		return nil
+	}
+	h.Wrapper.Stop()
+	h.cancelFn()
+	h.cancelFn = nil
+	if h.startErr == nil {
+		return nil
+	}
+	return <-h.startErr
+}
+
+// freePorts returns n distinct TCP ports which are free on the loopback interface.
+func freePorts(n int) ([]int, error) {
+	ports := make([]int, 0, n)
+	for range n {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			return nil, err
+		}
+		// keep the listeners open until all ports are picked to get distinct ports
+		defer func() {
+			_ = listener.Close()
+		}()
+		ports = append(ports, listener.Addr().(*net.TCPAddr).Port)
+	}
+	return ports, nil
+}
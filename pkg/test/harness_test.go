@@ -0,0 +1,125 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/pkg/wrapper"
+
+	. "github.com/onsi/gomega"
+	clientv3 "go.etcd.io/etcd/clientv3"
+)
+
+func TestHarness(t *testing.T) {
+	g := NewWithT(t)
+	h, err := NewHarness(t, Options{})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	t.Log("should start the wrapper till etcd is ready")
+	g.Expect(h.Start()).To(Succeed())
+	g.Expect(h.BackupRestore.TriggeredValidationModes()).To(HaveLen(1))
+	g.Eventually(func() bool { return h.Wrapper.Status().EtcdReady }).WithTimeout(10 * time.Second).Should(BeTrue())
+
+	t.Log("should serve client requests")
+	cli, err := clientv3.New(clientv3.Config{Endpoints: []string{h.ClientURL()}, DialTimeout: 5 * time.Second})
+	g.Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		_ = cli.Close()
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = cli.Put(ctx, "foo", "bar")
+	g.Expect(err).ToNot(HaveOccurred())
+
+	t.Log("should report readiness via the HTTP server of the wrapper")
+	g.Eventually(func() (int, error) {
+		response, err := http.Get(h.WrapperURL() + "/readyz")
+		if err != nil {
+			return 0, err
+		}
+		_ = response.Body.Close()
+		return response.StatusCode, nil
+	}).WithTimeout(10 * time.Second).Should(Equal(http.StatusOK))
+
+	t.Log("should stop the wrapper")
+	g.Expect(h.Stop()).To(Succeed())
+	g.Expect(h.Stop()).To(Succeed())
+}
+
+func TestHarnessSetupFailure(t *testing.T) {
+	g := NewWithT(t)
+	h, err := NewHarness(t, Options{
+		ConfigureWrapper: func(config *wrapper.Config) {
+			config.SkipRestoreVerification = true
+		},
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+	h.BackupRestore.SetEtcdConfig([]byte("unknown-field: true"))
+
+	g.Expect(h.Start()).To(MatchError(ContainSubstring("unknown-field")))
+	g.Expect(h.Config.SkipRestoreVerification).To(BeTrue())
+	g.Expect(h.BackupRestore.Requests("/config")).To(BeNumerically(">", 0))
+}
+
+func TestMockBackupRestore(t *testing.T) {
+	g := NewWithT(t)
+	m := NewMockBackupRestore(t)
+	get := func(path string) (int, string) {
+		response, err := http.Get("http://" + m.HostPort() + path)
+		g.Expect(err).ToNot(HaveOccurred())
+		defer func() {
+			_ = response.Body.Close()
+		}()
+		body := make([]byte, 512)
+		n, _ := response.Body.Read(body)
+		return response.StatusCode, string(body[:n])
+	}
+
+	t.Log("should report status New till initialization is triggered")
+	_, body := get("/initialization/status")
+	g.Expect(body).To(Equal(InitStatusNew))
+	code, _ := get("/initialization/start?mode=full")
+	g.Expect(code).To(Equal(http.StatusOK))
+	_, body = get("/initialization/status")
+	g.Expect(body).To(Equal(InitStatusSuccessful))
+	g.Expect(m.TriggeredValidationModes()).To(Equal([]string{"full"}))
+
+	t.Log("should return the configured initialization statuses")
+	m.SetInitStatuses(InitStatusInProgress, InitStatusSuccessful)
+	_, body = get("/initialization/status")
+	g.Expect(body).To(Equal(InitStatusInProgress))
+	_, body = get("/initialization/status")
+	g.Expect(body).To(Equal(InitStatusSuccessful))
+	_, body = get("/initialization/status")
+	g.Expect(body).To(Equal(InitStatusSuccessful))
+
+	t.Log("should serve no snapshots by default")
+	code, _ = get("/snapshot/latest")
+	g.Expect(code).To(Equal(http.StatusNotFound))
+	m.SetLatestSnapshots(&LatestSnapshots{FullSnapshot: &Snapshot{Kind: "Full", LastRevision: 10}})
+	code, body = get("/snapshot/latest")
+	g.Expect(code).To(Equal(http.StatusOK))
+	g.Expect(body).To(ContainSubstring(`"lastRevision":10`))
+
+	t.Log("should record triggered snapshots")
+	code, _ = get("/snapshot/full")
+	g.Expect(code).To(Equal(http.StatusOK))
+	g.Expect(m.TriggeredSnapshotKinds()).To(Equal([]string{"full"}))
+
+	t.Log("should fail requests as configured")
+	m.FailRequests("/config", http.StatusServiceUnavailable)
+	code, _ = get("/config")
+	g.Expect(code).To(Equal(http.StatusServiceUnavailable))
+	m.FailRequests("/config", 0)
+	m.SetEtcdConfig([]byte("name: etcd-test"))
+	code, body = get("/config")
+	g.Expect(code).To(Equal(http.StatusOK))
+	g.Expect(body).To(Equal("name: etcd-test"))
+	g.Expect(m.Requests("/config")).To(Equal(2))
+}
@@ -0,0 +1,129 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package wrapper
+
+import (
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/types"
+)
+
+// Config is the configuration of a Wrapper. Options of Config which are not set, and options of etcd-wrapper which are
+// not part of Config, keep the default of etcd-wrapper.
+type Config struct {
+	// BackupRestore is the configuration used to interact with the backup-restore sidecar.
+	BackupRestore BackupRestoreConfig
+	// EtcdClientTLS is the TLS configuration used to connect to the embedded etcd.
+	EtcdClientTLS EtcdClientTLSConfig
+	// EtcdClientAuth holds the credentials used to authenticate against the embedded etcd when auth is enabled.
+	EtcdClientAuth EtcdClientAuthConfig
+	// EtcdClientPort is the port on which the embedded etcd serves client requests.
+	EtcdClientPort int
+	// EtcdWrapperPort is the port of the HTTP server of the Wrapper.
+	EtcdWrapperPort int
+	// SkipRestoreVerification disables the verification of the etcd DB against the latest snapshot after initialization.
+	SkipRestoreVerification bool
+	// StateDir is the directory into which the files persisted by the Wrapper next to the data directory, e.g. the history
+	// of start attempts or the pinned cluster ID, are written. Defaults to /var/etcd/data if empty. Paths of Config which
+	// are set explicitly are kept.
+	StateDir string
+	// BootstrapHistoryPath is the file path of the history of start attempts. Defaults to the path used by etcd-wrapper if empty.
+	BootstrapHistoryPath string
+	// PhaseTimeouts are the timeouts of the bootstrap phases performed by Setup.
+	PhaseTimeouts PhaseTimeoutsConfig
+	// MaintenanceWindow confines disruptive operations initiated by the Wrapper to a recurring maintenance window.
+	MaintenanceWindow MaintenanceWindowConfig
+}
+
+// BackupRestoreConfig is the configuration used by a Wrapper to interact with the backup-restore sidecar.
+type BackupRestoreConfig struct {
+	// HostPort is the host and port of backup-restore, e.g. `localhost:8080`.
+	HostPort string
+	// TLSEnabled indicates whether backup-restore serves TLS.
+	TLSEnabled bool
+	// CaCertBundlePath is the file path of the CA certificate bundle to verify the certificate of backup-restore.
+	CaCertBundlePath string
+	// ServerName is the name expected in the TLS certificate of backup-restore. Defaults to the host of HostPort if empty.
+	ServerName string
+	// Protocol is the protocol used to communicate with backup-restore, either `http` or `grpc`. Defaults to `http` if empty.
+	Protocol string
+}
+
+// EtcdClientTLSConfig is the TLS configuration used by a Wrapper to connect to the embedded etcd.
+type EtcdClientTLSConfig struct {
+	// ServerName is the name expected in the certificate of the embedded etcd.
+	ServerName string
+	// CertPath is the file path of the client certificate.
+	CertPath string
+	// KeyPath is the file path of the client key.
+	KeyPath string
+	// KeyPassphrase is the passphrase of the client key if it is encrypted.
+	KeyPassphrase string
+}
+
+// EtcdClientAuthConfig holds the credentials used by a Wrapper to authenticate against the embedded etcd when auth is enabled.
+type EtcdClientAuthConfig struct {
+	// Username is the name of the etcd user. Password authentication is disabled if it is empty.
+	Username string
+	// Password is the password of the etcd user.
+	Password string
+}
+
+// PhaseTimeoutsConfig holds the timeouts of the bootstrap phases performed by Setup. A zero timeout means waiting forever.
+type PhaseTimeoutsConfig struct {
+	// SidecarProbe is the time to wait for backup-restore to respond with an initialization status.
+	SidecarProbe time.Duration
+	// Validation is the time to wait for the data directory validation to complete once it has been triggered.
+	Validation time.Duration
+	// RestorationWait is the time to wait for an initialization in progress, including restoration, to complete.
+	RestorationWait time.Duration
+}
+
+// MaintenanceWindowConfig holds the maintenance window to which disruptive operations initiated by a Wrapper are confined.
+type MaintenanceWindowConfig struct {
+	// Schedule is the cron expression, evaluated in UTC, at which the maintenance window opens. If it is empty,
+	// disruptive operations are never queued.
+	Schedule string
+	// Duration is the duration for which the maintenance window stays open. Defaults to one hour if zero.
+	Duration time.Duration
+}
+
+// toInternal converts the Config into the configuration of etcd-wrapper. It starts from the defaults of etcd-wrapper,
+// which are only overridden by the options of Config which are set.
+func (c Config) toInternal() types.Config {
+	config := types.NewDefaultConfig()
+	setIfNotZero(&config.BackupRestore.HostPort, c.BackupRestore.HostPort)
+	setIfNotZero(&config.BackupRestore.TLSEnabled, c.BackupRestore.TLSEnabled)
+	setIfNotZero(&config.BackupRestore.CaCertBundlePath, c.BackupRestore.CaCertBundlePath)
+	setIfNotZero(&config.BackupRestore.ServerName, c.BackupRestore.ServerName)
+	setIfNotZero(&config.BackupRestore.Protocol, c.BackupRestore.Protocol)
+	setIfNotZero(&config.EtcdClientTLS.ServerName, c.EtcdClientTLS.ServerName)
+	setIfNotZero(&config.EtcdClientTLS.CertPath, c.EtcdClientTLS.CertPath)
+	setIfNotZero(&config.EtcdClientTLS.KeyPath, c.EtcdClientTLS.KeyPath)
+	setIfNotZero(&config.EtcdClientTLS.KeyPassphrase, c.EtcdClientTLS.KeyPassphrase)
+	setIfNotZero(&config.EtcdClientAuth.Username, c.EtcdClientAuth.Username)
+	setIfNotZero(&config.EtcdClientAuth.Password, c.EtcdClientAuth.Password)
+	setIfNotZero(&config.EtcdClientPort, c.EtcdClientPort)
+	setIfNotZero(&config.EtcdWrapperPort, c.EtcdWrapperPort)
+	setIfNotZero(&config.SkipRestoreVerification, c.SkipRestoreVerification)
+	setIfNotZero(&config.BootstrapHistory.Path, c.BootstrapHistoryPath)
+	setIfNotZero(&config.PhaseTimeouts.SidecarProbe, c.PhaseTimeouts.SidecarProbe)
+	setIfNotZero(&config.PhaseTimeouts.Validation, c.PhaseTimeouts.Validation)
+	setIfNotZero(&config.PhaseTimeouts.RestorationWait, c.PhaseTimeouts.RestorationWait)
+	setIfNotZero(&config.MaintenanceWindow.Schedule, c.MaintenanceWindow.Schedule)
+	setIfNotZero(&config.MaintenanceWindow.Duration, c.MaintenanceWindow.Duration)
+	if c.StateDir != "" {
+		config.RedirectDefaultPaths(c.StateDir)
+	}
+	return config
+}
+
+// setIfNotZero sets the option at dst to value, unless value is the zero value which keeps the default at dst.
+func setIfNotZero[T comparable](dst *T, value T) {
+	var zero T
+	if value != zero {
+		*dst = value
+	}
+}
@@ -0,0 +1,90 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package wrapper
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/app"
+	"github.com/gardener/etcd-wrapper/internal/bootstrap"
+)
+
+// PhaseTimeoutError is returned by Setup and Start when a bootstrap phase has not completed within its timeout.
+type PhaseTimeoutError struct {
+	// Phase is the bootstrap phase which timed out, e.g. `sidecar-probe`, `validation`, `restoration-wait` or `etcd-ready`.
+	Phase string
+	// Timeout is the configured timeout of the phase.
+	Timeout time.Duration
+
+	err error
+}
+
+func (e *PhaseTimeoutError) Error() string {
+	return e.err.Error()
+}
+
+func (e *PhaseTimeoutError) Unwrap() error {
+	return e.err
+}
+
+// RestartBudgetExhaustedError is returned by Start when the embedded etcd would have to be restarted more often than
+// allowed by the restart budget.
+type RestartBudgetExhaustedError struct {
+	// MaxRestarts is the maximum number of restarts within Window.
+	MaxRestarts int
+	// Window is the window within which at most MaxRestarts restarts are allowed.
+	Window time.Duration
+
+	err error
+}
+
+func (e *RestartBudgetExhaustedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *RestartBudgetExhaustedError) Unwrap() error {
+	return e.err
+}
+
+// EtcdVersionSkewError is returned by Setup when the embedded etcd must not run on the data directory because it has
+// last been used by an incompatible version of etcd.
+type EtcdVersionSkewError struct {
+	// Recorded is the version of etcd which has last run on the data directory.
+	Recorded string
+	// Current is the version of the embedded etcd.
+	Current string
+	// Reason describes why the versions are incompatible.
+	Reason string
+
+	err error
+}
+
+func (e *EtcdVersionSkewError) Error() string {
+	return e.err.Error()
+}
+
+func (e *EtcdVersionSkewError) Unwrap() error {
+	return e.err
+}
+
+// convertError converts errors of etcd-wrapper which callers may want to handle into the error types of this package.
+// The original error is kept in the chain of the returned error. Other errors are returned unchanged.
+func convertError(err error) error {
+	var (
+		phaseTimeoutErr  *bootstrap.PhaseTimeoutError
+		restartBudgetErr *app.RestartBudgetExhaustedError
+		versionSkewErr   *app.EtcdVersionSkewError
+	)
+	switch {
+	case errors.As(err, &phaseTimeoutErr):
+		return &PhaseTimeoutError{Phase: string(phaseTimeoutErr.Phase), Timeout: phaseTimeoutErr.Timeout, err: err}
+	case errors.As(err, &restartBudgetErr):
+		return &RestartBudgetExhaustedError{MaxRestarts: restartBudgetErr.MaxRestarts, Window: restartBudgetErr.Window, err: err}
+	case errors.As(err, &versionSkewErr):
+		return &EtcdVersionSkewError{Recorded: versionSkewErr.Recorded, Current: versionSkewErr.Current, Reason: versionSkewErr.Reason, err: err}
+	}
+	return err
+}
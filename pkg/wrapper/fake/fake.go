@@ -0,0 +1,152 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package fake provides a fake implementation of wrapper.Interface, so that programs driving the lifecycle of
+// etcd-wrapper, e.g. controllers orchestrating restarts, can be tested without running an embedded etcd and
+// backup-restore.
+//
+// The fake walks through the same states as a wrapper.Wrapper: Setup transitions to StateProbingSidecar, Start to
+// StateStartingEtcd and StateReady, Restart back to StateStartingEtcd and StateReady, and Stop to StateStopping.
+// Failures are injected by setting the error fields before calling the respective method.
+package fake
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/pkg/wrapper"
+)
+
+// ErrEtcdNotRunning is returned by Restart if Start has not been called or the Wrapper has been stopped.
+var ErrEtcdNotRunning = errors.New("etcd is not running")
+
+// Wrapper is a fake implementation of wrapper.Interface. It is safe for concurrent use.
+type Wrapper struct {
+	// SetupErr is the error returned by Setup, which then transitions to StateFailed.
+	SetupErr error
+	// StartErr is the error returned by Start, which then transitions to StateFailed instead of StateReady.
+	StartErr error
+	// RestartErr is the error returned by Restart, which then does not restart.
+	RestartErr error
+
+	ctx      context.Context
+	cancelFn context.CancelFunc
+	mu       sync.Mutex
+	status   wrapper.Status
+	calls    []string
+}
+
+var _ wrapper.Interface = &Wrapper{}
+
+// New creates a fake Wrapper in StateNew. Like a wrapper.Wrapper, it is stopped once ctx is cancelled.
+func New(ctx context.Context) *Wrapper {
+	wrapperCtx, cancelFn := context.WithCancel(ctx)
+	return &Wrapper{
+		ctx:      wrapperCtx,
+		cancelFn: cancelFn,
+		status:   wrapper.Status{State: wrapper.StateNew, StateSince: time.Now()},
+	}
+}
+
+// Setup records the call and transitions to StateProbingSidecar, or returns SetupErr and transitions to StateFailed.
+func (w *Wrapper) Setup() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.calls = append(w.calls, "Setup")
+	if w.SetupErr != nil {
+		w.transitionTo(wrapper.StateFailed)
+		return w.SetupErr
+	}
+	w.transitionTo(wrapper.StateProbingSidecar)
+	return nil
+}
+
+// Start records the call, transitions to StateStartingEtcd and StateReady, and blocks until Stop is called or the
+// context passed to New is cancelled. If StartErr is set, it transitions to StateFailed and returns StartErr instead.
+func (w *Wrapper) Start() error {
+	w.mu.Lock()
+	w.calls = append(w.calls, "Start")
+	w.transitionTo(wrapper.StateStartingEtcd)
+	if w.StartErr != nil {
+		w.transitionTo(wrapper.StateFailed)
+		w.mu.Unlock()
+		return w.StartErr
+	}
+	w.status.EtcdRunning, w.status.EtcdReady = true, true
+	w.transitionTo(wrapper.StateReady)
+	w.mu.Unlock()
+
+	<-w.ctx.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.status.EtcdRunning, w.status.EtcdReady = false, false
+	if w.status.State != wrapper.StateStopping {
+		w.transitionTo(wrapper.StateStopping)
+	}
+	return nil
+}
+
+// Stop records the call, transitions to StateStopping and causes Start to return.
+func (w *Wrapper) Stop() {
+	w.mu.Lock()
+	w.calls = append(w.calls, "Stop")
+	w.transitionTo(wrapper.StateStopping)
+	w.mu.Unlock()
+	w.cancelFn()
+}
+
+// Restart records the call and, if etcd is running, counts a restart and transitions to StateStartingEtcd and
+// StateReady. It returns RestartErr if set, and ErrEtcdNotRunning if etcd is not running.
+func (w *Wrapper) Restart() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.calls = append(w.calls, "Restart")
+	if !w.status.EtcdRunning {
+		return ErrEtcdNotRunning
+	}
+	if w.RestartErr != nil {
+		return w.RestartErr
+	}
+	w.status.Restarts++
+	w.transitionTo(wrapper.StateStartingEtcd)
+	w.transitionTo(wrapper.StateReady)
+	return nil
+}
+
+// Status returns the current Status.
+func (w *Wrapper) Status() wrapper.Status {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	status := w.status
+	status.Transitions = slices.Clone(w.status.Transitions)
+	return status
+}
+
+// SetStatus replaces the Status returned by Status, e.g. to simulate a corruption alarm or a lost quorum. The
+// transitions are kept if status has none.
+func (w *Wrapper) SetStatus(status wrapper.Status) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if status.Transitions == nil {
+		status.Transitions = w.status.Transitions
+	}
+	w.status = status
+}
+
+// Calls returns the names of the methods of wrapper.Interface called so far, oldest first, e.g. "Setup" or "Restart".
+func (w *Wrapper) Calls() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return slices.Clone(w.calls)
+}
+
+// transitionTo records the transition to the state. w.mu must be held.
+func (w *Wrapper) transitionTo(to wrapper.State) {
+	now := time.Now()
+	w.status.Transitions = append(w.status.Transitions, wrapper.Transition{From: w.status.State, To: to, Timestamp: now})
+	w.status.State, w.status.StateSince = to, now
+}
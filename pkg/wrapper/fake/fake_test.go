@@ -0,0 +1,99 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package fake
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/pkg/wrapper"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestLifecycle(t *testing.T) {
+	g := NewWithT(t)
+	w := New(context.Background())
+	g.Expect(w.Status().State).To(Equal(wrapper.StateNew))
+
+	t.Log("should fail to restart before start")
+	g.Expect(w.Restart()).To(MatchError(ErrEtcdNotRunning))
+
+	t.Log("should be ready once started")
+	g.Expect(w.Setup()).To(Succeed())
+	started := make(chan error)
+	go func() { started <- w.Start() }()
+	g.Eventually(func() wrapper.State { return w.Status().State }).Should(Equal(wrapper.StateReady))
+	g.Expect(w.Status().EtcdRunning).To(BeTrue())
+
+	t.Log("should count restarts")
+	g.Expect(w.Restart()).To(Succeed())
+	g.Expect(w.Status().Restarts).To(Equal(1))
+	w.RestartErr = errors.New("restart refused")
+	g.Expect(w.Restart()).To(MatchError("restart refused"))
+	g.Expect(w.Status().Restarts).To(Equal(1))
+
+	t.Log("should return from start once stopped")
+	w.Stop()
+	g.Eventually(started).Should(Receive(BeNil()))
+	status := w.Status()
+	g.Expect(status.State).To(Equal(wrapper.StateStopping))
+	g.Expect(status.EtcdRunning).To(BeFalse())
+	g.Expect(w.Calls()).To(Equal([]string{"Restart", "Setup", "Start", "Restart", "Restart", "Stop"}))
+	var states []wrapper.State
+	for _, transition := range status.Transitions {
+		states = append(states, transition.To)
+	}
+	g.Expect(states).To(Equal([]wrapper.State{wrapper.StateProbingSidecar, wrapper.StateStartingEtcd, wrapper.StateReady, wrapper.StateStartingEtcd, wrapper.StateReady, wrapper.StateStopping}))
+}
+
+func TestFailures(t *testing.T) {
+	table := []struct {
+		description string
+		setupErr    error
+		startErr    error
+	}{
+		{"should fail setup", errors.New("sidecar unreachable"), nil},
+		{"should fail start", nil, errors.New("etcd not ready")},
+	}
+
+	for _, entry := range table {
+		t.Log(entry.description)
+		g := NewWithT(t)
+		w := New(context.Background())
+		w.SetupErr, w.StartErr = entry.setupErr, entry.startErr
+		err := w.Setup()
+		if err == nil {
+			err = w.Start()
+		}
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(w.Status().State).To(Equal(wrapper.StateFailed))
+	}
+}
+
+func TestStopOnContextCancellation(t *testing.T) {
+	g := NewWithT(t)
+	ctx, cancelFn := context.WithCancel(context.Background())
+	w := New(ctx)
+	g.Expect(w.Setup()).To(Succeed())
+	started := make(chan error)
+	go func() { started <- w.Start() }()
+	g.Eventually(func() wrapper.State { return w.Status().State }).Should(Equal(wrapper.StateReady))
+	cancelFn()
+	g.Eventually(started, time.Second).Should(Receive(BeNil()))
+	g.Expect(w.Status().State).To(Equal(wrapper.StateStopping))
+}
+
+func TestSetStatus(t *testing.T) {
+	g := NewWithT(t)
+	w := New(context.Background())
+	g.Expect(w.Setup()).To(Succeed())
+	w.SetStatus(wrapper.Status{State: wrapper.StateReady, CorruptionAlarm: true})
+	status := w.Status()
+	g.Expect(status.CorruptionAlarm).To(BeTrue())
+	g.Expect(status.Transitions).To(HaveLen(1))
+}
@@ -0,0 +1,119 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package wrapper
+
+import (
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/app"
+	"github.com/gardener/etcd-wrapper/internal/state"
+)
+
+// State is a state of a Wrapper during its lifecycle, as reported in the Status.
+type State string
+
+const (
+	// StateNew is the initial state of a Wrapper before Setup has been called.
+	StateNew = State(state.New)
+	// StateProbingSidecar indicates that the initialization status is fetched from backup-restore.
+	StateProbingSidecar = State(state.ProbingSidecar)
+	// StateValidating indicates that the validation of the data directory has been triggered on backup-restore.
+	StateValidating = State(state.Validating)
+	// StateRestoring indicates that backup-restore is initializing, and possibly restoring, the data directory.
+	StateRestoring = State(state.Restoring)
+	// StateStartingEtcd indicates that the data directory has been initialized and the embedded etcd is being started.
+	StateStartingEtcd = State(state.StartingEtcd)
+	// StateReady indicates that the embedded etcd is ready to serve client requests.
+	StateReady = State(state.Ready)
+	// StateStopping indicates that the Wrapper is stopping.
+	StateStopping = State(state.Stopping)
+	// StateFailed indicates that bootstrapping or running the embedded etcd has failed.
+	StateFailed = State(state.Failed)
+)
+
+// Transition is a change of the State of a Wrapper, as reported in the Status.
+type Transition struct {
+	// From is the state before the transition.
+	From State `json:"from"`
+	// To is the state after the transition.
+	To State `json:"to"`
+	// Timestamp is the time at which the transition happened.
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Status is the status of a Wrapper.
+type Status struct {
+	// State is the current state of the Wrapper.
+	State State `json:"state"`
+	// StateSince is the time since when the Wrapper is in State.
+	StateSince time.Time `json:"stateSince"`
+	// Transitions are all state transitions of the Wrapper, oldest first.
+	Transitions []Transition `json:"transitions,omitempty"`
+	// EtcdRunning indicates whether the embedded etcd has been started and not yet stopped.
+	EtcdRunning bool `json:"etcdRunning"`
+	// EtcdReady indicates whether the embedded etcd is ready to serve client requests.
+	EtcdReady bool `json:"etcdReady"`
+	// Restarts is the number of times the embedded etcd has been restarted.
+	Restarts int `json:"restarts"`
+	// CorruptionAlarm indicates whether etcd reports an active corruption alarm.
+	CorruptionAlarm bool `json:"corruptionAlarm"`
+	// Learner indicates whether the member is a raft learner.
+	Learner bool `json:"learner"`
+	// Membership is the membership of the etcd cluster as seen by the embedded etcd. It is nil if etcd is not running.
+	Membership *Membership `json:"membership,omitempty"`
+}
+
+// Membership is the membership of the etcd cluster as seen by the embedded etcd, as reported in the Status.
+type Membership struct {
+	// ClusterID is the ID of the etcd cluster.
+	ClusterID string `json:"clusterID"`
+	// LocalID is the ID of the embedded etcd.
+	LocalID string `json:"localID"`
+	// LeaderID is the ID of the leader as known by the embedded etcd. It is empty if there is no leader.
+	LeaderID string `json:"leaderID,omitempty"`
+	// Members are all members of the etcd cluster, sorted by their ID.
+	Members []Member `json:"members"`
+}
+
+// Member is a member of the etcd cluster, as reported in the Membership.
+type Member struct {
+	// ID is the ID of the member.
+	ID string `json:"id"`
+	// Name is the name of the member. It is empty if the member has not been started yet.
+	Name string `json:"name,omitempty"`
+	// PeerURLs are the URLs on which the member serves peer traffic.
+	PeerURLs []string `json:"peerURLs,omitempty"`
+	// ClientURLs are the URLs on which the member serves client traffic. They are empty if the member has not been started yet.
+	ClientURLs []string `json:"clientURLs,omitempty"`
+	// IsLearner indicates whether the member is a raft learner rather than a voting member.
+	IsLearner bool `json:"isLearner"`
+}
+
+// convertStatus converts the status of etcd-wrapper into a Status.
+func convertStatus(status app.Status) Status {
+	converted := Status{
+		State:           State(status.State),
+		StateSince:      status.StateSince,
+		EtcdRunning:     status.EtcdRunning,
+		EtcdReady:       status.EtcdReady,
+		Restarts:        status.Restarts,
+		CorruptionAlarm: status.CorruptionAlarm,
+		Learner:         status.Learner,
+	}
+	for _, transition := range status.Transitions {
+		converted.Transitions = append(converted.Transitions, Transition{From: State(transition.From), To: State(transition.To), Timestamp: transition.Timestamp})
+	}
+	if status.Membership != nil {
+		converted.Membership = &Membership{
+			ClusterID: status.Membership.ClusterID,
+			LocalID:   status.Membership.LocalID,
+			LeaderID:  status.Membership.LeaderID,
+		}
+		for _, member := range status.Membership.Members {
+			converted.Membership.Members = append(converted.Membership.Members, Member(member))
+		}
+	}
+	return converted
+}
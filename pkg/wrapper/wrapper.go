@@ -0,0 +1,79 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package wrapper allows other Go programs (e.g. operators or tests) to embed etcd-wrapper programmatically.
+//
+// A Wrapper is created via New, after which Setup coordinates the initialization of the etcd data directory with the
+// backup-restore sidecar and Start starts the embedded etcd. Start blocks until the Wrapper is stopped, either by
+// calling Stop, by cancelling the context passed to New, or by the embedded etcd stopping on its own.
+package wrapper
+
+import (
+	"context"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/app"
+
+	"go.uber.org/zap"
+)
+
+// Interface is the lifecycle of an embedded etcd as driven by programs embedding etcd-wrapper. It is implemented by
+// Wrapper and, for tests of such programs, by the fake in package fake.
+type Interface interface {
+	// Setup initializes the etcd data directory and must be called before Start.
+	Setup() error
+	// Start starts the embedded etcd and blocks until it is stopped.
+	Start() error
+	// Stop stops the embedded etcd and causes Start to return.
+	Stop()
+	// Restart stops the embedded etcd and starts it again without returning from Start.
+	Restart() error
+	// Status returns the current Status.
+	Status() Status
+}
+
+var _ Interface = &Wrapper{}
+
+// Wrapper manages the lifecycle of an embedded etcd.
+type Wrapper struct {
+	app *app.Application
+}
+
+// New creates a Wrapper. The Wrapper is stopped once ctx is cancelled. waitReadyTimeout is the time to wait for the
+// embedded etcd to be ready to serve client requests once it has been started, a zero value waits forever.
+func New(ctx context.Context, config Config, waitReadyTimeout time.Duration, logger *zap.Logger) (*Wrapper, error) {
+	wrapperCtx, cancelFn := context.WithCancel(ctx)
+	a, err := app.NewApplication(wrapperCtx, cancelFn, config.toInternal(), waitReadyTimeout, logger)
+	if err != nil {
+		cancelFn()
+		return nil, err
+	}
+	return &Wrapper{app: a}, nil
+}
+
+// Setup initializes the etcd data directory by coordinating with the backup-restore sidecar and fetches the etcd configuration.
+// It must be called before Start.
+func (w *Wrapper) Setup() error {
+	return convertError(w.app.Setup())
+}
+
+// Start starts the embedded etcd along with the HTTP server of the Wrapper and blocks until the Wrapper is stopped.
+func (w *Wrapper) Start() error {
+	return convertError(w.app.Start())
+}
+
+// Stop stops the embedded etcd and causes Start to return.
+func (w *Wrapper) Stop() {
+	w.app.Stop()
+}
+
+// Restart stops the embedded etcd and starts it again without returning from Start.
+func (w *Wrapper) Restart() error {
+	return w.app.Restart()
+}
+
+// Status returns the current Status of the Wrapper.
+func (w *Wrapper) Status() Status {
+	return convertStatus(w.app.Status())
+}
@@ -0,0 +1,104 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Gardener contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package wrapper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gardener/etcd-wrapper/internal/app"
+	"github.com/gardener/etcd-wrapper/internal/bootstrap"
+	"github.com/gardener/etcd-wrapper/internal/state"
+	"github.com/gardener/etcd-wrapper/internal/types"
+
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestNew(t *testing.T) {
+	table := []struct {
+		description string
+		config      Config
+		expectError bool
+	}{
+		{"should return error when backup-restore config is invalid", Config{BackupRestore: BackupRestoreConfig{HostPort: "localhost"}}, true},
+		{"should create wrapper when config is valid", Config{BackupRestore: BackupRestoreConfig{HostPort: ":8080"}}, false},
+	}
+
+	for _, entry := range table {
+		t.Run(entry.description, func(t *testing.T) {
+			g := NewWithT(t)
+			w, err := New(context.Background(), entry.config, time.Minute, zaptest.NewLogger(t))
+			g.Expect(err != nil).To(Equal(entry.expectError))
+			if err == nil {
+				g.Expect(w.Status().State).To(Equal(StateNew))
+				g.Expect(w.Status().EtcdRunning).To(BeFalse())
+			}
+		})
+	}
+}
+
+func TestConfigToInternal(t *testing.T) {
+	g := NewWithT(t)
+
+	t.Log("should keep the defaults of etcd-wrapper if no option is set")
+	g.Expect(Config{}.toInternal()).To(Equal(types.NewDefaultConfig()))
+
+	t.Log("should override the defaults of etcd-wrapper with the options which are set")
+	internal := Config{EtcdWrapperPort: 9096, BackupRestore: BackupRestoreConfig{HostPort: "etcd-main-local:8080"}, SkipRestoreVerification: true}.toInternal()
+	expected := types.NewDefaultConfig()
+	expected.EtcdWrapperPort = 9096
+	expected.BackupRestore.HostPort = "etcd-main-local:8080"
+	expected.SkipRestoreVerification = true
+	g.Expect(internal).To(Equal(expected))
+}
+
+func TestRestartBeforeStart(t *testing.T) {
+	g := NewWithT(t)
+	w, err := New(context.Background(), Config{BackupRestore: BackupRestoreConfig{HostPort: ":8080"}}, time.Minute, zaptest.NewLogger(t))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(w.Restart()).ToNot(Succeed())
+}
+
+func TestConvertStatus(t *testing.T) {
+	g := NewWithT(t)
+	now := time.Now()
+	status := convertStatus(app.Status{
+		State:       state.Ready,
+		StateSince:  now,
+		Transitions: []state.Transition{{From: state.StartingEtcd, To: state.Ready, Timestamp: now}},
+		EtcdRunning: true,
+		EtcdReady:   true,
+		Restarts:    2,
+		Membership:  &app.Membership{ClusterID: "c", LocalID: "1", LeaderID: "1", Members: []app.Member{{ID: "1", Name: "etcd-0", IsLearner: true}}},
+	})
+	g.Expect(status).To(Equal(Status{
+		State:       StateReady,
+		StateSince:  now,
+		Transitions: []Transition{{From: StateStartingEtcd, To: StateReady, Timestamp: now}},
+		EtcdRunning: true,
+		EtcdReady:   true,
+		Restarts:    2,
+		Membership:  &Membership{ClusterID: "c", LocalID: "1", LeaderID: "1", Members: []Member{{ID: "1", Name: "etcd-0", IsLearner: true}}},
+	}))
+}
+
+func TestConvertError(t *testing.T) {
+	g := NewWithT(t)
+	phaseTimeoutErr := &bootstrap.PhaseTimeoutError{Phase: bootstrap.PhaseValidation, Timeout: time.Minute}
+	err := convertError(fmt.Errorf("setup failed: %w", phaseTimeoutErr))
+	var converted *PhaseTimeoutError
+	g.Expect(errors.As(err, &converted)).To(BeTrue())
+	g.Expect(converted.Phase).To(Equal("validation"))
+	g.Expect(converted.Timeout).To(Equal(time.Minute))
+	g.Expect(errors.Is(err, phaseTimeoutErr)).To(BeTrue())
+
+	otherErr := errors.New("other")
+	g.Expect(convertError(otherErr)).To(Equal(otherErr))
+	g.Expect(convertError(nil)).To(BeNil())
+}